@@ -0,0 +1,312 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	affinityRuleTypeNode    = "nodeAffinity"
+	affinityRuleTypePod     = "podAffinity"
+	affinityRuleTypePodAnti = "podAntiAffinity"
+
+	// affinityViolationThreshold is the percentage of a pod's preferred
+	// weight below which its placement is a violation.
+	affinityViolationThreshold = 50
+)
+
+type affinityRuleResult struct {
+	AffinityRule string `json:"affinityRule"`
+	Type         string `json:"type"`
+	Weight       int32  `json:"weight"`
+	Satisfied    bool   `json:"satisfied"`
+}
+
+type affinityPlacement struct {
+	Pod              string               `json:"pod"`
+	Node             string               `json:"node"`
+	Rules            []affinityRuleResult `json:"rules"`
+	SatisfiedScore   int32                `json:"satisfiedScore"`
+	MaxScore         int32                `json:"maxScore"`
+	PercentSatisfied int32                `json:"percentSatisfied"`
+	Violation        bool                 `json:"violation"`
+}
+
+type affinityViolationsResponse struct {
+	Violations int                 `json:"violations"`
+	Pods       []affinityPlacement `json:"pods"`
+}
+
+type affinityViolationsHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*affinityViolationsHandler)(nil)
+
+func newAffinityViolationsHandler(kubeClient kubernetes.Interface, logger log.Logger) *affinityViolationsHandler {
+	return &affinityViolationsHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and scores how well the placement of
+// each scheduled pod in a namespace satisfies its preferred node affinity,
+// pod affinity and pod anti-affinity rules. A rule's weight counts towards
+// the satisfied score when the pod's node matches the preference, when a
+// matching pod runs in the same topology domain, or for anti-affinity when
+// none does. Pods satisfying less than half of their total weight are
+// violations.
+func (h *affinityViolationsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	nodeList, err := h.kubeClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	nodes := make(map[string]*corev1.Node)
+	for i := range nodeList.Items {
+		nodes[nodeList.Items[i].Name] = &nodeList.Items[i]
+	}
+
+	podsByNamespace := map[string][]corev1.Pod{
+		namespace: pods.Items,
+	}
+	listPods := func(namespace string) ([]corev1.Pod, error) {
+		if found, ok := podsByNamespace[namespace]; ok {
+			return found, nil
+		}
+
+		list, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "list pods")
+		}
+		podsByNamespace[namespace] = list.Items
+
+		return list.Items, nil
+	}
+
+	resp := affinityViolationsResponse{
+		Pods: []affinityPlacement{},
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		node, ok := nodes[pod.Spec.NodeName]
+		if !ok || !podIsActive(pod) || pod.Spec.Affinity == nil {
+			continue
+		}
+
+		placement, err := scoreAffinityPlacement(pod, node, nodes, listPods)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		if placement.MaxScore == 0 {
+			continue
+		}
+
+		if placement.Violation {
+			resp.Violations++
+		}
+		resp.Pods = append(resp.Pods, placement)
+	}
+
+	sort.Slice(resp.Pods, func(i, j int) bool {
+		return resp.Pods[i].Pod < resp.Pods[j].Pod
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// scoreAffinityPlacement evaluates a pod's preferred scheduling rules
+// against the node it runs on and the pods around it.
+func scoreAffinityPlacement(pod *corev1.Pod, node *corev1.Node, nodes map[string]*corev1.Node, listPods func(namespace string) ([]corev1.Pod, error)) (affinityPlacement, error) {
+	placement := affinityPlacement{
+		Pod:   pod.Name,
+		Node:  node.Name,
+		Rules: []affinityRuleResult{},
+	}
+
+	add := func(rule affinityRuleResult) {
+		placement.MaxScore += rule.Weight
+		if rule.Satisfied {
+			placement.SatisfiedScore += rule.Weight
+		}
+		placement.Rules = append(placement.Rules, rule)
+	}
+
+	affinity := pod.Spec.Affinity
+
+	if affinity.NodeAffinity != nil {
+		for _, term := range affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			add(affinityRuleResult{
+				AffinityRule: describeNodeSelectorTerm(term.Preference),
+				Type:         affinityRuleTypeNode,
+				Weight:       term.Weight,
+				Satisfied:    matchesNodeSelectorTerms([]corev1.NodeSelectorTerm{term.Preference}, node),
+			})
+		}
+	}
+
+	var podTerms []corev1.WeightedPodAffinityTerm
+	var antiTerms []corev1.WeightedPodAffinityTerm
+	if affinity.PodAffinity != nil {
+		podTerms = affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	}
+	if affinity.PodAntiAffinity != nil {
+		antiTerms = affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	}
+
+	for _, terms := range []struct {
+		ruleType string
+		terms    []corev1.WeightedPodAffinityTerm
+	}{
+		{ruleType: affinityRuleTypePod, terms: podTerms},
+		{ruleType: affinityRuleTypePodAnti, terms: antiTerms},
+	} {
+		for _, term := range terms.terms {
+			colocated, err := podAffinityTermColocated(pod, node, term.PodAffinityTerm, nodes, listPods)
+			if err != nil {
+				return affinityPlacement{}, err
+			}
+
+			add(affinityRuleResult{
+				AffinityRule: describePodAffinityTerm(term.PodAffinityTerm),
+				Type:         terms.ruleType,
+				Weight:       term.Weight,
+				Satisfied:    colocated == (terms.ruleType == affinityRuleTypePod),
+			})
+		}
+	}
+
+	if placement.MaxScore > 0 {
+		placement.PercentSatisfied = placement.SatisfiedScore * 100 / placement.MaxScore
+		placement.Violation = placement.PercentSatisfied < affinityViolationThreshold
+	}
+
+	return placement, nil
+}
+
+// podAffinityTermColocated reports whether another active pod matching the
+// term runs in the same topology domain as the pod. Terms without namespaces
+// match pods in the pod's namespace.
+func podAffinityTermColocated(pod *corev1.Pod, node *corev1.Node, term corev1.PodAffinityTerm, nodes map[string]*corev1.Node, listPods func(namespace string) ([]corev1.Pod, error)) (bool, error) {
+	domain, ok := node.Labels[term.TopologyKey]
+	if !ok {
+		return false, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+	if err != nil {
+		return false, errors.Wrapf(err, "pod %s has an invalid affinity label selector", pod.Name)
+	}
+
+	namespaces := term.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{pod.Namespace}
+	}
+
+	for _, namespace := range namespaces {
+		candidates, err := listPods(namespace)
+		if err != nil {
+			return false, err
+		}
+
+		for i := range candidates {
+			candidate := &candidates[i]
+			if candidate.Namespace == pod.Namespace && candidate.Name == pod.Name {
+				continue
+			}
+			if !podIsActive(candidate) || !selector.Matches(labels.Set(candidate.Labels)) {
+				continue
+			}
+
+			candidateNode, ok := nodes[candidate.Spec.NodeName]
+			if !ok {
+				continue
+			}
+
+			if value, ok := candidateNode.Labels[term.TopologyKey]; ok && value == domain {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// podIsActive reports whether a pod is scheduled and has not finished.
+func podIsActive(pod *corev1.Pod) bool {
+	if pod.Spec.NodeName == "" || pod.DeletionTimestamp != nil {
+		return false
+	}
+
+	return pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
+
+// describeNodeSelectorTerm formats a term's requirements like a label
+// selector, e.g. "topology.kubernetes.io/zone in (us-east-1a)".
+func describeNodeSelectorTerm(term corev1.NodeSelectorTerm) string {
+	var parts []string
+	for _, requirement := range term.MatchExpressions {
+		parts = append(parts, describeNodeSelectorRequirement(requirement))
+	}
+	for _, requirement := range term.MatchFields {
+		parts = append(parts, describeNodeSelectorRequirement(requirement))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func describeNodeSelectorRequirement(requirement corev1.NodeSelectorRequirement) string {
+	switch requirement.Operator {
+	case corev1.NodeSelectorOpExists:
+		return requirement.Key
+	case corev1.NodeSelectorOpDoesNotExist:
+		return "!" + requirement.Key
+	case corev1.NodeSelectorOpGt:
+		return fmt.Sprintf("%s > %s", requirement.Key, strings.Join(requirement.Values, ","))
+	case corev1.NodeSelectorOpLt:
+		return fmt.Sprintf("%s < %s", requirement.Key, strings.Join(requirement.Values, ","))
+	default:
+		return fmt.Sprintf("%s %s (%s)", requirement.Key, strings.ToLower(string(requirement.Operator)), strings.Join(requirement.Values, ","))
+	}
+}
+
+// describePodAffinityTerm formats a pod affinity term, e.g.
+// "app=cache in kubernetes.io/hostname".
+func describePodAffinityTerm(term corev1.PodAffinityTerm) string {
+	description := fmt.Sprintf("%s in %s", metav1.FormatLabelSelector(term.LabelSelector), term.TopologyKey)
+	if len(term.Namespaces) > 0 {
+		description += fmt.Sprintf(" (namespaces %s)", strings.Join(term.Namespaces, ","))
+	}
+
+	return description
+}
@@ -0,0 +1,170 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_affinityViolationsHandler(t *testing.T) {
+	const (
+		hostnameKey = "kubernetes.io/hostname"
+		zoneKey     = "topology.kubernetes.io/zone"
+	)
+
+	node := func(name, zone string, extra map[string]string) *corev1.Node {
+		labels := map[string]string{hostnameKey: name, zoneKey: zone}
+		for k, v := range extra {
+			labels[k] = v
+		}
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	pod := func(namespace, name, app, nodeName string, affinity *corev1.Affinity) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": app}},
+			Spec:       corev1.PodSpec{NodeName: nodeName, Affinity: affinity},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	podTerm := func(weight int32, app, topologyKey string, namespaces ...string) corev1.WeightedPodAffinityTerm {
+		return corev1.WeightedPodAffinityTerm{
+			Weight: weight,
+			PodAffinityTerm: corev1.PodAffinityTerm{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+				Namespaces:    namespaces,
+				TopologyKey:   topologyKey,
+			},
+		}
+	}
+
+	webAffinity := &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{
+				{
+					Weight: 80,
+					Preference: corev1.NodeSelectorTerm{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: zoneKey, Operator: corev1.NodeSelectorOpIn, Values: []string{"b"}},
+						},
+					},
+				},
+			},
+		},
+		PodAffinity: &corev1.PodAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				podTerm(20, "cache", hostnameKey),
+			},
+		},
+	}
+
+	spreadAffinity := &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				podTerm(100, "web", hostnameKey),
+			},
+		},
+	}
+
+	apiAffinity := &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{
+				{
+					Weight: 50,
+					Preference: corev1.NodeSelectorTerm{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: "disktype", Operator: corev1.NodeSelectorOpExists},
+						},
+					},
+				},
+			},
+		},
+		PodAffinity: &corev1.PodAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				podTerm(50, "db", zoneKey, "backend"),
+			},
+		},
+	}
+
+	pending := pod("default", "web-3", "web", "", spreadAffinity)
+	pending.Status.Phase = corev1.PodPending
+
+	kubeClient := kubefake.NewSimpleClientset(
+		node("node-1", "a", map[string]string{"disktype": "ssd"}),
+		node("node-2", "b", nil),
+		pod("default", "web-1", "web", "node-1", webAffinity),
+		pod("default", "web-2", "web", "node-2", spreadAffinity),
+		pod("default", "cache-1", "cache", "node-1", nil),
+		pod("default", "api-1", "api", "node-1", apiAffinity),
+		pending,
+		pod("backend", "db-1", "db", "node-2", nil),
+	)
+
+	handler := newAffinityViolationsHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/podaffinity-violations", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var got affinityViolationsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+
+	expected := affinityViolationsResponse{
+		Violations: 1,
+		Pods: []affinityPlacement{
+			{
+				Pod:  "api-1",
+				Node: "node-1",
+				Rules: []affinityRuleResult{
+					{AffinityRule: "disktype", Type: "nodeAffinity", Weight: 50, Satisfied: true},
+					{AffinityRule: "app=db in topology.kubernetes.io/zone (namespaces backend)", Type: "podAffinity", Weight: 50},
+				},
+				SatisfiedScore:   50,
+				MaxScore:         100,
+				PercentSatisfied: 50,
+			},
+			{
+				Pod:  "web-1",
+				Node: "node-1",
+				Rules: []affinityRuleResult{
+					{AffinityRule: "topology.kubernetes.io/zone in (b)", Type: "nodeAffinity", Weight: 80},
+					{AffinityRule: "app=cache in kubernetes.io/hostname", Type: "podAffinity", Weight: 20, Satisfied: true},
+				},
+				SatisfiedScore:   20,
+				MaxScore:         100,
+				PercentSatisfied: 20,
+				Violation:        true,
+			},
+			{
+				Pod:  "web-2",
+				Node: "node-2",
+				Rules: []affinityRuleResult{
+					{AffinityRule: "app=web in kubernetes.io/hostname", Type: "podAntiAffinity", Weight: 100, Satisfied: true},
+				},
+				SatisfiedScore:   100,
+				MaxScore:         100,
+				PercentSatisfied: 100,
+			},
+		},
+	}
+	assert.Equal(t, expected, got)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	alertsCacheTTL = 15 * time.Second
+	alertsCacheKey = "alerts"
+)
+
+type alertmanagerAlertStatus struct {
+	State string `json:"state"`
+}
+
+type alertmanagerAlert struct {
+	Labels      map[string]string       `json:"labels"`
+	Annotations map[string]string       `json:"annotations,omitempty"`
+	StartsAt    time.Time               `json:"startsAt"`
+	EndsAt      time.Time               `json:"endsAt"`
+	Fingerprint string                  `json:"fingerprint"`
+	Status      alertmanagerAlertStatus `json:"status"`
+}
+
+type alertGroup struct {
+	Alertname string              `json:"alertname"`
+	Severity  string              `json:"severity"`
+	Count     int                 `json:"count"`
+	Alerts    []alertmanagerAlert `json:"alerts"`
+}
+
+type alertsResponse struct {
+	Groups []alertGroup `json:"groups"`
+}
+
+type alertsHandler struct {
+	alertmanagerURL string
+	httpClient      *http.Client
+	cache           *ttlCache
+	logger          log.Logger
+}
+
+var _ http.Handler = (*alertsHandler)(nil)
+
+func newAlertsHandler(alertmanagerURL string, logger log.Logger) *alertsHandler {
+	return &alertsHandler{
+		alertmanagerURL: strings.TrimSuffix(alertmanagerURL, "/"),
+		httpClient:      &http.Client{Timeout: 10 * time.Second},
+		cache:           newTTLCache(alertsCacheTTL),
+		logger:          logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns active Alertmanager alerts
+// grouped by alert name and severity. An optional `namespace` query parameter
+// limits alerts to those with a matching namespace label.
+func (h *alertsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp := alertsResponse{
+		Groups: []alertGroup{},
+	}
+
+	if h.alertmanagerURL == "" {
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	alerts, err := h.activeAlerts()
+	if err != nil {
+		RespondWithError(w, http.StatusBadGateway, err.Error(), h.logger)
+		return
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+	resp.Groups = groupAlerts(alerts, namespace)
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func (h *alertsHandler) activeAlerts() ([]alertmanagerAlert, error) {
+	if cached, ok := h.cache.get(alertsCacheKey); ok {
+		return cached.([]alertmanagerAlert), nil
+	}
+
+	res, err := h.httpClient.Get(fmt.Sprintf("%s/api/v2/alerts?active=true", h.alertmanagerURL))
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch alerts from alertmanager")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("alertmanager returned %s", res.Status)
+	}
+
+	var alerts []alertmanagerAlert
+	if err := json.NewDecoder(res.Body).Decode(&alerts); err != nil {
+		return nil, errors.Wrap(err, "decode alertmanager alerts")
+	}
+
+	h.cache.set(alertsCacheKey, alerts)
+
+	return alerts, nil
+}
+
+func groupAlerts(alerts []alertmanagerAlert, namespace string) []alertGroup {
+	type groupKey struct {
+		alertname string
+		severity  string
+	}
+
+	groups := make(map[groupKey]*alertGroup)
+	for _, alert := range alerts {
+		if namespace != "" && alert.Labels["namespace"] != namespace {
+			continue
+		}
+
+		key := groupKey{alertname: alert.Labels["alertname"], severity: alert.Labels["severity"]}
+		group, ok := groups[key]
+		if !ok {
+			group = &alertGroup{Alertname: key.alertname, Severity: key.severity}
+			groups[key] = group
+		}
+
+		group.Alerts = append(group.Alerts, alert)
+		group.Count++
+	}
+
+	list := []alertGroup{}
+	for _, group := range groups {
+		list = append(list, *group)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Alertname != list[j].Alertname {
+			return list[i].Alertname < list[j].Alertname
+		}
+		return list[i].Severity < list[j].Severity
+	})
+
+	return list
+}
@@ -0,0 +1,93 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_alertsHandler(t *testing.T) {
+	alertmanager := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v2/alerts", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("active"))
+		fmt.Fprint(w, `[
+			{"labels":{"alertname":"PodCrashLooping","severity":"critical","namespace":"default"},"fingerprint":"1"},
+			{"labels":{"alertname":"PodCrashLooping","severity":"critical","namespace":"other"},"fingerprint":"2"},
+			{"labels":{"alertname":"HighLatency","severity":"warning","namespace":"default"},"fingerprint":"3"}
+		]`)
+	}))
+	defer alertmanager.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		query    string
+		expected map[string]int
+	}{
+		{
+			name:     "all namespaces",
+			url:      alertmanager.URL,
+			expected: map[string]int{"HighLatency/warning": 1, "PodCrashLooping/critical": 2},
+		},
+		{
+			name:     "filtered by namespace",
+			url:      alertmanager.URL,
+			query:    "?namespace=other",
+			expected: map[string]int{"PodCrashLooping/critical": 1},
+		},
+		{
+			name:     "alertmanager not configured",
+			expected: map[string]int{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newAlertsHandler(tc.url, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/alerts"+tc.query, nil)
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got alertsResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+			counts := make(map[string]int)
+			for _, group := range got.Groups {
+				counts[group.Alertname+"/"+group.Severity] = group.Count
+			}
+			assert.Equal(t, tc.expected, counts)
+		})
+	}
+}
+
+func Test_alertsHandler_cache(t *testing.T) {
+	requests := 0
+	alertmanager := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `[]`)
+	}))
+	defer alertmanager.Close()
+
+	handler := newAlertsHandler(alertmanager.URL, log.NopLogger())
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/alerts", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,303 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	anomalyHighRestartRate         = "HighRestartRate"
+	anomalyServiceWithoutEndpoints = "ServiceWithoutEndpoints"
+	anomalyDeploymentUnavailable   = "DeploymentUnavailable"
+	anomalyLargeConfigMap          = "LargeConfigMap"
+	anomalyStaleSecret             = "StaleSecret"
+
+	// maxRestartsPerHour is the average restart rate above which a pod is
+	// flagged.
+	maxRestartsPerHour = 10
+
+	// maxConfigMapSize is the size in bytes above which a ConfigMap is flagged.
+	maxConfigMapSize = 1000 * 1000
+
+	// staleSecretAge is the age after which an unbound secret is flagged.
+	staleSecretAge = 365 * 24 * time.Hour
+)
+
+type anomalyFinding struct {
+	Check    string          `json:"check"`
+	Resource workloadRef     `json:"resource"`
+	Severity findingSeverity `json:"severity"`
+	Message  string          `json:"message"`
+}
+
+type anomaliesResponse struct {
+	Findings []anomalyFinding `json:"findings"`
+}
+
+type anomalyHandler struct {
+	kubeClient kubernetes.Interface
+	nowFn      func() time.Time
+	logger     log.Logger
+}
+
+var _ http.Handler = (*anomalyHandler)(nil)
+
+func newAnomalyHandler(kubeClient kubernetes.Interface, logger log.Logger) *anomalyHandler {
+	return &anomalyHandler{
+		kubeClient: kubeClient,
+		nowFn:      time.Now,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and runs heuristic checks for unusual
+// resources in a namespace: pods restarting more than ten times an hour,
+// services without endpoints, deployments with no available replicas,
+// ConfigMaps over 1 MB and year old secrets no service account uses.
+func (h *anomalyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	services, err := h.kubeClient.CoreV1().Services(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	endpoints, err := h.kubeClient.CoreV1().Endpoints(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	deployments, err := h.kubeClient.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	configMaps, err := h.kubeClient.CoreV1().ConfigMaps(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	secrets, err := h.kubeClient.CoreV1().Secrets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	serviceAccounts, err := h.kubeClient.CoreV1().ServiceAccounts(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	now := h.nowFn()
+
+	resp := anomaliesResponse{
+		Findings: []anomalyFinding{},
+	}
+
+	resp.Findings = append(resp.Findings, restartRateAnomalies(pods.Items, now)...)
+	resp.Findings = append(resp.Findings, serviceEndpointAnomalies(services.Items, endpoints.Items)...)
+	resp.Findings = append(resp.Findings, deploymentAvailabilityAnomalies(deployments.Items)...)
+	resp.Findings = append(resp.Findings, configMapSizeAnomalies(configMaps.Items)...)
+	resp.Findings = append(resp.Findings, staleSecretAnomalies(secrets.Items, serviceAccounts.Items, now)...)
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// restartRateAnomalies flags pods whose containers have restarted more than
+// maxRestartsPerHour times in the last hour. Kubernetes only records a total,
+// so the rate is averaged over the pod's lifetime, or an hour for younger
+// pods.
+func restartRateAnomalies(pods []corev1.Pod, now time.Time) []anomalyFinding {
+	var findings []anomalyFinding
+
+	for _, pod := range pods {
+		var restarts int32
+		for _, status := range pod.Status.ContainerStatuses {
+			restarts += status.RestartCount
+		}
+
+		if restarts <= maxRestartsPerHour {
+			continue
+		}
+
+		started := pod.CreationTimestamp.Time
+		if pod.Status.StartTime != nil {
+			started = pod.Status.StartTime.Time
+		}
+
+		hours := now.Sub(started).Hours()
+		if hours < 1 {
+			hours = 1
+		}
+
+		rate := float64(restarts) / hours
+		if rate <= maxRestartsPerHour {
+			continue
+		}
+
+		findings = append(findings, anomalyFinding{
+			Check:    anomalyHighRestartRate,
+			Resource: workloadRef{Kind: "Pod", Name: pod.Name},
+			Severity: severityHigh,
+			Message:  fmt.Sprintf("containers restarted %d times, about %.0f an hour", restarts, rate),
+		})
+	}
+
+	return findings
+}
+
+// serviceEndpointAnomalies flags services which have no ready or unready
+// endpoint addresses. ExternalName services have no endpoints and are skipped.
+func serviceEndpointAnomalies(services []corev1.Service, endpoints []corev1.Endpoints) []anomalyFinding {
+	addresses := make(map[string]int)
+	for _, e := range endpoints {
+		for _, subset := range e.Subsets {
+			addresses[e.Name] += len(subset.Addresses) + len(subset.NotReadyAddresses)
+		}
+	}
+
+	var findings []anomalyFinding
+
+	for _, service := range services {
+		if service.Spec.Type == corev1.ServiceTypeExternalName {
+			continue
+		}
+
+		if addresses[service.Name] > 0 {
+			continue
+		}
+
+		findings = append(findings, anomalyFinding{
+			Check:    anomalyServiceWithoutEndpoints,
+			Resource: workloadRef{Kind: "Service", Name: service.Name},
+			Severity: severityMedium,
+			Message:  "service has no endpoints",
+		})
+	}
+
+	return findings
+}
+
+// deploymentAvailabilityAnomalies flags deployments which want replicas but
+// have none available. Deployments scaled to zero are skipped.
+func deploymentAvailabilityAnomalies(deployments []appsv1.Deployment) []anomalyFinding {
+	var findings []anomalyFinding
+
+	for _, deployment := range deployments {
+		desired := int32(1)
+		if deployment.Spec.Replicas != nil {
+			desired = *deployment.Spec.Replicas
+		}
+
+		if desired == 0 || deployment.Status.AvailableReplicas > 0 {
+			continue
+		}
+
+		findings = append(findings, anomalyFinding{
+			Check:    anomalyDeploymentUnavailable,
+			Resource: workloadRef{Kind: "Deployment", Name: deployment.Name},
+			Severity: severityHigh,
+			Message:  fmt.Sprintf("0 of %d replicas are available", desired),
+		})
+	}
+
+	return findings
+}
+
+// configMapSizeAnomalies flags ConfigMaps whose data is larger than
+// maxConfigMapSize.
+func configMapSizeAnomalies(configMaps []corev1.ConfigMap) []anomalyFinding {
+	var findings []anomalyFinding
+
+	for _, configMap := range configMaps {
+		size := 0
+		for key, value := range configMap.Data {
+			size += len(key) + len(value)
+		}
+		for key, value := range configMap.BinaryData {
+			size += len(key) + len(value)
+		}
+
+		if size <= maxConfigMapSize {
+			continue
+		}
+
+		findings = append(findings, anomalyFinding{
+			Check:    anomalyLargeConfigMap,
+			Resource: workloadRef{Kind: "ConfigMap", Name: configMap.Name},
+			Severity: severityMedium,
+			Message:  fmt.Sprintf("data is %d bytes", size),
+		})
+	}
+
+	return findings
+}
+
+// staleSecretAnomalies flags secrets older than staleSecretAge which no
+// service account references. A service account token is bound when the
+// service account it was issued for exists.
+func staleSecretAnomalies(secrets []corev1.Secret, serviceAccounts []corev1.ServiceAccount, now time.Time) []anomalyFinding {
+	names := sets.NewString()
+	bound := sets.NewString()
+	for _, serviceAccount := range serviceAccounts {
+		names.Insert(serviceAccount.Name)
+		for _, ref := range serviceAccount.Secrets {
+			bound.Insert(ref.Name)
+		}
+		for _, ref := range serviceAccount.ImagePullSecrets {
+			bound.Insert(ref.Name)
+		}
+	}
+
+	var findings []anomalyFinding
+
+	for _, secret := range secrets {
+		age := now.Sub(secret.CreationTimestamp.Time)
+		if age <= staleSecretAge {
+			continue
+		}
+
+		if bound.Has(secret.Name) {
+			continue
+		}
+
+		if secret.Type == corev1.SecretTypeServiceAccountToken && names.Has(secret.Annotations[corev1.ServiceAccountNameKey]) {
+			continue
+		}
+
+		findings = append(findings, anomalyFinding{
+			Check:    anomalyStaleSecret,
+			Resource: workloadRef{Kind: "Secret", Name: secret.Name},
+			Severity: severityLow,
+			Message:  fmt.Sprintf("secret is %d days old and not used by a service account", int(age.Hours()/24)),
+		})
+	}
+
+	return findings
+}
@@ -0,0 +1,121 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_anomalyHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	meta := func(name string, age time.Duration) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: metav1.NewTime(now.Add(-age))}
+	}
+
+	newPod := func(name string, age time.Duration, restarts int32) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: meta(name, age),
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: restarts}},
+			},
+		}
+	}
+
+	replicas := func(n int32) *int32 { return &n }
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newPod("crashing", 30*time.Minute, 15),
+		newPod("flapping", 3*time.Hour, 40),
+		newPod("old", 48*time.Hour, 100),
+		&corev1.Service{ObjectMeta: meta("web", time.Hour)},
+		&corev1.Service{ObjectMeta: meta("orphan", time.Hour)},
+		&corev1.Service{
+			ObjectMeta: meta("external", time.Hour),
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "example.com"},
+		},
+		&corev1.Endpoints{
+			ObjectMeta: meta("web", time.Hour),
+			Subsets: []corev1.EndpointSubset{
+				{NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: meta("api", time.Hour),
+			Spec:       appsv1.DeploymentSpec{Replicas: replicas(3)},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: meta("paused", time.Hour),
+			Spec:       appsv1.DeploymentSpec{Replicas: replicas(0)},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: meta("web", time.Hour),
+			Spec:       appsv1.DeploymentSpec{Replicas: replicas(2)},
+			Status:     appsv1.DeploymentStatus{AvailableReplicas: 2},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: meta("bundle", time.Hour),
+			Data:       map[string]string{"bundle.js": strings.Repeat("x", maxConfigMapSize)},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: meta("settings", time.Hour),
+			Data:       map[string]string{"key": "value"},
+		},
+		&corev1.Secret{ObjectMeta: meta("legacy", 400*24*time.Hour)},
+		&corev1.Secret{ObjectMeta: meta("registry", 400*24*time.Hour)},
+		&corev1.Secret{ObjectMeta: meta("recent", 10*24*time.Hour)},
+		&corev1.Secret{
+			ObjectMeta: func() metav1.ObjectMeta {
+				m := meta("default-token-abc", 400*24*time.Hour)
+				m.Annotations = map[string]string{corev1.ServiceAccountNameKey: "default"}
+				return m
+			}(),
+			Type: corev1.SecretTypeServiceAccountToken,
+		},
+		&corev1.ServiceAccount{
+			ObjectMeta:       meta("default", 400*24*time.Hour),
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+		},
+	)
+
+	handler := newAnomalyHandler(kubeClient, log.NopLogger())
+	handler.nowFn = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/anomalies", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got anomaliesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := []anomalyFinding{
+		{Check: anomalyHighRestartRate, Resource: workloadRef{Kind: "Pod", Name: "crashing"}, Severity: severityHigh, Message: "containers restarted 15 times, about 15 an hour"},
+		{Check: anomalyHighRestartRate, Resource: workloadRef{Kind: "Pod", Name: "flapping"}, Severity: severityHigh, Message: "containers restarted 40 times, about 13 an hour"},
+		{Check: anomalyServiceWithoutEndpoints, Resource: workloadRef{Kind: "Service", Name: "orphan"}, Severity: severityMedium, Message: "service has no endpoints"},
+		{Check: anomalyDeploymentUnavailable, Resource: workloadRef{Kind: "Deployment", Name: "api"}, Severity: severityHigh, Message: "0 of 3 replicas are available"},
+		{Check: anomalyLargeConfigMap, Resource: workloadRef{Kind: "ConfigMap", Name: "bundle"}, Severity: severityMedium, Message: "data is 1000009 bytes"},
+		{Check: anomalyStaleSecret, Resource: workloadRef{Kind: "Secret", Name: "legacy"}, Severity: severityLow, Message: "secret is 400 days old and not used by a service account"},
+	}
+	assert.Equal(t, expected, got.Findings)
+}
@@ -10,14 +10,19 @@ import (
 	"encoding/json"
 	"net/http"
 	"path"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 
 	"github.com/vmware/octant/internal/cluster"
 	"github.com/vmware/octant/internal/log"
 	"github.com/vmware/octant/internal/mime"
 	"github.com/vmware/octant/internal/module"
+	"github.com/vmware/octant/internal/telemetry"
 	"github.com/vmware/octant/pkg/navigation"
 )
 
@@ -81,6 +86,9 @@ func RespondWithError(w http.ResponseWriter, code int, message string, logger lo
 type ClusterClient interface {
 	NamespaceClient() (cluster.NamespaceInterface, error)
 	InfoClient() (cluster.InfoInterface, error)
+	KubernetesClient() (kubernetes.Interface, error)
+	DynamicClient() (dynamic.Interface, error)
+	RESTConfig() *rest.Config
 }
 
 // API is the API for the dashboard client
@@ -95,13 +103,179 @@ type API struct {
 	modulePaths   map[string]module.Module
 	modules       []module.Module
 	forceUpdateCh chan bool
+
+	maxCopySize     int64
+	telemetry       telemetry.Telemetry
+	alertmanagerURL string
+	trivyURL        string
+	kubecostURL     string
+	jaegerURL       string
+	lokiURL         string
+	prometheusURL   string
+
+	requireImagePullSecrets bool
+	resourceListConcurrency int
+
+	impersonationSessions *ttlCache
+	userSessions          *userSessionStore
+	requireUserTokens     bool
+	auditLogPath          string
+	dependencyLabel       string
+	quotaSnapshots        *quotaSnapshotStore
+	costConfigPath        string
+	costStore             CostStore
+	clusterRegistry       ClusterRegistry
 }
 
 var _ Service = (*API)(nil)
 
+// Option is an option for configuring API.
+type Option func(*API)
+
+// WithMaxCopySize sets the maximum size in bytes of an archive copied out of a pod.
+func WithMaxCopySize(size int64) Option {
+	return func(a *API) {
+		a.maxCopySize = size
+	}
+}
+
+// WithTelemetry sets the telemetry used to record API usage. Telemetry is
+// disabled unless this option is supplied.
+func WithTelemetry(t telemetry.Telemetry) Option {
+	return func(a *API) {
+		a.telemetry = t
+	}
+}
+
+// WithAlertmanagerURL sets the URL of the Alertmanager used for alerts.
+func WithAlertmanagerURL(alertmanagerURL string) Option {
+	return func(a *API) {
+		a.alertmanagerURL = alertmanagerURL
+	}
+}
+
+// WithTrivyURL sets the URL of the Trivy server used for config audits.
+func WithTrivyURL(trivyURL string) Option {
+	return func(a *API) {
+		a.trivyURL = trivyURL
+	}
+}
+
+// WithKubecostURL sets the URL of the KubeCost cost model used for namespace
+// cost summaries.
+func WithKubecostURL(kubecostURL string) Option {
+	return func(a *API) {
+		a.kubecostURL = kubecostURL
+	}
+}
+
+// WithJaegerURL sets the URL of the Jaeger query service used to show a
+// workload's traces.
+func WithJaegerURL(jaegerURL string) Option {
+	return func(a *API) {
+		a.jaegerURL = jaegerURL
+	}
+}
+
+// WithLokiURL sets the URL of the Loki server used for log search.
+func WithLokiURL(lokiURL string) Option {
+	return func(a *API) {
+		a.lokiURL = lokiURL
+	}
+}
+
+// WithPrometheusURL sets the URL of the Prometheus or Thanos query API used
+// for SLO burn rates and rule validation.
+func WithPrometheusURL(prometheusURL string) Option {
+	return func(a *API) {
+		a.prometheusURL = prometheusURL
+	}
+}
+
+// WithRequireImagePullSecrets flags service accounts without image pull
+// secrets, for clusters where images are pulled from private registries.
+func WithRequireImagePullSecrets(require bool) Option {
+	return func(a *API) {
+		a.requireImagePullSecrets = require
+	}
+}
+
+// WithResourceListConcurrency sets the maximum number of concurrent list calls
+// made when counting the resources in a namespace, and of concurrent queries
+// made when validating Prometheus rules.
+func WithResourceListConcurrency(n int) Option {
+	return func(a *API) {
+		a.resourceListConcurrency = n
+	}
+}
+
+// WithImpersonationTTL sets how long an impersonation session lasts.
+func WithImpersonationTTL(ttl time.Duration) Option {
+	return func(a *API) {
+		a.impersonationSessions = newImpersonationSessions(ttl)
+	}
+}
+
+// WithRequireUserTokens rejects API requests without a bearer token, for
+// deployments where octant is shared and every user authenticates.
+func WithRequireUserTokens(require bool) Option {
+	return func(a *API) {
+		a.requireUserTokens = require
+	}
+}
+
+// WithAuditLogPath sets the path of a Kubernetes audit log in JSON lines
+// format. When set, namespace timelines are read from the audit log instead
+// of events.
+func WithAuditLogPath(auditLogPath string) Option {
+	return func(a *API) {
+		a.auditLogPath = auditLogPath
+	}
+}
+
+// WithDependencyLabel sets the service label naming the service it depends
+// on, used to build namespace dependency graphs.
+func WithDependencyLabel(label string) Option {
+	return func(a *API) {
+		a.dependencyLabel = label
+	}
+}
+
+// WithQuotaSnapshotPath sets the file hourly resource quota usage is recorded
+// in, so quota forecasts can use usage from before octant started.
+func WithQuotaSnapshotPath(path string) Option {
+	return func(a *API) {
+		a.quotaSnapshots = newQuotaSnapshotStore(path)
+	}
+}
+
+// WithCostConfigPath sets the path of a JSON file pricing nodes, used to
+// allocate node costs to namespaces.
+func WithCostConfigPath(path string) Option {
+	return func(a *API) {
+		a.costConfigPath = path
+	}
+}
+
+// WithCostHistoryPath sets the file hourly workload costs are recorded in
+// for cost history. Without it no cost history is kept.
+func WithCostHistoryPath(path string) Option {
+	return func(a *API) {
+		a.costStore = newFileCostStore(path)
+	}
+}
+
+// WithClusterRegistry sets the clusters shown in the fleet overview. Only the
+// current cluster is shown otherwise.
+func WithClusterRegistry(registry ClusterRegistry) Option {
+	return func(a *API) {
+		a.clusterRegistry = registry
+	}
+}
+
 // New creates an instance of API.
-func New(ctx context.Context, prefix string, clusterClient ClusterClient, moduleManager module.ManagerInterface, actionDispatcher ActionDispatcher, logger log.Logger) *API {
-	return &API{
+func New(ctx context.Context, prefix string, clusterClient ClusterClient, moduleManager module.ManagerInterface, actionDispatcher ActionDispatcher, logger log.Logger, options ...Option) *API {
+	a := &API{
 		ctx:              ctx,
 		prefix:           prefix,
 		clusterClient:    clusterClient,
@@ -110,7 +284,21 @@ func New(ctx context.Context, prefix string, clusterClient ClusterClient, module
 		modulePaths:      make(map[string]module.Module),
 		logger:           logger,
 		forceUpdateCh:    make(chan bool, 1),
+		maxCopySize:      defaultMaxCopySize,
+		telemetry:        telemetry.NopTelemetry{},
+
+		impersonationSessions: newImpersonationSessions(defaultImpersonationTTL),
+		userSessions:          newUserSessionStore(defaultUserSessionTTL),
+		dependencyLabel:       defaultDependencyLabel,
+		quotaSnapshots:        newQuotaSnapshotStore(""),
+		costStore:             noopCostStore{},
 	}
+
+	for _, option := range options {
+		option(a)
+	}
+
+	return a
 }
 
 func (a *API) ForceUpdate() error {
@@ -124,6 +312,7 @@ func (a *API) Handler(ctx context.Context) (*mux.Router, error) {
 	router.Use(rebindHandler(acceptedHosts))
 
 	s := router.PathPrefix(a.prefix).Subrouter()
+	s.Use(trackRequests(a.telemetry))
 
 	nsClient, err := a.clusterClient.NamespaceClient()
 	if err != nil {
@@ -135,6 +324,21 @@ func (a *API) Handler(ctx context.Context) (*mux.Router, error) {
 		return nil, errors.Wrap(err, "retrieve cluster info client")
 	}
 
+	kubeClient, err := a.clusterClient.KubernetesClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "retrieve kubernetes client")
+	}
+
+	dynamicClient, err := a.clusterClient.DynamicClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "retrieve dynamic client")
+	}
+
+	// Tokens are authenticated before impersonation so an impersonated
+	// request can't skip review.
+	s.Use(userSessionAuthentication(a.userSessions, a.requireUserTokens, kubeClient, a.logger))
+	s.Use(impersonation(a.impersonationSessions, a.logger))
+
 	namespacesService := newNamespaces(nsClient, a.logger)
 	s.Handle("/namespaces", namespacesService).Methods(http.MethodGet)
 
@@ -155,6 +359,26 @@ func (a *API) Handler(ctx context.Context) (*mux.Router, error) {
 	actionService := newAction(a.logger, a.actionDispatcher)
 	s.Handle("/action", actionService)
 
+	telemetryStatusService := newTelemetryStatusHandler(a.telemetry, a.logger)
+	s.Handle("/telemetry/status", telemetryStatusService).Methods(http.MethodGet)
+
+	capabilitiesService := newCapabilitiesHandler(a.modules, a.logger)
+	s.Handle("/plugins/{name}/capabilities", capabilitiesService).Methods(http.MethodGet)
+
+	impersonateService := newImpersonateHandler(kubeClient, a.clusterClient.RESTConfig(), infoClient.User(), a.impersonationSessions, a.impersonatedRouter, a.logger)
+	s.Handle("/impersonate", impersonateService).Methods(http.MethodPost)
+
+	a.registerClusterRoutes(s, kubeClient, dynamicClient, a.clusterClient.RESTConfig())
+
+	// Background work starts once, with the base client. It must not start in
+	// registerClusterRoutes, which also runs for every impersonation session.
+	go a.impersonationSessions.sweepEvery(a.ctx, impersonationSweepInterval)
+	go a.userSessions.sweepEvery(a.ctx, userSessionSweepInterval)
+	go recordQuotaSnapshots(a.ctx, kubeClient, a.quotaSnapshots, quotaSnapshotInterval, a.logger)
+	if a.costConfigPath != "" {
+		go recordCostSamples(a.ctx, kubeClient, a.costConfigPath, a.costStore, costSampleInterval, a.logger)
+	}
+
 	// Register content routes
 	contentService := &contentHandler{
 		nsClient:      nsClient,
@@ -177,6 +401,336 @@ func (a *API) Handler(ctx context.Context) (*mux.Router, error) {
 	return router, nil
 }
 
+// registerClusterRoutes registers routes which are served using the supplied
+// cluster clients. These are the routes available to impersonation sessions,
+// so it runs once per session and must not start background work.
+func (a *API) registerClusterRoutes(s *mux.Router, kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, restConfig *rest.Config) {
+	podDescribeService := newPodDescribeHandler(kubeClient, a.logger)
+	s.Handle("/pods/{namespace}/{pod}/describe", podDescribeService).Methods(http.MethodGet)
+
+	podCopyService := newPodCopyHandler(kubeClient, restConfig, a.maxCopySize, a.logger)
+	s.Handle("/pods/{namespace}/{pod}/copy", podCopyService).Methods(http.MethodPost)
+
+	deploymentDiffsService := newDeploymentDiffsHandler(kubeClient, a.logger)
+	s.Handle("/diffs/deployments/{namespace}", deploymentDiffsService).Methods(http.MethodGet)
+
+	recommendationsService := newRecommendationsHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/recommendations/{namespace}", recommendationsService).Methods(http.MethodGet)
+
+	alertsService := newAlertsHandler(a.alertmanagerURL, a.logger)
+	s.Handle("/alerts", alertsService).Methods(http.MethodGet)
+
+	// Registered before /topology/{namespace} so "zones" isn't read as a namespace.
+	topologyZonesService := newTopologyZonesHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/topology/zones", topologyZonesService).Methods(http.MethodGet)
+
+	topologyService := newTopologyHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/topology/{namespace}", topologyService).Methods(http.MethodGet)
+
+	fluxService := newFluxHandler(dynamicClient, a.logger)
+	s.Handle("/flux/{namespace}", fluxService).Methods(http.MethodGet)
+
+	rolloutsService := newRolloutsHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/argo/{namespace}", rolloutsService).Methods(http.MethodGet)
+
+	rolloutPauseService := newRolloutPauseHandler(kubeClient, dynamicClient, true, a.logger)
+	s.Handle("/argo/{namespace}/{rollout}/pause", rolloutPauseService).Methods(http.MethodPost)
+
+	rolloutResumeService := newRolloutPauseHandler(kubeClient, dynamicClient, false, a.logger)
+	s.Handle("/argo/{namespace}/{rollout}/resume", rolloutResumeService).Methods(http.MethodPost)
+
+	argoStatusService := newArgoStatusHandler(dynamicClient, a.logger)
+	s.Handle("/gitops/status", argoStatusService).Methods(http.MethodGet)
+
+	deprecationsService := newDeprecationsHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/deprecations", deprecationsService).Methods(http.MethodGet)
+
+	podTopologyService := newPodTopologyHandler(kubeClient, a.logger)
+	s.Handle("/podtopology/{namespace}", podTopologyService).Methods(http.MethodGet)
+
+	pdbService := newPDBHandler(kubeClient, a.logger)
+	s.Handle("/pdb/{namespace}", pdbService).Methods(http.MethodGet)
+
+	certificatesService := newCertificatesHandler(dynamicClient, a.logger)
+	s.Handle("/certificates/{namespace}", certificatesService).Methods(http.MethodGet)
+
+	serviceAccountsService := newServiceAccountsHandler(kubeClient, a.requireImagePullSecrets, a.logger)
+	s.Handle("/serviceaccounts/{namespace}", serviceAccountsService).Methods(http.MethodGet)
+
+	webhooksService := newWebhooksHandler(kubeClient, a.logger)
+	s.Handle("/admission/webhooks", webhooksService).Methods(http.MethodGet)
+
+	leasesService := newLeasesHandler(kubeClient, a.logger)
+	s.Handle("/leases/{namespace}", leasesService).Methods(http.MethodGet)
+
+	endpointsService := newEndpointsHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/endpoints/{namespace}/{service}", endpointsService).Methods(http.MethodGet)
+
+	namespaceResourcesService := newNamespaceResourcesHandler(kubeClient, dynamicClient, a.resourceListConcurrency, a.logger)
+	s.Handle("/namespaces/{namespace}/resources", namespaceResourcesService).Methods(http.MethodGet)
+
+	podPresetService := newPodPresetHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/podpresets/{namespace}", podPresetService).Methods(http.MethodGet)
+
+	runtimeClassesService := newRuntimeClassesHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/runtimeclasses", runtimeClassesService).Methods(http.MethodGet)
+
+	webhookDryRunService := newWebhookDryRunHandler(kubeClient, a.logger)
+	s.Handle("/mutatingwebhook/dry-run", webhookDryRunService).Methods(http.MethodGet, http.MethodPost)
+
+	eventTimelineService := newEventTimelineHandler(kubeClient, a.logger)
+	s.Handle("/events/timeline", eventTimelineService).Methods(http.MethodGet)
+
+	imagesService := newImagesHandler(dynamicClient, a.logger)
+	s.Handle("/images/{namespace}", imagesService).Methods(http.MethodGet)
+
+	ownerTreeService := newOwnerTreeHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/namespaces/{namespace}/ownerTree", ownerTreeService).Methods(http.MethodGet)
+
+	clusterRolesService := newClusterRolesHandler(kubeClient, a.logger)
+	s.Handle("/clusterroles", clusterRolesService).Methods(http.MethodGet)
+
+	roleBindingsService := newRoleBindingsHandler(kubeClient, a.logger)
+	s.Handle("/rolebindings/{namespace}", roleBindingsService).Methods(http.MethodGet)
+
+	priorityClassesService := newPriorityClassesHandler(kubeClient, a.logger)
+	s.Handle("/priorityclasses", priorityClassesService).Methods(http.MethodGet)
+
+	limitRangesService := newLimitRangesHandler(kubeClient, a.logger)
+	s.Handle("/limitranges/{namespace}", limitRangesService).Methods(http.MethodGet)
+
+	conditionsService := newConditionsHandler(kubeClient, a.logger)
+	s.Handle("/conditions/{namespace}", conditionsService).Methods(http.MethodGet)
+
+	namespaceTimelineService := newNamespaceTimelineHandler(kubeClient, a.auditLogPath, a.logger)
+	s.Handle("/namespaces/{namespace}/timeline", namespaceTimelineService).Methods(http.MethodGet)
+
+	probesService := newProbesHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/probes/{namespace}", probesService).Methods(http.MethodGet)
+
+	securityPoliciesService := newSecurityPoliciesHandler(kubeClient, a.logger)
+	s.Handle("/security/policies", securityPoliciesService).Methods(http.MethodGet)
+
+	oomAnalysisService := newOOMAnalysisHandler(kubeClient, a.logger)
+	s.Handle("/memory-analysis/{namespace}", oomAnalysisService).Methods(http.MethodGet)
+
+	pendingPodsService := newPendingPodsHandler(kubeClient, a.logger)
+	s.Handle("/pending/{namespace}", pendingPodsService).Methods(http.MethodGet)
+
+	nodeSelectorService := newNodeSelectorHandler(kubeClient, a.logger)
+	s.Handle("/nodeselector/{namespace}", nodeSelectorService).Methods(http.MethodGet)
+
+	psaAuditService := newPSAAuditHandler(dynamicClient, a.logger)
+	s.Handle("/podsecurity/audit", psaAuditService).Methods(http.MethodGet)
+
+	resourceHistoryService := newResourceHistoryHandler(kubeClient, a.logger)
+	s.Handle("/namespaces/{namespace}/resourcehistory", resourceHistoryService).Methods(http.MethodGet)
+
+	finalizerStatusService := newFinalizerStatusHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/finalizerstatus/{namespace}", finalizerStatusService).Methods(http.MethodGet)
+
+	// Removals and rollbacks are logged with the session token's user, or
+	// without a session, the identity the cluster clients act as.
+	user := restConfig.Impersonate.UserName
+	if user == "" {
+		if infoClient, err := a.clusterClient.InfoClient(); err == nil {
+			user = infoClient.User()
+		}
+	}
+	removeFinalizerService := newRemoveFinalizerHandler(kubeClient, dynamicClient, user, a.logger)
+	s.Handle("/finalizerstatus/{namespace}/{resource}/{name}/removeFinalizer", removeFinalizerService).Methods(http.MethodPost)
+
+	resourceVersionsService := newResourceVersionsHandler(kubeClient, dynamicClient, a.resourceListConcurrency, a.logger)
+	s.Handle("/resource-versions", resourceVersionsService).Methods(http.MethodGet)
+
+	rawMetricsService := newRawMetricsHandler(kubeClient, restConfig, a.logger)
+	s.Handle("/metricsraw/{namespace}", rawMetricsService).Methods(http.MethodGet)
+
+	configAuditService := newConfigAuditHandler(kubeClient, a.trivyURL, a.logger)
+	s.Handle("/configaudit/{namespace}", configAuditService).Methods(http.MethodGet)
+
+	gatekeeperService := newGatekeeperHandler(dynamicClient, a.logger)
+	s.Handle("/gatekeeper/constraints", gatekeeperService).Methods(http.MethodGet)
+
+	linkerdMeshService := newLinkerdMeshHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/linkerd/meshstatus/{namespace}", linkerdMeshService).Methods(http.MethodGet)
+
+	istioMeshService := newIstioMeshHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/istio/meshconfig/{namespace}", istioMeshService).Methods(http.MethodGet)
+
+	userSessionsService := newUserSessionsHandler(kubeClient, a.userSessions, a.logger)
+	s.HandleFunc("/namespaces/{namespace}/usersessions", userSessionsService.list).Methods(http.MethodGet)
+	s.HandleFunc("/namespaces/{namespace}/usersessions/{user}", userSessionsService.invalidate).Methods(http.MethodDelete)
+
+	anomalyService := newAnomalyHandler(kubeClient, a.logger)
+	s.Handle("/namespaces/{namespace}/anomalies", anomalyService).Methods(http.MethodGet)
+
+	clusterEventsService := newClusterEventsHandler(kubeClient, a.logger)
+	s.Handle("/clusterevents", clusterEventsService).Methods(http.MethodGet)
+
+	karpenterService := newKarpenterHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/karpenter/nodepools", karpenterService).Methods(http.MethodGet)
+
+	veleroService := newVeleroHandler(dynamicClient, a.logger)
+	s.HandleFunc("/velero/backups", veleroService.backups).Methods(http.MethodGet)
+	s.HandleFunc("/velero/restores", veleroService.restores).Methods(http.MethodGet)
+
+	crossplaneService := newCrossplaneHandler(dynamicClient, a.logger)
+	s.Handle("/crossplane/managed", crossplaneService).Methods(http.MethodGet)
+
+	if infoClient, err := a.clusterClient.InfoClient(); err == nil {
+		apiGroupsService := newAPIGroupsHandler(kubeClient, infoClient, a.logger)
+		s.Handle("/kubernetes/apigroups", apiGroupsService).Methods(http.MethodGet)
+	}
+
+	networkPolicyCoverageService := newNetworkPolicyCoverageHandler(kubeClient, a.logger)
+	s.Handle("/networkpolicies/{namespace}/coverage", networkPolicyCoverageService).Methods(http.MethodGet)
+
+	kubeletConfigService := newKubeletConfigHandler(kubeClient, a.logger)
+	s.Handle("/kubelet/config/{node}", kubeletConfigService).Methods(http.MethodGet)
+
+	scalingRecommendationsService := newScalingRecommendationsHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/autoscaling/recommendations/{namespace}", scalingRecommendationsService).Methods(http.MethodGet)
+
+	crdSchemaService := newCRDSchemaHandler(dynamicClient, a.logger)
+	s.Handle("/crds/{group}/{version}/{resource}", crdSchemaService).Methods(http.MethodGet)
+
+	eventHeatmapService := newEventHeatmapHandler(kubeClient, a.logger)
+	s.Handle("/events/timeline/heatmap", eventHeatmapService).Methods(http.MethodGet)
+
+	dependencyGraphService := newDependencyGraphHandler(kubeClient, a.dependencyLabel, a.logger)
+	s.Handle("/namespaces/{namespace}/dependency-graph", dependencyGraphService).Methods(http.MethodGet)
+
+	quotaForecastService := newQuotaForecastHandler(kubeClient, a.quotaSnapshots, a.logger)
+	s.Handle("/namespaces/{namespace}/quotaforecast", quotaForecastService).Methods(http.MethodGet)
+
+	sidecarStatusService := newSidecarStatusHandler(kubeClient, a.logger)
+	s.Handle("/pods/{namespace}/{pod}/sidecarstatus", sidecarStatusService).Methods(http.MethodGet)
+
+	ephemeralContainersService := newEphemeralContainersHandler(dynamicClient, a.logger)
+	s.HandleFunc("/ephemeralcontainers/{namespace}/{pod}", ephemeralContainersService.list).Methods(http.MethodGet)
+	s.HandleFunc("/ephemeralcontainers/{namespace}/{pod}", ephemeralContainersService.add).Methods(http.MethodPost)
+
+	clusterCapacityService := newClusterCapacityHandler(kubeClient, a.logger)
+	s.Handle("/cluster/capacity", clusterCapacityService).Methods(http.MethodGet)
+
+	costAllocationService := newCostAllocationHandler(kubeClient, a.costConfigPath, a.logger)
+	s.Handle("/cluster/costallocation", costAllocationService).Methods(http.MethodGet)
+
+	orphanedResourcesService := newOrphanedResourcesHandler(kubeClient, a.logger)
+	s.Handle("/orphaned-resources/{namespace}", orphanedResourcesService).Methods(http.MethodGet)
+
+	costHistoryService := newCostHistoryHandler(a.costStore, a.logger)
+	s.Handle("/namespaces/{namespace}/costhistory", costHistoryService).Methods(http.MethodGet)
+
+	saTokensService := newSATokensHandler(kubeClient, a.logger)
+	s.Handle("/namespaces/{namespace}/serviceaccounttokens", saTokensService).Methods(http.MethodGet)
+
+	privilegedBindingsService := newPrivilegedBindingsHandler(kubeClient, a.logger)
+	s.Handle("/clusterrolebindings/privileged", privilegedBindingsService).Methods(http.MethodGet)
+
+	workloadIdentityService := newWorkloadIdentityHandler(kubeClient, a.logger)
+	s.Handle("/namespaces/{namespace}/workloadidentity", workloadIdentityService).Methods(http.MethodGet)
+
+	rolloutHistoryService := newRolloutHistoryHandler(kubeClient, a.logger)
+	s.HandleFunc("/namespaces/{namespace}/rollouthistory/{deployment}", rolloutHistoryService.list).Methods(http.MethodGet)
+	s.HandleFunc("/namespaces/{namespace}/rollouthistory/{deployment}/{revision}", rolloutHistoryService.revision).Methods(http.MethodGet)
+
+	rollbackService := newRollbackHandler(kubeClient, user, a.logger)
+	s.Handle("/namespaces/{namespace}/rollback/{deployment}/{revision}", rollbackService).Methods(http.MethodPost)
+
+	validatingHistoryService := newValidatingHistoryHandler(kubeClient, a.auditLogPath, a.logger)
+	s.Handle("/clusteradmission/validating-history", validatingHistoryService).Methods(http.MethodGet)
+
+	multiContainerPodsService := newMultiContainerPodsHandler(kubeClient, a.logger)
+	s.Handle("/namespaces/{namespace}/multicontainer-pods", multiContainerPodsService).Methods(http.MethodGet)
+
+	nodePressureService := newNodePressureHandler(kubeClient, a.logger)
+	s.Handle("/nodepressure", nodePressureService).Methods(http.MethodGet)
+
+	kubecostService := newKubecostProxyHandler(a.kubecostURL, a.logger)
+	s.Handle("/kubecost/summary/{namespace}", kubecostService).Methods(http.MethodGet)
+
+	securityScorecardService := newSecurityScorecardHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/namespaces/{namespace}/security-scorecard", securityScorecardService).Methods(http.MethodGet)
+
+	pvcService := newPVCHandler(kubeClient, a.logger)
+	s.Handle("/pvc/{namespace}", pvcService).Methods(http.MethodGet)
+
+	saPermissionsService := newSAPermissionsHandler(kubeClient, a.logger)
+	s.Handle("/serviceaccounts/{namespace}/{name}/permissions", saPermissionsService).Methods(http.MethodGet)
+
+	resourceDriftService := newResourceDriftHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/namespaces/{namespace}/resourcedrift", resourceDriftService).Methods(http.MethodGet)
+
+	initContainerService := newInitContainerHandler(kubeClient, a.logger)
+	s.Handle("/namespaces/{namespace}/initcontainers", initContainerService).Methods(http.MethodGet)
+
+	envVarsService := newEnvVarsHandler(kubeClient, user, a.logger)
+	s.Handle("/namespaces/{namespace}/envvars", envVarsService).Methods(http.MethodGet)
+
+	clusterRegistry := a.clusterRegistry
+	if clusterRegistry == nil {
+		name := "current"
+		if infoClient, err := a.clusterClient.InfoClient(); err == nil && infoClient.Context() != "" {
+			name = infoClient.Context()
+		}
+		clusterRegistry = staticClusterRegistry{{Name: name, Client: kubeClient}}
+	}
+	fleetOverviewService := newFleetOverviewHandler(clusterRegistry, a.logger)
+	s.Handle("/fleetoverview", fleetOverviewService).Methods(http.MethodGet)
+
+	terminatingPodsService := newTerminatingPodsHandler(kubeClient, a.logger)
+	s.Handle("/namespaces/{namespace}/terminatingpods", terminatingPodsService).Methods(http.MethodGet)
+
+	nodePodsService := newNodePodsHandler(kubeClient, a.logger)
+	s.Handle("/nodes/{node}/pods", nodePodsService).Methods(http.MethodGet)
+
+	nodeEventsService := newNodeEventsHandler(kubeClient, a.logger)
+	s.Handle("/nodes/{node}/events", nodeEventsService).Methods(http.MethodGet)
+
+	networkDiagnosticsService := newNetworkDiagnosticsHandler(kubeClient, restConfig, a.logger)
+	s.Handle("/namespaces/{namespace}/networkdiagnostics", networkDiagnosticsService).Methods(http.MethodGet)
+
+	meshPoliciesService := newMeshPoliciesHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/namespaces/{namespace}/servicemeshpolicies", meshPoliciesService).Methods(http.MethodGet)
+
+	podDisruptionsService := newPodDisruptionsHandler(kubeClient, a.logger)
+	s.Handle("/poddisruptions/{namespace}", podDisruptionsService).Methods(http.MethodGet)
+
+	helmReleasesService := newHelmReleasesHandler(kubeClient, user, a.logger)
+	s.HandleFunc("/namespaces/{namespace}/helmreleases", helmReleasesService.list).Methods(http.MethodGet)
+	s.HandleFunc("/namespaces/{namespace}/helmreleases/{name}/manifest", helmReleasesService.manifest).Methods(http.MethodGet)
+
+	kustomizeService := newKustomizeHandler(dynamicClient, a.logger)
+	s.HandleFunc("/namespaces/{namespace}/kustomizeoverlays", kustomizeService.list).Methods(http.MethodGet)
+
+	imageUpdateService := newImageUpdateHandler(dynamicClient, a.logger)
+	s.Handle("/namespaces/{namespace}/imageupdate", imageUpdateService).Methods(http.MethodGet)
+
+	gitRepoService := newGitRepoHandler(kubeClient, dynamicClient, a.logger)
+	s.HandleFunc("/namespaces/{namespace}/gitrepositories", gitRepoService.list).Methods(http.MethodGet)
+	s.HandleFunc("/namespaces/{namespace}/gitrepositories/{name}/tree", gitRepoService.tree).Methods(http.MethodGet)
+
+	tracingService := newTracingHandler(kubeClient, a.jaegerURL, a.logger)
+	s.Handle("/namespaces/{namespace}/tracing", tracingService).Methods(http.MethodGet)
+
+	logSearchService := newLokiSearchHandler(a.lokiURL, a.logger)
+	s.Handle("/namespaces/{namespace}/logsearch", logSearchService).Methods(http.MethodGet)
+
+	slothSLOService := newSlothSLOHandler(dynamicClient, a.prometheusURL, a.logger)
+	s.Handle("/namespaces/{namespace}/slothslos", slothSLOService).Methods(http.MethodGet)
+
+	prometheusRulesService := newPrometheusRulesHandler(dynamicClient, a.prometheusURL, a.resourceListConcurrency, a.logger)
+	s.Handle("/namespaces/{namespace}/prometheusrules", prometheusRulesService).Methods(http.MethodGet)
+
+	serviceMonitorService := newServiceMonitorHandler(kubeClient, dynamicClient, a.logger)
+	s.Handle("/namespaces/{namespace}/servicemonitoring", serviceMonitorService).Methods(http.MethodGet)
+
+	affinityViolationsService := newAffinityViolationsHandler(kubeClient, a.logger)
+	s.Handle("/namespaces/{namespace}/podaffinity-violations", affinityViolationsService).Methods(http.MethodGet)
+}
+
 // RegisterModule registers a module with the API service.
 func (a *API) RegisterModule(m module.Module) error {
 	contentPath := path.Join("/content", m.ContentPath())
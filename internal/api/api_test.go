@@ -19,6 +19,10 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
 
 	apiFake "github.com/vmware/octant/internal/api/fake"
 	clusterFake "github.com/vmware/octant/internal/cluster/fake"
@@ -169,6 +173,9 @@ func TestAPI_routes(t *testing.T) {
 			clusterClient := apiFake.NewMockClusterClient(controller)
 			clusterClient.EXPECT().NamespaceClient().Return(mocks.namespace, nil).AnyTimes()
 			clusterClient.EXPECT().InfoClient().Return(mocks.info, nil).AnyTimes()
+			clusterClient.EXPECT().KubernetesClient().Return(kubefake.NewSimpleClientset(), nil).AnyTimes()
+			clusterClient.EXPECT().DynamicClient().Return(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), nil).AnyTimes()
+			clusterClient.EXPECT().RESTConfig().Return(&rest.Config{}).AnyTimes()
 
 			actionDispatcher := apiFake.NewMockActionDispatcher(controller)
 
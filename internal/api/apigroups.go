@@ -0,0 +1,165 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/cluster"
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	apiGroupsCacheTTL = 5 * time.Minute
+)
+
+type apiGroupResource struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	Namespaced bool   `json:"namespaced"`
+}
+
+type apiGroup struct {
+	Name             string             `json:"name"`
+	Versions         []string           `json:"versions"`
+	PreferredVersion string             `json:"preferredVersion"`
+	Resources        []apiGroupResource `json:"resources"`
+}
+
+type apiGroupsResponse struct {
+	Groups []apiGroup `json:"groups"`
+}
+
+type apiGroupsHandler struct {
+	kubeClient kubernetes.Interface
+	infoClient cluster.InfoInterface
+	cache      *ttlCache
+	logger     log.Logger
+
+	mu      sync.Mutex
+	cluster string
+}
+
+var _ http.Handler = (*apiGroupsHandler)(nil)
+
+func newAPIGroupsHandler(kubeClient kubernetes.Interface, infoClient cluster.InfoInterface, logger log.Logger) *apiGroupsHandler {
+	return &apiGroupsHandler{
+		kubeClient: kubeClient,
+		infoClient: infoClient,
+		cache:      newTTLCache(apiGroupsCacheTTL),
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns each API group the cluster
+// serves with its versions and the resources of its preferred version.
+// Groups are ordered by name, so the core group, which has an empty name,
+// is first. Results are cached for five minutes and dropped when the current
+// context or its server changes.
+func (h *apiGroupsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := h.cacheKey()
+
+	if cached, ok := h.cache.get(key); ok {
+		resp := cached.(apiGroupsResponse)
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	groups, err := h.apiGroups()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := apiGroupsResponse{
+		Groups: groups,
+	}
+
+	h.cache.set(key, resp)
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// cacheKey identifies the cluster being served. The cache is reset when the
+// cluster differs from the previous request.
+func (h *apiGroupsHandler) cacheKey() string {
+	key := h.infoClient.Context() + "|" + h.infoClient.Server()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if key != h.cluster {
+		h.cache.reset()
+		h.cluster = key
+	}
+
+	return key
+}
+
+func (h *apiGroupsHandler) apiGroups() ([]apiGroup, error) {
+	groupList, err := h.kubeClient.Discovery().ServerGroups()
+	if err != nil {
+		return nil, errors.Wrap(err, "discover server groups")
+	}
+
+	resourceLists, err := discovery.ServerPreferredResources(h.kubeClient.Discovery())
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, errors.Wrap(err, "discover preferred resources")
+	}
+
+	resources := make(map[string][]apiGroupResource)
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+
+		for _, resource := range resourceList.APIResources {
+			resources[gv.Group] = append(resources[gv.Group], apiGroupResource{
+				Name:       resource.Name,
+				Kind:       resource.Kind,
+				Namespaced: resource.Namespaced,
+			})
+		}
+	}
+
+	groups := []apiGroup{}
+	for _, group := range groupList.Groups {
+		g := apiGroup{
+			Name:             group.Name,
+			Versions:         []string{},
+			PreferredVersion: group.PreferredVersion.Version,
+			Resources:        resources[group.Name],
+		}
+
+		for _, version := range group.Versions {
+			g.Versions = append(g.Versions, version.Version)
+		}
+
+		if g.Resources == nil {
+			g.Resources = []apiGroupResource{}
+		}
+		sort.Slice(g.Resources, func(i, j int) bool {
+			return g.Resources[i].Name < g.Resources[j].Name
+		})
+
+		groups = append(groups, g)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups, nil
+}
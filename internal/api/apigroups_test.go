@@ -0,0 +1,110 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type stubInfoClient struct {
+	context string
+	server  string
+}
+
+func (s *stubInfoClient) Context() string { return s.context }
+func (s *stubInfoClient) Cluster() string { return s.context }
+func (s *stubInfoClient) Server() string  { return s.server }
+func (s *stubInfoClient) User() string    { return "" }
+
+func Test_apiGroupsHandler(t *testing.T) {
+	verbs := metav1.Verbs{"get", "list"}
+
+	kubeClient := kubefake.NewSimpleClientset()
+	fakeDiscovery := kubeClient.Discovery().(*fakediscovery.FakeDiscovery)
+	fakeDiscovery.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: verbs},
+				{Name: "nodes", Kind: "Node", Verbs: verbs},
+			},
+		},
+		{
+			GroupVersion: "batch/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "jobs", Kind: "Job", Namespaced: true, Verbs: verbs},
+			},
+		},
+		{
+			GroupVersion: "batch/v1beta1",
+			APIResources: []metav1.APIResource{
+				{Name: "cronjobs", Kind: "CronJob", Namespaced: true, Verbs: verbs},
+			},
+		},
+	}
+
+	infoClient := &stubInfoClient{context: "dev", server: "https://dev.example.com"}
+	handler := newAPIGroupsHandler(kubeClient, infoClient, log.NopLogger())
+
+	get := func() apiGroupsResponse {
+		req := httptest.NewRequest(http.MethodGet, "/kubernetes/apigroups", nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var got apiGroupsResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		return got
+	}
+
+	expected := apiGroupsResponse{
+		Groups: []apiGroup{
+			{
+				Name:             "",
+				Versions:         []string{"v1"},
+				PreferredVersion: "v1",
+				Resources: []apiGroupResource{
+					{Name: "nodes", Kind: "Node"},
+					{Name: "pods", Kind: "Pod", Namespaced: true},
+				},
+			},
+			{
+				Name:             "batch",
+				Versions:         []string{"v1", "v1beta1"},
+				PreferredVersion: "v1",
+				Resources: []apiGroupResource{
+					{Name: "cronjobs", Kind: "CronJob", Namespaced: true},
+					{Name: "jobs", Kind: "Job", Namespaced: true},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, get())
+
+	fakeDiscovery.Resources = fakeDiscovery.Resources[:1]
+	assert.Equal(t, expected, get(), "cached groups are served")
+
+	infoClient.context = "prod"
+	infoClient.server = "https://prod.example.com"
+
+	assert.Equal(t, apiGroupsResponse{
+		Groups: []apiGroup{expected.Groups[0]},
+	}, get(), "cache is dropped when the cluster changes")
+}
@@ -0,0 +1,114 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const argoStatusUnknown = "Unknown"
+
+var argoApplicationGVR = schema.GroupVersionResource{
+	Group:    "argoproj.io",
+	Version:  "v1alpha1",
+	Resource: "applications",
+}
+
+type argoApplicationStatus struct {
+	Name                 string `json:"name"`
+	Namespace            string `json:"namespace"`
+	Project              string `json:"project"`
+	DestinationNamespace string `json:"destinationNamespace"`
+	SyncStatus           string `json:"syncStatus"`
+	HealthStatus         string `json:"healthStatus"`
+	LastSyncTime         string `json:"lastSyncTime,omitempty"`
+}
+
+type argoStatusResponse struct {
+	ArgoCDNotInstalled bool                    `json:"argocdNotInstalled"`
+	Applications       []argoApplicationStatus `json:"applications"`
+}
+
+type argoStatusHandler struct {
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*argoStatusHandler)(nil)
+
+func newArgoStatusHandler(dynamicClient dynamic.Interface, logger log.Logger) *argoStatusHandler {
+	return &argoStatusHandler{
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the sync and health status of
+// ArgoCD applications in all namespaces. The optional `project` and `namespace`
+// query parameters filter by project and destination namespace.
+func (h *argoStatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	project := r.URL.Query().Get("project")
+	namespace := r.URL.Query().Get("namespace")
+
+	applications, installed, err := listOptionalResource(h.dynamicClient, argoApplicationGVR, "", metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := argoStatusResponse{
+		ArgoCDNotInstalled: !installed,
+		Applications:       []argoApplicationStatus{},
+	}
+
+	for _, application := range applications.Items {
+		status := newArgoApplicationStatus(application)
+
+		if project != "" && status.Project != project {
+			continue
+		}
+		if namespace != "" && status.DestinationNamespace != namespace {
+			continue
+		}
+
+		resp.Applications = append(resp.Applications, status)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func newArgoApplicationStatus(application unstructured.Unstructured) argoApplicationStatus {
+	project, _, _ := unstructured.NestedString(application.Object, "spec", "project")
+	destinationNamespace, _, _ := unstructured.NestedString(application.Object, "spec", "destination", "namespace")
+	lastSyncTime, _, _ := unstructured.NestedString(application.Object, "status", "operationState", "finishedAt")
+
+	syncStatus, _, _ := unstructured.NestedString(application.Object, "status", "sync", "status")
+	if syncStatus == "" {
+		syncStatus = argoStatusUnknown
+	}
+
+	healthStatus, _, _ := unstructured.NestedString(application.Object, "status", "health", "status")
+	if healthStatus == "" {
+		healthStatus = argoStatusUnknown
+	}
+
+	return argoApplicationStatus{
+		Name:                 application.GetName(),
+		Namespace:            application.GetNamespace(),
+		Project:              project,
+		DestinationNamespace: destinationNamespace,
+		SyncStatus:           syncStatus,
+		HealthStatus:         healthStatus,
+		LastSyncTime:         lastSyncTime,
+	}
+}
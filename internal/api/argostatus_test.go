@@ -0,0 +1,113 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_argoStatusHandler(t *testing.T) {
+	newApplication := func(name, project, destination string, status map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Application",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "argocd"},
+			"spec": map[string]interface{}{
+				"project":     project,
+				"destination": map[string]interface{}{"namespace": destination},
+			},
+			"status": status,
+		}}
+	}
+
+	synced := newApplication("web", "team-a", "web", map[string]interface{}{
+		"sync":           map[string]interface{}{"status": "Synced"},
+		"health":         map[string]interface{}{"status": "Healthy"},
+		"operationState": map[string]interface{}{"finishedAt": "2019-10-01T10:00:00Z"},
+	})
+	pending := newApplication("api", "team-b", "api", map[string]interface{}{})
+
+	webStatus := argoApplicationStatus{
+		Name:                 "web",
+		Namespace:            "argocd",
+		Project:              "team-a",
+		DestinationNamespace: "web",
+		SyncStatus:           "Synced",
+		HealthStatus:         "Healthy",
+		LastSyncTime:         "2019-10-01T10:00:00Z",
+	}
+	apiStatus := argoApplicationStatus{
+		Name:                 "api",
+		Namespace:            "argocd",
+		Project:              "team-b",
+		DestinationNamespace: "api",
+		SyncStatus:           argoStatusUnknown,
+		HealthStatus:         argoStatusUnknown,
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		missing  bool
+		expected []argoApplicationStatus
+	}{
+		{
+			name:     "all applications",
+			expected: []argoApplicationStatus{apiStatus, webStatus},
+		},
+		{
+			name:     "filtered by project",
+			query:    "?project=team-a",
+			expected: []argoApplicationStatus{webStatus},
+		},
+		{
+			name:     "filtered by namespace",
+			query:    "?namespace=api",
+			expected: []argoApplicationStatus{apiStatus},
+		},
+		{
+			name:     "argocd not installed",
+			missing:  true,
+			expected: []argoApplicationStatus{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), synced, pending)
+			if tc.missing {
+				dynamicClient.PrependReactor("list", "applications", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+				})
+			}
+
+			handler := newArgoStatusHandler(dynamicClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/gitops/status"+tc.query, nil)
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got argoStatusResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tc.missing, got.ArgoCDNotInstalled)
+			assert.ElementsMatch(t, tc.expected, got.Applications)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type ttlCacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// ttlCache is a concurrency safe cache whose entries expire after a fixed duration.
+// Expired entries are removed when they are read, when entries are added, and
+// by sweepEvery.
+type ttlCache struct {
+	ttl   time.Duration
+	nowFn func() time.Time
+
+	// maxEntries caps the number of entries when it is greater than zero. At
+	// the cap, adding an entry evicts the entry closest to expiring.
+	maxEntries int
+	// onEvict, when set, is called with each value removed from the cache
+	// other than by reset.
+	onEvict func(value interface{})
+
+	mu      sync.Mutex
+	entries map[string]ttlCacheEntry
+}
+
+func newTTLCache(ttl time.Duration) *ttlCache {
+	return &ttlCache{
+		ttl:     ttl,
+		nowFn:   time.Now,
+		entries: make(map[string]ttlCacheEntry),
+	}
+}
+
+// get returns the value for key if it exists and has not expired.
+func (c *ttlCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		c.mu.Unlock()
+		return nil, false
+	}
+
+	if c.nowFn().After(entry.expires) {
+		delete(c.entries, key)
+		c.mu.Unlock()
+		c.evicted(entry.value)
+		return nil, false
+	}
+
+	c.mu.Unlock()
+	return entry.value, true
+}
+
+// set stores value for key.
+func (c *ttlCache) set(key string, value interface{}) {
+	c.mu.Lock()
+
+	now := c.nowFn()
+	evicted := c.removeExpired(now)
+
+	if existing, ok := c.entries[key]; ok {
+		delete(c.entries, key)
+		evicted = append(evicted, existing.value)
+	}
+
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		var oldestKey string
+		var oldest time.Time
+		for k, entry := range c.entries {
+			if oldestKey == "" || entry.expires.Before(oldest) {
+				oldestKey, oldest = k, entry.expires
+			}
+		}
+		evicted = append(evicted, c.entries[oldestKey].value)
+		delete(c.entries, oldestKey)
+	}
+
+	c.entries[key] = ttlCacheEntry{
+		value:   value,
+		expires: now.Add(c.ttl),
+	}
+
+	c.mu.Unlock()
+	c.evicted(evicted...)
+}
+
+// sweep removes expired entries.
+func (c *ttlCache) sweep() {
+	c.mu.Lock()
+	evicted := c.removeExpired(c.nowFn())
+	c.mu.Unlock()
+
+	c.evicted(evicted...)
+}
+
+// sweepEvery sweeps the cache each interval until ctx is done, so entries
+// which are never read again are still removed.
+func (c *ttlCache) sweepEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.sweep()
+		}
+	}
+}
+
+// reset removes all entries.
+func (c *ttlCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]ttlCacheEntry)
+}
+
+// removeExpired deletes entries expired at now and returns their values. The
+// caller must hold mu.
+func (c *ttlCache) removeExpired(now time.Time) []interface{} {
+	var evicted []interface{}
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+			evicted = append(evicted, entry.value)
+		}
+	}
+
+	return evicted
+}
+
+// evicted calls onEvict for values removed from the cache. It is called
+// without holding mu so onEvict may use the cache.
+func (c *ttlCache) evicted(values ...interface{}) {
+	if c.onEvict == nil {
+		return
+	}
+
+	for _, value := range values {
+		c.onEvict(value)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ttlCache(t *testing.T) {
+	now := time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	c := newTTLCache(15 * time.Second)
+	c.nowFn = func() time.Time { return now }
+
+	_, ok := c.get("key")
+	assert.False(t, ok)
+
+	c.set("key", "value")
+
+	got, ok := c.get("key")
+	assert.True(t, ok)
+	assert.Equal(t, "value", got)
+
+	now = now.Add(16 * time.Second)
+	_, ok = c.get("key")
+	assert.False(t, ok)
+
+	c.set("key", "value")
+	c.reset()
+	_, ok = c.get("key")
+	assert.False(t, ok)
+}
+
+func Test_ttlCache_eviction(t *testing.T) {
+	now := time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	var evicted []interface{}
+
+	c := newTTLCache(15 * time.Second)
+	c.nowFn = func() time.Time { return now }
+	c.maxEntries = 2
+	c.onEvict = func(value interface{}) {
+		evicted = append(evicted, value)
+	}
+
+	c.set("a", "a")
+	now = now.Add(time.Second)
+	c.set("b", "b")
+	now = now.Add(time.Second)
+	c.set("c", "c")
+	assert.Equal(t, []interface{}{"a"}, evicted, "the entry closest to expiring makes room")
+
+	now = now.Add(15 * time.Second)
+	c.sweep()
+	assert.ElementsMatch(t, []interface{}{"a", "b"}, evicted, "expired entries are swept without being read")
+
+	_, ok := c.get("c")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	c.sweep()
+	assert.ElementsMatch(t, []interface{}{"a", "b", "c"}, evicted)
+	assert.Empty(t, c.entries)
+}
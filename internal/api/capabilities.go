@@ -0,0 +1,71 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/vmware/octant/internal/log"
+	"github.com/vmware/octant/internal/module"
+)
+
+type capabilitiesResponse struct {
+	Name             string   `json:"name"`
+	SupportsSearch   bool     `json:"supportsSearch"`
+	SupportsExport   bool     `json:"supportsExport"`
+	SupportsEdit     bool     `json:"supportsEdit"`
+	SupportedActions []string `json:"supportedActions"`
+}
+
+type capabilitiesHandler struct {
+	modules []module.Module
+	logger  log.Logger
+}
+
+var _ http.Handler = (*capabilitiesHandler)(nil)
+
+func newCapabilitiesHandler(modules []module.Module, logger log.Logger) *capabilitiesHandler {
+	return &capabilitiesHandler{
+		modules: modules,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the capabilities of a module.
+// Modules which do not implement module.CapabilitiesProvider report no capabilities.
+func (h *capabilitiesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	for _, m := range h.modules {
+		if m.Name() != name {
+			continue
+		}
+
+		resp := capabilitiesResponse{
+			Name:             name,
+			SupportedActions: []string{},
+		}
+
+		if provider, ok := m.(module.CapabilitiesProvider); ok {
+			if capabilities := provider.Capabilities(); capabilities != nil {
+				resp.SupportsSearch = capabilities.SupportsSearch()
+				resp.SupportsExport = capabilities.SupportsExport()
+				resp.SupportsEdit = capabilities.SupportsEdit()
+				if actions := capabilities.SupportedActions(); actions != nil {
+					resp.SupportedActions = actions
+				}
+			}
+		}
+
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	RespondWithError(w, http.StatusNotFound, fmt.Sprintf("module %q not found", name), h.logger)
+}
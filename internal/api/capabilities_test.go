@@ -0,0 +1,101 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/octant/internal/log"
+	"github.com/vmware/octant/internal/module"
+)
+
+type stubModule struct {
+	module.Module
+	name string
+}
+
+func (m *stubModule) Name() string { return m.name }
+
+type stubCapabilities struct{}
+
+func (stubCapabilities) SupportsSearch() bool       { return true }
+func (stubCapabilities) SupportsExport() bool       { return false }
+func (stubCapabilities) SupportsEdit() bool         { return true }
+func (stubCapabilities) SupportedActions() []string { return []string{"deployment/configuration"} }
+
+type capableModule struct {
+	stubModule
+}
+
+func (m *capableModule) Capabilities() module.Capabilities { return stubCapabilities{} }
+
+func Test_capabilitiesHandler(t *testing.T) {
+	modules := []module.Module{
+		&stubModule{name: "plain"},
+		&capableModule{stubModule{name: "capable"}},
+	}
+
+	tests := []struct {
+		name         string
+		module       string
+		expectedCode int
+		expected     capabilitiesResponse
+	}{
+		{
+			name:         "module with capabilities",
+			module:       "capable",
+			expectedCode: http.StatusOK,
+			expected: capabilitiesResponse{
+				Name:             "capable",
+				SupportsSearch:   true,
+				SupportsEdit:     true,
+				SupportedActions: []string{"deployment/configuration"},
+			},
+		},
+		{
+			name:         "module without capabilities",
+			module:       "plain",
+			expectedCode: http.StatusOK,
+			expected: capabilitiesResponse{
+				Name:             "plain",
+				SupportedActions: []string{},
+			},
+		},
+		{
+			name:         "unknown module",
+			module:       "missing",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newCapabilitiesHandler(modules, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/plugins/"+tc.module+"/capabilities", nil)
+			req = mux.SetURLVars(req, map[string]string{"name": tc.module})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var got capabilitiesResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
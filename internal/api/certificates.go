@@ -0,0 +1,137 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// certificateExpiringDays is the number of days before expiry when a
+	// certificate is counted as expiring.
+	certificateExpiringDays = 30
+)
+
+var certificateGVR = schema.GroupVersionResource{
+	Group:    "cert-manager.io",
+	Version:  "v1",
+	Resource: "certificates",
+}
+
+type certificateIssuerRef struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind,omitempty"`
+	Group string `json:"group,omitempty"`
+}
+
+type certificateStatus struct {
+	Name            string               `json:"name"`
+	Ready           statusCondition      `json:"ready"`
+	NotBefore       string               `json:"notBefore,omitempty"`
+	NotAfter        string               `json:"notAfter,omitempty"`
+	DaysUntilExpiry *int                 `json:"daysUntilExpiry,omitempty"`
+	IssuerRef       certificateIssuerRef `json:"issuerRef"`
+	DNSNames        []string             `json:"dnsNames"`
+}
+
+type certificatesResponse struct {
+	CertManagerNotInstalled bool                `json:"certManagerNotInstalled"`
+	ExpiringCount           int                 `json:"expiringCount"`
+	FailedCount             int                 `json:"failedCount"`
+	Certificates            []certificateStatus `json:"certificates"`
+}
+
+type certificatesHandler struct {
+	dynamicClient dynamic.Interface
+	nowFn         func() time.Time
+	logger        log.Logger
+}
+
+var _ http.Handler = (*certificatesHandler)(nil)
+
+func newCertificatesHandler(dynamicClient dynamic.Interface, logger log.Logger) *certificatesHandler {
+	return &certificatesHandler{
+		dynamicClient: dynamicClient,
+		nowFn:         time.Now,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the status of cert-manager
+// certificates in a namespace. Certificates which are not ready count as
+// failed, and certificates expiring within 30 days count as expiring.
+func (h *certificatesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	certificates, installed, err := listOptionalResource(h.dynamicClient, certificateGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := certificatesResponse{
+		CertManagerNotInstalled: !installed,
+		Certificates:            []certificateStatus{},
+	}
+
+	for _, certificate := range certificates.Items {
+		status := h.newCertificateStatus(certificate)
+
+		if status.Ready.Status == string(corev1.ConditionFalse) {
+			resp.FailedCount++
+		}
+		if status.DaysUntilExpiry != nil && *status.DaysUntilExpiry <= certificateExpiringDays {
+			resp.ExpiringCount++
+		}
+
+		resp.Certificates = append(resp.Certificates, status)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func (h *certificatesHandler) newCertificateStatus(certificate unstructured.Unstructured) certificateStatus {
+	ready, _ := findCondition(certificate.Object, "Ready")
+	notBefore, _, _ := unstructured.NestedString(certificate.Object, "status", "notBefore")
+	notAfter, _, _ := unstructured.NestedString(certificate.Object, "status", "notAfter")
+	issuerRef, _, _ := unstructured.NestedStringMap(certificate.Object, "spec", "issuerRef")
+
+	dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	if dnsNames == nil {
+		dnsNames = []string{}
+	}
+
+	status := certificateStatus{
+		Name:      certificate.GetName(),
+		Ready:     ready,
+		NotBefore: notBefore,
+		NotAfter:  notAfter,
+		IssuerRef: certificateIssuerRef{
+			Name:  issuerRef["name"],
+			Kind:  issuerRef["kind"],
+			Group: issuerRef["group"],
+		},
+		DNSNames: dnsNames,
+	}
+
+	if expiry, err := time.Parse(time.RFC3339, notAfter); err == nil {
+		days := int(math.Floor(expiry.Sub(h.nowFn()).Hours() / 24))
+		status.DaysUntilExpiry = &days
+	}
+
+	return status
+}
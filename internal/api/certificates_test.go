@@ -0,0 +1,121 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_certificatesHandler(t *testing.T) {
+	newCertificate := func(name, ready, notAfter string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "Certificate",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"spec": map[string]interface{}{
+				"dnsNames":  []interface{}{name + ".example.com"},
+				"issuerRef": map[string]interface{}{"name": "letsencrypt", "kind": "ClusterIssuer"},
+			},
+			"status": map[string]interface{}{
+				"notBefore": "2019-09-01T00:00:00Z",
+				"notAfter":  notAfter,
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": ready},
+				},
+			},
+		}}
+	}
+
+	now := time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		missing  bool
+		expected certificatesResponse
+	}{
+		{
+			name: "cert-manager installed",
+			expected: certificatesResponse{
+				ExpiringCount: 1,
+				FailedCount:   1,
+			},
+		},
+		{
+			name:    "cert-manager not installed",
+			missing: true,
+			expected: certificatesResponse{
+				CertManagerNotInstalled: true,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+				newCertificate("web", "True", "2019-10-11T00:00:00Z"),
+				newCertificate("api", "False", "2019-12-30T00:00:00Z"),
+			)
+			if tc.missing {
+				dynamicClient.PrependReactor("list", "certificates", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+				})
+			}
+
+			handler := newCertificatesHandler(dynamicClient, log.NopLogger())
+			handler.nowFn = func() time.Time { return now }
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/certificates/default", nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got certificatesResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+			assert.Equal(t, tc.expected.CertManagerNotInstalled, got.CertManagerNotInstalled)
+			assert.Equal(t, tc.expected.ExpiringCount, got.ExpiringCount)
+			assert.Equal(t, tc.expected.FailedCount, got.FailedCount)
+
+			if tc.missing {
+				assert.Empty(t, got.Certificates)
+				return
+			}
+
+			byName := make(map[string]certificateStatus)
+			for _, certificate := range got.Certificates {
+				byName[certificate.Name] = certificate
+			}
+
+			web := byName["web"]
+			require.NotNil(t, web.DaysUntilExpiry)
+			assert.Equal(t, 10, *web.DaysUntilExpiry)
+			assert.Equal(t, "True", web.Ready.Status)
+			assert.Equal(t, certificateIssuerRef{Name: "letsencrypt", Kind: "ClusterIssuer"}, web.IssuerRef)
+			assert.Equal(t, []string{"web.example.com"}, web.DNSNames)
+
+			api := byName["api"]
+			require.NotNil(t, api.DaysUntilExpiry)
+			assert.Equal(t, 90, *api.DaysUntilExpiry)
+		})
+	}
+}
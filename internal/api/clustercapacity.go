@@ -0,0 +1,188 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type clusterUtilization struct {
+	CPU    float64 `json:"cpu"`
+	Memory float64 `json:"memory"`
+}
+
+type clusterCapacityResponse struct {
+	ReadyNodes             int                `json:"readyNodes"`
+	TotalAllocatableCPU    resource.Quantity  `json:"totalAllocatableCPU"`
+	TotalAllocatableMemory resource.Quantity  `json:"totalAllocatableMemory"`
+	RequestedCPU           resource.Quantity  `json:"requestedCPU"`
+	RequestedMemory        resource.Quantity  `json:"requestedMemory"`
+	FreeCapacityCPU        resource.Quantity  `json:"freeCapacityCPU"`
+	FreeCapacityMemory     resource.Quantity  `json:"freeCapacityMemory"`
+	UtilizationPercent     clusterUtilization `json:"utilizationPercent"`
+}
+
+type clusterCapacityHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*clusterCapacityHandler)(nil)
+
+func newClusterCapacityHandler(kubeClient kubernetes.Interface, logger log.Logger) *clusterCapacityHandler {
+	return &clusterCapacityHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the CPU and memory
+// allocatable on the cluster's ready nodes, how much of it is requested by the
+// pods running on those nodes, and how much is free. Pods which have finished
+// no longer hold their requests and are not counted.
+func (h *clusterCapacityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	nodes, err := h.kubeClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	pods, err := h.listPods()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	allocatableCPU := resource.NewMilliQuantity(0, resource.DecimalSI)
+	allocatableMemory := resource.NewQuantity(0, resource.BinarySI)
+	requestedCPU := resource.NewMilliQuantity(0, resource.DecimalSI)
+	requestedMemory := resource.NewQuantity(0, resource.BinarySI)
+
+	resp := clusterCapacityResponse{}
+
+	ready := make(map[string]bool)
+	for _, node := range nodes.Items {
+		if !isNodeReady(node) {
+			continue
+		}
+		ready[node.Name] = true
+		resp.ReadyNodes++
+
+		allocatableCPU.Add(node.Status.Allocatable[corev1.ResourceCPU])
+		allocatableMemory.Add(node.Status.Allocatable[corev1.ResourceMemory])
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		if !ready[pod.Spec.NodeName] || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		requests := podResourceRequests(pod)
+		requestedCPU.Add(requests[corev1.ResourceCPU])
+		requestedMemory.Add(requests[corev1.ResourceMemory])
+	}
+
+	resp.TotalAllocatableCPU = *allocatableCPU
+	resp.TotalAllocatableMemory = *allocatableMemory
+	resp.RequestedCPU = *requestedCPU
+	resp.RequestedMemory = *requestedMemory
+	resp.FreeCapacityCPU = freeCapacity(*allocatableCPU, *requestedCPU)
+	resp.FreeCapacityMemory = freeCapacity(*allocatableMemory, *requestedMemory)
+	resp.UtilizationPercent = clusterUtilization{
+		CPU:    utilizationPercent(requestedCPU.MilliValue(), allocatableCPU.MilliValue()),
+		Memory: utilizationPercent(requestedMemory.Value(), allocatableMemory.Value()),
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// listPods lists pods in all namespaces a page at a time.
+func (h *clusterCapacityHandler) listPods() ([]corev1.Pod, error) {
+	var pods []corev1.Pod
+
+	options := metav1.ListOptions{Limit: resourceListPageSize}
+	for {
+		list, err := h.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(options)
+		if err != nil {
+			return nil, errors.Wrap(err, "list pods")
+		}
+
+		pods = append(pods, list.Items...)
+
+		options.Continue = list.Continue
+		if options.Continue == "" {
+			return pods, nil
+		}
+	}
+}
+
+func isNodeReady(node corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// podResourceRequests returns the resources the scheduler reserves for a pod:
+// the sum of its containers' requests or, if larger, the largest init
+// container request.
+func podResourceRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			total := requests[name]
+			total.Add(quantity)
+			requests[name] = total
+		}
+	}
+
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if current, ok := requests[name]; !ok || quantity.Cmp(current) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+
+	return requests
+}
+
+// freeCapacity returns allocatable less requested, or zero when the requests
+// exceed it.
+func freeCapacity(allocatable, requested resource.Quantity) resource.Quantity {
+	free := allocatable.DeepCopy()
+	free.Sub(requested)
+
+	if free.Sign() < 0 {
+		return *resource.NewQuantity(0, allocatable.Format)
+	}
+
+	return free
+}
+
+// utilizationPercent returns requested as a percentage of allocatable,
+// rounded to one decimal place.
+func utilizationPercent(requested, allocatable int64) float64 {
+	if allocatable == 0 {
+		return 0
+	}
+
+	return math.Round(float64(requested)*1000/float64(allocatable)) / 10
+}
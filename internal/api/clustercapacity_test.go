@@ -0,0 +1,104 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_clusterCapacityHandler(t *testing.T) {
+	newNode := func(name string, ready corev1.ConditionStatus, cpu, memory string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+			},
+		}
+	}
+
+	requests := func(cpu, memory string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		}
+	}
+
+	newPod := func(name, namespace, node string, phase corev1.PodPhase, init corev1.ResourceRequirements, containers ...corev1.ResourceRequirements) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.PodSpec{
+				NodeName:       node,
+				InitContainers: []corev1.Container{{Name: "init", Resources: init}},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+		for _, resources := range containers {
+			pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: "app", Resources: resources})
+		}
+		return pod
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newNode("node-1", corev1.ConditionTrue, "4", "8Gi"),
+		newNode("node-2", corev1.ConditionTrue, "4", "8Gi"),
+		newNode("node-3", corev1.ConditionFalse, "4", "8Gi"),
+		newPod("web", "default", "node-1", corev1.PodRunning, corev1.ResourceRequirements{},
+			requests("500m", "1Gi"), requests("500m", "1Gi")),
+		newPod("migrate", "apps", "node-2", corev1.PodRunning, requests("2", "1Gi"), requests("1", "2Gi")),
+		newPod("done", "apps", "node-2", corev1.PodSucceeded, corev1.ResourceRequirements{}, requests("1", "1Gi")),
+		newPod("unready-node", "apps", "node-3", corev1.PodRunning, corev1.ResourceRequirements{}, requests("1", "1Gi")),
+	)
+
+	handler := newClusterCapacityHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/cluster/capacity", nil)
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got clusterCapacityResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	assert.Equal(t, 2, got.ReadyNodes)
+
+	quantities := map[string]string{
+		"totalAllocatableCPU":    got.TotalAllocatableCPU.String(),
+		"totalAllocatableMemory": got.TotalAllocatableMemory.String(),
+		"requestedCPU":           got.RequestedCPU.String(),
+		"requestedMemory":        got.RequestedMemory.String(),
+		"freeCapacityCPU":        got.FreeCapacityCPU.String(),
+		"freeCapacityMemory":     got.FreeCapacityMemory.String(),
+	}
+	assert.Equal(t, map[string]string{
+		"totalAllocatableCPU":    "8",
+		"totalAllocatableMemory": "16Gi",
+		"requestedCPU":           "3",
+		"requestedMemory":        "4Gi",
+		"freeCapacityCPU":        "5",
+		"freeCapacityMemory":     "12Gi",
+	}, quantities)
+
+	assert.Equal(t, clusterUtilization{CPU: 37.5, Memory: 25}, got.UtilizationPercent)
+}
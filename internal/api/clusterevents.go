@@ -0,0 +1,263 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	defaultClusterEventsLimit = 100
+	maxClusterEventsLimit     = 500
+)
+
+type clusterEventObject struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+type clusterEvent struct {
+	UID            types.UID          `json:"uid"`
+	InvolvedObject clusterEventObject `json:"involvedObject"`
+	Type           string             `json:"type"`
+	Reason         string             `json:"reason"`
+	Message        string             `json:"message"`
+	Count          int32              `json:"count"`
+	FirstTimestamp time.Time          `json:"firstTimestamp"`
+	LastTimestamp  time.Time          `json:"lastTimestamp"`
+}
+
+type clusterEventsResponse struct {
+	Events     []clusterEvent `json:"events"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+}
+
+// clusterEventsCursor is the position of the last event in a page. The UID
+// orders events with the same timestamp.
+type clusterEventsCursor struct {
+	Timestamp time.Time `json:"t"`
+	UID       types.UID `json:"uid"`
+}
+
+type clusterEventsHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*clusterEventsHandler)(nil)
+
+func newClusterEventsHandler(kubeClient kubernetes.Interface, logger log.Logger) *clusterEventsHandler {
+	return &clusterEventsHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns events from all namespaces,
+// most recent first. Events with the same reason, involved object and message
+// are merged and their counts summed. `minWarningLevel=Warning` returns only
+// warnings. Results are paged with `limit` and the `cursor` returned from the
+// previous page.
+func (h *clusterEventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	warningsOnly := false
+	switch level := query.Get("minWarningLevel"); level {
+	case "", corev1.EventTypeNormal:
+	case corev1.EventTypeWarning:
+		warningsOnly = true
+	default:
+		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("minWarningLevel must be %s or %s", corev1.EventTypeNormal, corev1.EventTypeWarning), h.logger)
+		return
+	}
+
+	limit := defaultClusterEventsLimit
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			RespondWithError(w, http.StatusBadRequest, "limit must be a positive integer", h.logger)
+			return
+		}
+		if n > maxClusterEventsLimit {
+			n = maxClusterEventsLimit
+		}
+		limit = n
+	}
+
+	var cursor *clusterEventsCursor
+	if s := query.Get("cursor"); s != "" {
+		c, err := decodeClusterEventsCursor(s)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error(), h.logger)
+			return
+		}
+		cursor = c
+	}
+
+	events, err := listEventsPaged(h.kubeClient, metav1.NamespaceAll)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	var merged []clusterEvent
+	for _, event := range dedupeClusterEvents(events, warningsOnly) {
+		if cursor != nil && !clusterEventLess(clusterEvent{LastTimestamp: cursor.Timestamp, UID: cursor.UID}, event) {
+			continue
+		}
+		merged = append(merged, event)
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return clusterEventLess(merged[i], merged[j])
+	})
+
+	resp := clusterEventsResponse{
+		Events: []clusterEvent{},
+	}
+
+	if len(merged) > limit {
+		merged = merged[:limit]
+		last := merged[limit-1]
+
+		next, err := encodeClusterEventsCursor(clusterEventsCursor{Timestamp: last.LastTimestamp, UID: last.UID})
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+		resp.NextCursor = next
+	}
+
+	resp.Events = append(resp.Events, merged...)
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// dedupeClusterEvents merges events with the same reason, involved object and
+// message. A merged event takes the UID and type of its most recent event.
+func dedupeClusterEvents(events []corev1.Event, warningsOnly bool) []clusterEvent {
+	type eventKey struct {
+		reason    string
+		object    corev1.ObjectReference
+		message   string
+		namespace string
+	}
+
+	merged := make(map[eventKey]*clusterEvent)
+	var keys []eventKey
+
+	for _, event := range events {
+		if warningsOnly && event.Type != corev1.EventTypeWarning {
+			continue
+		}
+
+		timestamp := eventTimestamp(event).UTC()
+		first := event.FirstTimestamp.Time.UTC()
+		if event.FirstTimestamp.IsZero() {
+			first = timestamp
+		}
+
+		count := event.Count
+		if count == 0 {
+			count = 1
+		}
+
+		key := eventKey{
+			reason: event.Reason,
+			object: corev1.ObjectReference{
+				Kind:      event.InvolvedObject.Kind,
+				Namespace: event.InvolvedObject.Namespace,
+				Name:      event.InvolvedObject.Name,
+				UID:       event.InvolvedObject.UID,
+			},
+			message:   event.Message,
+			namespace: event.Namespace,
+		}
+
+		existing, ok := merged[key]
+		if !ok {
+			merged[key] = &clusterEvent{
+				UID: event.UID,
+				InvolvedObject: clusterEventObject{
+					Kind:      event.InvolvedObject.Kind,
+					Namespace: event.InvolvedObject.Namespace,
+					Name:      event.InvolvedObject.Name,
+				},
+				Type:           event.Type,
+				Reason:         event.Reason,
+				Message:        event.Message,
+				Count:          count,
+				FirstTimestamp: first,
+				LastTimestamp:  timestamp,
+			}
+			keys = append(keys, key)
+			continue
+		}
+
+		existing.Count += count
+		if first.Before(existing.FirstTimestamp) {
+			existing.FirstTimestamp = first
+		}
+		if timestamp.After(existing.LastTimestamp) {
+			existing.LastTimestamp = timestamp
+			existing.UID = event.UID
+			existing.Type = event.Type
+		}
+	}
+
+	list := make([]clusterEvent, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, *merged[key])
+	}
+
+	return list
+}
+
+// clusterEventLess orders events most recent first.
+func clusterEventLess(a, b clusterEvent) bool {
+	if !a.LastTimestamp.Equal(b.LastTimestamp) {
+		return a.LastTimestamp.After(b.LastTimestamp)
+	}
+	return a.UID < b.UID
+}
+
+func encodeClusterEventsCursor(cursor clusterEventsCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeClusterEventsCursor(s string) (*clusterEventsCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var cursor clusterEventsCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.UID == "" {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &cursor, nil
+}
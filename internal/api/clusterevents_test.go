@@ -0,0 +1,142 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_clusterEventsHandler(t *testing.T) {
+	base := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	newEvent := func(namespace, name, uid, eventType, reason, object, message string, at time.Duration, count int32) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, UID: types.UID(uid)},
+			InvolvedObject: corev1.ObjectReference{
+				Kind:      "Pod",
+				Namespace: namespace,
+				Name:      object,
+			},
+			Type:           eventType,
+			Reason:         reason,
+			Message:        message,
+			Count:          count,
+			FirstTimestamp: metav1.NewTime(base.Add(at)),
+			LastTimestamp:  metav1.NewTime(base.Add(at)),
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newEvent("default", "web.1", "uid-1", corev1.EventTypeWarning, "BackOff", "web", "Back-off restarting failed container", time.Minute, 3),
+		newEvent("default", "web.2", "uid-2", corev1.EventTypeWarning, "BackOff", "web", "Back-off restarting failed container", 5*time.Minute, 2),
+		newEvent("default", "web.3", "uid-3", corev1.EventTypeNormal, "Pulled", "web", "Container image pulled", 2*time.Minute, 1),
+		newEvent("kube-system", "dns.1", "uid-4", corev1.EventTypeWarning, "Unhealthy", "dns", "Readiness probe failed", 3*time.Minute, 0),
+		newEvent("other", "web.1", "uid-5", corev1.EventTypeWarning, "BackOff", "web", "Back-off restarting failed container", 4*time.Minute, 1),
+	)
+
+	backOff := clusterEvent{
+		UID:            "uid-2",
+		InvolvedObject: clusterEventObject{Kind: "Pod", Namespace: "default", Name: "web"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+		Count:          5,
+		FirstTimestamp: base.Add(time.Minute),
+		LastTimestamp:  base.Add(5 * time.Minute),
+	}
+	otherBackOff := clusterEvent{
+		UID:            "uid-5",
+		InvolvedObject: clusterEventObject{Kind: "Pod", Namespace: "other", Name: "web"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+		Count:          1,
+		FirstTimestamp: base.Add(4 * time.Minute),
+		LastTimestamp:  base.Add(4 * time.Minute),
+	}
+	unhealthy := clusterEvent{
+		UID:            "uid-4",
+		InvolvedObject: clusterEventObject{Kind: "Pod", Namespace: "kube-system", Name: "dns"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "Unhealthy",
+		Message:        "Readiness probe failed",
+		Count:          1,
+		FirstTimestamp: base.Add(3 * time.Minute),
+		LastTimestamp:  base.Add(3 * time.Minute),
+	}
+	pulled := clusterEvent{
+		UID:            "uid-3",
+		InvolvedObject: clusterEventObject{Kind: "Pod", Namespace: "default", Name: "web"},
+		Type:           corev1.EventTypeNormal,
+		Reason:         "Pulled",
+		Message:        "Container image pulled",
+		Count:          1,
+		FirstTimestamp: base.Add(2 * time.Minute),
+		LastTimestamp:  base.Add(2 * time.Minute),
+	}
+
+	get := func(t *testing.T, query string) (int, clusterEventsResponse) {
+		handler := newClusterEventsHandler(kubeClient, log.NopLogger())
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/clusterevents"+query, nil)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		var got clusterEventsResponse
+		if resp.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		}
+		return resp.Code, got
+	}
+
+	t.Run("deduplicated events", func(t *testing.T) {
+		code, got := get(t, "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []clusterEvent{backOff, otherBackOff, unhealthy, pulled}, got.Events)
+		assert.Empty(t, got.NextCursor)
+	})
+
+	t.Run("warnings only", func(t *testing.T) {
+		code, got := get(t, "?minWarningLevel=Warning")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []clusterEvent{backOff, otherBackOff, unhealthy}, got.Events)
+	})
+
+	t.Run("paginated", func(t *testing.T) {
+		code, first := get(t, "?limit=3")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []clusterEvent{backOff, otherBackOff, unhealthy}, first.Events)
+		require.NotEmpty(t, first.NextCursor)
+
+		code, second := get(t, "?limit=3&cursor="+first.NextCursor)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []clusterEvent{pulled}, second.Events)
+		assert.Empty(t, second.NextCursor)
+	})
+
+	t.Run("invalid warning level", func(t *testing.T) {
+		code, _ := get(t, "?minWarningLevel=Error")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		code, _ := get(t, "?cursor=invalid")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
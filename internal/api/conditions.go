@@ -0,0 +1,186 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type unhealthyCondition struct {
+	Kind               string      `json:"kind"`
+	Name               string      `json:"name"`
+	Type               string      `json:"type"`
+	Status             string      `json:"status"`
+	Reason             string      `json:"reason,omitempty"`
+	Message            string      `json:"message,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+}
+
+type conditionsResponse struct {
+	Conditions []unhealthyCondition `json:"conditions"`
+}
+
+type conditionsHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*conditionsHandler)(nil)
+
+func newConditionsHandler(kubeClient kubernetes.Interface, logger log.Logger) *conditionsHandler {
+	return &conditionsHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the unhealthy conditions of the
+// pods, deployments and stateful sets in a namespace, and of the nodes its pods
+// run on, oldest transition first. The `types` query parameter limits the
+// results to a comma separated list of condition types.
+func (h *conditionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	types := make(map[string]bool)
+	if s := r.URL.Query().Get("types"); s != "" {
+		for _, conditionType := range strings.Split(s, ",") {
+			types[conditionType] = true
+		}
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	deployments, err := h.kubeClient.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	statefulSets, err := h.kubeClient.AppsV1().StatefulSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	nodes, err := h.kubeClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := conditionsResponse{
+		Conditions: []unhealthyCondition{},
+	}
+
+	add := func(c unhealthyCondition) {
+		if len(types) > 0 && !types[c.Type] {
+			return
+		}
+		if c.Status == conditionHealthyStatus(c.Kind, c.Type) {
+			return
+		}
+		resp.Conditions = append(resp.Conditions, c)
+	}
+
+	scheduledNodes := make(map[string]bool)
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != "" {
+			scheduledNodes[pod.Spec.NodeName] = true
+		}
+
+		for _, c := range pod.Status.Conditions {
+			add(unhealthyCondition{
+				Kind:               "Pod",
+				Name:               pod.Name,
+				Type:               string(c.Type),
+				Status:             string(c.Status),
+				Reason:             c.Reason,
+				Message:            c.Message,
+				LastTransitionTime: c.LastTransitionTime,
+			})
+		}
+	}
+
+	for _, deployment := range deployments.Items {
+		for _, c := range deployment.Status.Conditions {
+			add(unhealthyCondition{
+				Kind:               "Deployment",
+				Name:               deployment.Name,
+				Type:               string(c.Type),
+				Status:             string(c.Status),
+				Reason:             c.Reason,
+				Message:            c.Message,
+				LastTransitionTime: c.LastTransitionTime,
+			})
+		}
+	}
+
+	for _, statefulSet := range statefulSets.Items {
+		for _, c := range statefulSet.Status.Conditions {
+			add(unhealthyCondition{
+				Kind:               "StatefulSet",
+				Name:               statefulSet.Name,
+				Type:               string(c.Type),
+				Status:             string(c.Status),
+				Reason:             c.Reason,
+				Message:            c.Message,
+				LastTransitionTime: c.LastTransitionTime,
+			})
+		}
+	}
+
+	for _, node := range nodes.Items {
+		if !scheduledNodes[node.Name] {
+			continue
+		}
+
+		for _, c := range node.Status.Conditions {
+			add(unhealthyCondition{
+				Kind:               "Node",
+				Name:               node.Name,
+				Type:               string(c.Type),
+				Status:             string(c.Status),
+				Reason:             c.Reason,
+				Message:            c.Message,
+				LastTransitionTime: c.LastTransitionTime,
+			})
+		}
+	}
+
+	sort.SliceStable(resp.Conditions, func(i, j int) bool {
+		return resp.Conditions[i].LastTransitionTime.Before(&resp.Conditions[j].LastTransitionTime)
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// conditionHealthyStatus returns the status of a condition when the resource is
+// healthy. Most conditions are healthy when True, but node pressure conditions
+// and deployment replica failures report problems when True.
+func conditionHealthyStatus(kind, conditionType string) string {
+	switch {
+	case kind == "Node" && conditionType != string(corev1.NodeReady):
+		return string(corev1.ConditionFalse)
+	case kind == "Deployment" && conditionType == string(appsv1.DeploymentReplicaFailure):
+		return string(corev1.ConditionFalse)
+	default:
+		return string(corev1.ConditionTrue)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_conditionsHandler(t *testing.T) {
+	base := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) metav1.Time {
+		return metav1.NewTime(base.Add(time.Duration(minutes) * time.Minute))
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: "node-1"},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: at(0)},
+					{Type: corev1.PodReady, Status: corev1.ConditionFalse, Reason: "ContainersNotReady", Message: "containers with unready status: [app]", LastTransitionTime: at(10)},
+				},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Status: appsv1.DeploymentStatus{
+				Conditions: []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionFalse, Reason: "MinimumReplicasUnavailable", LastTransitionTime: at(5)},
+					{Type: appsv1.DeploymentReplicaFailure, Status: corev1.ConditionTrue, Reason: "FailedCreate", LastTransitionTime: at(2)},
+				},
+			},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+			Status: appsv1.StatefulSetStatus{
+				Conditions: []appsv1.StatefulSetCondition{
+					{Type: "Progressing", Status: corev1.ConditionUnknown, LastTransitionTime: at(1)},
+				},
+			},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionTrue, LastTransitionTime: at(0)},
+					{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionTrue, Reason: "KubeletHasInsufficientMemory", LastTransitionTime: at(3)},
+					{Type: corev1.NodeDiskPressure, Status: corev1.ConditionFalse, LastTransitionTime: at(0)},
+				},
+			},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionFalse, LastTransitionTime: at(4)},
+				},
+			},
+		},
+	)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []unhealthyCondition
+	}{
+		{
+			name: "all conditions",
+			expected: []unhealthyCondition{
+				{Kind: "StatefulSet", Name: "db", Type: "Progressing", Status: "Unknown", LastTransitionTime: at(1)},
+				{Kind: "Deployment", Name: "web", Type: "ReplicaFailure", Status: "True", Reason: "FailedCreate", LastTransitionTime: at(2)},
+				{Kind: "Node", Name: "node-1", Type: "MemoryPressure", Status: "True", Reason: "KubeletHasInsufficientMemory", LastTransitionTime: at(3)},
+				{Kind: "Deployment", Name: "web", Type: "Available", Status: "False", Reason: "MinimumReplicasUnavailable", LastTransitionTime: at(5)},
+				{Kind: "Pod", Name: "web", Type: "Ready", Status: "False", Reason: "ContainersNotReady", Message: "containers with unready status: [app]", LastTransitionTime: at(10)},
+			},
+		},
+		{
+			name:  "filter by type",
+			query: "?types=Ready,Available",
+			expected: []unhealthyCondition{
+				{Kind: "Deployment", Name: "web", Type: "Available", Status: "False", Reason: "MinimumReplicasUnavailable", LastTransitionTime: at(5)},
+				{Kind: "Pod", Name: "web", Type: "Ready", Status: "False", Reason: "ContainersNotReady", Message: "containers with unready status: [app]", LastTransitionTime: at(10)},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newConditionsHandler(kubeClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/conditions/default"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got conditionsResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			require.Len(t, got.Conditions, len(tc.expected))
+			for i := range tc.expected {
+				assert.True(t, tc.expected[i].LastTransitionTime.Equal(&got.Conditions[i].LastTransitionTime))
+				got.Conditions[i].LastTransitionTime = tc.expected[i].LastTransitionTime
+			}
+			assert.Equal(t, tc.expected, got.Conditions)
+		})
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	trivySeverityCritical = "CRITICAL"
+	trivySeverityHigh     = "HIGH"
+	trivySeverityMedium   = "MEDIUM"
+	trivySeverityLow      = "LOW"
+	trivySeverityUnknown  = "UNKNOWN"
+
+	// trivyStatusFail marks a check which the resource did not pass.
+	trivyStatusFail = "FAIL"
+)
+
+// trivySeverities is the order finding groups are returned in.
+var trivySeverities = []string{
+	trivySeverityCritical,
+	trivySeverityHigh,
+	trivySeverityMedium,
+	trivySeverityLow,
+	trivySeverityUnknown,
+}
+
+// trivyMisconfiguration is the subset of a Trivy misconfiguration result used
+// by the config audit.
+type trivyMisconfiguration struct {
+	ID          string `json:"ID"`
+	Title       string `json:"Title"`
+	Description string `json:"Description"`
+	Resolution  string `json:"Resolution"`
+	Severity    string `json:"Severity"`
+	Status      string `json:"Status"`
+}
+
+type trivyConfigAuditResult struct {
+	Misconfigurations []trivyMisconfiguration `json:"Misconfigurations"`
+}
+
+type configAuditResource struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type configAuditFinding struct {
+	Severity    string              `json:"severity"`
+	CheckID     string              `json:"checkID"`
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Remediation string              `json:"remediation"`
+	Resource    configAuditResource `json:"resource"`
+}
+
+type configAuditGroup struct {
+	Severity string               `json:"severity"`
+	Count    int                  `json:"count"`
+	Findings []configAuditFinding `json:"findings"`
+}
+
+type configAuditResponse struct {
+	CriticalCount int                `json:"criticalCount"`
+	HighCount     int                `json:"highCount"`
+	Groups        []configAuditGroup `json:"groups"`
+}
+
+type configAuditHandler struct {
+	kubeClient kubernetes.Interface
+	trivyURL   string
+	httpClient *http.Client
+	logger     log.Logger
+}
+
+var _ http.Handler = (*configAuditHandler)(nil)
+
+func newConfigAuditHandler(kubeClient kubernetes.Interface, trivyURL string, logger log.Logger) *configAuditHandler {
+	return &configAuditHandler{
+		kubeClient: kubeClient,
+		trivyURL:   strings.TrimSuffix(trivyURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and audits the deployments and pods in a
+// namespace with a Trivy server. Each manifest is posted to the server's
+// `/config-audit` endpoint, which returns Trivy's misconfiguration results.
+// Pods managed by a controller are skipped because their owner is audited
+// instead. Failed checks are grouped by severity.
+func (h *configAuditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp := configAuditResponse{
+		Groups: []configAuditGroup{},
+	}
+
+	if h.trivyURL == "" {
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	namespace := mux.Vars(r)["namespace"]
+
+	deployments, err := h.kubeClient.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	var findings []configAuditFinding
+
+	for i := range deployments.Items {
+		deployment := deployments.Items[i]
+		deployment.APIVersion = "apps/v1"
+		deployment.Kind = "Deployment"
+
+		found, err := h.audit(configAuditResource{Kind: deployment.Kind, Name: deployment.Name}, &deployment)
+		if err != nil {
+			RespondWithError(w, http.StatusBadGateway, err.Error(), h.logger)
+			return
+		}
+		findings = append(findings, found...)
+	}
+
+	for i := range pods.Items {
+		pod := pods.Items[i]
+		if metav1.GetControllerOf(&pod) != nil {
+			continue
+		}
+		pod.APIVersion = "v1"
+		pod.Kind = "Pod"
+
+		found, err := h.audit(configAuditResource{Kind: pod.Kind, Name: pod.Name}, &pod)
+		if err != nil {
+			RespondWithError(w, http.StatusBadGateway, err.Error(), h.logger)
+			return
+		}
+		findings = append(findings, found...)
+	}
+
+	resp.Groups = groupConfigAuditFindings(findings)
+	for _, group := range resp.Groups {
+		switch group.Severity {
+		case trivySeverityCritical:
+			resp.CriticalCount = group.Count
+		case trivySeverityHigh:
+			resp.HighCount = group.Count
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// audit posts a manifest to the Trivy server and returns its failed checks.
+func (h *configAuditHandler) audit(resource configAuditResource, object interface{}) ([]configAuditFinding, error) {
+	manifest, err := json.Marshal(object)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal %s %s", resource.Kind, resource.Name)
+	}
+
+	res, err := h.httpClient.Post(fmt.Sprintf("%s/config-audit", h.trivyURL), "application/json", bytes.NewReader(manifest))
+	if err != nil {
+		return nil, errors.Wrap(err, "send manifest to trivy")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("trivy returned %s", res.Status)
+	}
+
+	var result trivyConfigAuditResult
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, errors.Wrap(err, "decode trivy config audit")
+	}
+
+	var findings []configAuditFinding
+	for _, misconfiguration := range result.Misconfigurations {
+		if misconfiguration.Status != "" && misconfiguration.Status != trivyStatusFail {
+			continue
+		}
+
+		findings = append(findings, configAuditFinding{
+			Severity:    normalizeTrivySeverity(misconfiguration.Severity),
+			CheckID:     misconfiguration.ID,
+			Title:       misconfiguration.Title,
+			Description: misconfiguration.Description,
+			Remediation: misconfiguration.Resolution,
+			Resource:    resource,
+		})
+	}
+
+	return findings, nil
+}
+
+func normalizeTrivySeverity(severity string) string {
+	severity = strings.ToUpper(severity)
+	for _, known := range trivySeverities {
+		if severity == known {
+			return severity
+		}
+	}
+
+	return trivySeverityUnknown
+}
+
+// groupConfigAuditFindings groups findings by severity, most severe first.
+// Severities without findings are left out.
+func groupConfigAuditFindings(findings []configAuditFinding) []configAuditGroup {
+	bySeverity := make(map[string][]configAuditFinding)
+	for _, finding := range findings {
+		bySeverity[finding.Severity] = append(bySeverity[finding.Severity], finding)
+	}
+
+	groups := []configAuditGroup{}
+	for _, severity := range trivySeverities {
+		found := bySeverity[severity]
+		if len(found) == 0 {
+			continue
+		}
+
+		groups = append(groups, configAuditGroup{
+			Severity: severity,
+			Count:    len(found),
+			Findings: found,
+		})
+	}
+
+	return groups
+}
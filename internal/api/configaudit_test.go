@@ -0,0 +1,130 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_configAuditHandler(t *testing.T) {
+	controller := true
+	kubeClient := kubefake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "default"},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "web-abc",
+				Namespace: "default",
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "ReplicaSet", Name: "web-123", Controller: &controller},
+				},
+			},
+		},
+	)
+
+	results := map[string]trivyConfigAuditResult{
+		"Deployment": {
+			Misconfigurations: []trivyMisconfiguration{
+				{ID: "KSV001", Title: "Process can elevate its own privileges", Description: "allowPrivilegeEscalation is not false", Resolution: "Set allowPrivilegeEscalation to false", Severity: "MEDIUM", Status: "FAIL"},
+				{ID: "KSV012", Title: "Runs as root user", Severity: "HIGH", Status: "PASS"},
+			},
+		},
+		"Pod": {
+			Misconfigurations: []trivyMisconfiguration{
+				{ID: "KSV017", Title: "Privileged container", Resolution: "Change privileged to false", Severity: "critical", Status: "FAIL"},
+				{ID: "KSV012", Title: "Runs as root user", Resolution: "Set runAsNonRoot to true", Severity: "HIGH", Status: "FAIL"},
+			},
+		},
+	}
+
+	var audited []string
+	trivy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/config-audit", r.URL.Path)
+
+		var manifest unstructured.Unstructured
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&manifest.Object))
+		audited = append(audited, manifest.GetKind()+"/"+manifest.GetName())
+
+		require.NoError(t, json.NewEncoder(w).Encode(results[manifest.GetKind()]))
+	}))
+	defer trivy.Close()
+
+	handler := newConfigAuditHandler(kubeClient, trivy.URL, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/configaudit/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got configAuditResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	assert.Equal(t, []string{"Deployment/web", "Pod/debug"}, audited)
+
+	expected := configAuditResponse{
+		CriticalCount: 1,
+		HighCount:     1,
+		Groups: []configAuditGroup{
+			{
+				Severity: "CRITICAL",
+				Count:    1,
+				Findings: []configAuditFinding{
+					{Severity: "CRITICAL", CheckID: "KSV017", Title: "Privileged container", Remediation: "Change privileged to false", Resource: configAuditResource{Kind: "Pod", Name: "debug"}},
+				},
+			},
+			{
+				Severity: "HIGH",
+				Count:    1,
+				Findings: []configAuditFinding{
+					{Severity: "HIGH", CheckID: "KSV012", Title: "Runs as root user", Remediation: "Set runAsNonRoot to true", Resource: configAuditResource{Kind: "Pod", Name: "debug"}},
+				},
+			},
+			{
+				Severity: "MEDIUM",
+				Count:    1,
+				Findings: []configAuditFinding{
+					{Severity: "MEDIUM", CheckID: "KSV001", Title: "Process can elevate its own privileges", Description: "allowPrivilegeEscalation is not false", Remediation: "Set allowPrivilegeEscalation to false", Resource: configAuditResource{Kind: "Deployment", Name: "web"}},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, got)
+}
+
+func Test_configAuditHandler_not_configured(t *testing.T) {
+	handler := newConfigAuditHandler(kubefake.NewSimpleClientset(), "", log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/configaudit/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got configAuditResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, configAuditResponse{Groups: []configAuditGroup{}}, got)
+}
@@ -0,0 +1,263 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+// instanceTypeLabels are the node labels which name the node's instance
+// type, newest first.
+var instanceTypeLabels = []string{
+	"node.kubernetes.io/instance-type",
+	"beta.kubernetes.io/instance-type",
+}
+
+// costWeights are the shares of a node's cost attributed to CPU and memory.
+type costWeights struct {
+	CPU    float64 `json:"cpu"`
+	Memory float64 `json:"memory"`
+}
+
+// costConfig prices nodes by name or by instance type. Costs are per hour.
+type costConfig struct {
+	Nodes         map[string]float64 `json:"nodes"`
+	InstanceTypes map[string]float64 `json:"instanceTypes"`
+	CostWeights   *costWeights       `json:"costWeights"`
+}
+
+type namespaceCost struct {
+	Namespace           string  `json:"namespace"`
+	AllocatedCPUCost    float64 `json:"allocatedCPUCost"`
+	AllocatedMemoryCost float64 `json:"allocatedMemoryCost"`
+	TotalCost           float64 `json:"totalCost"`
+}
+
+type costAllocationResponse struct {
+	Namespaces  []namespaceCost `json:"namespaces"`
+	PricedNodes int             `json:"pricedNodes"`
+	NodeCost    float64         `json:"nodeCost"`
+	IdleCost    float64         `json:"idleCost"`
+}
+
+type costAllocationHandler struct {
+	kubeClient kubernetes.Interface
+	configPath string
+	logger     log.Logger
+}
+
+var _ http.Handler = (*costAllocationHandler)(nil)
+
+func newCostAllocationHandler(kubeClient kubernetes.Interface, configPath string, logger log.Logger) *costAllocationHandler {
+	return &costAllocationHandler{
+		kubeClient: kubeClient,
+		configPath: configPath,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and allocates the hourly cost of the
+// cluster's nodes to namespaces. Each node's cost is divided between CPU and
+// memory by the configured cost weights, equally by default, and a namespace
+// is charged for the share of a node's allocatable CPU and memory its pods
+// request. Cost no pod requests is returned as idle. Nodes are priced by name
+// or by instance type label from a JSON config file; nodes without a price
+// are left out.
+func (h *costAllocationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp := costAllocationResponse{
+		Namespaces: []namespaceCost{},
+	}
+
+	if h.configPath == "" {
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	config, err := loadCostConfig(h.configPath)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	nodes, err := h.kubeClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	allocation, err := allocateNodeCosts(config, nodes.Items, pods.Items)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp.PricedNodes = allocation.pricedNodes
+	resp.NodeCost = allocation.nodeCost
+
+	costs := make(map[string]*namespaceCost)
+	allocated := 0.0
+
+	for _, pc := range allocation.pods {
+		cost, ok := costs[pc.pod.Namespace]
+		if !ok {
+			cost = &namespaceCost{Namespace: pc.pod.Namespace}
+			costs[pc.pod.Namespace] = cost
+		}
+
+		cost.AllocatedCPUCost += pc.cpuCost
+		cost.AllocatedMemoryCost += pc.memoryCost
+	}
+
+	for _, cost := range costs {
+		allocated += cost.AllocatedCPUCost + cost.AllocatedMemoryCost
+
+		cost.TotalCost = roundCost(cost.AllocatedCPUCost + cost.AllocatedMemoryCost)
+		cost.AllocatedCPUCost = roundCost(cost.AllocatedCPUCost)
+		cost.AllocatedMemoryCost = roundCost(cost.AllocatedMemoryCost)
+
+		resp.Namespaces = append(resp.Namespaces, *cost)
+	}
+
+	resp.IdleCost = roundCost(math.Max(resp.NodeCost-allocated, 0))
+	resp.NodeCost = roundCost(resp.NodeCost)
+
+	sort.Slice(resp.Namespaces, func(i, j int) bool {
+		if resp.Namespaces[i].TotalCost != resp.Namespaces[j].TotalCost {
+			return resp.Namespaces[i].TotalCost > resp.Namespaces[j].TotalCost
+		}
+		return resp.Namespaces[i].Namespace < resp.Namespaces[j].Namespace
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// podCost is the hourly cost of a pod's requests on a priced node.
+type podCost struct {
+	pod        *corev1.Pod
+	cpuCost    float64
+	memoryCost float64
+}
+
+type nodeCostAllocation struct {
+	pods        []podCost
+	pricedNodes int
+	nodeCost    float64
+}
+
+// allocateNodeCosts prices nodes with the config and charges each pod
+// running on a priced node for the share of the node's allocatable CPU and
+// memory it requests. Each node's cost is divided between CPU and memory by
+// the configured cost weights, equally by default.
+func allocateNodeCosts(config costConfig, nodes []corev1.Node, pods []corev1.Pod) (nodeCostAllocation, error) {
+	var allocation nodeCostAllocation
+
+	weights := costWeights{CPU: 1, Memory: 1}
+	if config.CostWeights != nil {
+		weights = *config.CostWeights
+	}
+	totalWeight := weights.CPU + weights.Memory
+	if totalWeight <= 0 {
+		return allocation, errors.New("cost weights must be positive")
+	}
+
+	podsByNode := make(map[string][]*corev1.Pod)
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+	}
+
+	for _, node := range nodes {
+		price, ok := config.nodeCost(node)
+		if !ok {
+			continue
+		}
+
+		allocation.pricedNodes++
+		allocation.nodeCost += price
+
+		cpuCost := price * weights.CPU / totalWeight
+		memoryCost := price * weights.Memory / totalWeight
+
+		allocatableCPU := node.Status.Allocatable[corev1.ResourceCPU]
+		allocatableMemory := node.Status.Allocatable[corev1.ResourceMemory]
+
+		for _, pod := range podsByNode[node.Name] {
+			requests := podResourceRequests(pod)
+			cpu := requests[corev1.ResourceCPU]
+			memory := requests[corev1.ResourceMemory]
+
+			pc := podCost{pod: pod}
+			if allocatableCPU.MilliValue() > 0 {
+				pc.cpuCost = cpuCost * float64(cpu.MilliValue()) / float64(allocatableCPU.MilliValue())
+			}
+			if allocatableMemory.Value() > 0 {
+				pc.memoryCost = memoryCost * float64(memory.Value()) / float64(allocatableMemory.Value())
+			}
+
+			allocation.pods = append(allocation.pods, pc)
+		}
+	}
+
+	return allocation, nil
+}
+
+func loadCostConfig(path string) (costConfig, error) {
+	var config costConfig
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, errors.Wrap(err, "read cost config")
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, errors.Wrap(err, "parse cost config")
+	}
+
+	return config, nil
+}
+
+// nodeCost returns the hourly cost of a node, by name or else by instance
+// type.
+func (c costConfig) nodeCost(node corev1.Node) (float64, bool) {
+	if cost, ok := c.Nodes[node.Name]; ok {
+		return cost, true
+	}
+
+	for _, label := range instanceTypeLabels {
+		if instanceType, ok := node.Labels[label]; ok {
+			if cost, ok := c.InstanceTypes[instanceType]; ok {
+				return cost, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// roundCost rounds a cost to a hundredth of a cent.
+func roundCost(cost float64) float64 {
+	return math.Round(cost*10000) / 10000
+}
@@ -0,0 +1,134 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_costAllocationHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cost-allocation")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	newNode := func(name, instanceType, cpu, memory string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"node.kubernetes.io/instance-type": instanceType},
+			},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+			},
+		}
+	}
+
+	newPod := func(name, namespace, node, cpu, memory string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.PodSpec{
+				NodeName: node,
+				Containers: []corev1.Container{
+					{
+						Name: "app",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse(cpu),
+								corev1.ResourceMemory: resource.MustParse(memory),
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newNode("node-1", "custom", "4", "8Gi"),
+		newNode("node-2", "m5.large", "2", "4Gi"),
+		newNode("node-3", "unpriced", "2", "4Gi"),
+		newPod("web", "default", "node-1", "1", "2Gi"),
+		newPod("api", "apps", "node-2", "1", "1Gi"),
+		newPod("job", "batch", "node-3", "1", "1Gi"),
+	)
+
+	writeConfig := func(name, config string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(config), 0600))
+		return path
+	}
+
+	tests := []struct {
+		name       string
+		configPath string
+		expected   costAllocationResponse
+	}{
+		{
+			name:     "not configured",
+			expected: costAllocationResponse{Namespaces: []namespaceCost{}},
+		},
+		{
+			name:       "equal weights",
+			configPath: writeConfig("equal.json", `{"nodes":{"node-1":1},"instanceTypes":{"m5.large":0.5}}`),
+			expected: costAllocationResponse{
+				Namespaces: []namespaceCost{
+					{Namespace: "default", AllocatedCPUCost: 0.125, AllocatedMemoryCost: 0.125, TotalCost: 0.25},
+					{Namespace: "apps", AllocatedCPUCost: 0.125, AllocatedMemoryCost: 0.0625, TotalCost: 0.1875},
+				},
+				PricedNodes: 2,
+				NodeCost:    1.5,
+				IdleCost:    1.0625,
+			},
+		},
+		{
+			name:       "cost weights",
+			configPath: writeConfig("weighted.json", `{"nodes":{"node-1":1},"costWeights":{"cpu":3,"memory":1}}`),
+			expected: costAllocationResponse{
+				Namespaces: []namespaceCost{
+					{Namespace: "default", AllocatedCPUCost: 0.1875, AllocatedMemoryCost: 0.0625, TotalCost: 0.25},
+				},
+				PricedNodes: 1,
+				NodeCost:    1,
+				IdleCost:    0.75,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := newCostAllocationHandler(kubeClient, test.configPath, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/cluster/costallocation", nil)
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got costAllocationResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, test.expected, got)
+		})
+	}
+}
@@ -0,0 +1,408 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// costSampleInterval is how often workload costs are recorded. Each
+	// sample is the cost of one interval.
+	costSampleInterval = time.Hour
+
+	// costHistoryDays is how many days of cost history are kept and returned.
+	costHistoryDays = 30
+
+	costHistoryDateFormat = "2006-01-02"
+)
+
+// CostSample is the hourly cost of a workload at a point in time.
+type CostSample struct {
+	Time      time.Time   `json:"time"`
+	Namespace string      `json:"namespace"`
+	Workload  workloadRef `json:"workload"`
+	Cost      float64     `json:"cost"`
+}
+
+// CostStore records workload cost samples for cost history.
+type CostStore interface {
+	// Record stores samples.
+	Record(samples []CostSample) error
+	// Samples returns the samples for a namespace recorded at or after since.
+	Samples(namespace string, since time.Time) ([]CostSample, error)
+}
+
+// noopCostStore discards samples, for deployments which keep no state.
+type noopCostStore struct{}
+
+var _ CostStore = noopCostStore{}
+
+func (noopCostStore) Record([]CostSample) error {
+	return nil
+}
+
+func (noopCostStore) Samples(string, time.Time) ([]CostSample, error) {
+	return nil, nil
+}
+
+// fileCostStore keeps cost samples in a JSON lines file so they survive
+// restarts. Samples are bucketed by UTC hour: a workload has at most one
+// sample per hour, and a later sample for the same hour replaces the earlier
+// one, so restarting the recorder never counts an hour twice. Samples older
+// than the history window are dropped, and the file rewritten without them,
+// when it is loaded and as samples are recorded.
+type fileCostStore struct {
+	file  jsonLinesFile
+	nowFn func() time.Time
+
+	loadOnce sync.Once
+	mu       sync.Mutex
+	samples  map[costSampleKey]CostSample
+}
+
+// costSampleKey is the hourly bucket of a workload's cost sample.
+type costSampleKey struct {
+	hour      time.Time
+	namespace string
+	workload  workloadRef
+}
+
+var _ CostStore = (*fileCostStore)(nil)
+
+func newFileCostStore(path string) *fileCostStore {
+	return &fileCostStore{
+		file:    jsonLinesFile{path: path},
+		nowFn:   time.Now,
+		samples: make(map[costSampleKey]CostSample),
+	}
+}
+
+// load reads the samples file the first time it is called. The file is
+// compacted when it holds stale or duplicate samples.
+func (s *fileCostStore) load() error {
+	var err error
+	s.loadOnce.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		lines, readErr := s.file.read(func(line []byte) {
+			var sample CostSample
+			if json.Unmarshal(line, &sample) != nil {
+				return
+			}
+			sample, key := bucketCostSample(sample)
+			s.samples[key] = sample
+		})
+		if readErr != nil {
+			err = errors.Wrap(readErr, "load cost history")
+			return
+		}
+
+		s.prune()
+		if lines > len(s.samples) {
+			err = s.save()
+		}
+	})
+
+	return err
+}
+
+// Record implements CostStore and rewrites the file with the retained
+// samples.
+func (s *fileCostStore) Record(samples []CostSample) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sample := range samples {
+		sample, key := bucketCostSample(sample)
+		s.samples[key] = sample
+	}
+	s.prune()
+
+	return s.save()
+}
+
+// Samples implements CostStore and returns samples oldest first.
+func (s *fileCostStore) Samples(namespace string, since time.Time) ([]CostSample, error) {
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var samples []CostSample
+	for _, sample := range s.samples {
+		if sample.Namespace == namespace && !sample.Time.Before(since) {
+			samples = append(samples, sample)
+		}
+	}
+
+	sortCostSamples(samples)
+
+	return samples, nil
+}
+
+func (s *fileCostStore) prune() {
+	cutoff := s.nowFn().Add(-costHistoryDays * 24 * time.Hour)
+
+	for key, sample := range s.samples {
+		if sample.Time.Before(cutoff) {
+			delete(s.samples, key)
+		}
+	}
+}
+
+// save writes the retained samples to the file, oldest first.
+func (s *fileCostStore) save() error {
+	samples := make([]CostSample, 0, len(s.samples))
+	for _, sample := range s.samples {
+		samples = append(samples, sample)
+	}
+	sortCostSamples(samples)
+
+	err := s.file.write(func(encoder *json.Encoder) error {
+		for _, sample := range samples {
+			if err := encoder.Encode(sample); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return errors.Wrap(err, "write cost history")
+}
+
+func sortCostSamples(samples []CostSample) {
+	sort.Slice(samples, func(i, j int) bool {
+		a, b := samples[i], samples[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Workload.Kind != b.Workload.Kind {
+			return a.Workload.Kind < b.Workload.Kind
+		}
+		return a.Workload.Name < b.Workload.Name
+	})
+}
+
+// bucketCostSample moves a sample to the start of its UTC hour and returns
+// the bucket it belongs to.
+func bucketCostSample(sample CostSample) (CostSample, costSampleKey) {
+	sample.Time = sample.Time.UTC().Truncate(time.Hour)
+
+	return sample, costSampleKey{
+		hour:      sample.Time,
+		namespace: sample.Namespace,
+		workload:  sample.Workload,
+	}
+}
+
+// recordCostSamples records the hourly cost of every workload in the cluster
+// each interval until ctx is done. The cost config is read each time so price
+// changes are picked up.
+func recordCostSamples(ctx context.Context, kubeClient kubernetes.Interface, configPath string, store CostStore, interval time.Duration, logger log.Logger) {
+	record := func() {
+		config, err := loadCostConfig(configPath)
+		if err != nil {
+			logger.WithErr(err).Errorf("load cost config for cost history")
+			return
+		}
+
+		samples, err := workloadCostSamples(kubeClient, config, time.Now())
+		if err != nil {
+			logger.WithErr(err).Errorf("sample workload costs")
+			return
+		}
+
+		if err := store.Record(samples); err != nil {
+			logger.WithErr(err).Errorf("record cost samples")
+		}
+	}
+
+	record()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			record()
+		}
+	}
+}
+
+// workloadCostSamples allocates node costs to pods and sums them by
+// workload.
+func workloadCostSamples(kubeClient kubernetes.Interface, config costConfig, now time.Time) ([]CostSample, error) {
+	nodes, err := kubeClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list nodes")
+	}
+
+	pods, err := kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list pods")
+	}
+
+	replicaSets, err := kubeClient.AppsV1().ReplicaSets(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list replica sets")
+	}
+
+	allocation, err := allocateNodeCosts(config, nodes.Items, pods.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	type sampleKey struct {
+		namespace string
+		workload  workloadRef
+	}
+
+	costs := make(map[sampleKey]float64)
+	var keys []sampleKey
+	for _, pc := range allocation.pods {
+		key := sampleKey{namespace: pc.pod.Namespace, workload: podWorkload(pc.pod, replicaSets.Items)}
+		if _, ok := costs[key]; !ok {
+			keys = append(keys, key)
+		}
+		costs[key] += pc.cpuCost + pc.memoryCost
+	}
+
+	samples := make([]CostSample, 0, len(keys))
+	for _, key := range keys {
+		samples = append(samples, CostSample{
+			Time:      now.UTC(),
+			Namespace: key.namespace,
+			Workload:  key.workload,
+			Cost:      costs[key],
+		})
+	}
+
+	return samples, nil
+}
+
+type workloadCost struct {
+	Namespace string      `json:"namespace"`
+	Workload  workloadRef `json:"workload"`
+	Cost      float64     `json:"cost"`
+}
+
+type dailyCost struct {
+	Date      string         `json:"date"`
+	TotalCost float64        `json:"totalCost"`
+	Breakdown []workloadCost `json:"breakdown"`
+}
+
+type costHistoryResponse struct {
+	Days []dailyCost `json:"days"`
+}
+
+type costHistoryHandler struct {
+	store  CostStore
+	nowFn  func() time.Time
+	logger log.Logger
+}
+
+var _ http.Handler = (*costHistoryHandler)(nil)
+
+func newCostHistoryHandler(store CostStore, logger log.Logger) *costHistoryHandler {
+	return &costHistoryHandler{
+		store:  store,
+		nowFn:  time.Now,
+		logger: logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the cost of a namespace for
+// each of the last 30 days, oldest first, broken down by workload. Days are
+// UTC and include today. Costs come from the hourly samples in the cost
+// store, so days before recording started have no cost.
+func (h *costHistoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	now := h.nowFn().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := today.AddDate(0, 0, -(costHistoryDays - 1))
+
+	samples, err := h.store.Samples(namespace, start)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	byDay := make(map[string]map[workloadRef]float64)
+	for _, sample := range samples {
+		date := sample.Time.UTC().Format(costHistoryDateFormat)
+		if byDay[date] == nil {
+			byDay[date] = make(map[workloadRef]float64)
+		}
+		byDay[date][sample.Workload] += sample.Cost
+	}
+
+	resp := costHistoryResponse{
+		Days: []dailyCost{},
+	}
+
+	for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+		date := day.Format(costHistoryDateFormat)
+
+		d := dailyCost{
+			Date:      date,
+			Breakdown: []workloadCost{},
+		}
+
+		total := 0.0
+		for workload, cost := range byDay[date] {
+			total += cost
+			d.Breakdown = append(d.Breakdown, workloadCost{
+				Namespace: namespace,
+				Workload:  workload,
+				Cost:      roundCost(cost),
+			})
+		}
+		d.TotalCost = roundCost(total)
+
+		sort.Slice(d.Breakdown, func(i, j int) bool {
+			a, b := d.Breakdown[i], d.Breakdown[j]
+			if a.Cost != b.Cost {
+				return a.Cost > b.Cost
+			}
+			if a.Workload.Kind != b.Workload.Kind {
+				return a.Workload.Kind < b.Workload.Kind
+			}
+			return a.Workload.Name < b.Workload.Name
+		})
+
+		resp.Days = append(resp.Days, d)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
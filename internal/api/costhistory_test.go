@@ -0,0 +1,228 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_fileCostStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cost-history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "costs.jsonl")
+	now := time.Date(2019, 10, 31, 12, 0, 0, 0, time.UTC)
+
+	web := workloadRef{Kind: "Deployment", Name: "web"}
+	samples := []CostSample{
+		{Time: now.AddDate(0, 0, -40), Namespace: "default", Workload: web, Cost: 1},
+		{Time: now.AddDate(0, 0, -2), Namespace: "default", Workload: web, Cost: 2},
+		{Time: now.AddDate(0, 0, -1), Namespace: "other", Workload: web, Cost: 3},
+		{Time: now, Namespace: "default", Workload: web, Cost: 4},
+	}
+
+	store := newFileCostStore(path)
+	store.nowFn = func() time.Time { return now }
+	require.NoError(t, store.Record(samples))
+
+	reloaded := newFileCostStore(path)
+	reloaded.nowFn = func() time.Time { return now }
+
+	got, err := reloaded.Samples("default", now.AddDate(0, 0, -30))
+	require.NoError(t, err)
+	assert.Equal(t, []CostSample{samples[1], samples[3]}, got)
+
+	got, err = reloaded.Samples("default", now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []CostSample{samples[3]}, got)
+
+	// A recorder restarted within the hour replaces the hour's sample
+	// rather than adding another.
+	require.NoError(t, reloaded.Record([]CostSample{
+		{Time: now.Add(20 * time.Minute), Namespace: "default", Workload: web, Cost: 5},
+	}))
+
+	restarted := newFileCostStore(path)
+	restarted.nowFn = func() time.Time { return now }
+
+	got, err = restarted.Samples("default", now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []CostSample{{Time: now, Namespace: "default", Workload: web, Cost: 5}}, got)
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(data), "\n"), "the file only holds retained samples")
+}
+
+func Test_workloadCostSamples(t *testing.T) {
+	now := time.Date(2019, 10, 31, 12, 0, 0, 0, time.UTC)
+	controller := true
+
+	newPod := func(name string, owner metav1.OwnerReference) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: corev1.PodSpec{
+				NodeName: "node-1",
+				Containers: []corev1.Container{
+					{
+						Name: "app",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("1"),
+								corev1.ResourceMemory: resource.MustParse("2Gi"),
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		if owner.Name != "" {
+			pod.OwnerReferences = []metav1.OwnerReference{owner}
+		}
+		return pod
+	}
+
+	replicaSetOwner := metav1.OwnerReference{Kind: "ReplicaSet", Name: "web-abc", UID: types.UID("rs-uid"), Controller: &controller}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				},
+			},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "web-abc",
+				Namespace:       "default",
+				UID:             types.UID("rs-uid"),
+				OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", Controller: &controller}},
+			},
+		},
+		newPod("web-abc-1", replicaSetOwner),
+		newPod("web-abc-2", replicaSetOwner),
+		newPod("debug", metav1.OwnerReference{}),
+	)
+
+	config := costConfig{Nodes: map[string]float64{"node-1": 0.8}}
+
+	got, err := workloadCostSamples(kubeClient, config, now)
+	require.NoError(t, err)
+
+	expected := []CostSample{
+		{Time: now, Namespace: "default", Workload: workloadRef{Kind: "Deployment", Name: "web"}, Cost: 0.4},
+		{Time: now, Namespace: "default", Workload: workloadRef{Kind: "Pod", Name: "debug"}, Cost: 0.2},
+	}
+	assert.Equal(t, expected, got)
+}
+
+func Test_costHistoryHandler(t *testing.T) {
+	now := time.Date(2019, 10, 31, 12, 0, 0, 0, time.UTC)
+
+	web := workloadRef{Kind: "Deployment", Name: "web"}
+	worker := workloadRef{Kind: "Deployment", Name: "worker"}
+
+	dir, err := ioutil.TempDir("", "cost-history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store := newFileCostStore(filepath.Join(dir, "costs.jsonl"))
+	store.nowFn = func() time.Time { return now }
+	require.NoError(t, store.Record([]CostSample{
+		{Time: time.Date(2019, 10, 1, 23, 0, 0, 0, time.UTC), Namespace: "default", Workload: web, Cost: 1},
+		{Time: time.Date(2019, 10, 30, 1, 0, 0, 0, time.UTC), Namespace: "default", Workload: web, Cost: 0.5},
+		{Time: time.Date(2019, 10, 30, 2, 0, 0, 0, time.UTC), Namespace: "default", Workload: web, Cost: 0.5},
+		{Time: time.Date(2019, 10, 30, 2, 0, 0, 0, time.UTC), Namespace: "default", Workload: worker, Cost: 2},
+		{Time: time.Date(2019, 10, 31, 1, 0, 0, 0, time.UTC), Namespace: "default", Workload: web, Cost: 0.25},
+		{Time: time.Date(2019, 10, 31, 1, 0, 0, 0, time.UTC), Namespace: "other", Workload: web, Cost: 9},
+	}))
+
+	tests := []struct {
+		name  string
+		store CostStore
+		check func(t *testing.T, days []dailyCost)
+	}{
+		{
+			name:  "recorded samples",
+			store: store,
+			check: func(t *testing.T, days []dailyCost) {
+				assert.Equal(t, dailyCost{Date: "2019-10-02", Breakdown: []workloadCost{}}, days[0])
+
+				assert.Equal(t, dailyCost{
+					Date:      "2019-10-30",
+					TotalCost: 3,
+					Breakdown: []workloadCost{
+						{Namespace: "default", Workload: worker, Cost: 2},
+						{Namespace: "default", Workload: web, Cost: 1},
+					},
+				}, days[28])
+
+				assert.Equal(t, dailyCost{
+					Date:      "2019-10-31",
+					TotalCost: 0.25,
+					Breakdown: []workloadCost{
+						{Namespace: "default", Workload: web, Cost: 0.25},
+					},
+				}, days[29])
+			},
+		},
+		{
+			name:  "no-op store",
+			store: noopCostStore{},
+			check: func(t *testing.T, days []dailyCost) {
+				for _, day := range days {
+					assert.Zero(t, day.TotalCost)
+					assert.Empty(t, day.Breakdown)
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := newCostHistoryHandler(test.store, log.NopLogger())
+			handler.nowFn = func() time.Time { return now }
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/costhistory", nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got costHistoryResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+			require.Len(t, got.Days, costHistoryDays)
+			test.check(t, got.Days)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	jsonSchemaDraft4 = "http://json-schema.org/draft-04/schema#"
+
+	// crdSchemaCacheTTL bounds how long schemas of CRDs which are no longer
+	// requested are kept. Entries are keyed by resource version, so a changed
+	// CRD never serves a stale schema.
+	crdSchemaCacheTTL = time.Hour
+)
+
+type crdSchemaHandler struct {
+	dynamicClient dynamic.Interface
+	cache         *ttlCache
+	logger        log.Logger
+}
+
+var _ http.Handler = (*crdSchemaHandler)(nil)
+
+func newCRDSchemaHandler(dynamicClient dynamic.Interface, logger log.Logger) *crdSchemaHandler {
+	return &crdSchemaHandler{
+		dynamicClient: dynamicClient,
+		cache:         newTTLCache(crdSchemaCacheTTL),
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the OpenAPI v3 schema of a
+// CustomResourceDefinition version as a JSON Schema. `dereference=true`
+// replaces each local `$ref` with the schema it points to. References which
+// are recursive are left in place.
+func (h *crdSchemaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	group, version, resource := vars["group"], vars["version"], vars["resource"]
+	dereference := r.URL.Query().Get("dereference") == "true"
+
+	name := fmt.Sprintf("%s.%s", resource, group)
+
+	crd, err := h.dynamicClient.Resource(crdGVR).Get(name, metav1.GetOptions{})
+	if err != nil {
+		RespondWithError(w, statusForKubeError(err), err.Error(), h.logger)
+		return
+	}
+
+	key := fmt.Sprintf("%s|%s|%s|%t", name, crd.GetResourceVersion(), version, dereference)
+	if cached, ok := h.cache.get(key); ok {
+		schema := cached.(map[string]interface{})
+		serveAsJSON(w, &schema, h.logger)
+		return
+	}
+
+	schema, found, err := crdVersionSchema(crd.Object, version)
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+		return
+	}
+	if !found {
+		RespondWithError(w, http.StatusNotFound, fmt.Sprintf("%s version %s has no schema", name, version), h.logger)
+		return
+	}
+
+	if dereference {
+		if dereferenced, ok := dereferenceSchema(schema, schema, nil).(map[string]interface{}); ok {
+			schema = dereferenced
+		}
+	}
+	schema["$schema"] = jsonSchemaDraft4
+
+	h.cache.set(key, schema)
+
+	serveAsJSON(w, &schema, h.logger)
+}
+
+// crdVersionSchema returns a copy of the OpenAPI v3 schema for a version of a
+// CRD. A version without its own schema uses the CRD's top level validation.
+func crdVersionSchema(crd map[string]interface{}, version string) (map[string]interface{}, bool, error) {
+	served := false
+	if v, _, _ := unstructured.NestedString(crd, "spec", "version"); v == version {
+		served = true
+	}
+
+	versions, _, _ := unstructured.NestedSlice(crd, "spec", "versions")
+	for _, item := range versions {
+		v, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if n, _, _ := unstructured.NestedString(v, "name"); n != version {
+			continue
+		}
+
+		served = true
+		if schema, found, _ := unstructured.NestedMap(v, "schema", "openAPIV3Schema"); found {
+			return schema, true, nil
+		}
+	}
+
+	if !served {
+		return nil, false, errors.Errorf("version %s is not defined", version)
+	}
+
+	schema, found, _ := unstructured.NestedMap(crd, "spec", "validation", "openAPIV3Schema")
+	return schema, found, nil
+}
+
+// dereferenceSchema returns node with each `$ref` to a location in root
+// replaced by a copy of the referenced schema. refs holds the references
+// being resolved, so recursive references are left unchanged.
+func dereferenceSchema(root map[string]interface{}, node interface{}, refs []string) interface{} {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := node["$ref"].(string); ok {
+			for _, seen := range refs {
+				if seen == ref {
+					return node
+				}
+			}
+
+			target, ok := resolveSchemaRef(root, ref)
+			if !ok {
+				return node
+			}
+
+			return dereferenceSchema(root, runtime.DeepCopyJSONValue(target), append(refs, ref))
+		}
+
+		out := make(map[string]interface{}, len(node))
+		for k, v := range node {
+			out[k] = dereferenceSchema(root, v, refs)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(node))
+		for i, v := range node {
+			out[i] = dereferenceSchema(root, v, refs)
+		}
+		return out
+	default:
+		return node
+	}
+}
+
+// resolveSchemaRef resolves a local JSON pointer reference such as
+// `#/definitions/spec` against root.
+func resolveSchemaRef(root map[string]interface{}, ref string) (interface{}, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+
+	var node interface{} = root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if node, ok = m[token]; !ok {
+			return nil, false
+		}
+	}
+
+	return node, true
+}
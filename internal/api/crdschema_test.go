@@ -0,0 +1,156 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_crdSchemaHandler(t *testing.T) {
+	newCRD := func(resourceVersion string, replicasType string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apiextensions.k8s.io/v1beta1",
+			"kind":       "CustomResourceDefinition",
+			"metadata":   map[string]interface{}{"name": "widgets.example.com", "resourceVersion": resourceVersion},
+			"spec": map[string]interface{}{
+				"group": "example.com",
+				"names": map[string]interface{}{"plural": "widgets", "kind": "Widget"},
+				"validation": map[string]interface{}{
+					"openAPIV3Schema": map[string]interface{}{"type": "object"},
+				},
+				"versions": []interface{}{
+					map[string]interface{}{
+						"name":   "v1",
+						"served": true,
+						"schema": map[string]interface{}{
+							"openAPIV3Schema": map[string]interface{}{
+								"type": "object",
+								"definitions": map[string]interface{}{
+									"spec": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"replicas": map[string]interface{}{"type": replicasType},
+										},
+									},
+									"node": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"child": map[string]interface{}{"$ref": "#/definitions/node"},
+										},
+									},
+								},
+								"properties": map[string]interface{}{
+									"spec": map[string]interface{}{"$ref": "#/definitions/spec"},
+									"tree": map[string]interface{}{"$ref": "#/definitions/node"},
+								},
+							},
+						},
+					},
+					map[string]interface{}{"name": "v1beta1", "served": true},
+				},
+			},
+		}}
+	}
+
+	get := func(t *testing.T, handler *crdSchemaHandler, version, query string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/crds/example.com/"+version+"/widgets"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"group": "example.com", "version": version, "resource": "widgets"})
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		var got map[string]interface{}
+		if resp.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		}
+		return resp.Code, got
+	}
+
+	t.Run("schema", func(t *testing.T) {
+		handler := newCRDSchemaHandler(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newCRD("1", "integer")), log.NopLogger())
+
+		code, got := get(t, handler, "v1", "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, jsonSchemaDraft4, got["$schema"])
+		assert.Equal(t, map[string]interface{}{"$ref": "#/definitions/spec"},
+			got["properties"].(map[string]interface{})["spec"])
+	})
+
+	t.Run("dereference", func(t *testing.T) {
+		handler := newCRDSchemaHandler(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newCRD("1", "integer")), log.NopLogger())
+
+		code, got := get(t, handler, "v1", "?dereference=true")
+		require.Equal(t, http.StatusOK, code)
+
+		properties := got["properties"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"replicas": map[string]interface{}{"type": "integer"},
+			},
+		}, properties["spec"])
+		assert.Equal(t, map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"child": map[string]interface{}{"$ref": "#/definitions/node"},
+			},
+		}, properties["tree"], "recursive references are left in place")
+	})
+
+	t.Run("top level validation", func(t *testing.T) {
+		handler := newCRDSchemaHandler(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newCRD("1", "integer")), log.NopLogger())
+
+		code, got := get(t, handler, "v1beta1", "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, map[string]interface{}{"$schema": jsonSchemaDraft4, "type": "object"}, got)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		handler := newCRDSchemaHandler(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newCRD("1", "integer")), log.NopLogger())
+
+		code, _ := get(t, handler, "v2", "")
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("unknown crd", func(t *testing.T) {
+		handler := newCRDSchemaHandler(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), log.NopLogger())
+
+		code, _ := get(t, handler, "v1", "")
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("cached by resource version", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newCRD("1", "integer"))
+		handler := newCRDSchemaHandler(dynamicClient, log.NopLogger())
+
+		replicasType := func() interface{} {
+			code, got := get(t, handler, "v1", "?dereference=true")
+			require.Equal(t, http.StatusOK, code)
+			spec := got["properties"].(map[string]interface{})["spec"].(map[string]interface{})
+			return spec["properties"].(map[string]interface{})["replicas"].(map[string]interface{})["type"]
+		}
+
+		assert.Equal(t, "integer", replicasType())
+
+		_, err := dynamicClient.Resource(crdGVR).Update(newCRD("2", "string"), metav1.UpdateOptions{})
+		require.NoError(t, err)
+
+		assert.Equal(t, "string", replicasType())
+	})
+}
@@ -0,0 +1,105 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// crossplaneManagedLabel marks the CRDs of Crossplane managed resources.
+	crossplaneManagedLabel = "managed.crossplane.io"
+)
+
+type crossplaneManagedResource struct {
+	Resource   string                 `json:"resource"`
+	Kind       string                 `json:"kind"`
+	Name       string                 `json:"name"`
+	Synced     bool                   `json:"synced"`
+	Ready      bool                   `json:"ready"`
+	Flagged    bool                   `json:"flagged"`
+	AtProvider map[string]interface{} `json:"atProvider"`
+	Conditions []interface{}          `json:"conditions"`
+}
+
+type crossplaneResponse struct {
+	Resources []crossplaneManagedResource `json:"resources"`
+}
+
+type crossplaneHandler struct {
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*crossplaneHandler)(nil)
+
+func newCrossplaneHandler(dynamicClient dynamic.Interface, logger log.Logger) *crossplaneHandler {
+	return &crossplaneHandler{
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns every Crossplane managed
+// resource with its Synced and Ready conditions. Managed resource kinds are
+// discovered from CRDs labelled managed.crossplane.io. Resources which are
+// not synced or not ready are flagged.
+func (h *crossplaneHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp := crossplaneResponse{
+		Resources: []crossplaneManagedResource{},
+	}
+
+	crds, _, err := listOptionalResource(h.dynamicClient, crdGVR, "", metav1.ListOptions{LabelSelector: crossplaneManagedLabel})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	for _, crd := range crds.Items {
+		gvr, kind, ok := crdResource(crd)
+		if !ok {
+			continue
+		}
+
+		managed, _, err := listOptionalResource(h.dynamicClient, gvr, "", metav1.ListOptions{})
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		for _, object := range managed.Items {
+			synced, _ := findCondition(object.Object, "Synced")
+			ready, _ := findCondition(object.Object, "Ready")
+
+			atProvider, _, _ := unstructured.NestedMap(object.Object, "status", "atProvider")
+			conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+			if conditions == nil {
+				conditions = []interface{}{}
+			}
+
+			resource := crossplaneManagedResource{
+				Resource:   gvr.GroupResource().String(),
+				Kind:       kind,
+				Name:       object.GetName(),
+				Synced:     synced.Status == "True",
+				Ready:      ready.Status == "True",
+				AtProvider: atProvider,
+				Conditions: conditions,
+			}
+			resource.Flagged = !resource.Synced || !resource.Ready
+
+			resp.Resources = append(resp.Resources, resource)
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
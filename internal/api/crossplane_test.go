@@ -0,0 +1,96 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_crossplaneHandler(t *testing.T) {
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"group": "s3.aws.crossplane.io",
+			"names": map[string]interface{}{"kind": "Bucket", "plural": "buckets"},
+			"versions": []interface{}{
+				map[string]interface{}{"name": "v1beta1", "served": true},
+			},
+		},
+	}}
+	crd.SetAPIVersion("apiextensions.k8s.io/v1beta1")
+	crd.SetKind("CustomResourceDefinition")
+	crd.SetName("buckets.s3.aws.crossplane.io")
+	crd.SetLabels(map[string]string{crossplaneManagedLabel: "true"})
+
+	condition := func(conditionType, status string) interface{} {
+		return map[string]interface{}{"type": conditionType, "status": status}
+	}
+
+	newBucket := func(name string, atProvider map[string]interface{}, conditions ...interface{}) *unstructured.Unstructured {
+		bucket := &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{
+				"atProvider": atProvider,
+				"conditions": conditions,
+			},
+		}}
+		bucket.SetAPIVersion("s3.aws.crossplane.io/v1beta1")
+		bucket.SetKind("Bucket")
+		bucket.SetName(name)
+		return bucket
+	}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		crd,
+		newBucket("assets", map[string]interface{}{"arn": "arn:aws:s3:::assets"},
+			condition("Synced", "True"), condition("Ready", "True")),
+		newBucket("logs", map[string]interface{}{},
+			condition("Synced", "False"), condition("Ready", "True")),
+	)
+
+	handler := newCrossplaneHandler(dynamicClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/crossplane/managed", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got crossplaneResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := crossplaneResponse{
+		Resources: []crossplaneManagedResource{
+			{
+				Resource:   "buckets.s3.aws.crossplane.io",
+				Kind:       "Bucket",
+				Name:       "assets",
+				Synced:     true,
+				Ready:      true,
+				AtProvider: map[string]interface{}{"arn": "arn:aws:s3:::assets"},
+				Conditions: []interface{}{condition("Synced", "True"), condition("Ready", "True")},
+			},
+			{
+				Resource:   "buckets.s3.aws.crossplane.io",
+				Kind:       "Bucket",
+				Name:       "logs",
+				Ready:      true,
+				Flagged:    true,
+				AtProvider: map[string]interface{}{},
+				Conditions: []interface{}{condition("Synced", "False"), condition("Ready", "True")},
+			},
+		},
+	}
+	assert.Equal(t, expected, got)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var crdGVR = schema.GroupVersionResource{
+	Group:    "apiextensions.k8s.io",
+	Version:  "v1beta1",
+	Resource: "customresourcedefinitions",
+}
+
+// listOptionalResource lists a resource which may not be installed in the
+// cluster, such as a custom resource. If the API server does not serve the
+// resource, it returns an empty list and installed is false.
+func listOptionalResource(dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, namespace string, options metav1.ListOptions) (list *unstructured.UnstructuredList, installed bool, err error) {
+	list, err = dynamicClient.Resource(gvr).Namespace(namespace).List(options)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return &unstructured.UnstructuredList{}, false, nil
+		}
+		return nil, false, err
+	}
+
+	return list, true, nil
+}
+
+// statusCondition is a condition from a custom resource's status.
+type statusCondition struct {
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// findCondition returns the condition of the given type from an object's
+// `status.conditions`.
+func findCondition(object map[string]interface{}, conditionType string) (statusCondition, bool) {
+	conditions, _, _ := unstructured.NestedSlice(object, "status", "conditions")
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if t, _, _ := unstructured.NestedString(condition, "type"); t != conditionType {
+			continue
+		}
+
+		status, _, _ := unstructured.NestedString(condition, "status")
+		reason, _, _ := unstructured.NestedString(condition, "reason")
+		message, _, _ := unstructured.NestedString(condition, "message")
+
+		return statusCondition{Status: status, Reason: reason, Message: message}, true
+	}
+
+	return statusCondition{Status: "Unknown"}, false
+}
+
+// crdResource returns the resource and kind served by a
+// CustomResourceDefinition, using its first served version.
+func crdResource(crd unstructured.Unstructured) (schema.GroupVersionResource, string, bool) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+
+	version, _, _ := unstructured.NestedString(crd.Object, "spec", "version")
+	if version == "" {
+		versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+		for _, item := range versions {
+			v, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if served, _, _ := unstructured.NestedBool(v, "served"); served {
+				version, _, _ = unstructured.NestedString(v, "name")
+				break
+			}
+		}
+	}
+
+	if plural == "" || version == "" {
+		return schema.GroupVersionResource{}, "", false
+	}
+
+	return schema.GroupVersionResource{Group: group, Version: version, Resource: plural}, kind, true
+}
@@ -0,0 +1,265 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	defaultDependencyLabel = "depends-on"
+)
+
+type dependencyNode struct {
+	Name    string `json:"name"`
+	Missing bool   `json:"missing"`
+	InCycle bool   `json:"inCycle"`
+}
+
+type dependencyEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type dependencyGraphResponse struct {
+	Nodes  []dependencyNode `json:"nodes"`
+	Edges  []dependencyEdge `json:"edges"`
+	Cycles [][]string       `json:"cycles"`
+}
+
+type dependencyGraphHandler struct {
+	kubeClient kubernetes.Interface
+	label      string
+	logger     log.Logger
+}
+
+var _ http.Handler = (*dependencyGraphHandler)(nil)
+
+func newDependencyGraphHandler(kubeClient kubernetes.Interface, label string, logger log.Logger) *dependencyGraphHandler {
+	return &dependencyGraphHandler{
+		kubeClient: kubeClient,
+		label:      label,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the graph of services in a
+// namespace and the services they depend on. A service's dependency is the
+// value of its dependency label; an annotation with the same key may list
+// several, separated by commas. Dependencies which are not services are
+// marked missing. With a `seed` service, only services within `depth` hops
+// of it are returned. Cycles are the strongly connected groups of services in
+// the returned graph.
+func (h *dependencyGraphHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	seed := r.URL.Query().Get("seed")
+
+	depth := -1
+	if s := r.URL.Query().Get("depth"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			RespondWithError(w, http.StatusBadRequest, "depth must be a non-negative integer", h.logger)
+			return
+		}
+		depth = n
+	}
+
+	services, err := h.kubeClient.CoreV1().Services(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	exists := make(map[string]bool)
+	dependencies := make(map[string][]string)
+	for _, service := range services.Items {
+		exists[service.Name] = true
+
+		var names []string
+		if value := service.Labels[h.label]; value != "" {
+			names = append(names, value)
+		}
+		for _, value := range strings.Split(service.Annotations[h.label], ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				names = append(names, value)
+			}
+		}
+
+		seen := make(map[string]bool)
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				dependencies[service.Name] = append(dependencies[service.Name], name)
+			}
+		}
+	}
+
+	var included map[string]bool
+	if seed != "" {
+		if !exists[seed] {
+			RespondWithError(w, http.StatusNotFound, fmt.Sprintf("service %q not found", seed), h.logger)
+			return
+		}
+		included = dependencyReachable(dependencies, seed, depth)
+	} else {
+		included = make(map[string]bool)
+		for name := range exists {
+			included[name] = true
+			for _, dependency := range dependencies[name] {
+				included[dependency] = true
+			}
+		}
+	}
+
+	resp := dependencyGraphResponse{
+		Nodes:  []dependencyNode{},
+		Edges:  []dependencyEdge{},
+		Cycles: [][]string{},
+	}
+
+	graph := make(map[string][]string)
+	for name := range included {
+		for _, dependency := range dependencies[name] {
+			if included[dependency] {
+				graph[name] = append(graph[name], dependency)
+				resp.Edges = append(resp.Edges, dependencyEdge{From: name, To: dependency})
+			}
+		}
+	}
+
+	inCycle := make(map[string]bool)
+	for _, cycle := range dependencyCycles(graph) {
+		for _, name := range cycle {
+			inCycle[name] = true
+		}
+		resp.Cycles = append(resp.Cycles, cycle)
+	}
+
+	for name := range included {
+		resp.Nodes = append(resp.Nodes, dependencyNode{
+			Name:    name,
+			Missing: !exists[name],
+			InCycle: inCycle[name],
+		})
+	}
+
+	sort.Slice(resp.Nodes, func(i, j int) bool {
+		return resp.Nodes[i].Name < resp.Nodes[j].Name
+	})
+	sort.Slice(resp.Edges, func(i, j int) bool {
+		if resp.Edges[i].From != resp.Edges[j].From {
+			return resp.Edges[i].From < resp.Edges[j].From
+		}
+		return resp.Edges[i].To < resp.Edges[j].To
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// dependencyReachable returns the services reachable from seed in at most
+// depth hops. A negative depth is unlimited.
+func dependencyReachable(dependencies map[string][]string, seed string, depth int) map[string]bool {
+	reached := map[string]bool{seed: true}
+	frontier := []string{seed}
+
+	for hops := 0; len(frontier) > 0 && (depth < 0 || hops < depth); hops++ {
+		var next []string
+		for _, name := range frontier {
+			for _, dependency := range dependencies[name] {
+				if !reached[dependency] {
+					reached[dependency] = true
+					next = append(next, dependency)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	return reached
+}
+
+// dependencyCycles returns the strongly connected components of graph which
+// contain a cycle, each sorted by name. It uses Tarjan's algorithm.
+func dependencyCycles(graph map[string][]string) [][]string {
+	var names []string
+	for name := range graph {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	index := 0
+	indexes := make(map[string]int)
+	lowlinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+
+	var visit func(name string)
+	visit = func(name string) {
+		indexes[name] = index
+		lowlinks[name] = index
+		index++
+		stack = append(stack, name)
+		onStack[name] = true
+
+		selfLoop := false
+		for _, dependency := range graph[name] {
+			if dependency == name {
+				selfLoop = true
+			}
+			if _, ok := indexes[dependency]; !ok {
+				visit(dependency)
+				if lowlinks[dependency] < lowlinks[name] {
+					lowlinks[name] = lowlinks[dependency]
+				}
+			} else if onStack[dependency] && indexes[dependency] < lowlinks[name] {
+				lowlinks[name] = indexes[dependency]
+			}
+		}
+
+		if lowlinks[name] != indexes[name] {
+			return
+		}
+
+		var component []string
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == name {
+				break
+			}
+		}
+
+		if len(component) > 1 || selfLoop {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := indexes[name]; !ok {
+			visit(name)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0] < cycles[j][0]
+	})
+
+	return cycles
+}
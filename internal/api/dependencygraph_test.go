@@ -0,0 +1,127 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_dependencyGraphHandler(t *testing.T) {
+	newService := func(name, dependsOn, annotation string) *corev1.Service {
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		}
+		if dependsOn != "" {
+			service.Labels = map[string]string{"depends-on": dependsOn}
+		}
+		if annotation != "" {
+			service.Annotations = map[string]string{"depends-on": annotation}
+		}
+		return service
+	}
+
+	// web -> api -> db -> cache -> db, api -> auth (missing), orders -> orders
+	kubeClient := kubefake.NewSimpleClientset(
+		newService("web", "api", ""),
+		newService("api", "db", "db, auth"),
+		newService("db", "cache", ""),
+		newService("cache", "db", ""),
+		newService("orders", "orders", ""),
+	)
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expected     dependencyGraphResponse
+	}{
+		{
+			name:         "whole namespace",
+			expectedCode: http.StatusOK,
+			expected: dependencyGraphResponse{
+				Nodes: []dependencyNode{
+					{Name: "api"},
+					{Name: "auth", Missing: true},
+					{Name: "cache", InCycle: true},
+					{Name: "db", InCycle: true},
+					{Name: "orders", InCycle: true},
+					{Name: "web"},
+				},
+				Edges: []dependencyEdge{
+					{From: "api", To: "auth"},
+					{From: "api", To: "db"},
+					{From: "cache", To: "db"},
+					{From: "db", To: "cache"},
+					{From: "orders", To: "orders"},
+					{From: "web", To: "api"},
+				},
+				Cycles: [][]string{{"cache", "db"}, {"orders"}},
+			},
+		},
+		{
+			name:         "seed with depth",
+			query:        "?seed=web&depth=2",
+			expectedCode: http.StatusOK,
+			expected: dependencyGraphResponse{
+				Nodes: []dependencyNode{
+					{Name: "api"},
+					{Name: "auth", Missing: true},
+					{Name: "db"},
+					{Name: "web"},
+				},
+				Edges: []dependencyEdge{
+					{From: "api", To: "auth"},
+					{From: "api", To: "db"},
+					{From: "web", To: "api"},
+				},
+				Cycles: [][]string{},
+			},
+		},
+		{
+			name:         "unknown seed",
+			query:        "?seed=missing",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid depth",
+			query:        "?seed=web&depth=-1",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := newDependencyGraphHandler(kubeClient, defaultDependencyLabel, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/dependency-graph"+test.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, test.expectedCode, resp.Code)
+
+			if test.expectedCode != http.StatusOK {
+				return
+			}
+
+			var got dependencyGraphResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, test.expected, got)
+		})
+	}
+}
@@ -0,0 +1,326 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// deploymentRevisionAnnotation is set by the deployment controller on
+	// deployments and the replica sets they own.
+	deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+)
+
+type podDiff struct {
+	Pod   string               `json:"pod"`
+	Patch []jsonPatchOperation `json:"patch"`
+}
+
+// jsonPatchOperation is an RFC 6902 JSON Patch operation.
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type deploymentDiff struct {
+	Name       string    `json:"name"`
+	ReplicaSet string    `json:"replicaSet"`
+	Pods       []podDiff `json:"pods"`
+}
+
+type deploymentDiffsResponse struct {
+	Deployments []deploymentDiff `json:"deployments"`
+}
+
+type deploymentDiffsHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*deploymentDiffsHandler)(nil)
+
+func newDeploymentDiffsHandler(kubeClient kubernetes.Interface, logger log.Logger) *deploymentDiffsHandler {
+	return &deploymentDiffsHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns deployments whose running pods
+// have drifted from the deployment's pod template.
+func (h *deploymentDiffsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	deployments, err := h.kubeClient.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	replicaSets, err := h.kubeClient.AppsV1().ReplicaSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := deploymentDiffsResponse{
+		Deployments: []deploymentDiff{},
+	}
+
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+
+		replicaSet := currentReplicaSet(deployment, replicaSets.Items)
+		if replicaSet == nil {
+			continue
+		}
+
+		diff := deploymentDiff{
+			Name:       deployment.Name,
+			ReplicaSet: replicaSet.Name,
+		}
+
+		for j := range pods.Items {
+			pod := &pods.Items[j]
+			if !isControlledBy(pod.OwnerReferences, replicaSet.UID) {
+				continue
+			}
+
+			patch, err := podSpecPatch(deployment.Spec.Template.Spec, pod.Spec)
+			if err != nil {
+				RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+				return
+			}
+
+			if patch != nil {
+				diff.Pods = append(diff.Pods, podDiff{Pod: pod.Name, Patch: patch})
+			}
+		}
+
+		if len(diff.Pods) > 0 {
+			resp.Deployments = append(resp.Deployments, diff)
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// currentReplicaSet returns the replica set for a deployment's current revision.
+func currentReplicaSet(deployment *appsv1.Deployment, replicaSets []appsv1.ReplicaSet) *appsv1.ReplicaSet {
+	revision := deployment.Annotations[deploymentRevisionAnnotation]
+
+	for i := range replicaSets {
+		replicaSet := &replicaSets[i]
+		if !isControlledBy(replicaSet.OwnerReferences, deployment.UID) {
+			continue
+		}
+
+		if replicaSet.Annotations[deploymentRevisionAnnotation] == revision {
+			return replicaSet
+		}
+	}
+
+	return nil
+}
+
+func isControlledBy(ownerReferences []metav1.OwnerReference, uid types.UID) bool {
+	for _, ownerReference := range ownerReferences {
+		if ownerReference.Controller != nil && *ownerReference.Controller && ownerReference.UID == uid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// podSpecPatch creates a JSON patch from a template's pod spec to a running
+// pod's spec. Only user controlled container fields are compared because the
+// API server and admission controllers add defaults to pods. It returns nil
+// if the specs match.
+func podSpecPatch(template, running corev1.PodSpec) ([]jsonPatchOperation, error) {
+	original, err := json.Marshal(comparablePodSpec(template))
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal pod template spec")
+	}
+
+	modified, err := json.Marshal(comparablePodSpec(running))
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal pod spec")
+	}
+
+	patch, err := createJSONPatch(original, modified)
+	if err != nil {
+		return nil, errors.Wrap(err, "create pod spec patch")
+	}
+
+	return patch, nil
+}
+
+// createJSONPatch returns the JSON patch operations which turn the original
+// document into the modified one, or nil if they are equal. Objects are
+// compared key by key. Arrays are compared by index, with elements added or
+// removed at the end, so a reordered array shows as replaced elements.
+func createJSONPatch(original, modified []byte) ([]jsonPatchOperation, error) {
+	var from, to interface{}
+	if err := json.Unmarshal(original, &from); err != nil {
+		return nil, errors.Wrap(err, "decode original document")
+	}
+	if err := json.Unmarshal(modified, &to); err != nil {
+		return nil, errors.Wrap(err, "decode modified document")
+	}
+
+	var patch []jsonPatchOperation
+	if err := diffJSON("", from, to, &patch); err != nil {
+		return nil, err
+	}
+
+	return patch, nil
+}
+
+func diffJSON(path string, from, to interface{}, patch *[]jsonPatchOperation) error {
+	if reflect.DeepEqual(from, to) {
+		return nil
+	}
+
+	switch from := from.(type) {
+	case map[string]interface{}:
+		if to, ok := to.(map[string]interface{}); ok {
+			return diffJSONObjects(path, from, to, patch)
+		}
+	case []interface{}:
+		if to, ok := to.([]interface{}); ok {
+			return diffJSONArrays(path, from, to, patch)
+		}
+	}
+
+	return addJSONPatchOperation(patch, "replace", path, to)
+}
+
+func diffJSONObjects(path string, from, to map[string]interface{}, patch *[]jsonPatchOperation) error {
+	var keys []string
+	for key := range from {
+		keys = append(keys, key)
+	}
+	for key := range to {
+		if _, ok := from[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		keyPath := path + "/" + escapeJSONPointer(key)
+
+		toValue, inTo := to[key]
+		fromValue, inFrom := from[key]
+
+		var err error
+		switch {
+		case !inTo:
+			err = addJSONPatchOperation(patch, "remove", keyPath, nil)
+		case !inFrom:
+			err = addJSONPatchOperation(patch, "add", keyPath, toValue)
+		default:
+			err = diffJSON(keyPath, fromValue, toValue, patch)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func diffJSONArrays(path string, from, to []interface{}, patch *[]jsonPatchOperation) error {
+	for i := 0; i < len(from) && i < len(to); i++ {
+		if err := diffJSON(path+"/"+strconv.Itoa(i), from[i], to[i], patch); err != nil {
+			return err
+		}
+	}
+
+	for i := len(from); i < len(to); i++ {
+		if err := addJSONPatchOperation(patch, "add", path+"/"+strconv.Itoa(i), to[i]); err != nil {
+			return err
+		}
+	}
+
+	// Remove from the end so earlier indexes stay valid.
+	for i := len(from) - 1; i >= len(to); i-- {
+		if err := addJSONPatchOperation(patch, "remove", path+"/"+strconv.Itoa(i), nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addJSONPatchOperation(patch *[]jsonPatchOperation, op, path string, value interface{}) error {
+	operation := jsonPatchOperation{Op: op, Path: path}
+
+	if op != "remove" {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return errors.Wrapf(err, "encode value for %s", path)
+		}
+		operation.Value = data
+	}
+
+	*patch = append(*patch, operation)
+
+	return nil
+}
+
+// escapeJSONPointer escapes a key for use in a JSON pointer (RFC 6901).
+func escapeJSONPointer(key string) string {
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
+
+func comparablePodSpec(spec corev1.PodSpec) corev1.PodSpec {
+	return corev1.PodSpec{
+		InitContainers: comparableContainers(spec.InitContainers),
+		Containers:     comparableContainers(spec.Containers),
+	}
+}
+
+func comparableContainers(containers []corev1.Container) []corev1.Container {
+	var list []corev1.Container
+	for _, container := range containers {
+		list = append(list, corev1.Container{
+			Name:      container.Name,
+			Image:     container.Image,
+			Command:   container.Command,
+			Args:      container.Args,
+			Env:       container.Env,
+			EnvFrom:   container.EnvFrom,
+			Resources: container.Resources,
+		})
+	}
+
+	return list
+}
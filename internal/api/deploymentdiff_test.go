@@ -0,0 +1,166 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func controllerRef(kind, name string, uid types.UID) []metav1.OwnerReference {
+	return []metav1.OwnerReference{
+		{Kind: kind, Name: name, UID: uid, Controller: pointer.BoolPtr(true)},
+	}
+}
+
+func Test_deploymentDiffsHandler(t *testing.T) {
+	revision := map[string]string{deploymentRevisionAnnotation: "2"}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "deployment", Annotations: revision},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: "app:v2"}},
+				},
+			},
+		},
+	}
+
+	oldReplicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "app-1", Namespace: "default", UID: "rs-1",
+			Annotations:     map[string]string{deploymentRevisionAnnotation: "1"},
+			OwnerReferences: controllerRef("Deployment", "app", "deployment"),
+		},
+	}
+
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "app-2", Namespace: "default", UID: "rs-2",
+			Annotations:     revision,
+			OwnerReferences: controllerRef("Deployment", "app", "deployment"),
+		},
+	}
+
+	newPod := func(name string, owner *appsv1.ReplicaSet, image string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: "default",
+				OwnerReferences: controllerRef("ReplicaSet", owner.Name, owner.UID),
+			},
+			Spec: corev1.PodSpec{
+				NodeName:   "node",
+				Containers: []corev1.Container{{Name: "app", Image: image, TerminationMessagePath: "/dev/termination-log"}},
+			},
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		deployment,
+		oldReplicaSet,
+		replicaSet,
+		newPod("matches", replicaSet, "app:v2"),
+		newPod("patched", replicaSet, "app:debug"),
+		newPod("old-revision", oldReplicaSet, "app:v1"),
+	)
+
+	handler := newDeploymentDiffsHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/diffs/deployments/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got deploymentDiffsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	require.Len(t, got.Deployments, 1)
+	assert.Equal(t, "app", got.Deployments[0].Name)
+	assert.Equal(t, "app-2", got.Deployments[0].ReplicaSet)
+	require.Len(t, got.Deployments[0].Pods, 1)
+	assert.Equal(t, "patched", got.Deployments[0].Pods[0].Pod)
+	assert.Equal(t,
+		[]jsonPatchOperation{{Op: "replace", Path: "/containers/0/image", Value: json.RawMessage(`"app:debug"`)}},
+		got.Deployments[0].Pods[0].Patch)
+}
+
+func Test_createJSONPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		modified string
+		expected string
+	}{
+		{
+			name:     "equal",
+			original: `{"a":[1,{"b":true}]}`,
+			modified: `{"a":[1,{"b":true}]}`,
+			expected: `null`,
+		},
+		{
+			name:     "objects",
+			original: `{"keep":1,"change":"x","drop":true,"nested":{"a/b":1,"c~d":2}}`,
+			modified: `{"keep":1,"change":"y","add":null,"nested":{"a/b":2,"c~d":2}}`,
+			expected: `[
+				{"op":"add","path":"/add","value":null},
+				{"op":"replace","path":"/change","value":"y"},
+				{"op":"remove","path":"/drop"},
+				{"op":"replace","path":"/nested/a~1b","value":2}
+			]`,
+		},
+		{
+			name:     "arrays",
+			original: `{"grow":[1],"shrink":[1,2,3],"type":[1]}`,
+			modified: `{"grow":[1,2,3],"shrink":[4],"type":{"a":1}}`,
+			expected: `[
+				{"op":"add","path":"/grow/1","value":2},
+				{"op":"add","path":"/grow/2","value":3},
+				{"op":"replace","path":"/shrink/0","value":4},
+				{"op":"remove","path":"/shrink/2"},
+				{"op":"remove","path":"/shrink/1"},
+				{"op":"replace","path":"/type","value":{"a":1}}
+			]`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			patch, err := createJSONPatch([]byte(tc.original), []byte(tc.modified))
+			require.NoError(t, err)
+
+			data, err := json.Marshal(patch)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(data))
+
+			if patch == nil {
+				return
+			}
+			decoded, err := jsonpatch.DecodePatch(data)
+			require.NoError(t, err)
+			applied, err := decoded.Apply([]byte(tc.original))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.modified, string(applied), "the patch turns the original into the modified document")
+		})
+	}
+}
@@ -0,0 +1,239 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+// apiDeprecation describes a deprecated API version for a kind.
+type apiDeprecation struct {
+	APIVersion          string `json:"apiVersion"`
+	Kind                string `json:"kind"`
+	Resource            string `json:"-"`
+	DeprecatedIn        string `json:"deprecatedIn"`
+	RemovedIn           string `json:"removedIn"`
+	MigrateToAPIVersion string `json:"migrateToApiVersion"`
+}
+
+// apiDeprecations is the table of deprecated APIs. Add entries to it as new
+// Kubernetes releases deprecate APIs.
+var apiDeprecations = []apiDeprecation{
+	{APIVersion: "extensions/v1beta1", Kind: "Deployment", Resource: "deployments", DeprecatedIn: "1.9", RemovedIn: "1.16", MigrateToAPIVersion: "apps/v1"},
+	{APIVersion: "extensions/v1beta1", Kind: "DaemonSet", Resource: "daemonsets", DeprecatedIn: "1.9", RemovedIn: "1.16", MigrateToAPIVersion: "apps/v1"},
+	{APIVersion: "extensions/v1beta1", Kind: "ReplicaSet", Resource: "replicasets", DeprecatedIn: "1.9", RemovedIn: "1.16", MigrateToAPIVersion: "apps/v1"},
+	{APIVersion: "extensions/v1beta1", Kind: "NetworkPolicy", Resource: "networkpolicies", DeprecatedIn: "1.9", RemovedIn: "1.16", MigrateToAPIVersion: "networking.k8s.io/v1"},
+	{APIVersion: "extensions/v1beta1", Kind: "PodSecurityPolicy", Resource: "podsecuritypolicies", DeprecatedIn: "1.10", RemovedIn: "1.16", MigrateToAPIVersion: "policy/v1beta1"},
+	{APIVersion: "extensions/v1beta1", Kind: "Ingress", Resource: "ingresses", DeprecatedIn: "1.14", RemovedIn: "1.22", MigrateToAPIVersion: "networking.k8s.io/v1"},
+	{APIVersion: "apps/v1beta1", Kind: "Deployment", Resource: "deployments", DeprecatedIn: "1.9", RemovedIn: "1.16", MigrateToAPIVersion: "apps/v1"},
+	{APIVersion: "apps/v1beta1", Kind: "StatefulSet", Resource: "statefulsets", DeprecatedIn: "1.9", RemovedIn: "1.16", MigrateToAPIVersion: "apps/v1"},
+	{APIVersion: "apps/v1beta2", Kind: "Deployment", Resource: "deployments", DeprecatedIn: "1.9", RemovedIn: "1.16", MigrateToAPIVersion: "apps/v1"},
+	{APIVersion: "apps/v1beta2", Kind: "StatefulSet", Resource: "statefulsets", DeprecatedIn: "1.9", RemovedIn: "1.16", MigrateToAPIVersion: "apps/v1"},
+	{APIVersion: "apps/v1beta2", Kind: "DaemonSet", Resource: "daemonsets", DeprecatedIn: "1.9", RemovedIn: "1.16", MigrateToAPIVersion: "apps/v1"},
+	{APIVersion: "apps/v1beta2", Kind: "ReplicaSet", Resource: "replicasets", DeprecatedIn: "1.9", RemovedIn: "1.16", MigrateToAPIVersion: "apps/v1"},
+	{APIVersion: "scheduling.k8s.io/v1beta1", Kind: "PriorityClass", Resource: "priorityclasses", DeprecatedIn: "1.14", RemovedIn: "1.22", MigrateToAPIVersion: "scheduling.k8s.io/v1"},
+	{APIVersion: "apiextensions.k8s.io/v1beta1", Kind: "CustomResourceDefinition", Resource: "customresourcedefinitions", DeprecatedIn: "1.16", RemovedIn: "1.22", MigrateToAPIVersion: "apiextensions.k8s.io/v1"},
+	{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "MutatingWebhookConfiguration", Resource: "mutatingwebhookconfigurations", DeprecatedIn: "1.16", RemovedIn: "1.22", MigrateToAPIVersion: "admissionregistration.k8s.io/v1"},
+	{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "ValidatingWebhookConfiguration", Resource: "validatingwebhookconfigurations", DeprecatedIn: "1.16", RemovedIn: "1.22", MigrateToAPIVersion: "admissionregistration.k8s.io/v1"},
+	{APIVersion: "rbac.authorization.k8s.io/v1beta1", Kind: "ClusterRole", Resource: "clusterroles", DeprecatedIn: "1.17", RemovedIn: "1.22", MigrateToAPIVersion: "rbac.authorization.k8s.io/v1"},
+	{APIVersion: "rbac.authorization.k8s.io/v1beta1", Kind: "ClusterRoleBinding", Resource: "clusterrolebindings", DeprecatedIn: "1.17", RemovedIn: "1.22", MigrateToAPIVersion: "rbac.authorization.k8s.io/v1"},
+	{APIVersion: "rbac.authorization.k8s.io/v1beta1", Kind: "Role", Resource: "roles", DeprecatedIn: "1.17", RemovedIn: "1.22", MigrateToAPIVersion: "rbac.authorization.k8s.io/v1"},
+	{APIVersion: "rbac.authorization.k8s.io/v1beta1", Kind: "RoleBinding", Resource: "rolebindings", DeprecatedIn: "1.17", RemovedIn: "1.22", MigrateToAPIVersion: "rbac.authorization.k8s.io/v1"},
+	{APIVersion: "networking.k8s.io/v1beta1", Kind: "Ingress", Resource: "ingresses", DeprecatedIn: "1.19", RemovedIn: "1.22", MigrateToAPIVersion: "networking.k8s.io/v1"},
+	{APIVersion: "batch/v1beta1", Kind: "CronJob", Resource: "cronjobs", DeprecatedIn: "1.21", RemovedIn: "1.25", MigrateToAPIVersion: "batch/v1"},
+	{APIVersion: "policy/v1beta1", Kind: "PodDisruptionBudget", Resource: "poddisruptionbudgets", DeprecatedIn: "1.21", RemovedIn: "1.25", MigrateToAPIVersion: "policy/v1"},
+	{APIVersion: "policy/v1beta1", Kind: "PodSecurityPolicy", Resource: "podsecuritypolicies", DeprecatedIn: "1.21", RemovedIn: "1.25"},
+	{APIVersion: "discovery.k8s.io/v1beta1", Kind: "EndpointSlice", Resource: "endpointslices", DeprecatedIn: "1.21", RemovedIn: "1.25", MigrateToAPIVersion: "discovery.k8s.io/v1"},
+	{APIVersion: "autoscaling/v2beta1", Kind: "HorizontalPodAutoscaler", Resource: "horizontalpodautoscalers", DeprecatedIn: "1.22", RemovedIn: "1.25", MigrateToAPIVersion: "autoscaling/v2"},
+	{APIVersion: "autoscaling/v2beta2", Kind: "HorizontalPodAutoscaler", Resource: "horizontalpodautoscalers", DeprecatedIn: "1.23", RemovedIn: "1.26", MigrateToAPIVersion: "autoscaling/v2"},
+}
+
+const (
+	// deprecationStatusInUse means objects were last applied with the
+	// deprecated API version.
+	deprecationStatusInUse = "inUse"
+	// deprecationStatusServed means the cluster serves the deprecated API
+	// version but no object shows it being used. The API server converts
+	// objects to any served version, so listing through the deprecated
+	// version can't tell which version clients used.
+	deprecationStatusServed = "served"
+)
+
+// deprecatedAPI is a deprecated API served by the cluster and whether it is
+// in use.
+type deprecatedAPI struct {
+	apiDeprecation
+	Status string `json:"status"`
+	// Objects are the objects, as namespace/name, whose last applied
+	// configuration uses the deprecated API version.
+	Objects []string `json:"objects"`
+}
+
+type deprecationsResponse struct {
+	TargetVersion string          `json:"targetVersion"`
+	Deprecations  []deprecatedAPI `json:"deprecations"`
+}
+
+type deprecationsHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	deprecations  []apiDeprecation
+	logger        log.Logger
+}
+
+var _ http.Handler = (*deprecationsHandler)(nil)
+
+func newDeprecationsHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *deprecationsHandler {
+	return &deprecationsHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		deprecations:  apiDeprecations,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and lists deprecated APIs which are served
+// by the cluster and removed at or before the `targetVersion` query
+// parameter. An API is in use when an object's
+// kubectl.kubernetes.io/last-applied-configuration annotation has the
+// deprecated apiVersion, and is otherwise reported as served.
+func (h *deprecationsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	targetVersion := r.URL.Query().Get("targetVersion")
+	if targetVersion == "" {
+		RespondWithError(w, http.StatusBadRequest, "targetVersion is required", h.logger)
+		return
+	}
+
+	target, err := version.ParseGeneric(targetVersion)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid targetVersion: %v", err), h.logger)
+		return
+	}
+
+	served, err := h.servedResources()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := deprecationsResponse{
+		TargetVersion: targetVersion,
+		Deprecations:  []deprecatedAPI{},
+	}
+
+	for _, deprecation := range h.deprecations {
+		if !target.AtLeast(version.MustParseGeneric(deprecation.RemovedIn)) {
+			continue
+		}
+
+		gv, err := schema.ParseGroupVersion(deprecation.APIVersion)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		gvr := gv.WithResource(deprecation.Resource)
+		if !served[gvr] {
+			continue
+		}
+
+		objects, err := h.lastAppliedWith(gvr, deprecation.APIVersion)
+		if err != nil {
+			h.logger.WithErr(err).Errorf("list %s", gvr.String())
+			continue
+		}
+
+		status := deprecationStatusServed
+		if len(objects) > 0 {
+			status = deprecationStatusInUse
+		}
+
+		resp.Deprecations = append(resp.Deprecations, deprecatedAPI{
+			apiDeprecation: deprecation,
+			Status:         status,
+			Objects:        objects,
+		})
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// lastAppliedWith returns the objects of a resource, as namespace/name,
+// whose last applied configuration has the apiVersion.
+func (h *deprecationsHandler) lastAppliedWith(gvr schema.GroupVersionResource, apiVersion string) ([]string, error) {
+	client := h.dynamicClient.Resource(gvr)
+
+	objects := []string{}
+	options := metav1.ListOptions{Limit: resourceListPageSize}
+	for {
+		list, err := client.List(options)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range list.Items {
+			lastApplied, ok := object.GetAnnotations()[corev1.LastAppliedConfigAnnotation]
+			if !ok {
+				continue
+			}
+
+			var applied metav1.TypeMeta
+			if err := json.Unmarshal([]byte(lastApplied), &applied); err != nil {
+				continue
+			}
+
+			if applied.APIVersion == apiVersion {
+				objects = append(objects, path.Join(object.GetNamespace(), object.GetName()))
+			}
+		}
+
+		options.Continue = list.GetContinue()
+		if options.Continue == "" {
+			break
+		}
+	}
+
+	sort.Strings(objects)
+
+	return objects, nil
+}
+
+// servedResources returns the resources the API server serves. Groups which
+// fail discovery, such as an unavailable aggregated API, are skipped.
+func (h *deprecationsHandler) servedResources() (map[schema.GroupVersionResource]bool, error) {
+	resourceLists, err := h.kubeClient.Discovery().ServerResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	}
+
+	served := make(map[schema.GroupVersionResource]bool)
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+
+		for _, resource := range resourceList.APIResources {
+			served[gv.WithResource(resource.Name)] = true
+		}
+	}
+
+	return served, nil
+}
@@ -0,0 +1,127 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_deprecationsHandler(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "extensions/v1beta1",
+			APIResources: []metav1.APIResource{{Name: "ingresses"}, {Name: "deployments"}},
+		},
+		{
+			GroupVersion: "batch/v1beta1",
+			APIResources: []metav1.APIResource{{Name: "cronjobs"}},
+		},
+	}
+
+	object := func(apiVersion, kind, name, lastAppliedAPIVersion string) *unstructured.Unstructured {
+		metadata := map[string]interface{}{"name": name, "namespace": "default"}
+		if lastAppliedAPIVersion != "" {
+			metadata["annotations"] = map[string]interface{}{
+				"kubectl.kubernetes.io/last-applied-configuration": `{"apiVersion":"` + lastAppliedAPIVersion + `","kind":"` + kind + `"}`,
+			}
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   metadata,
+		}}
+	}
+
+	// The API server returns every object through each served version, so
+	// only the last applied configuration shows the version used.
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		object("extensions/v1beta1", "Deployment", "web", "apps/v1"),
+		object("extensions/v1beta1", "Ingress", "web", "extensions/v1beta1"),
+		object("extensions/v1beta1", "Ingress", "api", "networking.k8s.io/v1beta1"),
+		object("batch/v1beta1", "CronJob", "backup", ""),
+	)
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expected     []string
+	}{
+		{
+			name:         "target 1.16",
+			query:        "?targetVersion=1.16",
+			expectedCode: http.StatusOK,
+			expected:     []string{"extensions/v1beta1 Deployment served []"},
+		},
+		{
+			name:         "target 1.22",
+			query:        "?targetVersion=v1.22.0",
+			expectedCode: http.StatusOK,
+			expected: []string{
+				"extensions/v1beta1 Deployment served []",
+				"extensions/v1beta1 Ingress inUse [default/web]",
+			},
+		},
+		{
+			name:         "target 1.25",
+			query:        "?targetVersion=1.25",
+			expectedCode: http.StatusOK,
+			expected: []string{
+				"extensions/v1beta1 Deployment served []",
+				"extensions/v1beta1 Ingress inUse [default/web]",
+				"batch/v1beta1 CronJob served []",
+			},
+		},
+		{
+			name:         "missing target version",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid target version",
+			query:        "?targetVersion=latest",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newDeprecationsHandler(kubeClient, dynamicClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/deprecations"+tc.query, nil)
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var got deprecationsResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+			var names []string
+			for _, deprecation := range got.Deprecations {
+				names = append(names, fmt.Sprintf("%s %s %s %v", deprecation.APIVersion, deprecation.Kind, deprecation.Status, deprecation.Objects))
+			}
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+}
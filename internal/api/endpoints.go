@@ -0,0 +1,236 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// endpointSliceServiceLabel links an EndpointSlice to its service.
+	endpointSliceServiceLabel = "kubernetes.io/service-name"
+)
+
+var endpointSliceGVR = schema.GroupVersionResource{
+	Group:    "discovery.k8s.io",
+	Version:  "v1beta1",
+	Resource: "endpointslices",
+}
+
+type endpointAddress struct {
+	IP     string `json:"ip"`
+	Pod    string `json:"pod,omitempty"`
+	Node   string `json:"node,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type endpointsResponse struct {
+	Endpoints      *corev1.Endpoints        `json:"endpoints"`
+	EndpointSlices []map[string]interface{} `json:"endpointSlices"`
+	Ready          []endpointAddress        `json:"ready"`
+	NotReady       []endpointAddress        `json:"notReady"`
+	Terminating    []endpointAddress        `json:"terminating"`
+}
+
+type endpointsHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*endpointsHandler)(nil)
+
+func newEndpointsHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *endpointsHandler {
+	return &endpointsHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns a service's Endpoints and
+// EndpointSlices with its addresses grouped by readiness. Addresses of pods
+// which are being deleted are reported as terminating.
+func (h *endpointsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	service := vars["service"]
+
+	endpoints, err := h.kubeClient.CoreV1().Endpoints(namespace).Get(service, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	slices, _, err := listOptionalResource(h.dynamicClient, endpointSliceGVR, namespace, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", endpointSliceServiceLabel, service),
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	podsByName := make(map[string]*corev1.Pod)
+	for i := range pods.Items {
+		podsByName[pods.Items[i].Name] = &pods.Items[i]
+	}
+
+	resp := endpointsResponse{
+		Endpoints:      endpoints,
+		EndpointSlices: []map[string]interface{}{},
+		Ready:          []endpointAddress{},
+		NotReady:       []endpointAddress{},
+		Terminating:    []endpointAddress{},
+	}
+
+	seen := make(map[string]bool)
+	add := func(address endpointAddress, ready, terminating bool) {
+		if seen[address.IP] {
+			return
+		}
+		seen[address.IP] = true
+
+		pod := podsByName[address.Pod]
+		if pod != nil && pod.DeletionTimestamp != nil {
+			terminating = true
+		}
+
+		switch {
+		case terminating:
+			resp.Terminating = append(resp.Terminating, address)
+		case ready:
+			resp.Ready = append(resp.Ready, address)
+		default:
+			if pod != nil {
+				address.Reason = podNotReadyReason(pod)
+			}
+			resp.NotReady = append(resp.NotReady, address)
+		}
+	}
+
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			add(newEndpointAddress(address), true, false)
+		}
+		for _, address := range subset.NotReadyAddresses {
+			add(newEndpointAddress(address), false, false)
+		}
+	}
+
+	for _, slice := range slices.Items {
+		resp.EndpointSlices = append(resp.EndpointSlices, slice.Object)
+
+		items, _, _ := unstructured.NestedSlice(slice.Object, "endpoints")
+		for _, item := range items {
+			endpoint, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			for _, address := range endpointSliceAddresses(endpoint) {
+				// A missing ready condition means the endpoint is ready.
+				ready, found, _ := unstructured.NestedBool(endpoint, "conditions", "ready")
+				terminating, _, _ := unstructured.NestedBool(endpoint, "conditions", "terminating")
+				add(address, ready || !found, terminating)
+			}
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func newEndpointAddress(address corev1.EndpointAddress) endpointAddress {
+	ea := endpointAddress{IP: address.IP}
+	if address.NodeName != nil {
+		ea.Node = *address.NodeName
+	}
+	if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+		ea.Pod = address.TargetRef.Name
+	}
+
+	return ea
+}
+
+func endpointSliceAddresses(endpoint map[string]interface{}) []endpointAddress {
+	ips, _, _ := unstructured.NestedStringSlice(endpoint, "addresses")
+
+	// v1beta1 slices record the node in the endpoint's topology.
+	node, _, _ := unstructured.NestedString(endpoint, "nodeName")
+	if node == "" {
+		node, _, _ = unstructured.NestedString(endpoint, "topology", "kubernetes.io/hostname")
+	}
+
+	var pod string
+	if kind, _, _ := unstructured.NestedString(endpoint, "targetRef", "kind"); kind == "Pod" {
+		pod, _, _ = unstructured.NestedString(endpoint, "targetRef", "name")
+	}
+
+	var addresses []endpointAddress
+	for _, ip := range ips {
+		addresses = append(addresses, endpointAddress{IP: ip, Pod: pod, Node: node})
+	}
+
+	return addresses
+}
+
+// podNotReadyReason explains why a pod is not ready using its Ready condition
+// and the state of containers which are not ready.
+func podNotReadyReason(pod *corev1.Pod) string {
+	var reasons []string
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status != corev1.ConditionTrue {
+			reason := condition.Reason
+			if condition.Message != "" {
+				reason = fmt.Sprintf("%s: %s", condition.Reason, condition.Message)
+			}
+			if reason != "" {
+				reasons = append(reasons, reason)
+			}
+		}
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			continue
+		}
+
+		if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+			reasons = append(reasons, fmt.Sprintf("container %s: %s", status.Name, status.State.Waiting.Reason))
+		} else if status.State.Terminated != nil && status.State.Terminated.Reason != "" {
+			reasons = append(reasons, fmt.Sprintf("container %s: %s", status.Name, status.State.Terminated.Reason))
+		}
+	}
+
+	if len(reasons) == 0 && pod.Status.Phase != corev1.PodRunning {
+		reasons = append(reasons, fmt.Sprintf("pod is %s", pod.Status.Phase))
+	}
+
+	return strings.Join(reasons, "; ")
+}
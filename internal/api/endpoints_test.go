@@ -0,0 +1,125 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_endpointsHandler(t *testing.T) {
+	node := "node-a"
+	deleted := metav1.Now()
+
+	newAddress := func(ip, pod string) corev1.EndpointAddress {
+		return corev1.EndpointAddress{
+			IP:        ip,
+			NodeName:  &node,
+			TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: pod},
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Subsets: []corev1.EndpointSubset{
+				{
+					Addresses:         []corev1.EndpointAddress{newAddress("10.0.0.1", "web-1"), newAddress("10.0.0.3", "web-3")},
+					NotReadyAddresses: []corev1.EndpointAddress{newAddress("10.0.0.2", "web-2")},
+				},
+			},
+		},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "default"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.PodReady, Status: corev1.ConditionFalse, Reason: "ContainersNotReady"},
+				},
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:  "app",
+						State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					},
+				},
+			},
+		},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-3", Namespace: "default", DeletionTimestamp: &deleted}},
+	)
+
+	slice := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "discovery.k8s.io/v1beta1",
+		"kind":       "EndpointSlice",
+		"metadata": map[string]interface{}{
+			"name":      "web-abcde",
+			"namespace": "default",
+			"labels":    map[string]interface{}{endpointSliceServiceLabel: "web"},
+		},
+		"endpoints": []interface{}{
+			map[string]interface{}{
+				"addresses":  []interface{}{"10.0.0.1"},
+				"conditions": map[string]interface{}{"ready": true},
+			},
+			map[string]interface{}{
+				"addresses":  []interface{}{"10.0.0.4"},
+				"conditions": map[string]interface{}{"ready": false, "terminating": true},
+				"topology":   map[string]interface{}{"kubernetes.io/hostname": "node-b"},
+				"targetRef":  map[string]interface{}{"kind": "Pod", "name": "web-4"},
+			},
+		},
+	}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), slice)
+
+	handler := newEndpointsHandler(kubeClient, dynamicClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/endpoints/default/web", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default", "service": "web"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got endpointsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	require.NotNil(t, got.Endpoints)
+	assert.Len(t, got.EndpointSlices, 1)
+	assert.Equal(t, []endpointAddress{{IP: "10.0.0.1", Pod: "web-1", Node: "node-a"}}, got.Ready)
+	assert.Equal(t, []endpointAddress{
+		{IP: "10.0.0.2", Pod: "web-2", Node: "node-a", Reason: "ContainersNotReady; container app: CrashLoopBackOff"},
+	}, got.NotReady)
+	assert.Equal(t, []endpointAddress{
+		{IP: "10.0.0.3", Pod: "web-3", Node: "node-a"},
+		{IP: "10.0.0.4", Pod: "web-4", Node: "node-b"},
+	}, got.Terminating)
+}
+
+func Test_endpointsHandler_not_found(t *testing.T) {
+	handler := newEndpointsHandler(kubefake.NewSimpleClientset(),
+		dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/endpoints/default/missing", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default", "service": "missing"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
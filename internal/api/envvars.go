@@ -0,0 +1,230 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	envSourceLiteral          = "literal"
+	envSourceConfigMapKeyRef  = "configMapKeyRef"
+	envSourceSecretKeyRef     = "secretKeyRef"
+	envSourceFieldRef         = "fieldRef"
+	envSourceResourceFieldRef = "resourceFieldRef"
+	envSourceConfigMapRef     = "configMapRef"
+	envSourceSecretRef        = "secretRef"
+)
+
+// sensitiveNames are the name fragments of environment variables and Helm
+// values which hold credentials and should be read from secrets.
+var sensitiveNames = []string{"PASSWORD", "SECRET", "TOKEN", "KEY"}
+
+type envVarRef struct {
+	Name string `json:"name"`
+	Key  string `json:"key,omitempty"`
+}
+
+type envVarSummary struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	// Value is the literal value. It is left out unless literal values were
+	// requested.
+	Value     string     `json:"value,omitempty"`
+	Redacted  bool       `json:"redacted,omitempty"`
+	Ref       *envVarRef `json:"ref,omitempty"`
+	FieldPath string     `json:"fieldPath,omitempty"`
+	// Flagged is set for literal values whose name suggests they are
+	// sensitive.
+	Flagged bool `json:"flagged"`
+}
+
+type envFromSummary struct {
+	Source string `json:"source"`
+	Name   string `json:"name"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+type containerEnvVars struct {
+	Name    string           `json:"name"`
+	Env     []envVarSummary  `json:"env"`
+	EnvFrom []envFromSummary `json:"envFrom"`
+}
+
+type podEnvVars struct {
+	Pod        string             `json:"pod"`
+	Containers []containerEnvVars `json:"containers"`
+}
+
+type envVarsResponse struct {
+	ShowLiteralValues bool         `json:"showLiteralValues"`
+	FlaggedCount      int          `json:"flaggedCount"`
+	Pods              []podEnvVars `json:"pods"`
+}
+
+type envVarsHandler struct {
+	kubeClient kubernetes.Interface
+	user       string
+	logger     log.Logger
+}
+
+var _ http.Handler = (*envVarsHandler)(nil)
+
+func newEnvVarsHandler(kubeClient kubernetes.Interface, user string, logger log.Logger) *envVarsHandler {
+	return &envVarsHandler{
+		kubeClient: kubeClient,
+		user:       user,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the environment variables of
+// the containers in a namespace's pods with where their values come from.
+// Literal values are redacted unless `showLiteralValues=true` is set, which
+// requires permission to get secrets in the namespace. Literal values whose
+// names contain PASSWORD, SECRET, TOKEN or KEY are flagged.
+func (h *envVarsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	showLiteralValues := r.URL.Query().Get("showLiteralValues") == "true"
+
+	if showLiteralValues {
+		allowed, err := canGetSecrets(h.kubeClient, userSessionFrom(r.Context()), namespace)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+		if !allowed {
+			RespondWithError(w, http.StatusForbidden, "showing literal values requires permission to get secrets in "+namespace, h.logger)
+			return
+		}
+
+		h.logger.With("user", requesterName(r, h.user), "namespace", namespace).Infof("showing literal environment variable values")
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := envVarsResponse{
+		ShowLiteralValues: showLiteralValues,
+		Pods:              []podEnvVars{},
+	}
+
+	for _, pod := range pods.Items {
+		item := podEnvVars{
+			Pod:        pod.Name,
+			Containers: []containerEnvVars{},
+		}
+
+		containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for _, container := range containers {
+			if len(container.Env) == 0 && len(container.EnvFrom) == 0 {
+				continue
+			}
+
+			summary := containerEnvVars{
+				Name:    container.Name,
+				Env:     []envVarSummary{},
+				EnvFrom: []envFromSummary{},
+			}
+
+			for _, env := range container.Env {
+				envVar := summarizeEnvVar(env, showLiteralValues)
+				if envVar.Flagged {
+					resp.FlaggedCount++
+				}
+				summary.Env = append(summary.Env, envVar)
+			}
+
+			for _, envFrom := range container.EnvFrom {
+				switch {
+				case envFrom.ConfigMapRef != nil:
+					summary.EnvFrom = append(summary.EnvFrom, envFromSummary{Source: envSourceConfigMapRef, Name: envFrom.ConfigMapRef.Name, Prefix: envFrom.Prefix})
+				case envFrom.SecretRef != nil:
+					summary.EnvFrom = append(summary.EnvFrom, envFromSummary{Source: envSourceSecretRef, Name: envFrom.SecretRef.Name, Prefix: envFrom.Prefix})
+				}
+			}
+
+			item.Containers = append(item.Containers, summary)
+		}
+
+		if len(item.Containers) > 0 {
+			resp.Pods = append(resp.Pods, item)
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// canGetSecrets asks the cluster whether the requester can get secrets in the
+// namespace.
+func canGetSecrets(kubeClient kubernetes.Interface, requester *userSession, namespace string) (bool, error) {
+	allowed, err := reviewAccess(kubeClient, requester, &authorizationv1.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      "get",
+		Resource:  "secrets",
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "review access to secrets")
+	}
+
+	return allowed, nil
+}
+
+func summarizeEnvVar(env corev1.EnvVar, showLiteralValues bool) envVarSummary {
+	summary := envVarSummary{
+		Name:   env.Name,
+		Source: envSourceLiteral,
+	}
+
+	source := env.ValueFrom
+	switch {
+	case source == nil:
+		summary.Flagged = env.Value != "" && isSensitiveName(env.Name)
+		if showLiteralValues {
+			summary.Value = env.Value
+		} else {
+			summary.Redacted = env.Value != ""
+		}
+	case source.ConfigMapKeyRef != nil:
+		summary.Source = envSourceConfigMapKeyRef
+		summary.Ref = &envVarRef{Name: source.ConfigMapKeyRef.Name, Key: source.ConfigMapKeyRef.Key}
+	case source.SecretKeyRef != nil:
+		summary.Source = envSourceSecretKeyRef
+		summary.Ref = &envVarRef{Name: source.SecretKeyRef.Name, Key: source.SecretKeyRef.Key}
+	case source.FieldRef != nil:
+		summary.Source = envSourceFieldRef
+		summary.FieldPath = source.FieldRef.FieldPath
+	case source.ResourceFieldRef != nil:
+		summary.Source = envSourceResourceFieldRef
+		summary.Ref = &envVarRef{Name: source.ResourceFieldRef.ContainerName, Key: source.ResourceFieldRef.Resource}
+	}
+
+	return summary
+}
+
+func isSensitiveName(name string) bool {
+	name = strings.ToUpper(name)
+	for _, fragment := range sensitiveNames {
+		if strings.Contains(name, fragment) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,181 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_envVarsHandler(t *testing.T) {
+	pods := []runtime.Object{
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "setup"}},
+				Containers: []corev1.Container{
+					{
+						Name: "web",
+						Env: []corev1.EnvVar{
+							{Name: "LOG_LEVEL", Value: "debug"},
+							{Name: "DB_PASSWORD", Value: "hunter2"},
+							{Name: "API_TOKEN", ValueFrom: &corev1.EnvVarSource{
+								SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "api"}, Key: "token"},
+							}},
+							{Name: "MODE", ValueFrom: &corev1.EnvVarSource{
+								ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "web"}, Key: "mode"},
+							}},
+							{Name: "POD_IP", ValueFrom: &corev1.EnvVarSource{
+								FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.podIP"},
+							}},
+						},
+						EnvFrom: []corev1.EnvFromSource{
+							{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "defaults"}}},
+							{Prefix: "S3_", SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "s3"}}},
+						},
+					},
+				},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "default"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		},
+	}
+
+	expectedEnv := func(showLiteralValues bool) []envVarSummary {
+		logLevel := envVarSummary{Name: "LOG_LEVEL", Source: envSourceLiteral, Redacted: true}
+		password := envVarSummary{Name: "DB_PASSWORD", Source: envSourceLiteral, Redacted: true, Flagged: true}
+		if showLiteralValues {
+			logLevel.Redacted, logLevel.Value = false, "debug"
+			password.Redacted, password.Value = false, "hunter2"
+		}
+
+		return []envVarSummary{
+			logLevel,
+			password,
+			{Name: "API_TOKEN", Source: envSourceSecretKeyRef, Ref: &envVarRef{Name: "api", Key: "token"}},
+			{Name: "MODE", Source: envSourceConfigMapKeyRef, Ref: &envVarRef{Name: "web", Key: "mode"}},
+			{Name: "POD_IP", Source: envSourceFieldRef, FieldPath: "status.podIP"},
+		}
+	}
+
+	expectedEnvFrom := []envFromSummary{
+		{Source: envSourceConfigMapRef, Name: "defaults"},
+		{Source: envSourceSecretRef, Name: "s3", Prefix: "S3_"},
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		requester    *userSession
+		allowed      bool
+		expectedCode int
+		expectedShow bool
+	}{
+		{
+			name:         "literal values redacted",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "literal values shown",
+			query:        "?showLiteralValues=true",
+			allowed:      true,
+			expectedCode: http.StatusOK,
+			expectedShow: true,
+		},
+		{
+			name:         "literal values forbidden",
+			query:        "?showLiteralValues=true",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "literal values shown to session requester",
+			query:        "?showLiteralValues=true",
+			requester:    &userSession{User: "alice", Groups: []string{"developers"}},
+			allowed:      true,
+			expectedCode: http.StatusOK,
+			expectedShow: true,
+		},
+		{
+			name:         "literal values forbidden to session requester",
+			query:        "?showLiteralValues=true",
+			requester:    &userSession{User: "alice", Groups: []string{"developers"}},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(pods...)
+			kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				assert.Nil(t, tc.requester, "session requesters are reviewed as their user")
+				review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+				assert.Equal(t, "secrets", review.Spec.ResourceAttributes.Resource)
+				assert.Equal(t, "default", review.Spec.ResourceAttributes.Namespace)
+				review.Status.Allowed = tc.allowed
+				return true, review, nil
+			})
+			kubeClient.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				require.NotNil(t, tc.requester)
+				review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+				assert.Equal(t, tc.requester.User, review.Spec.User)
+				assert.Equal(t, tc.requester.Groups, review.Spec.Groups)
+				assert.Equal(t, "secrets", review.Spec.ResourceAttributes.Resource)
+				assert.Equal(t, "default", review.Spec.ResourceAttributes.Namespace)
+				review.Status.Allowed = tc.allowed
+				return true, review, nil
+			})
+
+			handler := newEnvVarsHandler(kubeClient, "user", log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/envvars"+tc.query, nil)
+			if tc.requester != nil {
+				req = req.WithContext(context.WithValue(req.Context(), userSessionContextKey{}, tc.requester))
+			}
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var resp envVarsResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+			expected := []podEnvVars{
+				{
+					Pod: "web",
+					Containers: []containerEnvVars{
+						{Name: "web", Env: expectedEnv(tc.expectedShow), EnvFrom: expectedEnvFrom},
+					},
+				},
+			}
+
+			assert.Equal(t, tc.expectedShow, resp.ShowLiteralValues)
+			assert.Equal(t, 1, resp.FlaggedCount)
+			assert.Equal(t, expected, resp.Pods)
+		})
+	}
+}
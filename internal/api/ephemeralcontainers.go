@@ -0,0 +1,246 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+// ephemeralContainersSubresource is the pod subresource ephemeral containers
+// are added through.
+const ephemeralContainersSubresource = "ephemeralcontainers"
+
+type ephemeralContainer struct {
+	Name                string     `json:"name"`
+	Image               string     `json:"image"`
+	TargetContainerName string     `json:"targetContainerName,omitempty"`
+	Command             []string   `json:"command,omitempty"`
+	Args                []string   `json:"args,omitempty"`
+	State               string     `json:"state"`
+	Reason              string     `json:"reason,omitempty"`
+	Message             string     `json:"message,omitempty"`
+	ExitCode            *int32     `json:"exitCode,omitempty"`
+	StartedAt           *time.Time `json:"startedAt,omitempty"`
+}
+
+type ephemeralContainersResponse struct {
+	Pod        string               `json:"pod"`
+	Containers []ephemeralContainer `json:"containers"`
+}
+
+type addEphemeralContainerRequest struct {
+	Name                string   `json:"name"`
+	Image               string   `json:"image"`
+	TargetContainerName string   `json:"targetContainerName"`
+	Command             []string `json:"command"`
+	Args                []string `json:"args"`
+}
+
+type ephemeralContainersHandler struct {
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+func newEphemeralContainersHandler(dynamicClient dynamic.Interface, logger log.Logger) *ephemeralContainersHandler {
+	return &ephemeralContainersHandler{
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// list returns a pod's ephemeral containers and their current state. Pods
+// are read with the dynamic client because the client's pod type predates
+// ephemeral containers.
+func (h *ephemeralContainersHandler) list(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	pod, err := h.dynamicClient.Resource(podGVR).Namespace(vars["namespace"]).Get(vars["pod"], metav1.GetOptions{})
+	if err != nil {
+		RespondWithError(w, statusForKubeError(err), err.Error(), h.logger)
+		return
+	}
+
+	resp := ephemeralContainers(pod)
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// add adds an ephemeral container to a pod through its ephemeral containers
+// subresource and returns the pod's ephemeral containers. The name must not be
+// used by any of the pod's containers. If set, the target container's process
+// namespace is shared with the new container.
+func (h *ephemeralContainersHandler) add(w http.ResponseWriter, r *http.Request) {
+	var req addEphemeralContainerRequest
+
+	defer func() {
+		if cErr := r.Body.Close(); cErr != nil {
+			h.logger.WithErr(cErr).Errorf("unable to close ephemeral container request body")
+		}
+	}()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
+	if req.Name == "" || req.Image == "" {
+		RespondWithError(w, http.StatusBadRequest, "name and image are required", h.logger)
+		return
+	}
+
+	vars := mux.Vars(r)
+	namespace, name := vars["namespace"], vars["pod"]
+	pods := h.dynamicClient.Resource(podGVR).Namespace(namespace)
+
+	pod, err := pods.Get(name, metav1.GetOptions{})
+	if err != nil {
+		RespondWithError(w, statusForKubeError(err), err.Error(), h.logger)
+		return
+	}
+
+	names := podContainerNames(pod)
+	if names[req.Name] {
+		RespondWithError(w, http.StatusConflict, fmt.Sprintf("pod %q already has a container named %q", name, req.Name), h.logger)
+		return
+	}
+	if req.TargetContainerName != "" && !names[req.TargetContainerName] {
+		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("pod %q has no container named %q", name, req.TargetContainerName), h.logger)
+		return
+	}
+
+	container := map[string]interface{}{
+		"name":                     req.Name,
+		"image":                    req.Image,
+		"terminationMessagePolicy": string(corev1.TerminationMessageReadFile),
+		"stdin":                    true,
+		"tty":                      true,
+	}
+	if req.TargetContainerName != "" {
+		container["targetContainerName"] = req.TargetContainerName
+	}
+	if len(req.Command) > 0 {
+		container["command"] = req.Command
+	}
+	if len(req.Args) > 0 {
+		container["args"] = req.Args
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ephemeralContainers": []interface{}{container},
+		},
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	updated, err := pods.Patch(name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}, ephemeralContainersSubresource)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			RespondWithError(w, http.StatusNotImplemented, "the cluster does not support ephemeral containers", h.logger)
+			return
+		}
+		RespondWithError(w, statusForKubeError(err), err.Error(), h.logger)
+		return
+	}
+
+	h.logger.With("namespace", namespace, "pod", name, "container", req.Name).Infof("added ephemeral container")
+
+	resp := ephemeralContainers(updated)
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func ephemeralContainers(pod *unstructured.Unstructured) ephemeralContainersResponse {
+	resp := ephemeralContainersResponse{
+		Pod:        pod.GetName(),
+		Containers: []ephemeralContainer{},
+	}
+
+	statuses := make(map[string]corev1.ContainerStatus)
+	items, _, _ := unstructured.NestedSlice(pod.Object, "status", "ephemeralContainerStatuses")
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var status corev1.ContainerStatus
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &status); err != nil {
+			continue
+		}
+		statuses[status.Name] = status
+	}
+
+	specs, _, _ := unstructured.NestedSlice(pod.Object, "spec", "ephemeralContainers")
+	for _, item := range specs {
+		spec, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		container := ephemeralContainer{State: "unknown"}
+		container.Name, _, _ = unstructured.NestedString(spec, "name")
+		container.Image, _, _ = unstructured.NestedString(spec, "image")
+		container.TargetContainerName, _, _ = unstructured.NestedString(spec, "targetContainerName")
+		container.Command, _, _ = unstructured.NestedStringSlice(spec, "command")
+		container.Args, _, _ = unstructured.NestedStringSlice(spec, "args")
+
+		if status, ok := statuses[container.Name]; ok {
+			switch state := status.State; {
+			case state.Running != nil:
+				container.State = "running"
+				startedAt := state.Running.StartedAt.UTC()
+				container.StartedAt = &startedAt
+			case state.Waiting != nil:
+				container.State = "waiting"
+				container.Reason = state.Waiting.Reason
+				container.Message = state.Waiting.Message
+			case state.Terminated != nil:
+				container.State = "terminated"
+				container.Reason = state.Terminated.Reason
+				container.Message = state.Terminated.Message
+				exitCode := state.Terminated.ExitCode
+				container.ExitCode = &exitCode
+			}
+		}
+
+		resp.Containers = append(resp.Containers, container)
+	}
+
+	return resp
+}
+
+// podContainerNames returns the names of a pod's containers, init containers
+// and ephemeral containers.
+func podContainerNames(pod *unstructured.Unstructured) map[string]bool {
+	names := make(map[string]bool)
+
+	for _, field := range []string{"containers", "initContainers", "ephemeralContainers"} {
+		containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", field)
+		for _, item := range containers {
+			if container, ok := item.(map[string]interface{}); ok {
+				if name, _, _ := unstructured.NestedString(container, "name"); name != "" {
+					names[name] = true
+				}
+			}
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,141 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_ephemeralContainersHandler(t *testing.T) {
+	newPod := func() *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+			"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{"name": "app", "image": "web:1"},
+				},
+				"ephemeralContainers": []interface{}{
+					map[string]interface{}{
+						"name":                "debugger",
+						"image":               "busybox",
+						"targetContainerName": "app",
+						"command":             []interface{}{"sh"},
+					},
+				},
+			},
+			"status": map[string]interface{}{
+				"ephemeralContainerStatuses": []interface{}{
+					map[string]interface{}{
+						"name": "debugger",
+						"state": map[string]interface{}{
+							"terminated": map[string]interface{}{"reason": "Completed", "exitCode": int64(0)},
+						},
+					},
+				},
+			},
+		}}
+	}
+
+	exitCode := int32(0)
+	debugger := ephemeralContainer{
+		Name:                "debugger",
+		Image:               "busybox",
+		TargetContainerName: "app",
+		Command:             []string{"sh"},
+		State:               "terminated",
+		Reason:              "Completed",
+		ExitCode:            &exitCode,
+	}
+
+	serve := func(handler http.HandlerFunc, method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/ephemeralcontainers/default/web", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"namespace": "default", "pod": "web"})
+
+		resp := httptest.NewRecorder()
+		handler(resp, req)
+		return resp
+	}
+
+	t.Run("list", func(t *testing.T) {
+		handler := newEphemeralContainersHandler(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newPod()), log.NopLogger())
+
+		resp := serve(handler.list, http.MethodGet, "")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var got ephemeralContainersResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		assert.Equal(t, ephemeralContainersResponse{Pod: "web", Containers: []ephemeralContainer{debugger}}, got)
+	})
+
+	t.Run("add", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newPod())
+
+		var patch clienttesting.PatchAction
+		dynamicClient.PrependReactor("patch", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+			patch = action.(clienttesting.PatchAction)
+
+			updated := newPod()
+			containers, _, _ := unstructured.NestedSlice(updated.Object, "spec", "ephemeralContainers")
+			containers = append(containers, map[string]interface{}{"name": "shell", "image": "alpine"})
+			require.NoError(t, unstructured.SetNestedSlice(updated.Object, containers, "spec", "ephemeralContainers"))
+			return true, updated, nil
+		})
+
+		handler := newEphemeralContainersHandler(dynamicClient, log.NopLogger())
+
+		resp := serve(handler.add, http.MethodPost, `{"name":"shell","image":"alpine","targetContainerName":"app"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		require.NotNil(t, patch)
+		assert.Equal(t, ephemeralContainersSubresource, patch.GetSubresource())
+		assert.Equal(t, types.StrategicMergePatchType, patch.GetPatchType())
+
+		var sent map[string]interface{}
+		require.NoError(t, json.Unmarshal(patch.GetPatch(), &sent))
+		added, _, _ := unstructured.NestedSlice(sent, "spec", "ephemeralContainers")
+		require.Len(t, added, 1)
+		assert.Equal(t, "shell", added[0].(map[string]interface{})["name"])
+		assert.Equal(t, "app", added[0].(map[string]interface{})["targetContainerName"])
+
+		var got ephemeralContainersResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		assert.Equal(t, []ephemeralContainer{
+			debugger,
+			{Name: "shell", Image: "alpine", State: "unknown"},
+		}, got.Containers)
+	})
+
+	t.Run("add rejects invalid requests", func(t *testing.T) {
+		handler := newEphemeralContainersHandler(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newPod()), log.NopLogger())
+
+		assert.Equal(t, http.StatusBadRequest, serve(handler.add, http.MethodPost, `{"name":"shell"}`).Code)
+		assert.Equal(t, http.StatusConflict, serve(handler.add, http.MethodPost, `{"name":"app","image":"alpine"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, serve(handler.add, http.MethodPost, `{"name":"shell","image":"alpine","targetContainerName":"db"}`).Code)
+	})
+
+	t.Run("missing pod", func(t *testing.T) {
+		handler := newEphemeralContainersHandler(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), log.NopLogger())
+
+		assert.Equal(t, http.StatusNotFound, serve(handler.list, http.MethodGet, "").Code)
+	})
+}
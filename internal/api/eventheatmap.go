@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	eventHeatmapBins   = 60
+	eventHeatmapBinLen = time.Minute
+)
+
+type eventHeatmapBin struct {
+	Minute       string `json:"minute"`
+	WarningCount int    `json:"warningCount"`
+	NormalCount  int    `json:"normalCount"`
+}
+
+type eventHeatmapResponse struct {
+	Bins []eventHeatmapBin `json:"bins"`
+}
+
+type eventHeatmapHandler struct {
+	kubeClient kubernetes.Interface
+	nowFn      func() time.Time
+	logger     log.Logger
+}
+
+var _ http.Handler = (*eventHeatmapHandler)(nil)
+
+func newEventHeatmapHandler(kubeClient kubernetes.Interface, logger log.Logger) *eventHeatmapHandler {
+	return &eventHeatmapHandler{
+		kubeClient: kubeClient,
+		nowFn:      time.Now,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and counts the events last seen in each
+// minute of the past hour, oldest first. The last bin is the current minute.
+// Events can be limited with the `namespace` and `reason` query parameters.
+func (h *eventHeatmapHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	reason := r.URL.Query().Get("reason")
+
+	events, err := listEventsPaged(h.kubeClient, namespace)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	end := h.nowFn().UTC().Truncate(eventHeatmapBinLen).Add(eventHeatmapBinLen)
+	start := end.Add(-eventHeatmapBins * eventHeatmapBinLen)
+
+	resp := eventHeatmapResponse{
+		Bins: make([]eventHeatmapBin, eventHeatmapBins),
+	}
+	for i := range resp.Bins {
+		resp.Bins[i].Minute = start.Add(time.Duration(i) * eventHeatmapBinLen).Format(time.RFC3339)
+	}
+
+	for _, event := range events {
+		if reason != "" && event.Reason != reason {
+			continue
+		}
+
+		timestamp := eventTimestamp(event)
+		if timestamp.Before(start) || !timestamp.Before(end) {
+			continue
+		}
+
+		bin := &resp.Bins[int(timestamp.Sub(start)/eventHeatmapBinLen)]
+		if event.Type == corev1.EventTypeWarning {
+			bin.WarningCount++
+		} else {
+			bin.NormalCount++
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_eventHeatmapHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 30, 20, 0, time.UTC)
+
+	newEvent := func(name, namespace, eventType, reason string, ago time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:    metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Type:          eventType,
+			Reason:        reason,
+			LastTimestamp: metav1.NewTime(now.Add(-ago)),
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newEvent("current", "default", corev1.EventTypeWarning, "BackOff", 10*time.Second),
+		newEvent("current-normal", "default", corev1.EventTypeNormal, "Pulled", 5*time.Second),
+		newEvent("other-namespace", "kube-system", corev1.EventTypeWarning, "BackOff", 15*time.Second),
+		newEvent("oldest", "default", corev1.EventTypeNormal, "Pulled", 59*time.Minute),
+		newEvent("expired", "default", corev1.EventTypeWarning, "BackOff", 61*time.Minute),
+	)
+
+	tests := []struct {
+		name  string
+		query string
+		first eventHeatmapBin
+		last  eventHeatmapBin
+	}{
+		{
+			name:  "all events",
+			first: eventHeatmapBin{Minute: "2019-10-01T11:31:00Z", NormalCount: 1},
+			last:  eventHeatmapBin{Minute: "2019-10-01T12:30:00Z", WarningCount: 2, NormalCount: 1},
+		},
+		{
+			name:  "namespace",
+			query: "?namespace=default",
+			first: eventHeatmapBin{Minute: "2019-10-01T11:31:00Z", NormalCount: 1},
+			last:  eventHeatmapBin{Minute: "2019-10-01T12:30:00Z", WarningCount: 1, NormalCount: 1},
+		},
+		{
+			name:  "reason",
+			query: "?reason=BackOff",
+			first: eventHeatmapBin{Minute: "2019-10-01T11:31:00Z"},
+			last:  eventHeatmapBin{Minute: "2019-10-01T12:30:00Z", WarningCount: 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := newEventHeatmapHandler(kubeClient, log.NopLogger())
+			handler.nowFn = func() time.Time { return now }
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/events/timeline/heatmap"+test.query, nil)
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got eventHeatmapResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+			require.Len(t, got.Bins, eventHeatmapBins)
+			assert.Equal(t, test.first, got.Bins[0])
+			assert.Equal(t, test.last, got.Bins[eventHeatmapBins-1])
+
+			total := 0
+			for _, bin := range got.Bins[1 : eventHeatmapBins-1] {
+				total += bin.WarningCount + bin.NormalCount
+			}
+			assert.Zero(t, total)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// listEventsPaged lists the events in a namespace, or all namespaces, a page
+// at a time so large clusters aren't fetched in one response.
+func listEventsPaged(kubeClient kubernetes.Interface, namespace string) ([]corev1.Event, error) {
+	var events []corev1.Event
+
+	options := metav1.ListOptions{Limit: resourceListPageSize}
+	for {
+		list, err := kubeClient.CoreV1().Events(namespace).List(options)
+		if err != nil {
+			return nil, errors.Wrap(err, "list events")
+		}
+
+		events = append(events, list.Items...)
+
+		options.Continue = list.Continue
+		if options.Continue == "" {
+			return events, nil
+		}
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func Test_listEventsPaged(t *testing.T) {
+	event := func(name string) corev1.Event {
+		return corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+	}
+
+	pages := map[string]corev1.EventList{
+		"":       {ListMeta: metav1.ListMeta{Continue: "page-2"}, Items: []corev1.Event{event("a"), event("b")}},
+		"page-2": {Items: []corev1.Event{event("c")}},
+	}
+
+	var continues []string
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/namespaces/default/events", r.URL.Path)
+		assert.Equal(t, strconv.Itoa(resourceListPageSize), r.URL.Query().Get("limit"))
+
+		continueToken := r.URL.Query().Get("continue")
+		continues = append(continues, continueToken)
+
+		page := pages[continueToken]
+		page.Kind, page.APIVersion = "EventList", "v1"
+
+		w.Header().Set("Content-Type", "application/json")
+		assert.NoError(t, json.NewEncoder(w).Encode(page))
+	}))
+	defer apiServer.Close()
+
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: apiServer.URL})
+	require.NoError(t, err)
+
+	events, err := listEventsPaged(kubeClient, "default")
+	require.NoError(t, err)
+
+	var names []string
+	for _, event := range events {
+		names = append(names, event.Name)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, names)
+	assert.Equal(t, []string{"", "page-2"}, continues)
+}
@@ -0,0 +1,276 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	defaultEventTimelineWindow = time.Hour
+	defaultEventTimelineLimit  = 100
+	maxEventTimelineLimit      = 500
+)
+
+type timelineObject struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type timelineEvent struct {
+	Namespace      string         `json:"namespace"`
+	Name           string         `json:"name"`
+	InvolvedObject timelineObject `json:"involvedObject"`
+	Type           string         `json:"type"`
+	Reason         string         `json:"reason"`
+	Message        string         `json:"message"`
+	Count          int32          `json:"count"`
+	LastTimestamp  time.Time      `json:"lastTimestamp"`
+}
+
+type eventTimelineResponse struct {
+	Events     []timelineEvent `json:"events"`
+	NextCursor string          `json:"nextCursor,omitempty"`
+}
+
+// timelineCursor is the position of the last event in a page.
+type timelineCursor struct {
+	Timestamp time.Time `json:"t"`
+	Namespace string    `json:"ns"`
+	Name      string    `json:"n"`
+}
+
+type eventTimelineHandler struct {
+	kubeClient kubernetes.Interface
+	nowFn      func() time.Time
+	logger     log.Logger
+}
+
+var _ http.Handler = (*eventTimelineHandler)(nil)
+
+func newEventTimelineHandler(kubeClient kubernetes.Interface, logger log.Logger) *eventTimelineHandler {
+	return &eventTimelineHandler{
+		kubeClient: kubeClient,
+		nowFn:      time.Now,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns events between the `from` and
+// `to` query parameters ordered by time. Events come from all namespaces
+// unless `namespaces` lists them. Results are paged with `limit` and the
+// `cursor` returned from the previous page.
+func (h *eventTimelineHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	to := h.nowFn()
+	if s := query.Get("to"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid to: %v", err), h.logger)
+			return
+		}
+		to = t
+	}
+
+	from := to.Add(-defaultEventTimelineWindow)
+	if s := query.Get("from"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid from: %v", err), h.logger)
+			return
+		}
+		from = t
+	}
+
+	if from.After(to) {
+		RespondWithError(w, http.StatusBadRequest, "from must be before to", h.logger)
+		return
+	}
+
+	limit := defaultEventTimelineLimit
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			RespondWithError(w, http.StatusBadRequest, "limit must be a positive integer", h.logger)
+			return
+		}
+		if n > maxEventTimelineLimit {
+			n = maxEventTimelineLimit
+		}
+		limit = n
+	}
+
+	var cursor *timelineCursor
+	if s := query.Get("cursor"); s != "" {
+		c, err := decodeTimelineCursor(s)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error(), h.logger)
+			return
+		}
+		cursor = c
+	}
+
+	namespaces := []string{metav1.NamespaceAll}
+	if s := query.Get("namespaces"); s != "" {
+		namespaces = strings.Split(s, ",")
+	}
+
+	events, err := h.listEvents(namespaces)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	var timeline []timelineEvent
+	for _, event := range events {
+		te := newTimelineEvent(event)
+		if te.LastTimestamp.Before(from) || te.LastTimestamp.After(to) {
+			continue
+		}
+		if cursor != nil && !timelineAfter(te, *cursor) {
+			continue
+		}
+		timeline = append(timeline, te)
+	}
+
+	sort.Slice(timeline, func(i, j int) bool {
+		return timelineLess(timeline[i], timeline[j])
+	})
+
+	resp := eventTimelineResponse{
+		Events: []timelineEvent{},
+	}
+
+	if len(timeline) > limit {
+		timeline = timeline[:limit]
+		last := timeline[limit-1]
+
+		next, err := encodeTimelineCursor(timelineCursor{Timestamp: last.LastTimestamp, Namespace: last.Namespace, Name: last.Name})
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+		resp.NextCursor = next
+	}
+
+	resp.Events = append(resp.Events, timeline...)
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// listEvents lists events from each namespace in parallel, a page at a time.
+func (h *eventTimelineHandler) listEvents(namespaces []string) ([]corev1.Event, error) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		events []corev1.Event
+		errs   []string
+	)
+
+	for _, namespace := range namespaces {
+		wg.Add(1)
+		go func(namespace string) {
+			defer wg.Done()
+
+			list, err := listEventsPaged(h.kubeClient, strings.TrimSpace(namespace))
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("in %q: %v", namespace, err))
+				return
+			}
+			events = append(events, list...)
+		}(namespace)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+
+	return events, nil
+}
+
+func newTimelineEvent(event corev1.Event) timelineEvent {
+	timestamp := event.LastTimestamp.Time
+	if timestamp.IsZero() {
+		timestamp = event.EventTime.Time
+	}
+	if timestamp.IsZero() {
+		timestamp = event.FirstTimestamp.Time
+	}
+
+	return timelineEvent{
+		Namespace: event.Namespace,
+		Name:      event.Name,
+		InvolvedObject: timelineObject{
+			Kind: event.InvolvedObject.Kind,
+			Name: event.InvolvedObject.Name,
+		},
+		Type:          event.Type,
+		Reason:        event.Reason,
+		Message:       event.Message,
+		Count:         event.Count,
+		LastTimestamp: timestamp.UTC(),
+	}
+}
+
+func timelineLess(a, b timelineEvent) bool {
+	if !a.LastTimestamp.Equal(b.LastTimestamp) {
+		return a.LastTimestamp.Before(b.LastTimestamp)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+func timelineAfter(event timelineEvent, cursor timelineCursor) bool {
+	return timelineLess(timelineEvent{LastTimestamp: cursor.Timestamp, Namespace: cursor.Namespace, Name: cursor.Name}, event)
+}
+
+func encodeTimelineCursor(cursor timelineCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeTimelineCursor(s string) (*timelineCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var cursor timelineCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &cursor, nil
+}
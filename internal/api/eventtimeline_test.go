@@ -0,0 +1,95 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_eventTimelineHandler(t *testing.T) {
+	base := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	newEvent := func(namespace, name string, offset time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: namespace},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: name + "-pod"},
+			Reason:         "BackOff",
+			Message:        "Back-off restarting failed container",
+			Count:          3,
+			LastTimestamp:  metav1.NewTime(base.Add(offset)),
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newEvent("default", "a", time.Minute),
+		newEvent("default", "b", 3*time.Minute),
+		newEvent("kube-system", "c", 2*time.Minute),
+		newEvent("other", "d", 2*time.Minute),
+		newEvent("default", "old", -2*time.Hour),
+	)
+
+	serve := func(query string) (int, eventTimelineResponse) {
+		handler := newEventTimelineHandler(kubeClient, log.NopLogger())
+		handler.nowFn = func() time.Time { return base.Add(10 * time.Minute) }
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events/timeline"+query, nil)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		var got eventTimelineResponse
+		if resp.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		}
+		return resp.Code, got
+	}
+
+	names := func(events []timelineEvent) []string {
+		var list []string
+		for _, event := range events {
+			list = append(list, event.Name)
+		}
+		return list
+	}
+
+	code, got := serve("?namespaces=default,kube-system")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"a", "c", "b"}, names(got.Events))
+	assert.Empty(t, got.NextCursor)
+	assert.Equal(t, timelineObject{Kind: "Pod", Name: "a-pod"}, got.Events[0].InvolvedObject)
+	assert.Equal(t, int32(3), got.Events[0].Count)
+
+	code, got = serve("?from=2019-10-01T12:01:30Z&to=2019-10-01T12:02:30Z")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"c", "d"}, names(got.Events))
+
+	code, got = serve("?limit=2")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"a", "c"}, names(got.Events))
+	require.NotEmpty(t, got.NextCursor)
+
+	code, got = serve("?limit=2&cursor=" + got.NextCursor)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"d", "b"}, names(got.Events))
+	assert.Empty(t, got.NextCursor)
+
+	code, _ = serve("?from=yesterday")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = serve("?cursor=bm90LWpzb24")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// finalizerStuckThreshold is how long a resource can be terminating
+	// before it is considered stuck.
+	finalizerStuckThreshold = 10 * time.Minute
+)
+
+// defaultFinalizerResources are checked unless the `resources` query parameter
+// is set. Names are in the `resource.group` form used by kubectl.
+var defaultFinalizerResources = []string{
+	"pods",
+	"persistentvolumeclaims",
+	"services",
+	"configmaps",
+	"secrets",
+	"deployments.apps",
+	"statefulsets.apps",
+	"jobs.batch",
+}
+
+type terminatingResource struct {
+	Resource         string      `json:"resource"`
+	Name             string      `json:"name"`
+	Finalizers       []string    `json:"finalizers"`
+	TerminatingSince metav1.Time `json:"terminatingSince"`
+	DurationSeconds  int64       `json:"durationSeconds"`
+	Stuck            bool        `json:"stuck"`
+}
+
+type finalizerStatusResponse struct {
+	Resources []terminatingResource `json:"resources"`
+}
+
+type finalizerStatusHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	nowFn         func() time.Time
+	logger        log.Logger
+}
+
+var _ http.Handler = (*finalizerStatusHandler)(nil)
+
+func newFinalizerStatusHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *finalizerStatusHandler {
+	return &finalizerStatusHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		nowFn:         time.Now,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the resources in a namespace
+// which are terminating but held by finalizers, longest terminating first. The
+// `resources` query parameter is a comma separated list of resource types to
+// check, e.g. `pods,deployments.apps`. Resources terminating for more than ten
+// minutes are flagged as stuck.
+func (h *finalizerStatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	names := defaultFinalizerResources
+	requested := r.URL.Query().Get("resources")
+	if requested != "" {
+		names = strings.Split(requested, ",")
+	}
+
+	served, err := namespacedResourcesByName(h.kubeClient)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := finalizerStatusResponse{
+		Resources: []terminatingResource{},
+	}
+
+	now := h.nowFn()
+
+	for _, name := range names {
+		gvr, ok := served[name]
+		if !ok {
+			if requested != "" {
+				RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("resource %q is not served by the cluster", name), h.logger)
+				return
+			}
+			continue
+		}
+
+		list, err := h.dynamicClient.Resource(gvr).Namespace(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		for _, object := range list.Items {
+			deletionTimestamp := object.GetDeletionTimestamp()
+			if deletionTimestamp == nil || len(object.GetFinalizers()) == 0 {
+				continue
+			}
+
+			duration := now.Sub(deletionTimestamp.Time)
+			resp.Resources = append(resp.Resources, terminatingResource{
+				Resource:         name,
+				Name:             object.GetName(),
+				Finalizers:       object.GetFinalizers(),
+				TerminatingSince: *deletionTimestamp,
+				DurationSeconds:  int64(duration / time.Second),
+				Stuck:            duration > finalizerStuckThreshold,
+			})
+		}
+	}
+
+	sort.SliceStable(resp.Resources, func(i, j int) bool {
+		return resp.Resources[i].DurationSeconds > resp.Resources[j].DurationSeconds
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// namespacedResourcesByName returns the preferred version of each listable
+// namespaced resource keyed by its `resource.group` name. Groups which fail
+// discovery are skipped.
+func namespacedResourcesByName(kubeClient kubernetes.Interface) (map[string]schema.GroupVersionResource, error) {
+	resourceLists, err := discovery.ServerPreferredNamespacedResources(kubeClient.Discovery())
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, errors.Wrap(err, "discover namespaced resources")
+	}
+
+	served := make(map[string]schema.GroupVersionResource)
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+
+		for _, resource := range resourceList.APIResources {
+			if !sets.NewString(resource.Verbs...).Has("list") {
+				continue
+			}
+
+			name := resource.Name
+			if gv.Group != "" {
+				name += "." + gv.Group
+			}
+			served[name] = gv.WithResource(resource.Name)
+		}
+	}
+
+	return served, nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_finalizerStatusHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	listVerbs := metav1.Verbs{"get", "list"}
+
+	kubeClient := kubefake.NewSimpleClientset()
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: listVerbs},
+				{Name: "persistentvolumeclaims", Kind: "PersistentVolumeClaim", Namespaced: true, Verbs: listVerbs},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: listVerbs},
+			},
+		},
+	}
+
+	newObject := func(apiVersion, kind, name string, deletedAgo time.Duration, finalizers ...string) *unstructured.Unstructured {
+		object := &unstructured.Unstructured{}
+		object.SetAPIVersion(apiVersion)
+		object.SetKind(kind)
+		object.SetNamespace("default")
+		object.SetName(name)
+		if deletedAgo > 0 {
+			deletionTimestamp := metav1.NewTime(now.Add(-deletedAgo))
+			object.SetDeletionTimestamp(&deletionTimestamp)
+		}
+		object.SetFinalizers(finalizers)
+		return object
+	}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newObject("v1", "PersistentVolumeClaim", "data", time.Hour, "kubernetes.io/pvc-protection"),
+		newObject("v1", "Pod", "web", 2*time.Minute, "example.com/cleanup"),
+		newObject("v1", "Pod", "running", 0, "example.com/cleanup"),
+		newObject("v1", "Pod", "deleting", time.Minute),
+		newObject("apps/v1", "Deployment", "api", 30*time.Minute, "foregroundDeletion"),
+	)
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expected     []terminatingResource
+	}{
+		{
+			name:         "default resources",
+			expectedCode: http.StatusOK,
+			expected: []terminatingResource{
+				{
+					Resource:         "persistentvolumeclaims",
+					Name:             "data",
+					Finalizers:       []string{"kubernetes.io/pvc-protection"},
+					TerminatingSince: metav1.NewTime(now.Add(-time.Hour)),
+					DurationSeconds:  3600,
+					Stuck:            true,
+				},
+				{
+					Resource:         "deployments.apps",
+					Name:             "api",
+					Finalizers:       []string{"foregroundDeletion"},
+					TerminatingSince: metav1.NewTime(now.Add(-30 * time.Minute)),
+					DurationSeconds:  1800,
+					Stuck:            true,
+				},
+				{
+					Resource:         "pods",
+					Name:             "web",
+					Finalizers:       []string{"example.com/cleanup"},
+					TerminatingSince: metav1.NewTime(now.Add(-2 * time.Minute)),
+					DurationSeconds:  120,
+				},
+			},
+		},
+		{
+			name:         "requested resources",
+			query:        "?resources=pods",
+			expectedCode: http.StatusOK,
+			expected: []terminatingResource{
+				{
+					Resource:         "pods",
+					Name:             "web",
+					Finalizers:       []string{"example.com/cleanup"},
+					TerminatingSince: metav1.NewTime(now.Add(-2 * time.Minute)),
+					DurationSeconds:  120,
+				},
+			},
+		},
+		{
+			name:         "unknown resource",
+			query:        "?resources=widgets.example.com",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newFinalizerStatusHandler(kubeClient, dynamicClient, log.NopLogger())
+			handler.nowFn = func() time.Time { return now }
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/finalizerstatus/default"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var got finalizerStatusResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			require.Len(t, got.Resources, len(tc.expected))
+			for i := range tc.expected {
+				assert.True(t, tc.expected[i].TerminatingSince.Equal(&got.Resources[i].TerminatingSince))
+				got.Resources[i].TerminatingSince = tc.expected[i].TerminatingSince
+			}
+			assert.Equal(t, tc.expected, got.Resources)
+		})
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+// RegisteredCluster is a cluster in the fleet.
+type RegisteredCluster struct {
+	Name   string
+	Client kubernetes.Interface
+}
+
+// ClusterRegistry lists the clusters in the fleet overview.
+type ClusterRegistry interface {
+	// Clusters returns the registered clusters.
+	Clusters() []RegisteredCluster
+}
+
+// staticClusterRegistry is a fixed set of clusters.
+type staticClusterRegistry []RegisteredCluster
+
+var _ ClusterRegistry = staticClusterRegistry(nil)
+
+func (r staticClusterRegistry) Clusters() []RegisteredCluster {
+	return r
+}
+
+type fleetCluster struct {
+	Cluster            string   `json:"cluster"`
+	NodeCount          int      `json:"nodeCount"`
+	PodCount           int      `json:"podCount"`
+	UnhealthyWorkloads int      `json:"unhealthyWorkloads"`
+	Alerts             []string `json:"alerts"`
+	// Error is set when the cluster couldn't be read.
+	Error string `json:"error,omitempty"`
+}
+
+type fleetSummary struct {
+	Clusters            int `json:"clusters"`
+	UnreachableClusters int `json:"unreachableClusters"`
+	NodeCount           int `json:"nodeCount"`
+	PodCount            int `json:"podCount"`
+	UnhealthyWorkloads  int `json:"unhealthyWorkloads"`
+	Alerts              int `json:"alerts"`
+}
+
+type fleetOverviewResponse struct {
+	Clusters []fleetCluster `json:"clusters"`
+	Summary  fleetSummary   `json:"summary"`
+}
+
+type fleetOverviewHandler struct {
+	registry ClusterRegistry
+	logger   log.Logger
+}
+
+var _ http.Handler = (*fleetOverviewHandler)(nil)
+
+func newFleetOverviewHandler(registry ClusterRegistry, logger log.Logger) *fleetOverviewHandler {
+	return &fleetOverviewHandler{
+		registry: registry,
+		logger:   logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and summarizes each registered cluster's
+// nodes, pods and unhealthy deployments, stateful sets and daemon sets.
+// Clusters are read in parallel. Nodes which aren't ready or are under
+// pressure are reported as cluster alerts. A cluster which can't be read is
+// returned with its error, and the summary totals the clusters which could.
+func (h *fleetOverviewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	clusters := h.registry.Clusters()
+
+	resp := fleetOverviewResponse{
+		Clusters: make([]fleetCluster, len(clusters)),
+	}
+
+	var wg sync.WaitGroup
+	for i := range clusters {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			overview, err := clusterOverview(clusters[i].Client)
+			if err != nil {
+				h.logger.WithErr(err).With("cluster", clusters[i].Name).Errorf("read cluster for fleet overview")
+				overview = fleetCluster{Alerts: []string{}, Error: err.Error()}
+			}
+			overview.Cluster = clusters[i].Name
+			resp.Clusters[i] = overview
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Slice(resp.Clusters, func(i, j int) bool {
+		return resp.Clusters[i].Cluster < resp.Clusters[j].Cluster
+	})
+
+	resp.Summary.Clusters = len(resp.Clusters)
+	for _, cluster := range resp.Clusters {
+		if cluster.Error != "" {
+			resp.Summary.UnreachableClusters++
+			continue
+		}
+		resp.Summary.NodeCount += cluster.NodeCount
+		resp.Summary.PodCount += cluster.PodCount
+		resp.Summary.UnhealthyWorkloads += cluster.UnhealthyWorkloads
+		resp.Summary.Alerts += len(cluster.Alerts)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// clusterOverview reads the nodes, pods and workloads of a cluster.
+func clusterOverview(kubeClient kubernetes.Interface) (fleetCluster, error) {
+	overview := fleetCluster{
+		Alerts: []string{},
+	}
+
+	nodes, err := kubeClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return overview, errors.Wrap(err, "list nodes")
+	}
+
+	pods, err := kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return overview, errors.Wrap(err, "list pods")
+	}
+
+	apps := kubeClient.AppsV1()
+
+	deployments, err := apps.Deployments(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return overview, errors.Wrap(err, "list deployments")
+	}
+
+	statefulSets, err := apps.StatefulSets(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return overview, errors.Wrap(err, "list stateful sets")
+	}
+
+	daemonSets, err := apps.DaemonSets(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return overview, errors.Wrap(err, "list daemon sets")
+	}
+
+	overview.NodeCount = len(nodes.Items)
+	overview.PodCount = len(pods.Items)
+
+	for _, node := range nodes.Items {
+		if !isNodeReady(node) {
+			overview.Alerts = append(overview.Alerts, fmt.Sprintf("node %s is not ready", node.Name))
+		}
+		for _, condition := range node.Status.Conditions {
+			if condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			for _, pressure := range nodePressureConditions {
+				if condition.Type == pressure {
+					overview.Alerts = append(overview.Alerts, fmt.Sprintf("node %s has %s", node.Name, pressure))
+				}
+			}
+		}
+	}
+
+	for _, deployment := range deployments.Items {
+		desired := int32(1)
+		if deployment.Spec.Replicas != nil {
+			desired = *deployment.Spec.Replicas
+		}
+		if deployment.Status.AvailableReplicas < desired {
+			overview.UnhealthyWorkloads++
+		}
+	}
+
+	for _, statefulSet := range statefulSets.Items {
+		desired := int32(1)
+		if statefulSet.Spec.Replicas != nil {
+			desired = *statefulSet.Spec.Replicas
+		}
+		if statefulSet.Status.ReadyReplicas < desired {
+			overview.UnhealthyWorkloads++
+		}
+	}
+
+	for _, daemonSet := range daemonSets.Items {
+		if daemonSet.Status.NumberReady < daemonSet.Status.DesiredNumberScheduled {
+			overview.UnhealthyWorkloads++
+		}
+	}
+
+	return overview, nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_fleetOverviewHandler(t *testing.T) {
+	three := int32(3)
+
+	node := func(name string, conditions ...corev1.NodeCondition) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.NodeStatus{Conditions: conditions},
+		}
+	}
+	ready := corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionTrue}
+	pod := func(namespace, name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+
+	production := kubefake.NewSimpleClientset(
+		node("node-1", ready),
+		node("node-2", ready, corev1.NodeCondition{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue}),
+		node("node-3"),
+		pod("default", "web-1"),
+		pod("default", "web-2"),
+		pod("kube-system", "dns"),
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &three},
+			Status:     appsv1.DeploymentStatus{AvailableReplicas: 2},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1},
+		},
+		&appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "kube-system"},
+			Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, NumberReady: 2},
+		},
+	)
+
+	staging := kubefake.NewSimpleClientset(
+		node("node-1", ready),
+		pod("default", "web-1"),
+	)
+
+	broken := kubefake.NewSimpleClientset()
+	broken.PrependReactor("list", "nodes", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+
+	registry := staticClusterRegistry{
+		{Name: "staging", Client: staging},
+		{Name: "production", Client: production},
+		{Name: "broken", Client: broken},
+	}
+
+	handler := newFleetOverviewHandler(registry, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/fleetoverview", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp fleetOverviewResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+	expected := fleetOverviewResponse{
+		Clusters: []fleetCluster{
+			{Cluster: "broken", Alerts: []string{}, Error: "list nodes: connection refused"},
+			{
+				Cluster:            "production",
+				NodeCount:          3,
+				PodCount:           3,
+				UnhealthyWorkloads: 2,
+				Alerts:             []string{"node node-2 has DiskPressure", "node node-3 is not ready"},
+			},
+			{Cluster: "staging", NodeCount: 1, PodCount: 1, Alerts: []string{}},
+		},
+		Summary: fleetSummary{
+			Clusters:            3,
+			UnreachableClusters: 1,
+			NodeCount:           4,
+			PodCount:            4,
+			UnhealthyWorkloads:  2,
+			Alerts:              2,
+		},
+	}
+
+	assert.Equal(t, expected, resp)
+}
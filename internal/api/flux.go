@@ -0,0 +1,171 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+var (
+	// kustomizationGVRs are the versions Flux Kustomizations are served at,
+	// newest first. Flux 2.0 serves v1, and older releases only the betas.
+	kustomizationGVRs = []schema.GroupVersionResource{
+		{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Resource: "kustomizations"},
+		{Group: "kustomize.toolkit.fluxcd.io", Version: "v1beta2", Resource: "kustomizations"},
+		{Group: "kustomize.toolkit.fluxcd.io", Version: "v1beta1", Resource: "kustomizations"},
+	}
+
+	helmReleaseGVR = schema.GroupVersionResource{
+		Group:    "helm.toolkit.fluxcd.io",
+		Version:  "v2beta1",
+		Resource: "helmreleases",
+	}
+)
+
+type fluxSourceRef struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type fluxCondition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
+
+type fluxResource struct {
+	Kind                  string          `json:"kind"`
+	Name                  string          `json:"name"`
+	Ready                 statusCondition `json:"ready"`
+	LastAppliedRevision   string          `json:"lastAppliedRevision"`
+	LastAttemptedRevision string          `json:"lastAttemptedRevision"`
+	SourceRef             fluxSourceRef   `json:"sourceRef"`
+}
+
+type fluxResponse struct {
+	CRDsMissing bool           `json:"crdsMissing"`
+	Resources   []fluxResource `json:"resources"`
+}
+
+type fluxHandler struct {
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*fluxHandler)(nil)
+
+func newFluxHandler(dynamicClient dynamic.Interface, logger log.Logger) *fluxHandler {
+	return &fluxHandler{
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the status of Flux
+// Kustomization and HelmRelease objects in a namespace.
+func (h *fluxHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	kustomizations, kustomizationsInstalled, err := listKustomizations(h.dynamicClient, namespace)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	helmReleases, helmReleasesInstalled, err := listOptionalResource(h.dynamicClient, helmReleaseGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := fluxResponse{
+		CRDsMissing: !kustomizationsInstalled && !helmReleasesInstalled,
+		Resources:   []fluxResource{},
+	}
+
+	for _, kustomization := range kustomizations.Items {
+		resp.Resources = append(resp.Resources, newFluxResource(kustomization, "spec", "sourceRef"))
+	}
+
+	for _, helmRelease := range helmReleases.Items {
+		resp.Resources = append(resp.Resources, newFluxResource(helmRelease, "spec", "chart", "spec", "sourceRef"))
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// listKustomizations lists the Kustomizations in a namespace at the newest
+// version the cluster serves. installed is false when no version is served.
+func listKustomizations(dynamicClient dynamic.Interface, namespace string) (*unstructured.UnstructuredList, bool, error) {
+	for _, gvr := range kustomizationGVRs {
+		list, installed, err := listOptionalResource(dynamicClient, gvr, namespace, metav1.ListOptions{})
+		if err != nil || installed {
+			return list, installed, err
+		}
+	}
+
+	return &unstructured.UnstructuredList{}, false, nil
+}
+
+// newFluxResource converts a Flux object to a fluxResource. The source
+// reference is read from sourceRefPath because its location differs between
+// Flux kinds.
+func newFluxResource(object unstructured.Unstructured, sourceRefPath ...string) fluxResource {
+	ready, _ := findCondition(object.Object, "Ready")
+
+	lastApplied, _, _ := unstructured.NestedString(object.Object, "status", "lastAppliedRevision")
+	lastAttempted, _, _ := unstructured.NestedString(object.Object, "status", "lastAttemptedRevision")
+
+	sourceRef, _, _ := unstructured.NestedStringMap(object.Object, sourceRefPath...)
+
+	return fluxResource{
+		Kind:                  object.GetKind(),
+		Name:                  object.GetName(),
+		Ready:                 ready,
+		LastAppliedRevision:   lastApplied,
+		LastAttemptedRevision: lastAttempted,
+		SourceRef: fluxSourceRef{
+			Kind:      sourceRef["kind"],
+			Name:      sourceRef["name"],
+			Namespace: sourceRef["namespace"],
+		},
+	}
+}
+
+// fluxConditions returns all of a Flux object's status conditions.
+func fluxConditions(object unstructured.Unstructured) []fluxCondition {
+	conditions := []fluxCondition{}
+
+	items, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, item := range items {
+		condition, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		c := fluxCondition{}
+		c.Type, _, _ = unstructured.NestedString(condition, "type")
+		c.Status, _, _ = unstructured.NestedString(condition, "status")
+		c.Reason, _, _ = unstructured.NestedString(condition, "reason")
+		c.Message, _, _ = unstructured.NestedString(condition, "message")
+		c.LastTransitionTime, _, _ = unstructured.NestedString(condition, "lastTransitionTime")
+
+		conditions = append(conditions, c)
+	}
+
+	return conditions
+}
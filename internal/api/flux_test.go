@@ -0,0 +1,132 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_fluxHandler(t *testing.T) {
+	kustomization := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kustomize.toolkit.fluxcd.io/v1beta1",
+		"kind":       "Kustomization",
+		"metadata":   map[string]interface{}{"name": "apps", "namespace": "flux-system"},
+		"spec": map[string]interface{}{
+			"sourceRef": map[string]interface{}{"kind": "GitRepository", "name": "fleet"},
+		},
+		"status": map[string]interface{}{
+			"lastAppliedRevision":   "main/abc123",
+			"lastAttemptedRevision": "main/def456",
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "False", "reason": "BuildFailed", "message": "kustomize build failed"},
+			},
+		},
+	}}
+
+	helmRelease := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "helm.toolkit.fluxcd.io/v2beta1",
+		"kind":       "HelmRelease",
+		"metadata":   map[string]interface{}{"name": "podinfo", "namespace": "flux-system"},
+		"spec": map[string]interface{}{
+			"chart": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"sourceRef": map[string]interface{}{"kind": "HelmRepository", "name": "podinfo", "namespace": "sources"},
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"lastAppliedRevision": "6.0.0",
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True", "reason": "ReconciliationSucceeded"},
+			},
+		},
+	}}
+
+	tests := []struct {
+		name     string
+		client   func() *dynamicfake.FakeDynamicClient
+		expected fluxResponse
+	}{
+		{
+			name: "flux installed serving kustomizations at v1beta1",
+			client: func() *dynamicfake.FakeDynamicClient {
+				client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), kustomization, helmRelease)
+				client.PrependReactor("list", "kustomizations", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					if action.GetResource().Version == "v1beta1" {
+						return false, nil, nil
+					}
+					return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+				})
+				return client
+			},
+			expected: fluxResponse{
+				Resources: []fluxResource{
+					{
+						Kind:                  "Kustomization",
+						Name:                  "apps",
+						Ready:                 statusCondition{Status: "False", Reason: "BuildFailed", Message: "kustomize build failed"},
+						LastAppliedRevision:   "main/abc123",
+						LastAttemptedRevision: "main/def456",
+						SourceRef:             fluxSourceRef{Kind: "GitRepository", Name: "fleet"},
+					},
+					{
+						Kind:                "HelmRelease",
+						Name:                "podinfo",
+						Ready:               statusCondition{Status: "True", Reason: "ReconciliationSucceeded"},
+						LastAppliedRevision: "6.0.0",
+						SourceRef:           fluxSourceRef{Kind: "HelmRepository", Name: "podinfo", Namespace: "sources"},
+					},
+				},
+			},
+		},
+		{
+			name: "flux not installed",
+			client: func() *dynamicfake.FakeDynamicClient {
+				client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+				client.PrependReactor("list", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					gr := action.GetResource().GroupResource()
+					return true, nil, kerrors.NewNotFound(gr, "")
+				})
+				return client
+			},
+			expected: fluxResponse{
+				CRDsMissing: true,
+				Resources:   []fluxResource{},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newFluxHandler(tc.client(), log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/flux/flux-system", nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "flux-system"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got fluxResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
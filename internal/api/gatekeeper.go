@@ -0,0 +1,156 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// gatekeeperCRDSelector selects the CRDs Gatekeeper installs for its
+	// constraint templates.
+	gatekeeperCRDSelector = "app.kubernetes.io/name=gatekeeper"
+
+	// gatekeeperConstraintsGroup is the API group of Gatekeeper constraints.
+	gatekeeperConstraintsGroup = "constraints.gatekeeper.sh"
+
+	defaultEnforcementAction = "deny"
+)
+
+type violationResource struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+type constraintViolation struct {
+	ConstraintKind    string            `json:"constraintKind"`
+	ConstraintName    string            `json:"constraintName"`
+	Resource          violationResource `json:"resource"`
+	Message           string            `json:"message"`
+	EnforcementAction string            `json:"enforcementAction"`
+}
+
+type gatekeeperResponse struct {
+	GatekeeperNotInstalled bool                  `json:"gatekeeperNotInstalled"`
+	Violations             []constraintViolation `json:"violations"`
+}
+
+type gatekeeperHandler struct {
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*gatekeeperHandler)(nil)
+
+func newGatekeeperHandler(dynamicClient dynamic.Interface, logger log.Logger) *gatekeeperHandler {
+	return &gatekeeperHandler{
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the violations Gatekeeper's
+// audit has recorded in the status of every constraint. Constraint kinds are
+// discovered from the CRDs Gatekeeper installs.
+func (h *gatekeeperHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp := gatekeeperResponse{
+		Violations: []constraintViolation{},
+	}
+
+	crds, _, err := listOptionalResource(h.dynamicClient, crdGVR, "", metav1.ListOptions{LabelSelector: gatekeeperCRDSelector})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	if len(crds.Items) == 0 {
+		resp.GatekeeperNotInstalled = true
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	for _, crd := range crds.Items {
+		gvr, kind, ok := constraintResource(crd)
+		if !ok {
+			continue
+		}
+
+		constraints, _, err := listOptionalResource(h.dynamicClient, gvr, "", metav1.ListOptions{})
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		for _, constraint := range constraints.Items {
+			resp.Violations = append(resp.Violations, constraintViolations(kind, constraint)...)
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// constraintResource returns the resource and kind served by a constraint
+// CRD. CRDs outside the constraints group, such as Gatekeeper's own config
+// types, are skipped.
+func constraintResource(crd unstructured.Unstructured) (schema.GroupVersionResource, string, bool) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	if group != gatekeeperConstraintsGroup {
+		return schema.GroupVersionResource{}, "", false
+	}
+
+	return crdResource(crd)
+}
+
+// constraintViolations flattens a constraint's `status.violations`.
+// Violations without an enforcement action inherit the constraint's.
+func constraintViolations(kind string, constraint unstructured.Unstructured) []constraintViolation {
+	enforcementAction, _, _ := unstructured.NestedString(constraint.Object, "spec", "enforcementAction")
+	if enforcementAction == "" {
+		enforcementAction = defaultEnforcementAction
+	}
+
+	var violations []constraintViolation
+
+	items, _, _ := unstructured.NestedSlice(constraint.Object, "status", "violations")
+	for _, item := range items {
+		violation, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		resourceKind, _, _ := unstructured.NestedString(violation, "kind")
+		namespace, _, _ := unstructured.NestedString(violation, "namespace")
+		name, _, _ := unstructured.NestedString(violation, "name")
+		message, _, _ := unstructured.NestedString(violation, "message")
+
+		action, _, _ := unstructured.NestedString(violation, "enforcementAction")
+		if action == "" {
+			action = enforcementAction
+		}
+
+		violations = append(violations, constraintViolation{
+			ConstraintKind: kind,
+			ConstraintName: constraint.GetName(),
+			Resource: violationResource{
+				Kind:      resourceKind,
+				Namespace: namespace,
+				Name:      name,
+			},
+			Message:           message,
+			EnforcementAction: action,
+		})
+	}
+
+	return violations
+}
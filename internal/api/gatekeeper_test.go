@@ -0,0 +1,128 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_gatekeeperHandler(t *testing.T) {
+	newCRD := func(name, group, kind, plural string, labels map[string]string) *unstructured.Unstructured {
+		crd := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"group": group,
+				"names": map[string]interface{}{"kind": kind, "plural": plural},
+				"versions": []interface{}{
+					map[string]interface{}{"name": "v1alpha1", "served": false},
+					map[string]interface{}{"name": "v1beta1", "served": true},
+				},
+			},
+		}}
+		crd.SetAPIVersion("apiextensions.k8s.io/v1beta1")
+		crd.SetKind("CustomResourceDefinition")
+		crd.SetName(name)
+		crd.SetLabels(labels)
+		return crd
+	}
+
+	gatekeeperLabels := map[string]string{"app.kubernetes.io/name": "gatekeeper"}
+
+	constraint := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"enforcementAction": "dryrun"},
+		"status": map[string]interface{}{
+			"violations": []interface{}{
+				map[string]interface{}{
+					"kind":      "Pod",
+					"namespace": "kube-system",
+					"name":      "proxy",
+					"message":   "Privileged container is not allowed: proxy",
+				},
+				map[string]interface{}{
+					"kind":              "Pod",
+					"namespace":         "default",
+					"name":              "web",
+					"message":           "Privileged container is not allowed: nginx",
+					"enforcementAction": "warn",
+				},
+			},
+		},
+	}}
+	constraint.SetAPIVersion("constraints.gatekeeper.sh/v1beta1")
+	constraint.SetKind("K8sPSPPrivilegedContainer")
+	constraint.SetName("psp-privileged-container")
+
+	tests := []struct {
+		name     string
+		objects  []runtime.Object
+		expected gatekeeperResponse
+	}{
+		{
+			name: "violations",
+			objects: []runtime.Object{
+				newCRD("k8spspprivilegedcontainers.constraints.gatekeeper.sh", "constraints.gatekeeper.sh", "K8sPSPPrivilegedContainer", "k8spspprivilegedcontainers", gatekeeperLabels),
+				newCRD("configs.config.gatekeeper.sh", "config.gatekeeper.sh", "Config", "configs", gatekeeperLabels),
+				newCRD("widgets.example.com", "constraints.gatekeeper.sh", "Widget", "widgets", nil),
+				constraint,
+			},
+			expected: gatekeeperResponse{
+				Violations: []constraintViolation{
+					{
+						ConstraintKind:    "K8sPSPPrivilegedContainer",
+						ConstraintName:    "psp-privileged-container",
+						Resource:          violationResource{Kind: "Pod", Namespace: "kube-system", Name: "proxy"},
+						Message:           "Privileged container is not allowed: proxy",
+						EnforcementAction: "dryrun",
+					},
+					{
+						ConstraintKind:    "K8sPSPPrivilegedContainer",
+						ConstraintName:    "psp-privileged-container",
+						Resource:          violationResource{Kind: "Pod", Namespace: "default", Name: "web"},
+						Message:           "Privileged container is not allowed: nginx",
+						EnforcementAction: "warn",
+					},
+				},
+			},
+		},
+		{
+			name: "gatekeeper not installed",
+			objects: []runtime.Object{
+				newCRD("widgets.example.com", "example.com", "Widget", "widgets", nil),
+			},
+			expected: gatekeeperResponse{
+				GatekeeperNotInstalled: true,
+				Violations:             []constraintViolation{},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), tc.objects...)
+			handler := newGatekeeperHandler(dynamicClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/gatekeeper/constraints", nil)
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got gatekeeperResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
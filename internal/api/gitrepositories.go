@@ -0,0 +1,331 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// maxArtifactSize is the largest compressed artifact which will be read.
+	maxArtifactSize = 100 << 20
+
+	// maxArtifactEntries is the most entries an artifact tree returns.
+	maxArtifactEntries = 10000
+
+	artifactEntryFile      = "file"
+	artifactEntryDirectory = "dir"
+)
+
+var gitRepositoryGVR = schema.GroupVersionResource{
+	Group:    "source.toolkit.fluxcd.io",
+	Version:  "v1",
+	Resource: "gitrepositories",
+}
+
+// artifactFetcher opens the artifact a Flux source controller serves at a
+// URL.
+type artifactFetcher func(artifactURL string) (io.ReadCloser, error)
+
+type fluxArtifact struct {
+	URL            string `json:"url"`
+	Revision       string `json:"revision"`
+	Digest         string `json:"digest,omitempty"`
+	LastUpdateTime string `json:"lastUpdateTime,omitempty"`
+	Size           int64  `json:"size,omitempty"`
+}
+
+type gitRepositoryStatus struct {
+	Name                string          `json:"name"`
+	URL                 string          `json:"url"`
+	Branch              string          `json:"branch,omitempty"`
+	LastFetchedRevision string          `json:"lastFetchedRevision"`
+	Ready               bool            `json:"ready"`
+	Conditions          []fluxCondition `json:"conditions"`
+	Artifact            *fluxArtifact   `json:"artifact,omitempty"`
+}
+
+type gitRepositoriesResponse struct {
+	FluxNotInstalled bool                  `json:"fluxNotInstalled"`
+	Repositories     []gitRepositoryStatus `json:"repositories"`
+}
+
+type artifactEntry struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Size int64  `json:"size,omitempty"`
+}
+
+type gitRepositoryTreeResponse struct {
+	Name      string          `json:"name"`
+	Revision  string          `json:"revision"`
+	Entries   []artifactEntry `json:"entries"`
+	Truncated bool            `json:"truncated"`
+}
+
+type gitRepoHandler struct {
+	dynamicClient dynamic.Interface
+	fetch         artifactFetcher
+	logger        log.Logger
+}
+
+func newGitRepoHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *gitRepoHandler {
+	return &gitRepoHandler{
+		dynamicClient: dynamicClient,
+		fetch: func(artifactURL string) (io.ReadCloser, error) {
+			return fetchArtifactThroughProxy(kubeClient, artifactURL)
+		},
+		logger: logger,
+	}
+}
+
+// list returns the Flux GitRepositories in a namespace with the revision and
+// artifact they last fetched, sorted by name.
+func (h *gitRepoHandler) list(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	repositories, installed, err := listOptionalResource(h.dynamicClient, gitRepositoryGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := gitRepositoriesResponse{
+		FluxNotInstalled: !installed,
+		Repositories:     []gitRepositoryStatus{},
+	}
+
+	for _, repository := range repositories.Items {
+		resp.Repositories = append(resp.Repositories, newGitRepositoryStatus(repository))
+	}
+
+	sort.Slice(resp.Repositories, func(i, j int) bool {
+		return resp.Repositories[i].Name < resp.Repositories[j].Name
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// tree lists the files and directories in the artifact a GitRepository last
+// fetched. The artifact is downloaded from the source controller through the
+// API server's service proxy. At most 10000 entries are returned, and only the
+// entries in the first 100MiB of a larger artifact.
+func (h *gitRepoHandler) tree(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	repository, err := h.dynamicClient.Resource(gitRepositoryGVR).Namespace(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	status := newGitRepositoryStatus(*repository)
+	if status.Artifact == nil || status.Artifact.URL == "" {
+		RespondWithError(w, http.StatusNotFound, fmt.Sprintf("git repository %q has not fetched an artifact", name), h.logger)
+		return
+	}
+
+	artifact, err := h.fetch(status.Artifact.URL)
+	if err != nil {
+		RespondWithError(w, http.StatusBadGateway, err.Error(), h.logger)
+		return
+	}
+	defer artifact.Close()
+
+	entries, truncated, err := artifactEntries(artifact, maxArtifactSize)
+	if err != nil {
+		RespondWithError(w, http.StatusBadGateway, err.Error(), h.logger)
+		return
+	}
+
+	resp := gitRepositoryTreeResponse{
+		Name:      name,
+		Revision:  status.Artifact.Revision,
+		Entries:   entries,
+		Truncated: truncated,
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func newGitRepositoryStatus(object unstructured.Unstructured) gitRepositoryStatus {
+	repoURL, _, _ := unstructured.NestedString(object.Object, "spec", "url")
+	branch, _, _ := unstructured.NestedString(object.Object, "spec", "ref", "branch")
+
+	status := gitRepositoryStatus{
+		Name:       object.GetName(),
+		URL:        repoURL,
+		Branch:     branch,
+		Conditions: fluxConditions(object),
+	}
+
+	for _, condition := range status.Conditions {
+		if condition.Type == "Ready" {
+			status.Ready = condition.Status == "True"
+		}
+	}
+
+	if artifact, ok, _ := unstructured.NestedMap(object.Object, "status", "artifact"); ok {
+		a := &fluxArtifact{}
+		a.URL, _, _ = unstructured.NestedString(artifact, "url")
+		a.Revision, _, _ = unstructured.NestedString(artifact, "revision")
+		a.Digest, _, _ = unstructured.NestedString(artifact, "digest")
+		a.LastUpdateTime, _, _ = unstructured.NestedString(artifact, "lastUpdateTime")
+		a.Size, _, _ = unstructured.NestedInt64(artifact, "size")
+
+		status.Artifact = a
+		status.LastFetchedRevision = a.Revision
+	}
+
+	return status
+}
+
+// errArtifactTooLarge is returned when reading past the size limit of an
+// artifact.
+var errArtifactTooLarge = errors.New("artifact is too large")
+
+// sizeLimitedReader reads from r until more than n bytes have been read, then
+// fails with errArtifactTooLarge. Unlike io.LimitReader, the limit can be told
+// apart from the end of the artifact.
+type sizeLimitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *sizeLimitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, errArtifactTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, errArtifactTooLarge
+	}
+
+	return n, err
+}
+
+// artifactEntries lists the entries of a gzipped tarball, sorted by path.
+// Directories which only appear in file paths are included. Only the first
+// maxSize bytes are read; the entries found before the limit are returned,
+// truncated.
+func artifactEntries(r io.Reader, maxSize int64) ([]artifactEntry, bool, error) {
+	gz, err := gzip.NewReader(&sizeLimitedReader{r: r, n: maxSize})
+	if err != nil {
+		return nil, false, errors.Wrap(err, "decompress artifact")
+	}
+	defer gz.Close()
+
+	entries := make(map[string]artifactEntry)
+	truncated := false
+
+	add := func(entry artifactEntry) {
+		if _, ok := entries[entry.Path]; ok {
+			return
+		}
+		if len(entries) >= maxArtifactEntries {
+			truncated = true
+			return
+		}
+		entries[entry.Path] = entry
+	}
+
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if errors.Cause(err) == errArtifactTooLarge {
+			truncated = true
+			break
+		}
+		if err != nil {
+			return nil, false, errors.Wrap(err, "read artifact")
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if name == "." || name == "" {
+			continue
+		}
+
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			add(artifactEntry{Path: dir, Type: artifactEntryDirectory})
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			add(artifactEntry{Path: name, Type: artifactEntryDirectory})
+		case tar.TypeReg:
+			add(artifactEntry{Path: name, Type: artifactEntryFile, Size: header.Size})
+		}
+	}
+
+	list := make([]artifactEntry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, entry)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Path < list[j].Path
+	})
+
+	return list, truncated, nil
+}
+
+// fetchArtifactThroughProxy downloads an artifact from the source controller
+// service named in its URL, e.g.
+// http://source-controller.flux-system.svc.cluster.local./gitrepository/...,
+// through the API server so it works from outside the cluster.
+func fetchArtifactThroughProxy(kubeClient kubernetes.Interface, artifactURL string) (io.ReadCloser, error) {
+	u, err := url.Parse(artifactURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse artifact url")
+	}
+
+	parts := strings.Split(strings.TrimSuffix(u.Hostname(), "."), ".")
+	if len(parts) < 2 {
+		return nil, errors.Errorf("artifact url %q does not name a service", artifactURL)
+	}
+
+	stream, err := kubeClient.CoreV1().Services(parts[1]).
+		ProxyGet(u.Scheme, parts[0], u.Port(), u.Path, nil).
+		Stream()
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch artifact")
+	}
+
+	return stream, nil
+}
@@ -0,0 +1,230 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const testArtifactURL = "http://source-controller.flux-system.svc.cluster.local./gitrepository/flux-system/fleet/abc.tar.gz"
+
+func newTestGitRepositoryWithArtifact(name string, artifact map[string]interface{}) *unstructured.Unstructured {
+	status := map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "True", "reason": "Succeeded"},
+		},
+	}
+	if artifact != nil {
+		status["artifact"] = artifact
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "source.toolkit.fluxcd.io/v1",
+		"kind":       "GitRepository",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "flux-system"},
+		"spec": map[string]interface{}{
+			"url": "https://github.com/example/" + name,
+			"ref": map[string]interface{}{"branch": "main"},
+		},
+		"status": status,
+	}}
+}
+
+func testArtifact(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "apps/", Typeflag: tar.TypeDir, Mode: 0755}))
+	for _, name := range []string{"apps/web/deployment.yaml", "README.md"} {
+		content := files[name]
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+func Test_gitRepoHandler_list(t *testing.T) {
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newTestGitRepositoryWithArtifact("fleet", map[string]interface{}{
+			"url":            testArtifactURL,
+			"revision":       "main@sha1:abc",
+			"digest":         "sha256:123",
+			"lastUpdateTime": "2019-10-01T12:00:00Z",
+			"size":           int64(2048),
+		}),
+		newTestGitRepositoryWithArtifact("apps", nil),
+	)
+
+	handler := newGitRepoHandler(nil, dynamicClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/flux-system/gitrepositories", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "flux-system"})
+	w := httptest.NewRecorder()
+
+	handler.list(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp gitRepositoriesResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+	conditions := []fluxCondition{{Type: "Ready", Status: "True", Reason: "Succeeded"}}
+	expected := []gitRepositoryStatus{
+		{
+			Name:       "apps",
+			URL:        "https://github.com/example/apps",
+			Branch:     "main",
+			Ready:      true,
+			Conditions: conditions,
+		},
+		{
+			Name:                "fleet",
+			URL:                 "https://github.com/example/fleet",
+			Branch:              "main",
+			LastFetchedRevision: "main@sha1:abc",
+			Ready:               true,
+			Conditions:          conditions,
+			Artifact: &fluxArtifact{
+				URL:            testArtifactURL,
+				Revision:       "main@sha1:abc",
+				Digest:         "sha256:123",
+				LastUpdateTime: "2019-10-01T12:00:00Z",
+				Size:           2048,
+			},
+		},
+	}
+	assert.False(t, resp.FluxNotInstalled)
+	assert.Equal(t, expected, resp.Repositories)
+}
+
+func Test_gitRepoHandler_tree(t *testing.T) {
+	artifact := testArtifact(t, map[string]string{
+		"apps/web/deployment.yaml": "kind: Deployment\n",
+		"README.md":                "# fleet\n",
+	})
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newTestGitRepositoryWithArtifact("fleet", map[string]interface{}{
+			"url":      testArtifactURL,
+			"revision": "main@sha1:abc",
+		}),
+		newTestGitRepositoryWithArtifact("apps", nil),
+	)
+
+	tests := []struct {
+		name         string
+		repository   string
+		fetchErr     error
+		expectedCode int
+	}{
+		{name: "artifact", repository: "fleet", expectedCode: http.StatusOK},
+		{name: "fetch fails", repository: "fleet", fetchErr: errors.New("unavailable"), expectedCode: http.StatusBadGateway},
+		{name: "no artifact", repository: "apps", expectedCode: http.StatusNotFound},
+		{name: "missing repository", repository: "other", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newGitRepoHandler(nil, dynamicClient, log.NopLogger())
+			handler.fetch = func(artifactURL string) (io.ReadCloser, error) {
+				assert.Equal(t, testArtifactURL, artifactURL)
+				if tc.fetchErr != nil {
+					return nil, tc.fetchErr
+				}
+				return ioutil.NopCloser(bytes.NewReader(artifact)), nil
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/flux-system/gitrepositories/"+tc.repository+"/tree", nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "flux-system", "name": tc.repository})
+			w := httptest.NewRecorder()
+
+			handler.tree(w, req)
+
+			require.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var resp gitRepositoryTreeResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+			expected := []artifactEntry{
+				{Path: "README.md", Type: "file", Size: 8},
+				{Path: "apps", Type: "dir"},
+				{Path: "apps/web", Type: "dir"},
+				{Path: "apps/web/deployment.yaml", Type: "file", Size: 17},
+			}
+			assert.Equal(t, "main@sha1:abc", resp.Revision)
+			assert.Equal(t, expected, resp.Entries)
+			assert.False(t, resp.Truncated)
+		})
+	}
+}
+
+func Test_artifactEntries(t *testing.T) {
+	// Random content doesn't compress, so the limit falls inside it.
+	content := make([]byte, 64<<10)
+	_, err := rand.New(rand.NewSource(1)).Read(content)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range []struct {
+		name    string
+		content []byte
+	}{
+		{name: "apps/web.yaml", content: []byte("kind: Deployment\n")},
+		{name: "big.bin", content: content},
+		{name: "z.txt", content: []byte("z\n")},
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: file.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(file.content))}))
+		_, err := tw.Write(file.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	artifact := buf.Bytes()
+
+	entries, truncated, err := artifactEntries(bytes.NewReader(artifact), int64(len(artifact)))
+	require.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Len(t, entries, 4)
+
+	entries, truncated, err = artifactEntries(bytes.NewReader(artifact), int64(len(artifact)/2))
+	require.NoError(t, err)
+	assert.True(t, truncated)
+	expected := []artifactEntry{
+		{Path: "apps", Type: artifactEntryDirectory},
+		{Path: "apps/web.yaml", Type: artifactEntryFile, Size: 17},
+		{Path: "big.bin", Type: artifactEntryFile, Size: int64(len(content))},
+	}
+	assert.Equal(t, expected, entries)
+}
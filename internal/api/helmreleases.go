@@ -0,0 +1,339 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sjson "k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+// redactedHelmValue replaces Helm values whose keys look like credentials.
+const redactedHelmValue = "REDACTED"
+
+type helmReleaseSummary struct {
+	Name       string                 `json:"name"`
+	Chart      string                 `json:"chart"`
+	Version    string                 `json:"version"`
+	AppVersion string                 `json:"appVersion,omitempty"`
+	Status     string                 `json:"status"`
+	Revision   int                    `json:"revision"`
+	UpdatedAt  time.Time              `json:"updatedAt"`
+	Values     map[string]interface{} `json:"values"`
+}
+
+type helmReleasesResponse struct {
+	ShowSensitiveValues bool                 `json:"showSensitiveValues"`
+	Releases            []helmReleaseSummary `json:"releases"`
+}
+
+type helmReleaseManifestResponse struct {
+	Name                string `json:"name"`
+	Revision            int    `json:"revision"`
+	ShowSensitiveValues bool   `json:"showSensitiveValues"`
+	Manifest            string `json:"manifest"`
+}
+
+type helmReleasesHandler struct {
+	kubeClient kubernetes.Interface
+	user       string
+	logger     log.Logger
+}
+
+func newHelmReleasesHandler(kubeClient kubernetes.Interface, user string, logger log.Logger) *helmReleasesHandler {
+	return &helmReleasesHandler{
+		kubeClient: kubeClient,
+		user:       user,
+		logger:     logger,
+	}
+}
+
+// list returns the latest revision of each Helm release in a namespace, read
+// from Helm's release secrets. The values are the ones supplied when the
+// release was installed or upgraded. Values whose keys contain PASSWORD,
+// SECRET, TOKEN or KEY are redacted unless `showSensitiveValues=true` is set,
+// which requires permission to get secrets in the namespace.
+func (h *helmReleasesHandler) list(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	showSensitiveValues := r.URL.Query().Get("showSensitiveValues") == "true"
+
+	if showSensitiveValues && !h.allowSensitiveValues(w, r, namespace) {
+		return
+	}
+
+	secrets, err := h.releaseSecrets(namespace, "")
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := helmReleasesResponse{
+		ShowSensitiveValues: showSensitiveValues,
+		Releases:            []helmReleaseSummary{},
+	}
+
+	for _, secret := range latestHelmReleaseSecrets(secrets) {
+		release, err := decodeHelmRelease(secret)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, errors.Wrapf(err, "decode release secret %s", secret.Name).Error(), h.logger)
+			return
+		}
+
+		values := release.Config
+		if values == nil {
+			values = map[string]interface{}{}
+		}
+		if !showSensitiveValues {
+			values = redactHelmValues(values)
+		}
+
+		resp.Releases = append(resp.Releases, helmReleaseSummary{
+			Name:       release.Name,
+			Chart:      release.Chart.Metadata.Name,
+			Version:    release.Chart.Metadata.Version,
+			AppVersion: release.Chart.Metadata.AppVersion,
+			Status:     release.Info.Status,
+			Revision:   release.Version,
+			UpdatedAt:  release.Info.LastDeployed.UTC(),
+			Values:     values,
+		})
+	}
+
+	sort.Slice(resp.Releases, func(i, j int) bool {
+		return resp.Releases[i].Name < resp.Releases[j].Name
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// manifest returns the rendered manifest of a Helm release. The latest
+// revision is returned unless `revision` is set. The values of Secrets in the
+// manifest are redacted unless `showSensitiveValues=true` is set, which
+// requires permission to get secrets in the namespace.
+func (h *helmReleasesHandler) manifest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	name := vars["name"]
+	showSensitiveValues := r.URL.Query().Get("showSensitiveValues") == "true"
+
+	revision := 0
+	if s := r.URL.Query().Get("revision"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			RespondWithError(w, http.StatusBadRequest, "revision must be a positive integer", h.logger)
+			return
+		}
+		revision = n
+	}
+
+	if showSensitiveValues && !h.allowSensitiveValues(w, r, namespace) {
+		return
+	}
+
+	secrets, err := h.releaseSecrets(namespace, name)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	var found *corev1.Secret
+	if revision == 0 {
+		if latest := latestHelmReleaseSecrets(secrets); len(latest) > 0 {
+			found = &latest[0]
+		}
+	} else {
+		for i := range secrets {
+			if secrets[i].Type == helmReleaseSecretType && secrets[i].Labels["version"] == strconv.Itoa(revision) {
+				found = &secrets[i]
+				break
+			}
+		}
+	}
+
+	if found == nil {
+		message := fmt.Sprintf("helm release %q not found", name)
+		if revision != 0 {
+			message = fmt.Sprintf("helm release %q has no revision %d", name, revision)
+		}
+		RespondWithError(w, http.StatusNotFound, message, h.logger)
+		return
+	}
+
+	release, err := decodeHelmRelease(*found)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, errors.Wrapf(err, "decode release secret %s", found.Name).Error(), h.logger)
+		return
+	}
+
+	manifest := release.Manifest
+	if !showSensitiveValues {
+		manifest, err = redactHelmManifestSecrets(manifest)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, errors.Wrapf(err, "redact manifest of release %s", release.Name).Error(), h.logger)
+			return
+		}
+	}
+
+	resp := helmReleaseManifestResponse{
+		Name:                release.Name,
+		Revision:            release.Version,
+		ShowSensitiveValues: showSensitiveValues,
+		Manifest:            manifest,
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// allowSensitiveValues reports whether the requester may see sensitive values
+// in a namespace, which requires permission to get its secrets. Otherwise it
+// responds with an error.
+func (h *helmReleasesHandler) allowSensitiveValues(w http.ResponseWriter, r *http.Request, namespace string) bool {
+	allowed, err := canGetSecrets(h.kubeClient, userSessionFrom(r.Context()), namespace)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return false
+	}
+	if !allowed {
+		RespondWithError(w, http.StatusForbidden, "showing sensitive values requires permission to get secrets in "+namespace, h.logger)
+		return false
+	}
+
+	h.logger.With("user", requesterName(r, h.user), "namespace", namespace).Infof("showing sensitive Helm values")
+
+	return true
+}
+
+// releaseSecrets lists the Helm release secrets in a namespace, only those of
+// the named release if a name is given.
+func (h *helmReleasesHandler) releaseSecrets(namespace, name string) ([]corev1.Secret, error) {
+	set := labels.Set{"owner": "helm"}
+	if name != "" {
+		set["name"] = name
+	}
+
+	secrets, err := h.kubeClient.CoreV1().Secrets(namespace).List(metav1.ListOptions{
+		LabelSelector: set.AsSelector().String(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list helm release secrets")
+	}
+
+	return secrets.Items, nil
+}
+
+// redactHelmValues returns a copy of the values where the values of sensitive
+// keys, at any depth, are replaced.
+func redactHelmValues(values map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if isSensitiveName(key) {
+			redacted[key] = redactedHelmValue
+			continue
+		}
+		redacted[key] = redactHelmValue(value)
+	}
+
+	return redacted
+}
+
+func redactHelmValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return redactHelmValues(v)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i := range v {
+			list[i] = redactHelmValue(v[i])
+		}
+		return list
+	default:
+		return value
+	}
+}
+
+// helmManifestSeparator matches the lines separating the documents of a
+// rendered manifest.
+var helmManifestSeparator = regexp.MustCompile(`(?m)^---.*$`)
+
+// redactHelmManifestSecrets returns a copy of a rendered manifest where the
+// values of Secrets are replaced. Other documents are unchanged.
+func redactHelmManifestSecrets(manifest string) (string, error) {
+	separators := append(helmManifestSeparator.FindAllStringIndex(manifest, -1), []int{len(manifest), len(manifest)})
+
+	var sb strings.Builder
+	start := 0
+	for _, separator := range separators {
+		document, err := redactHelmManifestSecret(manifest[start:separator[0]])
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(document)
+		sb.WriteString(manifest[separator[0]:separator[1]])
+		start = separator[1]
+	}
+
+	return sb.String(), nil
+}
+
+// redactHelmManifestSecret redacts the data and stringData values of a
+// manifest document if it is a Secret. The comments leading the document,
+// such as Helm's source template, are kept.
+func redactHelmManifestSecret(document string) (string, error) {
+	object := map[string]interface{}{}
+	if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(document), 4096).Decode(&object); err != nil {
+		if err == io.EOF {
+			return document, nil
+		}
+		return "", errors.Wrap(err, "decode manifest document")
+	}
+
+	if object["kind"] != "Secret" {
+		return document, nil
+	}
+
+	for _, field := range []string{"data", "stringData"} {
+		values, ok := object[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key := range values {
+			values[key] = redactedHelmValue
+		}
+	}
+
+	var sb strings.Builder
+	for _, line := range strings.SplitAfter(document, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		sb.WriteString(line)
+	}
+
+	serializer := k8sjson.NewYAMLSerializer(k8sjson.DefaultMetaFactory, nil, nil)
+	if err := serializer.Encode(&unstructured.Unstructured{Object: object}, &sb); err != nil {
+		return "", errors.Wrap(err, "encode redacted secret")
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,290 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func newHelmReleaseSecret(t *testing.T, name string, version int, status string, deployed time.Time) *corev1.Secret {
+	return newHelmReleaseSecretWithManifest(t, name, version, status, deployed, "# revision "+strconv.Itoa(version))
+}
+
+func newHelmReleaseSecretWithManifest(t *testing.T, name string, version int, status string, deployed time.Time, manifest string) *corev1.Secret {
+	release := map[string]interface{}{
+		"name":      name,
+		"namespace": "default",
+		"version":   version,
+		"manifest":  manifest,
+		"info": map[string]interface{}{
+			"status":        status,
+			"last_deployed": deployed.Format(time.RFC3339Nano),
+		},
+		"chart": map[string]interface{}{
+			"metadata": map[string]interface{}{"name": name + "-chart", "version": "1.2.3", "appVersion": "4.5"},
+		},
+		"config": map[string]interface{}{
+			"replicas": 2,
+			"database": map[string]interface{}{
+				"host":     "db",
+				"password": "hunter2",
+			},
+			"apiKey": "abc",
+		},
+	}
+
+	data, err := json.Marshal(release)
+	require.NoError(t, err)
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, err = writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sh.helm.release.v1." + name + ".v" + strconv.Itoa(version),
+			Namespace: "default",
+			Labels:    map[string]string{"owner": "helm", "name": name, "version": strconv.Itoa(version), "status": status},
+		},
+		Type: helmReleaseSecretType,
+		Data: map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(compressed.Bytes()))},
+	}
+}
+
+func Test_helmReleasesHandler_list(t *testing.T) {
+	deployed := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		requester      *userSession
+		allowed        bool
+		expectedCode   int
+		expectedValues map[string]interface{}
+	}{
+		{
+			name:         "values redacted",
+			expectedCode: http.StatusOK,
+			expectedValues: map[string]interface{}{
+				"replicas": float64(2),
+				"database": map[string]interface{}{"host": "db", "password": "REDACTED"},
+				"apiKey":   "REDACTED",
+			},
+		},
+		{
+			name:         "sensitive values shown",
+			query:        "?showSensitiveValues=true",
+			allowed:      true,
+			expectedCode: http.StatusOK,
+			expectedValues: map[string]interface{}{
+				"replicas": float64(2),
+				"database": map[string]interface{}{"host": "db", "password": "hunter2"},
+				"apiKey":   "abc",
+			},
+		},
+		{
+			name:         "sensitive values forbidden",
+			query:        "?showSensitiveValues=true",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "sensitive values forbidden to session requester",
+			query:        "?showSensitiveValues=true",
+			requester:    &userSession{User: "alice"},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(
+				newHelmReleaseSecret(t, "web", 1, "superseded", deployed.Add(-time.Hour)),
+				newHelmReleaseSecret(t, "web", 2, "failed", deployed),
+				newHelmReleaseSecret(t, "api", 1, helmReleaseStatusDeployed, deployed),
+			)
+			kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				// Octant's own identity may get secrets; a session
+				// requester must not be reviewed as it.
+				review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+				review.Status.Allowed = tc.requester == nil && tc.allowed
+				return true, review, nil
+			})
+			kubeClient.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+				require.NotNil(t, tc.requester)
+				assert.Equal(t, tc.requester.User, review.Spec.User)
+				review.Status.Allowed = tc.allowed
+				return true, review, nil
+			})
+
+			handler := newHelmReleasesHandler(kubeClient, "user", log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/helmreleases"+tc.query, nil)
+			if tc.requester != nil {
+				req = req.WithContext(context.WithValue(req.Context(), userSessionContextKey{}, tc.requester))
+			}
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+			w := httptest.NewRecorder()
+
+			handler.list(w, req)
+
+			require.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var resp helmReleasesResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			require.Len(t, resp.Releases, 2)
+
+			api := resp.Releases[0]
+			assert.Equal(t, "api", api.Name)
+			assert.Equal(t, helmReleaseStatusDeployed, api.Status)
+
+			web := resp.Releases[1]
+			assert.Equal(t, "web", web.Name)
+			assert.Equal(t, "web-chart", web.Chart)
+			assert.Equal(t, "1.2.3", web.Version)
+			assert.Equal(t, "4.5", web.AppVersion)
+			assert.Equal(t, "failed", web.Status)
+			assert.Equal(t, 2, web.Revision)
+			assert.True(t, deployed.Equal(web.UpdatedAt))
+			assert.Equal(t, tc.expectedValues, web.Values)
+		})
+	}
+}
+
+func Test_helmReleasesHandler_manifest(t *testing.T) {
+	deployed := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	credentials := `---
+# Source: db/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: db
+data:
+  host: db
+---
+# Source: db/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+data:
+  password: aHVudGVyMg==
+stringData:
+  token: abc
+`
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newHelmReleaseSecret(t, "web", 1, "superseded", deployed.Add(-time.Hour)),
+		newHelmReleaseSecret(t, "web", 2, helmReleaseStatusDeployed, deployed),
+		newHelmReleaseSecretWithManifest(t, "db", 1, helmReleaseStatusDeployed, deployed, credentials),
+	)
+	kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = true
+		return true, review, nil
+	})
+	kubeClient.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		review.Status.Allowed = false
+		return true, review, nil
+	})
+
+	redacted := `---
+# Source: db/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: db
+data:
+  host: db
+---
+# Source: db/templates/secret.yaml
+apiVersion: v1
+data:
+  password: REDACTED
+kind: Secret
+metadata:
+  name: db
+stringData:
+  token: REDACTED
+`
+
+	tests := []struct {
+		name             string
+		release          string
+		query            string
+		requester        *userSession
+		expectedCode     int
+		expectedManifest string
+	}{
+		{name: "latest revision", release: "web", expectedCode: http.StatusOK, expectedManifest: "# revision 2"},
+		{name: "revision", release: "web", query: "?revision=1", expectedCode: http.StatusOK, expectedManifest: "# revision 1"},
+		{name: "secrets redacted", release: "db", expectedCode: http.StatusOK, expectedManifest: redacted},
+		{name: "secrets shown", release: "db", query: "?showSensitiveValues=true", expectedCode: http.StatusOK, expectedManifest: credentials},
+		{
+			name:         "secrets forbidden to session requester",
+			release:      "db",
+			query:        "?showSensitiveValues=true",
+			requester:    &userSession{User: "alice"},
+			expectedCode: http.StatusForbidden,
+		},
+		{name: "missing revision", release: "web", query: "?revision=3", expectedCode: http.StatusNotFound},
+		{name: "invalid revision", release: "web", query: "?revision=x", expectedCode: http.StatusBadRequest},
+		{name: "missing release", release: "api", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newHelmReleasesHandler(kubeClient, "user", log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/helmreleases/"+tc.release+"/manifest"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default", "name": tc.release})
+			if tc.requester != nil {
+				req = req.WithContext(context.WithValue(req.Context(), userSessionContextKey{}, tc.requester))
+			}
+			w := httptest.NewRecorder()
+
+			handler.manifest(w, req)
+
+			require.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var resp helmReleaseManifestResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Equal(t, tc.release, resp.Name)
+			assert.Equal(t, tc.expectedManifest, resp.Manifest)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const defaultImageRegistry = "docker.io"
+
+var podGVR = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+// podContainerFields pairs the spec and status fields for each kind of container.
+var podContainerFields = []struct {
+	spec   string
+	status string
+}{
+	{spec: "initContainers", status: "initContainerStatuses"},
+	{spec: "containers", status: "containerStatuses"},
+	{spec: "ephemeralContainers", status: "ephemeralContainerStatuses"},
+}
+
+type imageContainer struct {
+	Pod           string `json:"pod"`
+	ContainerName string `json:"containerName"`
+}
+
+type imageUsage struct {
+	Image         string           `json:"image"`
+	DigestIfKnown string           `json:"digestIfKnown,omitempty"`
+	Containers    []imageContainer `json:"containers"`
+}
+
+type imagesResponse struct {
+	Images []imageUsage `json:"images"`
+}
+
+type imagesHandler struct {
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*imagesHandler)(nil)
+
+func newImagesHandler(dynamicClient dynamic.Interface, logger log.Logger) *imagesHandler {
+	return &imagesHandler{
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the images used by init,
+// regular, and ephemeral containers of pods in a namespace. The optional
+// `registry` query parameter limits images to a registry prefix such as
+// `gcr.io/project`. Images without a registry are from Docker Hub.
+//
+// Pods are read with the dynamic client because the typed client predates
+// ephemeral containers.
+func (h *imagesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	registry := strings.TrimSuffix(r.URL.Query().Get("registry"), "/")
+
+	pods, err := h.dynamicClient.Resource(podGVR).Namespace(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	images := make(map[string]*imageUsage)
+
+	for _, pod := range pods.Items {
+		for _, fields := range podContainerFields {
+			imageIDs := containerImageIDs(pod, fields.status)
+
+			containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", fields.spec)
+			for _, item := range containers {
+				container, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				name, _, _ := unstructured.NestedString(container, "name")
+				image, _, _ := unstructured.NestedString(container, "image")
+				if image == "" || !imageInRegistry(image, registry) {
+					continue
+				}
+
+				usage, ok := images[image]
+				if !ok {
+					usage = &imageUsage{Image: image}
+					images[image] = usage
+				}
+
+				if usage.DigestIfKnown == "" {
+					usage.DigestIfKnown = imageDigest(image, imageIDs[name])
+				}
+
+				usage.Containers = append(usage.Containers, imageContainer{Pod: pod.GetName(), ContainerName: name})
+			}
+		}
+	}
+
+	resp := imagesResponse{
+		Images: []imageUsage{},
+	}
+
+	for _, usage := range images {
+		resp.Images = append(resp.Images, *usage)
+	}
+	sort.Slice(resp.Images, func(i, j int) bool {
+		return resp.Images[i].Image < resp.Images[j].Image
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// containerImageIDs returns the image IDs reported in a pod's container
+// statuses by container name.
+func containerImageIDs(pod unstructured.Unstructured, field string) map[string]string {
+	ids := make(map[string]string)
+
+	statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", field)
+	for _, item := range statuses {
+		status, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		name, _, _ := unstructured.NestedString(status, "name")
+		imageID, _, _ := unstructured.NestedString(status, "imageID")
+		ids[name] = imageID
+	}
+
+	return ids
+}
+
+// imageDigest returns the digest from an image reference, or from the image
+// ID reported by the container runtime, e.g. `docker-pullable://nginx@sha256:...`.
+func imageDigest(image, imageID string) string {
+	for _, ref := range []string{image, imageID} {
+		if i := strings.LastIndex(ref, "@"); i != -1 {
+			return ref[i+1:]
+		}
+	}
+
+	if strings.HasPrefix(imageID, "sha256:") {
+		return imageID
+	}
+
+	return ""
+}
+
+// imageInRegistry reports whether an image reference starts with a
+// registry prefix. An empty prefix matches all images.
+func imageInRegistry(image, registry string) bool {
+	if registry == "" {
+		return true
+	}
+
+	normalized := image
+	if !imageHasRegistry(image) {
+		normalized = defaultImageRegistry + "/" + image
+	}
+
+	return normalized == registry || strings.HasPrefix(normalized, registry+"/")
+}
+
+// imageHasRegistry reports whether the first component of an image
+// reference is a registry host, using the same rules as docker.
+func imageHasRegistry(image string) bool {
+	i := strings.Index(image, "/")
+	if i == -1 {
+		return false
+	}
+
+	host := image[:i]
+	return strings.ContainsAny(host, ".:") || host == "localhost"
+}
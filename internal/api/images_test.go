@@ -0,0 +1,113 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_imagesHandler(t *testing.T) {
+	newContainer := func(name, image string) map[string]interface{} {
+		return map[string]interface{}{"name": name, "image": image}
+	}
+
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"initContainers":      []interface{}{newContainer("migrate", "gcr.io/project/migrate:v1")},
+			"containers":          []interface{}{newContainer("app", "nginx:1.17"), newContainer("sidecar", "gcr.io/project/proxy@sha256:abc")},
+			"ephemeralContainers": []interface{}{newContainer("debug", "busybox")},
+		},
+		"status": map[string]interface{}{
+			"containerStatuses": []interface{}{
+				map[string]interface{}{"name": "app", "imageID": "docker-pullable://nginx@sha256:def"},
+			},
+		},
+	}}
+
+	other := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "web-2", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{newContainer("app", "nginx:1.17")},
+		},
+	}}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []imageUsage
+	}{
+		{
+			name: "all images",
+			expected: []imageUsage{
+				{Image: "busybox", Containers: []imageContainer{{Pod: "web", ContainerName: "debug"}}},
+				{Image: "gcr.io/project/migrate:v1", Containers: []imageContainer{{Pod: "web", ContainerName: "migrate"}}},
+				{Image: "gcr.io/project/proxy@sha256:abc", DigestIfKnown: "sha256:abc", Containers: []imageContainer{{Pod: "web", ContainerName: "sidecar"}}},
+				{Image: "nginx:1.17", DigestIfKnown: "sha256:def", Containers: []imageContainer{
+					{Pod: "web", ContainerName: "app"}, {Pod: "web-2", ContainerName: "app"},
+				}},
+			},
+		},
+		{
+			name:  "registry filter",
+			query: "?registry=gcr.io/project",
+			expected: []imageUsage{
+				{Image: "gcr.io/project/migrate:v1", Containers: []imageContainer{{Pod: "web", ContainerName: "migrate"}}},
+				{Image: "gcr.io/project/proxy@sha256:abc", DigestIfKnown: "sha256:abc", Containers: []imageContainer{{Pod: "web", ContainerName: "sidecar"}}},
+			},
+		},
+		{
+			name:  "docker hub",
+			query: "?registry=docker.io",
+			expected: []imageUsage{
+				{Image: "busybox", Containers: []imageContainer{{Pod: "web", ContainerName: "debug"}}},
+				{Image: "nginx:1.17", DigestIfKnown: "sha256:def", Containers: []imageContainer{
+					{Pod: "web", ContainerName: "app"}, {Pod: "web-2", ContainerName: "app"},
+				}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), pod, other)
+			handler := newImagesHandler(dynamicClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/images/default"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got imagesResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			require.Len(t, got.Images, len(tc.expected))
+
+			for i := range got.Images {
+				assert.ElementsMatch(t, tc.expected[i].Containers, got.Images[i].Containers)
+				got.Images[i].Containers = tc.expected[i].Containers
+			}
+			assert.Equal(t, tc.expected, got.Images)
+		})
+	}
+}
@@ -0,0 +1,213 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	imagePolicySemver       = "semver"
+	imagePolicyAlphabetical = "alphabetical"
+	imagePolicyNumerical    = "numerical"
+)
+
+var (
+	imageUpdateAutomationGVR = schema.GroupVersionResource{
+		Group:    "image.toolkit.fluxcd.io",
+		Version:  "v1beta1",
+		Resource: "imageupdateautomations",
+	}
+
+	imagePolicyGVR = schema.GroupVersionResource{
+		Group:    "image.toolkit.fluxcd.io",
+		Version:  "v1beta1",
+		Resource: "imagepolicies",
+	}
+)
+
+type imageUpdateAutomation struct {
+	Name           string          `json:"name"`
+	SourceRef      fluxSourceRef   `json:"sourceRef"`
+	Interval       string          `json:"interval"`
+	Suspended      bool            `json:"suspended"`
+	Ready          statusCondition `json:"ready"`
+	LastRunTime    *time.Time      `json:"lastRunTime,omitempty"`
+	NextRunTime    *time.Time      `json:"nextRunTime,omitempty"`
+	LastPushCommit string          `json:"lastPushCommit,omitempty"`
+	LastPushTime   *time.Time      `json:"lastPushTime,omitempty"`
+}
+
+type imagePolicyFilter struct {
+	// Type is semver, alphabetical or numerical.
+	Type string `json:"type"`
+	// Range is the semver range tags must be in.
+	Range string `json:"range,omitempty"`
+	// Order is the alphabetical or numerical order, asc or desc.
+	Order       string `json:"order,omitempty"`
+	TagPattern  string `json:"tagPattern,omitempty"`
+	TagTemplate string `json:"tagTemplate,omitempty"`
+}
+
+type imagePolicyStatus struct {
+	Name            string            `json:"name"`
+	ImageRepository string            `json:"imageRepository"`
+	Ready           statusCondition   `json:"ready"`
+	LatestImage     string            `json:"latestImage,omitempty"`
+	LatestTag       string            `json:"latestTag,omitempty"`
+	Policy          imagePolicyFilter `json:"policy"`
+}
+
+type imageUpdateResponse struct {
+	FluxNotInstalled bool                    `json:"fluxNotInstalled"`
+	Automations      []imageUpdateAutomation `json:"automations"`
+	Policies         []imagePolicyStatus     `json:"policies"`
+}
+
+type imageUpdateHandler struct {
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*imageUpdateHandler)(nil)
+
+func newImageUpdateHandler(dynamicClient dynamic.Interface, logger log.Logger) *imageUpdateHandler {
+	return &imageUpdateHandler{
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the status of Flux image
+// update automation in a namespace: when each ImageUpdateAutomation last ran
+// and will next run, and the latest image each ImagePolicy selected with the
+// filter it selects by. The next run is the last run plus the automation's
+// interval; suspended automations have no next run.
+func (h *imageUpdateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	automations, automationsInstalled, err := listOptionalResource(h.dynamicClient, imageUpdateAutomationGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	policies, policiesInstalled, err := listOptionalResource(h.dynamicClient, imagePolicyGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := imageUpdateResponse{
+		FluxNotInstalled: !automationsInstalled && !policiesInstalled,
+		Automations:      []imageUpdateAutomation{},
+		Policies:         []imagePolicyStatus{},
+	}
+
+	for _, automation := range automations.Items {
+		resp.Automations = append(resp.Automations, newImageUpdateAutomation(automation))
+	}
+
+	for _, policy := range policies.Items {
+		resp.Policies = append(resp.Policies, newImagePolicyStatus(policy))
+	}
+
+	sort.Slice(resp.Automations, func(i, j int) bool {
+		return resp.Automations[i].Name < resp.Automations[j].Name
+	})
+	sort.Slice(resp.Policies, func(i, j int) bool {
+		return resp.Policies[i].Name < resp.Policies[j].Name
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func newImageUpdateAutomation(object unstructured.Unstructured) imageUpdateAutomation {
+	ready, _ := findCondition(object.Object, "Ready")
+	sourceRef, _, _ := unstructured.NestedStringMap(object.Object, "spec", "sourceRef")
+	interval, _, _ := unstructured.NestedString(object.Object, "spec", "interval")
+	suspended, _, _ := unstructured.NestedBool(object.Object, "spec", "suspend")
+	lastPushCommit, _, _ := unstructured.NestedString(object.Object, "status", "lastPushCommit")
+
+	automation := imageUpdateAutomation{
+		Name: object.GetName(),
+		SourceRef: fluxSourceRef{
+			Kind:      sourceRef["kind"],
+			Name:      sourceRef["name"],
+			Namespace: sourceRef["namespace"],
+		},
+		Interval:       interval,
+		Suspended:      suspended,
+		Ready:          ready,
+		LastRunTime:    nestedTime(object.Object, "status", "lastAutomationRunTime"),
+		LastPushCommit: lastPushCommit,
+		LastPushTime:   nestedTime(object.Object, "status", "lastPushTime"),
+	}
+
+	if every, err := time.ParseDuration(interval); err == nil && automation.LastRunTime != nil && !suspended {
+		next := automation.LastRunTime.Add(every)
+		automation.NextRunTime = &next
+	}
+
+	return automation
+}
+
+func newImagePolicyStatus(object unstructured.Unstructured) imagePolicyStatus {
+	ready, _ := findCondition(object.Object, "Ready")
+	repository, _, _ := unstructured.NestedString(object.Object, "spec", "imageRepositoryRef", "name")
+	latestImage, _, _ := unstructured.NestedString(object.Object, "status", "latestImage")
+	tagPattern, _, _ := unstructured.NestedString(object.Object, "spec", "filterTags", "pattern")
+	tagTemplate, _, _ := unstructured.NestedString(object.Object, "spec", "filterTags", "extract")
+
+	filter := imagePolicyFilter{
+		TagPattern:  tagPattern,
+		TagTemplate: tagTemplate,
+	}
+
+	policy, _, _ := unstructured.NestedMap(object.Object, "spec", "policy")
+	for _, policyType := range []string{imagePolicySemver, imagePolicyAlphabetical, imagePolicyNumerical} {
+		if _, ok := policy[policyType]; !ok {
+			continue
+		}
+
+		filter.Type = policyType
+		filter.Range, _, _ = unstructured.NestedString(policy, policyType, "range")
+		filter.Order, _, _ = unstructured.NestedString(policy, policyType, "order")
+		break
+	}
+
+	return imagePolicyStatus{
+		Name:            object.GetName(),
+		ImageRepository: repository,
+		Ready:           ready,
+		LatestImage:     latestImage,
+		LatestTag:       imageTag(latestImage),
+		Policy:          filter,
+	}
+}
+
+// nestedTime returns the RFC 3339 time at a path in an object, or nil when it
+// is missing or malformed.
+func nestedTime(object map[string]interface{}, fields ...string) *time.Time {
+	s, _, _ := unstructured.NestedString(object, fields...)
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+
+	t = t.UTC()
+	return &t
+}
@@ -0,0 +1,152 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_imageUpdateHandler(t *testing.T) {
+	automation := func(name string, suspend bool) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "image.toolkit.fluxcd.io/v1beta1",
+			"kind":       "ImageUpdateAutomation",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "flux-system"},
+			"spec": map[string]interface{}{
+				"interval":  "30m",
+				"suspend":   suspend,
+				"sourceRef": map[string]interface{}{"kind": "GitRepository", "name": "fleet"},
+			},
+			"status": map[string]interface{}{
+				"lastAutomationRunTime": "2019-10-01T12:00:00Z",
+				"lastPushCommit":        "abc123",
+				"lastPushTime":          "2019-10-01T11:00:00Z",
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": "True"},
+				},
+			},
+		}}
+	}
+
+	policy := func(name string, spec map[string]interface{}, latestImage string) *unstructured.Unstructured {
+		spec["imageRepositoryRef"] = map[string]interface{}{"name": "podinfo"}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "image.toolkit.fluxcd.io/v1beta1",
+			"kind":       "ImagePolicy",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "flux-system"},
+			"spec":       spec,
+			"status":     map[string]interface{}{"latestImage": latestImage},
+		}}
+	}
+
+	lastRun := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	nextRun := lastRun.Add(30 * time.Minute)
+	lastPush := lastRun.Add(-time.Hour)
+
+	t.Run("flux installed", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+			automation("paused", true),
+			automation("fleet", false),
+			policy("stable", map[string]interface{}{
+				"policy": map[string]interface{}{"semver": map[string]interface{}{"range": ">=1.0.0"}},
+			}, "ghcr.io/example/podinfo:1.2.3"),
+			policy("builds", map[string]interface{}{
+				"policy":     map[string]interface{}{"numerical": map[string]interface{}{"order": "asc"}},
+				"filterTags": map[string]interface{}{"pattern": "^main-(?P<ts>[0-9]+)$", "extract": "$ts"},
+			}, "ghcr.io/example/podinfo:main-1570000000"),
+		)
+
+		got := serveImageUpdate(t, newImageUpdateHandler(dynamicClient, log.NopLogger()))
+
+		assert.False(t, got.FluxNotInstalled)
+
+		expectedAutomations := []imageUpdateAutomation{
+			{
+				Name:           "fleet",
+				SourceRef:      fluxSourceRef{Kind: "GitRepository", Name: "fleet"},
+				Interval:       "30m",
+				Ready:          statusCondition{Status: "True"},
+				LastRunTime:    &lastRun,
+				NextRunTime:    &nextRun,
+				LastPushCommit: "abc123",
+				LastPushTime:   &lastPush,
+			},
+			{
+				Name:           "paused",
+				SourceRef:      fluxSourceRef{Kind: "GitRepository", Name: "fleet"},
+				Interval:       "30m",
+				Suspended:      true,
+				Ready:          statusCondition{Status: "True"},
+				LastRunTime:    &lastRun,
+				LastPushCommit: "abc123",
+				LastPushTime:   &lastPush,
+			},
+		}
+		assert.Equal(t, expectedAutomations, got.Automations)
+
+		expectedPolicies := []imagePolicyStatus{
+			{
+				Name:            "builds",
+				ImageRepository: "podinfo",
+				Ready:           statusCondition{Status: "Unknown"},
+				LatestImage:     "ghcr.io/example/podinfo:main-1570000000",
+				LatestTag:       "main-1570000000",
+				Policy:          imagePolicyFilter{Type: "numerical", Order: "asc", TagPattern: "^main-(?P<ts>[0-9]+)$", TagTemplate: "$ts"},
+			},
+			{
+				Name:            "stable",
+				ImageRepository: "podinfo",
+				Ready:           statusCondition{Status: "Unknown"},
+				LatestImage:     "ghcr.io/example/podinfo:1.2.3",
+				LatestTag:       "1.2.3",
+				Policy:          imagePolicyFilter{Type: "semver", Range: ">=1.0.0"},
+			},
+		}
+		assert.Equal(t, expectedPolicies, got.Policies)
+	})
+
+	t.Run("flux not installed", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		dynamicClient.PrependReactor("list", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+		})
+
+		got := serveImageUpdate(t, newImageUpdateHandler(dynamicClient, log.NopLogger()))
+
+		assert.True(t, got.FluxNotInstalled)
+		assert.Empty(t, got.Automations)
+		assert.Empty(t, got.Policies)
+	})
+}
+
+func serveImageUpdate(t *testing.T, handler *imageUpdateHandler) imageUpdateResponse {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/flux-system/imageupdate", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "flux-system"})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var got imageUpdateResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+
+	return got
+}
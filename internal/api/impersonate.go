@@ -0,0 +1,306 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// impersonateTokenHeader is the request header carrying an impersonation
+	// session token.
+	impersonateTokenHeader = "X-Octant-Impersonate-Token"
+
+	// defaultImpersonationTTL is how long an impersonation session lasts unless
+	// configured otherwise.
+	defaultImpersonationTTL = 15 * time.Minute
+
+	// maxImpersonationSessions caps the number of live impersonation sessions.
+	// Starting another session ends the one closest to expiring.
+	maxImpersonationSessions = 100
+
+	// impersonationSweepInterval is how often expired impersonation sessions
+	// are removed.
+	impersonationSweepInterval = time.Minute
+)
+
+type impersonateRequest struct {
+	User   string              `json:"user"`
+	Groups []string            `json:"groups"`
+	Extra  map[string][]string `json:"extra"`
+}
+
+type impersonateResponse struct {
+	Token     string    `json:"token"`
+	User      string    `json:"user"`
+	Groups    []string  `json:"groups"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// impersonationSession is a router whose cluster clients act as another identity.
+type impersonationSession struct {
+	user string
+
+	mu     sync.Mutex
+	router *mux.Router
+}
+
+// handler returns the session's router, or nil once the session is closed.
+func (s *impersonationSession) handler() *mux.Router {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.router
+}
+
+// close releases the session's router and the cluster clients it serves
+// with. Requests already being served finish.
+func (s *impersonationSession) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.router = nil
+}
+
+// newImpersonationSessions creates the cache of impersonation sessions.
+// Sessions are closed when they expire or are evicted to make room for a new
+// one.
+func newImpersonationSessions(ttl time.Duration) *ttlCache {
+	sessions := newTTLCache(ttl)
+	sessions.maxEntries = maxImpersonationSessions
+	sessions.onEvict = func(value interface{}) {
+		if session, ok := value.(*impersonationSession); ok {
+			session.close()
+		}
+	}
+
+	return sessions
+}
+
+// impersonatedRouterFactory creates a router serving cluster routes with the
+// supplied configuration.
+type impersonatedRouterFactory func(config *rest.Config) (*mux.Router, error)
+
+type impersonateHandler struct {
+	kubeClient kubernetes.Interface
+	restConfig *rest.Config
+	clientUser string
+	sessions   *ttlCache
+	newRouter  impersonatedRouterFactory
+	logger     log.Logger
+}
+
+var _ http.Handler = (*impersonateHandler)(nil)
+
+func newImpersonateHandler(kubeClient kubernetes.Interface, restConfig *rest.Config, clientUser string, sessions *ttlCache, newRouter impersonatedRouterFactory, logger log.Logger) *impersonateHandler {
+	return &impersonateHandler{
+		kubeClient: kubeClient,
+		restConfig: restConfig,
+		clientUser: clientUser,
+		sessions:   sessions,
+		newRouter:  newRouter,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and starts an impersonation session. Requests
+// sending the returned token in the X-Octant-Impersonate-Token header are made
+// to the cluster as the impersonated user. The requester must be allowed to
+// impersonate the user, each group and each extra.
+func (h *impersonateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req impersonateRequest
+
+	defer func() {
+		if cErr := r.Body.Close(); cErr != nil {
+			h.logger.WithErr(cErr).Errorf("unable to close impersonate request body")
+		}
+	}()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
+	if req.User == "" {
+		RespondWithError(w, http.StatusBadRequest, "user is required", h.logger)
+		return
+	}
+
+	if h.restConfig == nil {
+		RespondWithError(w, http.StatusInternalServerError, "cluster configuration is unavailable", h.logger)
+		return
+	}
+
+	requester := userSessionFrom(r.Context())
+	allowed, err := canImpersonate(h.kubeClient, requester, req)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+	if !allowed {
+		RespondWithError(w, http.StatusForbidden, fmt.Sprintf("not allowed to impersonate %q", req.User), h.logger)
+		return
+	}
+
+	config := rest.CopyConfig(h.restConfig)
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: req.User,
+		Groups:   req.Groups,
+		Extra:    req.Extra,
+	}
+
+	router, err := h.newRouter(config)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	token, err := uuid.NewRandom()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	h.sessions.set(token.String(), &impersonationSession{
+		user:   req.User,
+		router: router,
+	})
+
+	resp := impersonateResponse{
+		Token:     token.String(),
+		User:      req.User,
+		Groups:    req.Groups,
+		ExpiresAt: h.sessions.nowFn().Add(h.sessions.ttl),
+	}
+	if resp.Groups == nil {
+		resp.Groups = []string{}
+	}
+
+	h.logger.With(
+		"requester", requesterName(r, h.clientUser),
+		"user", req.User,
+		"groups", req.Groups,
+	).Infof("started impersonation session")
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// canImpersonate reports whether the requester may impersonate the
+// requested user, each of its groups and each of its extras.
+func canImpersonate(kubeClient kubernetes.Interface, requester *userSession, req impersonateRequest) (bool, error) {
+	attributes := []*authorizationv1.ResourceAttributes{
+		{Verb: "impersonate", Resource: "users", Name: req.User},
+	}
+	for _, group := range req.Groups {
+		attributes = append(attributes, &authorizationv1.ResourceAttributes{
+			Verb:     "impersonate",
+			Resource: "groups",
+			Name:     group,
+		})
+	}
+
+	keys := make([]string, 0, len(req.Extra))
+	for key := range req.Extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range req.Extra[key] {
+			attributes = append(attributes, &authorizationv1.ResourceAttributes{
+				Verb:        "impersonate",
+				Resource:    "userextras",
+				Subresource: key,
+				Name:        value,
+			})
+		}
+	}
+
+	for _, attribute := range attributes {
+		allowed, err := reviewAccess(kubeClient, requester, attribute)
+		if err != nil {
+			return false, errors.Wrap(err, "review access to impersonate")
+		}
+		if !allowed {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// impersonation is a middleware which serves requests carrying an
+// impersonation token with the session's router. Only cluster routes can be
+// impersonated; other routes are rejected rather than served as the current
+// user.
+func impersonation(sessions *ttlCache, logger log.Logger) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.Header.Get(impersonateTokenHeader)
+			if token == "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			value, ok := sessions.get(token)
+			if !ok {
+				RespondWithError(w, http.StatusUnauthorized, "impersonation session is invalid or has expired", logger)
+				return
+			}
+			session := value.(*impersonationSession)
+
+			router := session.handler()
+			if router == nil {
+				RespondWithError(w, http.StatusUnauthorized, "impersonation session is invalid or has expired", logger)
+				return
+			}
+
+			var match mux.RouteMatch
+			if !router.Match(r, &match) {
+				RespondWithError(w, http.StatusBadRequest, "route does not support impersonation", logger)
+				return
+			}
+
+			logger.With("user", session.user, "path", r.URL.Path).Debugf("serving impersonated request")
+			router.ServeHTTP(w, r)
+		})
+	}
+}
+
+// impersonatedRouter creates a router serving cluster routes using clients
+// created from config.
+func (a *API) impersonatedRouter(config *rest.Config) (*mux.Router, error) {
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "create impersonated kubernetes client")
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "create impersonated dynamic client")
+	}
+
+	router := mux.NewRouter()
+	s := router.PathPrefix(a.prefix).Subrouter()
+	a.registerClusterRoutes(s, kubeClient, dynamicClient, config)
+
+	return router, nil
+}
@@ -0,0 +1,242 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_impersonateHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		requester    *userSession
+		denied       string
+		expectedCode int
+		expected     rest.ImpersonationConfig
+	}{
+		{
+			name:         "impersonate user",
+			body:         `{"user":"system:serviceaccount:default:app","groups":["developers"],"extra":{"scopes":["view"]}}`,
+			expectedCode: http.StatusOK,
+			expected: rest.ImpersonationConfig{
+				UserName: "system:serviceaccount:default:app",
+				Groups:   []string{"developers"},
+				Extra:    map[string][]string{"scopes": {"view"}},
+			},
+		},
+		{
+			name:         "impersonate user with session requester",
+			body:         `{"user":"bob"}`,
+			requester:    &userSession{User: "alice", Groups: []string{"admins"}},
+			expectedCode: http.StatusOK,
+			expected:     rest.ImpersonationConfig{UserName: "bob"},
+		},
+		{
+			name:         "user denied",
+			body:         `{"user":"admin"}`,
+			denied:       "users",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "group denied",
+			body:         `{"user":"bob","groups":["system:masters"]}`,
+			denied:       "groups",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "extra denied",
+			body:         `{"user":"bob","extra":{"scopes":["admin"]}}`,
+			denied:       "userextras",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "missing user",
+			body:         `{"groups":["developers"]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+			sessions := newTTLCache(time.Minute)
+			sessions.nowFn = func() time.Time { return now }
+
+			var got rest.ImpersonationConfig
+			newRouter := func(config *rest.Config) (*mux.Router, error) {
+				got = config.Impersonate
+				return mux.NewRouter(), nil
+			}
+
+			var reviewed []string
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				require.Nil(t, tc.requester, "requester with a session must be reviewed as its user")
+				review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+				attributes := review.Spec.ResourceAttributes
+				assert.Equal(t, "impersonate", attributes.Verb)
+				reviewed = append(reviewed, attributes.Resource)
+				review.Status.Allowed = attributes.Resource != tc.denied
+				return true, review, nil
+			})
+			kubeClient.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				require.NotNil(t, tc.requester)
+				review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+				assert.Equal(t, tc.requester.User, review.Spec.User)
+				assert.Equal(t, tc.requester.Groups, review.Spec.Groups)
+				attributes := review.Spec.ResourceAttributes
+				reviewed = append(reviewed, attributes.Resource)
+				review.Status.Allowed = attributes.Resource != tc.denied
+				return true, review, nil
+			})
+
+			handler := newImpersonateHandler(kubeClient, &rest.Config{Host: "https://cluster"}, "octant", sessions, newRouter, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/impersonate", strings.NewReader(tc.body))
+			if tc.requester != nil {
+				req = req.WithContext(context.WithValue(req.Context(), userSessionContextKey{}, tc.requester))
+			}
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.denied != "" {
+				assert.Contains(t, reviewed, tc.denied)
+				assert.Empty(t, sessions.entries)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, tc.expected, got)
+
+			var body impersonateResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.NotEmpty(t, body.Token)
+			assert.Equal(t, now.Add(time.Minute), body.ExpiresAt)
+
+			_, ok := sessions.get(body.Token)
+			assert.True(t, ok)
+		})
+	}
+}
+
+func Test_impersonation(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	sessions := newTTLCache(time.Minute)
+	sessions.nowFn = func() time.Time { return now }
+
+	sessionRouter := mux.NewRouter()
+	sessionRouter.HandleFunc("/api/v1/pods/{namespace}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("impersonated " + mux.Vars(r)["namespace"]))
+	})
+	sessions.set("token", &impersonationSession{user: "alice", router: sessionRouter})
+
+	router := mux.NewRouter()
+	router.Use(impersonation(sessions, log.NopLogger()))
+	router.HandleFunc("/api/v1/pods/{namespace}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("current"))
+	})
+	router.HandleFunc("/api/v1/content", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("content"))
+	})
+
+	tests := []struct {
+		name         string
+		path         string
+		token        string
+		elapsed      time.Duration
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "no token",
+			path:         "/api/v1/pods/default",
+			expectedCode: http.StatusOK,
+			expectedBody: "current",
+		},
+		{
+			name:         "valid token",
+			path:         "/api/v1/pods/default",
+			token:        "token",
+			expectedCode: http.StatusOK,
+			expectedBody: "impersonated default",
+		},
+		{
+			name:         "route not supported",
+			path:         "/api/v1/content",
+			token:        "token",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown token",
+			path:         "/api/v1/pods/default",
+			token:        "unknown",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "expired token",
+			path:         "/api/v1/pods/default",
+			token:        "token",
+			elapsed:      2 * time.Minute,
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sessions.nowFn = func() time.Time { return now.Add(tc.elapsed) }
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.token != "" {
+				req.Header.Set(impersonateTokenHeader, tc.token)
+			}
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, tc.expectedBody, resp.Body.String())
+		})
+	}
+}
+
+func Test_newImpersonationSessions(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	sessions := newImpersonationSessions(time.Minute)
+	sessions.nowFn = func() time.Time { return now }
+
+	session := &impersonationSession{user: "alice", router: mux.NewRouter()}
+	sessions.set("token", session)
+
+	now = now.Add(2 * time.Minute)
+	sessions.sweep()
+
+	assert.Nil(t, session.handler(), "expired sessions are closed")
+	assert.Empty(t, sessions.entries)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubernetes/staging/src/k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+// defaultSlowInitContainerThreshold is how long an init container runs before
+// it is flagged as slow.
+const defaultSlowInitContainerThreshold = 5 * time.Minute
+
+type initContainerStatus struct {
+	Name            string `json:"name"`
+	Image           string `json:"image"`
+	State           string `json:"state"`
+	Reason          string `json:"reason,omitempty"`
+	ExitCode        *int32 `json:"exitCode,omitempty"`
+	Duration        string `json:"duration,omitempty"`
+	DurationSeconds int64  `json:"durationSeconds"`
+	RestartCount    int32  `json:"restartCount"`
+	Slow            bool   `json:"slow"`
+}
+
+type podInitContainers struct {
+	Pod        string                `json:"pod"`
+	Containers []initContainerStatus `json:"containers"`
+}
+
+type initContainersResponse struct {
+	Threshold string              `json:"threshold"`
+	Pods      []podInitContainers `json:"pods"`
+}
+
+type initContainerHandler struct {
+	kubeClient kubernetes.Interface
+	nowFn      func() time.Time
+	logger     log.Logger
+}
+
+var _ http.Handler = (*initContainerHandler)(nil)
+
+func newInitContainerHandler(kubeClient kubernetes.Interface, logger log.Logger) *initContainerHandler {
+	return &initContainerHandler{
+		kubeClient: kubeClient,
+		nowFn:      time.Now,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the init container statuses
+// of the pods in a namespace. A container's duration runs from when it started
+// until it finished, or until now if it is still running. Containers which ran
+// longer than the `threshold` query parameter, five minutes by default, are
+// flagged as slow. Pods without init containers are skipped.
+func (h *initContainerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	threshold := defaultSlowInitContainerThreshold
+	if s := r.URL.Query().Get("threshold"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid threshold %q", s), h.logger)
+			return
+		}
+		threshold = d
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	now := h.nowFn()
+
+	resp := initContainersResponse{
+		Threshold: threshold.String(),
+		Pods:      []podInitContainers{},
+	}
+
+	for _, pod := range pods.Items {
+		if len(pod.Spec.InitContainers) == 0 {
+			continue
+		}
+
+		statuses := make(map[string]corev1.ContainerStatus)
+		for _, status := range pod.Status.InitContainerStatuses {
+			statuses[status.Name] = status
+		}
+
+		item := podInitContainers{
+			Pod:        pod.Name,
+			Containers: []initContainerStatus{},
+		}
+
+		for _, container := range pod.Spec.InitContainers {
+			status := initContainerState(container, statuses[container.Name], now)
+			status.Slow = time.Duration(status.DurationSeconds)*time.Second > threshold
+			item.Containers = append(item.Containers, status)
+		}
+
+		resp.Pods = append(resp.Pods, item)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// initContainerState summarizes an init container's status. Containers which
+// haven't started are waiting and have no duration.
+func initContainerState(container corev1.Container, status corev1.ContainerStatus, now time.Time) initContainerStatus {
+	s := initContainerStatus{
+		Name:         container.Name,
+		Image:        container.Image,
+		State:        "waiting",
+		RestartCount: status.RestartCount,
+	}
+
+	var started, finished time.Time
+
+	switch {
+	case status.State.Running != nil:
+		s.State = "running"
+		started = status.State.Running.StartedAt.Time
+		finished = now
+	case status.State.Terminated != nil:
+		terminated := status.State.Terminated
+		s.State = "terminated"
+		s.Reason = terminated.Reason
+		exitCode := terminated.ExitCode
+		s.ExitCode = &exitCode
+		started = terminated.StartedAt.Time
+		finished = terminated.FinishedAt.Time
+	case status.State.Waiting != nil:
+		s.Reason = status.State.Waiting.Reason
+		if terminated := status.LastTerminationState.Terminated; terminated != nil {
+			exitCode := terminated.ExitCode
+			s.ExitCode = &exitCode
+		}
+	}
+
+	if !started.IsZero() && finished.After(started) {
+		elapsed := finished.Sub(started)
+		s.Duration = duration.ShortHumanDuration(elapsed)
+		s.DurationSeconds = int64(elapsed / time.Second)
+	}
+
+	return s
+}
@@ -0,0 +1,165 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_initContainerHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) metav1.Time {
+		return metav1.NewTime(now.Add(-ago))
+	}
+	exitCode := func(code int32) *int32 {
+		return &code
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					{Name: "migrate", Image: "migrate:1"},
+					{Name: "wait-for-db", Image: "busybox"},
+					{Name: "warm-cache", Image: "cache:1"},
+				},
+				Containers: []corev1.Container{{Name: "web", Image: "web:1"}},
+			},
+			Status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name: "migrate",
+						State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+							Reason:     "Completed",
+							StartedAt:  at(20 * time.Minute),
+							FinishedAt: at(19 * time.Minute),
+						}},
+					},
+					{
+						Name:         "wait-for-db",
+						RestartCount: 2,
+						State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: at(10 * time.Minute)}},
+					},
+					{
+						Name:  "warm-cache",
+						State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}},
+					},
+				},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "fetch", Image: "curl"}},
+			},
+			Status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:         "fetch",
+						RestartCount: 4,
+						State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+						LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+							ExitCode: 1,
+						}},
+					},
+				},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "default"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		},
+	)
+
+	web := func(waitSlow bool) podInitContainers {
+		return podInitContainers{
+			Pod: "web",
+			Containers: []initContainerStatus{
+				{
+					Name: "migrate", Image: "migrate:1", State: "terminated", Reason: "Completed",
+					ExitCode: exitCode(0), Duration: "1m", DurationSeconds: 60,
+				},
+				{
+					Name: "wait-for-db", Image: "busybox", State: "running",
+					Duration: "10m", DurationSeconds: 600, RestartCount: 2, Slow: waitSlow,
+				},
+				{Name: "warm-cache", Image: "cache:1", State: "waiting", Reason: "PodInitializing"},
+			},
+		}
+	}
+
+	job := podInitContainers{
+		Pod: "job",
+		Containers: []initContainerStatus{
+			{Name: "fetch", Image: "curl", State: "waiting", Reason: "CrashLoopBackOff", ExitCode: exitCode(1), RestartCount: 4},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		query             string
+		expectedCode      int
+		expectedThreshold string
+		expected          []podInitContainers
+	}{
+		{
+			name:              "default threshold",
+			expectedCode:      http.StatusOK,
+			expectedThreshold: "5m0s",
+			expected:          []podInitContainers{web(true), job},
+		},
+		{
+			name:              "custom threshold",
+			query:             "?threshold=15m",
+			expectedCode:      http.StatusOK,
+			expectedThreshold: "15m0s",
+			expected:          []podInitContainers{web(false), job},
+		},
+		{
+			name:         "invalid threshold",
+			query:        "?threshold=soon",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newInitContainerHandler(kubeClient, log.NopLogger())
+			handler.nowFn = func() time.Time { return now }
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/initcontainers"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var resp initContainersResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+			assert.Equal(t, tc.expectedThreshold, resp.Threshold)
+			assert.ElementsMatch(t, tc.expected, resp.Pods)
+		})
+	}
+}
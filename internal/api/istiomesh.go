@@ -0,0 +1,197 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	istioProxyContainer          = "istio-proxy"
+	istioSidecarStatusAnnotation = "sidecar.istio.io/status"
+)
+
+// istioSidecarStatus is the injection record the sidecar injector writes to
+// the sidecar.istio.io/status annotation.
+type istioSidecarStatus struct {
+	Version        string   `json:"version"`
+	InitContainers []string `json:"initContainers"`
+}
+
+type istioPodMeshStatus struct {
+	Pod            string   `json:"pod"`
+	Injected       bool     `json:"injected"`
+	ProxyVersion   string   `json:"proxyVersion"`
+	InjectVersion  string   `json:"injectVersion"`
+	InitContainers []string `json:"initContainers"`
+}
+
+type istioMeshResource struct {
+	Kind      string   `json:"kind"`
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Hosts     []string `json:"hosts"`
+}
+
+type istioMeshResponse struct {
+	Pods             []istioPodMeshStatus `json:"pods"`
+	DestinationRules []istioMeshResource  `json:"destinationRules"`
+	VirtualServices  []istioMeshResource  `json:"virtualServices"`
+}
+
+type istioMeshHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*istioMeshHandler)(nil)
+
+func newIstioMeshHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *istioMeshHandler {
+	return &istioMeshHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the Istio sidecar status of
+// each pod in a namespace, along with the DestinationRules and
+// VirtualServices which affect it. These are the objects in the namespace and
+// objects in other namespaces which are exported to it and route to one of
+// its services.
+func (h *istioMeshHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	destinationRules, _, err := listOptionalResource(h.dynamicClient, destinationRuleGVR, "", metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	virtualServices, _, err := listOptionalResource(h.dynamicClient, virtualServiceGVR, "", metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := istioMeshResponse{
+		Pods:             []istioPodMeshStatus{},
+		DestinationRules: []istioMeshResource{},
+		VirtualServices:  []istioMeshResource{},
+	}
+
+	for i := range pods.Items {
+		resp.Pods = append(resp.Pods, istioPodStatus(&pods.Items[i]))
+	}
+
+	for _, destinationRule := range destinationRules.Items {
+		host, _, _ := unstructured.NestedString(destinationRule.Object, "spec", "host")
+		if resource, ok := istioResourceAffecting(destinationRule, []string{host}, namespace); ok {
+			resp.DestinationRules = append(resp.DestinationRules, resource)
+		}
+	}
+
+	for _, virtualService := range virtualServices.Items {
+		hosts, _, _ := unstructured.NestedStringSlice(virtualService.Object, "spec", "hosts")
+		if resource, ok := istioResourceAffecting(virtualService, hosts, namespace); ok {
+			resp.VirtualServices = append(resp.VirtualServices, resource)
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// istioPodStatus returns a pod's sidecar status. A pod is injected when it
+// runs the istio-proxy container. Malformed status annotations are ignored.
+func istioPodStatus(pod *corev1.Pod) istioPodMeshStatus {
+	status := istioPodMeshStatus{
+		Pod:            pod.Name,
+		InitContainers: []string{},
+	}
+
+	for _, container := range pod.Spec.Containers {
+		if container.Name == istioProxyContainer {
+			status.Injected = true
+			status.ProxyVersion = imageTag(container.Image)
+		}
+	}
+
+	if value, ok := pod.Annotations[istioSidecarStatusAnnotation]; ok {
+		var sidecarStatus istioSidecarStatus
+		if err := json.Unmarshal([]byte(value), &sidecarStatus); err == nil {
+			status.InjectVersion = sidecarStatus.Version
+			if sidecarStatus.InitContainers != nil {
+				status.InitContainers = sidecarStatus.InitContainers
+			}
+		}
+	}
+
+	return status
+}
+
+// istioResourceAffecting reports whether an Istio networking object affects a
+// namespace. Objects in the namespace always do. Objects elsewhere do when
+// they are exported to the namespace and one of their hosts is a service in
+// it.
+func istioResourceAffecting(object unstructured.Unstructured, hosts []string, namespace string) (istioMeshResource, bool) {
+	resource := istioMeshResource{
+		Kind:      object.GetKind(),
+		Namespace: object.GetNamespace(),
+		Name:      object.GetName(),
+		Hosts:     hosts,
+	}
+
+	if object.GetNamespace() == namespace {
+		return resource, true
+	}
+
+	exportTo, _, _ := unstructured.NestedStringSlice(object.Object, "spec", "exportTo")
+	if len(exportTo) > 0 && !containsString(exportTo, "*") && !containsString(exportTo, namespace) {
+		return resource, false
+	}
+
+	for _, host := range hosts {
+		if istioHostNamespace(host) == namespace {
+			return resource, true
+		}
+	}
+
+	return resource, false
+}
+
+// istioHostNamespace returns the namespace of a service host such as
+// `reviews.bookinfo` or `reviews.bookinfo.svc.cluster.local`. Short names
+// are relative to the object's own namespace, so an empty string is returned
+// for them and for hosts outside the cluster.
+func istioHostNamespace(host string) string {
+	parts := strings.Split(host, ".")
+	switch {
+	case len(parts) == 2:
+		return parts[1]
+	case len(parts) >= 3 && parts[2] == "svc":
+		return parts[1]
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_istioMeshHandler(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "reviews",
+				Namespace: "bookinfo",
+				Annotations: map[string]string{
+					istioSidecarStatusAnnotation: `{"version":"8a5a6","initContainers":["istio-init"],"containers":["istio-proxy"]}`,
+				},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "reviews", Image: "reviews:v1"},
+					{Name: istioProxyContainer, Image: "docker.io/istio/proxyv2:1.3.3"},
+				},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "bookinfo"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "mysql", Image: "mysql:5.7"}},
+			},
+		},
+	)
+
+	newObject := func(kind, namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+		object := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+		object.SetAPIVersion("networking.istio.io/v1alpha3")
+		object.SetKind(kind)
+		object.SetNamespace(namespace)
+		object.SetName(name)
+		return object
+	}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newObject("DestinationRule", "bookinfo", "reviews", map[string]interface{}{"host": "reviews"}),
+		newObject("DestinationRule", "mesh", "reviews-mtls", map[string]interface{}{
+			"host": "reviews.bookinfo.svc.cluster.local",
+		}),
+		newObject("DestinationRule", "mesh", "private", map[string]interface{}{
+			"host":     "reviews.bookinfo.svc.cluster.local",
+			"exportTo": []interface{}{"."},
+		}),
+		newObject("DestinationRule", "other", "ratings", map[string]interface{}{"host": "ratings"}),
+		newObject("VirtualService", "gateway", "bookinfo", map[string]interface{}{
+			"hosts":    []interface{}{"bookinfo.example.com", "productpage.bookinfo"},
+			"exportTo": []interface{}{"*"},
+		}),
+		newObject("VirtualService", "gateway", "external", map[string]interface{}{
+			"hosts": []interface{}{"api.example.com"},
+		}),
+	)
+
+	handler := newIstioMeshHandler(kubeClient, dynamicClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/istio/meshconfig/bookinfo", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "bookinfo"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got istioMeshResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := istioMeshResponse{
+		Pods: []istioPodMeshStatus{
+			{Pod: "reviews", Injected: true, ProxyVersion: "1.3.3", InjectVersion: "8a5a6", InitContainers: []string{"istio-init"}},
+			{Pod: "mysql", InitContainers: []string{}},
+		},
+		DestinationRules: []istioMeshResource{
+			{Kind: "DestinationRule", Namespace: "bookinfo", Name: "reviews", Hosts: []string{"reviews"}},
+			{Kind: "DestinationRule", Namespace: "mesh", Name: "reviews-mtls", Hosts: []string{"reviews.bookinfo.svc.cluster.local"}},
+		},
+		VirtualServices: []istioMeshResource{
+			{Kind: "VirtualService", Namespace: "gateway", Name: "bookinfo", Hosts: []string{"bookinfo.example.com", "productpage.bookinfo"}},
+		},
+	}
+	assert.Equal(t, expected, got)
+}
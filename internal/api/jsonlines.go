@@ -0,0 +1,78 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// jsonLinesFile persists a store's records as a JSON lines file, one record
+// per line. Stores read the file once and rewrite it whenever their records
+// change, so the file only ever holds the records the store retains.
+type jsonLinesFile struct {
+	path string
+}
+
+// read passes each line of the file to decode and returns the number of
+// lines read. A missing file has no lines.
+func (f jsonLinesFile) read(decode func(line []byte)) (int, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, "open file")
+	}
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines++
+		decode(scanner.Bytes())
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, errors.Wrap(err, "read file")
+	}
+
+	return lines, nil
+}
+
+// write replaces the file with the records encode writes. The records are
+// written to a temporary file which is renamed over the original, so a
+// failed write leaves the previous records in place.
+func (f jsonLinesFile) write(encode func(encoder *json.Encoder) error) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "create temporary file")
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	if err := encode(json.NewEncoder(writer)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "write temporary file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "close temporary file")
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return errors.Wrap(err, "replace file")
+	}
+
+	return nil
+}
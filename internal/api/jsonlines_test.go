@@ -0,0 +1,63 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_jsonLinesFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "json-lines")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := jsonLinesFile{path: filepath.Join(dir, "records.jsonl")}
+
+	readAll := func() (int, []string) {
+		var records []string
+		lines, err := file.read(func(line []byte) {
+			var record string
+			if json.Unmarshal(line, &record) == nil {
+				records = append(records, record)
+			}
+		})
+		require.NoError(t, err)
+		return lines, records
+	}
+
+	lines, records := readAll()
+	assert.Zero(t, lines, "a missing file has no lines")
+	assert.Empty(t, records)
+
+	write := func(records ...string) {
+		require.NoError(t, file.write(func(encoder *json.Encoder) error {
+			for _, record := range records {
+				if err := encoder.Encode(record); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	}
+
+	write("a", "b", "c")
+	write("b", "c")
+
+	lines, records = readAll()
+	assert.Equal(t, 2, lines, "writes replace the file")
+	assert.Equal(t, []string{"b", "c"}, records)
+
+	entries, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files are removed")
+}
@@ -0,0 +1,105 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// karpenterNodePoolLabel is set on nodes to the NodePool which launched them.
+	karpenterNodePoolLabel = "karpenter.sh/nodepool"
+)
+
+var karpenterNodePoolGVR = schema.GroupVersionResource{
+	Group:    "karpenter.sh",
+	Version:  "v1",
+	Resource: "nodepools",
+}
+
+type karpenterNodePool struct {
+	Name         string                 `json:"name"`
+	NodeClassRef map[string]interface{} `json:"nodeClassRef"`
+	Disruption   map[string]interface{} `json:"disruption"`
+	Limits       map[string]interface{} `json:"limits"`
+	NodeCount    int                    `json:"nodeCount"`
+}
+
+type karpenterResponse struct {
+	KarpenterNotInstalled bool                `json:"karpenterNotInstalled"`
+	NodePools             []karpenterNodePool `json:"nodePools"`
+}
+
+type karpenterHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*karpenterHandler)(nil)
+
+func newKarpenterHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *karpenterHandler {
+	return &karpenterHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns Karpenter NodePools with the
+// number of nodes each has launched.
+func (h *karpenterHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp := karpenterResponse{
+		NodePools: []karpenterNodePool{},
+	}
+
+	nodePools, installed, err := listOptionalResource(h.dynamicClient, karpenterNodePoolGVR, "", metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	if !installed {
+		resp.KarpenterNotInstalled = true
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	nodes, err := h.kubeClient.CoreV1().Nodes().List(metav1.ListOptions{LabelSelector: karpenterNodePoolLabel})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	nodeCounts := make(map[string]int)
+	for _, node := range nodes.Items {
+		nodeCounts[node.Labels[karpenterNodePoolLabel]]++
+	}
+
+	for _, nodePool := range nodePools.Items {
+		nodeClassRef, _, _ := unstructured.NestedMap(nodePool.Object, "spec", "template", "spec", "nodeClassRef")
+		disruption, _, _ := unstructured.NestedMap(nodePool.Object, "spec", "disruption")
+		limits, _, _ := unstructured.NestedMap(nodePool.Object, "spec", "limits")
+
+		resp.NodePools = append(resp.NodePools, karpenterNodePool{
+			Name:         nodePool.GetName(),
+			NodeClassRef: nodeClassRef,
+			Disruption:   disruption,
+			Limits:       limits,
+			NodeCount:    nodeCounts[nodePool.GetName()],
+		})
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_karpenterHandler(t *testing.T) {
+	newNode := func(name, nodePool string) *corev1.Node {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if nodePool != "" {
+			node.Labels = map[string]string{karpenterNodePoolLabel: nodePool}
+		}
+		return node
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newNode("node-1", "default"),
+		newNode("node-2", "default"),
+		newNode("node-3", "gpu"),
+		newNode("node-4", ""),
+	)
+
+	nodePool := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"nodeClassRef": map[string]interface{}{"group": "karpenter.k8s.aws", "kind": "EC2NodeClass", "name": "default"},
+				},
+			},
+			"disruption": map[string]interface{}{"consolidationPolicy": "WhenEmptyOrUnderutilized", "consolidateAfter": "1m"},
+			"limits":     map[string]interface{}{"cpu": "1000"},
+		},
+	}}
+	nodePool.SetAPIVersion("karpenter.sh/v1")
+	nodePool.SetKind("NodePool")
+	nodePool.SetName("default")
+
+	tests := []struct {
+		name     string
+		missing  bool
+		expected karpenterResponse
+	}{
+		{
+			name: "node pools",
+			expected: karpenterResponse{
+				NodePools: []karpenterNodePool{
+					{
+						Name:         "default",
+						NodeClassRef: map[string]interface{}{"group": "karpenter.k8s.aws", "kind": "EC2NodeClass", "name": "default"},
+						Disruption:   map[string]interface{}{"consolidationPolicy": "WhenEmptyOrUnderutilized", "consolidateAfter": "1m"},
+						Limits:       map[string]interface{}{"cpu": "1000"},
+						NodeCount:    2,
+					},
+				},
+			},
+		},
+		{
+			name:    "karpenter not installed",
+			missing: true,
+			expected: karpenterResponse{
+				KarpenterNotInstalled: true,
+				NodePools:             []karpenterNodePool{},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), nodePool)
+			if tc.missing {
+				dynamicClient.PrependReactor("list", "nodepools", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+				})
+			}
+
+			handler := newKarpenterHandler(kubeClient, dynamicClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/karpenter/nodepools", nil)
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got karpenterResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	kubecostCacheTTL = 5 * time.Minute
+
+	defaultKubecostWindow = "7d"
+)
+
+// kubecostWindowPattern matches KubeCost windows such as `7d`, `today` and
+// `2019-10-01T00:00:00Z,2019-10-02T00:00:00Z`.
+var kubecostWindowPattern = regexp.MustCompile(`^[A-Za-z0-9:,.\-]+$`)
+
+// kubecostAllocation is the subset of a KubeCost allocation used for
+// summaries.
+type kubecostAllocation struct {
+	CPUCost     float64 `json:"cpuCost"`
+	RAMCost     float64 `json:"ramCost"`
+	PVCost      float64 `json:"pvCost"`
+	NetworkCost float64 `json:"networkCost"`
+	TotalCost   float64 `json:"totalCost"`
+}
+
+// kubecostAllocationResponse is a KubeCost allocation API response. Each
+// entry in data is an allocation set keyed by the aggregate's name.
+type kubecostAllocationResponse struct {
+	Code    int                              `json:"code"`
+	Message string                           `json:"message"`
+	Data    []map[string]*kubecostAllocation `json:"data"`
+}
+
+type kubecostSummary struct {
+	Namespace   string  `json:"namespace"`
+	Window      string  `json:"window"`
+	CPUCost     float64 `json:"cpuCost"`
+	MemoryCost  float64 `json:"memoryCost"`
+	PVCost      float64 `json:"pvCost"`
+	NetworkCost float64 `json:"networkCost"`
+	TotalCost   float64 `json:"totalCost"`
+}
+
+type kubecostProxyHandler struct {
+	kubecostURL string
+	httpClient  *http.Client
+	cache       *ttlCache
+	logger      log.Logger
+}
+
+var _ http.Handler = (*kubecostProxyHandler)(nil)
+
+func newKubecostProxyHandler(kubecostURL string, logger log.Logger) *kubecostProxyHandler {
+	return &kubecostProxyHandler{
+		kubecostURL: strings.TrimSuffix(kubecostURL, "/"),
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		cache:       newTTLCache(kubecostCacheTTL),
+		logger:      logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns a namespace's costs from the
+// KubeCost allocation API over the `window` query parameter, seven days by
+// default. Memory cost is KubeCost's RAM cost. Summaries are cached for five
+// minutes.
+func (h *kubecostProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.kubecostURL == "" {
+		RespondWithError(w, http.StatusNotImplemented, "KubeCost is not configured; set OCTANT_KUBECOST_URL to the KubeCost cost model URL", h.logger)
+		return
+	}
+
+	namespace := mux.Vars(r)["namespace"]
+
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = defaultKubecostWindow
+	}
+	if !kubecostWindowPattern.MatchString(window) {
+		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid window %q", window), h.logger)
+		return
+	}
+
+	key := namespace + "|" + window
+	if cached, ok := h.cache.get(key); ok {
+		resp := cached.(kubecostSummary)
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	resp, err := h.summary(namespace, window)
+	if err != nil {
+		RespondWithError(w, http.StatusBadGateway, err.Error(), h.logger)
+		return
+	}
+
+	h.cache.set(key, resp)
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// summary requests a namespace's accumulated allocation from KubeCost.
+func (h *kubecostProxyHandler) summary(namespace, window string) (kubecostSummary, error) {
+	resp := kubecostSummary{
+		Namespace: namespace,
+		Window:    window,
+	}
+
+	query := url.Values{}
+	query.Set("window", window)
+	query.Set("aggregate", "namespace")
+	query.Set("accumulate", "true")
+	query.Set("filterNamespaces", namespace)
+
+	res, err := h.httpClient.Get(fmt.Sprintf("%s/model/allocation?%s", h.kubecostURL, query.Encode()))
+	if err != nil {
+		return resp, errors.Wrap(err, "fetch allocation from kubecost")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return resp, errors.Errorf("kubecost returned %s", res.Status)
+	}
+
+	var allocations kubecostAllocationResponse
+	if err := json.NewDecoder(res.Body).Decode(&allocations); err != nil {
+		return resp, errors.Wrap(err, "decode kubecost allocation")
+	}
+
+	if allocations.Code != 0 && allocations.Code != http.StatusOK {
+		return resp, errors.Errorf("kubecost returned %d: %s", allocations.Code, allocations.Message)
+	}
+
+	for _, set := range allocations.Data {
+		allocation := set[namespace]
+		if allocation == nil {
+			continue
+		}
+
+		resp.CPUCost += allocation.CPUCost
+		resp.MemoryCost += allocation.RAMCost
+		resp.PVCost += allocation.PVCost
+		resp.NetworkCost += allocation.NetworkCost
+		resp.TotalCost += allocation.TotalCost
+	}
+
+	resp.CPUCost = roundCost(resp.CPUCost)
+	resp.MemoryCost = roundCost(resp.MemoryCost)
+	resp.PVCost = roundCost(resp.PVCost)
+	resp.NetworkCost = roundCost(resp.NetworkCost)
+	resp.TotalCost = roundCost(resp.TotalCost)
+
+	return resp, nil
+}
@@ -0,0 +1,139 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_kubecostProxyHandler(t *testing.T) {
+	kubecost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/model/allocation", r.URL.Path)
+		assert.Equal(t, "namespace", r.URL.Query().Get("aggregate"))
+		assert.Equal(t, "true", r.URL.Query().Get("accumulate"))
+
+		switch r.URL.Query().Get("filterNamespaces") {
+		case "failing":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "default":
+			assert.Equal(t, "7d", r.URL.Query().Get("window"))
+			fmt.Fprint(w, `{"code":200,"data":[
+				{"default":{"name":"default","cpuCost":1.2345,"ramCost":0.5,"pvCost":0.25,"networkCost":0.1,"totalCost":2.0845}},
+				{"default":{"name":"default","cpuCost":1,"ramCost":1,"pvCost":0,"networkCost":0,"totalCost":2}}
+			]}`)
+		default:
+			fmt.Fprint(w, `{"code":200,"data":[{}]}`)
+		}
+	}))
+	defer kubecost.Close()
+
+	tests := []struct {
+		name         string
+		url          string
+		namespace    string
+		query        string
+		expectedCode int
+		expected     kubecostSummary
+	}{
+		{
+			name:         "summed allocations",
+			url:          kubecost.URL + "/",
+			namespace:    "default",
+			expectedCode: http.StatusOK,
+			expected: kubecostSummary{
+				Namespace:   "default",
+				Window:      "7d",
+				CPUCost:     2.2345,
+				MemoryCost:  1.5,
+				PVCost:      0.25,
+				NetworkCost: 0.1,
+				TotalCost:   4.0845,
+			},
+		},
+		{
+			name:         "namespace without allocations",
+			url:          kubecost.URL,
+			namespace:    "empty",
+			query:        "?window=24h",
+			expectedCode: http.StatusOK,
+			expected: kubecostSummary{
+				Namespace: "empty",
+				Window:    "24h",
+			},
+		},
+		{
+			name:         "invalid window",
+			url:          kubecost.URL,
+			namespace:    "default",
+			query:        "?window=7d%26aggregate=pod",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "kubecost error",
+			url:          kubecost.URL,
+			namespace:    "failing",
+			expectedCode: http.StatusBadGateway,
+		},
+		{
+			name:         "kubecost not configured",
+			namespace:    "default",
+			expectedCode: http.StatusNotImplemented,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newKubecostProxyHandler(tc.url, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/kubecost/summary/"+tc.namespace+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": tc.namespace})
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var got kubecostSummary
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func Test_kubecostProxyHandler_cache(t *testing.T) {
+	requests := 0
+	kubecost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"code":200,"data":[{"default":{"totalCost":1}}]}`)
+	}))
+	defer kubecost.Close()
+
+	handler := newKubecostProxyHandler(kubecost.URL, log.NopLogger())
+
+	for _, query := range []string{"", "", "?window=24h"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/kubecost/summary/default"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	assert.Equal(t, 2, requests)
+}
@@ -0,0 +1,205 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// kubeletConfigMapPrefix is the name kubeadm gives the ConfigMap holding
+	// the cluster wide kubelet configuration, followed by a version suffix.
+	kubeletConfigMapPrefix = "kubelet-config"
+
+	// kubeletConfigMapKey is the ConfigMap key of the kubelet configuration.
+	kubeletConfigMapKey = "kubelet"
+)
+
+// kubeletConfigFetcher returns the body of a node's kubelet configz endpoint.
+type kubeletConfigFetcher func(node string) ([]byte, error)
+
+type kubeletConfigDeviation struct {
+	Setting string      `json:"setting"`
+	Value   interface{} `json:"value"`
+	Default interface{} `json:"default"`
+}
+
+type kubeletConfigResponse struct {
+	Node       string                   `json:"node"`
+	Config     map[string]interface{}   `json:"config"`
+	ConfigMap  string                   `json:"configMap,omitempty"`
+	Deviations []kubeletConfigDeviation `json:"deviations"`
+}
+
+type kubeletConfigHandler struct {
+	kubeClient kubernetes.Interface
+	fetch      kubeletConfigFetcher
+	logger     log.Logger
+}
+
+var _ http.Handler = (*kubeletConfigHandler)(nil)
+
+func newKubeletConfigHandler(kubeClient kubernetes.Interface, logger log.Logger) *kubeletConfigHandler {
+	return &kubeletConfigHandler{
+		kubeClient: kubeClient,
+		fetch:      newNodeProxyKubeletConfigFetcher(kubeClient),
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns a node's active kubelet
+// configuration, read from the kubelet's `/configz` endpoint through the API
+// server's node proxy. When kube-system has a kubeadm `kubelet-config`
+// ConfigMap, settings whose values differ from it are returned as deviations.
+func (h *kubeletConfigHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	node := mux.Vars(r)["node"]
+
+	if _, err := h.kubeClient.CoreV1().Nodes().Get(node, metav1.GetOptions{}); err != nil {
+		RespondWithError(w, statusForKubeError(err), err.Error(), h.logger)
+		return
+	}
+
+	data, err := h.fetch(node)
+	if err != nil {
+		RespondWithError(w, http.StatusBadGateway, errors.Wrap(err, "fetch kubelet configz").Error(), h.logger)
+		return
+	}
+
+	var configz struct {
+		KubeletConfig map[string]interface{} `json:"kubeletconfig"`
+	}
+	if err := json.Unmarshal(data, &configz); err != nil || configz.KubeletConfig == nil {
+		RespondWithError(w, http.StatusBadGateway, "kubelet configz response is not a kubelet configuration", h.logger)
+		return
+	}
+
+	resp := kubeletConfigResponse{
+		Node:       node,
+		Config:     configz.KubeletConfig,
+		Deviations: []kubeletConfigDeviation{},
+	}
+
+	name, defaults, err := h.clusterKubeletConfig()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	if defaults != nil {
+		resp.ConfigMap = name
+		resp.Deviations = kubeletConfigDeviations(configz.KubeletConfig, defaults)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// clusterKubeletConfig returns the name and contents of the cluster wide
+// kubelet configuration. kubeadm keeps one ConfigMap per minor version; the
+// last by name is used. It returns nil if there is no such ConfigMap.
+func (h *kubeletConfigHandler) clusterKubeletConfig() (string, map[string]interface{}, error) {
+	configMaps, err := h.kubeClient.CoreV1().ConfigMaps(metav1.NamespaceSystem).List(metav1.ListOptions{})
+	if err != nil {
+		if kerrors.IsForbidden(err) {
+			return "", nil, nil
+		}
+		return "", nil, errors.Wrap(err, "list kube-system configmaps")
+	}
+
+	var name, data string
+	for _, configMap := range configMaps.Items {
+		if !strings.HasPrefix(configMap.Name, kubeletConfigMapPrefix) {
+			continue
+		}
+		value, ok := configMap.Data[kubeletConfigMapKey]
+		if !ok || configMap.Name < name {
+			continue
+		}
+		name, data = configMap.Name, value
+	}
+
+	if name == "" {
+		return "", nil, nil
+	}
+
+	converted, err := yaml.ToJSON([]byte(data))
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "parse configmap %s", name)
+	}
+
+	var config map[string]interface{}
+	if err := json.Unmarshal(converted, &config); err != nil {
+		return "", nil, errors.Wrapf(err, "parse configmap %s", name)
+	}
+
+	return name, config, nil
+}
+
+// kubeletConfigDeviations returns the settings in defaults whose values differ
+// in config. Nested settings are compared individually and named by their
+// dotted path. Type metadata is not compared.
+func kubeletConfigDeviations(config, defaults map[string]interface{}) []kubeletConfigDeviation {
+	deviations := []kubeletConfigDeviation{}
+
+	var compare func(prefix string, config, defaults map[string]interface{})
+	compare = func(prefix string, config, defaults map[string]interface{}) {
+		for key, want := range defaults {
+			if prefix == "" && (key == "apiVersion" || key == "kind") {
+				continue
+			}
+
+			setting := prefix + key
+			got := config[key]
+
+			wantMap, wantIsMap := want.(map[string]interface{})
+			gotMap, gotIsMap := got.(map[string]interface{})
+			if wantIsMap && gotIsMap {
+				compare(setting+".", gotMap, wantMap)
+				continue
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				deviations = append(deviations, kubeletConfigDeviation{
+					Setting: setting,
+					Value:   got,
+					Default: want,
+				})
+			}
+		}
+	}
+	compare("", config, defaults)
+
+	sort.Slice(deviations, func(i, j int) bool {
+		return deviations[i].Setting < deviations[j].Setting
+	})
+
+	return deviations
+}
+
+// newNodeProxyKubeletConfigFetcher creates a kubeletConfigFetcher which
+// reaches the kubelet through the API server's node proxy.
+func newNodeProxyKubeletConfigFetcher(kubeClient kubernetes.Interface) kubeletConfigFetcher {
+	return func(node string) ([]byte, error) {
+		return kubeClient.CoreV1().RESTClient().Get().
+			Resource("nodes").
+			Name(node).
+			SubResource("proxy").
+			Suffix("configz").
+			DoRaw()
+	}
+}
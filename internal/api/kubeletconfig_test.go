@@ -0,0 +1,137 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_kubeletConfigHandler(t *testing.T) {
+	configz := `{"kubeletconfig":{
+		"maxPods":110,
+		"imageGCHighThresholdPercent":90,
+		"evictionHard":{"memory.available":"100Mi","nodefs.available":"5%"},
+		"cgroupDriver":"cgroupfs"
+	}}`
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+
+	clusterConfig := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubelet-config-1.15", Namespace: "kube-system"},
+		Data: map[string]string{
+			"kubelet": `apiVersion: kubelet.config.k8s.io/v1beta1
+kind: KubeletConfiguration
+maxPods: 110
+imageGCHighThresholdPercent: 85
+evictionHard:
+  memory.available: 100Mi
+  nodefs.available: 10%
+cgroupDriver: systemd
+`,
+		},
+	}
+
+	tests := []struct {
+		name         string
+		node         string
+		objects      []runtime.Object
+		fetchErr     error
+		expectedCode int
+		expected     *kubeletConfigResponse
+	}{
+		{
+			name:         "without cluster configuration",
+			node:         "node-1",
+			objects:      []runtime.Object{node},
+			expectedCode: http.StatusOK,
+			expected: &kubeletConfigResponse{
+				Node: "node-1",
+				Config: map[string]interface{}{
+					"maxPods":                     float64(110),
+					"imageGCHighThresholdPercent": float64(90),
+					"evictionHard":                map[string]interface{}{"memory.available": "100Mi", "nodefs.available": "5%"},
+					"cgroupDriver":                "cgroupfs",
+				},
+				Deviations: []kubeletConfigDeviation{},
+			},
+		},
+		{
+			name:         "deviations from cluster configuration",
+			node:         "node-1",
+			objects:      []runtime.Object{node, clusterConfig},
+			expectedCode: http.StatusOK,
+			expected: &kubeletConfigResponse{
+				Node: "node-1",
+				Config: map[string]interface{}{
+					"maxPods":                     float64(110),
+					"imageGCHighThresholdPercent": float64(90),
+					"evictionHard":                map[string]interface{}{"memory.available": "100Mi", "nodefs.available": "5%"},
+					"cgroupDriver":                "cgroupfs",
+				},
+				ConfigMap: "kubelet-config-1.15",
+				Deviations: []kubeletConfigDeviation{
+					{Setting: "cgroupDriver", Value: "cgroupfs", Default: "systemd"},
+					{Setting: "evictionHard.nodefs.available", Value: "5%", Default: "10%"},
+					{Setting: "imageGCHighThresholdPercent", Value: float64(90), Default: float64(85)},
+				},
+			},
+		},
+		{
+			name:         "node not found",
+			node:         "missing",
+			objects:      []runtime.Object{node},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "kubelet unreachable",
+			node:         "node-1",
+			objects:      []runtime.Object{node},
+			fetchErr:     errors.New("connection refused"),
+			expectedCode: http.StatusBadGateway,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := newKubeletConfigHandler(kubefake.NewSimpleClientset(test.objects...), log.NopLogger())
+			handler.fetch = func(node string) ([]byte, error) {
+				if test.fetchErr != nil {
+					return nil, test.fetchErr
+				}
+				return []byte(configz), nil
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/kubelet/config/"+test.node, nil)
+			req = mux.SetURLVars(req, map[string]string{"node": test.node})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, test.expectedCode, resp.Code)
+
+			if test.expected == nil {
+				return
+			}
+
+			var got kubeletConfigResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, *test.expected, got)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type kustomizationOverlay struct {
+	Name                string          `json:"name"`
+	Path                string          `json:"path"`
+	SourceRef           fluxSourceRef   `json:"sourceRef"`
+	LastAppliedRevision string          `json:"lastAppliedRevision"`
+	Ready               bool            `json:"ready"`
+	Conditions          []fluxCondition `json:"conditions"`
+}
+
+type kustomizeOverlaysResponse struct {
+	KustomizeNotInstalled bool                   `json:"kustomizeNotInstalled"`
+	Kustomizations        []kustomizationOverlay `json:"kustomizations"`
+}
+
+type kustomizeHandler struct {
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+func newKustomizeHandler(dynamicClient dynamic.Interface, logger log.Logger) *kustomizeHandler {
+	return &kustomizeHandler{
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// list returns the Flux Kustomizations in a namespace with the path they
+// apply, their source and their conditions, sorted by name.
+func (h *kustomizeHandler) list(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	kustomizations, installed, err := listKustomizations(h.dynamicClient, namespace)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := kustomizeOverlaysResponse{
+		KustomizeNotInstalled: !installed,
+		Kustomizations:        []kustomizationOverlay{},
+	}
+
+	for _, kustomization := range kustomizations.Items {
+		path, _, _ := unstructured.NestedString(kustomization.Object, "spec", "path")
+		lastApplied, _, _ := unstructured.NestedString(kustomization.Object, "status", "lastAppliedRevision")
+
+		overlay := kustomizationOverlay{
+			Name:                kustomization.GetName(),
+			Path:                path,
+			SourceRef:           kustomizationSourceRef(kustomization),
+			LastAppliedRevision: lastApplied,
+			Conditions:          fluxConditions(kustomization),
+		}
+
+		for _, condition := range overlay.Conditions {
+			if condition.Type == "Ready" {
+				overlay.Ready = condition.Status == "True"
+			}
+		}
+
+		resp.Kustomizations = append(resp.Kustomizations, overlay)
+	}
+
+	sort.Slice(resp.Kustomizations, func(i, j int) bool {
+		return resp.Kustomizations[i].Name < resp.Kustomizations[j].Name
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func kustomizationSourceRef(kustomization unstructured.Unstructured) fluxSourceRef {
+	sourceRef, _, _ := unstructured.NestedStringMap(kustomization.Object, "spec", "sourceRef")
+
+	return fluxSourceRef{
+		Kind:      sourceRef["kind"],
+		Name:      sourceRef["name"],
+		Namespace: sourceRef["namespace"],
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func newTestKustomization(name, source, lastApplied, ready string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kustomize.toolkit.fluxcd.io/v1",
+		"kind":       "Kustomization",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "flux-system"},
+		"spec": map[string]interface{}{
+			"path":      "./overlays/" + name,
+			"sourceRef": map[string]interface{}{"kind": "GitRepository", "name": source},
+		},
+		"status": map[string]interface{}{
+			"lastAppliedRevision":   lastApplied,
+			"lastAttemptedRevision": lastApplied,
+			"conditions": []interface{}{
+				map[string]interface{}{
+					"type":               "Ready",
+					"status":             ready,
+					"reason":             "ReconciliationSucceeded",
+					"lastTransitionTime": "2019-10-01T12:00:00Z",
+				},
+			},
+		},
+	}}
+}
+
+func Test_kustomizeHandler_list(t *testing.T) {
+	tests := []struct {
+		name    string
+		missing bool
+	}{
+		{name: "kustomize installed"},
+		{name: "kustomize not installed", missing: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+				newTestKustomization("staging", "app", "main@sha1:bbb", "False"),
+				newTestKustomization("production", "app", "main@sha1:aaa", "True"),
+			)
+			if tc.missing {
+				dynamicClient.PrependReactor("list", "kustomizations", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+				})
+			}
+
+			handler := newKustomizeHandler(dynamicClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/flux-system/kustomizeoverlays", nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "flux-system"})
+			w := httptest.NewRecorder()
+
+			handler.list(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var resp kustomizeOverlaysResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+			if tc.missing {
+				assert.True(t, resp.KustomizeNotInstalled)
+				assert.Empty(t, resp.Kustomizations)
+				return
+			}
+
+			expected := []kustomizationOverlay{
+				{
+					Name:                "production",
+					Path:                "./overlays/production",
+					SourceRef:           fluxSourceRef{Kind: "GitRepository", Name: "app"},
+					LastAppliedRevision: "main@sha1:aaa",
+					Ready:               true,
+					Conditions: []fluxCondition{
+						{Type: "Ready", Status: "True", Reason: "ReconciliationSucceeded", LastTransitionTime: "2019-10-01T12:00:00Z"},
+					},
+				},
+				{
+					Name:                "staging",
+					Path:                "./overlays/staging",
+					SourceRef:           fluxSourceRef{Kind: "GitRepository", Name: "app"},
+					LastAppliedRevision: "main@sha1:bbb",
+					Conditions: []fluxCondition{
+						{Type: "Ready", Status: "False", Reason: "ReconciliationSucceeded", LastTransitionTime: "2019-10-01T12:00:00Z"},
+					},
+				},
+			}
+			assert.False(t, resp.KustomizeNotInstalled)
+			assert.Equal(t, expected, resp.Kustomizations)
+		})
+	}
+}
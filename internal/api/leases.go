@@ -0,0 +1,134 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+var leaseComponentLabels = []string{"app.kubernetes.io/component", "component"}
+
+type leaseStatus struct {
+	Name                 string            `json:"name"`
+	HolderIdentity       string            `json:"holderIdentity"`
+	LeaseDurationSeconds *int32            `json:"leaseDurationSeconds"`
+	AcquireTime          *metav1.MicroTime `json:"acquireTime,omitempty"`
+	RenewTime            *metav1.MicroTime `json:"renewTime,omitempty"`
+	// Expired is null when the lease has no duration, since its expiry is
+	// unknown.
+	Expired *bool `json:"expired"`
+}
+
+type leaseGroup struct {
+	Component string        `json:"component"`
+	Leases    []leaseStatus `json:"leases"`
+}
+
+type leasesResponse struct {
+	Groups []leaseGroup `json:"groups"`
+}
+
+type leasesHandler struct {
+	kubeClient kubernetes.Interface
+	nowFn      func() time.Time
+	logger     log.Logger
+}
+
+var _ http.Handler = (*leasesHandler)(nil)
+
+func newLeasesHandler(kubeClient kubernetes.Interface, logger log.Logger) *leasesHandler {
+	return &leasesHandler{
+		kubeClient: kubeClient,
+		nowFn:      time.Now,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the leader election leases in
+// a namespace grouped by component label. Leases without a component label
+// are grouped under an empty component.
+func (h *leasesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	leases, err := h.kubeClient.CoordinationV1().Leases(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	now := h.nowFn()
+	groups := make(map[string][]leaseStatus)
+
+	for i := range leases.Items {
+		lease := &leases.Items[i]
+		component := leaseComponent(lease)
+		groups[component] = append(groups[component], newLeaseStatus(lease, now))
+	}
+
+	resp := leasesResponse{
+		Groups: []leaseGroup{},
+	}
+
+	for component, statuses := range groups {
+		sort.Slice(statuses, func(i, j int) bool {
+			return statuses[i].Name < statuses[j].Name
+		})
+		resp.Groups = append(resp.Groups, leaseGroup{Component: component, Leases: statuses})
+	}
+	sort.Slice(resp.Groups, func(i, j int) bool {
+		return resp.Groups[i].Component < resp.Groups[j].Component
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func leaseComponent(lease *coordinationv1.Lease) string {
+	for _, label := range leaseComponentLabels {
+		if component := lease.Labels[label]; component != "" {
+			return component
+		}
+	}
+
+	return ""
+}
+
+// newLeaseStatus converts a lease to a leaseStatus. A lease is expired when it
+// has not been renewed within its duration. Whether a lease without a
+// duration has expired is unknown.
+func newLeaseStatus(lease *coordinationv1.Lease, now time.Time) leaseStatus {
+	status := leaseStatus{
+		Name:                 lease.Name,
+		LeaseDurationSeconds: lease.Spec.LeaseDurationSeconds,
+		AcquireTime:          lease.Spec.AcquireTime,
+		RenewTime:            lease.Spec.RenewTime,
+	}
+
+	if lease.Spec.HolderIdentity != nil {
+		status.HolderIdentity = *lease.Spec.HolderIdentity
+	}
+
+	if status.LeaseDurationSeconds == nil {
+		return status
+	}
+
+	expired := true
+	if status.RenewTime != nil {
+		expiry := status.RenewTime.Add(time.Duration(*status.LeaseDurationSeconds) * time.Second)
+		expired = expiry.Before(now)
+	}
+	status.Expired = &expired
+
+	return status
+}
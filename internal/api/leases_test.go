@@ -0,0 +1,90 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_leasesHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	duration := int32(15)
+
+	newLease := func(name, component string, duration *int32, renewedAgo time.Duration) *coordinationv1.Lease {
+		holder := name + "-holder"
+		renewTime := metav1.NewMicroTime(now.Add(-renewedAgo))
+
+		lease := &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kube-system"},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: duration,
+				RenewTime:            &renewTime,
+			},
+		}
+		if component != "" {
+			lease.Labels = map[string]string{"component": component}
+		}
+		return lease
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newLease("kube-scheduler", "scheduler", &duration, 5*time.Second),
+		newLease("kube-controller-manager", "controller-manager", &duration, time.Minute),
+		newLease("operator", "", &duration, 0),
+		newLease("unbounded", "", nil, time.Hour),
+	)
+
+	handler := newLeasesHandler(kubeClient, log.NopLogger())
+	handler.nowFn = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/leases/kube-system", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "kube-system"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got leasesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	require.Len(t, got.Groups, 3)
+
+	expired := make(map[string]*bool)
+	for _, group := range got.Groups {
+		for _, lease := range group.Leases {
+			assert.Equal(t, lease.Name+"-holder", lease.HolderIdentity)
+			if lease.Name == "unbounded" {
+				assert.Nil(t, lease.LeaseDurationSeconds)
+			} else {
+				assert.Equal(t, &duration, lease.LeaseDurationSeconds)
+			}
+			expired[group.Component+"/"+lease.Name] = lease.Expired
+		}
+	}
+
+	yes, no := true, false
+	assert.Equal(t, map[string]*bool{
+		"/operator":  &no,
+		"/unbounded": nil,
+		"controller-manager/kube-controller-manager": &yes,
+		"scheduler/kube-scheduler":                   &no,
+	}, expired)
+}
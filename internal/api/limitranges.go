@@ -0,0 +1,196 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type limitRangeLimit struct {
+	Type           corev1.LimitType   `json:"type"`
+	Resource       string             `json:"resource"`
+	Min            *resource.Quantity `json:"min,omitempty"`
+	Max            *resource.Quantity `json:"max,omitempty"`
+	Default        *resource.Quantity `json:"default,omitempty"`
+	DefaultRequest *resource.Quantity `json:"defaultRequest,omitempty"`
+	PercentAtMin   float64            `json:"percentAtMin"`
+	PercentAtMax   float64            `json:"percentAtMax"`
+}
+
+type limitRangeStatus struct {
+	Name   string            `json:"name"`
+	Limits []limitRangeLimit `json:"limits"`
+}
+
+type limitRangesResponse struct {
+	RunningPods int                `json:"runningPods"`
+	LimitRanges []limitRangeStatus `json:"limitRanges"`
+}
+
+type limitRangesHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*limitRangesHandler)(nil)
+
+func newLimitRangesHandler(kubeClient kubernetes.Interface, logger log.Logger) *limitRangesHandler {
+	return &limitRangesHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the LimitRanges in a namespace.
+// Each limit reports the percentage of running pods whose requests sit at the
+// minimum or whose limits sit at the maximum.
+func (h *limitRangesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	limitRanges, err := h.kubeClient.CoreV1().LimitRanges(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	var running []corev1.Pod
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			running = append(running, pod)
+		}
+	}
+
+	resp := limitRangesResponse{
+		RunningPods: len(running),
+		LimitRanges: []limitRangeStatus{},
+	}
+
+	for _, limitRange := range limitRanges.Items {
+		status := limitRangeStatus{
+			Name:   limitRange.Name,
+			Limits: []limitRangeLimit{},
+		}
+
+		for _, item := range limitRange.Spec.Limits {
+			for _, name := range limitRangeResourceNames(item) {
+				limit := limitRangeLimit{
+					Type:           item.Type,
+					Resource:       string(name),
+					Min:            quantityFor(item.Min, name),
+					Max:            quantityFor(item.Max, name),
+					Default:        quantityFor(item.Default, name),
+					DefaultRequest: quantityFor(item.DefaultRequest, name),
+				}
+
+				limit.PercentAtMin, limit.PercentAtMax = limitBoundaryPercentages(item.Type, name, limit.Min, limit.Max, running)
+				status.Limits = append(status.Limits, limit)
+			}
+		}
+
+		resp.LimitRanges = append(resp.LimitRanges, status)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// limitRangeResourceNames returns the sorted names of resources constrained by a limit.
+func limitRangeResourceNames(item corev1.LimitRangeItem) []corev1.ResourceName {
+	seen := make(map[corev1.ResourceName]bool)
+	for _, list := range []corev1.ResourceList{item.Min, item.Max, item.Default, item.DefaultRequest, item.MaxLimitRequestRatio} {
+		for name := range list {
+			seen[name] = true
+		}
+	}
+
+	var names []corev1.ResourceName
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	return names
+}
+
+func quantityFor(list corev1.ResourceList, name corev1.ResourceName) *resource.Quantity {
+	q, ok := list[name]
+	if !ok {
+		return nil
+	}
+
+	return &q
+}
+
+// limitBoundaryPercentages returns the percentage of pods with a request equal
+// to min and with a limit equal to max. Container limits are checked per
+// container, and a pod counts if any of its containers is at the boundary.
+// Pod limits are checked against the sum of the pod's containers. Other limit
+// types don't apply to pods.
+func limitBoundaryPercentages(limitType corev1.LimitType, name corev1.ResourceName, min, max *resource.Quantity, pods []corev1.Pod) (float64, float64) {
+	if len(pods) == 0 || (limitType != corev1.LimitTypeContainer && limitType != corev1.LimitTypePod) {
+		return 0, 0
+	}
+
+	atBoundary := func(boundary *resource.Quantity, values []resource.Quantity) bool {
+		if boundary == nil {
+			return false
+		}
+		for _, value := range values {
+			if value.Cmp(*boundary) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	var atMin, atMax int
+	for _, pod := range pods {
+		var requests, limits []resource.Quantity
+
+		if limitType == corev1.LimitTypeContainer {
+			for _, container := range pod.Spec.Containers {
+				if q, ok := container.Resources.Requests[name]; ok {
+					requests = append(requests, q)
+				}
+				if q, ok := container.Resources.Limits[name]; ok {
+					limits = append(limits, q)
+				}
+			}
+		} else {
+			var request, limit resource.Quantity
+			for _, container := range pod.Spec.Containers {
+				request.Add(container.Resources.Requests[name])
+				limit.Add(container.Resources.Limits[name])
+			}
+			requests = append(requests, request)
+			limits = append(limits, limit)
+		}
+
+		if atBoundary(min, requests) {
+			atMin++
+		}
+		if atBoundary(max, limits) {
+			atMax++
+		}
+	}
+
+	total := float64(len(pods))
+	return 100 * float64(atMin) / total, 100 * float64(atMax) / total
+}
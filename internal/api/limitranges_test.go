@@ -0,0 +1,109 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_limitRangesHandler(t *testing.T) {
+	newPod := func(name string, phase corev1.PodPhase, requests, limits corev1.ResourceList) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "app", Resources: corev1.ResourceRequirements{Requests: requests, Limits: limits}},
+				},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	cpu := func(value string) corev1.ResourceList {
+		return corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(value)}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Name: "limits", Namespace: "default"},
+			Spec: corev1.LimitRangeSpec{
+				Limits: []corev1.LimitRangeItem{
+					{
+						Type:           corev1.LimitTypeContainer,
+						Min:            cpu("100m"),
+						Max:            cpu("1"),
+						DefaultRequest: cpu("100m"),
+					},
+					{
+						Type: corev1.LimitTypePersistentVolumeClaim,
+						Max:  corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+					},
+				},
+			},
+		},
+		newPod("at-min", corev1.PodRunning, cpu("100m"), cpu("500m")),
+		newPod("at-both", corev1.PodRunning, cpu("100m"), cpu("1")),
+		newPod("between", corev1.PodRunning, cpu("200m"), cpu("500m")),
+		newPod("unbounded", corev1.PodRunning, nil, nil),
+		newPod("pending", corev1.PodPending, cpu("100m"), cpu("1")),
+	)
+
+	handler := newLimitRangesHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/limitranges/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got limitRangesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	quantity := func(value string) *resource.Quantity {
+		q := resource.MustParse(value)
+		return &q
+	}
+
+	expected := limitRangesResponse{
+		RunningPods: 4,
+		LimitRanges: []limitRangeStatus{
+			{
+				Name: "limits",
+				Limits: []limitRangeLimit{
+					{
+						Type:           corev1.LimitTypeContainer,
+						Resource:       "cpu",
+						Min:            quantity("100m"),
+						Max:            quantity("1"),
+						DefaultRequest: quantity("100m"),
+						PercentAtMin:   50,
+						PercentAtMax:   25,
+					},
+					{
+						Type:     corev1.LimitTypePersistentVolumeClaim,
+						Resource: "storage",
+						Max:      quantity("10Gi"),
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, got)
+}
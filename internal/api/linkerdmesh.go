@@ -0,0 +1,144 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	linkerdProxyContainer          = "linkerd-proxy"
+	linkerdProxyInjectorAnnotation = "linkerd.io/proxy-injector"
+	linkerdProxyVersionAnnotation  = "linkerd.io/proxy-version"
+	linkerdSkipInboundAnnotation   = "config.linkerd.io/skip-inbound-ports"
+)
+
+// linkerdServiceProfileGVR is used to detect whether Linkerd's CRDs are installed.
+var linkerdServiceProfileGVR = schema.GroupVersionResource{
+	Group:    "linkerd.io",
+	Version:  "v1alpha2",
+	Resource: "serviceprofiles",
+}
+
+type linkerdPodMeshStatus struct {
+	Pod                string   `json:"pod"`
+	Meshed             bool     `json:"meshed"`
+	InjectorAnnotation bool     `json:"injectorAnnotation"`
+	ProxyVersion       string   `json:"proxyVersion"`
+	SkipInboundPorts   []string `json:"skipInboundPorts"`
+}
+
+type linkerdMeshResponse struct {
+	LinkerdNotInstalled bool                   `json:"linkerdNotInstalled"`
+	Pods                []linkerdPodMeshStatus `json:"pods"`
+}
+
+type linkerdMeshHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*linkerdMeshHandler)(nil)
+
+func newLinkerdMeshHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *linkerdMeshHandler {
+	return &linkerdMeshHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns whether each pod in a
+// namespace is meshed by Linkerd. A pod is meshed when it runs the
+// linkerd-proxy container; the injector annotation is reported separately
+// because a pod can be annotated but not yet restarted with the proxy.
+func (h *linkerdMeshHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	resp := linkerdMeshResponse{
+		Pods: []linkerdPodMeshStatus{},
+	}
+
+	_, installed, err := listOptionalResource(h.dynamicClient, linkerdServiceProfileGVR, namespace, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	if !installed {
+		resp.LinkerdNotInstalled = true
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	for i := range pods.Items {
+		resp.Pods = append(resp.Pods, linkerdPodStatus(&pods.Items[i]))
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func linkerdPodStatus(pod *corev1.Pod) linkerdPodMeshStatus {
+	_, annotated := pod.Annotations[linkerdProxyInjectorAnnotation]
+
+	status := linkerdPodMeshStatus{
+		Pod:                pod.Name,
+		InjectorAnnotation: annotated,
+		SkipInboundPorts:   []string{},
+	}
+
+	for _, container := range pod.Spec.Containers {
+		if container.Name != linkerdProxyContainer {
+			continue
+		}
+
+		status.Meshed = true
+		status.ProxyVersion = pod.Annotations[linkerdProxyVersionAnnotation]
+		if status.ProxyVersion == "" {
+			status.ProxyVersion = imageTag(container.Image)
+		}
+	}
+
+	for _, port := range strings.Split(pod.Annotations[linkerdSkipInboundAnnotation], ",") {
+		if port = strings.TrimSpace(port); port != "" {
+			status.SkipInboundPorts = append(status.SkipInboundPorts, port)
+		}
+	}
+
+	return status
+}
+
+// imageTag returns the tag of an image reference, or an empty string when
+// the image is untagged or pinned by digest.
+func imageTag(image string) string {
+	if strings.Contains(image, "@") {
+		return ""
+	}
+
+	i := strings.LastIndex(image, ":")
+	if i == -1 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+
+	return image[i+1:]
+}
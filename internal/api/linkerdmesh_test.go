@@ -0,0 +1,115 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_linkerdMeshHandler(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "web",
+				Namespace: "default",
+				Annotations: map[string]string{
+					linkerdProxyInjectorAnnotation: "linkerd-proxy-injector-abc",
+					linkerdProxyVersionAnnotation:  "stable-2.6.0",
+					linkerdSkipInboundAnnotation:   "25, 3306",
+				},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "web", Image: "nginx"},
+					{Name: linkerdProxyContainer, Image: "gcr.io/linkerd-io/proxy:stable-2.6.0"},
+				},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "injected", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: linkerdProxyContainer, Image: "gcr.io/linkerd-io/proxy:edge-19.10.1"},
+				},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "restarting",
+				Namespace:   "default",
+				Annotations: map[string]string{linkerdProxyInjectorAnnotation: "linkerd-proxy-injector-abc"},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: "app:1.0"}},
+			},
+		},
+	)
+
+	tests := []struct {
+		name     string
+		missing  bool
+		expected linkerdMeshResponse
+	}{
+		{
+			name: "mesh status",
+			expected: linkerdMeshResponse{
+				Pods: []linkerdPodMeshStatus{
+					{Pod: "web", Meshed: true, InjectorAnnotation: true, ProxyVersion: "stable-2.6.0", SkipInboundPorts: []string{"25", "3306"}},
+					{Pod: "injected", Meshed: true, ProxyVersion: "edge-19.10.1", SkipInboundPorts: []string{}},
+					{Pod: "restarting", InjectorAnnotation: true, SkipInboundPorts: []string{}},
+				},
+			},
+		},
+		{
+			name:    "linkerd not installed",
+			missing: true,
+			expected: linkerdMeshResponse{
+				LinkerdNotInstalled: true,
+				Pods:                []linkerdPodMeshStatus{},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+			if tc.missing {
+				dynamicClient.PrependReactor("list", "serviceprofiles", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+				})
+			}
+
+			handler := newLinkerdMeshHandler(kubeClient, dynamicClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/linkerd/meshstatus/default", nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got linkerdMeshResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
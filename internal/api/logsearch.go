@@ -0,0 +1,379 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/vmware/octant/internal/log"
+	"github.com/vmware/octant/internal/octant"
+)
+
+const (
+	defaultLogSearchLimit = 100
+
+	// maxLogSearchLimit matches Loki's default max_entries_limit_per_query.
+	maxLogSearchLimit = 5000
+
+	// defaultLogSearchWindow is how far back a search without `start` looks.
+	defaultLogSearchWindow = time.Hour
+
+	// lokiPollInterval is how often Loki is queried for new lines while
+	// following.
+	lokiPollInterval = 2 * time.Second
+
+	// lokiTenantHeader selects the tenant in multi-tenant Loki installs.
+	lokiTenantHeader = "X-Scope-OrgID"
+
+	// lokiAuthorizationHeader carries the credentials for Loki. It is sent to
+	// Loki as Authorization, which octant keeps for the Kubernetes token.
+	lokiAuthorizationHeader = "X-Loki-Authorization"
+
+	// lokiNamespaceLabel is the stream label holding a log line's namespace.
+	lokiNamespaceLabel = "namespace"
+
+	logSearchEventType octant.EventType = "logLine"
+)
+
+// lokiForwardedHeaders maps the request headers passed on to Loki, so it can
+// authenticate the user and select their tenant, to the headers Loki reads.
+// The request's own Authorization header is never forwarded.
+var lokiForwardedHeaders = map[string]string{
+	lokiAuthorizationHeader: "Authorization",
+	lokiTenantHeader:        lokiTenantHeader,
+}
+
+// lokiStream is a set of log lines sharing labels. Each value is a pair of a
+// nanosecond timestamp and a line.
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// lokiQueryRangeResponse is the subset of a Loki query_range response used by
+// log search.
+type lokiQueryRangeResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string       `json:"resultType"`
+		Result     []lokiStream `json:"result"`
+	} `json:"data"`
+}
+
+type logSearchEntry struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Stream    map[string]string `json:"stream"`
+	Line      string            `json:"line"`
+}
+
+type logSearchResponse struct {
+	LokiNotConfigured bool             `json:"lokiNotConfigured"`
+	Entries           []logSearchEntry `json:"entries"`
+}
+
+// lokiQuery is a log search sent to Loki.
+type lokiQuery struct {
+	query     string
+	start     time.Time
+	end       time.Time
+	limit     int
+	direction string
+	header    http.Header
+}
+
+type lokiSearchHandler struct {
+	lokiURL      string
+	httpClient   *http.Client
+	nowFn        func() time.Time
+	pollInterval time.Duration
+	logger       log.Logger
+}
+
+var _ http.Handler = (*lokiSearchHandler)(nil)
+
+func newLokiSearchHandler(lokiURL string, logger log.Logger) *lokiSearchHandler {
+	return &lokiSearchHandler{
+		lokiURL:      strings.TrimSuffix(lokiURL, "/"),
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		nowFn:        time.Now,
+		pollInterval: lokiPollInterval,
+		logger:       logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and searches Loki with the LogQL in
+// `query`. `start` and `end` are RFC 3339 times and default to the last hour,
+// and `limit` caps the lines returned, most recent first. The query's stream
+// selector is scoped to the namespace's label, so only lines from the
+// namespace are returned. For multi tenant installs
+// X-Loki-Authorization is sent to Loki as Authorization, and X-Scope-OrgID is
+// forwarded. With `follow=true` matching lines are streamed as server sent
+// events, oldest first, and Loki is polled for new lines until the client
+// disconnects.
+func (h *lokiSearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.lokiURL == "" {
+		resp := logSearchResponse{
+			LokiNotConfigured: true,
+			Entries:           []logSearchEntry{},
+		}
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	namespace := mux.Vars(r)["namespace"]
+
+	search, err := h.parseQuery(r, namespace)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
+	if r.URL.Query().Get("follow") == "true" {
+		h.follow(w, r, namespace, search)
+		return
+	}
+
+	entries, _, err := h.search(r.Context(), namespace, search)
+	if err != nil {
+		RespondWithError(w, http.StatusBadGateway, err.Error(), h.logger)
+		return
+	}
+
+	resp := logSearchResponse{
+		Entries: entries,
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func (h *lokiSearchHandler) parseQuery(r *http.Request, namespace string) (lokiQuery, error) {
+	query := r.URL.Query()
+
+	search := lokiQuery{
+		end:       h.nowFn(),
+		limit:     defaultLogSearchLimit,
+		direction: "backward",
+		header:    http.Header{},
+	}
+
+	if query.Get("query") == "" {
+		return lokiQuery{}, errors.New("query is required")
+	}
+
+	scoped, err := scopeLokiQuery(query.Get("query"), namespace)
+	if err != nil {
+		return lokiQuery{}, err
+	}
+	search.query = scoped
+
+	if s := query.Get("end"); s != "" {
+		end, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return lokiQuery{}, errors.New("end must be an RFC 3339 time")
+		}
+		search.end = end
+	}
+
+	search.start = search.end.Add(-defaultLogSearchWindow)
+	if s := query.Get("start"); s != "" {
+		start, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return lokiQuery{}, errors.New("start must be an RFC 3339 time")
+		}
+		search.start = start
+	}
+
+	if !search.start.Before(search.end) {
+		return lokiQuery{}, errors.New("start must be before end")
+	}
+
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return lokiQuery{}, errors.New("limit must be a positive integer")
+		}
+		if n > maxLogSearchLimit {
+			n = maxLogSearchLimit
+		}
+		search.limit = n
+	}
+
+	for name, lokiName := range lokiForwardedHeaders {
+		if value := r.Header.Get(name); value != "" {
+			search.header.Set(lokiName, value)
+		}
+	}
+
+	return search, nil
+}
+
+// follow streams the lines matching a search, then polls Loki for lines
+// newer than the last one sent.
+func (h *lokiSearchHandler) follow(w http.ResponseWriter, r *http.Request, namespace string, search lokiQuery) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	search.direction = "forward"
+
+	ch := make(chan octant.Event)
+	go h.pollLoki(ctx, cancel, namespace, search, ch)
+
+	streamer := &eventSourceStreamer{w: w}
+	streamer.Stream(ctx, ch)
+}
+
+// pollLoki sends the lines matching a search, then queries Loki every poll
+// interval for lines after the last one sent. It cancels the stream when a
+// query fails.
+func (h *lokiSearchHandler) pollLoki(ctx context.Context, cancel context.CancelFunc, namespace string, search lokiQuery, ch chan<- octant.Event) {
+	defer cancel()
+
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		entries, newest, err := h.search(ctx, namespace, search)
+		if err != nil {
+			if ctx.Err() == nil {
+				h.logger.WithErr(err).Errorf("following loki logs")
+			}
+			return
+		}
+
+		// Entries are returned newest first.
+		for i := len(entries) - 1; i >= 0; i-- {
+			data, err := json.Marshal(entries[i])
+			if err != nil {
+				h.logger.WithErr(err).Errorf("marshal log line")
+				return
+			}
+
+			select {
+			case ch <- octant.Event{Type: logSearchEventType, Data: data}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		// Loki applies the limit before lines are filtered, so the next
+		// search starts after the newest line Loki returned, sent or not.
+		if !newest.IsZero() {
+			search.start = newest.Add(time.Nanosecond)
+		}
+
+		select {
+		case <-ticker.C:
+			search.end = h.nowFn()
+			if !search.start.Before(search.end) {
+				search.end = search.start.Add(time.Nanosecond)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// search runs a search with Loki's query_range API and returns the lines in
+// the namespace, most recent first, and the time of the newest line Loki
+// returned.
+func (h *lokiSearchHandler) search(ctx context.Context, namespace string, search lokiQuery) ([]logSearchEntry, time.Time, error) {
+	query := url.Values{}
+	query.Set("query", search.query)
+	query.Set("start", strconv.FormatInt(search.start.UnixNano(), 10))
+	query.Set("end", strconv.FormatInt(search.end.UnixNano(), 10))
+	query.Set("limit", strconv.Itoa(search.limit))
+	query.Set("direction", search.direction)
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/loki/api/v1/query_range?%s", h.lokiURL, query.Encode()), nil)
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "create loki request")
+	}
+	for name, values := range search.header {
+		req.Header[name] = values
+	}
+
+	res, err := h.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "query loki")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, time.Time{}, errors.Errorf("loki returned %s", res.Status)
+	}
+
+	var found lokiQueryRangeResponse
+	if err := json.NewDecoder(res.Body).Decode(&found); err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "decode loki response")
+	}
+
+	if found.Data.ResultType != "" && found.Data.ResultType != "streams" {
+		return nil, time.Time{}, errors.Errorf("query returned %s, not log lines", found.Data.ResultType)
+	}
+
+	var newest time.Time
+	entries := []logSearchEntry{}
+	for _, stream := range found.Data.Result {
+		for _, value := range stream.Values {
+			nanoseconds, err := strconv.ParseInt(value[0], 10, 64)
+			if err != nil {
+				return nil, time.Time{}, errors.Errorf("loki returned invalid timestamp %q", value[0])
+			}
+
+			timestamp := time.Unix(0, nanoseconds).UTC()
+			if timestamp.After(newest) {
+				newest = timestamp
+			}
+
+			if stream.Stream[lokiNamespaceLabel] != namespace {
+				continue
+			}
+
+			entries = append(entries, logSearchEntry{
+				Timestamp: timestamp,
+				Stream:    stream.Stream,
+				Line:      value[1],
+			})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+	if len(entries) > search.limit {
+		entries = entries[:search.limit]
+	}
+
+	return entries, newest, nil
+}
+
+// scopeLokiQuery adds a matcher for the namespace's label to the first stream
+// selector of a LogQL query. A selector already matching another namespace
+// then matches nothing.
+func scopeLokiQuery(query, namespace string) (string, error) {
+	i := strings.Index(query, "{")
+	if i < 0 {
+		return "", errors.New("query must have a stream selector")
+	}
+
+	matcher := lokiNamespaceLabel + "=" + strconv.Quote(namespace)
+	if !strings.HasPrefix(strings.TrimSpace(query[i+1:]), "}") {
+		matcher += ", "
+	}
+
+	return query[:i+1] + matcher + query[i+1:], nil
+}
@@ -0,0 +1,302 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_lokiSearchHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	nanos := func(d time.Duration) string {
+		return strconv.FormatInt(now.Add(d).UnixNano(), 10)
+	}
+
+	var mu sync.Mutex
+	var queries []*http.Request
+	loki := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r)
+		mu.Unlock()
+
+		assert.Equal(t, "/loki/api/v1/query_range", r.URL.Path)
+		switch r.URL.Query().Get("query") {
+		case `{namespace="default", app="broken"}`:
+			w.WriteHeader(http.StatusBadRequest)
+		case `rate({namespace="default", app="web"}[1m])`:
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"matrix","result":[]}}`)
+		case `{namespace="default", app="foreign"}`:
+			fmt.Fprintf(w, `{"status":"success","data":{"resultType":"streams","result":[
+				{"stream":{"namespace":"other","pod":"web-3"},"values":[["%s","hidden"]]}
+			]}}`, nanos(-30*time.Second))
+		case `{namespace="default", app="web"}`:
+			fmt.Fprintf(w, `{"status":"success","data":{"resultType":"streams","result":[
+				{"stream":{"namespace":"default","pod":"web-1"},"values":[["%s","second"],["%s","first"]]},
+				{"stream":{"namespace":"default","pod":"web-2"},"values":[["%s","third"]]},
+				{"stream":{"namespace":"other","pod":"web-3"},"values":[["%s","hidden"]]}
+			]}}`, nanos(-2*time.Minute), nanos(-3*time.Minute), nanos(-time.Minute), nanos(-30*time.Second))
+		default:
+			t.Errorf("unexpected query %q", r.URL.Query().Get("query"))
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer loki.Close()
+
+	newHandler := func(lokiURL string) *lokiSearchHandler {
+		handler := newLokiSearchHandler(lokiURL, log.NopLogger())
+		handler.nowFn = func() time.Time { return now }
+		handler.pollInterval = 10 * time.Millisecond
+		return handler
+	}
+
+	web1 := map[string]string{"namespace": "default", "pod": "web-1"}
+	web2 := map[string]string{"namespace": "default", "pod": "web-2"}
+
+	tests := []struct {
+		name         string
+		query        string
+		lokiURL      string
+		expectedCode int
+		expected     *logSearchResponse
+	}{
+		{
+			name:         "log lines",
+			query:        `?query={app="web"}`,
+			lokiURL:      loki.URL,
+			expectedCode: http.StatusOK,
+			expected: &logSearchResponse{
+				Entries: []logSearchEntry{
+					{Timestamp: now.Add(-time.Minute), Stream: web2, Line: "third"},
+					{Timestamp: now.Add(-2 * time.Minute), Stream: web1, Line: "second"},
+					{Timestamp: now.Add(-3 * time.Minute), Stream: web1, Line: "first"},
+				},
+			},
+		},
+		{
+			name:         "limit",
+			query:        `?query={app="web"}&limit=1`,
+			lokiURL:      loki.URL,
+			expectedCode: http.StatusOK,
+			expected: &logSearchResponse{
+				Entries: []logSearchEntry{
+					{Timestamp: now.Add(-time.Minute), Stream: web2, Line: "third"},
+				},
+			},
+		},
+		{
+			name:         "loki not configured",
+			query:        `?query={app="web"}`,
+			expectedCode: http.StatusOK,
+			expected:     &logSearchResponse{LokiNotConfigured: true, Entries: []logSearchEntry{}},
+		},
+		{name: "missing query", lokiURL: loki.URL, expectedCode: http.StatusBadRequest},
+		{name: "missing stream selector", query: `?query=web`, lokiURL: loki.URL, expectedCode: http.StatusBadRequest},
+		{name: "invalid start", query: `?query={app="web"}&start=yesterday`, lokiURL: loki.URL, expectedCode: http.StatusBadRequest},
+		{name: "start after end", query: `?query={app="web"}&start=2019-10-01T12:00:00Z&end=2019-10-01T11:00:00Z`, lokiURL: loki.URL, expectedCode: http.StatusBadRequest},
+		{name: "invalid limit", query: `?query={app="web"}&limit=0`, lokiURL: loki.URL, expectedCode: http.StatusBadRequest},
+		{name: "metric query", query: `?query=rate({app="web"}[1m])`, lokiURL: loki.URL, expectedCode: http.StatusBadGateway},
+		{name: "loki error", query: `?query={app="broken"}`, lokiURL: loki.URL, expectedCode: http.StatusBadGateway},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			target := "/api/v1/namespaces/default/logsearch" + strings.Replace(tc.query, `"`, "%22", -1)
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+			w := httptest.NewRecorder()
+
+			newHandler(tc.lokiURL).ServeHTTP(w, req)
+
+			require.Equal(t, tc.expectedCode, w.Code)
+			if tc.expected == nil {
+				return
+			}
+
+			var resp logSearchResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Equal(t, *tc.expected, resp)
+		})
+	}
+
+	t.Run("forwards authentication headers", func(t *testing.T) {
+		mu.Lock()
+		queries = nil
+		mu.Unlock()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/logsearch?query=%7Bapp%3D%22web%22%7D&start=2019-10-01T11:30:00Z", nil)
+		req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+		req.Header.Set("Authorization", "Bearer kubernetes-token")
+		req.Header.Set("X-Loki-Authorization", "Bearer loki-token")
+		req.Header.Set("X-Scope-OrgID", "team-a")
+		req.Header.Set("Cookie", "session=secret")
+		w := httptest.NewRecorder()
+
+		newHandler(loki.URL).ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, queries, 1)
+		assert.Equal(t, "Bearer loki-token", queries[0].Header.Get("Authorization"))
+		assert.Empty(t, queries[0].Header.Get("X-Loki-Authorization"))
+		assert.Equal(t, "team-a", queries[0].Header.Get("X-Scope-OrgID"))
+		assert.Empty(t, queries[0].Header.Get("Cookie"))
+		assert.Equal(t, nanos(-30*time.Minute), queries[0].URL.Query().Get("start"))
+		assert.Equal(t, nanos(0), queries[0].URL.Query().Get("end"))
+		assert.Equal(t, "backward", queries[0].URL.Query().Get("direction"))
+	})
+
+	t.Run("follow", func(t *testing.T) {
+		mu.Lock()
+		queries = nil
+		mu.Unlock()
+
+		router := mux.NewRouter()
+		router.Handle("/namespaces/{namespace}/logsearch", newHandler(loki.URL))
+
+		server := httptest.NewServer(router)
+		defer server.Close()
+
+		res, err := http.Get(server.URL + "/namespaces/default/logsearch?query=%7Bapp%3D%22web%22%7D&follow=true")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+		reader := bufio.NewReader(res.Body)
+		next := func() logSearchEntry {
+			var data string
+			for {
+				line, err := reader.ReadString('\n')
+				require.NoError(t, err)
+				line = strings.TrimSpace(line)
+				if line == "" && data != "" {
+					break
+				}
+				if strings.HasPrefix(line, "event: ") {
+					assert.Equal(t, string(logSearchEventType), strings.TrimPrefix(line, "event: "))
+				}
+				if strings.HasPrefix(line, "data: ") {
+					data = strings.TrimPrefix(line, "data: ")
+				}
+			}
+
+			var got logSearchEntry
+			require.NoError(t, json.Unmarshal([]byte(data), &got))
+			return got
+		}
+
+		assert.Equal(t, "first", next().Line)
+		assert.Equal(t, "second", next().Line)
+		assert.Equal(t, "third", next().Line)
+
+		// The fake returns the same lines for every poll, so the next poll
+		// searches after the newest line returned, including the line from
+		// another namespace which wasn't sent.
+		polled := func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(queries) > 1
+		}
+		for deadline := time.Now().Add(time.Second); !polled() && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.True(t, len(queries) > 1, "loki was not polled")
+		assert.Equal(t, "forward", queries[0].URL.Query().Get("direction"))
+		assert.Equal(t, strconv.FormatInt(now.Add(-30*time.Second).UnixNano()+1, 10), queries[1].URL.Query().Get("start"))
+	})
+
+	t.Run("follow past lines from other namespaces", func(t *testing.T) {
+		mu.Lock()
+		queries = nil
+		mu.Unlock()
+
+		router := mux.NewRouter()
+		router.Handle("/namespaces/{namespace}/logsearch", newHandler(loki.URL))
+
+		server := httptest.NewServer(router)
+		defer server.Close()
+
+		// No line is sent, so the response headers are never flushed.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/namespaces/default/logsearch?query=%7Bapp%3D%22foreign%22%7D&follow=true", nil)
+		require.NoError(t, err)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if res, err := http.DefaultClient.Do(req.WithContext(ctx)); err == nil {
+				_ = res.Body.Close()
+			}
+		}()
+		defer func() {
+			cancel()
+			<-done
+		}()
+
+		polled := func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(queries) > 1
+		}
+		for deadline := time.Now().Add(time.Second); !polled() && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.True(t, len(queries) > 1, "loki was not polled")
+		assert.Equal(t, strconv.FormatInt(now.Add(-30*time.Second).UnixNano()+1, 10), queries[1].URL.Query().Get("start"))
+	})
+}
+
+func Test_scopeLokiQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		isErr    bool
+	}{
+		{name: "selector", query: `{app="web"} |= "error"`, expected: `{namespace="default", app="web"} |= "error"`},
+		{name: "empty selector", query: `{ }`, expected: `{namespace="default" }`},
+		{name: "metric query", query: `rate({app="web"}[1m])`, expected: `rate({namespace="default", app="web"}[1m])`},
+		{name: "other namespace", query: `{namespace="kube-system"}`, expected: `{namespace="default", namespace="kube-system"}`},
+		{name: "no selector", query: `web`, isErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := scopeLokiQuery(tc.query, "default")
+			if tc.isErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -0,0 +1,298 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// istioRootNamespace is the namespace whose policies without a selector
+	// apply to the whole mesh.
+	istioRootNamespace = "istio-system"
+
+	meshPolicyScopeMesh      = "mesh"
+	meshPolicyScopeNamespace = "namespace"
+	meshPolicyScopeWorkload  = "workload"
+
+	mtlsModeUnset      = "UNSET"
+	mtlsModePermissive = "PERMISSIVE"
+	mtlsModeStrict     = "STRICT"
+
+	authorizationActionAllow = "ALLOW"
+	authorizationActionDeny  = "DENY"
+)
+
+var (
+	peerAuthenticationGVR = schema.GroupVersionResource{
+		Group:    "security.istio.io",
+		Version:  "v1",
+		Resource: "peerauthentications",
+	}
+
+	authorizationPolicyGVR = schema.GroupVersionResource{
+		Group:    "security.istio.io",
+		Version:  "v1",
+		Resource: "authorizationpolicies",
+	}
+)
+
+type meshPeerAuthentication struct {
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	Mode      string            `json:"mode"`
+	Selector  map[string]string `json:"selector,omitempty"`
+	Scope     string            `json:"scope"`
+}
+
+type meshAuthorizationPolicy struct {
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	Action    string            `json:"action"`
+	Selector  map[string]string `json:"selector,omitempty"`
+	Scope     string            `json:"scope"`
+	Rules     int               `json:"rules"`
+}
+
+type meshPodPolicies struct {
+	Pod                string   `json:"pod"`
+	MTLSMode           string   `json:"mtlsMode"`
+	StrictMTLS         bool     `json:"strictMTLS"`
+	PeerAuthentication string   `json:"peerAuthentication,omitempty"`
+	AllowPolicies      []string `json:"allowPolicies"`
+	DenyPolicies       []string `json:"denyPolicies"`
+}
+
+type meshPoliciesResponse struct {
+	IstioNotInstalled     bool                      `json:"istioNotInstalled"`
+	PeerAuthentications   []meshPeerAuthentication  `json:"peerAuthentications"`
+	AuthorizationPolicies []meshAuthorizationPolicy `json:"authorizationPolicies"`
+	Pods                  []meshPodPolicies         `json:"pods"`
+}
+
+type meshPoliciesHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*meshPoliciesHandler)(nil)
+
+func newMeshPoliciesHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *meshPoliciesHandler {
+	return &meshPoliciesHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the Istio PeerAuthentications
+// and AuthorizationPolicies which apply to a namespace, including mesh-wide
+// policies in the root namespace, and the effective mTLS mode and
+// authorization policies of each pod. A workload policy overrides the
+// namespace policy, which overrides the mesh policy, and an UNSET mode
+// inherits from the next level. Pods without any mode are PERMISSIVE.
+func (h *meshPoliciesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	resp := meshPoliciesResponse{
+		PeerAuthentications:   []meshPeerAuthentication{},
+		AuthorizationPolicies: []meshAuthorizationPolicy{},
+		Pods:                  []meshPodPolicies{},
+	}
+
+	peerAuthentications, installed, err := h.listPolicies(peerAuthenticationGVR, namespace)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	if !installed {
+		resp.IstioNotInstalled = true
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	authorizationPolicies, _, err := h.listPolicies(authorizationPolicyGVR, namespace)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	for _, object := range peerAuthentications {
+		mode, _, _ := unstructured.NestedString(object.Object, "spec", "mtls", "mode")
+		if mode == "" {
+			mode = mtlsModeUnset
+		}
+
+		resp.PeerAuthentications = append(resp.PeerAuthentications, meshPeerAuthentication{
+			Namespace: object.GetNamespace(),
+			Name:      object.GetName(),
+			Mode:      mode,
+			Selector:  meshPolicySelector(object),
+			Scope:     meshPolicyScope(object),
+		})
+	}
+
+	for _, object := range authorizationPolicies {
+		action, _, _ := unstructured.NestedString(object.Object, "spec", "action")
+		if action == "" {
+			action = authorizationActionAllow
+		}
+
+		rules, _, _ := unstructured.NestedSlice(object.Object, "spec", "rules")
+
+		resp.AuthorizationPolicies = append(resp.AuthorizationPolicies, meshAuthorizationPolicy{
+			Namespace: object.GetNamespace(),
+			Name:      object.GetName(),
+			Action:    action,
+			Selector:  meshPolicySelector(object),
+			Scope:     meshPolicyScope(object),
+			Rules:     len(rules),
+		})
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		resp.Pods = append(resp.Pods, podMeshPolicies(pod, resp.PeerAuthentications, resp.AuthorizationPolicies))
+	}
+
+	sort.Slice(resp.Pods, func(i, j int) bool {
+		return resp.Pods[i].Pod < resp.Pods[j].Pod
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// listPolicies lists the policies of a kind in a namespace and the root
+// namespace, sorted by namespace and name. Only the root namespace policies
+// without a selector apply outside the root namespace.
+func (h *meshPoliciesHandler) listPolicies(gvr schema.GroupVersionResource, namespace string) ([]unstructured.Unstructured, bool, error) {
+	list, installed, err := listOptionalResource(h.dynamicClient, gvr, namespace, metav1.ListOptions{})
+	if err != nil || !installed {
+		return nil, installed, err
+	}
+
+	policies := list.Items
+
+	if namespace != istioRootNamespace {
+		root, _, err := listOptionalResource(h.dynamicClient, gvr, istioRootNamespace, metav1.ListOptions{})
+		if err != nil {
+			return nil, false, err
+		}
+
+		for _, policy := range root.Items {
+			if meshPolicySelector(policy) == nil {
+				policies = append(policies, policy)
+			}
+		}
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].GetNamespace() != policies[j].GetNamespace() {
+			return policies[i].GetNamespace() < policies[j].GetNamespace()
+		}
+		return policies[i].GetName() < policies[j].GetName()
+	})
+
+	return policies, true, nil
+}
+
+func meshPolicySelector(object unstructured.Unstructured) map[string]string {
+	selector, _, _ := unstructured.NestedStringMap(object.Object, "spec", "selector", "matchLabels")
+	if len(selector) == 0 {
+		return nil
+	}
+	return selector
+}
+
+func meshPolicyScope(object unstructured.Unstructured) string {
+	switch {
+	case meshPolicySelector(object) != nil:
+		return meshPolicyScopeWorkload
+	case object.GetNamespace() == istioRootNamespace:
+		return meshPolicyScopeMesh
+	default:
+		return meshPolicyScopeNamespace
+	}
+}
+
+// meshPolicyApplies reports whether a policy in scope of a pod's namespace
+// selects the pod.
+func meshPolicyApplies(selector map[string]string, pod corev1.Pod) bool {
+	return selector == nil || labels.SelectorFromSet(selector).Matches(labels.Set(pod.Labels))
+}
+
+// podMeshPolicies returns a pod's effective mTLS mode and the authorization
+// policies which select it.
+func podMeshPolicies(pod corev1.Pod, peerAuthentications []meshPeerAuthentication, authorizationPolicies []meshAuthorizationPolicy) meshPodPolicies {
+	status := meshPodPolicies{
+		Pod:           pod.Name,
+		MTLSMode:      mtlsModePermissive,
+		AllowPolicies: []string{},
+		DenyPolicies:  []string{},
+	}
+
+	if mode, name, ok := effectiveMTLSMode(pod, peerAuthentications); ok {
+		status.MTLSMode = mode
+		status.PeerAuthentication = name
+	}
+	status.StrictMTLS = status.MTLSMode == mtlsModeStrict
+
+	for _, policy := range authorizationPolicies {
+		if !meshPolicyApplies(policy.Selector, pod) {
+			continue
+		}
+
+		name := policy.Namespace + "/" + policy.Name
+		switch policy.Action {
+		case authorizationActionAllow:
+			status.AllowPolicies = append(status.AllowPolicies, name)
+		case authorizationActionDeny:
+			status.DenyPolicies = append(status.DenyPolicies, name)
+		}
+	}
+
+	return status
+}
+
+// effectiveMTLSMode returns the mode of the most specific PeerAuthentication
+// which selects a pod and sets a mode.
+func effectiveMTLSMode(pod corev1.Pod, peerAuthentications []meshPeerAuthentication) (string, string, bool) {
+	for _, scope := range []string{meshPolicyScopeWorkload, meshPolicyScopeNamespace, meshPolicyScopeMesh} {
+		for _, peerAuthentication := range peerAuthentications {
+			if peerAuthentication.Scope != scope || peerAuthentication.Mode == mtlsModeUnset ||
+				!meshPolicyApplies(peerAuthentication.Selector, pod) {
+				continue
+			}
+
+			return peerAuthentication.Mode, peerAuthentication.Namespace + "/" + peerAuthentication.Name, true
+		}
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,148 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_meshPoliciesHandler(t *testing.T) {
+	policy := func(kind, namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "security.istio.io/v1",
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+			"spec":       spec,
+		}}
+	}
+
+	selector := func(app string) map[string]interface{} {
+		return map[string]interface{}{"matchLabels": map[string]interface{}{"app": app}}
+	}
+
+	pod := func(name, app string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": app}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	objects := []runtime.Object{
+		policy("PeerAuthentication", "istio-system", "mesh", map[string]interface{}{
+			"mtls": map[string]interface{}{"mode": "PERMISSIVE"},
+		}),
+		policy("PeerAuthentication", "istio-system", "gateway", map[string]interface{}{
+			"selector": selector("gateway"),
+			"mtls":     map[string]interface{}{"mode": "DISABLE"},
+		}),
+		policy("PeerAuthentication", "default", "strict", map[string]interface{}{
+			"mtls": map[string]interface{}{"mode": "STRICT"},
+		}),
+		policy("PeerAuthentication", "default", "legacy", map[string]interface{}{
+			"selector": selector("legacy"),
+			"mtls":     map[string]interface{}{"mode": "PERMISSIVE"},
+		}),
+		policy("PeerAuthentication", "default", "inherit", map[string]interface{}{
+			"selector": selector("web"),
+		}),
+		policy("AuthorizationPolicy", "default", "allow-web", map[string]interface{}{
+			"selector": selector("web"),
+			"rules":    []interface{}{map[string]interface{}{}},
+		}),
+		policy("AuthorizationPolicy", "default", "deny-all", map[string]interface{}{
+			"action": "DENY",
+			"rules":  []interface{}{map[string]interface{}{}, map[string]interface{}{}},
+		}),
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(pod("web", "web"), pod("legacy", "legacy"))
+
+	t.Run("istio installed", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+
+		got := serveMeshPolicies(t, newMeshPoliciesHandler(kubeClient, dynamicClient, log.NopLogger()))
+
+		assert.False(t, got.IstioNotInstalled)
+
+		expectedPeerAuthentications := []meshPeerAuthentication{
+			{Namespace: "default", Name: "inherit", Mode: "UNSET", Selector: map[string]string{"app": "web"}, Scope: "workload"},
+			{Namespace: "default", Name: "legacy", Mode: "PERMISSIVE", Selector: map[string]string{"app": "legacy"}, Scope: "workload"},
+			{Namespace: "default", Name: "strict", Mode: "STRICT", Scope: "namespace"},
+			{Namespace: "istio-system", Name: "mesh", Mode: "PERMISSIVE", Scope: "mesh"},
+		}
+		assert.Equal(t, expectedPeerAuthentications, got.PeerAuthentications)
+
+		expectedAuthorizationPolicies := []meshAuthorizationPolicy{
+			{Namespace: "default", Name: "allow-web", Action: "ALLOW", Selector: map[string]string{"app": "web"}, Scope: "workload", Rules: 1},
+			{Namespace: "default", Name: "deny-all", Action: "DENY", Scope: "namespace", Rules: 2},
+		}
+		assert.Equal(t, expectedAuthorizationPolicies, got.AuthorizationPolicies)
+
+		expectedPods := []meshPodPolicies{
+			{
+				Pod:                "legacy",
+				MTLSMode:           "PERMISSIVE",
+				PeerAuthentication: "default/legacy",
+				AllowPolicies:      []string{},
+				DenyPolicies:       []string{"default/deny-all"},
+			},
+			{
+				Pod:                "web",
+				MTLSMode:           "STRICT",
+				StrictMTLS:         true,
+				PeerAuthentication: "default/strict",
+				AllowPolicies:      []string{"default/allow-web"},
+				DenyPolicies:       []string{"default/deny-all"},
+			},
+		}
+		assert.Equal(t, expectedPods, got.Pods)
+	})
+
+	t.Run("istio not installed", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		dynamicClient.PrependReactor("list", "peerauthentications", func(action clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+		})
+
+		got := serveMeshPolicies(t, newMeshPoliciesHandler(kubeClient, dynamicClient, log.NopLogger()))
+
+		assert.True(t, got.IstioNotInstalled)
+		assert.Empty(t, got.PeerAuthentications)
+		assert.Empty(t, got.AuthorizationPolicies)
+		assert.Empty(t, got.Pods)
+	})
+}
+
+func serveMeshPolicies(t *testing.T, handler *meshPoliciesHandler) meshPoliciesResponse {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/servicemeshpolicies", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var got meshPoliciesResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+
+	return got
+}
@@ -0,0 +1,176 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type multiContainerPodContainer struct {
+	Name    string `json:"name"`
+	Image   string `json:"image"`
+	State   string `json:"state"`
+	Primary bool   `json:"primary"`
+}
+
+// sharedMount is a volume the primary container and a sidecar mount at the
+// same path.
+type sharedMount struct {
+	Volume     string   `json:"volume"`
+	MountPath  string   `json:"mountPath"`
+	Containers []string `json:"containers"`
+}
+
+type multiContainerPod struct {
+	Pod                string                       `json:"pod"`
+	Containers         []multiContainerPodContainer `json:"containers"`
+	SharedVolumes      []string                     `json:"sharedVolumes"`
+	InitContainerCount int                          `json:"initContainerCount"`
+	SamePathMounts     []sharedMount                `json:"samePathMounts"`
+	Flagged            bool                         `json:"flagged"`
+}
+
+type multiContainerPodsResponse struct {
+	Pods []multiContainerPod `json:"pods"`
+}
+
+type multiContainerPodsHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*multiContainerPodsHandler)(nil)
+
+func newMultiContainerPodsHandler(kubeClient kubernetes.Interface, logger log.Logger) *multiContainerPodsHandler {
+	return &multiContainerPodsHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the pods in a namespace with
+// more than one app container. Init containers are counted separately and
+// don't make a pod multi-container. Shared volumes are those mounted by more
+// than one app container. Pods whose primary container and a sidecar mount a
+// volume at the same path are flagged.
+func (h *multiContainerPodsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := multiContainerPodsResponse{
+		Pods: []multiContainerPod{},
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if len(pod.Spec.Containers) < 2 {
+			continue
+		}
+		resp.Pods = append(resp.Pods, describeMultiContainerPod(pod))
+	}
+
+	sort.Slice(resp.Pods, func(i, j int) bool {
+		return resp.Pods[i].Pod < resp.Pods[j].Pod
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func describeMultiContainerPod(pod *corev1.Pod) multiContainerPod {
+	primary := primaryContainer(pod)
+
+	statuses := make(map[string]corev1.ContainerStatus)
+	for _, status := range pod.Status.ContainerStatuses {
+		statuses[status.Name] = status
+	}
+
+	described := multiContainerPod{
+		Pod:                pod.Name,
+		Containers:         []multiContainerPodContainer{},
+		InitContainerCount: len(pod.Spec.InitContainers),
+		SamePathMounts:     []sharedMount{},
+	}
+
+	mountedBy := make(map[string]sets.String)
+	primaryMounts := make(map[corev1.VolumeMount]bool)
+
+	for _, container := range pod.Spec.Containers {
+		described.Containers = append(described.Containers, multiContainerPodContainer{
+			Name:    container.Name,
+			Image:   container.Image,
+			State:   sidecarContainerState(container.Name, sidecarContainerTypeSidecar, statuses[container.Name]).State,
+			Primary: container.Name == primary,
+		})
+
+		for _, mount := range container.VolumeMounts {
+			if mountedBy[mount.Name] == nil {
+				mountedBy[mount.Name] = sets.NewString()
+			}
+			mountedBy[mount.Name].Insert(container.Name)
+
+			if container.Name == primary {
+				primaryMounts[corev1.VolumeMount{Name: mount.Name, MountPath: mount.MountPath}] = true
+			}
+		}
+	}
+
+	shared := sets.NewString()
+	for volume, containers := range mountedBy {
+		if containers.Len() > 1 {
+			shared.Insert(volume)
+		}
+	}
+	described.SharedVolumes = shared.List()
+
+	samePath := make(map[corev1.VolumeMount]sets.String)
+	for _, container := range pod.Spec.Containers {
+		if container.Name == primary {
+			continue
+		}
+		for _, mount := range container.VolumeMounts {
+			key := corev1.VolumeMount{Name: mount.Name, MountPath: mount.MountPath}
+			if !primaryMounts[key] {
+				continue
+			}
+			if samePath[key] == nil {
+				samePath[key] = sets.NewString(primary)
+			}
+			samePath[key].Insert(container.Name)
+		}
+	}
+
+	for key, containers := range samePath {
+		described.SamePathMounts = append(described.SamePathMounts, sharedMount{
+			Volume:     key.Name,
+			MountPath:  key.MountPath,
+			Containers: containers.List(),
+		})
+	}
+	sort.Slice(described.SamePathMounts, func(i, j int) bool {
+		if described.SamePathMounts[i].Volume != described.SamePathMounts[j].Volume {
+			return described.SamePathMounts[i].Volume < described.SamePathMounts[j].Volume
+		}
+		return described.SamePathMounts[i].MountPath < described.SamePathMounts[j].MountPath
+	})
+
+	described.Flagged = len(described.SamePathMounts) > 0
+
+	return described
+}
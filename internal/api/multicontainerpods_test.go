@@ -0,0 +1,103 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_multiContainerPodsHandler(t *testing.T) {
+	mount := func(volume, path string) corev1.VolumeMount {
+		return corev1.VolumeMount{Name: volume, MountPath: path}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "migrate", Image: "web:1"}},
+				Containers: []corev1.Container{
+					{Name: "istio-proxy", Image: "istio/proxyv2", VolumeMounts: []corev1.VolumeMount{mount("certs", "/etc/certs")}},
+					{Name: "web", Image: "web:1", VolumeMounts: []corev1.VolumeMount{mount("data", "/data"), mount("certs", "/etc/certs"), mount("logs", "/var/log")}},
+					{Name: "log-shipper", Image: "fluent-bit", VolumeMounts: []corev1.VolumeMount{mount("logs", "/logs")}},
+				},
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "istio-proxy", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+					{Name: "web", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: crashLoopBackOffReason}}},
+				},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "api", Image: "api:1"},
+					{Name: "cache", Image: "redis"},
+				},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "single", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init", Image: "busybox"}},
+				Containers:     []corev1.Container{{Name: "app", Image: "app:1"}},
+			},
+		},
+	)
+
+	handler := newMultiContainerPodsHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/multicontainer-pods", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got multiContainerPodsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := []multiContainerPod{
+		{
+			Pod: "api",
+			Containers: []multiContainerPodContainer{
+				{Name: "api", Image: "api:1", State: "unknown", Primary: true},
+				{Name: "cache", Image: "redis", State: "unknown"},
+			},
+			SharedVolumes:  []string{},
+			SamePathMounts: []sharedMount{},
+		},
+		{
+			Pod: "web",
+			Containers: []multiContainerPodContainer{
+				{Name: "istio-proxy", Image: "istio/proxyv2", State: "running"},
+				{Name: "web", Image: "web:1", State: "waiting", Primary: true},
+				{Name: "log-shipper", Image: "fluent-bit", State: "unknown"},
+			},
+			SharedVolumes:      []string{"certs", "logs"},
+			InitContainerCount: 1,
+			SamePathMounts: []sharedMount{
+				{Volume: "certs", MountPath: "/etc/certs", Containers: []string{"istio-proxy", "web"}},
+			},
+			Flagged: true,
+		},
+	}
+	assert.Equal(t, expected, got.Pods)
+}
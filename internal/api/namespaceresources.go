@@ -0,0 +1,191 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	namespaceResourcesCacheTTL = 60 * time.Second
+
+	// defaultResourceListConcurrency is the default number of concurrent list
+	// calls made when counting resources.
+	defaultResourceListConcurrency = 10
+
+	resourceListPageSize = 500
+)
+
+type namespaceResourceCount struct {
+	Resource   string `json:"resource"`
+	APIVersion string `json:"apiVersion"`
+	Count      int    `json:"count"`
+}
+
+type namespaceResourcesResponse struct {
+	Resources []namespaceResourceCount `json:"resources"`
+}
+
+type namespaceResourcesHandler struct {
+	kubeClient     kubernetes.Interface
+	dynamicClient  dynamic.Interface
+	maxConcurrency int
+	cache          *ttlCache
+	logger         log.Logger
+}
+
+var _ http.Handler = (*namespaceResourcesHandler)(nil)
+
+func newNamespaceResourcesHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, maxConcurrency int, logger log.Logger) *namespaceResourcesHandler {
+	if maxConcurrency < 1 {
+		maxConcurrency = defaultResourceListConcurrency
+	}
+
+	return &namespaceResourcesHandler{
+		kubeClient:     kubeClient,
+		dynamicClient:  dynamicClient,
+		maxConcurrency: maxConcurrency,
+		cache:          newTTLCache(namespaceResourcesCacheTTL),
+		logger:         logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the number of objects of each
+// namespaced resource type in a namespace, sorted by count. Results are cached
+// per namespace because counting lists every resource type.
+func (h *namespaceResourcesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	if cached, ok := h.cache.get(namespace); ok {
+		resp := cached.(namespaceResourcesResponse)
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	gvrs, err := h.listableResources()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := namespaceResourcesResponse{
+		Resources: h.countResources(namespace, gvrs),
+	}
+
+	h.cache.set(namespace, resp)
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// listableResources returns the preferred version of each namespaced resource
+// which supports list. Groups which fail discovery are skipped.
+func (h *namespaceResourcesHandler) listableResources() ([]schema.GroupVersionResource, error) {
+	resourceLists, err := discovery.ServerPreferredNamespacedResources(h.kubeClient.Discovery())
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, errors.Wrap(err, "discover namespaced resources")
+	}
+
+	var gvrs []schema.GroupVersionResource
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+
+		for _, resource := range resourceList.APIResources {
+			if !sets.NewString(resource.Verbs...).Has("list") {
+				continue
+			}
+			gvrs = append(gvrs, gv.WithResource(resource.Name))
+		}
+	}
+
+	return gvrs, nil
+}
+
+// countResources counts the objects of each resource, running at most
+// maxConcurrency list calls at once. Resources which can't be listed, for
+// example because of RBAC, are left out.
+func (h *namespaceResourcesHandler) countResources(namespace string, gvrs []schema.GroupVersionResource) []namespaceResourceCount {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		counts = []namespaceResourceCount{}
+		sem    = make(chan struct{}, h.maxConcurrency)
+	)
+
+	for _, gvr := range gvrs {
+		wg.Add(1)
+		go func(gvr schema.GroupVersionResource) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			count, err := h.countResource(namespace, gvr)
+			if err != nil {
+				h.logger.WithErr(err).Debugf("count %s", gvr.String())
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			counts = append(counts, namespaceResourceCount{
+				Resource:   gvr.Resource,
+				APIVersion: gvr.GroupVersion().String(),
+				Count:      count,
+			})
+		}(gvr)
+	}
+
+	wg.Wait()
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		if counts[i].Resource != counts[j].Resource {
+			return counts[i].Resource < counts[j].Resource
+		}
+		return counts[i].APIVersion < counts[j].APIVersion
+	})
+
+	return counts
+}
+
+func (h *namespaceResourcesHandler) countResource(namespace string, gvr schema.GroupVersionResource) (int, error) {
+	client := h.dynamicClient.Resource(gvr).Namespace(namespace)
+
+	count := 0
+	options := metav1.ListOptions{Limit: resourceListPageSize}
+	for {
+		list, err := client.List(options)
+		if err != nil {
+			return 0, err
+		}
+
+		count += len(list.Items)
+
+		options.Continue = list.GetContinue()
+		if options.Continue == "" {
+			return count, nil
+		}
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_namespaceResourcesHandler(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "configmaps", Namespaced: true, Verbs: []string{"list"}},
+				{Name: "pods", Namespaced: true, Verbs: []string{"list"}},
+				{Name: "nodes", Namespaced: false, Verbs: []string{"list"}},
+				{Name: "bindings", Namespaced: true, Verbs: []string{"create"}},
+			},
+		},
+	}
+
+	newObject := func(kind, name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		}}
+	}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newObject("ConfigMap", "a"),
+		newObject("Pod", "a"),
+		newObject("Pod", "b"),
+	)
+
+	handler := newNamespaceResourcesHandler(kubeClient, dynamicClient, 1, log.NopLogger())
+
+	serve := func() namespaceResourcesResponse {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/resources", nil)
+		req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var got namespaceResourcesResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		return got
+	}
+
+	expected := []namespaceResourceCount{
+		{Resource: "pods", APIVersion: "v1", Count: 2},
+		{Resource: "configmaps", APIVersion: "v1", Count: 1},
+	}
+	assert.Equal(t, expected, serve().Resources)
+
+	listCalls := len(dynamicClient.Actions())
+	assert.Equal(t, expected, serve().Resources)
+	assert.Equal(t, listCalls, len(dynamicClient.Actions()), "expected cached response")
+}
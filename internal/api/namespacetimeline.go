@@ -0,0 +1,227 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	namespaceTimelineWindow = time.Hour
+
+	timelineSourceAudit  = "audit"
+	timelineSourceEvents = "events"
+
+	// maxAuditLogLineSize is the largest audit log entry which will be read.
+	maxAuditLogLineSize = 1 << 20
+)
+
+// eventReasonVerbs maps event reasons to the change they record.
+var eventReasonVerbs = map[string]string{
+	"Created": "create",
+	"Updated": "update",
+	"Deleted": "delete",
+}
+
+// auditEvent is the subset of an audit.k8s.io/v1 Event used to build
+// timelines and webhook histories.
+type auditEvent struct {
+	Stage string `json:"stage"`
+	Verb  string `json:"verb"`
+	User  struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	ObjectRef *struct {
+		Resource    string `json:"resource"`
+		Namespace   string `json:"namespace"`
+		Name        string `json:"name"`
+		APIGroup    string `json:"apiGroup"`
+		APIVersion  string `json:"apiVersion"`
+		Subresource string `json:"subresource"`
+	} `json:"objectRef"`
+	ResponseStatus *struct {
+		Code    int32  `json:"code"`
+		Message string `json:"message"`
+	} `json:"responseStatus"`
+	Annotations    map[string]string `json:"annotations"`
+	StageTimestamp metav1.MicroTime  `json:"stageTimestamp"`
+}
+
+type namespaceTimelineEntry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Verb        string    `json:"verb"`
+	Resource    string    `json:"resource"`
+	Name        string    `json:"name"`
+	InitiatedBy string    `json:"initiatedBy"`
+}
+
+type namespaceTimelineResponse struct {
+	Source  string                   `json:"source"`
+	Entries []namespaceTimelineEntry `json:"entries"`
+}
+
+type namespaceTimelineHandler struct {
+	kubeClient   kubernetes.Interface
+	auditLogPath string
+	nowFn        func() time.Time
+	logger       log.Logger
+}
+
+var _ http.Handler = (*namespaceTimelineHandler)(nil)
+
+func newNamespaceTimelineHandler(kubeClient kubernetes.Interface, auditLogPath string, logger log.Logger) *namespaceTimelineHandler {
+	return &namespaceTimelineHandler{
+		kubeClient:   kubeClient,
+		auditLogPath: auditLogPath,
+		nowFn:        time.Now,
+		logger:       logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the resources created, updated
+// and deleted in a namespace over the past hour. Changes are read from the
+// audit log when one is configured, and otherwise reconstructed from events.
+func (h *namespaceTimelineHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	since := h.nowFn().Add(-namespaceTimelineWindow)
+
+	resp := namespaceTimelineResponse{
+		Entries: []namespaceTimelineEntry{},
+	}
+
+	var entries []namespaceTimelineEntry
+	var err error
+
+	if h.auditLogPath != "" {
+		resp.Source = timelineSourceAudit
+		entries, err = auditLogTimeline(h.auditLogPath, namespace, since)
+	} else {
+		resp.Source = timelineSourceEvents
+		entries, err = h.eventTimeline(namespace, since)
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	resp.Entries = append(resp.Entries, entries...)
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func (h *namespaceTimelineHandler) eventTimeline(namespace string, since time.Time) ([]namespaceTimelineEntry, error) {
+	events, err := h.kubeClient.CoreV1().Events(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []namespaceTimelineEntry
+	for _, event := range events.Items {
+		verb, ok := eventReasonVerbs[event.Reason]
+		if !ok {
+			continue
+		}
+
+		timestamp := eventTimestamp(event)
+		if timestamp.Before(since) {
+			continue
+		}
+
+		entries = append(entries, namespaceTimelineEntry{
+			Timestamp:   timestamp,
+			Verb:        verb,
+			Resource:    event.InvolvedObject.Kind,
+			Name:        event.InvolvedObject.Name,
+			InitiatedBy: event.Source.Component,
+		})
+	}
+
+	return entries, nil
+}
+
+func eventTimestamp(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+// auditLogTimeline reads changes to resources in a namespace from a JSON lines
+// audit log. Only completed create, update, patch and delete requests for
+// resources, rather than subresources, are included.
+func auditLogTimeline(path, namespace string, since time.Time) ([]namespaceTimelineEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open audit log")
+	}
+	defer f.Close()
+
+	var entries []namespaceTimelineEntry
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxAuditLogLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var event auditEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			// Skip entries which can't be parsed rather than failing on a
+			// partially written log.
+			continue
+		}
+
+		if event.Stage != "ResponseComplete" || event.ObjectRef == nil || event.ObjectRef.Subresource != "" {
+			continue
+		}
+		if event.ObjectRef.Namespace != namespace || event.StageTimestamp.Time.Before(since) {
+			continue
+		}
+
+		switch event.Verb {
+		case "create", "update", "patch", "delete":
+		default:
+			continue
+		}
+
+		entries = append(entries, namespaceTimelineEntry{
+			Timestamp:   event.StageTimestamp.Time,
+			Verb:        event.Verb,
+			Resource:    event.ObjectRef.Resource,
+			Name:        event.ObjectRef.Name,
+			InitiatedBy: event.User.Username,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read audit log")
+	}
+
+	return entries, nil
+}
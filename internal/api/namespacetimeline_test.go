@@ -0,0 +1,139 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_namespaceTimelineHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	dir, err := ioutil.TempDir("", "timeline")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	auditLog := filepath.Join(dir, "audit.log")
+	lines := []string{
+		`{"stage":"ResponseComplete","verb":"create","user":{"username":"alice"},"objectRef":{"resource":"deployments","namespace":"default","name":"web"},"stageTimestamp":"2019-10-01T11:30:00.000000Z"}`,
+		`{"stage":"RequestReceived","verb":"create","user":{"username":"alice"},"objectRef":{"resource":"deployments","namespace":"default","name":"web"},"stageTimestamp":"2019-10-01T11:30:00.000000Z"}`,
+		`{"stage":"ResponseComplete","verb":"patch","user":{"username":"system:serviceaccount:ci:deployer"},"objectRef":{"resource":"deployments","namespace":"default","name":"web"},"stageTimestamp":"2019-10-01T11:20:00.000000Z"}`,
+		`{"stage":"ResponseComplete","verb":"get","user":{"username":"alice"},"objectRef":{"resource":"deployments","namespace":"default","name":"web"},"stageTimestamp":"2019-10-01T11:40:00.000000Z"}`,
+		`{"stage":"ResponseComplete","verb":"update","user":{"username":"kubelet"},"objectRef":{"resource":"pods","namespace":"default","name":"web-1","subresource":"status"},"stageTimestamp":"2019-10-01T11:40:00.000000Z"}`,
+		`{"stage":"ResponseComplete","verb":"delete","user":{"username":"alice"},"objectRef":{"resource":"configmaps","namespace":"other","name":"config"},"stageTimestamp":"2019-10-01T11:40:00.000000Z"}`,
+		`{"stage":"ResponseComplete","verb":"delete","user":{"username":"alice"},"objectRef":{"resource":"configmaps","namespace":"default","name":"old"},"stageTimestamp":"2019-10-01T10:00:00.000000Z"}`,
+		`not json`,
+	}
+	require.NoError(t, ioutil.WriteFile(auditLog, []byte(strings.Join(lines, "\n")), 0600))
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "created", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-1"},
+			Reason:         "Created",
+			Source:         corev1.EventSource{Component: "kubelet"},
+			LastTimestamp:  metav1.NewTime(now.Add(-10 * time.Minute)),
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "pulled", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-1"},
+			Reason:         "Pulled",
+			LastTimestamp:  metav1.NewTime(now.Add(-10 * time.Minute)),
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "deleted", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-0"},
+			Reason:         "Deleted",
+			Source:         corev1.EventSource{Component: "controllermanager"},
+			LastTimestamp:  metav1.NewTime(now.Add(-20 * time.Minute)),
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "stale", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-old"},
+			Reason:         "Created",
+			LastTimestamp:  metav1.NewTime(now.Add(-2 * time.Hour)),
+		},
+	)
+
+	tests := []struct {
+		name         string
+		auditLogPath string
+		expected     namespaceTimelineResponse
+		expectedCode int
+	}{
+		{
+			name:         "audit log",
+			auditLogPath: auditLog,
+			expectedCode: http.StatusOK,
+			expected: namespaceTimelineResponse{
+				Source: timelineSourceAudit,
+				Entries: []namespaceTimelineEntry{
+					{Timestamp: now.Add(-40 * time.Minute), Verb: "patch", Resource: "deployments", Name: "web", InitiatedBy: "system:serviceaccount:ci:deployer"},
+					{Timestamp: now.Add(-30 * time.Minute), Verb: "create", Resource: "deployments", Name: "web", InitiatedBy: "alice"},
+				},
+			},
+		},
+		{
+			name:         "events",
+			expectedCode: http.StatusOK,
+			expected: namespaceTimelineResponse{
+				Source: timelineSourceEvents,
+				Entries: []namespaceTimelineEntry{
+					{Timestamp: now.Add(-20 * time.Minute), Verb: "delete", Resource: "Pod", Name: "web-0", InitiatedBy: "controllermanager"},
+					{Timestamp: now.Add(-10 * time.Minute), Verb: "create", Resource: "Pod", Name: "web-1", InitiatedBy: "kubelet"},
+				},
+			},
+		},
+		{
+			name:         "missing audit log",
+			auditLogPath: filepath.Join(dir, "missing.log"),
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newNamespaceTimelineHandler(kubeClient, tc.auditLogPath, log.NopLogger())
+			handler.nowFn = func() time.Time { return now }
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/timeline", nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var got namespaceTimelineResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			require.Len(t, got.Entries, len(tc.expected.Entries))
+			for i := range got.Entries {
+				assert.True(t, tc.expected.Entries[i].Timestamp.Equal(got.Entries[i].Timestamp))
+				got.Entries[i].Timestamp = tc.expected.Entries[i].Timestamp
+			}
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -0,0 +1,503 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// clusterDomain is the DNS domain service records are looked up in.
+	clusterDomain = "cluster.local"
+
+	// dnsLookupTimeout bounds the lookup of a service's DNS record.
+	dnsLookupTimeout = 5 * time.Second
+
+	// getentNotFoundExitStatus is the exit status of getent when a key is
+	// not found.
+	getentNotFoundExitStatus = 2
+)
+
+// errHostNotFound is returned by a podHostLookup when the host has no
+// records. Other errors mean the lookup could not run.
+var errHostNotFound = errors.New("no such host")
+
+// podHostLookup resolves a host name to its addresses from inside a pod.
+type podHostLookup func(ctx context.Context, pod *corev1.Pod, host string) ([]string, error)
+
+type networkDiagnosticsResponse struct {
+	NetworkPolicyAllows bool `json:"networkPolicyAllows"`
+	EndpointsReady      bool `json:"endpointsReady"`
+	// DNSResolvesCorrectly is null when the service's DNS name could not be
+	// looked up from the pod.
+	DNSResolvesCorrectly *bool  `json:"dnsResolvesCorrectly"`
+	Summary              string `json:"summary"`
+}
+
+type networkDiagnosticsHandler struct {
+	kubeClient kubernetes.Interface
+	lookupHost podHostLookup
+	logger     log.Logger
+}
+
+var _ http.Handler = (*networkDiagnosticsHandler)(nil)
+
+func newNetworkDiagnosticsHandler(kubeClient kubernetes.Interface, restConfig *rest.Config, logger log.Logger) *networkDiagnosticsHandler {
+	return &networkDiagnosticsHandler{
+		kubeClient: kubeClient,
+		lookupHost: newPodExecHostLookup(newSPDYPodExecutorFactory(kubeClient, restConfig)),
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and checks whether the pod given by
+// `fromPod` can reach a service in the same namespace, given by `toService`,
+// on the service port given by `port`. It evaluates the network policies
+// selecting the pod and the service's pods, checks the service has ready
+// endpoints and resolves the service's DNS name, which should return its
+// cluster IP. The DNS lookup runs `getent hosts` in the pod's first
+// container so it uses the pod's resolver. When the lookup can't run, for
+// example because the image has no getent, the DNS result is unknown.
+func (h *networkDiagnosticsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	query := r.URL.Query()
+
+	podName := query.Get("fromPod")
+	serviceName := query.Get("toService")
+	if podName == "" || serviceName == "" {
+		RespondWithError(w, http.StatusBadRequest, "fromPod and toService are required", h.logger)
+		return
+	}
+
+	port, err := strconv.Atoi(query.Get("port"))
+	if err != nil || port < 1 || port > 65535 {
+		RespondWithError(w, http.StatusBadRequest, "port must be between 1 and 65535", h.logger)
+		return
+	}
+
+	pod, err := h.kubeClient.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	service, err := h.kubeClient.CoreV1().Services(namespace).Get(serviceName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	servicePort, ok := findServicePort(service, int32(port))
+	if !ok {
+		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("service %q does not expose port %d", serviceName, port), h.logger)
+		return
+	}
+
+	var namespaceLabels map[string]string
+	ns, err := h.kubeClient.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		namespaceLabels = ns.Labels
+	case !kerrors.IsNotFound(err):
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	policies, err := h.kubeClient.NetworkingV1().NetworkPolicies(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	var backends []corev1.Pod
+	if len(service.Spec.Selector) > 0 {
+		pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(service.Spec.Selector).String(),
+		})
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		for _, backend := range pods.Items {
+			if backend.Status.Phase == corev1.PodRunning {
+				backends = append(backends, backend)
+			}
+		}
+	}
+
+	endpoints, endpointsErr := h.kubeClient.CoreV1().Endpoints(namespace).Get(serviceName, metav1.GetOptions{})
+	if endpointsErr != nil && !kerrors.IsNotFound(endpointsErr) {
+		RespondWithError(w, http.StatusInternalServerError, endpointsErr.Error(), h.logger)
+		return
+	}
+
+	var resp networkDiagnosticsResponse
+	var problems []string
+
+	source := networkPeerPod{pod: pod, namespaceLabels: namespaceLabels}
+	for i := range backends {
+		destination := networkPeerPod{pod: &backends[i], namespaceLabels: namespaceLabels}
+
+		targetPort, ok := resolveTargetPort(destination.pod, servicePort)
+		if !ok {
+			continue
+		}
+
+		if networkPolicyAllowsTraffic(policies.Items, source, destination, targetPort, servicePort.Protocol, h.logger) {
+			resp.NetworkPolicyAllows = true
+			break
+		}
+	}
+
+	switch {
+	case len(backends) == 0:
+		problems = append(problems, fmt.Sprintf("service %q selects no running pods", serviceName))
+	case !resp.NetworkPolicyAllows:
+		problems = append(problems, fmt.Sprintf("network policies block traffic from pod %q to the pods of service %q on port %d", podName, serviceName, port))
+	}
+
+	if endpointsErr == nil {
+		resp.EndpointsReady = endpointsHaveReadyAddress(endpoints, servicePort.Name)
+	}
+	if !resp.EndpointsReady {
+		problems = append(problems, fmt.Sprintf("service %q has no ready endpoints for port %d", serviceName, port))
+	}
+
+	host := fmt.Sprintf("%s.%s.svc.%s", serviceName, namespace, clusterDomain)
+
+	ctx, cancel := context.WithTimeout(r.Context(), dnsLookupTimeout)
+	defer cancel()
+
+	var notes []string
+	resolves := false
+
+	addresses, lookupErr := h.lookupHost(ctx, pod, host)
+	switch {
+	case errors.Cause(lookupErr) == errHostNotFound:
+		resp.DNSResolvesCorrectly = &resolves
+		problems = append(problems, fmt.Sprintf("%s does not resolve from pod %q", host, podName))
+	case lookupErr != nil:
+		notes = append(notes, fmt.Sprintf("DNS could not be checked from pod %q: %v", podName, lookupErr))
+	case !dnsAddressesExpected(service, endpoints, addresses):
+		resp.DNSResolvesCorrectly = &resolves
+		problems = append(problems, fmt.Sprintf("%s resolves to %s rather than the service's addresses", host, strings.Join(addresses, ", ")))
+	default:
+		resolves = true
+		resp.DNSResolvesCorrectly = &resolves
+	}
+
+	summary := problems
+	if len(summary) == 0 {
+		summary = []string{fmt.Sprintf("pod %q should be able to reach service %q on port %d", podName, serviceName, port)}
+	}
+	resp.Summary = strings.Join(append(summary, notes...), "; ")
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// networkPeerPod is a pod and the labels of its namespace, which network
+// policy namespace selectors match.
+type networkPeerPod struct {
+	pod             *corev1.Pod
+	namespaceLabels map[string]string
+}
+
+// networkPolicyAllowsTraffic reports whether the policies allow traffic from
+// the source to a port on the destination. Both the source's egress and the
+// destination's ingress must be allowed. A pod which no policy of a type
+// selects allows all traffic of that type.
+func networkPolicyAllowsTraffic(policies []networkingv1.NetworkPolicy, source, destination networkPeerPod, port int32, protocol corev1.Protocol, logger log.Logger) bool {
+	egressRestricted, egressAllowed := false, false
+	ingressRestricted, ingressAllowed := false, false
+
+	for _, policy := range policies {
+		selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+		if err != nil {
+			logger.WithErr(err).With("networkPolicy", policy.Name).Errorf("parse pod selector")
+			continue
+		}
+
+		ingress, egress := networkPolicyTypes(policy)
+
+		if egress && policy.Namespace == source.pod.Namespace && selector.Matches(labels.Set(source.pod.Labels)) {
+			egressRestricted = true
+			for _, rule := range policy.Spec.Egress {
+				if networkPolicyPeersMatch(rule.To, policy.Namespace, destination) &&
+					networkPolicyPortsMatch(rule.Ports, destination.pod, port, protocol) {
+					egressAllowed = true
+				}
+			}
+		}
+
+		if ingress && policy.Namespace == destination.pod.Namespace && selector.Matches(labels.Set(destination.pod.Labels)) {
+			ingressRestricted = true
+			for _, rule := range policy.Spec.Ingress {
+				if networkPolicyPeersMatch(rule.From, policy.Namespace, source) &&
+					networkPolicyPortsMatch(rule.Ports, destination.pod, port, protocol) {
+					ingressAllowed = true
+				}
+			}
+		}
+	}
+
+	return (!egressRestricted || egressAllowed) && (!ingressRestricted || ingressAllowed)
+}
+
+// networkPolicyPeersMatch reports whether a rule's peers include a pod. A rule
+// without peers matches every pod.
+func networkPolicyPeersMatch(peers []networkingv1.NetworkPolicyPeer, policyNamespace string, peer networkPeerPod) bool {
+	if len(peers) == 0 {
+		return true
+	}
+
+	for _, p := range peers {
+		if p.IPBlock != nil {
+			if ipBlockContains(p.IPBlock, peer.pod.Status.PodIP) {
+				return true
+			}
+			continue
+		}
+
+		if p.NamespaceSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(p.NamespaceSelector)
+			if err != nil || !selector.Matches(labels.Set(peer.namespaceLabels)) {
+				continue
+			}
+		} else if peer.pod.Namespace != policyNamespace {
+			continue
+		}
+
+		if p.PodSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(p.PodSelector)
+			if err != nil || !selector.Matches(labels.Set(peer.pod.Labels)) {
+				continue
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// networkPolicyPortsMatch reports whether a rule's ports include a port on a
+// pod. Named ports are looked up in the pod's containers. A rule without ports
+// matches every port.
+func networkPolicyPortsMatch(ports []networkingv1.NetworkPolicyPort, pod *corev1.Pod, port int32, protocol corev1.Protocol) bool {
+	if len(ports) == 0 {
+		return true
+	}
+
+	for _, p := range ports {
+		ruleProtocol := corev1.ProtocolTCP
+		if p.Protocol != nil {
+			ruleProtocol = *p.Protocol
+		}
+		if ruleProtocol != protocol {
+			continue
+		}
+
+		if p.Port == nil {
+			return true
+		}
+
+		if p.Port.Type == intstr.Int {
+			if p.Port.IntVal == port {
+				return true
+			}
+			continue
+		}
+
+		if number, ok := containerPortNumber(pod, p.Port.StrVal); ok && number == port {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ipBlockContains(block *networkingv1.IPBlock, address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	_, cidr, err := net.ParseCIDR(block.CIDR)
+	if err != nil || !cidr.Contains(ip) {
+		return false
+	}
+
+	for _, except := range block.Except {
+		if _, excluded, err := net.ParseCIDR(except); err == nil && excluded.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func findServicePort(service *corev1.Service, port int32) (corev1.ServicePort, bool) {
+	for _, servicePort := range service.Spec.Ports {
+		if servicePort.Port == port {
+			if servicePort.Protocol == "" {
+				servicePort.Protocol = corev1.ProtocolTCP
+			}
+			return servicePort, true
+		}
+	}
+
+	return corev1.ServicePort{}, false
+}
+
+// resolveTargetPort returns the container port on a pod which a service port
+// forwards to. It returns false when a named target port is missing from the
+// pod.
+func resolveTargetPort(pod *corev1.Pod, servicePort corev1.ServicePort) (int32, bool) {
+	switch {
+	case servicePort.TargetPort.Type == intstr.String && servicePort.TargetPort.StrVal != "":
+		return containerPortNumber(pod, servicePort.TargetPort.StrVal)
+	case servicePort.TargetPort.IntVal != 0:
+		return servicePort.TargetPort.IntVal, true
+	default:
+		return servicePort.Port, true
+	}
+}
+
+func containerPortNumber(pod *corev1.Pod, name string) (int32, bool) {
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == name {
+				return port.ContainerPort, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// endpointsHaveReadyAddress reports whether a subset serving the named port
+// has a ready address.
+func endpointsHaveReadyAddress(endpoints *corev1.Endpoints, portName string) bool {
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) == 0 {
+			continue
+		}
+
+		for _, port := range subset.Ports {
+			if port.Name == portName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// newPodExecHostLookup creates a podHostLookup which runs `getent hosts` in
+// the pod's first container.
+func newPodExecHostLookup(newExecutor podExecutorFactory) podHostLookup {
+	return func(ctx context.Context, pod *corev1.Pod, host string) ([]string, error) {
+		if pod.Status.Phase != corev1.PodRunning || len(pod.Spec.Containers) == 0 {
+			return nil, errors.Errorf("pod %q is not running", pod.Name)
+		}
+
+		executor, err := newExecutor(pod.Namespace, pod.Name, &corev1.PodExecOptions{
+			Container: pod.Spec.Containers[0].Name,
+			Command:   []string{"getent", "hosts", host},
+			Stdout:    true,
+			Stderr:    true,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "create pod executor")
+		}
+
+		var stdout, stderr bytes.Buffer
+		done := make(chan error, 1)
+		go func() {
+			done <- executor.Stream(remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr})
+		}()
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "run getent")
+		case err := <-done:
+			if exitErr, ok := err.(utilexec.ExitError); ok && exitErr.ExitStatus() == getentNotFoundExitStatus {
+				return nil, errHostNotFound
+			}
+			if err != nil {
+				return nil, errors.Wrap(err, "run getent")
+			}
+		}
+
+		// Each line is an address followed by the names it has.
+		var addresses []string
+		for _, line := range strings.Split(stdout.String(), "\n") {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				addresses = append(addresses, fields[0])
+			}
+		}
+
+		return addresses, nil
+	}
+}
+
+// dnsAddressesExpected reports whether a service's DNS record resolved to its
+// cluster IP. Headless services resolve to their ready endpoint addresses
+// and ExternalName services to whatever their external name resolves to.
+func dnsAddressesExpected(service *corev1.Service, endpoints *corev1.Endpoints, addresses []string) bool {
+	if service.Spec.Type == corev1.ServiceTypeExternalName {
+		return len(addresses) > 0
+	}
+
+	if service.Spec.ClusterIP != corev1.ClusterIPNone {
+		return containsString(addresses, service.Spec.ClusterIP)
+	}
+
+	if endpoints == nil || len(addresses) == 0 {
+		return false
+	}
+
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if !containsString(addresses, address.IP) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,328 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_networkDiagnosticsHandler(t *testing.T) {
+	pod := func(name, app, ip string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": app}},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "app", Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}}},
+				},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: ip},
+		}
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "10.96.0.10",
+			Selector:  map[string]string{"app": "api"},
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 80, TargetPort: intstr.FromString("http")},
+			},
+		},
+	}
+
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Subsets: []corev1.EndpointSubset{
+			{
+				Addresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}},
+				Ports:     []corev1.EndpointPort{{Name: "http", Port: 8080}},
+			},
+		},
+	}
+
+	allowFrontend := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-frontend", Namespace: "default"},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From: []networkingv1.NetworkPolicyPeer{
+						{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}}},
+					},
+					Ports: []networkingv1.NetworkPolicyPort{{Port: &intstr.IntOrString{Type: intstr.Int, IntVal: 8080}}},
+				},
+			},
+		},
+	}
+
+	lookup := func(addresses ...string) podHostLookup {
+		return func(ctx context.Context, pod *corev1.Pod, host string) ([]string, error) {
+			if host != "api.default.svc.cluster.local" {
+				return nil, errors.Errorf("unexpected host %s", host)
+			}
+			if len(addresses) == 0 {
+				return nil, errHostNotFound
+			}
+			return addresses, nil
+		}
+	}
+
+	unavailable := func(ctx context.Context, pod *corev1.Pod, host string) ([]string, error) {
+		return nil, errors.New("getent: not found")
+	}
+
+	resolves := true
+	doesNotResolve := false
+
+	objects := []runtime.Object{
+		pod("frontend", "frontend", "10.0.0.1"),
+		pod("batch", "batch", "10.0.0.3"),
+		pod("api-1", "api", "10.0.0.2"),
+		service,
+		allowFrontend,
+	}
+
+	tests := []struct {
+		name         string
+		objects      []runtime.Object
+		query        string
+		lookup       podHostLookup
+		expectedCode int
+		expected     networkDiagnosticsResponse
+	}{
+		{
+			name:         "reachable",
+			objects:      append(objects, endpoints),
+			query:        "fromPod=frontend&toService=api&port=80",
+			lookup:       lookup("10.96.0.10"),
+			expectedCode: http.StatusOK,
+			expected: networkDiagnosticsResponse{
+				NetworkPolicyAllows:  true,
+				EndpointsReady:       true,
+				DNSResolvesCorrectly: &resolves,
+				Summary:              `pod "frontend" should be able to reach service "api" on port 80`,
+			},
+		},
+		{
+			name:         "blocked by policy",
+			objects:      append(objects, endpoints),
+			query:        "fromPod=batch&toService=api&port=80",
+			lookup:       lookup("10.96.0.10"),
+			expectedCode: http.StatusOK,
+			expected: networkDiagnosticsResponse{
+				EndpointsReady:       true,
+				DNSResolvesCorrectly: &resolves,
+				Summary:              `network policies block traffic from pod "batch" to the pods of service "api" on port 80`,
+			},
+		},
+		{
+			name:         "no endpoints and wrong dns",
+			objects:      objects,
+			query:        "fromPod=frontend&toService=api&port=80",
+			lookup:       lookup("10.96.0.99"),
+			expectedCode: http.StatusOK,
+			expected: networkDiagnosticsResponse{
+				NetworkPolicyAllows:  true,
+				DNSResolvesCorrectly: &doesNotResolve,
+				Summary: `service "api" has no ready endpoints for port 80; ` +
+					`api.default.svc.cluster.local resolves to 10.96.0.99 rather than the service's addresses`,
+			},
+		},
+		{
+			name:         "dns does not resolve",
+			objects:      append(objects, endpoints),
+			query:        "fromPod=frontend&toService=api&port=80",
+			lookup:       lookup(),
+			expectedCode: http.StatusOK,
+			expected: networkDiagnosticsResponse{
+				NetworkPolicyAllows:  true,
+				EndpointsReady:       true,
+				DNSResolvesCorrectly: &doesNotResolve,
+				Summary:              `api.default.svc.cluster.local does not resolve from pod "frontend"`,
+			},
+		},
+		{
+			name:         "dns unknown",
+			objects:      append(objects, endpoints),
+			query:        "fromPod=frontend&toService=api&port=80",
+			lookup:       unavailable,
+			expectedCode: http.StatusOK,
+			expected: networkDiagnosticsResponse{
+				NetworkPolicyAllows: true,
+				EndpointsReady:      true,
+				Summary: `pod "frontend" should be able to reach service "api" on port 80; ` +
+					`DNS could not be checked from pod "frontend": getent: not found`,
+			},
+		},
+		{
+			name:         "port not exposed",
+			objects:      objects,
+			query:        "fromPod=frontend&toService=api&port=443",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "missing parameters",
+			objects:      objects,
+			query:        "fromPod=frontend&port=80",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "missing service",
+			objects:      objects,
+			query:        "fromPod=frontend&toService=db&port=80",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newNetworkDiagnosticsHandler(kubefake.NewSimpleClientset(tc.objects...), nil, log.NopLogger())
+			handler.lookupHost = tc.lookup
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/networkdiagnostics?"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var resp networkDiagnosticsResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Equal(t, tc.expected, resp)
+		})
+	}
+}
+
+func Test_newPodExecHostLookup(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "default"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		executor *fakeExecutor
+		expected []string
+		isErr    bool
+		notFound bool
+	}{
+		{
+			name:     "resolves",
+			pod:      pod,
+			executor: &fakeExecutor{stdout: []byte("10.96.0.10      api.default.svc.cluster.local\n")},
+			expected: []string{"10.96.0.10"},
+		},
+		{
+			name:     "not found",
+			pod:      pod,
+			executor: &fakeExecutor{err: utilexec.CodeExitError{Err: errors.New("exit 2"), Code: 2}},
+			isErr:    true,
+			notFound: true,
+		},
+		{
+			name:     "getent missing",
+			pod:      pod,
+			executor: &fakeExecutor{err: utilexec.CodeExitError{Err: errors.New("exit 127"), Code: 127}},
+			isErr:    true,
+		},
+		{
+			name:  "pod not running",
+			pod:   &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pending"}},
+			isErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			lookup := newPodExecHostLookup(func(namespace, name string, options *corev1.PodExecOptions) (remotecommand.Executor, error) {
+				assert.Equal(t, "app", options.Container)
+				assert.Equal(t, []string{"getent", "hosts", "api.default.svc.cluster.local"}, options.Command)
+				return tc.executor, nil
+			})
+
+			got, err := lookup(context.Background(), tc.pod, "api.default.svc.cluster.local")
+			if tc.isErr {
+				require.Error(t, err)
+				assert.Equal(t, tc.notFound, errors.Cause(err) == errHostNotFound)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func Test_networkPolicyPeersMatch(t *testing.T) {
+	peer := networkPeerPod{
+		pod: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "other", Labels: map[string]string{"app": "web"}},
+			Status:     corev1.PodStatus{PodIP: "10.1.2.3"},
+		},
+		namespaceLabels: map[string]string{"team": "web"},
+	}
+
+	tests := []struct {
+		name     string
+		peers    []networkingv1.NetworkPolicyPeer
+		expected bool
+	}{
+		{name: "no peers", expected: true},
+		{
+			name:     "pod selector in another namespace",
+			peers:    []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}},
+			expected: false,
+		},
+		{
+			name: "namespace selector",
+			peers: []networkingv1.NetworkPolicyPeer{{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "web"}},
+				PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			}},
+			expected: true,
+		},
+		{
+			name:     "ip block",
+			peers:    []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.1.0.0/16"}}},
+			expected: true,
+		},
+		{
+			name:     "ip block exception",
+			peers:    []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.1.0.0/16", Except: []string{"10.1.2.0/24"}}}},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, networkPolicyPeersMatch(tc.peers, "default", peer))
+		})
+	}
+}
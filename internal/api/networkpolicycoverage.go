@@ -0,0 +1,165 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type networkPolicyPodCoverage struct {
+	Pod            string   `json:"pod"`
+	IngressCovered bool     `json:"ingressCovered"`
+	EgressCovered  bool     `json:"egressCovered"`
+	Policies       []string `json:"policies"`
+}
+
+type networkPolicyFinding struct {
+	Pod      string          `json:"pod"`
+	Severity findingSeverity `json:"severity"`
+	Message  string          `json:"message"`
+}
+
+type networkPolicyCoverageResponse struct {
+	Pods     []networkPolicyPodCoverage `json:"pods"`
+	Findings []networkPolicyFinding     `json:"findings"`
+}
+
+type networkPolicyCoverageHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*networkPolicyCoverageHandler)(nil)
+
+func newNetworkPolicyCoverageHandler(kubeClient kubernetes.Interface, logger log.Logger) *networkPolicyCoverageHandler {
+	return &networkPolicyCoverageHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and reports whether each pod in a
+// namespace is selected by a network policy restricting its ingress and its
+// egress traffic. Pods missing either are returned as findings. Pods which have
+// finished are skipped.
+func (h *networkPolicyCoverageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	policies, err := h.kubeClient.NetworkingV1().NetworkPolicies(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := networkPolicyCoverageResponse{
+		Pods:     []networkPolicyPodCoverage{},
+		Findings: []networkPolicyFinding{},
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		coverage := podNetworkPolicyCoverage(pod, policies.Items, h.logger)
+
+		resp.Pods = append(resp.Pods, coverage)
+
+		if finding, ok := networkPolicyCoverageFinding(coverage); ok {
+			resp.Findings = append(resp.Findings, finding)
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// podNetworkPolicyCoverage returns the policies selecting a pod and whether
+// they restrict its ingress and egress. Policies with invalid selectors are
+// logged and skipped.
+func podNetworkPolicyCoverage(pod corev1.Pod, policies []networkingv1.NetworkPolicy, logger log.Logger) networkPolicyPodCoverage {
+	coverage := networkPolicyPodCoverage{
+		Pod:      pod.Name,
+		Policies: []string{},
+	}
+
+	for _, policy := range policies {
+		selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+		if err != nil {
+			logger.WithErr(err).With("networkPolicy", policy.Name).Errorf("parse pod selector")
+			continue
+		}
+
+		if !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+
+		ingress, egress := networkPolicyTypes(policy)
+		coverage.IngressCovered = coverage.IngressCovered || ingress
+		coverage.EgressCovered = coverage.EgressCovered || egress
+		coverage.Policies = append(coverage.Policies, policy.Name)
+	}
+
+	return coverage
+}
+
+// networkPolicyTypes reports whether a policy restricts ingress and egress.
+// Policies without policy types always restrict ingress, and restrict egress
+// when they have egress rules.
+func networkPolicyTypes(policy networkingv1.NetworkPolicy) (bool, bool) {
+	if len(policy.Spec.PolicyTypes) == 0 {
+		return true, len(policy.Spec.Egress) > 0
+	}
+
+	ingress, egress := false, false
+	for _, policyType := range policy.Spec.PolicyTypes {
+		switch policyType {
+		case networkingv1.PolicyTypeIngress:
+			ingress = true
+		case networkingv1.PolicyTypeEgress:
+			egress = true
+		}
+	}
+
+	return ingress, egress
+}
+
+func networkPolicyCoverageFinding(coverage networkPolicyPodCoverage) (networkPolicyFinding, bool) {
+	var message string
+	severity := severityMedium
+
+	switch {
+	case !coverage.IngressCovered && !coverage.EgressCovered:
+		message = "no network policy restricts ingress or egress"
+		severity = severityHigh
+	case !coverage.IngressCovered:
+		message = "no network policy restricts ingress"
+	case !coverage.EgressCovered:
+		message = "no network policy restricts egress"
+	default:
+		return networkPolicyFinding{}, false
+	}
+
+	return networkPolicyFinding{
+		Pod:      coverage.Pod,
+		Severity: severity,
+		Message:  message,
+	}, true
+}
@@ -0,0 +1,96 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_networkPolicyCoverageHandler(t *testing.T) {
+	newPod := func(name string, labels map[string]string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	newPolicy := func(name string, selector map[string]string, spec networkingv1.NetworkPolicySpec) *networkingv1.NetworkPolicy {
+		spec.PodSelector = metav1.LabelSelector{MatchLabels: selector}
+		return &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       spec,
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newPod("web", map[string]string{"app": "web"}, corev1.PodRunning),
+		newPod("api", map[string]string{"app": "api"}, corev1.PodRunning),
+		newPod("worker", map[string]string{"app": "worker"}, corev1.PodRunning),
+		newPod("migrate", map[string]string{"app": "migrate"}, corev1.PodSucceeded),
+		newPolicy("web-ingress", map[string]string{"app": "web"}, networkingv1.NetworkPolicySpec{}),
+		newPolicy("web-egress", map[string]string{"app": "web"}, networkingv1.NetworkPolicySpec{
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+		}),
+		newPolicy("api", map[string]string{"app": "api"}, networkingv1.NetworkPolicySpec{
+			Egress: []networkingv1.NetworkPolicyEgressRule{{}},
+		}),
+		newPolicy("worker-egress", map[string]string{"app": "worker"}, networkingv1.NetworkPolicySpec{
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+		}),
+		newPolicy("other", map[string]string{"app": "other"}, networkingv1.NetworkPolicySpec{}),
+	)
+
+	handler := newNetworkPolicyCoverageHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/networkpolicies/default/coverage", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got networkPolicyCoverageResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := networkPolicyCoverageResponse{
+		Pods: []networkPolicyPodCoverage{
+			{Pod: "web", IngressCovered: true, EgressCovered: true, Policies: []string{"web-ingress", "web-egress"}},
+			{Pod: "api", IngressCovered: true, EgressCovered: true, Policies: []string{"api"}},
+			{Pod: "worker", EgressCovered: true, Policies: []string{"worker-egress"}},
+		},
+		Findings: []networkPolicyFinding{
+			{Pod: "worker", Severity: severityMedium, Message: "no network policy restricts ingress"},
+		},
+	}
+	assert.Equal(t, expected, got)
+
+	kubeClient = kubefake.NewSimpleClientset(newPod("lonely", nil, corev1.PodPending))
+	handler = newNetworkPolicyCoverageHandler(kubeClient, log.NopLogger())
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	got = networkPolicyCoverageResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	assert.Equal(t, []networkPolicyFinding{
+		{Pod: "lonely", Severity: severityHigh, Message: "no network policy restricts ingress or egress"},
+	}, got.Findings)
+}
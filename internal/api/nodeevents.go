@@ -0,0 +1,157 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type nodeCondition struct {
+	Type               corev1.NodeConditionType `json:"type"`
+	Status             corev1.ConditionStatus   `json:"status"`
+	Reason             string                   `json:"reason,omitempty"`
+	Message            string                   `json:"message,omitempty"`
+	LastTransitionTime time.Time                `json:"lastTransitionTime"`
+}
+
+type nodeEvent struct {
+	Type           string    `json:"type"`
+	Reason         string    `json:"reason"`
+	Message        string    `json:"message"`
+	Count          int32     `json:"count"`
+	Source         string    `json:"source,omitempty"`
+	FirstTimestamp time.Time `json:"firstTimestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+}
+
+type nodeEventsResponse struct {
+	Node       string          `json:"node"`
+	Conditions []nodeCondition `json:"conditions"`
+	Events     []nodeEvent     `json:"events"`
+}
+
+type nodeEventsHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*nodeEventsHandler)(nil)
+
+func newNodeEventsHandler(kubeClient kubernetes.Interface, logger log.Logger) *nodeEventsHandler {
+	return &nodeEventsHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the events involving a node,
+// most recent first, with the node's current conditions. The `reason` and
+// `type` query parameters filter the events.
+func (h *nodeEventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	nodeName := mux.Vars(r)["node"]
+	query := r.URL.Query()
+	reason := query.Get("reason")
+
+	eventType := query.Get("type")
+	switch eventType {
+	case "", corev1.EventTypeNormal, corev1.EventTypeWarning:
+	default:
+		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("type must be %s or %s", corev1.EventTypeNormal, corev1.EventTypeWarning), h.logger)
+		return
+	}
+
+	node, err := h.kubeClient.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	selector := fields.Set{
+		"involvedObject.kind": "Node",
+		"involvedObject.name": nodeName,
+	}
+	if reason != "" {
+		selector["reason"] = reason
+	}
+	if eventType != "" {
+		selector["type"] = eventType
+	}
+
+	events, err := h.kubeClient.CoreV1().Events(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: selector.AsSelector().String(),
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := nodeEventsResponse{
+		Node:       nodeName,
+		Conditions: []nodeCondition{},
+		Events:     []nodeEvent{},
+	}
+
+	for _, condition := range node.Status.Conditions {
+		resp.Conditions = append(resp.Conditions, nodeCondition{
+			Type:               condition.Type,
+			Status:             condition.Status,
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: condition.LastTransitionTime.Time.UTC(),
+		})
+	}
+
+	for _, event := range events.Items {
+		if event.InvolvedObject.Kind != "Node" || event.InvolvedObject.Name != nodeName ||
+			(reason != "" && event.Reason != reason) ||
+			(eventType != "" && event.Type != eventType) {
+			continue
+		}
+
+		last := eventTimestamp(event).UTC()
+		first := event.FirstTimestamp.Time.UTC()
+		if event.FirstTimestamp.IsZero() {
+			first = last
+		}
+
+		count := event.Count
+		if count == 0 {
+			count = 1
+		}
+
+		resp.Events = append(resp.Events, nodeEvent{
+			Type:           event.Type,
+			Reason:         event.Reason,
+			Message:        event.Message,
+			Count:          count,
+			Source:         event.Source.Component,
+			FirstTimestamp: first,
+			LastTimestamp:  last,
+		})
+	}
+
+	sort.SliceStable(resp.Events, func(i, j int) bool {
+		return resp.Events[i].LastTimestamp.After(resp.Events[j].LastTimestamp)
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
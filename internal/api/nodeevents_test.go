@@ -0,0 +1,135 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_nodeEventsHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	event := func(name, kind, object, eventType, reason string, last time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: object},
+			Type:           eventType,
+			Reason:         reason,
+			Message:        reason + " on " + object,
+			Count:          2,
+			Source:         corev1.EventSource{Component: "kubelet"},
+			FirstTimestamp: metav1.NewTime(last.Add(-time.Hour)),
+			LastTimestamp:  metav1.NewTime(last),
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{
+						Type:               corev1.NodeReady,
+						Status:             corev1.ConditionTrue,
+						Reason:             "KubeletReady",
+						LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+					},
+				},
+			},
+		},
+		event("starting", "Node", "node-1", corev1.EventTypeNormal, "Starting", now.Add(-2*time.Hour)),
+		event("pressure", "Node", "node-1", corev1.EventTypeWarning, "EvictionThresholdMet", now.Add(-time.Minute)),
+		event("rebooted", "Node", "node-1", corev1.EventTypeWarning, "Rebooted", now.Add(-30*time.Minute)),
+		event("other-node", "Node", "node-2", corev1.EventTypeWarning, "Rebooted", now),
+		event("pod", "Pod", "node-1", corev1.EventTypeWarning, "BackOff", now),
+	)
+
+	tests := []struct {
+		name            string
+		node            string
+		query           string
+		expectedCode    int
+		expectedReasons []string
+	}{
+		{
+			name:            "all events",
+			node:            "node-1",
+			expectedCode:    http.StatusOK,
+			expectedReasons: []string{"EvictionThresholdMet", "Rebooted", "Starting"},
+		},
+		{
+			name:            "type filter",
+			node:            "node-1",
+			query:           "?type=Normal",
+			expectedCode:    http.StatusOK,
+			expectedReasons: []string{"Starting"},
+		},
+		{
+			name:            "reason filter",
+			node:            "node-1",
+			query:           "?reason=Rebooted",
+			expectedCode:    http.StatusOK,
+			expectedReasons: []string{"Rebooted"},
+		},
+		{
+			name:         "invalid type",
+			node:         "node-1",
+			query:        "?type=Error",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "missing node",
+			node:         "node-9",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newNodeEventsHandler(kubeClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/nodes/"+tc.node+"/events"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"node": tc.node})
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var resp nodeEventsResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+			assert.Equal(t, tc.node, resp.Node)
+			require.Len(t, resp.Conditions, 1)
+			assert.Equal(t, corev1.NodeReady, resp.Conditions[0].Type)
+			assert.Equal(t, "KubeletReady", resp.Conditions[0].Reason)
+
+			var reasons []string
+			for _, e := range resp.Events {
+				reasons = append(reasons, e.Reason)
+				assert.Equal(t, "kubelet", e.Source)
+				assert.Equal(t, int32(2), e.Count)
+			}
+			assert.Equal(t, tc.expectedReasons, reasons)
+		})
+	}
+}
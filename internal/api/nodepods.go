@@ -0,0 +1,144 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type nodePod struct {
+	Pod           string             `json:"pod"`
+	Namespace     string             `json:"namespace"`
+	Phase         corev1.PodPhase    `json:"phase"`
+	QOSClass      corev1.PodQOSClass `json:"qosClass"`
+	CPURequest    resource.Quantity  `json:"cpuRequest"`
+	MemoryRequest resource.Quantity  `json:"memoryRequest"`
+	RestartCount  int32              `json:"restartCount"`
+}
+
+type nodePodsSummary struct {
+	PodCount        int               `json:"podCount"`
+	RequestedCPU    resource.Quantity `json:"requestedCPU"`
+	RequestedMemory resource.Quantity `json:"requestedMemory"`
+}
+
+type nodePodsResponse struct {
+	Node    string          `json:"node"`
+	Pods    []nodePod       `json:"pods"`
+	Summary nodePodsSummary `json:"summary"`
+	// BestEffortPods are the namespace/name of pods without requests or
+	// limits, which are evicted first.
+	BestEffortPods []string `json:"bestEffortPods"`
+}
+
+type nodePodsHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*nodePodsHandler)(nil)
+
+func newNodePodsHandler(kubeClient kubernetes.Interface, logger log.Logger) *nodePodsHandler {
+	return &nodePodsHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the pods scheduled to a node
+// with their resource requests, ordered by namespace and name. Pods which have
+// finished are skipped because they hold no resources. The summary totals the
+// requests the scheduler reserves on the node.
+func (h *nodePodsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	nodeName := mux.Vars(r)["node"]
+
+	if _, err := h.kubeClient.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{}); err != nil {
+		if kerrors.IsNotFound(err) {
+			RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := nodePodsResponse{
+		Node:           nodeName,
+		Pods:           []nodePod{},
+		BestEffortPods: []string{},
+		Summary: nodePodsSummary{
+			RequestedCPU:    *resource.NewMilliQuantity(0, resource.DecimalSI),
+			RequestedMemory: *resource.NewQuantity(0, resource.BinarySI),
+		},
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeName != nodeName || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		requests := podResourceRequests(pod)
+
+		item := nodePod{
+			Pod:           pod.Name,
+			Namespace:     pod.Namespace,
+			Phase:         pod.Status.Phase,
+			QOSClass:      pod.Status.QOSClass,
+			CPURequest:    *resource.NewMilliQuantity(0, resource.DecimalSI),
+			MemoryRequest: *resource.NewQuantity(0, resource.BinarySI),
+		}
+		if cpu, ok := requests[corev1.ResourceCPU]; ok {
+			item.CPURequest = cpu
+		}
+		if memory, ok := requests[corev1.ResourceMemory]; ok {
+			item.MemoryRequest = memory
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			item.RestartCount += status.RestartCount
+		}
+
+		resp.Summary.RequestedCPU.Add(item.CPURequest)
+		resp.Summary.RequestedMemory.Add(item.MemoryRequest)
+
+		if item.QOSClass == corev1.PodQOSBestEffort {
+			resp.BestEffortPods = append(resp.BestEffortPods, pod.Namespace+"/"+pod.Name)
+		}
+
+		resp.Pods = append(resp.Pods, item)
+	}
+
+	resp.Summary.PodCount = len(resp.Pods)
+
+	sort.Slice(resp.Pods, func(i, j int) bool {
+		a, b := resp.Pods[i], resp.Pods[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Pod < b.Pod
+	})
+	sort.Strings(resp.BestEffortPods)
+
+	serveAsJSON(w, &resp, h.logger)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_nodePodsHandler(t *testing.T) {
+	pod := func(namespace, name, node string, phase corev1.PodPhase, qos corev1.PodQOSClass, requests corev1.ResourceList) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.PodSpec{
+				NodeName: node,
+				Containers: []corev1.Container{
+					{Name: "app", Resources: corev1.ResourceRequirements{Requests: requests}},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:             phase,
+				QOSClass:          qos,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: 1}},
+			},
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		pod("default", "web", "node-1", corev1.PodRunning, corev1.PodQOSBurstable, corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("250m"),
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		}),
+		pod("kube-system", "dns", "node-1", corev1.PodRunning, corev1.PodQOSGuaranteed, corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		}),
+		pod("default", "batch", "node-1", corev1.PodPending, corev1.PodQOSBestEffort, nil),
+		pod("default", "done", "node-1", corev1.PodSucceeded, corev1.PodQOSBestEffort, nil),
+		pod("default", "elsewhere", "node-2", corev1.PodRunning, corev1.PodQOSBestEffort, nil),
+	)
+
+	tests := []struct {
+		name         string
+		node         string
+		expectedCode int
+	}{
+		{name: "node", node: "node-1", expectedCode: http.StatusOK},
+		{name: "missing node", node: "node-9", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newNodePodsHandler(kubeClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/nodes/"+tc.node+"/pods", nil)
+			req = mux.SetURLVars(req, map[string]string{"node": tc.node})
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var resp nodePodsResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+			type podRequests struct {
+				pod, qos, cpu, memory string
+			}
+			var got []podRequests
+			for _, p := range resp.Pods {
+				got = append(got, podRequests{p.Namespace + "/" + p.Pod, string(p.QOSClass), p.CPURequest.String(), p.MemoryRequest.String()})
+				assert.Equal(t, int32(1), p.RestartCount)
+			}
+
+			assert.Equal(t, []podRequests{
+				{"default/batch", "BestEffort", "0", "0"},
+				{"default/web", "Burstable", "250m", "256Mi"},
+				{"kube-system/dns", "Guaranteed", "100m", "128Mi"},
+			}, got)
+
+			assert.Equal(t, "node-1", resp.Node)
+			assert.Equal(t, 3, resp.Summary.PodCount)
+			assert.Equal(t, "350m", resp.Summary.RequestedCPU.String())
+			assert.Equal(t, "384Mi", resp.Summary.RequestedMemory.String())
+			assert.Equal(t, []string{"default/batch"}, resp.BestEffortPods)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	evictedEventReason = "Evicted"
+
+	// nodeEvictionWindow is how far back evictions are counted.
+	nodeEvictionWindow = 5 * time.Minute
+)
+
+// nodePressureConditions are the node conditions which lead the kubelet to
+// evict pods.
+var nodePressureConditions = []corev1.NodeConditionType{
+	corev1.NodeMemoryPressure,
+	corev1.NodeDiskPressure,
+	corev1.NodePIDPressure,
+}
+
+type nodePressureCondition struct {
+	Type               corev1.NodeConditionType `json:"type"`
+	Reason             string                   `json:"reason,omitempty"`
+	Message            string                   `json:"message,omitempty"`
+	LastTransitionTime time.Time                `json:"lastTransitionTime"`
+}
+
+type nodePressure struct {
+	Node              string                  `json:"node"`
+	Conditions        []nodePressureCondition `json:"conditions"`
+	AllocatedPods     int                     `json:"allocatedPods"`
+	EvictedPodsLast5m int                     `json:"evictedPodsLast5m"`
+}
+
+type nodePressureResponse struct {
+	Nodes []nodePressure `json:"nodes"`
+}
+
+type nodePressureHandler struct {
+	kubeClient kubernetes.Interface
+	nowFn      func() time.Time
+	logger     log.Logger
+}
+
+var _ http.Handler = (*nodePressureHandler)(nil)
+
+func newNodePressureHandler(kubeClient kubernetes.Interface, logger log.Logger) *nodePressureHandler {
+	return &nodePressureHandler{
+		kubeClient: kubeClient,
+		nowFn:      time.Now,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the nodes under memory, disk
+// or PID pressure with the pressure conditions which are true, the number of
+// running or pending pods on the node, and how many pods the kubelet on the
+// node has evicted in the last five minutes.
+func (h *nodePressureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	nodes, err := h.kubeClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := nodePressureResponse{
+		Nodes: []nodePressure{},
+	}
+
+	pressured := make(map[string]*nodePressure)
+	for _, node := range nodes.Items {
+		var conditions []nodePressureCondition
+		for _, condition := range node.Status.Conditions {
+			if condition.Status != corev1.ConditionTrue || !isPressureCondition(condition.Type) {
+				continue
+			}
+			conditions = append(conditions, nodePressureCondition{
+				Type:               condition.Type,
+				Reason:             condition.Reason,
+				Message:            condition.Message,
+				LastTransitionTime: condition.LastTransitionTime.Time.UTC(),
+			})
+		}
+
+		if len(conditions) > 0 {
+			pressured[node.Name] = &nodePressure{Node: node.Name, Conditions: conditions}
+		}
+	}
+
+	if len(pressured) == 0 {
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if node, ok := pressured[pod.Spec.NodeName]; ok {
+			node.AllocatedPods++
+		}
+	}
+
+	events, err := h.kubeClient.CoreV1().Events(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("reason", evictedEventReason).String(),
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	since := h.nowFn().Add(-nodeEvictionWindow)
+	evicted := make(map[string]sets.String)
+	for _, event := range events.Items {
+		if event.Reason != evictedEventReason || eventTimestamp(event).Before(since) {
+			continue
+		}
+
+		// The kubelet reports evictions against the pod, with the node as
+		// the event's source host.
+		node := event.Source.Host
+		if _, ok := pressured[node]; !ok {
+			continue
+		}
+		if evicted[node] == nil {
+			evicted[node] = sets.NewString()
+		}
+		evicted[node].Insert(event.InvolvedObject.Namespace + "/" + event.InvolvedObject.Name)
+	}
+
+	for name, node := range pressured {
+		node.EvictedPodsLast5m = evicted[name].Len()
+		resp.Nodes = append(resp.Nodes, *node)
+	}
+
+	sort.Slice(resp.Nodes, func(i, j int) bool {
+		return resp.Nodes[i].Node < resp.Nodes[j].Node
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func isPressureCondition(conditionType corev1.NodeConditionType) bool {
+	for _, t := range nodePressureConditions {
+		if t == conditionType {
+			return true
+		}
+	}
+
+	return false
+}
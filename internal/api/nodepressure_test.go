@@ -0,0 +1,106 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_nodePressureHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	transitioned := now.Add(-10 * time.Minute)
+
+	newNode := func(name string, conditions ...corev1.NodeCondition) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.NodeStatus{Conditions: conditions},
+		}
+	}
+
+	condition := func(conditionType corev1.NodeConditionType, status corev1.ConditionStatus) corev1.NodeCondition {
+		return corev1.NodeCondition{
+			Type:               conditionType,
+			Status:             status,
+			Reason:             "Kubelet" + string(conditionType),
+			LastTransitionTime: metav1.NewTime(transitioned),
+		}
+	}
+
+	newPod := func(name, node string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: node},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	newEviction := func(name, pod, node string, age time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: pod},
+			Reason:         evictedEventReason,
+			Source:         corev1.EventSource{Component: "kubelet", Host: node},
+			LastTimestamp:  metav1.NewTime(now.Add(-age)),
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newNode("node-1", condition(corev1.NodeReady, corev1.ConditionTrue), condition(corev1.NodeMemoryPressure, corev1.ConditionTrue), condition(corev1.NodeDiskPressure, corev1.ConditionTrue)),
+		newNode("node-2", condition(corev1.NodeMemoryPressure, corev1.ConditionFalse), condition(corev1.NodePIDPressure, corev1.ConditionTrue)),
+		newNode("node-3", condition(corev1.NodeMemoryPressure, corev1.ConditionFalse)),
+		newPod("web-1", "node-1", corev1.PodRunning),
+		newPod("web-2", "node-1", corev1.PodPending),
+		newPod("web-3", "node-1", corev1.PodFailed),
+		newPod("api-1", "node-3", corev1.PodRunning),
+		newEviction("web-3.1", "web-3", "node-1", time.Minute),
+		newEviction("web-3.2", "web-3", "node-1", 2*time.Minute),
+		newEviction("web-4.1", "web-4", "node-1", 3*time.Minute),
+		newEviction("web-5.1", "web-5", "node-1", 10*time.Minute),
+		newEviction("api-2.1", "api-2", "node-3", time.Minute),
+	)
+
+	handler := newNodePressureHandler(kubeClient, log.NopLogger())
+	handler.nowFn = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/nodepressure", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got nodePressureResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := []nodePressure{
+		{
+			Node: "node-1",
+			Conditions: []nodePressureCondition{
+				{Type: corev1.NodeMemoryPressure, Reason: "KubeletMemoryPressure", LastTransitionTime: transitioned},
+				{Type: corev1.NodeDiskPressure, Reason: "KubeletDiskPressure", LastTransitionTime: transitioned},
+			},
+			AllocatedPods:     2,
+			EvictedPodsLast5m: 2,
+		},
+		{
+			Node: "node-2",
+			Conditions: []nodePressureCondition{
+				{Type: corev1.NodePIDPressure, Reason: "KubeletPIDPressure", LastTransitionTime: transitioned},
+			},
+		},
+	}
+	assert.Equal(t, expected, got.Nodes)
+}
@@ -0,0 +1,245 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	nodeIneligibleUnschedulable = "node(s) were unschedulable"
+	nodeIneligibleSelector      = "node(s) didn't match node selector"
+	nodeIneligibleAffinity      = "node(s) didn't match node affinity"
+	nodeIneligibleTaints        = "node(s) had taints that the pod didn't tolerate"
+)
+
+type podEligibility struct {
+	Pod           string              `json:"pod"`
+	NodeSelector  map[string]string   `json:"nodeSelector,omitempty"`
+	Affinity      *corev1.Affinity    `json:"affinity,omitempty"`
+	Tolerations   []corev1.Toleration `json:"tolerations,omitempty"`
+	EligibleNodes []string            `json:"eligibleNodes"`
+	Reason        string              `json:"reason,omitempty"`
+}
+
+type nodeSelectorResponse struct {
+	Pods []podEligibility `json:"pods"`
+}
+
+type nodeSelectorHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*nodeSelectorHandler)(nil)
+
+func newNodeSelectorHandler(kubeClient kubernetes.Interface, logger log.Logger) *nodeSelectorHandler {
+	return &nodeSelectorHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the nodes each pod in a
+// namespace is eligible to run on given its node selector, required node
+// affinity and tolerations. When no node is eligible, the reason explains why
+// the nodes were ruled out, in the style of the scheduler's messages.
+func (h *nodeSelectorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	nodes, err := h.kubeClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := nodeSelectorResponse{
+		Pods: []podEligibility{},
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		eligibility := podEligibility{
+			Pod:           pod.Name,
+			NodeSelector:  pod.Spec.NodeSelector,
+			Affinity:      pod.Spec.Affinity,
+			Tolerations:   pod.Spec.Tolerations,
+			EligibleNodes: []string{},
+		}
+
+		ineligible := make(map[string]int)
+		for j := range nodes.Items {
+			node := &nodes.Items[j]
+
+			reason := nodeIneligibleReason(pod, node)
+			if reason == "" {
+				eligibility.EligibleNodes = append(eligibility.EligibleNodes, node.Name)
+				continue
+			}
+			ineligible[reason]++
+		}
+		sort.Strings(eligibility.EligibleNodes)
+
+		if len(eligibility.EligibleNodes) == 0 {
+			eligibility.Reason = ineligibleMessage(len(nodes.Items), ineligible)
+		}
+
+		resp.Pods = append(resp.Pods, eligibility)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// nodeIneligibleReason returns why a pod can't run on a node, or an empty
+// string if it can.
+func nodeIneligibleReason(pod *corev1.Pod, node *corev1.Node) string {
+	if node.Spec.Unschedulable {
+		return nodeIneligibleUnschedulable
+	}
+
+	if !labels.SelectorFromSet(pod.Spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		return nodeIneligibleSelector
+	}
+
+	if affinity := pod.Spec.Affinity; affinity != nil && affinity.NodeAffinity != nil {
+		if required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+			if !matchesNodeSelectorTerms(required.NodeSelectorTerms, node) {
+				return nodeIneligibleAffinity
+			}
+		}
+	}
+
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect != corev1.TaintEffectNoSchedule && taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+		if !toleratesTaint(pod.Spec.Tolerations, taint) {
+			return nodeIneligibleTaints
+		}
+	}
+
+	return ""
+}
+
+func toleratesTaint(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesNodeSelectorTerms returns true if a node matches any of the terms.
+// A term matches when all of its requirements do.
+func matchesNodeSelectorTerms(terms []corev1.NodeSelectorTerm, node *corev1.Node) bool {
+	for _, term := range terms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+
+		matches := true
+		for _, requirement := range term.MatchExpressions {
+			value, ok := node.Labels[requirement.Key]
+			if !matchesNodeSelectorRequirement(requirement, value, ok) {
+				matches = false
+				break
+			}
+		}
+		for _, requirement := range term.MatchFields {
+			// metadata.name is the only supported field.
+			ok := requirement.Key == "metadata.name"
+			if !ok || !matchesNodeSelectorRequirement(requirement, node.Name, ok) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesNodeSelectorRequirement(requirement corev1.NodeSelectorRequirement, value string, exists bool) bool {
+	switch requirement.Operator {
+	case corev1.NodeSelectorOpIn:
+		return exists && containsString(requirement.Values, value)
+	case corev1.NodeSelectorOpNotIn:
+		return !exists || !containsString(requirement.Values, value)
+	case corev1.NodeSelectorOpExists:
+		return exists
+	case corev1.NodeSelectorOpDoesNotExist:
+		return !exists
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if !exists || len(requirement.Values) != 1 {
+			return false
+		}
+		actual, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		expected, err := strconv.ParseInt(requirement.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if requirement.Operator == corev1.NodeSelectorOpGt {
+			return actual > expected
+		}
+		return actual < expected
+	default:
+		return false
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ineligibleMessage describes why no node was eligible, e.g.
+// "0/3 nodes are eligible: 1 node(s) were unschedulable, 2 node(s) didn't match node selector."
+func ineligibleMessage(total int, reasons map[string]int) string {
+	if total == 0 {
+		return "there are no nodes in the cluster"
+	}
+
+	var parts []string
+	for reason, count := range reasons {
+		parts = append(parts, fmt.Sprintf("%d %s", count, reason))
+	}
+	sort.Strings(parts)
+
+	return fmt.Sprintf("0/%d nodes are eligible: %s.", total, strings.Join(parts, ", "))
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_nodeSelectorHandler(t *testing.T) {
+	masterTaint := corev1.Taint{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule}
+
+	newNode := func(name string, nodeLabels map[string]string, unschedulable bool, taints ...corev1.Taint) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nodeLabels},
+			Spec:       corev1.NodeSpec{Unschedulable: unschedulable, Taints: taints},
+		}
+	}
+
+	newPod := func(name string, spec corev1.PodSpec) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       spec,
+		}
+	}
+
+	requiredAffinity := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+			},
+		}
+	}
+
+	gpuAffinity := requiredAffinity(corev1.NodeSelectorTerm{
+		MatchExpressions: []corev1.NodeSelectorRequirement{
+			{Key: "gpu", Operator: corev1.NodeSelectorOpExists},
+			{Key: "cores", Operator: corev1.NodeSelectorOpGt, Values: []string{"8"}},
+		},
+	})
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newNode("master", map[string]string{"disk": "ssd"}, false, masterTaint),
+		newNode("worker-1", map[string]string{"disk": "ssd", "gpu": "true", "cores": "16"}, false),
+		newNode("worker-2", map[string]string{"disk": "hdd", "gpu": "true", "cores": "4"}, false),
+		newNode("cordoned", map[string]string{"disk": "ssd"}, true),
+		newPod("any", corev1.PodSpec{}),
+		newPod("ssd", corev1.PodSpec{NodeSelector: map[string]string{"disk": "ssd"}}),
+		newPod("tolerant", corev1.PodSpec{
+			NodeSelector: map[string]string{"disk": "ssd"},
+			Tolerations:  []corev1.Toleration{{Key: "node-role.kubernetes.io/master", Operator: corev1.TolerationOpExists}},
+		}),
+		newPod("gpu", corev1.PodSpec{Affinity: gpuAffinity}),
+		newPod("nvme", corev1.PodSpec{NodeSelector: map[string]string{"disk": "nvme"}}),
+		newPod("by-name", corev1.PodSpec{Affinity: requiredAffinity(corev1.NodeSelectorTerm{
+			MatchFields: []corev1.NodeSelectorRequirement{
+				{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"worker-2"}},
+			},
+		})}),
+	)
+
+	handler := newNodeSelectorHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/nodeselector/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got nodeSelectorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	eligible := make(map[string][]string)
+	reasons := make(map[string]string)
+	for _, pod := range got.Pods {
+		eligible[pod.Pod] = pod.EligibleNodes
+		reasons[pod.Pod] = pod.Reason
+	}
+
+	assert.Equal(t, map[string][]string{
+		"any":      {"worker-1", "worker-2"},
+		"ssd":      {"worker-1"},
+		"tolerant": {"master", "worker-1"},
+		"gpu":      {"worker-1"},
+		"nvme":     {},
+		"by-name":  {"worker-2"},
+	}, eligible)
+
+	assert.Equal(t, "0/4 nodes are eligible: 1 node(s) were unschedulable, 3 node(s) didn't match node selector.", reasons["nvme"])
+	assert.Empty(t, reasons["ssd"])
+}
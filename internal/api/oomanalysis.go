@@ -0,0 +1,204 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	oomKilledReason = "OOMKilled"
+	// oomKillingReason is the reason the kubelet gives events for kernel OOM
+	// kills.
+	oomKillingReason = "OOMKilling"
+	// oomWindow is how far back OOM events are counted.
+	oomWindow = 24 * time.Hour
+
+	// oomLimitIncrease is the factor applied to the memory limit of a killed
+	// container, and oomLimitRounding the size the result is rounded to.
+	oomLimitIncrease = 1.5
+	oomLimitRounding = 64 << 20
+)
+
+type containerOOM struct {
+	Pod              string             `json:"pod"`
+	Container        string             `json:"container"`
+	MemoryLimit      *resource.Quantity `json:"memoryLimit,omitempty"`
+	LastKilledAt     metav1.Time        `json:"lastKilledAt"`
+	OOMCount         int                `json:"oomCount"`
+	RestartCount     int32              `json:"restartCount"`
+	RecommendedLimit *resource.Quantity `json:"recommendedLimit,omitempty"`
+}
+
+type oomAnalysisResponse struct {
+	Containers []containerOOM `json:"containers"`
+}
+
+type oomAnalysisHandler struct {
+	kubeClient kubernetes.Interface
+	nowFn      func() time.Time
+	logger     log.Logger
+}
+
+var _ http.Handler = (*oomAnalysisHandler)(nil)
+
+func newOOMAnalysisHandler(kubeClient kubernetes.Interface, logger log.Logger) *oomAnalysisHandler {
+	return &oomAnalysisHandler{
+		kubeClient: kubeClient,
+		nowFn:      time.Now,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the containers in a namespace
+// whose last termination was an OOM kill, however long ago, with a
+// recommended memory limit. A container status only holds its most recent
+// termination, so OOM counts come from the pod's OOMKilling and OOMKilled
+// events over the past 24 hours, and are at least one.
+func (h *oomAnalysisHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	events, err := h.kubeClient.CoreV1().Events(namespace).List(metav1.ListOptions{
+		FieldSelector: "involvedObject.kind=Pod",
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := oomAnalysisResponse{
+		Containers: []containerOOM{},
+	}
+
+	since := h.nowFn().Add(-oomWindow)
+	kills := countOOMKills(events.Items, since)
+
+	for _, pod := range pods.Items {
+		limits := make(map[string]corev1.ResourceList)
+		for _, container := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+			limits[container.Name] = container.Resources.Limits
+		}
+
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			terminated := status.LastTerminationState.Terminated
+			if terminated == nil || terminated.Reason != oomKilledReason {
+				continue
+			}
+
+			count := kills[oomKillKey{pod: pod.Name, container: status.Name}]
+			if len(statuses) == 1 {
+				count += kills[oomKillKey{pod: pod.Name}]
+			}
+			// The termination itself is a kill, even when its event has
+			// expired or was never recorded.
+			if count == 0 {
+				count = 1
+			}
+
+			oom := containerOOM{
+				Pod:          pod.Name,
+				Container:    status.Name,
+				LastKilledAt: terminated.FinishedAt,
+				OOMCount:     count,
+				RestartCount: status.RestartCount,
+			}
+
+			if limit, ok := limits[status.Name][corev1.ResourceMemory]; ok {
+				oom.MemoryLimit = &limit
+				oom.RecommendedLimit = recommendedMemoryLimit(limit)
+			}
+
+			resp.Containers = append(resp.Containers, oom)
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+type oomKillKey struct {
+	pod       string
+	container string
+}
+
+// countOOMKills counts the OOM kill events since a time for each container.
+// Events whose field path doesn't name a container are counted against the
+// pod, with an empty container, and only attributed to pods with a single
+// container.
+func countOOMKills(events []corev1.Event, since time.Time) map[oomKillKey]int {
+	kills := make(map[oomKillKey]int)
+
+	for _, event := range events {
+		if event.InvolvedObject.Kind != "Pod" {
+			continue
+		}
+		if event.Reason != oomKillingReason && event.Reason != oomKilledReason {
+			continue
+		}
+
+		timestamp := event.LastTimestamp.Time
+		if timestamp.IsZero() {
+			timestamp = event.EventTime.Time
+		}
+		if timestamp.Before(since) {
+			continue
+		}
+
+		count := int(event.Count)
+		if count < 1 {
+			count = 1
+		}
+
+		key := oomKillKey{
+			pod:       event.InvolvedObject.Name,
+			container: containerFromFieldPath(event.InvolvedObject.FieldPath),
+		}
+		kills[key] += count
+	}
+
+	return kills
+}
+
+// containerFromFieldPath returns the container named by an event's field
+// path, e.g. "spec.containers{app}", or an empty string.
+func containerFromFieldPath(fieldPath string) string {
+	for _, prefix := range []string{"spec.containers{", "spec.initContainers{"} {
+		if strings.HasPrefix(fieldPath, prefix) && strings.HasSuffix(fieldPath, "}") {
+			return strings.TrimSuffix(strings.TrimPrefix(fieldPath, prefix), "}")
+		}
+	}
+
+	return ""
+}
+
+// recommendedMemoryLimit increases a memory limit by half, rounded to the
+// nearest 64Mi.
+func recommendedMemoryLimit(limit resource.Quantity) *resource.Quantity {
+	increased := float64(limit.Value()) * oomLimitIncrease
+
+	rounded := int64(increased/oomLimitRounding+0.5) * oomLimitRounding
+	if rounded < oomLimitRounding {
+		rounded = oomLimitRounding
+	}
+
+	return resource.NewQuantity(rounded, resource.BinarySI)
+}
@@ -0,0 +1,134 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_oomAnalysisHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	newPod := func(name, limit string, reason string, finishedAt time.Time) *corev1.Pod {
+		container := corev1.Container{Name: "app"}
+		if limit != "" {
+			container.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(limit)}
+		}
+
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{container}},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:         "app",
+						RestartCount: 3,
+						LastTerminationState: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{Reason: reason, FinishedAt: metav1.NewTime(finishedAt)},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	newEvent := func(name, pod, fieldPath, reason string, count int32, lastSeen time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: pod, FieldPath: fieldPath},
+			Reason:         reason,
+			Count:          count,
+			LastTimestamp:  metav1.NewTime(lastSeen),
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newEvent("web.1", "web", "spec.containers{app}", oomKilledReason, 2, now.Add(-time.Hour)),
+		newEvent("web.2", "web", "", oomKillingReason, 1, now.Add(-3*time.Hour)),
+		newEvent("web.3", "web", "spec.containers{app}", oomKilledReason, 4, now.Add(-30*time.Hour)),
+		newEvent("web.4", "web", "spec.containers{app}", "BackOff", 5, now.Add(-time.Hour)),
+		newPod("web", "256Mi", oomKilledReason, now.Add(-time.Hour)),
+		newPod("unlimited", "", oomKilledReason, now.Add(-2*time.Hour)),
+		newPod("crashed", "256Mi", "Error", now.Add(-time.Hour)),
+		newPod("old", "256Mi", oomKilledReason, now.Add(-48*time.Hour)),
+	)
+
+	handler := newOOMAnalysisHandler(kubeClient, log.NopLogger())
+	handler.nowFn = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/memory-analysis/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got oomAnalysisResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	require.Len(t, got.Containers, 3)
+
+	web := got.Containers[0]
+	assert.Equal(t, "web", web.Pod)
+	assert.Equal(t, "app", web.Container)
+	assert.Equal(t, 3, web.OOMCount)
+	assert.Equal(t, int32(3), web.RestartCount)
+	assert.Equal(t, "256Mi", web.MemoryLimit.String())
+	assert.Equal(t, "384Mi", web.RecommendedLimit.String())
+	assert.True(t, now.Add(-time.Hour).Equal(web.LastKilledAt.Time))
+
+	unlimited := got.Containers[1]
+	assert.Equal(t, "unlimited", unlimited.Pod)
+	assert.Equal(t, 1, unlimited.OOMCount)
+	assert.Nil(t, unlimited.MemoryLimit)
+	assert.Nil(t, unlimited.RecommendedLimit)
+
+	// Kills older than the event window are still reported, counted once.
+	old := got.Containers[2]
+	assert.Equal(t, "old", old.Pod)
+	assert.Equal(t, 1, old.OOMCount)
+	assert.True(t, now.Add(-48*time.Hour).Equal(old.LastKilledAt.Time))
+}
+
+func Test_recommendedMemoryLimit(t *testing.T) {
+	tests := []struct {
+		limit    string
+		expected string
+	}{
+		{limit: "256Mi", expected: "384Mi"},
+		{limit: "100Mi", expected: "128Mi"},
+		{limit: "1Gi", expected: "1536Mi"},
+		{limit: "10Mi", expected: "64Mi"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.limit, func(t *testing.T) {
+			got := recommendedMemoryLimit(resource.MustParse(tc.limit))
+			assert.Equal(t, tc.expected, got.String())
+		})
+	}
+}
+
+func Test_containerFromFieldPath(t *testing.T) {
+	assert.Equal(t, "app", containerFromFieldPath("spec.containers{app}"))
+	assert.Equal(t, "init", containerFromFieldPath("spec.initContainers{init}"))
+	assert.Equal(t, "", containerFromFieldPath(""))
+	assert.Equal(t, "", containerFromFieldPath("spec.volumes{data}"))
+}
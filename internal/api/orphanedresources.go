@@ -0,0 +1,290 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubernetes/staging/src/k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// orphanMinAge is how old an unreferenced resource must be before it is
+	// reported, so resources whose users are still being created are not.
+	orphanMinAge = 7 * 24 * time.Hour
+
+	// rootCAConfigMap is published to every namespace by the controller
+	// manager.
+	rootCAConfigMap = "kube-root-ca.crt"
+
+	helmReleaseSecretType corev1.SecretType = "helm.sh/release.v1"
+)
+
+type orphanedResource struct {
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	Age       string    `json:"age"`
+	Reason    string    `json:"reason"`
+}
+
+type orphanedResourcesResponse struct {
+	Resources []orphanedResource `json:"resources"`
+}
+
+// podSpecReferences are the names of resources used by pod specs.
+type podSpecReferences struct {
+	configMaps      sets.String
+	secrets         sets.String
+	claims          sets.String
+	serviceAccounts sets.String
+}
+
+type orphanedResourcesHandler struct {
+	kubeClient kubernetes.Interface
+	nowFn      func() time.Time
+	logger     log.Logger
+}
+
+var _ http.Handler = (*orphanedResourcesHandler)(nil)
+
+func newOrphanedResourcesHandler(kubeClient kubernetes.Interface, logger log.Logger) *orphanedResourcesHandler {
+	return &orphanedResourcesHandler{
+		kubeClient: kubeClient,
+		nowFn:      time.Now,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the ConfigMaps, Secrets,
+// PersistentVolumeClaims, Services and ServiceAccounts in a namespace which
+// have no owner, are older than a week, and are not used by a pod or
+// deployment. Services are used when their selector matches a pod or
+// deployment template. Secrets are also used when a service account lists
+// them or they are the token of an existing service account. Resources
+// Kubernetes or Helm create and rely on are never reported.
+func (h *orphanedResourcesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	core := h.kubeClient.CoreV1()
+
+	pods, err := core.Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	deployments, err := h.kubeClient.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	configMaps, err := core.ConfigMaps(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	secrets, err := core.Secrets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	claims, err := core.PersistentVolumeClaims(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	services, err := core.Services(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	serviceAccounts, err := core.ServiceAccounts(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	refs := podSpecReferences{
+		configMaps:      sets.NewString(),
+		secrets:         sets.NewString(),
+		claims:          sets.NewString(),
+		serviceAccounts: sets.NewString(),
+	}
+	var podLabels []labels.Set
+
+	for _, pod := range pods.Items {
+		refs.add(pod.Spec)
+		podLabels = append(podLabels, pod.Labels)
+	}
+	for _, deployment := range deployments.Items {
+		refs.add(deployment.Spec.Template.Spec)
+		podLabels = append(podLabels, deployment.Spec.Template.Labels)
+	}
+
+	serviceAccountNames := sets.NewString()
+	for _, serviceAccount := range serviceAccounts.Items {
+		serviceAccountNames.Insert(serviceAccount.Name)
+		for _, ref := range serviceAccount.Secrets {
+			refs.secrets.Insert(ref.Name)
+		}
+		for _, ref := range serviceAccount.ImagePullSecrets {
+			refs.secrets.Insert(ref.Name)
+		}
+	}
+
+	now := h.nowFn()
+
+	resp := orphanedResourcesResponse{
+		Resources: []orphanedResource{},
+	}
+
+	report := func(kind string, object metav1.ObjectMeta, reason string) {
+		age := now.Sub(object.CreationTimestamp.Time)
+		if len(object.OwnerReferences) > 0 || age <= orphanMinAge {
+			return
+		}
+
+		resp.Resources = append(resp.Resources, orphanedResource{
+			Kind:      kind,
+			Name:      object.Name,
+			CreatedAt: object.CreationTimestamp.Time.UTC(),
+			Age:       duration.ShortHumanDuration(age),
+			Reason:    reason,
+		})
+	}
+
+	for _, configMap := range configMaps.Items {
+		if configMap.Name != rootCAConfigMap && !refs.configMaps.Has(configMap.Name) {
+			report("ConfigMap", configMap.ObjectMeta, "not used by any pod or deployment")
+		}
+	}
+
+	for _, secret := range secrets.Items {
+		switch {
+		case secret.Type == helmReleaseSecretType:
+		case secret.Type == corev1.SecretTypeServiceAccountToken:
+			if !serviceAccountNames.Has(secret.Annotations[corev1.ServiceAccountNameKey]) {
+				report("Secret", secret.ObjectMeta, "token for a service account which no longer exists")
+			}
+		case !refs.secrets.Has(secret.Name):
+			report("Secret", secret.ObjectMeta, "not used by any pod, deployment or service account")
+		}
+	}
+
+	for _, claim := range claims.Items {
+		if !refs.claims.Has(claim.Name) {
+			report("PersistentVolumeClaim", claim.ObjectMeta, "not mounted by any pod or deployment")
+		}
+	}
+
+	for _, service := range services.Items {
+		// Services without a selector have manually managed endpoints.
+		if len(service.Spec.Selector) == 0 {
+			continue
+		}
+
+		selector := labels.SelectorFromSet(service.Spec.Selector)
+		matched := false
+		for _, set := range podLabels {
+			if selector.Matches(set) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			report("Service", service.ObjectMeta, "selector matches no pod or deployment")
+		}
+	}
+
+	for _, serviceAccount := range serviceAccounts.Items {
+		if serviceAccount.Name != "default" && !refs.serviceAccounts.Has(serviceAccount.Name) {
+			report("ServiceAccount", serviceAccount.ObjectMeta, "not used by any pod or deployment")
+		}
+	}
+
+	sort.Slice(resp.Resources, func(i, j int) bool {
+		if resp.Resources[i].Kind != resp.Resources[j].Kind {
+			return resp.Resources[i].Kind < resp.Resources[j].Kind
+		}
+		return resp.Resources[i].Name < resp.Resources[j].Name
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// add records the resources a pod spec uses through volumes, environment,
+// image pull secrets and its service account.
+func (refs podSpecReferences) add(spec corev1.PodSpec) {
+	serviceAccount := spec.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+	refs.serviceAccounts.Insert(serviceAccount)
+
+	for _, ref := range spec.ImagePullSecrets {
+		refs.secrets.Insert(ref.Name)
+	}
+
+	for _, volume := range spec.Volumes {
+		switch {
+		case volume.ConfigMap != nil:
+			refs.configMaps.Insert(volume.ConfigMap.Name)
+		case volume.Secret != nil:
+			refs.secrets.Insert(volume.Secret.SecretName)
+		case volume.PersistentVolumeClaim != nil:
+			refs.claims.Insert(volume.PersistentVolumeClaim.ClaimName)
+		case volume.Projected != nil:
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					refs.configMaps.Insert(source.ConfigMap.Name)
+				}
+				if source.Secret != nil {
+					refs.secrets.Insert(source.Secret.Name)
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, from := range container.EnvFrom {
+			if from.ConfigMapRef != nil {
+				refs.configMaps.Insert(from.ConfigMapRef.Name)
+			}
+			if from.SecretRef != nil {
+				refs.secrets.Insert(from.SecretRef.Name)
+			}
+		}
+
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				refs.configMaps.Insert(env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				refs.secrets.Insert(env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+}
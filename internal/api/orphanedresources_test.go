@@ -0,0 +1,132 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_orphanedResourcesHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	old := 30 * 24 * time.Hour
+
+	meta := func(name string, age time.Duration) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: metav1.NewTime(now.Add(-age))}
+	}
+
+	owned := meta("owned-config", old)
+	owned.OwnerReferences = []metav1.OwnerReference{{Kind: "Deployment", Name: "web"}}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: func() metav1.ObjectMeta {
+				m := meta("web-1", time.Hour)
+				m.Labels = map[string]string{"app": "web"}
+				return m
+			}(),
+			Spec: corev1.PodSpec{
+				ServiceAccountName: "web",
+				Volumes: []corev1.Volume{
+					{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "web-config"}}}},
+					{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "web-data"}}},
+				},
+				Containers: []corev1.Container{{
+					Name: "app",
+					Env: []corev1.EnvVar{{
+						Name:      "PASSWORD",
+						ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "web-credentials"}}},
+					}},
+				}},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: meta("worker", old),
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "worker"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{
+							Name:    "worker",
+							EnvFrom: []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "worker-config"}}}},
+						}},
+					},
+				},
+			},
+		},
+		&corev1.ConfigMap{ObjectMeta: meta("web-config", old)},
+		&corev1.ConfigMap{ObjectMeta: meta("worker-config", old)},
+		&corev1.ConfigMap{ObjectMeta: meta("leftover", old)},
+		&corev1.ConfigMap{ObjectMeta: meta("recent", 2*24*time.Hour)},
+		&corev1.ConfigMap{ObjectMeta: owned},
+		&corev1.ConfigMap{ObjectMeta: meta(rootCAConfigMap, old)},
+		&corev1.Secret{ObjectMeta: meta("web-credentials", old)},
+		&corev1.Secret{ObjectMeta: meta("old-credentials", old)},
+		&corev1.Secret{ObjectMeta: meta("sh.helm.release.v1.web.v1", old), Type: helmReleaseSecretType},
+		&corev1.Secret{
+			ObjectMeta: func() metav1.ObjectMeta {
+				m := meta("web-token-abc", old)
+				m.Annotations = map[string]string{corev1.ServiceAccountNameKey: "web"}
+				return m
+			}(),
+			Type: corev1.SecretTypeServiceAccountToken,
+		},
+		&corev1.Secret{
+			ObjectMeta: func() metav1.ObjectMeta {
+				m := meta("removed-token-abc", old)
+				m.Annotations = map[string]string{corev1.ServiceAccountNameKey: "removed"}
+				return m
+			}(),
+			Type: corev1.SecretTypeServiceAccountToken,
+		},
+		&corev1.PersistentVolumeClaim{ObjectMeta: meta("web-data", old)},
+		&corev1.PersistentVolumeClaim{ObjectMeta: meta("scratch", old)},
+		&corev1.Service{ObjectMeta: meta("web", old), Spec: corev1.ServiceSpec{Selector: map[string]string{"app": "web"}}},
+		&corev1.Service{ObjectMeta: meta("worker", old), Spec: corev1.ServiceSpec{Selector: map[string]string{"app": "worker"}}},
+		&corev1.Service{ObjectMeta: meta("legacy", old), Spec: corev1.ServiceSpec{Selector: map[string]string{"app": "legacy"}}},
+		&corev1.Service{ObjectMeta: meta("external", old)},
+		&corev1.ServiceAccount{ObjectMeta: meta("default", old)},
+		&corev1.ServiceAccount{ObjectMeta: meta("web", old)},
+		&corev1.ServiceAccount{ObjectMeta: meta("builder", old)},
+	)
+
+	handler := newOrphanedResourcesHandler(kubeClient, log.NopLogger())
+	handler.nowFn = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/orphaned-resources/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got orphanedResourcesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	created := now.Add(-old)
+	expected := []orphanedResource{
+		{Kind: "ConfigMap", Name: "leftover", CreatedAt: created, Age: "30d", Reason: "not used by any pod or deployment"},
+		{Kind: "PersistentVolumeClaim", Name: "scratch", CreatedAt: created, Age: "30d", Reason: "not mounted by any pod or deployment"},
+		{Kind: "Secret", Name: "old-credentials", CreatedAt: created, Age: "30d", Reason: "not used by any pod, deployment or service account"},
+		{Kind: "Secret", Name: "removed-token-abc", CreatedAt: created, Age: "30d", Reason: "token for a service account which no longer exists"},
+		{Kind: "Service", Name: "legacy", CreatedAt: created, Age: "30d", Reason: "selector matches no pod or deployment"},
+		{Kind: "ServiceAccount", Name: "builder", CreatedAt: created, Age: "30d", Reason: "not used by any pod or deployment"},
+	}
+	assert.Equal(t, expected, got.Resources)
+}
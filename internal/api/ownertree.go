@@ -0,0 +1,228 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	defaultOwnerTreeDepth = 10
+	maxOwnerTreeDepth     = 50
+)
+
+type ownerTreeNode struct {
+	APIVersion string           `json:"apiVersion"`
+	Kind       string           `json:"kind"`
+	Name       string           `json:"name"`
+	UID        types.UID        `json:"uid,omitempty"`
+	Controller bool             `json:"controller,omitempty"`
+	Missing    bool             `json:"missing,omitempty"`
+	Cycle      bool             `json:"cycle,omitempty"`
+	Truncated  bool             `json:"truncated,omitempty"`
+	Owners     []*ownerTreeNode `json:"owners"`
+}
+
+type ownerTreeHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*ownerTreeHandler)(nil)
+
+func newOwnerTreeHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *ownerTreeHandler {
+	return &ownerTreeHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the owners of the object
+// identified by the `apiVersion`, `kind`, and `name` query parameters, and
+// their owners, up to `maxDepth` levels. An object which is reached again is
+// marked as a cycle instead of being expanded.
+func (h *ownerTreeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	query := r.URL.Query()
+
+	apiVersion, kind, name := query.Get("apiVersion"), query.Get("kind"), query.Get("name")
+	if apiVersion == "" || kind == "" || name == "" {
+		RespondWithError(w, http.StatusBadRequest, "apiVersion, kind, and name are required", h.logger)
+		return
+	}
+
+	maxDepth := defaultOwnerTreeDepth
+	if s := query.Get("maxDepth"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > maxOwnerTreeDepth {
+			RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("maxDepth must be between 0 and %d", maxOwnerTreeDepth), h.logger)
+			return
+		}
+		maxDepth = n
+	}
+
+	builder := &ownerTreeBuilder{
+		handler:   h,
+		namespace: namespace,
+		visited:   make(map[types.UID]bool),
+		resources: make(map[schema.GroupVersionKind]ownerTreeResource),
+	}
+
+	root, err := builder.get(apiVersion, kind, name)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	tree, err := builder.build(root, &ownerTreeNode{APIVersion: apiVersion, Kind: kind, Name: name}, maxDepth)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	serveAsJSON(w, tree, h.logger)
+}
+
+type ownerTreeResource struct {
+	gvr        schema.GroupVersionResource
+	namespaced bool
+}
+
+// ownerTreeBuilder holds the state for building a single tree.
+type ownerTreeBuilder struct {
+	handler   *ownerTreeHandler
+	namespace string
+	visited   map[types.UID]bool
+	resources map[schema.GroupVersionKind]ownerTreeResource
+}
+
+func (b *ownerTreeBuilder) build(object *unstructured.Unstructured, node *ownerTreeNode, depth int) (*ownerTreeNode, error) {
+	node.UID = object.GetUID()
+	node.Owners = []*ownerTreeNode{}
+
+	if b.visited[node.UID] {
+		node.Cycle = true
+		return node, nil
+	}
+	b.visited[node.UID] = true
+
+	ownerReferences := object.GetOwnerReferences()
+	if len(ownerReferences) == 0 {
+		return node, nil
+	}
+
+	if depth == 0 {
+		node.Truncated = true
+		return node, nil
+	}
+
+	for _, ownerReference := range ownerReferences {
+		owner := &ownerTreeNode{
+			APIVersion: ownerReference.APIVersion,
+			Kind:       ownerReference.Kind,
+			Name:       ownerReference.Name,
+			UID:        ownerReference.UID,
+			Controller: ownerReference.Controller != nil && *ownerReference.Controller,
+			Owners:     []*ownerTreeNode{},
+		}
+		node.Owners = append(node.Owners, owner)
+
+		if b.visited[ownerReference.UID] {
+			owner.Cycle = true
+			continue
+		}
+
+		ownerObject, err := b.get(ownerReference.APIVersion, ownerReference.Kind, ownerReference.Name)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				owner.Missing = true
+				continue
+			}
+			return nil, err
+		}
+
+		// The UID check guards against a new object which reuses the owner's name.
+		if ownerObject.GetUID() != ownerReference.UID {
+			owner.Missing = true
+			continue
+		}
+
+		if _, err := b.build(ownerObject, owner, depth-1); err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
+
+func (b *ownerTreeBuilder) get(apiVersion, kind, name string) (*unstructured.Unstructured, error) {
+	resource, err := b.resource(apiVersion, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	client := b.handler.dynamicClient.Resource(resource.gvr)
+	if resource.namespaced {
+		return client.Namespace(b.namespace).Get(name, metav1.GetOptions{})
+	}
+
+	return client.Get(name, metav1.GetOptions{})
+}
+
+// resource finds the resource for a kind using discovery.
+func (b *ownerTreeBuilder) resource(apiVersion, kind string) (ownerTreeResource, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return ownerTreeResource{}, err
+	}
+
+	gvk := gv.WithKind(kind)
+	if resource, ok := b.resources[gvk]; ok {
+		return resource, nil
+	}
+
+	resourceList, err := b.handler.kubeClient.Discovery().ServerResourcesForGroupVersion(apiVersion)
+	if err != nil {
+		return ownerTreeResource{}, errors.Wrapf(err, "discover resources for %s", apiVersion)
+	}
+
+	for _, apiResource := range resourceList.APIResources {
+		// Subresources share the kind of their parent.
+		if apiResource.Kind != kind || strings.Contains(apiResource.Name, "/") {
+			continue
+		}
+
+		resource := ownerTreeResource{
+			gvr:        gv.WithResource(apiResource.Name),
+			namespaced: apiResource.Namespaced,
+		}
+		b.resources[gvk] = resource
+		return resource, nil
+	}
+
+	return ownerTreeResource{}, kerrors.NewNotFound(gv.WithResource("").GroupResource(), kind)
+}
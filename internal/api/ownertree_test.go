@@ -0,0 +1,149 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_ownerTreeHandler(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", Kind: "Pod", Namespaced: true},
+				{Name: "pods/log", Kind: "Pod", Namespaced: true},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", Kind: "Deployment", Namespaced: true},
+				{Name: "replicasets", Kind: "ReplicaSet", Namespaced: true},
+			},
+		},
+	}
+
+	newObject := func(apiVersion, kind, name, uid string, owners ...map[string]interface{}) *unstructured.Unstructured {
+		var ownerReferences []interface{}
+		for _, owner := range owners {
+			ownerReferences = append(ownerReferences, owner)
+		}
+
+		metadata := map[string]interface{}{"name": name, "namespace": "default", "uid": uid}
+		if len(ownerReferences) > 0 {
+			metadata["ownerReferences"] = ownerReferences
+		}
+
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   metadata,
+		}}
+	}
+
+	ownerRef := func(apiVersion, kind, name, uid string) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": apiVersion, "kind": kind, "name": name, "uid": uid, "controller": true,
+		}
+	}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newObject("v1", "Pod", "web-1-abcde", "pod", ownerRef("apps/v1", "ReplicaSet", "web-1", "rs")),
+		newObject("apps/v1", "ReplicaSet", "web-1", "rs", ownerRef("apps/v1", "Deployment", "web", "deployment")),
+		// The deployment claims to be owned by its own replica set.
+		newObject("apps/v1", "Deployment", "web", "deployment",
+			ownerRef("apps/v1", "ReplicaSet", "web-1", "rs"),
+			ownerRef("apps/v1", "ReplicaSet", "deleted", "gone")),
+	)
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expected     *ownerTreeNode
+	}{
+		{
+			name:         "full tree with cycle",
+			query:        "?apiVersion=v1&kind=Pod&name=web-1-abcde",
+			expectedCode: http.StatusOK,
+			expected: &ownerTreeNode{
+				APIVersion: "v1", Kind: "Pod", Name: "web-1-abcde", UID: "pod",
+				Owners: []*ownerTreeNode{
+					{
+						APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-1", UID: "rs", Controller: true,
+						Owners: []*ownerTreeNode{
+							{
+								APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "deployment", Controller: true,
+								Owners: []*ownerTreeNode{
+									{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-1", UID: "rs", Controller: true, Cycle: true, Owners: []*ownerTreeNode{}},
+									{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "deleted", UID: "gone", Controller: true, Missing: true, Owners: []*ownerTreeNode{}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:         "max depth",
+			query:        "?apiVersion=v1&kind=Pod&name=web-1-abcde&maxDepth=1",
+			expectedCode: http.StatusOK,
+			expected: &ownerTreeNode{
+				APIVersion: "v1", Kind: "Pod", Name: "web-1-abcde", UID: "pod",
+				Owners: []*ownerTreeNode{
+					{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-1", UID: "rs", Controller: true, Truncated: true, Owners: []*ownerTreeNode{}},
+				},
+			},
+		},
+		{
+			name:         "missing root",
+			query:        "?apiVersion=v1&kind=Pod&name=missing",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "missing parameters",
+			query:        "?kind=Pod",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newOwnerTreeHandler(kubeClient, dynamicClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/ownerTree"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var got ownerTreeNode
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tc.expected, &got)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type pdbPod struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+}
+
+type pdbStatus struct {
+	Name               string   `json:"name"`
+	CurrentHealthy     int32    `json:"currentHealthy"`
+	DesiredHealthy     int32    `json:"desiredHealthy"`
+	DisruptionsAllowed int32    `json:"disruptionsAllowed"`
+	Blocking           bool     `json:"blocking"`
+	Pods               []pdbPod `json:"pods"`
+}
+
+type pdbResponse struct {
+	PodDisruptionBudgets []pdbStatus `json:"podDisruptionBudgets"`
+}
+
+type pdbHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*pdbHandler)(nil)
+
+func newPDBHandler(kubeClient kubernetes.Interface, logger log.Logger) *pdbHandler {
+	return &pdbHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the pod disruption budgets in a
+// namespace along with the pods they select. Budgets which allow no
+// disruptions would block a node drain and are marked as blocking.
+//
+// The policy/v1beta1 API is used because it is the newest version served by
+// the clusters this client supports.
+func (h *pdbHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pdbs, err := h.kubeClient.PolicyV1beta1().PodDisruptionBudgets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := pdbResponse{
+		PodDisruptionBudgets: []pdbStatus{},
+	}
+
+	for i := range pdbs.Items {
+		status, err := newPDBStatus(&pdbs.Items[i], pods.Items)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		resp.PodDisruptionBudgets = append(resp.PodDisruptionBudgets, status)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// newPDBStatus computes a budget's health from the pods it selects. The
+// expected pod count is the number of selected pods, which matches the
+// disruption controller when workloads are not scaling.
+func newPDBStatus(pdb *policyv1beta1.PodDisruptionBudget, pods []corev1.Pod) (pdbStatus, error) {
+	status := pdbStatus{
+		Name: pdb.Name,
+		Pods: []pdbPod{},
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+	if err != nil {
+		return pdbStatus{}, err
+	}
+
+	for _, pod := range pods {
+		if selector.Empty() || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+
+		ready := isPodReady(&pod)
+		if ready {
+			status.CurrentHealthy++
+		}
+		status.Pods = append(status.Pods, pdbPod{Name: pod.Name, Ready: ready})
+	}
+
+	expected := len(status.Pods)
+
+	switch {
+	case pdb.Spec.MinAvailable != nil:
+		desired, err := intstr.GetValueFromIntOrPercent(pdb.Spec.MinAvailable, expected, true)
+		if err != nil {
+			return pdbStatus{}, err
+		}
+		status.DesiredHealthy = int32(desired)
+	case pdb.Spec.MaxUnavailable != nil:
+		unavailable, err := intstr.GetValueFromIntOrPercent(pdb.Spec.MaxUnavailable, expected, true)
+		if err != nil {
+			return pdbStatus{}, err
+		}
+		if desired := expected - unavailable; desired > 0 {
+			status.DesiredHealthy = int32(desired)
+		}
+	}
+
+	if allowed := status.CurrentHealthy - status.DesiredHealthy; allowed > 0 {
+		status.DisruptionsAllowed = allowed
+	}
+	status.Blocking = status.DisruptionsAllowed == 0
+
+	return status, nil
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
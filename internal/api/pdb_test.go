@@ -0,0 +1,95 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_pdbHandler(t *testing.T) {
+	newPod := func(name, app string, ready bool) *corev1.Pod {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": app}},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+			},
+		}
+	}
+
+	newPDB := func(name, app string, minAvailable, maxUnavailable *intstr.IntOrString) *policyv1beta1.PodDisruptionBudget {
+		return &policyv1beta1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: policyv1beta1.PodDisruptionBudgetSpec{
+				Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+				MinAvailable:   minAvailable,
+				MaxUnavailable: maxUnavailable,
+			},
+		}
+	}
+
+	two := intstr.FromInt(2)
+	half := intstr.FromString("50%")
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newPDB("web", "web", &two, nil),
+		newPDB("db", "db", nil, &half),
+		newPod("web-1", "web", true),
+		newPod("web-2", "web", false),
+		newPod("db-1", "db", true),
+		newPod("db-2", "db", true),
+		newPod("db-3", "db", true),
+	)
+
+	handler := newPDBHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/pdb/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got pdbResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	byName := make(map[string]pdbStatus)
+	for _, status := range got.PodDisruptionBudgets {
+		byName[status.Name] = status
+	}
+
+	web := byName["web"]
+	assert.Equal(t, int32(1), web.CurrentHealthy)
+	assert.Equal(t, int32(2), web.DesiredHealthy)
+	assert.Equal(t, int32(0), web.DisruptionsAllowed)
+	assert.True(t, web.Blocking)
+	assert.ElementsMatch(t, []pdbPod{{Name: "web-1", Ready: true}, {Name: "web-2", Ready: false}}, web.Pods)
+
+	db := byName["db"]
+	assert.Equal(t, int32(3), db.CurrentHealthy)
+	assert.Equal(t, int32(1), db.DesiredHealthy)
+	assert.Equal(t, int32(2), db.DisruptionsAllowed)
+	assert.False(t, db.Blocking)
+	assert.Len(t, db.Pods, 3)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	failedSchedulingReason = "FailedScheduling"
+
+	// pendingWarningThreshold is how long a pod can be pending before it is
+	// flagged.
+	pendingWarningThreshold = 5 * time.Minute
+
+	warningLevelHigh = "high"
+)
+
+type pendingPod struct {
+	PodName             string       `json:"podName"`
+	Reason              string       `json:"reason,omitempty"`
+	Message             string       `json:"message,omitempty"`
+	PendingDuration     string       `json:"pendingDuration"`
+	LastScheduleAttempt *metav1.Time `json:"lastScheduleAttempt,omitempty"`
+	WarningLevel        string       `json:"warningLevel,omitempty"`
+}
+
+type pendingPodsResponse struct {
+	Pods []pendingPod `json:"pods"`
+}
+
+type pendingPodsHandler struct {
+	kubeClient kubernetes.Interface
+	nowFn      func() time.Time
+	logger     log.Logger
+}
+
+var _ http.Handler = (*pendingPodsHandler)(nil)
+
+func newPendingPodsHandler(kubeClient kubernetes.Interface, logger log.Logger) *pendingPodsHandler {
+	return &pendingPodsHandler{
+		kubeClient: kubeClient,
+		nowFn:      time.Now,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the pending pods in a namespace
+// with the scheduler's explanation of why they haven't been scheduled. Pods
+// pending for more than five minutes are flagged.
+func (h *pendingPodsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("status.phase", string(corev1.PodPending)).String(),
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := pendingPodsResponse{
+		Pods: []pendingPod{},
+	}
+
+	now := h.nowFn()
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodPending {
+			continue
+		}
+
+		selector := fields.Set{
+			"involvedObject.kind": "Pod",
+			"involvedObject.name": pod.Name,
+		}.AsSelector().String()
+
+		events, err := h.kubeClient.CoreV1().Events(namespace).List(metav1.ListOptions{FieldSelector: selector})
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		pending := now.Sub(pod.CreationTimestamp.Time).Round(time.Second)
+
+		pp := pendingPod{
+			PodName:         pod.Name,
+			PendingDuration: pending.String(),
+		}
+
+		if pending > pendingWarningThreshold {
+			pp.WarningLevel = warningLevelHigh
+		}
+
+		if event := lastSchedulingEvent(pod, events.Items); event != nil {
+			timestamp := metav1.NewTime(eventTimestamp(*event))
+			pp.Reason = event.Reason
+			pp.Message = event.Message
+			pp.LastScheduleAttempt = &timestamp
+		} else {
+			// Fall back to the pod's own explanation, e.g. when events have
+			// expired.
+			for _, condition := range pod.Status.Conditions {
+				if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+					pp.Reason = condition.Reason
+					pp.Message = condition.Message
+				}
+			}
+		}
+
+		resp.Pods = append(resp.Pods, pp)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// lastSchedulingEvent returns the most recent failed scheduling event for a
+// pod. Events for earlier pods with the same name are ignored.
+func lastSchedulingEvent(pod *corev1.Pod, events []corev1.Event) *corev1.Event {
+	var last *corev1.Event
+	for i := range events {
+		event := &events[i]
+		if event.Reason != failedSchedulingReason || event.InvolvedObject.Name != pod.Name {
+			continue
+		}
+		if event.InvolvedObject.UID != "" && event.InvolvedObject.UID != pod.UID {
+			continue
+		}
+
+		if last == nil || eventTimestamp(*event).After(eventTimestamp(*last)) {
+			last = event
+		}
+	}
+
+	return last
+}
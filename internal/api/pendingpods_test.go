@@ -0,0 +1,98 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_pendingPodsHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	newPod := func(name string, uid types.UID, phase corev1.PodPhase, age time.Duration, conditions ...corev1.PodCondition) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				UID:               uid,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Status: corev1.PodStatus{Phase: phase, Conditions: conditions},
+		}
+	}
+
+	newEvent := func(name, pod string, uid types.UID, reason, message string, age time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: pod, UID: uid},
+			Reason:         reason,
+			Message:        message,
+			LastTimestamp:  metav1.NewTime(now.Add(-age)),
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newPod("db-0", "db", corev1.PodPending, 10*time.Minute),
+		newPod("web", "web", corev1.PodPending, 2*time.Minute, corev1.PodCondition{
+			Type:    corev1.PodScheduled,
+			Status:  corev1.ConditionFalse,
+			Reason:  "Unschedulable",
+			Message: "0/3 nodes are available: 3 node(s) had taints that the pod didn't tolerate.",
+		}),
+		newPod("running", "running", corev1.PodRunning, time.Hour),
+		newEvent("db-0.1", "db-0", "db", failedSchedulingReason, "0/3 nodes are available: 3 Insufficient memory.", 5*time.Minute),
+		newEvent("db-0.2", "db-0", "db", failedSchedulingReason, "0/3 nodes are available: 3 Insufficient cpu.", time.Minute),
+		newEvent("db-0.3", "db-0", "db-previous", failedSchedulingReason, "pod has unbound immediate PersistentVolumeClaims", 30*time.Second),
+		newEvent("db-0.4", "db-0", "db", "Scheduled", "assigned", 10*time.Second),
+	)
+
+	handler := newPendingPodsHandler(kubeClient, log.NopLogger())
+	handler.nowFn = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/pending/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got pendingPodsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.Len(t, got.Pods, 2)
+
+	db := got.Pods[0]
+	require.NotNil(t, db.LastScheduleAttempt)
+	assert.True(t, now.Add(-time.Minute).Equal(db.LastScheduleAttempt.Time))
+	db.LastScheduleAttempt = nil
+	assert.Equal(t, pendingPod{
+		PodName:         "db-0",
+		Reason:          failedSchedulingReason,
+		Message:         "0/3 nodes are available: 3 Insufficient cpu.",
+		PendingDuration: "10m0s",
+		WarningLevel:    warningLevelHigh,
+	}, db)
+
+	assert.Equal(t, pendingPod{
+		PodName:         "web",
+		Reason:          "Unschedulable",
+		Message:         "0/3 nodes are available: 3 node(s) had taints that the pod didn't tolerate.",
+		PendingDuration: "2m0s",
+	}, got.Pods[1])
+}
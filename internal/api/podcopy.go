@@ -0,0 +1,171 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// defaultMaxCopySize is the default limit for archives copied out of a pod.
+	defaultMaxCopySize = 100 << 20
+)
+
+var errCopyTooLarge = errors.New("archive exceeds maximum copy size")
+
+type podCopyRequest struct {
+	ContainerName string `json:"containerName"`
+	RemotePath    string `json:"remotePath"`
+}
+
+// podExecutorFactory creates an executor for running a command in a pod.
+type podExecutorFactory func(namespace, name string, options *corev1.PodExecOptions) (remotecommand.Executor, error)
+
+type podCopyHandler struct {
+	kubeClient  kubernetes.Interface
+	newExecutor podExecutorFactory
+	maxSize     int64
+	logger      log.Logger
+}
+
+var _ http.Handler = (*podCopyHandler)(nil)
+
+func newPodCopyHandler(kubeClient kubernetes.Interface, restConfig *rest.Config, maxSize int64, logger log.Logger) *podCopyHandler {
+	return &podCopyHandler{
+		kubeClient:  kubeClient,
+		newExecutor: newSPDYPodExecutorFactory(kubeClient, restConfig),
+		maxSize:     maxSize,
+		logger:      logger,
+	}
+}
+
+// newSPDYPodExecutorFactory creates a podExecutorFactory which runs commands
+// through the API server's pod exec subresource.
+func newSPDYPodExecutorFactory(kubeClient kubernetes.Interface, restConfig *rest.Config) podExecutorFactory {
+	return func(namespace, name string, options *corev1.PodExecOptions) (remotecommand.Executor, error) {
+		req := kubeClient.CoreV1().RESTClient().Post().
+			Resource("pods").
+			Namespace(namespace).
+			Name(name).
+			SubResource("exec").
+			VersionedParams(options, scheme.ParameterCodec)
+
+		return remotecommand.NewSPDYExecutor(restConfig, http.MethodPost, req.URL())
+	}
+}
+
+// ServeHTTP implements http.Handler and copies a path out of a container as
+// a tar archive. This mirrors `kubectl cp`.
+func (h *podCopyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req podCopyRequest
+
+	defer func() {
+		if cErr := r.Body.Close(); cErr != nil {
+			h.logger.WithErr(cErr).Errorf("unable to close copy request body")
+		}
+	}()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
+	if req.ContainerName == "" || req.RemotePath == "" {
+		RespondWithError(w, http.StatusBadRequest, "containerName and remotePath are required", h.logger)
+		return
+	}
+
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	name := vars["pod"]
+
+	if _, err := h.kubeClient.CoreV1().Pods(namespace).Get(name, metav1.GetOptions{}); err != nil {
+		if kerrors.IsNotFound(err) {
+			RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	remotePath := path.Clean(req.RemotePath)
+
+	executor, err := h.newExecutor(namespace, name, &corev1.PodExecOptions{
+		Container: req.ContainerName,
+		Command:   []string{"tar", "cf", "-", "-C", path.Dir(remotePath), path.Base(remotePath)},
+		Stdout:    true,
+		Stderr:    true,
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	// The archive is buffered so the size limit can be enforced before any
+	// of the response is written.
+	archive := &cappedBuffer{max: h.maxSize}
+	var stderr bytes.Buffer
+
+	err = executor.Stream(remotecommand.StreamOptions{
+		Stdout: archive,
+		Stderr: &stderr,
+	})
+	if archive.exceeded {
+		RespondWithError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("%s exceeds the maximum copy size of %d bytes", req.RemotePath, h.maxSize), h.logger)
+		return
+	}
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		RespondWithError(w, http.StatusInternalServerError, message, h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(remotePath)+".tar"))
+	w.Header().Set("Content-Length", strconv.Itoa(archive.buf.Len()))
+
+	if _, err := archive.buf.WriteTo(w); err != nil {
+		h.logger.WithErr(err).Errorf("writing pod copy archive")
+	}
+}
+
+// cappedBuffer is a buffer which refuses writes beyond a maximum size.
+type cappedBuffer struct {
+	buf      bytes.Buffer
+	max      int64
+	exceeded bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if int64(b.buf.Len()+len(p)) > b.max {
+		b.exceeded = true
+		return 0, errCopyTooLarge
+	}
+
+	return b.buf.Write(p)
+}
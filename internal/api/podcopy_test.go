@@ -0,0 +1,99 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type fakeExecutor struct {
+	stdout []byte
+	err    error
+}
+
+func (e *fakeExecutor) Stream(options remotecommand.StreamOptions) error {
+	if _, err := options.Stdout.Write(e.stdout); err != nil {
+		return err
+	}
+	return e.err
+}
+
+func Test_podCopyHandler(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+	}
+
+	tests := []struct {
+		name         string
+		body         string
+		archive      string
+		maxSize      int64
+		expectedCode int
+	}{
+		{
+			name:         "copy file",
+			body:         `{"containerName":"app","remotePath":"/var/log/app.log"}`,
+			archive:      "archive",
+			maxSize:      1024,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "archive too large",
+			body:         `{"containerName":"app","remotePath":"/var/log/app.log"}`,
+			archive:      "archive",
+			maxSize:      4,
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:         "missing remote path",
+			body:         `{"containerName":"app"}`,
+			maxSize:      1024,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(pod)
+
+			var gotCommand []string
+			handler := newPodCopyHandler(kubeClient, nil, tc.maxSize, log.NopLogger())
+			handler.newExecutor = func(namespace, name string, options *corev1.PodExecOptions) (remotecommand.Executor, error) {
+				gotCommand = options.Command
+				return &fakeExecutor{stdout: []byte(tc.archive)}, nil
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/pods/default/pod/copy", strings.NewReader(tc.body))
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default", "pod": "pod"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, []string{"tar", "cf", "-", "-C", "/var/log", "app.log"}, gotCommand)
+			assert.Equal(t, "application/octet-stream", resp.Header().Get("Content-Type"))
+			assert.Equal(t, `attachment; filename="app.log.tar"`, resp.Header().Get("Content-Disposition"))
+			assert.Equal(t, tc.archive, resp.Body.String())
+		})
+	}
+}
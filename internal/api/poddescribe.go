@@ -0,0 +1,249 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.opencensus.io/trace"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type podDescribeContainer struct {
+	Name         string                 `json:"name"`
+	Image        string                 `json:"image"`
+	Ports        []corev1.ContainerPort `json:"ports,omitempty"`
+	State        string                 `json:"state"`
+	Reason       string                 `json:"reason,omitempty"`
+	Ready        bool                   `json:"ready"`
+	RestartCount int32                  `json:"restartCount"`
+	Requests     corev1.ResourceList    `json:"requests,omitempty"`
+	Limits       corev1.ResourceList    `json:"limits,omitempty"`
+}
+
+type podDescribeCondition struct {
+	Type               corev1.PodConditionType `json:"type"`
+	Status             corev1.ConditionStatus  `json:"status"`
+	Reason             string                  `json:"reason,omitempty"`
+	Message            string                  `json:"message,omitempty"`
+	LastTransitionTime metav1.Time             `json:"lastTransitionTime,omitempty"`
+}
+
+type podDescribeVolume struct {
+	Name   string              `json:"name"`
+	Type   string              `json:"type"`
+	Source corev1.VolumeSource `json:"source"`
+}
+
+type podDescribeEvent struct {
+	Type           string      `json:"type"`
+	Reason         string      `json:"reason"`
+	Message        string      `json:"message"`
+	Source         string      `json:"source,omitempty"`
+	Count          int32       `json:"count"`
+	FirstTimestamp metav1.Time `json:"firstTimestamp,omitempty"`
+	LastTimestamp  metav1.Time `json:"lastTimestamp,omitempty"`
+}
+
+type podDescribeResponse struct {
+	Name           string                 `json:"name"`
+	Namespace      string                 `json:"namespace"`
+	Node           string                 `json:"node,omitempty"`
+	StartTime      *metav1.Time           `json:"startTime,omitempty"`
+	Labels         map[string]string      `json:"labels,omitempty"`
+	Annotations    map[string]string      `json:"annotations,omitempty"`
+	Phase          corev1.PodPhase        `json:"phase"`
+	Reason         string                 `json:"reason,omitempty"`
+	Message        string                 `json:"message,omitempty"`
+	PodIP          string                 `json:"podIP,omitempty"`
+	QOSClass       corev1.PodQOSClass     `json:"qosClass,omitempty"`
+	ServiceAccount string                 `json:"serviceAccount,omitempty"`
+	NodeSelector   map[string]string      `json:"nodeSelector,omitempty"`
+	Tolerations    []corev1.Toleration    `json:"tolerations,omitempty"`
+	InitContainers []podDescribeContainer `json:"initContainers,omitempty"`
+	Containers     []podDescribeContainer `json:"containers"`
+	Conditions     []podDescribeCondition `json:"conditions,omitempty"`
+	Volumes        []podDescribeVolume    `json:"volumes,omitempty"`
+	Events         []podDescribeEvent     `json:"events"`
+}
+
+type podDescribeHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*podDescribeHandler)(nil)
+
+func newPodDescribeHandler(kubeClient kubernetes.Interface, logger log.Logger) *podDescribeHandler {
+	return &podDescribeHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns a structured summary of a pod
+// similar to the output of `kubectl describe pod`.
+func (h *podDescribeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_, span := trace.StartSpan(r.Context(), "api:podDescribeHandler")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	name := vars["pod"]
+
+	pod, err := h.kubeClient.CoreV1().Pods(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	selector := fields.Set{
+		"involvedObject.kind":      "Pod",
+		"involvedObject.name":      pod.Name,
+		"involvedObject.namespace": pod.Namespace,
+		"involvedObject.uid":       string(pod.UID),
+	}.AsSelector().String()
+
+	events, err := h.kubeClient.CoreV1().Events(namespace).List(metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	serveAsJSON(w, describePod(pod, events.Items), h.logger)
+}
+
+func describePod(pod *corev1.Pod, events []corev1.Event) podDescribeResponse {
+	resp := podDescribeResponse{
+		Name:           pod.Name,
+		Namespace:      pod.Namespace,
+		Node:           pod.Spec.NodeName,
+		StartTime:      pod.Status.StartTime,
+		Labels:         pod.Labels,
+		Annotations:    pod.Annotations,
+		Phase:          pod.Status.Phase,
+		Reason:         pod.Status.Reason,
+		Message:        pod.Status.Message,
+		PodIP:          pod.Status.PodIP,
+		QOSClass:       pod.Status.QOSClass,
+		ServiceAccount: pod.Spec.ServiceAccountName,
+		NodeSelector:   pod.Spec.NodeSelector,
+		Tolerations:    pod.Spec.Tolerations,
+		InitContainers: describeContainers(pod.Spec.InitContainers, pod.Status.InitContainerStatuses),
+		Containers:     describeContainers(pod.Spec.Containers, pod.Status.ContainerStatuses),
+		Events:         []podDescribeEvent{},
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		resp.Conditions = append(resp.Conditions, podDescribeCondition{
+			Type:               condition.Type,
+			Status:             condition.Status,
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: condition.LastTransitionTime,
+		})
+	}
+
+	for _, volume := range pod.Spec.Volumes {
+		resp.Volumes = append(resp.Volumes, podDescribeVolume{
+			Name:   volume.Name,
+			Type:   volumeSourceType(volume.VolumeSource),
+			Source: volume.VolumeSource,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].LastTimestamp.Before(&events[j].LastTimestamp)
+	})
+
+	for _, event := range events {
+		resp.Events = append(resp.Events, podDescribeEvent{
+			Type:           event.Type,
+			Reason:         event.Reason,
+			Message:        event.Message,
+			Source:         event.Source.Component,
+			Count:          event.Count,
+			FirstTimestamp: event.FirstTimestamp,
+			LastTimestamp:  event.LastTimestamp,
+		})
+	}
+
+	return resp
+}
+
+func describeContainers(containers []corev1.Container, statuses []corev1.ContainerStatus) []podDescribeContainer {
+	statusByName := make(map[string]corev1.ContainerStatus)
+	for _, status := range statuses {
+		statusByName[status.Name] = status
+	}
+
+	var list []podDescribeContainer
+	for _, container := range containers {
+		dc := podDescribeContainer{
+			Name:     container.Name,
+			Image:    container.Image,
+			Ports:    container.Ports,
+			State:    "Waiting",
+			Requests: container.Resources.Requests,
+			Limits:   container.Resources.Limits,
+		}
+
+		if status, ok := statusByName[container.Name]; ok {
+			dc.Ready = status.Ready
+			dc.RestartCount = status.RestartCount
+			dc.State, dc.Reason = containerState(status.State)
+		}
+
+		list = append(list, dc)
+	}
+
+	return list
+}
+
+// containerState returns the name of a container's current state and the
+// reason for it, if one is available.
+func containerState(state corev1.ContainerState) (string, string) {
+	switch {
+	case state.Running != nil:
+		return "Running", ""
+	case state.Terminated != nil:
+		return "Terminated", state.Terminated.Reason
+	case state.Waiting != nil:
+		return "Waiting", state.Waiting.Reason
+	default:
+		return "Waiting", ""
+	}
+}
+
+// volumeSourceType returns the JSON name of the populated field in a volume
+// source, e.g. `configMap` or `persistentVolumeClaim`.
+func volumeSourceType(source corev1.VolumeSource) string {
+	v := reflect.ValueOf(source)
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsNil() {
+			continue
+		}
+
+		tag := v.Type().Field(i).Tag.Get("json")
+		return strings.Split(tag, ",")[0]
+	}
+
+	return "unknown"
+}
@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_podDescribeHandler(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default", UID: "uid"},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+			Containers: []corev1.Container{
+				{
+					Name:  "app",
+					Image: "nginx",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name:         "config",
+					VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:         "app",
+					Ready:        true,
+					RestartCount: 2,
+					State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				},
+			},
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{Name: "event", Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{
+			Kind: "Pod", Name: "pod", Namespace: "default", UID: "uid",
+		},
+		Type:   corev1.EventTypeNormal,
+		Reason: "Scheduled",
+		Count:  1,
+	}
+
+	tests := []struct {
+		name         string
+		objects      []runtime.Object
+		pod          string
+		expectedCode int
+	}{
+		{
+			name:         "pod exists",
+			objects:      []runtime.Object{pod, event},
+			pod:          "pod",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "pod does not exist",
+			pod:          "missing",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(tc.objects...)
+
+			handler := newPodDescribeHandler(kubeClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/pods/default/"+tc.pod+"/describe", nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default", "pod": tc.pod})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var got podDescribeResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+			assert.Equal(t, "node-1", got.Node)
+			require.Len(t, got.Containers, 1)
+			assert.Equal(t, "Running", got.Containers[0].State)
+			assert.Equal(t, int32(2), got.Containers[0].RestartCount)
+			assert.Equal(t, "100m", got.Containers[0].Requests.Cpu().String())
+			require.Len(t, got.Volumes, 1)
+			assert.Equal(t, "configMap", got.Volumes[0].Type)
+			require.Len(t, got.Events, 1)
+			assert.Equal(t, "Scheduled", got.Events[0].Reason)
+		})
+	}
+}
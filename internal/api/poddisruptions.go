@@ -0,0 +1,389 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubernetes/staging/src/k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// podDisruptionWindow is how far back disruptions are reported.
+	podDisruptionWindow = time.Hour
+
+	disruptionVoluntary   = "voluntary"
+	disruptionInvoluntary = "involuntary"
+
+	disruptionScaleDown     = "ScaleDown"
+	disruptionRollingUpdate = "RollingUpdate"
+	disruptionDeleted       = "Deleted"
+	disruptionEvictionAPI   = "EvictionAPI"
+	disruptionLivenessProbe = "LivenessProbeFailed"
+	disruptionOOMKilled     = "OOMKilled"
+	disruptionEvicted       = "Evicted"
+	disruptionPreempted     = "Preempted"
+	disruptionNodeFailure   = "NodeFailure"
+
+	// disruptionTargetCondition is the pod condition set when a pod is about
+	// to be deleted because of a disruption.
+	disruptionTargetCondition = "DisruptionTarget"
+)
+
+type podDisruption struct {
+	Pod             string    `json:"pod"`
+	Container       string    `json:"container,omitempty"`
+	DisruptionType  string    `json:"disruptionType"`
+	Reason          string    `json:"reason"`
+	Message         string    `json:"message,omitempty"`
+	Timestamp       time.Time `json:"timestamp"`
+	Duration        string    `json:"duration"`
+	DurationSeconds int64     `json:"durationSeconds"`
+	Recovered       bool      `json:"recovered"`
+}
+
+type podDisruptionsResponse struct {
+	Disruptions []podDisruption `json:"disruptions"`
+}
+
+type podDisruptionsHandler struct {
+	kubeClient kubernetes.Interface
+	nowFn      func() time.Time
+	logger     log.Logger
+}
+
+var _ http.Handler = (*podDisruptionsHandler)(nil)
+
+func newPodDisruptionsHandler(kubeClient kubernetes.Interface, logger log.Logger) *podDisruptionsHandler {
+	return &podDisruptionsHandler{
+		kubeClient: kubeClient,
+		nowFn:      time.Now,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the pods in a namespace which
+// were disrupted in the last hour, most recent first. Disruptions come from
+// Killing, Evicted and Preempted events, the pods' DisruptionTarget
+// conditions and containers which were OOM killed. Pods killed because they
+// were scaled down, replaced by a rolling update, deleted or evicted through
+// the eviction API are voluntary disruptions. The duration is how long the pod
+// was unavailable, up to now for pods which have not recovered or no longer
+// exist.
+func (h *podDisruptionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	events, err := h.kubeClient.CoreV1().Events(namespace).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.kind", "Pod").String(),
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	replicaSets, err := h.kubeClient.AppsV1().ReplicaSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	deployments, err := h.kubeClient.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	now := h.nowFn()
+	since := now.Add(-podDisruptionWindow)
+
+	podsByName := make(map[string]*corev1.Pod)
+	for i := range pods.Items {
+		podsByName[pods.Items[i].Name] = &pods.Items[i]
+	}
+
+	classifier := newDisruptionClassifier(replicaSets.Items, deployments.Items)
+
+	resp := podDisruptionsResponse{
+		Disruptions: []podDisruption{},
+	}
+
+	type disruptionKey struct {
+		pod    string
+		reason string
+	}
+	seen := make(map[disruptionKey]bool)
+	disrupted := make(map[string]bool)
+
+	add := func(disruption podDisruption, pod *corev1.Pod) {
+		key := disruptionKey{pod: disruption.Pod, reason: disruption.Reason}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		disrupted[disruption.Pod] = true
+
+		recovered := podRecoveredAt(pod, disruption.Timestamp)
+		setDisruptionDuration(&disruption, recovered, now)
+		resp.Disruptions = append(resp.Disruptions, disruption)
+	}
+
+	for _, event := range events.Items {
+		if event.InvolvedObject.Kind != "Pod" {
+			continue
+		}
+
+		timestamp := eventTimestamp(event)
+		if timestamp.Before(since) {
+			continue
+		}
+
+		pod := podsByName[event.InvolvedObject.Name]
+
+		disruptionType, reason, ok := classifier.classifyEvent(event, pod)
+		if !ok {
+			continue
+		}
+
+		add(podDisruption{
+			Pod:            event.InvolvedObject.Name,
+			DisruptionType: disruptionType,
+			Reason:         reason,
+			Message:        event.Message,
+			Timestamp:      timestamp.UTC(),
+		}, pod)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		// Events describe a disruption better than the condition it sets.
+		if !disrupted[pod.Name] {
+			for _, disruption := range podConditionDisruptions(pod, since) {
+				add(disruption, pod)
+			}
+		}
+
+		resp.Disruptions = append(resp.Disruptions, oomKilledDisruptions(pod, since, now)...)
+	}
+
+	sort.SliceStable(resp.Disruptions, func(i, j int) bool {
+		return resp.Disruptions[i].Timestamp.After(resp.Disruptions[j].Timestamp)
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// disruptionClassifier works out why pods owned by deployments were killed.
+type disruptionClassifier struct {
+	replicaSets map[string]*appsv1.ReplicaSet
+	deployments map[string]*appsv1.Deployment
+}
+
+func newDisruptionClassifier(replicaSets []appsv1.ReplicaSet, deployments []appsv1.Deployment) *disruptionClassifier {
+	c := &disruptionClassifier{
+		replicaSets: make(map[string]*appsv1.ReplicaSet),
+		deployments: make(map[string]*appsv1.Deployment),
+	}
+
+	for i := range replicaSets {
+		c.replicaSets[replicaSets[i].Name] = &replicaSets[i]
+	}
+	for i := range deployments {
+		c.deployments[deployments[i].Name] = &deployments[i]
+	}
+
+	return c
+}
+
+// classifyEvent returns the disruption type and reason of a pod event. It
+// returns false for events which are not disruptions.
+func (c *disruptionClassifier) classifyEvent(event corev1.Event, pod *corev1.Pod) (string, string, bool) {
+	switch event.Reason {
+	case "Evicted":
+		return disruptionInvoluntary, disruptionEvicted, true
+	case "Preempted":
+		return disruptionInvoluntary, disruptionPreempted, true
+	case "Killing":
+		message := strings.ToLower(event.Message)
+		if strings.Contains(message, "failed liveness probe") || strings.Contains(message, "failed startup probe") {
+			return disruptionInvoluntary, disruptionLivenessProbe, true
+		}
+		return disruptionVoluntary, c.killingReason(event.InvolvedObject.Name, pod), true
+	default:
+		return "", "", false
+	}
+}
+
+// killingReason tells whether a killed pod was removed by a rolling update or
+// a scale down of its deployment. A pod whose ReplicaSet is not the current
+// revision of its deployment was replaced by a rolling update. Pods which no
+// longer exist are matched to their ReplicaSet by name.
+func (c *disruptionClassifier) killingReason(name string, pod *corev1.Pod) string {
+	var replicaSet *appsv1.ReplicaSet
+	if pod != nil {
+		if controller := metav1.GetControllerOf(pod); controller != nil && controller.Kind == "ReplicaSet" {
+			replicaSet = c.replicaSets[controller.Name]
+		}
+	} else if i := strings.LastIndex(name, "-"); i > 0 {
+		replicaSet = c.replicaSets[name[:i]]
+	}
+
+	if replicaSet == nil {
+		return disruptionDeleted
+	}
+
+	owner := metav1.GetControllerOf(replicaSet)
+	if owner == nil || owner.Kind != "Deployment" {
+		return disruptionScaleDown
+	}
+
+	deployment, ok := c.deployments[owner.Name]
+	if ok && replicaSet.Annotations[deploymentRevisionAnnotation] != deployment.Annotations[deploymentRevisionAnnotation] {
+		return disruptionRollingUpdate
+	}
+
+	return disruptionScaleDown
+}
+
+// podConditionDisruptions returns the disruptions recorded in a pod's status:
+// a DisruptionTarget condition, or a pod lost with its node.
+func podConditionDisruptions(pod *corev1.Pod, since time.Time) []podDisruption {
+	var disruptions []podDisruption
+
+	for _, condition := range pod.Status.Conditions {
+		if string(condition.Type) != disruptionTargetCondition || condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		if condition.LastTransitionTime.Time.Before(since) {
+			continue
+		}
+
+		disruption := podDisruption{
+			Pod:            pod.Name,
+			DisruptionType: disruptionInvoluntary,
+			Message:        condition.Message,
+			Timestamp:      condition.LastTransitionTime.Time.UTC(),
+		}
+
+		switch condition.Reason {
+		case "EvictionByEvictionAPI":
+			disruption.DisruptionType = disruptionVoluntary
+			disruption.Reason = disruptionEvictionAPI
+		case "PreemptionByScheduler", "PreemptionByKubeScheduler":
+			disruption.Reason = disruptionPreempted
+		case "DeletionByTaintManager", "DeletionByPodGC":
+			disruption.Reason = disruptionNodeFailure
+		default:
+			disruption.Reason = disruptionEvicted
+		}
+
+		disruptions = append(disruptions, disruption)
+	}
+
+	if pod.Status.Reason == "NodeLost" {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type != corev1.PodReady || condition.LastTransitionTime.Time.Before(since) {
+				continue
+			}
+
+			disruptions = append(disruptions, podDisruption{
+				Pod:            pod.Name,
+				DisruptionType: disruptionInvoluntary,
+				Reason:         disruptionNodeFailure,
+				Message:        pod.Status.Message,
+				Timestamp:      condition.LastTransitionTime.Time.UTC(),
+			})
+		}
+	}
+
+	return disruptions
+}
+
+// oomKilledDisruptions returns the containers in a pod whose last run was OOM
+// killed. A container recovers when it starts running again.
+func oomKilledDisruptions(pod *corev1.Pod, since, now time.Time) []podDisruption {
+	var disruptions []podDisruption
+
+	for _, status := range pod.Status.ContainerStatuses {
+		terminated := status.LastTerminationState.Terminated
+		if terminated == nil || terminated.Reason != disruptionOOMKilled {
+			continue
+		}
+		if terminated.FinishedAt.Time.Before(since) {
+			continue
+		}
+
+		disruption := podDisruption{
+			Pod:            pod.Name,
+			Container:      status.Name,
+			DisruptionType: disruptionInvoluntary,
+			Reason:         disruptionOOMKilled,
+			Timestamp:      terminated.FinishedAt.Time.UTC(),
+		}
+
+		var recovered time.Time
+		if running := status.State.Running; running != nil && !running.StartedAt.Time.Before(terminated.FinishedAt.Time) {
+			recovered = running.StartedAt.Time
+		}
+		setDisruptionDuration(&disruption, recovered, now)
+
+		disruptions = append(disruptions, disruption)
+	}
+
+	return disruptions
+}
+
+// podRecoveredAt returns when a pod became ready after a disruption, or zero
+// if it is gone or has not become ready since.
+func podRecoveredAt(pod *corev1.Pod, disrupted time.Time) time.Time {
+	if pod == nil || pod.DeletionTimestamp != nil {
+		return time.Time{}
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue &&
+			condition.LastTransitionTime.Time.After(disrupted) {
+			return condition.LastTransitionTime.Time
+		}
+	}
+
+	return time.Time{}
+}
+
+// setDisruptionDuration sets how long a disruption lasted, up to now when it
+// has not recovered.
+func setDisruptionDuration(disruption *podDisruption, recovered, now time.Time) {
+	end := now
+	if !recovered.IsZero() {
+		end = recovered
+		disruption.Recovered = true
+	}
+
+	elapsed := end.Sub(disruption.Timestamp)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	disruption.Duration = duration.ShortHumanDuration(elapsed)
+	disruption.DurationSeconds = int64(elapsed / time.Second)
+}
@@ -0,0 +1,135 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_podDisruptionsHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	controller := true
+
+	event := func(name, pod, reason, message string, at time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: pod},
+			Reason:         reason,
+			Message:        message,
+			LastTimestamp:  metav1.NewTime(at),
+		}
+	}
+
+	replicaSet := func(name, revision string) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Annotations: map[string]string{deploymentRevisionAnnotation: revision},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Deployment", Name: "web", Controller: &controller},
+				},
+			},
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "web",
+				Namespace:   "default",
+				Annotations: map[string]string{deploymentRevisionAnnotation: "2"},
+			},
+		},
+		replicaSet("web-old", "1"),
+		replicaSet("web-new", "2"),
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute))},
+				},
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name: "app",
+						State: corev1.ContainerState{
+							Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(now.Add(-19 * time.Minute))},
+						},
+						LastTerminationState: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(now.Add(-20 * time.Minute))},
+						},
+					},
+				},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "drained", Namespace: "default"},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{
+					{
+						Type:               disruptionTargetCondition,
+						Status:             corev1.ConditionTrue,
+						Reason:             "EvictionByEvictionAPI",
+						LastTransitionTime: metav1.NewTime(now.Add(-5 * time.Minute)),
+					},
+				},
+			},
+		},
+		event("rollout", "web-old-abcde", "Killing", "Stopping container app", now.Add(-2*time.Minute)),
+		event("scale", "web-new-fghij", "Killing", "Stopping container app", now.Add(-3*time.Minute)),
+		event("probe", "api", "Killing", "Container app failed liveness probe, will be restarted", now.Add(-30*time.Minute)),
+		event("evicted", "batch", "Evicted", "The node was low on resource: memory.", now.Add(-40*time.Minute)),
+		event("old", "batch", "Preempted", "Preempted by a higher priority pod", now.Add(-2*time.Hour)),
+		event("scheduled", "batch", "Scheduled", "Successfully assigned", now.Add(-time.Minute)),
+	)
+
+	handler := newPodDisruptionsHandler(kubeClient, log.NopLogger())
+	handler.nowFn = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/poddisruptions/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp podDisruptionsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+	type summary struct {
+		pod, container, disruptionType, reason, duration string
+		recovered                                        bool
+	}
+
+	var got []summary
+	for _, d := range resp.Disruptions {
+		got = append(got, summary{d.Pod, d.Container, d.DisruptionType, d.Reason, d.Duration, d.Recovered})
+	}
+
+	expected := []summary{
+		{"web-old-abcde", "", "voluntary", "RollingUpdate", "2m", false},
+		{"web-new-fghij", "", "voluntary", "ScaleDown", "3m", false},
+		{"drained", "", "voluntary", "EvictionAPI", "5m", false},
+		{"api", "app", "involuntary", "OOMKilled", "1m", true},
+		{"api", "", "involuntary", "LivenessProbeFailed", "20m", true},
+		{"batch", "", "involuntary", "Evicted", "40m", false},
+	}
+	assert.Equal(t, expected, got)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// podPresetsRemovedMessage is returned when the cluster does not serve PodPresets.
+	podPresetsRemovedMessage = "PodPresets were removed in Kubernetes 1.20 and are not served by this cluster. " +
+		"Use a mutating admission webhook, a policy engine such as Kyverno or Gatekeeper, " +
+		"or templating with Helm or Kustomize to inject common pod configuration."
+
+	// podPresetsDeprecationNotice is included in responses from clusters which
+	// still serve PodPresets.
+	podPresetsDeprecationNotice = "PodPresets are an alpha API which was removed in Kubernetes 1.20."
+)
+
+var (
+	podPresetGVR = schema.GroupVersionResource{
+		Group:    "settings.k8s.io",
+		Version:  "v1alpha1",
+		Resource: "podpresets",
+	}
+
+	podPresetsRemovedIn = version.MustParseGeneric("1.20")
+)
+
+type podPresetsResponse struct {
+	DeprecationNotice string                   `json:"deprecationNotice"`
+	PodPresets        []map[string]interface{} `json:"podPresets"`
+}
+
+type podPresetHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*podPresetHandler)(nil)
+
+func newPodPresetHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *podPresetHandler {
+	return &podPresetHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the PodPresets in a namespace.
+// It responds with 410 Gone on clusters which no longer serve PodPresets.
+func (h *podPresetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	serverVersion, err := h.serverVersion()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	if serverVersion.AtLeast(podPresetsRemovedIn) {
+		RespondWithError(w, http.StatusGone, podPresetsRemovedMessage, h.logger)
+		return
+	}
+
+	podPresets, installed, err := listOptionalResource(h.dynamicClient, podPresetGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	if !installed {
+		RespondWithError(w, http.StatusGone, podPresetsRemovedMessage, h.logger)
+		return
+	}
+
+	resp := podPresetsResponse{
+		DeprecationNotice: podPresetsDeprecationNotice,
+		PodPresets:        []map[string]interface{}{},
+	}
+
+	for _, podPreset := range podPresets.Items {
+		resp.PodPresets = append(resp.PodPresets, podPreset.Object)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func (h *podPresetHandler) serverVersion() (*version.Version, error) {
+	info, err := h.kubeClient.Discovery().ServerVersion()
+	if err != nil {
+		return nil, errors.Wrap(err, "retrieve server version")
+	}
+
+	v, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse server version %q", info.GitVersion)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeversion "k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_podPresetHandler(t *testing.T) {
+	podPreset := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "settings.k8s.io/v1alpha1",
+		"kind":       "PodPreset",
+		"metadata":   map[string]interface{}{"name": "proxy", "namespace": "default"},
+	}}
+
+	tests := []struct {
+		name          string
+		serverVersion string
+		missingAPI    bool
+		expectedCode  int
+	}{
+		{
+			name:          "older cluster",
+			serverVersion: "v1.15.3",
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "removed in cluster version",
+			serverVersion: "v1.20.0-gke.1",
+			expectedCode:  http.StatusGone,
+		},
+		{
+			name:          "api group not enabled",
+			serverVersion: "v1.15.3",
+			missingAPI:    true,
+			expectedCode:  http.StatusGone,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &kubeversion.Info{GitVersion: tc.serverVersion}
+
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), podPreset)
+			if tc.missingAPI {
+				dynamicClient.PrependReactor("list", "podpresets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+				})
+			}
+
+			handler := newPodPresetHandler(kubeClient, dynamicClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/podpresets/default", nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				assert.Contains(t, resp.Body.String(), "PodPresets were removed")
+				return
+			}
+
+			var got podPresetsResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, podPresetsDeprecationNotice, got.DeprecationNotice)
+			assert.Len(t, got.PodPresets, 1)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	zoneLabel           = "topology.kubernetes.io/zone"
+	zoneLabelDeprecated = "failure-domain.beta.kubernetes.io/zone"
+	hostnameLabel       = "kubernetes.io/hostname"
+
+	// unknownZone is used for nodes without a zone label.
+	unknownZone = "unknown"
+)
+
+type workloadTopology struct {
+	Workload   workloadRef    `json:"workload"`
+	Replicas   int            `json:"replicas"`
+	Zones      map[string]int `json:"zones"`
+	Nodes      map[string]int `json:"nodes"`
+	SingleZone bool           `json:"singleZone"`
+}
+
+type podTopologyResponse struct {
+	Zones     []string           `json:"zones"`
+	Workloads []workloadTopology `json:"workloads"`
+}
+
+type podTopologyHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*podTopologyHandler)(nil)
+
+func newPodTopologyHandler(kubeClient kubernetes.Interface, logger log.Logger) *podTopologyHandler {
+	return &podTopologyHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns how the scheduled pods of each
+// deployment and stateful set in a namespace are spread across zones and nodes.
+func (h *podTopologyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	replicaSets, err := h.kubeClient.AppsV1().ReplicaSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	nodes, err := h.kubeClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	nodeZones := make(map[string]string)
+	nodeHostnames := make(map[string]string)
+	for _, node := range nodes.Items {
+		nodeZones[node.Name] = nodeZone(node.Labels)
+
+		hostname := node.Labels[hostnameLabel]
+		if hostname == "" {
+			hostname = node.Name
+		}
+		nodeHostnames[node.Name] = hostname
+	}
+
+	workloads := make(map[workloadRef]*workloadTopology)
+	zones := make(map[string]bool)
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+
+		ref := podWorkload(pod, replicaSets.Items)
+		if ref.Kind != "Deployment" && ref.Kind != "StatefulSet" {
+			continue
+		}
+
+		topology, ok := workloads[ref]
+		if !ok {
+			topology = &workloadTopology{
+				Workload: ref,
+				Zones:    make(map[string]int),
+				Nodes:    make(map[string]int),
+			}
+			workloads[ref] = topology
+		}
+
+		zone, ok := nodeZones[pod.Spec.NodeName]
+		if !ok {
+			zone = unknownZone
+		}
+		hostname, ok := nodeHostnames[pod.Spec.NodeName]
+		if !ok {
+			hostname = pod.Spec.NodeName
+		}
+
+		topology.Replicas++
+		topology.Zones[zone]++
+		topology.Nodes[hostname]++
+		zones[zone] = true
+	}
+
+	resp := podTopologyResponse{
+		Zones:     []string{},
+		Workloads: []workloadTopology{},
+	}
+
+	for zone := range zones {
+		resp.Zones = append(resp.Zones, zone)
+	}
+	sort.Strings(resp.Zones)
+
+	for _, topology := range workloads {
+		topology.SingleZone = topology.Replicas > 1 && len(topology.Zones) == 1
+		resp.Workloads = append(resp.Workloads, *topology)
+	}
+	sort.Slice(resp.Workloads, func(i, j int) bool {
+		a, b := resp.Workloads[i].Workload, resp.Workloads[j].Workload
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// nodeZone returns a node's zone. Older clusters only set the beta zone label.
+func nodeZone(labels map[string]string) string {
+	if zone := labels[zoneLabel]; zone != "" {
+		return zone
+	}
+	if zone := labels[zoneLabelDeprecated]; zone != "" {
+		return zone
+	}
+
+	return unknownZone
+}
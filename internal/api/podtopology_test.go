@@ -0,0 +1,86 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_podTopologyHandler(t *testing.T) {
+	newNode := func(name string, labels map[string]string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	newPod := func(name, nodeName string, ownerReferences []metav1.OwnerReference) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", OwnerReferences: ownerReferences},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newNode("node-a", map[string]string{zoneLabel: "us-east-1a", hostnameLabel: "host-a"}),
+		newNode("node-b", map[string]string{zoneLabelDeprecated: "us-east-1b", hostnameLabel: "host-b"}),
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "web-1", Namespace: "default", UID: "rs",
+				OwnerReferences: controllerRef("Deployment", "web", "deployment"),
+			},
+		},
+		newPod("web-1-a", "node-a", controllerRef("ReplicaSet", "web-1", "rs")),
+		newPod("web-1-b", "node-b", controllerRef("ReplicaSet", "web-1", "rs")),
+		newPod("db-0", "node-a", controllerRef("StatefulSet", "db", "sts")),
+		newPod("db-1", "node-a", controllerRef("StatefulSet", "db", "sts")),
+		newPod("db-2", "", controllerRef("StatefulSet", "db", "sts")),
+		newPod("standalone", "node-b", nil),
+	)
+
+	handler := newPodTopologyHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/podtopology/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got podTopologyResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := podTopologyResponse{
+		Zones: []string{"us-east-1a", "us-east-1b"},
+		Workloads: []workloadTopology{
+			{
+				Workload:   workloadRef{Kind: "Deployment", Name: "web"},
+				Replicas:   2,
+				Zones:      map[string]int{"us-east-1a": 1, "us-east-1b": 1},
+				Nodes:      map[string]int{"host-a": 1, "host-b": 1},
+				SingleZone: false,
+			},
+			{
+				Workload:   workloadRef{Kind: "StatefulSet", Name: "db"},
+				Replicas:   2,
+				Zones:      map[string]int{"us-east-1a": 2},
+				Nodes:      map[string]int{"host-a": 2},
+				SingleZone: true,
+			},
+		},
+	}
+	assert.Equal(t, expected, got)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type priorityClassStatus struct {
+	Name             string                  `json:"name"`
+	Value            int32                   `json:"value"`
+	GlobalDefault    bool                    `json:"globalDefault"`
+	PreemptionPolicy corev1.PreemptionPolicy `json:"preemptionPolicy"`
+	Description      string                  `json:"description,omitempty"`
+	PodCount         int                     `json:"podCount"`
+}
+
+type priorityClassesResponse struct {
+	PriorityClasses []priorityClassStatus `json:"priorityClasses"`
+	// UnclassifiedPodCount is the number of pods without a priority class.
+	UnclassifiedPodCount int `json:"unclassifiedPodCount"`
+}
+
+type priorityClassesHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*priorityClassesHandler)(nil)
+
+func newPriorityClassesHandler(kubeClient kubernetes.Interface, logger log.Logger) *priorityClassesHandler {
+	return &priorityClassesHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the cluster's PriorityClasses,
+// highest value first, with the number of pods in all namespaces using each.
+func (h *priorityClassesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	priorityClasses, err := h.kubeClient.SchedulingV1().PriorityClasses().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := priorityClassesResponse{
+		PriorityClasses: []priorityClassStatus{},
+	}
+
+	podCounts := make(map[string]int)
+	for _, pod := range pods.Items {
+		if pod.Spec.PriorityClassName == "" {
+			resp.UnclassifiedPodCount++
+			continue
+		}
+		podCounts[pod.Spec.PriorityClassName]++
+	}
+
+	for _, priorityClass := range priorityClasses.Items {
+		// Pods preempt lower priority pods unless the policy says otherwise.
+		policy := corev1.PreemptLowerPriority
+		if priorityClass.PreemptionPolicy != nil {
+			policy = *priorityClass.PreemptionPolicy
+		}
+
+		resp.PriorityClasses = append(resp.PriorityClasses, priorityClassStatus{
+			Name:             priorityClass.Name,
+			Value:            priorityClass.Value,
+			GlobalDefault:    priorityClass.GlobalDefault,
+			PreemptionPolicy: policy,
+			Description:      priorityClass.Description,
+			PodCount:         podCounts[priorityClass.Name],
+		})
+	}
+
+	sort.SliceStable(resp.PriorityClasses, func(i, j int) bool {
+		return resp.PriorityClasses[i].Value > resp.PriorityClasses[j].Value
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_priorityClassesHandler(t *testing.T) {
+	never := corev1.PreemptNever
+
+	newPod := func(namespace, name, priorityClass string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       corev1.PodSpec{PriorityClassName: priorityClass},
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&schedulingv1.PriorityClass{
+			ObjectMeta:    metav1.ObjectMeta{Name: "batch"},
+			Value:         100,
+			GlobalDefault: true,
+		},
+		&schedulingv1.PriorityClass{
+			ObjectMeta:       metav1.ObjectMeta{Name: "critical"},
+			Value:            1000000,
+			PreemptionPolicy: &never,
+			Description:      "critical services",
+		},
+		newPod("default", "web", "critical"),
+		newPod("kube-system", "dns", "critical"),
+		newPod("default", "job", "batch"),
+		newPod("default", "legacy", ""),
+	)
+
+	handler := newPriorityClassesHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/priorityclasses", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got priorityClassesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := priorityClassesResponse{
+		PriorityClasses: []priorityClassStatus{
+			{
+				Name:             "critical",
+				Value:            1000000,
+				PreemptionPolicy: corev1.PreemptNever,
+				Description:      "critical services",
+				PodCount:         2,
+			},
+			{
+				Name:             "batch",
+				Value:            100,
+				GlobalDefault:    true,
+				PreemptionPolicy: corev1.PreemptLowerPriority,
+				PodCount:         1,
+			},
+		},
+		UnclassifiedPodCount: 1,
+	}
+	assert.Equal(t, expected, got)
+}
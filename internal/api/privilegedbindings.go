@@ -0,0 +1,202 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type privilegedSubject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Flagged is set for service accounts outside kube-system.
+	Flagged bool `json:"flagged"`
+}
+
+type privilegedBinding struct {
+	Kind        string              `json:"kind"`
+	Namespace   string              `json:"namespace,omitempty"`
+	BindingName string              `json:"bindingName"`
+	RoleKind    string              `json:"roleKind"`
+	RoleName    string              `json:"roleName"`
+	Subjects    []privilegedSubject `json:"subjects"`
+	GrantedAt   time.Time           `json:"grantedAt"`
+	Flagged     bool                `json:"flagged"`
+}
+
+type privilegedBindingsResponse struct {
+	Bindings []privilegedBinding `json:"bindings"`
+}
+
+type privilegedBindingsHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*privilegedBindingsHandler)(nil)
+
+func newPrivilegedBindingsHandler(kubeClient kubernetes.Interface, logger log.Logger) *privilegedBindingsHandler {
+	return &privilegedBindingsHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the cluster role bindings and
+// role bindings in every namespace which bind cluster-admin or a role
+// granting every verb on every resource. Service account subjects outside
+// kube-system, including service accounts bound by user name, are flagged.
+// The grant time is when the binding was created.
+func (h *privilegedBindingsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rbac := h.kubeClient.RbacV1()
+
+	clusterRoles, err := rbac.ClusterRoles().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	roles, err := rbac.Roles(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	clusterRoleBindings, err := rbac.ClusterRoleBindings().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	roleBindings, err := rbac.RoleBindings(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	privilegedClusterRoles := map[string]bool{clusterAdminRole: true}
+	for _, clusterRole := range clusterRoles.Items {
+		if grantsWildcardAccess(clusterRole.Rules) {
+			privilegedClusterRoles[clusterRole.Name] = true
+		}
+	}
+
+	privilegedRoles := make(map[string]bool)
+	for _, role := range roles.Items {
+		if grantsWildcardAccess(role.Rules) {
+			privilegedRoles[role.Namespace+"/"+role.Name] = true
+		}
+	}
+
+	isPrivileged := func(namespace string, roleRef rbacv1.RoleRef) bool {
+		switch roleRef.Kind {
+		case "ClusterRole":
+			return privilegedClusterRoles[roleRef.Name]
+		case "Role":
+			return privilegedRoles[namespace+"/"+roleRef.Name]
+		}
+		return false
+	}
+
+	resp := privilegedBindingsResponse{
+		Bindings: []privilegedBinding{},
+	}
+
+	add := func(kind string, object metav1.ObjectMeta, roleRef rbacv1.RoleRef, subjects []rbacv1.Subject) {
+		binding := privilegedBinding{
+			Kind:        kind,
+			Namespace:   object.Namespace,
+			BindingName: object.Name,
+			RoleKind:    roleRef.Kind,
+			RoleName:    roleRef.Name,
+			Subjects:    []privilegedSubject{},
+			GrantedAt:   object.CreationTimestamp.Time.UTC(),
+		}
+
+		for _, subject := range subjects {
+			s := privilegedSubject{
+				Kind:      subject.Kind,
+				Name:      subject.Name,
+				Namespace: subject.Namespace,
+			}
+
+			if namespace, ok := serviceAccountSubjectNamespace(subject, object.Namespace); ok {
+				s.Flagged = namespace != metav1.NamespaceSystem
+			}
+			binding.Flagged = binding.Flagged || s.Flagged
+
+			binding.Subjects = append(binding.Subjects, s)
+		}
+
+		resp.Bindings = append(resp.Bindings, binding)
+	}
+
+	for _, binding := range clusterRoleBindings.Items {
+		if isPrivileged("", binding.RoleRef) {
+			add("ClusterRoleBinding", binding.ObjectMeta, binding.RoleRef, binding.Subjects)
+		}
+	}
+
+	for _, binding := range roleBindings.Items {
+		if isPrivileged(binding.Namespace, binding.RoleRef) {
+			add("RoleBinding", binding.ObjectMeta, binding.RoleRef, binding.Subjects)
+		}
+	}
+
+	sort.Slice(resp.Bindings, func(i, j int) bool {
+		a, b := resp.Bindings[i], resp.Bindings[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.BindingName < b.BindingName
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// grantsWildcardAccess returns true if a rule allows every verb on every
+// resource.
+func grantsWildcardAccess(rules []rbacv1.PolicyRule) bool {
+	for _, rule := range rules {
+		if containsWildcard(rule.Verbs) && containsWildcard(rule.Resources) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// serviceAccountSubjectNamespace returns the namespace of the service account
+// a subject refers to, either directly or by its user name.
+func serviceAccountSubjectNamespace(subject rbacv1.Subject, bindingNamespace string) (string, bool) {
+	switch subject.Kind {
+	case rbacv1.ServiceAccountKind:
+		if subject.Namespace == "" {
+			return bindingNamespace, true
+		}
+		return subject.Namespace, true
+	case rbacv1.UserKind:
+		parts := strings.Split(strings.TrimPrefix(subject.Name, serviceAccountUserPrefix), ":")
+		if strings.HasPrefix(subject.Name, serviceAccountUserPrefix) && len(parts) == 2 {
+			return parts[0], true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,117 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_privilegedBindingsHandler(t *testing.T) {
+	granted := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	meta := func(namespace, name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: namespace, CreationTimestamp: metav1.NewTime(granted)}
+	}
+
+	wildcard := []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"*"}, Verbs: []string{"*"}}}
+	readOnly := []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"get", "list"}}}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&rbacv1.ClusterRole{ObjectMeta: meta("", "viewer"), Rules: readOnly},
+		&rbacv1.ClusterRole{ObjectMeta: meta("", "core-admin"), Rules: wildcard},
+		&rbacv1.Role{ObjectMeta: meta("apps", "owner"), Rules: wildcard},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: meta("", "admins"),
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: clusterAdminRole},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.GroupKind, Name: "system:masters"},
+				{Kind: rbacv1.ServiceAccountKind, Name: "controller", Namespace: "kube-system"},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: meta("", "ci"),
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "core-admin"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.UserKind, Name: "system:serviceaccount:ci:deployer"},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: meta("", "viewers"),
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "viewer"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "viewer", Namespace: "apps"}},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: meta("apps", "owners"),
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "owner"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "app"}},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: meta("web", "owners"),
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "owner"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "app"}},
+		},
+	)
+
+	handler := newPrivilegedBindingsHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/clusterrolebindings/privileged", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got privilegedBindingsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := []privilegedBinding{
+		{
+			Kind:        "ClusterRoleBinding",
+			BindingName: "admins",
+			RoleKind:    "ClusterRole",
+			RoleName:    clusterAdminRole,
+			Subjects: []privilegedSubject{
+				{Kind: rbacv1.GroupKind, Name: "system:masters"},
+				{Kind: rbacv1.ServiceAccountKind, Name: "controller", Namespace: "kube-system"},
+			},
+			GrantedAt: granted,
+		},
+		{
+			Kind:        "ClusterRoleBinding",
+			BindingName: "ci",
+			RoleKind:    "ClusterRole",
+			RoleName:    "core-admin",
+			Subjects: []privilegedSubject{
+				{Kind: rbacv1.UserKind, Name: "system:serviceaccount:ci:deployer", Flagged: true},
+			},
+			GrantedAt: granted,
+			Flagged:   true,
+		},
+		{
+			Kind:        "RoleBinding",
+			Namespace:   "apps",
+			BindingName: "owners",
+			RoleKind:    "Role",
+			RoleName:    "owner",
+			Subjects: []privilegedSubject{
+				{Kind: rbacv1.ServiceAccountKind, Name: "app", Flagged: true},
+			},
+			GrantedAt: granted,
+			Flagged:   true,
+		},
+	}
+	assert.Equal(t, expected, got.Bindings)
+}
@@ -0,0 +1,300 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type findingSeverity string
+
+const (
+	severityHigh   findingSeverity = "high"
+	severityMedium findingSeverity = "medium"
+	severityLow    findingSeverity = "low"
+)
+
+const (
+	probeLiveness  = "liveness"
+	probeReadiness = "readiness"
+	probeStartup   = "startup"
+
+	findingLivenessBeforeStartup = "LivenessBeforeStartup"
+	findingSharedProbeEndpoint   = "SharedProbeEndpoint"
+	findingAggressiveThreshold   = "AggressiveFailureThreshold"
+
+	// Kubernetes defaults for unset probe fields.
+	defaultProbePeriodSeconds    = 10
+	defaultProbeFailureThreshold = 3
+)
+
+type probeFinding struct {
+	Workload  workloadRef     `json:"workload"`
+	Container string          `json:"container"`
+	Probe     string          `json:"probe"`
+	Code      string          `json:"code"`
+	Severity  findingSeverity `json:"severity"`
+	Message   string          `json:"message"`
+	Pods      []string        `json:"pods"`
+}
+
+type probesResponse struct {
+	Findings []probeFinding `json:"findings"`
+}
+
+type probesHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*probesHandler)(nil)
+
+func newProbesHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *probesHandler {
+	return &probesHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns probe misconfigurations for the
+// containers in a namespace. Findings are reported once per workload and list
+// the pods they were found in. Pods are read with the dynamic client because
+// the vendored core/v1 types predate startup probes.
+func (h *probesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pods, err := h.dynamicClient.Resource(podGVR).Namespace(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	replicaSets, err := h.kubeClient.AppsV1().ReplicaSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := probesResponse{
+		Findings: []probeFinding{},
+	}
+
+	// Findings are indexed so a workload's replicas are reported together.
+	index := make(map[string]int)
+
+	for i := range pods.Items {
+		var pod corev1.Pod
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(pods.Items[i].Object, &pod); err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		startupProbes, err := podStartupProbes(pods.Items[i])
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		workload := podWorkload(&pod, replicaSets.Items)
+
+		for _, container := range pod.Spec.Containers {
+			for _, finding := range containerProbeFindings(&pod, container, startupProbes[container.Name]) {
+				key := fmt.Sprintf("%s/%s/%s/%s/%s", workload.Kind, workload.Name, container.Name, finding.Probe, finding.Code)
+				if j, ok := index[key]; ok {
+					resp.Findings[j].Pods = append(resp.Findings[j].Pods, pod.Name)
+					continue
+				}
+
+				finding.Workload = workload
+				finding.Container = container.Name
+				finding.Pods = []string{pod.Name}
+
+				index[key] = len(resp.Findings)
+				resp.Findings = append(resp.Findings, finding)
+			}
+		}
+	}
+
+	for _, finding := range resp.Findings {
+		sort.Strings(finding.Pods)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// containerProbeFindings checks a container's probes for common mistakes.
+func containerProbeFindings(pod *corev1.Pod, container corev1.Container, startupProbe *corev1.Probe) []probeFinding {
+	var findings []probeFinding
+
+	probes := []struct {
+		name  string
+		probe *corev1.Probe
+	}{
+		{name: probeLiveness, probe: container.LivenessProbe},
+		{name: probeReadiness, probe: container.ReadinessProbe},
+		{name: probeStartup, probe: startupProbe},
+	}
+
+	for _, p := range probes {
+		if p.probe == nil || p.probe.FailureThreshold != 1 {
+			continue
+		}
+
+		// A single failed liveness or startup check restarts the container,
+		// whereas a failed readiness check only removes it from endpoints.
+		severity := severityHigh
+		if p.name == probeReadiness {
+			severity = severityLow
+		}
+
+		findings = append(findings, probeFinding{
+			Probe:    p.name,
+			Code:     findingAggressiveThreshold,
+			Severity: severity,
+			Message:  fmt.Sprintf("%s probe fails after a single unsuccessful check", p.name),
+		})
+	}
+
+	liveness := container.LivenessProbe
+	readiness := container.ReadinessProbe
+
+	// Liveness probes wait for startup probes to succeed, so containers with
+	// a startup probe can't be restarted before they start.
+	if liveness != nil && startupProbe == nil {
+		window := livenessStartupWindow(liveness)
+
+		startup := time.Duration(0)
+		if readiness != nil {
+			startup = time.Duration(readiness.InitialDelaySeconds) * time.Second
+		}
+		if observed, ok := observedStartupDuration(pod, container.Name); ok && observed > startup {
+			startup = observed
+		}
+
+		if startup > window {
+			findings = append(findings, probeFinding{
+				Probe:    probeLiveness,
+				Code:     findingLivenessBeforeStartup,
+				Severity: severityHigh,
+				Message: fmt.Sprintf("liveness probe can restart the container after %s, but it takes %s to start; add a startup probe or increase initialDelaySeconds",
+					window, startup),
+			})
+		}
+	}
+
+	if liveness != nil && readiness != nil && sameHTTPEndpoint(liveness, readiness) {
+		severity := severityMedium
+		if effectiveFailureThreshold(liveness) <= effectiveFailureThreshold(readiness) {
+			// The container is restarted no later than it is taken out of
+			// rotation, so readiness failures can't be handled independently.
+			severity = severityHigh
+		}
+
+		findings = append(findings, probeFinding{
+			Probe:    probeReadiness,
+			Code:     findingSharedProbeEndpoint,
+			Severity: severity,
+			Message:  fmt.Sprintf("readiness and liveness probes both check %s; a failing dependency will restart the container instead of only removing it from endpoints", liveness.HTTPGet.Path),
+		})
+	}
+
+	return findings
+}
+
+// podStartupProbes returns the startup probes of a pod's containers by container name.
+func podStartupProbes(pod unstructured.Unstructured) (map[string]*corev1.Probe, error) {
+	probes := make(map[string]*corev1.Probe)
+
+	containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", "containers")
+	for _, item := range containers {
+		container, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		object, ok, _ := unstructured.NestedMap(container, "startupProbe")
+		if !ok {
+			continue
+		}
+
+		var probe corev1.Probe
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object, &probe); err != nil {
+			return nil, err
+		}
+
+		name, _, _ := unstructured.NestedString(container, "name")
+		probes[name] = &probe
+	}
+
+	return probes, nil
+}
+
+// livenessStartupWindow returns how long after starting a container can run
+// before failing liveness checks restart it.
+func livenessStartupWindow(probe *corev1.Probe) time.Duration {
+	period := probe.PeriodSeconds
+	if period == 0 {
+		period = defaultProbePeriodSeconds
+	}
+
+	seconds := probe.InitialDelaySeconds + effectiveFailureThreshold(probe)*period
+	return time.Duration(seconds) * time.Second
+}
+
+func effectiveFailureThreshold(probe *corev1.Probe) int32 {
+	if probe.FailureThreshold == 0 {
+		return defaultProbeFailureThreshold
+	}
+	return probe.FailureThreshold
+}
+
+func sameHTTPEndpoint(a, b *corev1.Probe) bool {
+	if a.HTTPGet == nil || b.HTTPGet == nil {
+		return false
+	}
+
+	return a.HTTPGet.Path == b.HTTPGet.Path && a.HTTPGet.Port == b.HTTPGet.Port
+}
+
+// observedStartupDuration returns how long a running container took to become
+// ready, from its start time to the pod's Ready transition.
+func observedStartupDuration(pod *corev1.Pod, containerName string) (time.Duration, bool) {
+	var started time.Time
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == containerName && status.Ready && status.State.Running != nil {
+			started = status.State.Running.StartedAt.Time
+		}
+	}
+	if started.IsZero() {
+		return 0, false
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			ready := condition.LastTransitionTime.Time
+			if ready.After(started) {
+				return ready.Sub(started), true
+			}
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,158 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_probesHandler(t *testing.T) {
+	started := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	httpProbe := func(path string, initialDelay, failureThreshold int32) *corev1.Probe {
+		return &corev1.Probe{
+			Handler: corev1.Handler{
+				HTTPGet: &corev1.HTTPGetAction{Path: path, Port: intstr.FromInt(8080)},
+			},
+			InitialDelaySeconds: initialDelay,
+			PeriodSeconds:       10,
+			FailureThreshold:    failureThreshold,
+		}
+	}
+
+	isController := true
+	newPod := func(name string, owner *metav1.OwnerReference, container corev1.Container, status corev1.PodStatus, startupProbe map[string]interface{}) *unstructured.Unstructured {
+		pod := &corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{container}},
+			Status:     status,
+		}
+		if owner != nil {
+			pod.OwnerReferences = []metav1.OwnerReference{*owner}
+		}
+
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+		require.NoError(t, err)
+
+		if startupProbe != nil {
+			containers, _, _ := unstructured.NestedSlice(object, "spec", "containers")
+			containers[0].(map[string]interface{})["startupProbe"] = startupProbe
+			require.NoError(t, unstructured.SetNestedSlice(object, containers, "spec", "containers"))
+		}
+
+		return &unstructured.Unstructured{Object: object}
+	}
+
+	replicaSetOwner := &metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "api-1", UID: "rs", Controller: &isController}
+
+	apiContainer := corev1.Container{
+		Name:           "api",
+		LivenessProbe:  httpProbe("/healthz", 0, 1),
+		ReadinessProbe: httpProbe("/healthz", 0, 3),
+	}
+
+	slow := corev1.Container{
+		Name:           "app",
+		LivenessProbe:  httpProbe("/live", 5, 3),
+		ReadinessProbe: httpProbe("/ready", 0, 3),
+	}
+
+	slowStatus := corev1.PodStatus{
+		Conditions: []corev1.PodCondition{
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(started.Add(time.Minute))},
+		},
+		ContainerStatuses: []corev1.ContainerStatus{
+			{
+				Name:  "app",
+				Ready: true,
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(started)}},
+			},
+		},
+	}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newPod("api-1-a", replicaSetOwner, apiContainer, corev1.PodStatus{}, nil),
+		newPod("api-1-b", replicaSetOwner, apiContainer, corev1.PodStatus{}, nil),
+		newPod("slow", nil, slow, slowStatus, nil),
+		newPod("slow-with-startup", nil, slow, slowStatus, map[string]interface{}{
+			"httpGet":          map[string]interface{}{"path": "/live", "port": int64(8080)},
+			"failureThreshold": int64(30),
+		}),
+	)
+
+	kubeClient := kubefake.NewSimpleClientset(&appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "api-1",
+			Namespace:       "default",
+			UID:             "rs",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "api", Controller: &isController}},
+		},
+	})
+
+	handler := newProbesHandler(kubeClient, dynamicClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/probes/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got probesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := probesResponse{
+		Findings: []probeFinding{
+			{
+				Workload:  workloadRef{Kind: "Deployment", Name: "api"},
+				Container: "api",
+				Probe:     probeLiveness,
+				Code:      findingAggressiveThreshold,
+				Severity:  severityHigh,
+				Message:   "liveness probe fails after a single unsuccessful check",
+				Pods:      []string{"api-1-a", "api-1-b"},
+			},
+			{
+				Workload:  workloadRef{Kind: "Deployment", Name: "api"},
+				Container: "api",
+				Probe:     probeReadiness,
+				Code:      findingSharedProbeEndpoint,
+				Severity:  severityHigh,
+				Message:   "readiness and liveness probes both check /healthz; a failing dependency will restart the container instead of only removing it from endpoints",
+				Pods:      []string{"api-1-a", "api-1-b"},
+			},
+			{
+				Workload:  workloadRef{Kind: "Pod", Name: "slow"},
+				Container: "app",
+				Probe:     probeLiveness,
+				Code:      findingLivenessBeforeStartup,
+				Severity:  severityHigh,
+				Message:   "liveness probe can restart the container after 35s, but it takes 1m0s to start; add a startup probe or increase initialDelaySeconds",
+				Pods:      []string{"slow"},
+			},
+		},
+	}
+	assert.Equal(t, expected, got)
+}
@@ -0,0 +1,316 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	prometheusRuleTypeRecording = "recording"
+	prometheusRuleTypeAlerting  = "alerting"
+
+	// prometheusErrorBadData and prometheusErrorExecution are the query API
+	// error types for expressions which don't parse or can't be evaluated.
+	prometheusErrorBadData   = "bad_data"
+	prometheusErrorExecution = "execution"
+)
+
+var prometheusRuleGVR = schema.GroupVersionResource{
+	Group:    "monitoring.coreos.com",
+	Version:  "v1",
+	Resource: "prometheusrules",
+}
+
+// prometheusLoadedRule is a rule from the Prometheus rules API. The
+// evaluation time is in seconds.
+type prometheusLoadedRule struct {
+	Name           string  `json:"name"`
+	EvaluationTime float64 `json:"evaluationTime"`
+}
+
+type prometheusRuleGroup struct {
+	Name  string                 `json:"name"`
+	File  string                 `json:"file"`
+	Rules []prometheusLoadedRule `json:"rules"`
+}
+
+// prometheusRulesAPIResponse is the subset of a Prometheus rules API response
+// used for rule evaluation times.
+type prometheusRulesAPIResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		Groups []prometheusRuleGroup `json:"groups"`
+	} `json:"data"`
+}
+
+type prometheusRuleStatus struct {
+	// Name is the PrometheusRule the rule is defined in.
+	Name        string `json:"name"`
+	Group       string `json:"group"`
+	Rule        string `json:"rule"`
+	Type        string `json:"type"`
+	Expr        string `json:"expr"`
+	Valid       bool   `json:"valid"`
+	Error       string `json:"error,omitempty"`
+	SeriesCount int    `json:"seriesCount"`
+	// LastEvalDuration is how long Prometheus took to evaluate the rule
+	// last, if it has loaded it.
+	LastEvalDuration string `json:"lastEvalDuration,omitempty"`
+}
+
+type prometheusRulesResponse struct {
+	PrometheusOperatorNotInstalled bool                   `json:"prometheusOperatorNotInstalled"`
+	PrometheusNotConfigured        bool                   `json:"prometheusNotConfigured"`
+	Rules                          []prometheusRuleStatus `json:"rules"`
+}
+
+type prometheusRulesHandler struct {
+	dynamicClient  dynamic.Interface
+	prometheusURL  string
+	httpClient     *http.Client
+	maxConcurrency int
+	logger         log.Logger
+}
+
+var _ http.Handler = (*prometheusRulesHandler)(nil)
+
+func newPrometheusRulesHandler(dynamicClient dynamic.Interface, prometheusURL string, maxConcurrency int, logger log.Logger) *prometheusRulesHandler {
+	if maxConcurrency < 1 {
+		maxConcurrency = defaultResourceListConcurrency
+	}
+
+	return &prometheusRulesHandler{
+		dynamicClient:  dynamicClient,
+		prometheusURL:  strings.TrimSuffix(prometheusURL, "/"),
+		httpClient:     &http.Client{Timeout: 10 * time.Second},
+		maxConcurrency: maxConcurrency,
+		logger:         logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and validates the rules in a namespace's
+// PrometheusRules. Each rule's expression is run as an instant query: a rule
+// is valid when Prometheus can parse and evaluate it, and the series count is
+// how many series it returns now. Alerting rules only return series while
+// they are firing. Evaluation durations come from the rules Prometheus has
+// loaded, matched by the rule file the operator writes for each
+// PrometheusRule.
+//
+// Queries run concurrently. A rule whose query fails for reasons other than
+// its expression is reported with the error rather than failing the request.
+func (h *prometheusRulesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp := prometheusRulesResponse{
+		Rules: []prometheusRuleStatus{},
+	}
+
+	if h.prometheusURL == "" {
+		resp.PrometheusNotConfigured = true
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	namespace := mux.Vars(r)["namespace"]
+
+	prometheusRules, installed, err := listOptionalResource(h.dynamicClient, prometheusRuleGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp.PrometheusOperatorNotInstalled = !installed
+
+	sort.Slice(prometheusRules.Items, func(i, j int) bool {
+		return prometheusRules.Items[i].GetName() < prometheusRules.Items[j].GetName()
+	})
+
+	for _, prometheusRule := range prometheusRules.Items {
+		resp.Rules = append(resp.Rules, definedPrometheusRules(prometheusRule)...)
+	}
+
+	if len(resp.Rules) == 0 {
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	loaded, err := h.loadedRuleGroups()
+	if err != nil {
+		h.logger.WithErr(err).Warnf("unable to load prometheus rule evaluation times")
+	}
+
+	h.validateRules(resp.Rules)
+
+	for i := range resp.Rules {
+		rule := &resp.Rules[i]
+		if seconds, ok := ruleEvaluationTime(loaded, namespace, *rule); ok {
+			rule.LastEvalDuration = time.Duration(seconds * float64(time.Second)).String()
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// validateRules validates the rules, running at most maxConcurrency queries
+// at once. Each goroutine only writes its own rule.
+func (h *prometheusRulesHandler) validateRules(rules []prometheusRuleStatus) {
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, h.maxConcurrency)
+	)
+
+	for i := range rules {
+		wg.Add(1)
+		go func(rule *prometheusRuleStatus) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := h.validate(rule); err != nil {
+				rule.Error = err.Error()
+			}
+		}(&rules[i])
+	}
+
+	wg.Wait()
+}
+
+// validate runs a rule's expression and records whether Prometheus accepted
+// it and how many series it returned. It returns an error when Prometheus
+// failed for reasons other than the expression.
+func (h *prometheusRulesHandler) validate(rule *prometheusRuleStatus) error {
+	found, err := queryPrometheus(h.httpClient, h.prometheusURL, rule.Expr)
+	if err != nil {
+		return err
+	}
+
+	if found.Status != "success" {
+		switch found.ErrorType {
+		case prometheusErrorBadData, prometheusErrorExecution:
+			rule.Error = found.Error
+			return nil
+		default:
+			return errors.Errorf("prometheus returned an error: %s", found.Error)
+		}
+	}
+
+	switch found.Data.ResultType {
+	case "vector", "matrix":
+		var series []json.RawMessage
+		if err := json.Unmarshal(found.Data.Result, &series); err != nil {
+			return errors.Wrap(err, "decode prometheus result")
+		}
+		rule.SeriesCount = len(series)
+	default:
+		rule.SeriesCount = 1
+	}
+
+	rule.Valid = true
+
+	return nil
+}
+
+// loadedRuleGroups returns the rule groups Prometheus has loaded.
+func (h *prometheusRulesHandler) loadedRuleGroups() ([]prometheusRuleGroup, error) {
+	res, err := h.httpClient.Get(fmt.Sprintf("%s/api/v1/rules", h.prometheusURL))
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch prometheus rules")
+	}
+	defer res.Body.Close()
+
+	var found prometheusRulesAPIResponse
+	if err := json.NewDecoder(res.Body).Decode(&found); err != nil {
+		return nil, errors.Wrapf(err, "decode prometheus rules (%s)", res.Status)
+	}
+
+	if found.Status != "success" {
+		return nil, errors.Errorf("prometheus returned an error: %s", found.Error)
+	}
+
+	return found.Data.Groups, nil
+}
+
+// ruleEvaluationTime finds a rule in the loaded groups and returns its last
+// evaluation time. The operator names rule files after the PrometheusRule's
+// namespace and name.
+func ruleEvaluationTime(groups []prometheusRuleGroup, namespace string, rule prometheusRuleStatus) (float64, bool) {
+	prefix := namespace + "-" + rule.Name
+	for _, group := range groups {
+		if group.Name != rule.Group || !strings.HasPrefix(path.Base(group.File), prefix) {
+			continue
+		}
+
+		for _, loaded := range group.Rules {
+			if loaded.Name == rule.Rule {
+				return loaded.EvaluationTime, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// definedPrometheusRules returns the rules in a PrometheusRule in the order
+// they are defined.
+func definedPrometheusRules(prometheusRule unstructured.Unstructured) []prometheusRuleStatus {
+	var rules []prometheusRuleStatus
+
+	groups, _, _ := unstructured.NestedSlice(prometheusRule.Object, "spec", "groups")
+	for _, item := range groups {
+		group, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		groupName, _, _ := unstructured.NestedString(group, "name")
+		groupRules, _, _ := unstructured.NestedSlice(group, "rules")
+
+		for _, item := range groupRules {
+			spec, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			rule := prometheusRuleStatus{
+				Name:  prometheusRule.GetName(),
+				Group: groupName,
+				Type:  prometheusRuleTypeRecording,
+			}
+
+			// Expressions are an int or string, so a constant may be a number.
+			if expr, ok := spec["expr"]; ok && expr != nil {
+				rule.Expr = fmt.Sprint(expr)
+			}
+
+			rule.Rule, _, _ = unstructured.NestedString(spec, "record")
+			if alert, _, _ := unstructured.NestedString(spec, "alert"); alert != "" {
+				rule.Rule = alert
+				rule.Type = prometheusRuleTypeAlerting
+			}
+
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
@@ -0,0 +1,196 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_prometheusRulesHandler(t *testing.T) {
+	prometheusRule := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "PrometheusRule",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"groups": []interface{}{
+				map[string]interface{}{
+					"name": "web.rules",
+					"rules": []interface{}{
+						map[string]interface{}{"record": "job:http_requests:rate5m", "expr": "sum by (job) (rate(http_requests_total[5m]))"},
+						map[string]interface{}{"alert": "WebDown", "expr": "up{job=\"web\"} == 0"},
+						map[string]interface{}{"record": "job:typo:rate5m", "expr": "sum(rate(http_requests_total[5m])"},
+						map[string]interface{}{"record": "job:one", "expr": int64(1)},
+					},
+				},
+			},
+		},
+	}}
+
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/rules" {
+			fmt.Fprint(w, `{"status":"success","data":{"groups":[
+				{"name":"web.rules","file":"/etc/prometheus/rules/prometheus-k8s-rulefiles-0/other-web-1234.yaml","rules":[
+					{"name":"job:http_requests:rate5m","evaluationTime":0.5}
+				]},
+				{"name":"web.rules","file":"/etc/prometheus/rules/prometheus-k8s-rulefiles-0/default-web-1234.yaml","rules":[
+					{"name":"job:http_requests:rate5m","evaluationTime":0.0015},
+					{"name":"WebDown","evaluationTime":0.0002}
+				]}
+			]}}`)
+			return
+		}
+
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+		switch r.URL.Query().Get("query") {
+		case "sum by (job) (rate(http_requests_total[5m]))":
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"job":"web"},"value":[1569931200,"12.5"]},
+				{"metric":{"job":"api"},"value":[1569931200,"3"]}
+			]}}`)
+		case `up{job="web"} == 0`:
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+		case "1":
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"scalar","result":[1569931200,"1"]}}`)
+		case "unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"status":"error","errorType":"unavailable","error":"overloaded"}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"unclosed left parenthesis"}`)
+		}
+	}))
+	defer prometheus.Close()
+
+	t.Run("rule status", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), prometheusRule)
+		handler := newPrometheusRulesHandler(dynamicClient, prometheus.URL+"/", 2, log.NopLogger())
+
+		got := servePrometheusRules(t, handler, http.StatusOK)
+
+		expected := prometheusRulesResponse{
+			Rules: []prometheusRuleStatus{
+				{
+					Name:             "web",
+					Group:            "web.rules",
+					Rule:             "job:http_requests:rate5m",
+					Type:             "recording",
+					Expr:             "sum by (job) (rate(http_requests_total[5m]))",
+					Valid:            true,
+					SeriesCount:      2,
+					LastEvalDuration: "1.5ms",
+				},
+				{
+					Name:             "web",
+					Group:            "web.rules",
+					Rule:             "WebDown",
+					Type:             "alerting",
+					Expr:             `up{job="web"} == 0`,
+					Valid:            true,
+					LastEvalDuration: "200µs",
+				},
+				{
+					Name:  "web",
+					Group: "web.rules",
+					Rule:  "job:typo:rate5m",
+					Type:  "recording",
+					Expr:  "sum(rate(http_requests_total[5m])",
+					Error: "unclosed left parenthesis",
+				},
+				{
+					Name:        "web",
+					Group:       "web.rules",
+					Rule:        "job:one",
+					Type:        "recording",
+					Expr:        "1",
+					Valid:       true,
+					SeriesCount: 1,
+				},
+			},
+		}
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("prometheus unavailable", func(t *testing.T) {
+		unavailable := prometheusRule.DeepCopy()
+		unavailable.Object["spec"] = map[string]interface{}{
+			"groups": []interface{}{
+				map[string]interface{}{
+					"name":  "web.rules",
+					"rules": []interface{}{map[string]interface{}{"record": "job:unavailable", "expr": "unavailable"}},
+				},
+			},
+		}
+
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), unavailable)
+		handler := newPrometheusRulesHandler(dynamicClient, prometheus.URL, 0, log.NopLogger())
+
+		got := servePrometheusRules(t, handler, http.StatusOK)
+
+		expected := []prometheusRuleStatus{
+			{
+				Name:  "web",
+				Group: "web.rules",
+				Rule:  "job:unavailable",
+				Type:  "recording",
+				Expr:  "unavailable",
+				Error: "prometheus returned an error: overloaded",
+			},
+		}
+		assert.Equal(t, expected, got.Rules)
+	})
+
+	t.Run("prometheus not configured", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), prometheusRule)
+
+		got := servePrometheusRules(t, newPrometheusRulesHandler(dynamicClient, "", 0, log.NopLogger()), http.StatusOK)
+
+		assert.True(t, got.PrometheusNotConfigured)
+		assert.Empty(t, got.Rules)
+	})
+
+	t.Run("prometheus operator not installed", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		dynamicClient.PrependReactor("list", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+		})
+
+		got := servePrometheusRules(t, newPrometheusRulesHandler(dynamicClient, prometheus.URL, 0, log.NopLogger()), http.StatusOK)
+
+		assert.True(t, got.PrometheusOperatorNotInstalled)
+		assert.Empty(t, got.Rules)
+	})
+}
+
+func servePrometheusRules(t *testing.T, handler *prometheusRulesHandler, expectedCode int) prometheusRulesResponse {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/prometheusrules", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, expectedCode, w.Code)
+
+	var got prometheusRulesResponse
+	if expectedCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	}
+
+	return got
+}
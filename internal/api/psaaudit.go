@@ -0,0 +1,432 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	psaLevelPrivileged = "privileged"
+	psaLevelBaseline   = "baseline"
+	psaLevelRestricted = "restricted"
+
+	seccompRuntimeDefault = "RuntimeDefault"
+	seccompLocalhost      = "Localhost"
+	seccompUnconfined     = "Unconfined"
+
+	seccompPodAnnotation             = "seccomp.security.alpha.kubernetes.io/pod"
+	seccompContainerAnnotationPrefix = "container.seccomp.security.alpha.kubernetes.io/"
+	appArmorAnnotationPrefix         = "container.apparmor.security.beta.kubernetes.io/"
+)
+
+var (
+	// psaBaselineCapabilities are the capabilities containers may add under
+	// the baseline standard.
+	psaBaselineCapabilities = map[string]bool{
+		"AUDIT_WRITE": true, "CHOWN": true, "DAC_OVERRIDE": true, "FOWNER": true, "FSETID": true,
+		"KILL": true, "MKNOD": true, "NET_BIND_SERVICE": true, "SETFCAP": true, "SETGID": true,
+		"SETPCAP": true, "SETUID": true, "SYS_CHROOT": true,
+	}
+
+	psaSafeSysctls = map[string]bool{
+		"kernel.shm_rmid_forced":              true,
+		"net.ipv4.ip_local_port_range":        true,
+		"net.ipv4.ip_unprivileged_port_start": true,
+		"net.ipv4.tcp_syncookies":             true,
+		"net.ipv4.ping_group_range":           true,
+	}
+
+	psaSELinuxTypes = map[string]bool{
+		"": true, "container_t": true, "container_init_t": true, "container_kvm_t": true,
+	}
+
+	// psaRestrictedVolumes are the volume types allowed under the restricted
+	// standard, by their JSON field name.
+	psaRestrictedVolumes = map[string]bool{
+		"configMap": true, "csi": true, "downwardAPI": true, "emptyDir": true, "ephemeral": true,
+		"persistentVolumeClaim": true, "projected": true, "secret": true,
+	}
+)
+
+type psaFailure struct {
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+type psaLevelVerdict struct {
+	Allowed  bool         `json:"allowed"`
+	Failures []psaFailure `json:"failures"`
+}
+
+type psaPodVerdict struct {
+	Namespace string                     `json:"namespace"`
+	Pod       string                     `json:"pod"`
+	Levels    map[string]psaLevelVerdict `json:"levels"`
+}
+
+type psaAuditResponse struct {
+	Pods []psaPodVerdict `json:"pods"`
+}
+
+type psaAuditHandler struct {
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*psaAuditHandler)(nil)
+
+func newPSAAuditHandler(dynamicClient dynamic.Interface, logger log.Logger) *psaAuditHandler {
+	return &psaAuditHandler{
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and evaluates pods against the privileged,
+// baseline and restricted Pod Security Standards. Pods come from all namespaces
+// unless the `namespace` query parameter is set.
+//
+// The checks follow k8s.io/pod-security-admission, which can't be vendored
+// alongside this version of client-go. Pods are read with the dynamic client
+// so seccomp profiles and host process settings, which are newer than the
+// vendored API types, are evaluated.
+func (h *psaAuditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+
+	pods, err := h.dynamicClient.Resource(podGVR).Namespace(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := psaAuditResponse{
+		Pods: []psaPodVerdict{},
+	}
+
+	for _, object := range pods.Items {
+		var pod corev1.Pod
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &pod); err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		baseline := psaBaselineFailures(&pod, object)
+		restricted := append(append([]psaFailure{}, baseline...), psaRestrictedFailures(&pod, object)...)
+
+		resp.Pods = append(resp.Pods, psaPodVerdict{
+			Namespace: pod.Namespace,
+			Pod:       pod.Name,
+			Levels: map[string]psaLevelVerdict{
+				psaLevelPrivileged: {Allowed: true, Failures: []psaFailure{}},
+				psaLevelBaseline:   {Allowed: len(baseline) == 0, Failures: baseline},
+				psaLevelRestricted: {Allowed: len(restricted) == 0, Failures: restricted},
+			},
+		})
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// psaContainer is a container with the fields needed by the checks which the
+// vendored API types don't have.
+type psaContainer struct {
+	corev1.Container
+	seccomp     string
+	hostProcess bool
+}
+
+func psaContainers(pod *corev1.Pod, object unstructured.Unstructured) []psaContainer {
+	var containers []psaContainer
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		items, _, _ := unstructured.NestedSlice(object.Object, "spec", field)
+		for _, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			// Ephemeral containers share the container fields the checks use.
+			var c psaContainer
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &c.Container); err != nil {
+				continue
+			}
+
+			c.seccomp, _, _ = unstructured.NestedString(m, "securityContext", "seccompProfile", "type")
+			if c.seccomp == "" {
+				c.seccomp = seccompFromAnnotation(pod.Annotations[seccompContainerAnnotationPrefix+c.Name])
+			}
+			c.hostProcess, _, _ = unstructured.NestedBool(m, "securityContext", "windowsOptions", "hostProcess")
+
+			containers = append(containers, c)
+		}
+	}
+
+	return containers
+}
+
+func podSeccompProfile(pod *corev1.Pod, object unstructured.Unstructured) string {
+	if seccomp, _, _ := unstructured.NestedString(object.Object, "spec", "securityContext", "seccompProfile", "type"); seccomp != "" {
+		return seccomp
+	}
+
+	return seccompFromAnnotation(pod.Annotations[seccompPodAnnotation])
+}
+
+func seccompFromAnnotation(value string) string {
+	switch {
+	case value == "":
+		return ""
+	case value == "runtime/default" || value == "docker/default":
+		return seccompRuntimeDefault
+	case strings.HasPrefix(value, "localhost/"):
+		return seccompLocalhost
+	default:
+		return seccompUnconfined
+	}
+}
+
+// psaBaselineFailures returns the baseline checks a pod fails.
+func psaBaselineFailures(pod *corev1.Pod, object unstructured.Unstructured) []psaFailure {
+	failures := []psaFailure{}
+	fail := func(check, format string, args ...interface{}) {
+		failures = append(failures, psaFailure{Check: check, Message: fmt.Sprintf(format, args...)})
+	}
+
+	containers := psaContainers(pod, object)
+	psc := pod.Spec.SecurityContext
+	if psc == nil {
+		psc = &corev1.PodSecurityContext{}
+	}
+
+	podHostProcess, _, _ := unstructured.NestedBool(object.Object, "spec", "securityContext", "windowsOptions", "hostProcess")
+	hostProcess := containerNames(containers, func(c psaContainer) bool { return c.hostProcess })
+	if podHostProcess || len(hostProcess) > 0 {
+		fail("hostProcess", "host process containers are not allowed")
+	}
+
+	var namespaces []string
+	if pod.Spec.HostNetwork {
+		namespaces = append(namespaces, "hostNetwork=true")
+	}
+	if pod.Spec.HostPID {
+		namespaces = append(namespaces, "hostPID=true")
+	}
+	if pod.Spec.HostIPC {
+		namespaces = append(namespaces, "hostIPC=true")
+	}
+	if len(namespaces) > 0 {
+		fail("hostNamespaces", "%s", strings.Join(namespaces, ", "))
+	}
+
+	if names := containerNames(containers, func(c psaContainer) bool {
+		return c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged
+	}); len(names) > 0 {
+		fail("privileged", "containers %s must not set securityContext.privileged=true", quoteNames(names))
+	}
+
+	var added []string
+	for _, c := range containers {
+		if c.SecurityContext == nil || c.SecurityContext.Capabilities == nil {
+			continue
+		}
+		for _, capability := range c.SecurityContext.Capabilities.Add {
+			if !psaBaselineCapabilities[string(capability)] {
+				added = append(added, string(capability))
+			}
+		}
+	}
+	if len(added) > 0 {
+		fail("capabilities_baseline", "non-default capabilities %s must not be added", quoteNames(added))
+	}
+
+	var hostPaths []string
+	for _, volume := range pod.Spec.Volumes {
+		if volume.HostPath != nil {
+			hostPaths = append(hostPaths, volume.Name)
+		}
+	}
+	if len(hostPaths) > 0 {
+		fail("hostPathVolumes", "hostPath volumes %s are not allowed", quoteNames(hostPaths))
+	}
+
+	var hostPorts []string
+	for _, c := range containers {
+		for _, port := range c.Ports {
+			if port.HostPort != 0 {
+				hostPorts = append(hostPorts, fmt.Sprintf("%d", port.HostPort))
+			}
+		}
+	}
+	if len(hostPorts) > 0 {
+		fail("hostPorts", "hostPorts %s are not allowed", strings.Join(hostPorts, ", "))
+	}
+
+	var appArmor []string
+	for key, value := range pod.Annotations {
+		if !strings.HasPrefix(key, appArmorAnnotationPrefix) {
+			continue
+		}
+		if value != "" && value != "runtime/default" && !strings.HasPrefix(value, "localhost/") {
+			appArmor = append(appArmor, strings.TrimPrefix(key, appArmorAnnotationPrefix))
+		}
+	}
+	if len(appArmor) > 0 {
+		sort.Strings(appArmor)
+		fail("appArmorProfile", "containers %s must not override the AppArmor profile", quoteNames(appArmor))
+	}
+
+	seLinuxAllowed := func(options *corev1.SELinuxOptions) bool {
+		return options == nil || (psaSELinuxTypes[options.Type] && options.User == "" && options.Role == "")
+	}
+	seLinux := containerNames(containers, func(c psaContainer) bool {
+		return c.SecurityContext != nil && !seLinuxAllowed(c.SecurityContext.SELinuxOptions)
+	})
+	if !seLinuxAllowed(psc.SELinuxOptions) || len(seLinux) > 0 {
+		fail("seLinuxOptions", "SELinux user and role must not be set and type must be a container type")
+	}
+
+	if names := containerNames(containers, func(c psaContainer) bool {
+		return c.SecurityContext != nil && c.SecurityContext.ProcMount != nil && *c.SecurityContext.ProcMount != corev1.DefaultProcMount
+	}); len(names) > 0 {
+		fail("procMount", "containers %s must not set a procMount other than Default", quoteNames(names))
+	}
+
+	unconfined := containerNames(containers, func(c psaContainer) bool { return c.seccomp == seccompUnconfined })
+	if podSeccompProfile(pod, object) == seccompUnconfined || len(unconfined) > 0 {
+		fail("seccompProfile_baseline", "seccomp profile must not be Unconfined")
+	}
+
+	var sysctls []string
+	for _, sysctl := range psc.Sysctls {
+		if !psaSafeSysctls[sysctl.Name] {
+			sysctls = append(sysctls, sysctl.Name)
+		}
+	}
+	if len(sysctls) > 0 {
+		fail("sysctls", "unsafe sysctls %s are not allowed", quoteNames(sysctls))
+	}
+
+	return failures
+}
+
+// psaRestrictedFailures returns the checks a pod fails which are only part of
+// the restricted standard.
+func psaRestrictedFailures(pod *corev1.Pod, object unstructured.Unstructured) []psaFailure {
+	var failures []psaFailure
+	fail := func(check, format string, args ...interface{}) {
+		failures = append(failures, psaFailure{Check: check, Message: fmt.Sprintf(format, args...)})
+	}
+
+	containers := psaContainers(pod, object)
+	psc := pod.Spec.SecurityContext
+	if psc == nil {
+		psc = &corev1.PodSecurityContext{}
+	}
+
+	var volumes []string
+	for _, volume := range pod.Spec.Volumes {
+		if volumeType := volumeSourceType(volume.VolumeSource); !psaRestrictedVolumes[volumeType] {
+			volumes = append(volumes, fmt.Sprintf("%s (%s)", volume.Name, volumeType))
+		}
+	}
+	if len(volumes) > 0 {
+		fail("volumeTypes_restricted", "volumes %s use restricted volume types", strings.Join(volumes, ", "))
+	}
+
+	if names := containerNames(containers, func(c psaContainer) bool {
+		return c.SecurityContext == nil || c.SecurityContext.AllowPrivilegeEscalation == nil || *c.SecurityContext.AllowPrivilegeEscalation
+	}); len(names) > 0 {
+		fail("allowPrivilegeEscalation", "containers %s must set securityContext.allowPrivilegeEscalation=false", quoteNames(names))
+	}
+
+	podNonRoot := psc.RunAsNonRoot != nil && *psc.RunAsNonRoot
+	if names := containerNames(containers, func(c psaContainer) bool {
+		if c.SecurityContext != nil && c.SecurityContext.RunAsNonRoot != nil {
+			return !*c.SecurityContext.RunAsNonRoot
+		}
+		return !podNonRoot
+	}); len(names) > 0 {
+		fail("runAsNonRoot", "containers %s must set securityContext.runAsNonRoot=true", quoteNames(names))
+	}
+
+	rootUser := containerNames(containers, func(c psaContainer) bool {
+		return c.SecurityContext != nil && c.SecurityContext.RunAsUser != nil && *c.SecurityContext.RunAsUser == 0
+	})
+	if (psc.RunAsUser != nil && *psc.RunAsUser == 0) || len(rootUser) > 0 {
+		fail("runAsUser", "runAsUser must not be 0")
+	}
+
+	podSeccomp := podSeccompProfile(pod, object)
+	if names := containerNames(containers, func(c psaContainer) bool {
+		seccomp := c.seccomp
+		if seccomp == "" {
+			seccomp = podSeccomp
+		}
+		return seccomp != seccompRuntimeDefault && seccomp != seccompLocalhost
+	}); len(names) > 0 {
+		fail("seccompProfile_restricted", "containers %s must set a RuntimeDefault or Localhost seccomp profile", quoteNames(names))
+	}
+
+	var notDropped, added []string
+	for _, c := range containers {
+		dropsAll := false
+		if c.SecurityContext != nil && c.SecurityContext.Capabilities != nil {
+			for _, capability := range c.SecurityContext.Capabilities.Drop {
+				if capability == "ALL" {
+					dropsAll = true
+				}
+			}
+			for _, capability := range c.SecurityContext.Capabilities.Add {
+				if capability != "NET_BIND_SERVICE" {
+					added = append(added, string(capability))
+				}
+			}
+		}
+		if !dropsAll {
+			notDropped = append(notDropped, c.Name)
+		}
+	}
+	if len(notDropped) > 0 {
+		fail("capabilities_restricted", "containers %s must drop ALL capabilities", quoteNames(notDropped))
+	}
+	if len(added) > 0 {
+		fail("capabilities_restricted", "capabilities %s must not be added", quoteNames(added))
+	}
+
+	return failures
+}
+
+func containerNames(containers []psaContainer, matches func(psaContainer) bool) []string {
+	var names []string
+	for _, c := range containers {
+		if matches(c) {
+			names = append(names, c.Name)
+		}
+	}
+
+	return names
+}
+
+func quoteNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,175 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_psaAuditHandler(t *testing.T) {
+	yes, no := true, false
+
+	newPod := func(namespace, name string, spec corev1.PodSpec, podSeccomp string) *unstructured.Unstructured {
+		pod := &corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       spec,
+		}
+
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+		require.NoError(t, err)
+
+		if podSeccomp != "" {
+			require.NoError(t, unstructured.SetNestedField(object, podSeccomp, "spec", "securityContext", "seccompProfile", "type"))
+		}
+
+		return &unstructured.Unstructured{Object: object}
+	}
+
+	restricted := corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{RunAsNonRoot: &yes},
+		Containers: []corev1.Container{
+			{
+				Name: "app",
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: &no,
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}, Add: []corev1.Capability{"NET_BIND_SERVICE"}},
+				},
+			},
+		},
+		Volumes: []corev1.Volume{
+			{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}}},
+		},
+	}
+
+	baseline := corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "app"}},
+	}
+
+	privileged := corev1.PodSpec{
+		HostNetwork: true,
+		Containers: []corev1.Container{
+			{
+				Name:            "agent",
+				SecurityContext: &corev1.SecurityContext{Privileged: &yes},
+				Ports:           []corev1.ContainerPort{{ContainerPort: 9100, HostPort: 9100}},
+			},
+		},
+		Volumes: []corev1.Volume{
+			{Name: "root", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}}},
+		},
+	}
+
+	debugged := newPod("default", "debugged", restricted, seccompRuntimeDefault)
+	require.NoError(t, unstructured.SetNestedSlice(debugged.Object, []interface{}{
+		map[string]interface{}{
+			"name":                "debugger",
+			"targetContainerName": "app",
+			"securityContext":     map[string]interface{}{"privileged": true},
+		},
+	}, "spec", "ephemeralContainers"))
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newPod("default", "restricted", restricted, seccompRuntimeDefault),
+		debugged,
+		newPod("default", "baseline", baseline, ""),
+		newPod("monitoring", "privileged", privileged, ""),
+	)
+
+	checks := func(failures []psaFailure) []string {
+		names := []string{}
+		for _, failure := range failures {
+			names = append(names, failure.Check)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected map[string]map[string][]string
+	}{
+		{
+			name: "all namespaces",
+			expected: map[string]map[string][]string{
+				"restricted": {
+					psaLevelPrivileged: {},
+					psaLevelBaseline:   {},
+					psaLevelRestricted: {},
+				},
+				"debugged": {
+					psaLevelPrivileged: {},
+					psaLevelBaseline:   {"privileged"},
+					psaLevelRestricted: {"privileged", "allowPrivilegeEscalation", "capabilities_restricted"},
+				},
+				"baseline": {
+					psaLevelPrivileged: {},
+					psaLevelBaseline:   {},
+					psaLevelRestricted: {"allowPrivilegeEscalation", "runAsNonRoot", "seccompProfile_restricted", "capabilities_restricted"},
+				},
+				"privileged": {
+					psaLevelPrivileged: {},
+					psaLevelBaseline:   {"hostNamespaces", "privileged", "hostPathVolumes", "hostPorts"},
+					psaLevelRestricted: {
+						"hostNamespaces", "privileged", "hostPathVolumes", "hostPorts",
+						"volumeTypes_restricted", "allowPrivilegeEscalation", "runAsNonRoot", "seccompProfile_restricted", "capabilities_restricted",
+					},
+				},
+			},
+		},
+		{
+			name:  "namespace",
+			query: "?namespace=monitoring",
+			expected: map[string]map[string][]string{
+				"privileged": {
+					psaLevelPrivileged: {},
+					psaLevelBaseline:   {"hostNamespaces", "privileged", "hostPathVolumes", "hostPorts"},
+					psaLevelRestricted: {
+						"hostNamespaces", "privileged", "hostPathVolumes", "hostPorts",
+						"volumeTypes_restricted", "allowPrivilegeEscalation", "runAsNonRoot", "seccompProfile_restricted", "capabilities_restricted",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newPSAAuditHandler(dynamicClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/podsecurity/audit"+tc.query, nil)
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got psaAuditResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+			verdicts := make(map[string]map[string][]string)
+			for _, pod := range got.Pods {
+				verdicts[pod.Pod] = make(map[string][]string)
+				for level, verdict := range pod.Levels {
+					assert.Equal(t, len(verdict.Failures) == 0, verdict.Allowed)
+					verdicts[pod.Pod][level] = checks(verdict.Failures)
+				}
+			}
+			assert.Equal(t, tc.expected, verdicts)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+// betaStorageClassAnnotation names the storage class of claims created before
+// storageClassName was added.
+const betaStorageClassAnnotation = "volume.beta.kubernetes.io/storage-class"
+
+type pvcSummary struct {
+	Name               string   `json:"name"`
+	Capacity           string   `json:"capacity"`
+	StorageClass       string   `json:"storageClass"`
+	AccessModes        []string `json:"accessModes"`
+	Phase              string   `json:"phase"`
+	Volume             string   `json:"volume,omitempty"`
+	ReclaimPolicy      string   `json:"reclaimPolicy,omitempty"`
+	VolumeBindingMode  string   `json:"volumeBindingMode,omitempty"`
+	ExpansionSupported bool     `json:"expansionSupported"`
+}
+
+type pvcResponse struct {
+	Claims []pvcSummary `json:"claims"`
+}
+
+type pvcHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*pvcHandler)(nil)
+
+func newPVCHandler(kubeClient kubernetes.Interface, logger log.Logger) *pvcHandler {
+	return &pvcHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the persistent volume claims
+// in a namespace, ordered by name, with their bound volume's reclaim policy and
+// their storage class's binding mode. A claim can be expanded when its storage
+// class sets allowVolumeExpansion. Bound claims use the storage class of their
+// volume.
+func (h *pvcHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	claims, err := h.kubeClient.CoreV1().PersistentVolumeClaims(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	storageClassList, err := h.kubeClient.StorageV1().StorageClasses().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	storageClasses := make(map[string]storagev1.StorageClass)
+	for _, storageClass := range storageClassList.Items {
+		storageClasses[storageClass.Name] = storageClass
+	}
+
+	resp := pvcResponse{
+		Claims: []pvcSummary{},
+	}
+
+	for _, claim := range claims.Items {
+		summary := pvcSummary{
+			Name:         claim.Name,
+			StorageClass: claimStorageClass(claim),
+			AccessModes:  []string{},
+			Phase:        string(claim.Status.Phase),
+			Volume:       claim.Spec.VolumeName,
+		}
+
+		for _, mode := range claim.Spec.AccessModes {
+			summary.AccessModes = append(summary.AccessModes, string(mode))
+		}
+
+		if capacity, ok := claim.Status.Capacity[corev1.ResourceStorage]; ok {
+			summary.Capacity = capacity.String()
+		} else if request, ok := claim.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			summary.Capacity = request.String()
+		}
+
+		if claim.Spec.VolumeName != "" {
+			volume, err := h.kubeClient.CoreV1().PersistentVolumes().Get(claim.Spec.VolumeName, metav1.GetOptions{})
+			switch {
+			case kerrors.IsNotFound(err):
+			case err != nil:
+				RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+				return
+			default:
+				summary.ReclaimPolicy = string(volume.Spec.PersistentVolumeReclaimPolicy)
+				if volume.Spec.StorageClassName != "" {
+					summary.StorageClass = volume.Spec.StorageClassName
+				}
+			}
+		}
+
+		if storageClass, ok := storageClasses[summary.StorageClass]; ok {
+			summary.ExpansionSupported = storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion
+
+			summary.VolumeBindingMode = string(storagev1.VolumeBindingImmediate)
+			if storageClass.VolumeBindingMode != nil {
+				summary.VolumeBindingMode = string(*storageClass.VolumeBindingMode)
+			}
+		}
+
+		resp.Claims = append(resp.Claims, summary)
+	}
+
+	sort.Slice(resp.Claims, func(i, j int) bool {
+		return resp.Claims[i].Name < resp.Claims[j].Name
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// claimStorageClass returns the storage class a claim requests.
+func claimStorageClass(claim corev1.PersistentVolumeClaim) string {
+	if claim.Spec.StorageClassName != nil {
+		return *claim.Spec.StorageClassName
+	}
+
+	return claim.Annotations[betaStorageClassAnnotation]
+}
@@ -0,0 +1,126 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_pvcHandler(t *testing.T) {
+	yes := true
+	fast, slow := "fast", "slow"
+	waitForFirstConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&storagev1.StorageClass{
+			ObjectMeta:           metav1.ObjectMeta{Name: "fast"},
+			AllowVolumeExpansion: &yes,
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:        metav1.ObjectMeta{Name: "slow"},
+			VolumeBindingMode: &waitForFirstConsumer,
+		},
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-data"},
+			Spec: corev1.PersistentVolumeSpec{
+				StorageClassName:              "fast",
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+			},
+		},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: &fast,
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				VolumeName:       "pv-data",
+			},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Phase:    corev1.ClaimBound,
+				Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+			},
+		},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "logs", Namespace: "default"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: &slow,
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+				},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "legacy",
+				Namespace:   "default",
+				Annotations: map[string]string{betaStorageClassAnnotation: "unknown"},
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				VolumeName: "pv-deleted",
+			},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimLost},
+		},
+	)
+
+	handler := newPVCHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/pvc/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp pvcResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+	expected := []pvcSummary{
+		{
+			Name:               "data",
+			Capacity:           "10Gi",
+			StorageClass:       "fast",
+			AccessModes:        []string{"ReadWriteOnce"},
+			Phase:              "Bound",
+			Volume:             "pv-data",
+			ReclaimPolicy:      "Retain",
+			VolumeBindingMode:  "Immediate",
+			ExpansionSupported: true,
+		},
+		{
+			Name:         "legacy",
+			StorageClass: "unknown",
+			AccessModes:  []string{},
+			Phase:        "Lost",
+			Volume:       "pv-deleted",
+		},
+		{
+			Name:              "logs",
+			Capacity:          "1Gi",
+			StorageClass:      "slow",
+			AccessModes:       []string{"ReadWriteMany"},
+			Phase:             "Pending",
+			VolumeBindingMode: "WaitForFirstConsumer",
+		},
+	}
+
+	assert.Equal(t, expected, resp.Claims)
+}
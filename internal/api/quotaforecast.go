@@ -0,0 +1,404 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// quotaSnapshotInterval is how often quota usage is recorded.
+	quotaSnapshotInterval = time.Hour
+
+	// quotaSnapshotRetention is how long quota usage snapshots are kept and
+	// the window growth is measured over.
+	quotaSnapshotRetention = 7 * 24 * time.Hour
+
+	// quotaForecastHorizon is how far ahead exhaustion is projected.
+	quotaForecastHorizon = 30 * 24 * time.Hour
+
+	quotaGrowthConfigured = "configured"
+	quotaGrowthMeasured   = "measured"
+)
+
+// quotaSnapshot is the usage of a resource quota at a point in time.
+type quotaSnapshot struct {
+	Time      time.Time         `json:"time"`
+	Namespace string            `json:"namespace"`
+	Quota     string            `json:"quota"`
+	Used      map[string]string `json:"used"`
+}
+
+// quotaSnapshotStore keeps a week of quota usage snapshots. When it has a
+// path, snapshots are kept in a JSON lines file there so they survive
+// restarts. Snapshots older than the retention window are dropped, and the
+// file rewritten without them, when it is loaded and as snapshots are added.
+type quotaSnapshotStore struct {
+	file  *jsonLinesFile
+	nowFn func() time.Time
+
+	loadOnce  sync.Once
+	mu        sync.Mutex
+	snapshots []quotaSnapshot
+}
+
+func newQuotaSnapshotStore(path string) *quotaSnapshotStore {
+	s := &quotaSnapshotStore{
+		nowFn: time.Now,
+	}
+	if path != "" {
+		s.file = &jsonLinesFile{path: path}
+	}
+
+	return s
+}
+
+// load reads the snapshots file, if any, the first time it is called. The
+// file is compacted when it holds stale snapshots.
+func (s *quotaSnapshotStore) load() error {
+	var err error
+	s.loadOnce.Do(func() {
+		if s.file == nil {
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		lines, readErr := s.file.read(func(line []byte) {
+			var snapshot quotaSnapshot
+			if json.Unmarshal(line, &snapshot) != nil {
+				return
+			}
+			s.snapshots = append(s.snapshots, snapshot)
+		})
+		if readErr != nil {
+			err = errors.Wrap(readErr, "load quota snapshots")
+			return
+		}
+
+		s.prune()
+		if lines > len(s.snapshots) {
+			err = s.save()
+		}
+	})
+
+	return err
+}
+
+// add records snapshots, dropping those older than the retention window.
+func (s *quotaSnapshotStore) add(snapshots ...quotaSnapshot) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshots = append(s.snapshots, snapshots...)
+	s.prune()
+
+	if s.file == nil {
+		return nil
+	}
+
+	return s.save()
+}
+
+// save writes the retained snapshots to the file.
+func (s *quotaSnapshotStore) save() error {
+	err := s.file.write(func(encoder *json.Encoder) error {
+		for _, snapshot := range s.snapshots {
+			if err := encoder.Encode(snapshot); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return errors.Wrap(err, "write quota snapshots")
+}
+
+// list returns the retained snapshots of a quota, oldest first.
+func (s *quotaSnapshotStore) list(namespace, quota string) ([]quotaSnapshot, error) {
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+
+	var snapshots []quotaSnapshot
+	for _, snapshot := range s.snapshots {
+		if snapshot.Namespace == namespace && snapshot.Quota == quota {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.Before(snapshots[j].Time)
+	})
+
+	return snapshots, nil
+}
+
+func (s *quotaSnapshotStore) prune() {
+	cutoff := s.nowFn().Add(-quotaSnapshotRetention)
+
+	retained := s.snapshots[:0]
+	for _, snapshot := range s.snapshots {
+		if !snapshot.Time.Before(cutoff) {
+			retained = append(retained, snapshot)
+		}
+	}
+	s.snapshots = retained
+}
+
+// recordQuotaSnapshots records the usage of every resource quota in the
+// cluster each interval until ctx is done.
+func recordQuotaSnapshots(ctx context.Context, kubeClient kubernetes.Interface, store *quotaSnapshotStore, interval time.Duration, logger log.Logger) {
+	record := func() {
+		quotas, err := kubeClient.CoreV1().ResourceQuotas(metav1.NamespaceAll).List(metav1.ListOptions{})
+		if err != nil {
+			logger.WithErr(err).Errorf("list resource quotas for snapshot")
+			return
+		}
+
+		now := store.nowFn()
+
+		var snapshots []quotaSnapshot
+		for _, quota := range quotas.Items {
+			used := make(map[string]string)
+			for name, quantity := range quota.Status.Used {
+				used[string(name)] = quantity.String()
+			}
+
+			snapshots = append(snapshots, quotaSnapshot{
+				Time:      now,
+				Namespace: quota.Namespace,
+				Quota:     quota.Name,
+				Used:      used,
+			})
+		}
+
+		if err := store.add(snapshots...); err != nil {
+			logger.WithErr(err).Errorf("record quota snapshots")
+		}
+	}
+
+	record()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			record()
+		}
+	}
+}
+
+type quotaForecast struct {
+	Quota                   string     `json:"quota"`
+	Resource                string     `json:"resource"`
+	CurrentUsed             string     `json:"currentUsed"`
+	Hard                    string     `json:"hard"`
+	GrowthPerDay            float64    `json:"growthPerDay"`
+	GrowthSource            string     `json:"growthSource"`
+	ProjectedExhaustionDate *time.Time `json:"projectedExhaustionDate"`
+}
+
+type quotaForecastResponse struct {
+	Forecasts []quotaForecast `json:"forecasts"`
+}
+
+type quotaForecastHandler struct {
+	kubeClient kubernetes.Interface
+	snapshots  *quotaSnapshotStore
+	nowFn      func() time.Time
+	logger     log.Logger
+}
+
+var _ http.Handler = (*quotaForecastHandler)(nil)
+
+func newQuotaForecastHandler(kubeClient kubernetes.Interface, snapshots *quotaSnapshotStore, logger log.Logger) *quotaForecastHandler {
+	return &quotaForecastHandler{
+		kubeClient: kubeClient,
+		snapshots:  snapshots,
+		nowFn:      time.Now,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the resource quota dimensions
+// in a namespace which are projected to be exhausted within 30 days. Growth is
+// the `growthRate` query parameter, a percentage of current usage per day, or
+// else a linear regression over the past week of hourly usage snapshots.
+// Dimensions without enough history to measure are only returned when they
+// are already exhausted.
+func (h *quotaForecastHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	var growthRate *float64
+	if s := r.URL.Query().Get("growthRate"); s != "" {
+		rate, err := strconv.ParseFloat(s, 64)
+		if err != nil || rate < 0 {
+			RespondWithError(w, http.StatusBadRequest, "growthRate must be a non-negative percentage", h.logger)
+			return
+		}
+		growthRate = &rate
+	}
+
+	quotas, err := h.kubeClient.CoreV1().ResourceQuotas(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	now := h.nowFn()
+
+	resp := quotaForecastResponse{
+		Forecasts: []quotaForecast{},
+	}
+
+	for _, quota := range quotas.Items {
+		snapshots, err := h.snapshots.list(namespace, quota.Name)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		for name, hard := range quota.Status.Hard {
+			used, ok := quota.Status.Used[name]
+			if !ok {
+				continue
+			}
+
+			forecast := quotaForecast{
+				Quota:       quota.Name,
+				Resource:    string(name),
+				CurrentUsed: used.String(),
+				Hard:        hard.String(),
+			}
+
+			if growthRate != nil {
+				forecast.GrowthPerDay = quantityValue(used) * *growthRate / 100
+				forecast.GrowthSource = quotaGrowthConfigured
+			} else {
+				forecast.GrowthPerDay = measuredQuotaGrowth(snapshots, name, used, now)
+				forecast.GrowthSource = quotaGrowthMeasured
+			}
+
+			exhaustion, ok := projectQuotaExhaustion(quantityValue(used), quantityValue(hard), forecast.GrowthPerDay, now)
+			if !ok {
+				continue
+			}
+			forecast.ProjectedExhaustionDate = &exhaustion
+
+			resp.Forecasts = append(resp.Forecasts, forecast)
+		}
+	}
+
+	sort.Slice(resp.Forecasts, func(i, j int) bool {
+		a, b := resp.Forecasts[i], resp.Forecasts[j]
+		if !a.ProjectedExhaustionDate.Equal(*b.ProjectedExhaustionDate) {
+			return a.ProjectedExhaustionDate.Before(*b.ProjectedExhaustionDate)
+		}
+		if a.Quota != b.Quota {
+			return a.Quota < b.Quota
+		}
+		return a.Resource < b.Resource
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// measuredQuotaGrowth fits a line to a resource's usage in the snapshots and
+// its current usage, and returns the slope per day. It needs usage from at
+// least two distinct times, and returns zero otherwise.
+func measuredQuotaGrowth(snapshots []quotaSnapshot, name corev1.ResourceName, current resource.Quantity, now time.Time) float64 {
+	var xs, ys []float64
+	for _, snapshot := range snapshots {
+		value, ok := snapshot.Used[string(name)]
+		if !ok {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			continue
+		}
+		xs = append(xs, snapshot.Time.Sub(now).Hours()/24)
+		ys = append(ys, quantityValue(quantity))
+	}
+	xs = append(xs, 0)
+	ys = append(ys, quantityValue(current))
+
+	n := float64(len(xs))
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var covariance, variance float64
+	for i := range xs {
+		covariance += (xs[i] - meanX) * (ys[i] - meanY)
+		variance += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+
+	if variance == 0 {
+		return 0
+	}
+
+	return covariance / variance
+}
+
+// projectQuotaExhaustion returns when usage growing linearly reaches hard, if
+// that is within the forecast horizon. Usage at or over hard is exhausted now.
+func projectQuotaExhaustion(used, hard, perDay float64, now time.Time) (time.Time, bool) {
+	if used >= hard {
+		return now, true
+	}
+
+	if perDay <= 0 {
+		return time.Time{}, false
+	}
+
+	days := (hard - used) / perDay
+	if days > quotaForecastHorizon.Hours()/24 {
+		return time.Time{}, false
+	}
+
+	return now.Add(time.Duration(math.Round(days * float64(24*time.Hour)))), true
+}
+
+// quantityValue returns a quantity as a float, keeping millis for resources
+// such as CPU.
+func quantityValue(q resource.Quantity) float64 {
+	return float64(q.MilliValue()) / 1000
+}
@@ -0,0 +1,171 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_quotaForecastHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "default"},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{
+				corev1.ResourceRequestsCPU:    resource.MustParse("10"),
+				corev1.ResourceRequestsMemory: resource.MustParse("10Gi"),
+				corev1.ResourcePods:           resource.MustParse("20"),
+			},
+			Used: corev1.ResourceList{
+				corev1.ResourceRequestsCPU:    resource.MustParse("8"),
+				corev1.ResourceRequestsMemory: resource.MustParse("1Gi"),
+				corev1.ResourcePods:           resource.MustParse("20"),
+			},
+		},
+	}
+
+	store := newQuotaSnapshotStore("")
+	store.nowFn = func() time.Time { return now }
+	for day := 1; day <= 3; day++ {
+		require.NoError(t, store.add(quotaSnapshot{
+			Time:      now.Add(-time.Duration(day) * 24 * time.Hour),
+			Namespace: "default",
+			Quota:     "compute",
+			Used: map[string]string{
+				string(corev1.ResourceRequestsCPU):    resource.NewQuantity(int64(8-day), resource.DecimalSI).String(),
+				string(corev1.ResourceRequestsMemory): "1Gi",
+			},
+		}))
+	}
+
+	twoDays := now.Add(48 * time.Hour)
+	halfDay := now.Add(12 * time.Hour)
+	eighteenDays := now.Add(18 * 24 * time.Hour)
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expected     []quotaForecast
+	}{
+		{
+			name:         "measured growth",
+			expectedCode: http.StatusOK,
+			expected: []quotaForecast{
+				{Quota: "compute", Resource: "pods", CurrentUsed: "20", Hard: "20", GrowthSource: quotaGrowthMeasured, ProjectedExhaustionDate: &now},
+				{Quota: "compute", Resource: "requests.cpu", CurrentUsed: "8", Hard: "10", GrowthPerDay: 1, GrowthSource: quotaGrowthMeasured, ProjectedExhaustionDate: &twoDays},
+			},
+		},
+		{
+			name:         "configured growth",
+			query:        "?growthRate=50",
+			expectedCode: http.StatusOK,
+			expected: []quotaForecast{
+				{Quota: "compute", Resource: "pods", CurrentUsed: "20", Hard: "20", GrowthPerDay: 10, GrowthSource: quotaGrowthConfigured, ProjectedExhaustionDate: &now},
+				{Quota: "compute", Resource: "requests.cpu", CurrentUsed: "8", Hard: "10", GrowthPerDay: 4, GrowthSource: quotaGrowthConfigured, ProjectedExhaustionDate: &halfDay},
+				{Quota: "compute", Resource: "requests.memory", CurrentUsed: "1Gi", Hard: "10Gi", GrowthPerDay: 512 * 1024 * 1024, GrowthSource: quotaGrowthConfigured, ProjectedExhaustionDate: &eighteenDays},
+			},
+		},
+		{
+			name:         "invalid growth rate",
+			query:        "?growthRate=fast",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := newQuotaForecastHandler(kubefake.NewSimpleClientset(quota), store, log.NopLogger())
+			handler.nowFn = func() time.Time { return now }
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/quotaforecast"+test.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, test.expectedCode, resp.Code)
+
+			if test.expectedCode != http.StatusOK {
+				return
+			}
+
+			var got quotaForecastResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+			require.Len(t, got.Forecasts, len(test.expected))
+			for i := range test.expected {
+				expected, actual := test.expected[i], got.Forecasts[i]
+				assert.InDelta(t, expected.GrowthPerDay, actual.GrowthPerDay, 1e-9)
+				assert.True(t, expected.ProjectedExhaustionDate.Equal(*actual.ProjectedExhaustionDate))
+				expected.GrowthPerDay, actual.GrowthPerDay = 0, 0
+				expected.ProjectedExhaustionDate, actual.ProjectedExhaustionDate = nil, nil
+				assert.Equal(t, expected, actual)
+			}
+		})
+	}
+}
+
+func Test_quotaSnapshotStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quota-snapshots")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "snapshots.jsonl")
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	kubeClient := kubefake.NewSimpleClientset(&corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "default"},
+		Status: corev1.ResourceQuotaStatus{
+			Used: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("3")},
+		},
+	})
+
+	store := newQuotaSnapshotStore(path)
+	store.nowFn = func() time.Time { return now }
+
+	require.NoError(t, store.add(quotaSnapshot{
+		Time: now.Add(-8 * 24 * time.Hour), Namespace: "default", Quota: "compute",
+		Used: map[string]string{"pods": "1"},
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	recordQuotaSnapshots(ctx, kubeClient, store, time.Hour, log.NopLogger())
+
+	reloaded := newQuotaSnapshotStore(path)
+	reloaded.nowFn = func() time.Time { return now }
+
+	snapshots, err := reloaded.list("default", "compute")
+	require.NoError(t, err)
+
+	require.Len(t, snapshots, 1, "snapshots older than a week are dropped")
+	assert.True(t, now.Equal(snapshots[0].Time))
+	assert.Equal(t, map[string]string{"pods": "3"}, snapshots[0].Used)
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "\n"), "the file only holds retained snapshots")
+}
@@ -0,0 +1,245 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	prometheusScrapeAnnotation = "prometheus.io/scrape"
+	prometheusPortAnnotation   = "prometheus.io/port"
+	prometheusPathAnnotation   = "prometheus.io/path"
+
+	defaultPrometheusPath = "/metrics"
+
+	// metricsPortName is the container port used when a pod has no port
+	// annotation.
+	metricsPortName = "metrics"
+
+	// rawMetricsTimeout bounds the port forward and scrape of a pod.
+	rawMetricsTimeout = 5 * time.Second
+
+	// maxRawMetricsSize is the largest scrape which will be returned.
+	maxRawMetricsSize = 10 << 20
+)
+
+// podMetricsScraper fetches a path from a port on a pod.
+type podMetricsScraper func(ctx context.Context, namespace, name string, port int, path string) ([]byte, error)
+
+type rawMetricsHandler struct {
+	kubeClient kubernetes.Interface
+	scrape     podMetricsScraper
+	logger     log.Logger
+}
+
+var _ http.Handler = (*rawMetricsHandler)(nil)
+
+func newRawMetricsHandler(kubeClient kubernetes.Interface, restConfig *rest.Config, logger log.Logger) *rawMetricsHandler {
+	return &rawMetricsHandler{
+		kubeClient: kubeClient,
+		scrape: func(ctx context.Context, namespace, name string, port int, path string) ([]byte, error) {
+			return scrapeThroughPortForward(ctx, kubeClient, restConfig, namespace, name, port, path)
+		},
+		logger: logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns a pod's raw Prometheus
+// metrics. The `pod` query parameter selects the pod; when it is omitted the
+// first running pod annotated for scraping is used. The port and path come from the
+// pod's `prometheus.io/port` and `prometheus.io/path` annotations. Pods
+// without a port annotation are scraped on the port named `metrics` in the
+// container given by the `container` query parameter, or in any container.
+func (h *rawMetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	podName := r.URL.Query().Get("pod")
+	containerName := r.URL.Query().Get("container")
+
+	var pod *corev1.Pod
+	if podName != "" {
+		var err error
+		pod, err = h.kubeClient.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+				return
+			}
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+	} else {
+		pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		pod = firstScrapedPod(pods.Items)
+		if pod == nil {
+			RespondWithError(w, http.StatusNotFound, fmt.Sprintf("no pods in %s are annotated for scraping", namespace), h.logger)
+			return
+		}
+	}
+
+	if pod.Status.Phase != corev1.PodRunning {
+		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("pod %q is not running", pod.Name), h.logger)
+		return
+	}
+
+	port, err := prometheusPort(pod, containerName)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
+	path := pod.Annotations[prometheusPathAnnotation]
+	if path == "" {
+		path = defaultPrometheusPath
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), rawMetricsTimeout)
+	defer cancel()
+
+	metrics, err := h.scrape(ctx, namespace, pod.Name, port, path)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			RespondWithError(w, http.StatusGatewayTimeout, fmt.Sprintf("scraping pod %q timed out", pod.Name), h.logger)
+			return
+		}
+		RespondWithError(w, http.StatusBadGateway, err.Error(), h.logger)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := w.Write(metrics); err != nil {
+		h.logger.WithErr(err).Errorf("writing raw metrics")
+	}
+}
+
+// firstScrapedPod returns the first running pod by name which is annotated
+// for Prometheus scraping.
+func firstScrapedPod(pods []corev1.Pod) *corev1.Pod {
+	var found *corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		if pod.Annotations[prometheusScrapeAnnotation] != "true" && pod.Annotations[prometheusPortAnnotation] == "" {
+			continue
+		}
+		if found == nil || pod.Name < found.Name {
+			found = pod
+		}
+	}
+
+	return found
+}
+
+// prometheusPort returns the port a pod's metrics are served on.
+func prometheusPort(pod *corev1.Pod, containerName string) (int, error) {
+	if value := pod.Annotations[prometheusPortAnnotation]; value != "" {
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			return 0, errors.Errorf("pod %q has invalid %s annotation %q", pod.Name, prometheusPortAnnotation, value)
+		}
+		return port, nil
+	}
+
+	for _, container := range pod.Spec.Containers {
+		if containerName != "" && container.Name != containerName {
+			continue
+		}
+		for _, port := range container.Ports {
+			if port.Name == metricsPortName {
+				return int(port.ContainerPort), nil
+			}
+		}
+	}
+
+	return 0, errors.Errorf("pod %q has no %s annotation or %q port", pod.Name, prometheusPortAnnotation, metricsPortName)
+}
+
+// scrapeThroughPortForward forwards a local port to the pod and fetches the
+// metrics path over it. The forward is closed once the scrape finishes.
+func scrapeThroughPortForward(ctx context.Context, kubeClient kubernetes.Interface, restConfig *rest.Config, namespace, name string, port int, path string) ([]byte, error) {
+	req := kubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(name).
+		SubResource("portforward")
+
+	transport, upgrader, err := spdy.RoundTripperFor(restConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "create port forward transport")
+	}
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	defer close(stopCh)
+
+	fw, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", port)},
+		stopCh, readyCh, ioutil.Discard, ioutil.Discard)
+	if err != nil {
+		return nil, errors.Wrap(err, "create port forward")
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fw.ForwardPorts()
+	}()
+
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return nil, errors.Wrap(err, "forward port")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	ports, err := fw.GetPorts()
+	if err != nil || len(ports) == 0 {
+		return nil, errors.New("port forward has no local port")
+	}
+
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", ports[0].Local, path)
+	scrapeReq, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create scrape request")
+	}
+
+	resp, err := http.DefaultClient.Do(scrapeReq.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "scrape metrics")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("metrics endpoint returned %s", resp.Status)
+	}
+
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxRawMetricsSize))
+}
@@ -0,0 +1,125 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_rawMetricsHandler(t *testing.T) {
+	newPod := func(name string, annotations map[string]string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "app", Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}}},
+					{Name: "exporter", Ports: []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9100}}},
+				},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newPod("web", map[string]string{
+			prometheusScrapeAnnotation: "true",
+			prometheusPortAnnotation:   "9090",
+			prometheusPathAnnotation:   "/stats",
+		}, corev1.PodRunning),
+		newPod("exporter", nil, corev1.PodRunning),
+		newPod("pending", map[string]string{prometheusPortAnnotation: "9090"}, corev1.PodPending),
+		newPod("worker", map[string]string{prometheusPortAnnotation: "http"}, corev1.PodRunning),
+	)
+
+	type scrape struct {
+		pod  string
+		port int
+		path string
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expected     scrape
+	}{
+		{
+			name:         "annotated pod",
+			query:        "?pod=web",
+			expectedCode: http.StatusOK,
+			expected:     scrape{pod: "web", port: 9090, path: "/stats"},
+		},
+		{
+			name:         "first annotated pod",
+			expectedCode: http.StatusOK,
+			expected:     scrape{pod: "web", port: 9090, path: "/stats"},
+		},
+		{
+			name:         "metrics port",
+			query:        "?pod=exporter&container=exporter",
+			expectedCode: http.StatusOK,
+			expected:     scrape{pod: "exporter", port: 9100, path: "/metrics"},
+		},
+		{
+			name:         "container without metrics port",
+			query:        "?pod=exporter&container=app",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid port annotation",
+			query:        "?pod=worker",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "pod not running",
+			query:        "?pod=pending",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "pod not found",
+			query:        "?pod=missing",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got scrape
+			handler := newRawMetricsHandler(kubeClient, nil, log.NopLogger())
+			handler.scrape = func(ctx context.Context, namespace, name string, port int, path string) ([]byte, error) {
+				got = scrape{pod: name, port: port, path: path}
+				return []byte("up 1\n"), nil
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/metricsraw/default"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, tc.expected, got)
+			assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", resp.Header().Get("Content-Type"))
+			assert.Equal(t, "up 1\n", resp.Body.String())
+		})
+	}
+}
@@ -0,0 +1,281 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	clusterAdminRole = "cluster-admin"
+
+	// serviceAccountUserPrefix prefixes the user names of service accounts.
+	serviceAccountUserPrefix = "system:serviceaccount:"
+)
+
+type clusterRoleSummary struct {
+	Name              string              `json:"name"`
+	Aggregated        bool                `json:"aggregated"`
+	RuleCount         int                 `json:"ruleCount"`
+	WildcardVerbs     bool                `json:"wildcardVerbs"`
+	WildcardResources bool                `json:"wildcardResources"`
+	ClusterAdmin      bool                `json:"clusterAdmin"`
+	PowerfulRules     []rbacv1.PolicyRule `json:"powerfulRules"`
+}
+
+type clusterRolesResponse struct {
+	ClusterRoles []clusterRoleSummary `json:"clusterRoles"`
+}
+
+type clusterRolesHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*clusterRolesHandler)(nil)
+
+func newClusterRolesHandler(kubeClient kubernetes.Interface, logger log.Logger) *clusterRolesHandler {
+	return &clusterRolesHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns cluster roles with a summary of
+// their most powerful permissions.
+func (h *clusterRolesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	clusterRoles, err := h.kubeClient.RbacV1().ClusterRoles().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := clusterRolesResponse{
+		ClusterRoles: []clusterRoleSummary{},
+	}
+
+	for _, clusterRole := range clusterRoles.Items {
+		summary := clusterRoleSummary{
+			Name:          clusterRole.Name,
+			Aggregated:    clusterRole.AggregationRule != nil,
+			RuleCount:     len(clusterRole.Rules),
+			ClusterAdmin:  grantsClusterAdmin(clusterRole.Rules),
+			PowerfulRules: []rbacv1.PolicyRule{},
+		}
+
+		for _, rule := range clusterRole.Rules {
+			wildcardVerbs := containsWildcard(rule.Verbs)
+			wildcardResources := containsWildcard(rule.Resources) || containsWildcard(rule.NonResourceURLs)
+
+			summary.WildcardVerbs = summary.WildcardVerbs || wildcardVerbs
+			summary.WildcardResources = summary.WildcardResources || wildcardResources
+
+			if wildcardVerbs || wildcardResources || containsWildcard(rule.APIGroups) {
+				summary.PowerfulRules = append(summary.PowerfulRules, rule)
+			}
+		}
+
+		resp.ClusterRoles = append(resp.ClusterRoles, summary)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+type roleBindingSubject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// ServiceAccount is the namespace/name of the service account the subject
+	// refers to, if any.
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+	// Exists is set for service account subjects and reports whether the
+	// service account exists.
+	Exists *bool `json:"exists,omitempty"`
+}
+
+type roleBindingSummary struct {
+	Name         string               `json:"name"`
+	RoleRef      rbacv1.RoleRef       `json:"roleRef"`
+	Subjects     []roleBindingSubject `json:"subjects"`
+	ClusterAdmin bool                 `json:"clusterAdmin"`
+}
+
+type roleBindingsResponse struct {
+	RoleBindings []roleBindingSummary `json:"roleBindings"`
+}
+
+type roleBindingsHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*roleBindingsHandler)(nil)
+
+func newRoleBindingsHandler(kubeClient kubernetes.Interface, logger log.Logger) *roleBindingsHandler {
+	return &roleBindingsHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the role bindings in a
+// namespace. Service account subjects are resolved, and bindings granting
+// cluster-admin are flagged.
+func (h *roleBindingsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	roleBindings, err := h.kubeClient.RbacV1().RoleBindings(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resolver := &subjectResolver{
+		kubeClient: h.kubeClient,
+		exists:     make(map[string]bool),
+	}
+	clusterAdminRoles := make(map[string]bool)
+
+	resp := roleBindingsResponse{
+		RoleBindings: []roleBindingSummary{},
+	}
+
+	for _, roleBinding := range roleBindings.Items {
+		summary := roleBindingSummary{
+			Name:     roleBinding.Name,
+			RoleRef:  roleBinding.RoleRef,
+			Subjects: []roleBindingSubject{},
+		}
+
+		if roleBinding.RoleRef.Kind == "ClusterRole" {
+			clusterAdmin, ok := clusterAdminRoles[roleBinding.RoleRef.Name]
+			if !ok {
+				clusterAdmin, err = h.isClusterAdminRole(roleBinding.RoleRef.Name)
+				if err != nil {
+					RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+					return
+				}
+				clusterAdminRoles[roleBinding.RoleRef.Name] = clusterAdmin
+			}
+			summary.ClusterAdmin = clusterAdmin
+		}
+
+		for _, subject := range roleBinding.Subjects {
+			resolved, err := resolver.resolve(subject, roleBinding.Namespace)
+			if err != nil {
+				RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+				return
+			}
+			summary.Subjects = append(summary.Subjects, resolved)
+		}
+
+		resp.RoleBindings = append(resp.RoleBindings, summary)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// isClusterAdminRole returns true if the named cluster role is cluster-admin
+// or grants every verb on every resource.
+func (h *roleBindingsHandler) isClusterAdminRole(name string) (bool, error) {
+	if name == clusterAdminRole {
+		return true, nil
+	}
+
+	clusterRole, err := h.kubeClient.RbacV1().ClusterRoles().Get(name, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return grantsClusterAdmin(clusterRole.Rules), nil
+}
+
+// subjectResolver resolves binding subjects to service accounts, caching
+// service account lookups.
+type subjectResolver struct {
+	kubeClient kubernetes.Interface
+	exists     map[string]bool
+}
+
+func (r *subjectResolver) resolve(subject rbacv1.Subject, bindingNamespace string) (roleBindingSubject, error) {
+	resolved := roleBindingSubject{
+		Kind:      subject.Kind,
+		Name:      subject.Name,
+		Namespace: subject.Namespace,
+	}
+
+	var namespace, name string
+	switch subject.Kind {
+	case rbacv1.ServiceAccountKind:
+		namespace, name = subject.Namespace, subject.Name
+		if namespace == "" {
+			namespace = bindingNamespace
+		}
+	case rbacv1.UserKind:
+		// Service accounts can also be bound as users by their user name.
+		parts := strings.Split(strings.TrimPrefix(subject.Name, serviceAccountUserPrefix), ":")
+		if !strings.HasPrefix(subject.Name, serviceAccountUserPrefix) || len(parts) != 2 {
+			return resolved, nil
+		}
+		namespace, name = parts[0], parts[1]
+	default:
+		return resolved, nil
+	}
+
+	key := namespace + "/" + name
+	exists, ok := r.exists[key]
+	if !ok {
+		_, err := r.kubeClient.CoreV1().ServiceAccounts(namespace).Get(name, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			exists = true
+		case kerrors.IsNotFound(err):
+			exists = false
+		default:
+			return roleBindingSubject{}, err
+		}
+		r.exists[key] = exists
+	}
+
+	resolved.ServiceAccount = key
+	resolved.Exists = &exists
+
+	return resolved, nil
+}
+
+// grantsClusterAdmin returns true if the rules allow every verb on every
+// resource in every API group.
+func grantsClusterAdmin(rules []rbacv1.PolicyRule) bool {
+	for _, rule := range rules {
+		if containsWildcard(rule.APIGroups) && containsWildcard(rule.Resources) && containsWildcard(rule.Verbs) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsWildcard(values []string) bool {
+	for _, value := range values {
+		if value == "*" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,159 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_clusterRolesHandler(t *testing.T) {
+	readPods := rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}}
+	allSecrets := rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"*"}}
+	everything := rbacv1.PolicyRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "admin"},
+			Rules:      []rbacv1.PolicyRule{everything},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta:      metav1.ObjectMeta{Name: "secrets"},
+			AggregationRule: &rbacv1.AggregationRule{},
+			Rules:           []rbacv1.PolicyRule{readPods, allSecrets},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "view"},
+			Rules:      []rbacv1.PolicyRule{readPods},
+		},
+	)
+
+	handler := newClusterRolesHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/clusterroles", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got clusterRolesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := clusterRolesResponse{
+		ClusterRoles: []clusterRoleSummary{
+			{
+				Name:              "admin",
+				RuleCount:         1,
+				WildcardVerbs:     true,
+				WildcardResources: true,
+				ClusterAdmin:      true,
+				PowerfulRules:     []rbacv1.PolicyRule{everything},
+			},
+			{
+				Name:          "secrets",
+				Aggregated:    true,
+				RuleCount:     2,
+				WildcardVerbs: true,
+				PowerfulRules: []rbacv1.PolicyRule{allSecrets},
+			},
+			{
+				Name:          "view",
+				RuleCount:     1,
+				PowerfulRules: []rbacv1.PolicyRule{},
+			},
+		},
+	}
+	assert.Equal(t, expected, got)
+}
+
+func Test_roleBindingsHandler(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"}},
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "ci", Namespace: "tools"}},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "superuser"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "admins", Namespace: "default"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.UserKind, Name: "alice"},
+				{Kind: rbacv1.GroupKind, Name: "ops"},
+			},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "superusers", Namespace: "default"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "superuser"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.UserKind, Name: "system:serviceaccount:tools:ci"},
+			},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "app"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.ServiceAccountKind, Name: "app"},
+				{Kind: rbacv1.ServiceAccountKind, Name: "deleted", Namespace: "default"},
+			},
+		},
+	)
+
+	handler := newRoleBindingsHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/rolebindings/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got roleBindingsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	exists, missing := true, false
+	expected := roleBindingsResponse{
+		RoleBindings: []roleBindingSummary{
+			{
+				Name:    "admins",
+				RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+				Subjects: []roleBindingSubject{
+					{Kind: rbacv1.UserKind, Name: "alice"},
+					{Kind: rbacv1.GroupKind, Name: "ops"},
+				},
+				ClusterAdmin: true,
+			},
+			{
+				Name:    "superusers",
+				RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "superuser"},
+				Subjects: []roleBindingSubject{
+					{Kind: rbacv1.UserKind, Name: "system:serviceaccount:tools:ci", ServiceAccount: "tools/ci", Exists: &exists},
+				},
+				ClusterAdmin: true,
+			},
+			{
+				Name:    "app",
+				RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "app"},
+				Subjects: []roleBindingSubject{
+					{Kind: rbacv1.ServiceAccountKind, Name: "app", ServiceAccount: "default/app", Exists: &exists},
+					{Kind: rbacv1.ServiceAccountKind, Name: "deleted", Namespace: "default", ServiceAccount: "default/deleted", Exists: &missing},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, got)
+}
@@ -0,0 +1,261 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	recommendationSourceVPA     = "vpa"
+	recommendationSourceMetrics = "metrics"
+
+	// metricsHeadroomPercent is added to observed usage when recommending
+	// requests from metrics-server data.
+	metricsHeadroomPercent = 15
+)
+
+var (
+	vpaGVR = schema.GroupVersionResource{
+		Group:    "autoscaling.k8s.io",
+		Version:  "v1",
+		Resource: "verticalpodautoscalers",
+	}
+
+	podMetricsGVR = schema.GroupVersionResource{
+		Group:    "metrics.k8s.io",
+		Version:  "v1beta1",
+		Resource: "pods",
+	}
+)
+
+type containerRecommendation struct {
+	Container          string              `json:"container"`
+	Source             string              `json:"source"`
+	CurrentRequest     corev1.ResourceList `json:"currentRequest"`
+	RecommendedRequest corev1.ResourceList `json:"recommendedRequest"`
+	SavingsEstimate    corev1.ResourceList `json:"savingsEstimate"`
+}
+
+type podRecommendation struct {
+	Pod        string                    `json:"pod"`
+	Workload   workloadRef               `json:"workload"`
+	Containers []containerRecommendation `json:"containers"`
+}
+
+type recommendationsResponse struct {
+	Pods []podRecommendation `json:"pods"`
+}
+
+type recommendationsHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*recommendationsHandler)(nil)
+
+func newRecommendationsHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *recommendationsHandler {
+	return &recommendationsHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns resource request recommendations
+// for pods in a namespace. Recommendations come from VerticalPodAutoscaler
+// objects when present, and otherwise from metrics-server usage.
+func (h *recommendationsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	replicaSets, err := h.kubeClient.AppsV1().ReplicaSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	vpas, _, err := listOptionalResource(h.dynamicClient, vpaGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	podMetrics, _, err := listOptionalResource(h.dynamicClient, podMetricsGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	vpaTargets := vpaRecommendationsByWorkload(vpas.Items)
+	usage := podMetricsByPod(podMetrics.Items)
+
+	resp := recommendationsResponse{
+		Pods: []podRecommendation{},
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		workload := podWorkload(pod, replicaSets.Items)
+
+		pr := podRecommendation{
+			Pod:      pod.Name,
+			Workload: workload,
+		}
+
+		for _, container := range pod.Spec.Containers {
+			current := container.Resources.Requests
+
+			var recommended corev1.ResourceList
+			source := recommendationSourceVPA
+
+			if target, ok := vpaTargets[workload][container.Name]; ok {
+				recommended = target
+			} else if used, ok := usage[pod.Name][container.Name]; ok {
+				recommended = withHeadroom(used)
+				source = recommendationSourceMetrics
+			} else {
+				continue
+			}
+
+			pr.Containers = append(pr.Containers, containerRecommendation{
+				Container:          container.Name,
+				Source:             source,
+				CurrentRequest:     current,
+				RecommendedRequest: recommended,
+				SavingsEstimate:    savings(current, recommended),
+			})
+		}
+
+		if len(pr.Containers) > 0 {
+			resp.Pods = append(resp.Pods, pr)
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// vpaRecommendationsByWorkload indexes VPA target recommendations by the
+// workload they target and container name.
+func vpaRecommendationsByWorkload(vpas []unstructured.Unstructured) map[workloadRef]map[string]corev1.ResourceList {
+	m := make(map[workloadRef]map[string]corev1.ResourceList)
+
+	for _, vpa := range vpas {
+		kind, _, _ := unstructured.NestedString(vpa.Object, "spec", "targetRef", "kind")
+		name, _, _ := unstructured.NestedString(vpa.Object, "spec", "targetRef", "name")
+		ref := workloadRef{Kind: kind, Name: name}
+
+		recommendations, _, _ := unstructured.NestedSlice(vpa.Object, "status", "recommendation", "containerRecommendations")
+		for _, item := range recommendations {
+			recommendation, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			containerName, _, _ := unstructured.NestedString(recommendation, "containerName")
+			target, _, _ := unstructured.NestedStringMap(recommendation, "target")
+
+			if m[ref] == nil {
+				m[ref] = make(map[string]corev1.ResourceList)
+			}
+			m[ref][containerName] = parseResourceList(target)
+		}
+	}
+
+	return m
+}
+
+// podMetricsByPod indexes metrics-server container usage by pod and container name.
+func podMetricsByPod(podMetrics []unstructured.Unstructured) map[string]map[string]corev1.ResourceList {
+	m := make(map[string]map[string]corev1.ResourceList)
+
+	for _, metrics := range podMetrics {
+		containers, _, _ := unstructured.NestedSlice(metrics.Object, "containers")
+		for _, item := range containers {
+			container, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			name, _, _ := unstructured.NestedString(container, "name")
+			usage, _, _ := unstructured.NestedStringMap(container, "usage")
+
+			if m[metrics.GetName()] == nil {
+				m[metrics.GetName()] = make(map[string]corev1.ResourceList)
+			}
+			m[metrics.GetName()][name] = parseResourceList(usage)
+		}
+	}
+
+	return m
+}
+
+// parseResourceList parses a map of resource names to quantities. Values
+// which are not valid quantities are skipped.
+func parseResourceList(in map[string]string) corev1.ResourceList {
+	list := corev1.ResourceList{}
+	for name, value := range in {
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			continue
+		}
+		list[corev1.ResourceName(name)] = q
+	}
+
+	return list
+}
+
+func withHeadroom(usage corev1.ResourceList) corev1.ResourceList {
+	list := corev1.ResourceList{}
+
+	if cpu, ok := usage[corev1.ResourceCPU]; ok {
+		milli := cpu.MilliValue() * (100 + metricsHeadroomPercent) / 100
+		list[corev1.ResourceCPU] = *resource.NewMilliQuantity(milli, resource.DecimalSI)
+	}
+
+	if memory, ok := usage[corev1.ResourceMemory]; ok {
+		bytes := memory.Value() * (100 + metricsHeadroomPercent) / 100
+		list[corev1.ResourceMemory] = *resource.NewQuantity(bytes, resource.BinarySI)
+	}
+
+	return list
+}
+
+// savings returns how much of each resource would be released by applying
+// the recommendation. Negative values mean the container is under-provisioned.
+func savings(current, recommended corev1.ResourceList) corev1.ResourceList {
+	list := corev1.ResourceList{}
+
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		target, ok := recommended[name]
+		if !ok {
+			continue
+		}
+
+		saved := current[name].DeepCopy()
+		saved.Sub(target)
+		list[name] = saved
+	}
+
+	return list
+}
@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_recommendationsHandler(t *testing.T) {
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web-1", Namespace: "default", UID: "rs",
+			OwnerReferences: controllerRef("Deployment", "web", "deployment"),
+		},
+	}
+
+	newPod := func(name string, ownerReferences []metav1.OwnerReference) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", OwnerReferences: ownerReferences},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name: "app",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("500m"),
+								corev1.ResourceMemory: resource.MustParse("512Mi"),
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		replicaSet,
+		newPod("web-1-abcde", controllerRef("ReplicaSet", "web-1", "rs")),
+		newPod("standalone", nil),
+	)
+
+	vpa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling.k8s.io/v1",
+		"kind":       "VerticalPodAutoscaler",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"targetRef": map[string]interface{}{"kind": "Deployment", "name": "web"},
+		},
+		"status": map[string]interface{}{
+			"recommendation": map[string]interface{}{
+				"containerRecommendations": []interface{}{
+					map[string]interface{}{
+						"containerName": "app",
+						"target":        map[string]interface{}{"cpu": "200m", "memory": "256Mi"},
+					},
+				},
+			},
+		},
+	}}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), vpa)
+	dynamicClient.PrependReactor("list", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		list := &unstructured.UnstructuredList{
+			Object: map[string]interface{}{"apiVersion": "metrics.k8s.io/v1beta1", "kind": "PodMetricsList"},
+			Items: []unstructured.Unstructured{
+				{Object: map[string]interface{}{
+					"apiVersion": "metrics.k8s.io/v1beta1",
+					"kind":       "PodMetrics",
+					"metadata":   map[string]interface{}{"name": "standalone", "namespace": "default"},
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":  "app",
+							"usage": map[string]interface{}{"cpu": "100m", "memory": "100Mi"},
+						},
+					},
+				}},
+			},
+		}
+		return true, list, nil
+	})
+
+	handler := newRecommendationsHandler(kubeClient, dynamicClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/recommendations/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got recommendationsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	byPod := make(map[string]containerRecommendation)
+	for _, pod := range got.Pods {
+		require.Len(t, pod.Containers, 1)
+		byPod[pod.Pod] = pod.Containers[0]
+	}
+
+	fromVPA := byPod["web-1-abcde"]
+	assert.Equal(t, recommendationSourceVPA, fromVPA.Source)
+	assert.Equal(t, "200m", fromVPA.RecommendedRequest.Cpu().String())
+	assert.Equal(t, "300m", fromVPA.SavingsEstimate.Cpu().String())
+	assert.Equal(t, "256Mi", fromVPA.SavingsEstimate.Memory().String())
+
+	fromMetrics := byPod["standalone"]
+	assert.Equal(t, recommendationSourceMetrics, fromMetrics.Source)
+	assert.Equal(t, "115m", fromMetrics.RecommendedRequest.Cpu().String())
+	assert.Equal(t, "385m", fromMetrics.SavingsEstimate.Cpu().String())
+}
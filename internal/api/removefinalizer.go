@@ -0,0 +1,158 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type removeFinalizerRequest struct {
+	Finalizer string `json:"finalizer"`
+	Confirm   bool   `json:"confirm"`
+}
+
+type removeFinalizerHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	user          string
+	logger        log.Logger
+}
+
+var _ http.Handler = (*removeFinalizerHandler)(nil)
+
+func newRemoveFinalizerHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, user string, logger log.Logger) *removeFinalizerHandler {
+	return &removeFinalizerHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		user:          user,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and removes a single finalizer from a
+// resource, returning the updated object. The request must set `confirm` to
+// true. The patch includes the resource version that was read so a
+// concurrent change to the finalizers results in a conflict rather than
+// being overwritten.
+func (h *removeFinalizerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req removeFinalizerRequest
+
+	defer func() {
+		if cErr := r.Body.Close(); cErr != nil {
+			h.logger.WithErr(cErr).Errorf("unable to close remove finalizer request body")
+		}
+	}()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
+	if req.Finalizer == "" {
+		RespondWithError(w, http.StatusBadRequest, "finalizer is required", h.logger)
+		return
+	}
+
+	if !req.Confirm {
+		RespondWithError(w, http.StatusBadRequest, "confirm must be true to remove a finalizer", h.logger)
+		return
+	}
+
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	resource := vars["resource"]
+	name := vars["name"]
+
+	served, err := namespacedResourcesByName(h.kubeClient)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	gvr, ok := served[resource]
+	if !ok {
+		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("resource %q is not served by the cluster", resource), h.logger)
+		return
+	}
+
+	client := h.dynamicClient.Resource(gvr).Namespace(namespace)
+
+	object, err := client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		RespondWithError(w, statusForKubeError(err), err.Error(), h.logger)
+		return
+	}
+
+	finalizers := []string{}
+	found := false
+	for _, finalizer := range object.GetFinalizers() {
+		if finalizer == req.Finalizer {
+			found = true
+			continue
+		}
+		finalizers = append(finalizers, finalizer)
+	}
+
+	if !found {
+		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("%s %q does not have finalizer %q", resource, name, req.Finalizer), h.logger)
+		return
+	}
+
+	// A merge patch replaces lists wholesale, so the remaining finalizers are
+	// sent in full.
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"finalizers":      finalizers,
+			"resourceVersion": object.GetResourceVersion(),
+		},
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	updated, err := client.Patch(name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		RespondWithError(w, statusForKubeError(err), err.Error(), h.logger)
+		return
+	}
+
+	h.logger.With(
+		"user", requesterName(r, h.user),
+		"namespace", namespace,
+		"resource", resource,
+		"name", name,
+		"finalizer", req.Finalizer,
+	).Infof("removed finalizer")
+
+	serveAsJSON(w, updated, h.logger)
+}
+
+// statusForKubeError maps an API server error to the HTTP status returned to
+// the client.
+func statusForKubeError(err error) int {
+	switch {
+	case kerrors.IsNotFound(err):
+		return http.StatusNotFound
+	case kerrors.IsConflict(err):
+		return http.StatusConflict
+	case kerrors.IsForbidden(err):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_removeFinalizerHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		resource     string
+		object       string
+		body         string
+		expectedCode int
+		expected     []string
+	}{
+		{
+			name:         "remove finalizer",
+			resource:     "persistentvolumeclaims",
+			object:       "data",
+			body:         `{"finalizer":"example.com/cleanup","confirm":true}`,
+			expectedCode: http.StatusOK,
+			expected:     []string{"kubernetes.io/pvc-protection"},
+		},
+		{
+			name:         "not confirmed",
+			resource:     "persistentvolumeclaims",
+			object:       "data",
+			body:         `{"finalizer":"example.com/cleanup"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "missing finalizer",
+			resource:     "persistentvolumeclaims",
+			object:       "data",
+			body:         `{"confirm":true}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "finalizer not set",
+			resource:     "persistentvolumeclaims",
+			object:       "data",
+			body:         `{"finalizer":"example.com/other","confirm":true}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown resource",
+			resource:     "widgets.example.com",
+			object:       "data",
+			body:         `{"finalizer":"example.com/cleanup","confirm":true}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "object not found",
+			resource:     "persistentvolumeclaims",
+			object:       "missing",
+			body:         `{"finalizer":"example.com/cleanup","confirm":true}`,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+				{
+					GroupVersion: "v1",
+					APIResources: []metav1.APIResource{
+						{Name: "persistentvolumeclaims", Kind: "PersistentVolumeClaim", Namespaced: true, Verbs: metav1.Verbs{"get", "list", "patch"}},
+					},
+				},
+			}
+
+			pvc := &unstructured.Unstructured{}
+			pvc.SetAPIVersion("v1")
+			pvc.SetKind("PersistentVolumeClaim")
+			pvc.SetNamespace("default")
+			pvc.SetName("data")
+			pvc.SetFinalizers([]string{"kubernetes.io/pvc-protection", "example.com/cleanup"})
+
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), pvc)
+
+			handler := newRemoveFinalizerHandler(kubeClient, dynamicClient, "admin", log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodPost,
+				"/api/v1/finalizerstatus/default/"+tc.resource+"/"+tc.object+"/removeFinalizer", strings.NewReader(tc.body))
+			req = mux.SetURLVars(req, map[string]string{
+				"namespace": "default",
+				"resource":  tc.resource,
+				"name":      tc.object,
+			})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var got unstructured.Unstructured
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got.Object))
+			assert.Equal(t, tc.expected, got.GetFinalizers())
+		})
+	}
+}
@@ -0,0 +1,451 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	defaultMaxHelmReleases = 10
+	maxHelmReleasesLimit   = 100
+
+	helmReleaseStatusDeployed = "deployed"
+)
+
+// gzipMagic starts gzip compressed data. Helm compresses release data before
+// encoding it.
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// helmRelease is the subset of a Helm 3 release used by the Helm endpoints.
+type helmRelease struct {
+	Name      string                 `json:"name"`
+	Namespace string                 `json:"namespace"`
+	Version   int                    `json:"version"`
+	Manifest  string                 `json:"manifest"`
+	Info      helmReleaseInfo        `json:"info"`
+	Chart     helmReleaseChart       `json:"chart"`
+	Config    map[string]interface{} `json:"config"`
+}
+
+type helmReleaseInfo struct {
+	Status       string    `json:"status"`
+	LastDeployed time.Time `json:"last_deployed"`
+}
+
+type helmReleaseChart struct {
+	Metadata struct {
+		Name       string `json:"name"`
+		Version    string `json:"version"`
+		AppVersion string `json:"appVersion"`
+	} `json:"metadata"`
+}
+
+type driftRelease struct {
+	Name     string `json:"name"`
+	Revision int    `json:"revision"`
+}
+
+type driftedResource struct {
+	Release    string `json:"release"`
+	Revision   int    `json:"revision"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	// Missing is set when the resource no longer exists.
+	Missing bool `json:"missing"`
+	// Patch is the patch which would return the live resource to its
+	// manifest.
+	Patch json.RawMessage `json:"patch,omitempty"`
+}
+
+type resourceDriftResponse struct {
+	Releases []driftRelease    `json:"releases"`
+	Drifted  []driftedResource `json:"drifted"`
+}
+
+type resourceDriftHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*resourceDriftHandler)(nil)
+
+func newResourceDriftHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *resourceDriftHandler {
+	return &resourceDriftHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and compares the resources in the
+// manifests of a namespace's deployed Helm releases to their live versions.
+// Only the latest deployed revision of each release is checked, and at most
+// `maxReleases` releases, the most recently deployed first. Built in types are
+// compared with a three-way strategic merge patch and custom resources with a
+// JSON merge patch. Fields the cluster adds to a resource aren't drift. The
+// values of Secrets are redacted from patches.
+func (h *resourceDriftHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	maxReleases := defaultMaxHelmReleases
+	if s := r.URL.Query().Get("maxReleases"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			RespondWithError(w, http.StatusBadRequest, "maxReleases must be a positive integer", h.logger)
+			return
+		}
+		if n > maxHelmReleasesLimit {
+			n = maxHelmReleasesLimit
+		}
+		maxReleases = n
+	}
+
+	secrets, err := h.kubeClient.CoreV1().Secrets(namespace).List(metav1.ListOptions{LabelSelector: "owner=helm"})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := resourceDriftResponse{
+		Releases: []driftRelease{},
+		Drifted:  []driftedResource{},
+	}
+
+	releaseSecrets := deployedHelmReleaseSecrets(secrets.Items)
+	if len(releaseSecrets) > maxReleases {
+		releaseSecrets = releaseSecrets[:maxReleases]
+	}
+	if len(releaseSecrets) == 0 {
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	groupResources, err := restmapper.GetAPIGroupResources(h.kubeClient.Discovery())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+	mapper := restmapper.NewDiscoveryRESTMapper(groupResources)
+
+	for _, secret := range releaseSecrets {
+		release, err := decodeHelmRelease(secret)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, errors.Wrapf(err, "decode release secret %s", secret.Name).Error(), h.logger)
+			return
+		}
+
+		resp.Releases = append(resp.Releases, driftRelease{Name: release.Name, Revision: release.Version})
+
+		objects, err := manifestObjects(release.Manifest)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, errors.Wrapf(err, "parse manifest of release %s", release.Name).Error(), h.logger)
+			return
+		}
+
+		for _, object := range objects {
+			drifted, ok, err := h.drift(mapper, namespace, object)
+			if err != nil {
+				RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+				return
+			}
+			if !ok {
+				continue
+			}
+
+			drifted.Release = release.Name
+			drifted.Revision = release.Version
+			resp.Drifted = append(resp.Drifted, drifted)
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// drift compares a manifest object to its live version. It returns false if
+// the live version matches or the object's kind isn't served by the cluster.
+func (h *resourceDriftHandler) drift(mapper meta.RESTMapper, namespace string, object *unstructured.Unstructured) (driftedResource, bool, error) {
+	gvk := object.GroupVersionKind()
+
+	drifted := driftedResource{
+		APIVersion: object.GetAPIVersion(),
+		Kind:       object.GetKind(),
+		Name:       object.GetName(),
+	}
+
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		h.logger.WithErr(err).With("kind", gvk.String()).Debugf("skip resource drift for unknown kind")
+		return drifted, false, nil
+	}
+
+	resource := h.dynamicClient.Resource(mapping.Resource)
+
+	var live *unstructured.Unstructured
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if object.GetNamespace() == "" {
+			object.SetNamespace(namespace)
+		}
+		drifted.Namespace = object.GetNamespace()
+		live, err = resource.Namespace(drifted.Namespace).Get(drifted.Name, metav1.GetOptions{})
+	} else {
+		live, err = resource.Get(drifted.Name, metav1.GetOptions{})
+	}
+	if kerrors.IsNotFound(err) {
+		drifted.Missing = true
+		return drifted, true, nil
+	}
+	if err != nil {
+		return drifted, false, errors.Wrapf(err, "get %s %s", drifted.Kind, drifted.Name)
+	}
+
+	patch, err := driftPatch(object, live)
+	if err != nil {
+		return drifted, false, errors.Wrapf(err, "compare %s %s", drifted.Kind, drifted.Name)
+	}
+
+	if string(patch) == "{}" {
+		return drifted, false, nil
+	}
+
+	drifted.Patch = patch
+	return drifted, true, nil
+}
+
+// driftPatch creates the patch which would return a live object to its
+// manifest. The manifest is both the original and the modified object, so
+// only manifest fields which changed are in the patch. Secrets are compared
+// on their encoded data, and their values are redacted from the patch.
+func driftPatch(manifest, live *unstructured.Unstructured) ([]byte, error) {
+	secret := manifest.GroupVersionKind().Group == "" && manifest.GetKind() == "Secret"
+	if secret {
+		manifest = encodeSecretStringData(manifest)
+	}
+
+	patch, err := createDriftPatch(manifest, live)
+	if err != nil || !secret {
+		return patch, err
+	}
+
+	return redactSecretPatch(patch)
+}
+
+func createDriftPatch(manifest, live *unstructured.Unstructured) ([]byte, error) {
+	manifestJSON, err := manifest.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	liveJSON, err := live.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	typed, err := scheme.Scheme.New(manifest.GroupVersionKind())
+	if err != nil {
+		// Custom resources have no patch strategy, so apply the manifest as
+		// a JSON merge patch and diff the result.
+		desired, err := jsonpatch.MergePatch(liveJSON, manifestJSON)
+		if err != nil {
+			return nil, err
+		}
+		return jsonpatch.CreateMergePatch(liveJSON, desired)
+	}
+
+	patchMeta, err := strategicpatch.NewPatchMetaFromStruct(typed)
+	if err != nil {
+		return nil, err
+	}
+
+	return strategicpatch.CreateThreeWayMergePatch(manifestJSON, manifestJSON, liveJSON, patchMeta, true)
+}
+
+// encodeSecretStringData returns a copy of a Secret manifest with its
+// stringData merged into data, base64 encoded, as the API server stores it.
+func encodeSecretStringData(manifest *unstructured.Unstructured) *unstructured.Unstructured {
+	stringData, ok := manifest.Object["stringData"].(map[string]interface{})
+	if !ok {
+		return manifest
+	}
+
+	encoded := manifest.DeepCopy()
+	data, ok := encoded.Object["data"].(map[string]interface{})
+	if !ok {
+		data = map[string]interface{}{}
+	}
+	for key, value := range stringData {
+		data[key] = base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(value)))
+	}
+	encoded.Object["data"] = data
+	delete(encoded.Object, "stringData")
+
+	return encoded
+}
+
+// redactSecretPatch replaces the values a Secret patch sets in data or
+// stringData. Removed keys are kept.
+func redactSecretPatch(patch []byte) ([]byte, error) {
+	var object map[string]interface{}
+	if err := json.Unmarshal(patch, &object); err != nil {
+		return nil, err
+	}
+
+	for _, field := range []string{"data", "stringData"} {
+		values, ok := object[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, value := range values {
+			if value != nil {
+				values[key] = redactedHelmValue
+			}
+		}
+	}
+
+	return json.Marshal(object)
+}
+
+// deployedHelmReleaseSecrets returns the secret of the latest deployed
+// revision of each Helm release, most recently created first.
+func deployedHelmReleaseSecrets(secrets []corev1.Secret) []corev1.Secret {
+	var deployed []corev1.Secret
+	for _, secret := range secrets {
+		if secret.Labels["status"] == helmReleaseStatusDeployed {
+			deployed = append(deployed, secret)
+		}
+	}
+
+	return latestHelmReleaseSecrets(deployed)
+}
+
+// latestHelmReleaseSecrets returns the secret of the latest revision of each
+// release, the most recently created first.
+func latestHelmReleaseSecrets(secrets []corev1.Secret) []corev1.Secret {
+	latest := make(map[string]corev1.Secret)
+	revisions := make(map[string]int)
+
+	for _, secret := range secrets {
+		if secret.Type != helmReleaseSecretType {
+			continue
+		}
+
+		name := secret.Labels["name"]
+		revision, err := strconv.Atoi(secret.Labels["version"])
+		if name == "" || err != nil {
+			continue
+		}
+
+		if current, ok := revisions[name]; ok && current >= revision {
+			continue
+		}
+
+		latest[name] = secret
+		revisions[name] = revision
+	}
+
+	var list []corev1.Secret
+	for _, secret := range latest {
+		list = append(list, secret)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i].CreationTimestamp, list[j].CreationTimestamp
+		if !a.Equal(&b) {
+			return b.Before(&a)
+		}
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// decodeHelmRelease decodes a release from its secret. Helm stores releases
+// as base64 encoded, gzipped JSON.
+func decodeHelmRelease(secret corev1.Secret) (helmRelease, error) {
+	var release helmRelease
+
+	data, err := base64.StdEncoding.DecodeString(string(secret.Data["release"]))
+	if err != nil {
+		return release, errors.Wrap(err, "decode base64")
+	}
+
+	if bytes.HasPrefix(data, gzipMagic) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return release, errors.Wrap(err, "decompress")
+		}
+		defer reader.Close()
+
+		data, err = ioutil.ReadAll(reader)
+		if err != nil {
+			return release, errors.Wrap(err, "decompress")
+		}
+	}
+
+	if err := json.Unmarshal(data, &release); err != nil {
+		return release, errors.Wrap(err, "unmarshal")
+	}
+
+	return release, nil
+}
+
+// manifestObjects splits a rendered manifest into its objects. Empty
+// documents are skipped.
+func manifestObjects(manifest string) ([]*unstructured.Unstructured, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 4096)
+
+	var objects []*unstructured.Unstructured
+	for {
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err != nil {
+			if err == io.EOF {
+				return objects, nil
+			}
+			return nil, err
+		}
+
+		if len(object) == 0 {
+			continue
+		}
+
+		u := &unstructured.Unstructured{Object: object}
+		if u.GetKind() == "" || u.GetName() == "" {
+			return nil, errors.New("manifest object is missing a kind or name")
+		}
+		objects = append(objects, u)
+	}
+}
@@ -0,0 +1,257 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const driftManifest = `---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 3
+---
+# Source: app/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  mode: production
+---
+# Source: app/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+data:
+  user: YWRtaW4=
+stringData:
+  password: hunter2
+  token: abc
+---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+---
+# Source: app/templates/clusterrole.yaml
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: app
+---
+# Source: app/templates/widget.yaml
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: app
+spec:
+  size: 2
+---
+# Source: app/templates/gadget.yaml
+apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: app
+`
+
+func Test_resourceDriftHandler(t *testing.T) {
+	created := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	releaseSecret := func(name string, version int, status, manifest string, age time.Duration) *corev1.Secret {
+		data, err := json.Marshal(helmRelease{Name: name, Namespace: "default", Version: version, Manifest: manifest})
+		require.NoError(t, err)
+
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		_, err = writer.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "sh.helm.release.v1." + name + ".v" + strconv.Itoa(version),
+				Namespace:         "default",
+				Labels:            map[string]string{"owner": "helm", "name": name, "version": strconv.Itoa(version), "status": status},
+				CreationTimestamp: metav1.NewTime(created.Add(-age)),
+			},
+			Type: helmReleaseSecretType,
+			Data: map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(compressed.Bytes()))},
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		releaseSecret("app", 1, "superseded", "", 2*time.Hour),
+		releaseSecret("app", 2, helmReleaseStatusDeployed, driftManifest, time.Hour),
+		releaseSecret("old", 1, helmReleaseStatusDeployed, "", 24*time.Hour),
+	)
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "configmaps", Kind: "ConfigMap", Namespaced: true},
+				{Name: "secrets", Kind: "Secret", Namespaced: true},
+				{Name: "services", Kind: "Service", Namespaced: true},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{{Name: "deployments", Kind: "Deployment", Namespaced: true}},
+		},
+		{
+			GroupVersion: "rbac.authorization.k8s.io/v1",
+			APIResources: []metav1.APIResource{{Name: "clusterroles", Kind: "ClusterRole"}},
+		},
+		{
+			GroupVersion: "example.com/v1",
+			APIResources: []metav1.APIResource{{Name: "widgets", Kind: "Widget", Namespaced: true}},
+		},
+	}
+
+	live := func(apiVersion, kind, namespace string, fields map[string]interface{}) *unstructured.Unstructured {
+		object := map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":      "app",
+				"namespace": namespace,
+				"labels":    map[string]interface{}{"app.kubernetes.io/managed-by": "Helm"},
+			},
+		}
+		for k, v := range fields {
+			object[k] = v
+		}
+		return &unstructured.Unstructured{Object: object}
+	}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		live("apps/v1", "Deployment", "default", map[string]interface{}{
+			"spec":   map[string]interface{}{"replicas": int64(5)},
+			"status": map[string]interface{}{"readyReplicas": int64(5)},
+		}),
+		live("v1", "ConfigMap", "default", map[string]interface{}{
+			"data": map[string]interface{}{"mode": "production"},
+		}),
+		live("v1", "Secret", "default", map[string]interface{}{
+			"type": "Opaque",
+			"data": map[string]interface{}{
+				"user":     base64.StdEncoding.EncodeToString([]byte("admin")),
+				"password": base64.StdEncoding.EncodeToString([]byte("changed")),
+				"token":    base64.StdEncoding.EncodeToString([]byte("abc")),
+			},
+		}),
+		live("rbac.authorization.k8s.io/v1", "ClusterRole", "", nil),
+		live("example.com/v1", "Widget", "default", map[string]interface{}{
+			"spec": map[string]interface{}{"size": int64(3), "paused": false},
+		}),
+	)
+
+	tests := []struct {
+		name             string
+		query            string
+		expectedCode     int
+		expectedReleases []driftRelease
+		expected         map[string]string
+	}{
+		{
+			name:             "drifted resources",
+			expectedCode:     http.StatusOK,
+			expectedReleases: []driftRelease{{Name: "app", Revision: 2}, {Name: "old", Revision: 1}},
+			expected: map[string]string{
+				"Deployment": `{"spec":{"replicas":3}}`,
+				"Secret":     `{"data":{"password":"REDACTED"}}`,
+				"Service":    "",
+				"Widget":     `{"spec":{"size":2}}`,
+			},
+		},
+		{
+			name:             "limited releases",
+			query:            "?maxReleases=1",
+			expectedCode:     http.StatusOK,
+			expectedReleases: []driftRelease{{Name: "app", Revision: 2}},
+			expected: map[string]string{
+				"Deployment": `{"spec":{"replicas":3}}`,
+				"Secret":     `{"data":{"password":"REDACTED"}}`,
+				"Service":    "",
+				"Widget":     `{"spec":{"size":2}}`,
+			},
+		},
+		{
+			name:         "invalid maxReleases",
+			query:        "?maxReleases=0",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newResourceDriftHandler(kubeClient, dynamicClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/resourcedrift"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tc.expectedCode, w.Code, w.Body.String())
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var resp resourceDriftResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+			assert.Equal(t, tc.expectedReleases, resp.Releases)
+
+			got := make(map[string]driftedResource)
+			for _, drifted := range resp.Drifted {
+				assert.Equal(t, "app", drifted.Release)
+				assert.Equal(t, 2, drifted.Revision)
+				got[drifted.Kind] = drifted
+			}
+			require.Len(t, got, len(tc.expected))
+
+			for kind, patch := range tc.expected {
+				drifted, ok := got[kind]
+				require.True(t, ok, kind)
+				assert.Equal(t, "default", drifted.Namespace)
+				if patch == "" {
+					assert.True(t, drifted.Missing, kind)
+					continue
+				}
+				assert.False(t, drifted.Missing, kind)
+				assert.JSONEq(t, patch, string(drifted.Patch), kind)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	resourceHistoryNote = "Counts are a best-effort approximation reconstructed from events, " +
+		"which the API server only retains for a limited time (one hour by default). " +
+		"Changes which weren't recorded as events are not reflected."
+)
+
+// resourceChangePatterns match the messages controllers record when they
+// create or delete resources.
+var resourceChangePatterns = []struct {
+	pattern  *regexp.Regexp
+	resource string
+	delta    int
+}{
+	{pattern: regexp.MustCompile(`^Created pod: \S+`), resource: "pods", delta: 1},
+	{pattern: regexp.MustCompile(`^Deleted pod: \S+`), resource: "pods", delta: -1},
+	{pattern: regexp.MustCompile(`^create Pod \S+ in StatefulSet \S+ successful`), resource: "pods", delta: 1},
+	{pattern: regexp.MustCompile(`^delete Pod \S+ in StatefulSet \S+ successful`), resource: "pods", delta: -1},
+	{pattern: regexp.MustCompile(`^Created job \S+`), resource: "jobs", delta: 1},
+	{pattern: regexp.MustCompile(`^Deleted job \S+`), resource: "jobs", delta: -1},
+}
+
+type resourceCountSeries struct {
+	Resource   string      `json:"resource"`
+	Timestamps []time.Time `json:"timestamps"`
+	Counts     []int       `json:"counts"`
+}
+
+type resourceHistoryResponse struct {
+	Approximate bool                  `json:"approximate"`
+	Note        string                `json:"note"`
+	Series      []resourceCountSeries `json:"series"`
+}
+
+type resourceChange struct {
+	timestamp time.Time
+	delta     int
+}
+
+type resourceHistoryHandler struct {
+	kubeClient kubernetes.Interface
+	nowFn      func() time.Time
+	logger     log.Logger
+}
+
+var _ http.Handler = (*resourceHistoryHandler)(nil)
+
+func newResourceHistoryHandler(kubeClient kubernetes.Interface, logger log.Logger) *resourceHistoryHandler {
+	return &resourceHistoryHandler{
+		kubeClient: kubeClient,
+		nowFn:      time.Now,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns approximate pod and job counts
+// in a namespace over the event retention period. Counts are worked out
+// backwards from the current counts by undoing the creates and deletes
+// recorded in events.
+func (h *resourceHistoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	jobs, err := h.kubeClient.BatchV1().Jobs(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	events, err := h.kubeClient.CoreV1().Events(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	current := map[string]int{
+		"pods": len(pods.Items),
+		"jobs": len(jobs.Items),
+	}
+
+	changes := make(map[string][]resourceChange)
+	for _, event := range events.Items {
+		for _, p := range resourceChangePatterns {
+			if !p.pattern.MatchString(event.Message) {
+				continue
+			}
+
+			count := int(event.Count)
+			if count < 1 {
+				count = 1
+			}
+
+			changes[p.resource] = append(changes[p.resource], resourceChange{
+				timestamp: eventTimestamp(event),
+				delta:     p.delta * count,
+			})
+			break
+		}
+	}
+
+	resp := resourceHistoryResponse{
+		Approximate: true,
+		Note:        resourceHistoryNote,
+		Series:      []resourceCountSeries{},
+	}
+
+	now := h.nowFn()
+	for _, resource := range []string{"jobs", "pods"} {
+		resp.Series = append(resp.Series, resourceSeries(resource, current[resource], changes[resource], now))
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// resourceSeries reconstructs counts for a resource from its current count and
+// changes. The series starts just before the first change and ends at now.
+func resourceSeries(resource string, current int, changes []resourceChange, now time.Time) resourceCountSeries {
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].timestamp.Before(changes[j].timestamp)
+	})
+
+	// Work backwards to find the count before the first change.
+	initial := current
+	for _, change := range changes {
+		initial -= change.delta
+	}
+	if initial < 0 {
+		initial = 0
+	}
+
+	series := resourceCountSeries{
+		Resource:   resource,
+		Timestamps: []time.Time{},
+		Counts:     []int{},
+	}
+
+	add := func(timestamp time.Time, count int) {
+		if n := len(series.Timestamps); n > 0 && series.Timestamps[n-1].Equal(timestamp) {
+			series.Counts[n-1] = count
+			return
+		}
+		series.Timestamps = append(series.Timestamps, timestamp)
+		series.Counts = append(series.Counts, count)
+	}
+
+	count := initial
+	if len(changes) > 0 {
+		add(changes[0].timestamp.Add(-time.Second), count)
+	}
+	for _, change := range changes {
+		count += change.delta
+		if count < 0 {
+			count = 0
+		}
+		add(change.timestamp, count)
+	}
+	add(now, current)
+
+	return series
+}
@@ -0,0 +1,88 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_resourceHistoryHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(minutes int) time.Time {
+		return now.Add(-time.Duration(minutes) * time.Minute)
+	}
+
+	newEvent := func(name, reason, message string, timestamp time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:    metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Reason:        reason,
+			Message:       message,
+			LastTimestamp: metav1.NewTime(timestamp),
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-a", Namespace: "default"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-b", Namespace: "default"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "default"}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "backup-2", Namespace: "default"}},
+		newEvent("1", "SuccessfulCreate", "Created pod: web-a", ago(50)),
+		newEvent("2", "SuccessfulCreate", "Created pod: web-b", ago(40)),
+		newEvent("3", "SuccessfulDelete", "Deleted pod: web-old", ago(40)),
+		newEvent("4", "SuccessfulCreate", "create Pod db-0 in StatefulSet db successful", ago(30)),
+		newEvent("5", "SuccessfulCreate", "Created job backup-1", ago(20)),
+		newEvent("6", "SuccessfulDelete", "Deleted job backup-1", ago(10)),
+		newEvent("7", "SuccessfulCreate", "Created job backup-2", ago(10)),
+		newEvent("8", "Pulled", "Successfully pulled image", ago(5)),
+	)
+
+	handler := newResourceHistoryHandler(kubeClient, log.NopLogger())
+	handler.nowFn = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/resourcehistory", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got resourceHistoryResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	assert.True(t, got.Approximate)
+	assert.NotEmpty(t, got.Note)
+	require.Len(t, got.Series, 2)
+
+	assertSeries := func(series resourceCountSeries, resource string, timestamps []time.Time, counts []int) {
+		assert.Equal(t, resource, series.Resource)
+		assert.Equal(t, counts, series.Counts)
+		require.Len(t, series.Timestamps, len(timestamps))
+		for i := range timestamps {
+			assert.True(t, timestamps[i].Equal(series.Timestamps[i]), "timestamp %d: %s", i, series.Timestamps[i])
+		}
+	}
+
+	assertSeries(got.Series[0], "jobs",
+		[]time.Time{ago(20).Add(-time.Second), ago(20), ago(10), now},
+		[]int{0, 1, 1, 1})
+	assertSeries(got.Series[1], "pods",
+		[]time.Time{ago(50).Add(-time.Second), ago(50), ago(40), ago(30), now},
+		[]int{1, 2, 2, 3, 3})
+}
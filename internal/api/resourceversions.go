@@ -0,0 +1,153 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type resourceVersionsResponse struct {
+	ResourceVersions map[string]string `json:"resourceVersions"`
+}
+
+type resourceVersionsHandler struct {
+	kubeClient     kubernetes.Interface
+	dynamicClient  dynamic.Interface
+	maxConcurrency int
+	logger         log.Logger
+}
+
+var _ http.Handler = (*resourceVersionsHandler)(nil)
+
+func newResourceVersionsHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, maxConcurrency int, logger log.Logger) *resourceVersionsHandler {
+	if maxConcurrency < 1 {
+		maxConcurrency = defaultResourceListConcurrency
+	}
+
+	return &resourceVersionsHandler{
+		kubeClient:     kubeClient,
+		dynamicClient:  dynamicClient,
+		maxConcurrency: maxConcurrency,
+		logger:         logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the current list resource
+// version of each resource type, keyed by `apiVersion/resource`. Clients can
+// use these to start watches. The `resources` query parameter limits the
+// response to a comma separated list of `resource.group` names; by default
+// every listable resource is included. Each list asks for a single object so
+// only the list metadata is fetched.
+func (h *resourceVersionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	served, err := h.listableResources()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	var gvrs []schema.GroupVersionResource
+	if requested := r.URL.Query().Get("resources"); requested != "" {
+		for _, name := range strings.Split(requested, ",") {
+			gvr, ok := served[name]
+			if !ok {
+				RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("resource %q is not served by the cluster", name), h.logger)
+				return
+			}
+			gvrs = append(gvrs, gvr)
+		}
+	} else {
+		for _, gvr := range served {
+			gvrs = append(gvrs, gvr)
+		}
+	}
+
+	resp := resourceVersionsResponse{
+		ResourceVersions: h.resourceVersions(gvrs),
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// listableResources returns the preferred version of each resource which
+// supports list, keyed by its `resource.group` name. Groups which fail
+// discovery are skipped.
+func (h *resourceVersionsHandler) listableResources() (map[string]schema.GroupVersionResource, error) {
+	resourceLists, err := discovery.ServerPreferredResources(h.kubeClient.Discovery())
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, errors.Wrap(err, "discover resources")
+	}
+
+	served := make(map[string]schema.GroupVersionResource)
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+
+		for _, resource := range resourceList.APIResources {
+			if !sets.NewString(resource.Verbs...).Has("list") {
+				continue
+			}
+
+			name := resource.Name
+			if gv.Group != "" {
+				name += "." + gv.Group
+			}
+			served[name] = gv.WithResource(resource.Name)
+		}
+	}
+
+	return served, nil
+}
+
+// resourceVersions lists each resource across all namespaces, running at most
+// maxConcurrency list calls at once. Resources which can't be listed, for
+// example because of RBAC, are left out.
+func (h *resourceVersionsHandler) resourceVersions(gvrs []schema.GroupVersionResource) map[string]string {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		versions = make(map[string]string)
+		sem      = make(chan struct{}, h.maxConcurrency)
+	)
+
+	for _, gvr := range gvrs {
+		wg.Add(1)
+		go func(gvr schema.GroupVersionResource) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			list, err := h.dynamicClient.Resource(gvr).List(metav1.ListOptions{Limit: 1})
+			if err != nil {
+				h.logger.WithErr(err).Debugf("list %s", gvr.String())
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			versions[gvr.GroupVersion().String()+"/"+gvr.Resource] = list.GetResourceVersion()
+		}(gvr)
+	}
+
+	wg.Wait()
+
+	return versions
+}
@@ -0,0 +1,127 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_resourceVersionsHandler(t *testing.T) {
+	listVerbs := metav1.Verbs{"get", "list", "watch"}
+
+	kubeClient := kubefake.NewSimpleClientset()
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: listVerbs},
+				{Name: "nodes", Kind: "Node", Verbs: listVerbs},
+				{Name: "bindings", Kind: "Binding", Namespaced: true, Verbs: metav1.Verbs{"create"}},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: listVerbs},
+			},
+		},
+		{
+			GroupVersion: "rbac.authorization.k8s.io/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "roles", Kind: "Role", Namespaced: true, Verbs: listVerbs},
+			},
+		},
+	}
+
+	resourceVersions := map[string]string{
+		"pods":        "100",
+		"nodes":       "200",
+		"deployments": "300",
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expected     map[string]string
+	}{
+		{
+			name:         "all resources",
+			expectedCode: http.StatusOK,
+			expected: map[string]string{
+				"v1/pods":             "100",
+				"v1/nodes":            "200",
+				"apps/v1/deployments": "300",
+			},
+		},
+		{
+			name:         "requested resources",
+			query:        "?resources=pods,deployments.apps",
+			expectedCode: http.StatusOK,
+			expected: map[string]string{
+				"v1/pods":             "100",
+				"apps/v1/deployments": "300",
+			},
+		},
+		{
+			name:         "unknown resource",
+			query:        "?resources=widgets.example.com",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+			dynamicClient.PrependReactor("list", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				resource := action.GetResource().Resource
+
+				resourceVersion, ok := resourceVersions[resource]
+				if !ok {
+					return true, nil, kerrors.NewForbidden(schema.GroupResource{Resource: resource}, "", nil)
+				}
+
+				list := &unstructured.UnstructuredList{}
+				list.SetAPIVersion("v1")
+				list.SetKind("List")
+				list.SetResourceVersion(resourceVersion)
+				return true, list, nil
+			})
+
+			handler := newResourceVersionsHandler(kubeClient, dynamicClient, 2, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/resource-versions"+tc.query, nil)
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var got resourceVersionsResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tc.expected, got.ResourceVersions)
+		})
+	}
+}
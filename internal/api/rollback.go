@@ -0,0 +1,108 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type rollbackResponse struct {
+	Deployment *appsv1.Deployment `json:"deployment"`
+	Revision   int64              `json:"revision"`
+}
+
+type rollbackHandler struct {
+	kubeClient kubernetes.Interface
+	user       string
+	logger     log.Logger
+}
+
+var _ http.Handler = (*rollbackHandler)(nil)
+
+func newRollbackHandler(kubeClient kubernetes.Interface, user string, logger log.Logger) *rollbackHandler {
+	return &rollbackHandler{
+		kubeClient: kubeClient,
+		user:       user,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and rolls a deployment back to an earlier
+// revision by copying that revision's pod template into the deployment, which
+// starts a rolling update. Paused deployments and deployments already running
+// the revision's template are rejected. The update carries the resource
+// version that was read, so a concurrent change results in a conflict.
+func (h *rollbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+
+	number, err := strconv.ParseInt(vars["revision"], 10, 64)
+	if err != nil || number < 1 {
+		RespondWithError(w, http.StatusBadRequest, "revision must be a positive integer", h.logger)
+		return
+	}
+
+	deployment, replicaSets, err := deploymentRevisions(h.kubeClient, namespace, vars["deployment"])
+	if err != nil {
+		RespondWithError(w, statusForKubeError(err), err.Error(), h.logger)
+		return
+	}
+
+	var target *appsv1.ReplicaSet
+	for i := range replicaSets {
+		if replicaSetRevision(replicaSets[i]) == number {
+			target = &replicaSets[i]
+			break
+		}
+	}
+
+	if target == nil {
+		RespondWithError(w, http.StatusNotFound, fmt.Sprintf("deployment %q has no revision %d", deployment.Name, number), h.logger)
+		return
+	}
+
+	if deployment.Spec.Paused {
+		RespondWithError(w, http.StatusConflict, fmt.Sprintf("deployment %q is paused", deployment.Name), h.logger)
+		return
+	}
+
+	template := revisionPodTemplate(*target)
+	if equality.Semantic.DeepEqual(template, deployment.Spec.Template) {
+		RespondWithError(w, http.StatusConflict, fmt.Sprintf("deployment %q is already running revision %d", deployment.Name, number), h.logger)
+		return
+	}
+
+	deployment.Spec.Template = template
+
+	updated, err := h.kubeClient.AppsV1().Deployments(namespace).Update(deployment)
+	if err != nil {
+		RespondWithError(w, statusForKubeError(err), err.Error(), h.logger)
+		return
+	}
+
+	h.logger.With(
+		"user", requesterName(r, h.user),
+		"namespace", namespace,
+		"deployment", deployment.Name,
+		"revision", number,
+	).Infof("rolled back deployment")
+
+	resp := rollbackResponse{
+		Deployment: updated,
+		Revision:   number,
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
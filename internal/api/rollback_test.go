@@ -0,0 +1,113 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_rollbackHandler(t *testing.T) {
+	controller := true
+
+	template := func(image, hash string) corev1.PodTemplateSpec {
+		labels := map[string]string{"app": "web"}
+		if hash != "" {
+			labels[appsv1.DefaultDeploymentUniqueLabelKey] = hash
+		}
+		return corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: labels},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "web", Image: image}},
+			},
+		}
+	}
+
+	newDeployment := func(name string, paused bool) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				UID:         "web-uid",
+				Annotations: map[string]string{deploymentRevisionAnnotation: "2"},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Paused:   paused,
+				Template: template("web:2", ""),
+			},
+		}
+	}
+
+	newReplicaSet := func(name, revision, image, hash string) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				Annotations:     map[string]string{deploymentRevisionAnnotation: revision},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", UID: "web-uid", Controller: &controller}},
+			},
+			Spec: appsv1.ReplicaSetSpec{Template: template(image, hash)},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		paused     bool
+		revision   string
+		expectCode int
+	}{
+		{name: "rollback", revision: "1", expectCode: http.StatusOK},
+		{name: "current revision", revision: "2", expectCode: http.StatusConflict},
+		{name: "paused", paused: true, revision: "1", expectCode: http.StatusConflict},
+		{name: "unknown revision", revision: "5", expectCode: http.StatusNotFound},
+		{name: "invalid revision", revision: "0", expectCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(
+				newDeployment("web", test.paused),
+				newReplicaSet("web-1", "1", "web:1", "aaa"),
+				newReplicaSet("web-2", "2", "web:2", "bbb"),
+			)
+
+			handler := newRollbackHandler(kubeClient, "admin", log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/namespaces/default/rollback/web/"+test.revision, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default", "deployment": "web", "revision": test.revision})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, test.expectCode, resp.Code)
+
+			deployment, err := kubeClient.AppsV1().Deployments("default").Get("web", metav1.GetOptions{})
+			require.NoError(t, err)
+
+			if test.expectCode != http.StatusOK {
+				assert.Equal(t, "web:2", deployment.Spec.Template.Spec.Containers[0].Image)
+				return
+			}
+
+			var got rollbackResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, int64(1), got.Revision)
+			assert.Equal(t, template("web:1", ""), got.Deployment.Spec.Template)
+			assert.Equal(t, template("web:1", ""), deployment.Spec.Template)
+		})
+	}
+}
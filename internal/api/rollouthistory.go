@@ -0,0 +1,182 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// changeCauseAnnotation records the command which caused a rollout.
+	changeCauseAnnotation = "kubernetes.io/change-cause"
+)
+
+type rolloutRevision struct {
+	Revision        int64     `json:"revision"`
+	ReplicaSet      string    `json:"replicaSet"`
+	Image           string    `json:"image"`
+	CreatedAt       time.Time `json:"createdAt"`
+	Replicas        int32     `json:"replicas"`
+	PodTemplateHash string    `json:"podTemplateHash"`
+	ChangeCause     string    `json:"changeCause,omitempty"`
+	Current         bool      `json:"current"`
+}
+
+type rolloutHistoryResponse struct {
+	Deployment string            `json:"deployment"`
+	Revisions  []rolloutRevision `json:"revisions"`
+}
+
+type rolloutRevisionResponse struct {
+	Deployment  string                 `json:"deployment"`
+	Revision    int64                  `json:"revision"`
+	PodTemplate corev1.PodTemplateSpec `json:"podTemplate"`
+}
+
+type rolloutHistoryHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+func newRolloutHistoryHandler(kubeClient kubernetes.Interface, logger log.Logger) *rolloutHistoryHandler {
+	return &rolloutHistoryHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// list returns a deployment's revisions, oldest first, from the replica sets
+// it owns. The image is the first container's.
+func (h *rolloutHistoryHandler) list(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	deployment, replicaSets, err := deploymentRevisions(h.kubeClient, vars["namespace"], vars["deployment"])
+	if err != nil {
+		RespondWithError(w, statusForKubeError(err), err.Error(), h.logger)
+		return
+	}
+
+	resp := rolloutHistoryResponse{
+		Deployment: deployment.Name,
+		Revisions:  []rolloutRevision{},
+	}
+
+	current := deployment.Annotations[deploymentRevisionAnnotation]
+
+	for _, replicaSet := range replicaSets {
+		revision := rolloutRevision{
+			Revision:        replicaSetRevision(replicaSet),
+			ReplicaSet:      replicaSet.Name,
+			CreatedAt:       replicaSet.CreationTimestamp.Time.UTC(),
+			Replicas:        replicaSet.Status.Replicas,
+			PodTemplateHash: replicaSet.Labels[appsv1.DefaultDeploymentUniqueLabelKey],
+			ChangeCause:     replicaSet.Annotations[changeCauseAnnotation],
+			Current:         replicaSet.Annotations[deploymentRevisionAnnotation] == current,
+		}
+
+		if containers := replicaSet.Spec.Template.Spec.Containers; len(containers) > 0 {
+			revision.Image = containers[0].Image
+		}
+
+		resp.Revisions = append(resp.Revisions, revision)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// revision returns the pod template of one of a deployment's revisions,
+// without the pod template hash label the deployment controller adds.
+func (h *rolloutHistoryHandler) revision(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	number, err := strconv.ParseInt(vars["revision"], 10, 64)
+	if err != nil || number < 1 {
+		RespondWithError(w, http.StatusBadRequest, "revision must be a positive integer", h.logger)
+		return
+	}
+
+	deployment, replicaSets, err := deploymentRevisions(h.kubeClient, vars["namespace"], vars["deployment"])
+	if err != nil {
+		RespondWithError(w, statusForKubeError(err), err.Error(), h.logger)
+		return
+	}
+
+	for _, replicaSet := range replicaSets {
+		if replicaSetRevision(replicaSet) != number {
+			continue
+		}
+
+		resp := rolloutRevisionResponse{
+			Deployment:  deployment.Name,
+			Revision:    number,
+			PodTemplate: revisionPodTemplate(replicaSet),
+		}
+
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	RespondWithError(w, http.StatusNotFound, fmt.Sprintf("deployment %q has no revision %d", deployment.Name, number), h.logger)
+}
+
+// deploymentRevisions returns a deployment and the replica sets it controls
+// which have a revision, ordered by revision.
+func deploymentRevisions(kubeClient kubernetes.Interface, namespace, name string) (*appsv1.Deployment, []appsv1.ReplicaSet, error) {
+	deployment, err := kubeClient.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list, err := kubeClient.AppsV1().ReplicaSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var replicaSets []appsv1.ReplicaSet
+	for _, replicaSet := range list.Items {
+		if isControlledBy(replicaSet.OwnerReferences, deployment.UID) && replicaSetRevision(replicaSet) > 0 {
+			replicaSets = append(replicaSets, replicaSet)
+		}
+	}
+
+	sort.Slice(replicaSets, func(i, j int) bool {
+		return replicaSetRevision(replicaSets[i]) < replicaSetRevision(replicaSets[j])
+	})
+
+	return deployment, replicaSets, nil
+}
+
+// replicaSetRevision returns a replica set's deployment revision, or 0 if it
+// has none.
+func replicaSetRevision(replicaSet appsv1.ReplicaSet) int64 {
+	revision, err := strconv.ParseInt(replicaSet.Annotations[deploymentRevisionAnnotation], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return revision
+}
+
+// revisionPodTemplate returns a replica set's pod template without the pod
+// template hash label the deployment controller adds.
+func revisionPodTemplate(replicaSet appsv1.ReplicaSet) corev1.PodTemplateSpec {
+	template := *replicaSet.Spec.Template.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+
+	return template
+}
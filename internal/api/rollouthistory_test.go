@@ -0,0 +1,155 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_rolloutHistoryHandler(t *testing.T) {
+	created := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	controller := true
+
+	newReplicaSet := func(name, revision, hash, image string, replicas int32, owner types.UID) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(created),
+				Labels:            map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: hash},
+				Annotations:       map[string]string{deploymentRevisionAnnotation: revision},
+				OwnerReferences:   []metav1.OwnerReference{{Kind: "Deployment", Name: "web", UID: owner, Controller: &controller}},
+			},
+			Spec: appsv1.ReplicaSetSpec{
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{"app": "web", appsv1.DefaultDeploymentUniqueLabelKey: hash},
+					},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web", Image: image}},
+					},
+				},
+			},
+			Status: appsv1.ReplicaSetStatus{Replicas: replicas},
+		}
+	}
+
+	rolledBack := newReplicaSet("web-3", "10", "ccc", "web:3", 2, "web-uid")
+	rolledBack.Annotations[changeCauseAnnotation] = "kubectl rollout undo"
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "web",
+				Namespace:   "default",
+				UID:         "web-uid",
+				Annotations: map[string]string{deploymentRevisionAnnotation: "10"},
+			},
+		},
+		rolledBack,
+		newReplicaSet("web-1", "1", "aaa", "web:1", 0, "web-uid"),
+		newReplicaSet("web-2", "2", "bbb", "web:2", 0, "web-uid"),
+		newReplicaSet("api-1", "1", "ddd", "api:1", 1, "api-uid"),
+	)
+
+	handler := newRolloutHistoryHandler(kubeClient, log.NopLogger())
+
+	t.Run("list", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/rollouthistory/web", nil)
+		req = mux.SetURLVars(req, map[string]string{"namespace": "default", "deployment": "web"})
+
+		resp := httptest.NewRecorder()
+		handler.list(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var got rolloutHistoryResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+		expected := rolloutHistoryResponse{
+			Deployment: "web",
+			Revisions: []rolloutRevision{
+				{Revision: 1, ReplicaSet: "web-1", Image: "web:1", CreatedAt: created, PodTemplateHash: "aaa"},
+				{Revision: 2, ReplicaSet: "web-2", Image: "web:2", CreatedAt: created, PodTemplateHash: "bbb"},
+				{Revision: 10, ReplicaSet: "web-3", Image: "web:3", CreatedAt: created, Replicas: 2, PodTemplateHash: "ccc", ChangeCause: "kubectl rollout undo", Current: true},
+			},
+		}
+		assert.Equal(t, expected, got)
+	})
+
+	tests := []struct {
+		name       string
+		deployment string
+		revision   string
+		expectCode int
+		expectPod  corev1.PodTemplateSpec
+	}{
+		{
+			name:       "revision",
+			deployment: "web",
+			revision:   "2",
+			expectCode: http.StatusOK,
+			expectPod: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "web", Image: "web:2"}},
+				},
+			},
+		},
+		{
+			name:       "unknown revision",
+			deployment: "web",
+			revision:   "3",
+			expectCode: http.StatusNotFound,
+		},
+		{
+			name:       "invalid revision",
+			deployment: "web",
+			revision:   "latest",
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown deployment",
+			deployment: "missing",
+			revision:   "1",
+			expectCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/rollouthistory/"+test.deployment+"/"+test.revision, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default", "deployment": test.deployment, "revision": test.revision})
+
+			resp := httptest.NewRecorder()
+			handler.revision(resp, req)
+			require.Equal(t, test.expectCode, resp.Code)
+
+			if test.expectCode != http.StatusOK {
+				return
+			}
+
+			var got rolloutRevisionResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, int64(2), got.Revision)
+			assert.Equal(t, test.expectPod, got.PodTemplate)
+		})
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	appsv1 "k8s.io/api/apps/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	rolloutStrategyCanary    = "canary"
+	rolloutStrategyBlueGreen = "blueGreen"
+
+	// rolloutPodTemplateHashLabel is set by Argo Rollouts on the replica sets
+	// it manages.
+	rolloutPodTemplateHashLabel = "rollouts-pod-template-hash"
+)
+
+var rolloutGVR = schema.GroupVersionResource{
+	Group:    "argoproj.io",
+	Version:  "v1alpha1",
+	Resource: "rollouts",
+}
+
+type rolloutStatus struct {
+	Name             string `json:"name"`
+	Strategy         string `json:"strategy"`
+	Paused           bool   `json:"paused"`
+	CurrentStep      *int64 `json:"currentStep,omitempty"`
+	TotalSteps       int    `json:"totalSteps"`
+	StableReplicaSet string `json:"stableReplicaSet"`
+	CanaryReplicaSet string `json:"canaryReplicaSet"`
+}
+
+type rolloutsResponse struct {
+	CRDsMissing bool            `json:"crdsMissing"`
+	Rollouts    []rolloutStatus `json:"rollouts"`
+}
+
+type rolloutsHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*rolloutsHandler)(nil)
+
+func newRolloutsHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *rolloutsHandler {
+	return &rolloutsHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the status of Argo Rollouts
+// in a namespace.
+func (h *rolloutsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	rollouts, installed, err := listOptionalResource(h.dynamicClient, rolloutGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := rolloutsResponse{
+		CRDsMissing: !installed,
+		Rollouts:    []rolloutStatus{},
+	}
+
+	if len(rollouts.Items) > 0 {
+		replicaSets, err := h.kubeClient.AppsV1().ReplicaSets(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		for i := range rollouts.Items {
+			resp.Rollouts = append(resp.Rollouts, newRolloutStatus(&rollouts.Items[i], replicaSets.Items))
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+type rolloutPauseHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	paused        bool
+	logger        log.Logger
+}
+
+var _ http.Handler = (*rolloutPauseHandler)(nil)
+
+func newRolloutPauseHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, paused bool, logger log.Logger) *rolloutPauseHandler {
+	return &rolloutPauseHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		paused:        paused,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and pauses or resumes a rollout. Resuming
+// also clears the rollout's pause conditions so a rollout paused at a canary
+// step continues, which mirrors `kubectl argo rollouts resume`.
+func (h *rolloutPauseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	name := vars["rollout"]
+
+	client := h.dynamicClient.Resource(rolloutGVR).Namespace(namespace)
+
+	patch := `{"spec":{"paused":true}}`
+	if !h.paused {
+		patch = `{"spec":{"paused":false}}`
+	}
+
+	rollout, err := client.Patch(name, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	if !h.paused {
+		rollout, err = client.Patch(name, types.MergePatchType, []byte(`{"status":{"pauseConditions":null}}`), metav1.PatchOptions{}, "status")
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+	}
+
+	replicaSets, err := h.kubeClient.AppsV1().ReplicaSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := newRolloutStatus(rollout, replicaSets.Items)
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func newRolloutStatus(rollout *unstructured.Unstructured, replicaSets []appsv1.ReplicaSet) rolloutStatus {
+	status := rolloutStatus{
+		Name: rollout.GetName(),
+	}
+
+	status.Paused, _, _ = unstructured.NestedBool(rollout.Object, "spec", "paused")
+	if pauseConditions, _, _ := unstructured.NestedSlice(rollout.Object, "status", "pauseConditions"); len(pauseConditions) > 0 {
+		status.Paused = true
+	}
+
+	var stableHash, canaryHash string
+
+	if _, ok, _ := unstructured.NestedMap(rollout.Object, "spec", "strategy", "blueGreen"); ok {
+		status.Strategy = rolloutStrategyBlueGreen
+		stableHash, _, _ = unstructured.NestedString(rollout.Object, "status", "blueGreen", "activeSelector")
+		canaryHash, _, _ = unstructured.NestedString(rollout.Object, "status", "blueGreen", "previewSelector")
+	} else {
+		status.Strategy = rolloutStrategyCanary
+		stableHash, _, _ = unstructured.NestedString(rollout.Object, "status", "stableRS")
+		canaryHash, _, _ = unstructured.NestedString(rollout.Object, "status", "currentPodHash")
+
+		steps, _, _ := unstructured.NestedSlice(rollout.Object, "spec", "strategy", "canary", "steps")
+		status.TotalSteps = len(steps)
+
+		if step, ok, _ := unstructured.NestedInt64(rollout.Object, "status", "currentStepIndex"); ok {
+			status.CurrentStep = &step
+		}
+	}
+
+	status.StableReplicaSet = rolloutReplicaSet(rollout.GetUID(), stableHash, replicaSets)
+	status.CanaryReplicaSet = rolloutReplicaSet(rollout.GetUID(), canaryHash, replicaSets)
+
+	return status
+}
+
+// rolloutReplicaSet returns the name of the rollout's replica set with the
+// given pod template hash.
+func rolloutReplicaSet(uid types.UID, hash string, replicaSets []appsv1.ReplicaSet) string {
+	if hash == "" {
+		return ""
+	}
+
+	for _, replicaSet := range replicaSets {
+		if isControlledBy(replicaSet.OwnerReferences, uid) && replicaSet.Labels[rolloutPodTemplateHashLabel] == hash {
+			return replicaSet.Name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,161 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func newTestRollout() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Rollout",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default", "uid": "rollout"},
+		"spec": map[string]interface{}{
+			"strategy": map[string]interface{}{
+				"canary": map[string]interface{}{
+					"steps": []interface{}{
+						map[string]interface{}{"setWeight": int64(20)},
+						map[string]interface{}{"pause": map[string]interface{}{}},
+						map[string]interface{}{"setWeight": int64(100)},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"currentStepIndex": int64(1),
+			"stableRS":         "aaa",
+			"currentPodHash":   "bbb",
+			"pauseConditions": []interface{}{
+				map[string]interface{}{"reason": "CanaryPauseStep"},
+			},
+		},
+	}}
+}
+
+func newTestRolloutReplicaSets() *kubefake.Clientset {
+	newReplicaSet := func(name, hash string) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				Labels:          map[string]string{rolloutPodTemplateHashLabel: hash},
+				OwnerReferences: controllerRef("Rollout", "web", "rollout"),
+			},
+		}
+	}
+
+	return kubefake.NewSimpleClientset(newReplicaSet("web-aaa", "aaa"), newReplicaSet("web-bbb", "bbb"))
+}
+
+func Test_rolloutsHandler(t *testing.T) {
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newTestRollout())
+	handler := newRolloutsHandler(newTestRolloutReplicaSets(), dynamicClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/argo/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got rolloutsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	step := int64(1)
+	expected := rolloutsResponse{
+		Rollouts: []rolloutStatus{
+			{
+				Name:             "web",
+				Strategy:         rolloutStrategyCanary,
+				Paused:           true,
+				CurrentStep:      &step,
+				TotalSteps:       3,
+				StableReplicaSet: "web-aaa",
+				CanaryReplicaSet: "web-bbb",
+			},
+		},
+	}
+	assert.Equal(t, expected, got)
+}
+
+func Test_rolloutPauseHandler(t *testing.T) {
+	tests := []struct {
+		name            string
+		paused          bool
+		rollout         string
+		expectedCode    int
+		expectedPaused  bool
+		expectedPatches int
+	}{
+		{
+			name:            "pause",
+			paused:          true,
+			rollout:         "web",
+			expectedCode:    http.StatusOK,
+			expectedPaused:  true,
+			expectedPatches: 1,
+		},
+		{
+			name:            "resume",
+			paused:          false,
+			rollout:         "web",
+			expectedCode:    http.StatusOK,
+			expectedPaused:  false,
+			expectedPatches: 2,
+		},
+		{
+			name:         "missing rollout",
+			paused:       true,
+			rollout:      "missing",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newTestRollout())
+			handler := newRolloutPauseHandler(newTestRolloutReplicaSets(), dynamicClient, tc.paused, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/argo/default/"+tc.rollout+"/pause", nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default", "rollout": tc.rollout})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var got rolloutStatus
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tc.expectedPaused, got.Paused)
+
+			patches := 0
+			for _, action := range dynamicClient.Actions() {
+				if action.GetVerb() == "patch" {
+					patches++
+				}
+			}
+			assert.Equal(t, tc.expectedPatches, patches)
+		})
+	}
+}
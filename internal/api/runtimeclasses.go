@@ -0,0 +1,155 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+var (
+	runtimeClassGVR = schema.GroupVersionResource{
+		Group:    "node.k8s.io",
+		Version:  "v1",
+		Resource: "runtimeclasses",
+	}
+
+	runtimeClassBetaGVR = schema.GroupVersionResource{
+		Group:    "node.k8s.io",
+		Version:  "v1beta1",
+		Resource: "runtimeclasses",
+	}
+)
+
+type runtimeClassScheduling struct {
+	NodeSelector map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations  []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+type runtimeClassStatus struct {
+	Name       string                  `json:"name"`
+	Handler    string                  `json:"handler"`
+	Overhead   corev1.ResourceList     `json:"overhead,omitempty"`
+	Scheduling *runtimeClassScheduling `json:"scheduling,omitempty"`
+	Pods       []string                `json:"pods"`
+}
+
+type runtimeClassesResponse struct {
+	RuntimeClasses []runtimeClassStatus `json:"runtimeClasses"`
+}
+
+type runtimeClassesHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*runtimeClassesHandler)(nil)
+
+func newRuntimeClassesHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *runtimeClassesHandler {
+	return &runtimeClassesHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the cluster's RuntimeClasses.
+// When the `namespace` query parameter is set, each runtime class includes the
+// pods in that namespace which use it.
+func (h *runtimeClassesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	runtimeClasses, err := h.listRuntimeClasses()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	podsByRuntimeClass := make(map[string][]string)
+	if namespace := r.URL.Query().Get("namespace"); namespace != "" {
+		pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		for _, pod := range pods.Items {
+			if pod.Spec.RuntimeClassName == nil {
+				continue
+			}
+			name := *pod.Spec.RuntimeClassName
+			podsByRuntimeClass[name] = append(podsByRuntimeClass[name], pod.Name)
+		}
+	}
+
+	resp := runtimeClassesResponse{
+		RuntimeClasses: []runtimeClassStatus{},
+	}
+
+	for _, runtimeClass := range runtimeClasses.Items {
+		status, err := newRuntimeClassStatus(runtimeClass)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		status.Pods = podsByRuntimeClass[status.Name]
+		if status.Pods == nil {
+			status.Pods = []string{}
+		}
+		sort.Strings(status.Pods)
+
+		resp.RuntimeClasses = append(resp.RuntimeClasses, status)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// listRuntimeClasses lists RuntimeClasses using node.k8s.io/v1, falling back
+// to v1beta1 on clusters which don't serve v1 yet.
+func (h *runtimeClassesHandler) listRuntimeClasses() (*unstructured.UnstructuredList, error) {
+	list, installed, err := listOptionalResource(h.dynamicClient, runtimeClassGVR, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if installed {
+		return list, nil
+	}
+
+	list, _, err = listOptionalResource(h.dynamicClient, runtimeClassBetaGVR, "", metav1.ListOptions{})
+	return list, err
+}
+
+func newRuntimeClassStatus(runtimeClass unstructured.Unstructured) (runtimeClassStatus, error) {
+	handler, _, _ := unstructured.NestedString(runtimeClass.Object, "handler")
+
+	status := runtimeClassStatus{
+		Name:    runtimeClass.GetName(),
+		Handler: handler,
+	}
+
+	if podFixed, ok, _ := unstructured.NestedStringMap(runtimeClass.Object, "overhead", "podFixed"); ok {
+		status.Overhead = parseResourceList(podFixed)
+	}
+
+	if scheduling, ok, _ := unstructured.NestedMap(runtimeClass.Object, "scheduling"); ok {
+		status.Scheduling = &runtimeClassScheduling{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(scheduling, status.Scheduling); err != nil {
+			return runtimeClassStatus{}, err
+		}
+	}
+
+	return status, nil
+}
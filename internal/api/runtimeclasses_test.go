@@ -0,0 +1,113 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_runtimeClassesHandler(t *testing.T) {
+	gvisor := "gvisor"
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "sandboxed", Namespace: "default"},
+			Spec:       corev1.PodSpec{RuntimeClassName: &gvisor},
+		},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "default"}},
+	)
+
+	newRuntimeClass := func(apiVersion string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       "RuntimeClass",
+			"metadata":   map[string]interface{}{"name": "gvisor"},
+			"handler":    "runsc",
+			"overhead": map[string]interface{}{
+				"podFixed": map[string]interface{}{"cpu": "250m", "memory": "64Mi"},
+			},
+			"scheduling": map[string]interface{}{
+				"nodeSelector": map[string]interface{}{"sandbox": "gvisor"},
+				"tolerations": []interface{}{
+					map[string]interface{}{"key": "sandbox", "operator": "Exists", "effect": "NoSchedule"},
+				},
+			},
+		}}
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		v1Missing    bool
+		apiVersion   string
+		expectedPods []string
+	}{
+		{
+			name:         "node.k8s.io/v1 with pods",
+			query:        "?namespace=default",
+			apiVersion:   "node.k8s.io/v1",
+			expectedPods: []string{"sandboxed"},
+		},
+		{
+			name:         "falls back to v1beta1",
+			v1Missing:    true,
+			apiVersion:   "node.k8s.io/v1beta1",
+			expectedPods: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newRuntimeClass(tc.apiVersion))
+			if tc.v1Missing {
+				dynamicClient.PrependReactor("list", "runtimeclasses", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					if action.GetResource().Version != "v1" {
+						return false, nil, nil
+					}
+					return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+				})
+			}
+
+			handler := newRuntimeClassesHandler(kubeClient, dynamicClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/runtimeclasses"+tc.query, nil)
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got runtimeClassesResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			require.Len(t, got.RuntimeClasses, 1)
+
+			runtimeClass := got.RuntimeClasses[0]
+			assert.Equal(t, "gvisor", runtimeClass.Name)
+			assert.Equal(t, "runsc", runtimeClass.Handler)
+			assert.Equal(t, "250m", runtimeClass.Overhead.Cpu().String())
+			require.NotNil(t, runtimeClass.Scheduling)
+			assert.Equal(t, map[string]string{"sandbox": "gvisor"}, runtimeClass.Scheduling.NodeSelector)
+			assert.Equal(t, []corev1.Toleration{
+				{Key: "sandbox", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+			}, runtimeClass.Scheduling.Tolerations)
+			assert.Equal(t, tc.expectedPods, runtimeClass.Pods)
+		})
+	}
+}
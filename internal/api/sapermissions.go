@@ -0,0 +1,243 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// serviceAccountsGroup is the group of every service account.
+	serviceAccountsGroup = "system:serviceaccounts"
+)
+
+type saPermission struct {
+	// Namespace is where the permission applies. It is empty for permissions
+	// granted cluster wide.
+	Namespace       string   `json:"namespace,omitempty"`
+	Verb            string   `json:"verb"`
+	APIGroups       []string `json:"apiGroups"`
+	Resources       []string `json:"resources"`
+	ResourceNames   []string `json:"resourceNames"`
+	NonResourceURLs []string `json:"nonResourceURLs,omitempty"`
+	Wildcard        bool     `json:"wildcard"`
+	// GrantedBy lists the bindings granting the permission.
+	GrantedBy []string `json:"grantedBy"`
+}
+
+type saPermissionsResponse struct {
+	ServiceAccount string         `json:"serviceAccount"`
+	Namespace      string         `json:"namespace"`
+	Permissions    []saPermission `json:"permissions"`
+}
+
+type saPermissionsHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*saPermissionsHandler)(nil)
+
+func newSAPermissionsHandler(kubeClient kubernetes.Interface, logger log.Logger) *saPermissionsHandler {
+	return &saPermissionsHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns what a service account can do.
+// Role bindings and cluster role bindings naming the service account, its
+// user name or the service account groups are resolved to their roles' rules.
+// Rules are flattened to one permission per verb and deduplicated across
+// bindings. Permissions using `*` are flagged as wildcards.
+func (h *saPermissionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace, name := vars["namespace"], vars["name"]
+
+	if _, err := h.kubeClient.CoreV1().ServiceAccounts(namespace).Get(name, metav1.GetOptions{}); err != nil {
+		if kerrors.IsNotFound(err) {
+			RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	rbac := h.kubeClient.RbacV1()
+
+	clusterRoleList, err := rbac.ClusterRoles().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	roleList, err := rbac.Roles(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	clusterRoleBindings, err := rbac.ClusterRoleBindings().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	roleBindings, err := rbac.RoleBindings(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	clusterRoles := make(map[string][]rbacv1.PolicyRule)
+	for _, clusterRole := range clusterRoleList.Items {
+		clusterRoles[clusterRole.Name] = clusterRole.Rules
+	}
+
+	roles := make(map[string][]rbacv1.PolicyRule)
+	for _, role := range roleList.Items {
+		roles[role.Namespace+"/"+role.Name] = role.Rules
+	}
+
+	rules := func(bindingNamespace string, roleRef rbacv1.RoleRef) []rbacv1.PolicyRule {
+		switch roleRef.Kind {
+		case "ClusterRole":
+			return clusterRoles[roleRef.Name]
+		case "Role":
+			return roles[bindingNamespace+"/"+roleRef.Name]
+		}
+		return nil
+	}
+
+	permissions := make(map[string]*saPermission)
+	var keys []string
+
+	add := func(scope, grantedBy string, rule rbacv1.PolicyRule) {
+		for _, verb := range rule.Verbs {
+			permission := saPermission{
+				Namespace:       scope,
+				Verb:            verb,
+				APIGroups:       sortedStrings(rule.APIGroups),
+				Resources:       sortedStrings(rule.Resources),
+				ResourceNames:   sortedStrings(rule.ResourceNames),
+				NonResourceURLs: sortedStrings(rule.NonResourceURLs),
+			}
+			permission.Wildcard = verb == rbacv1.VerbAll ||
+				containsWildcard(permission.APIGroups) ||
+				containsWildcard(permission.Resources) ||
+				containsWildcard(permission.NonResourceURLs)
+
+			key := saPermissionKey(permission)
+			existing, ok := permissions[key]
+			if !ok {
+				permission.GrantedBy = []string{}
+				existing = &permission
+				permissions[key] = existing
+				keys = append(keys, key)
+			}
+			if !containsString(existing.GrantedBy, grantedBy) {
+				existing.GrantedBy = append(existing.GrantedBy, grantedBy)
+			}
+		}
+	}
+
+	for _, binding := range clusterRoleBindings.Items {
+		if !bindsServiceAccount(binding.Subjects, "", namespace, name) {
+			continue
+		}
+		for _, rule := range rules("", binding.RoleRef) {
+			add("", "ClusterRoleBinding "+binding.Name, rule)
+		}
+	}
+
+	for _, binding := range roleBindings.Items {
+		if !bindsServiceAccount(binding.Subjects, binding.Namespace, namespace, name) {
+			continue
+		}
+		for _, rule := range rules(binding.Namespace, binding.RoleRef) {
+			add(binding.Namespace, fmt.Sprintf("RoleBinding %s/%s", binding.Namespace, binding.Name), rule)
+		}
+	}
+
+	resp := saPermissionsResponse{
+		ServiceAccount: name,
+		Namespace:      namespace,
+		Permissions:    []saPermission{},
+	}
+
+	for _, key := range keys {
+		resp.Permissions = append(resp.Permissions, *permissions[key])
+	}
+
+	sort.Slice(resp.Permissions, func(i, j int) bool {
+		a, b := resp.Permissions[i], resp.Permissions[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Verb != b.Verb {
+			return a.Verb < b.Verb
+		}
+		return saPermissionKey(a) < saPermissionKey(b)
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// bindsServiceAccount returns true if a binding's subjects include the
+// service account, its user name, or a group containing it.
+func bindsServiceAccount(subjects []rbacv1.Subject, bindingNamespace, namespace, name string) bool {
+	for _, subject := range subjects {
+		switch subject.Kind {
+		case rbacv1.ServiceAccountKind:
+			subjectNamespace := subject.Namespace
+			if subjectNamespace == "" {
+				subjectNamespace = bindingNamespace
+			}
+			if subject.Name == name && subjectNamespace == namespace {
+				return true
+			}
+		case rbacv1.UserKind:
+			if subject.Name == serviceAccountUserPrefix+namespace+":"+name {
+				return true
+			}
+		case rbacv1.GroupKind:
+			if subject.Name == serviceAccountsGroup || subject.Name == serviceAccountsGroup+":"+namespace {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// saPermissionKey identifies a permission.
+func saPermissionKey(permission saPermission) string {
+	return strings.Join([]string{
+		permission.Namespace,
+		permission.Verb,
+		strings.Join(permission.APIGroups, ","),
+		strings.Join(permission.Resources, ","),
+		strings.Join(permission.ResourceNames, ","),
+		strings.Join(permission.NonResourceURLs, ","),
+	}, "|")
+}
+
+func sortedStrings(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return sorted
+}
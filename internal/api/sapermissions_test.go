@@ -0,0 +1,146 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_saPermissionsHandler(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"}},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-reader"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}},
+			},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "metrics"},
+			Rules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"get"}},
+			},
+		},
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "config-admin", Namespace: "default"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"settings"}, Verbs: []string{"*"}},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "readers"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "pod-reader"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "app", Namespace: "default"}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "all-service-accounts"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "metrics"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: serviceAccountsGroup}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "pod-reader"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "app", Namespace: "other"}},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "readers-by-user", Namespace: "default"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "pod-reader"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "system:serviceaccount:default:app"}},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"},
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "config-admin"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "app"}},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "config-again", Namespace: "default"},
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "config-admin"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "system:serviceaccounts:default"}},
+		},
+	)
+
+	tests := []struct {
+		name         string
+		account      string
+		expectedCode int
+		expected     []saPermission
+	}{
+		{
+			name:         "service account",
+			account:      "app",
+			expectedCode: http.StatusOK,
+			expected: []saPermission{
+				{
+					Verb: "get", APIGroups: []string{""}, Resources: []string{"pods"}, ResourceNames: []string{},
+					GrantedBy: []string{"ClusterRoleBinding readers"},
+				},
+				{
+					Verb: "get", APIGroups: []string{}, Resources: []string{}, ResourceNames: []string{},
+					NonResourceURLs: []string{"/metrics"},
+					GrantedBy:       []string{"ClusterRoleBinding all-service-accounts"},
+				},
+				{
+					Verb: "list", APIGroups: []string{""}, Resources: []string{"pods"}, ResourceNames: []string{},
+					GrantedBy: []string{"ClusterRoleBinding readers"},
+				},
+				{
+					Namespace: "default", Verb: "*", APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"settings"},
+					Wildcard:  true,
+					GrantedBy: []string{"RoleBinding default/config", "RoleBinding default/config-again"},
+				},
+				{
+					Namespace: "default", Verb: "get", APIGroups: []string{""}, Resources: []string{"pods"}, ResourceNames: []string{},
+					GrantedBy: []string{"RoleBinding default/readers-by-user"},
+				},
+				{
+					Namespace: "default", Verb: "list", APIGroups: []string{""}, Resources: []string{"pods"}, ResourceNames: []string{},
+					GrantedBy: []string{"RoleBinding default/readers-by-user"},
+				},
+			},
+		},
+		{
+			name:         "missing service account",
+			account:      "missing",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newSAPermissionsHandler(kubeClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/serviceaccounts/default/"+tc.account+"/permissions", nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default", "name": tc.account})
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var resp saPermissionsResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+			assert.Equal(t, "app", resp.ServiceAccount)
+			assert.Equal(t, tc.expected, resp.Permissions)
+		})
+	}
+}
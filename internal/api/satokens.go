@@ -0,0 +1,146 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"path"
+	"sort"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// defaultTokenExpirationSeconds is the lifetime of a projected token
+	// which does not set one.
+	defaultTokenExpirationSeconds = 3600
+
+	// longLivedTokenSeconds is the lifetime above which a projected token is
+	// flagged as long lived.
+	longLivedTokenSeconds = 24 * 60 * 60
+)
+
+type serviceAccountTokenProjection struct {
+	Pod               string `json:"pod"`
+	Container         string `json:"container"`
+	ServiceAccount    string `json:"serviceAccount"`
+	Audience          string `json:"audience"`
+	ExpirationSeconds int64  `json:"expirationSeconds"`
+	MountPath         string `json:"mountPath"`
+	TokenPath         string `json:"tokenPath"`
+	LongLived         bool   `json:"longLived"`
+}
+
+type serviceAccountTokensResponse struct {
+	Tokens []serviceAccountTokenProjection `json:"tokens"`
+}
+
+type satokensHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*satokensHandler)(nil)
+
+func newSATokensHandler(kubeClient kubernetes.Interface, logger log.Logger) *satokensHandler {
+	return &satokensHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and lists the service account tokens
+// projected into the containers of a namespace's pods, one entry for each
+// container mounting a projected volume with a token source. Tokens without
+// an expiration last an hour, and tokens lasting more than a day are flagged
+// as long lived. An empty audience is the API server's.
+func (h *satokensHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := serviceAccountTokensResponse{
+		Tokens: []serviceAccountTokenProjection{},
+	}
+
+	for _, pod := range pods.Items {
+		resp.Tokens = append(resp.Tokens, podTokenProjections(pod)...)
+	}
+
+	sort.Slice(resp.Tokens, func(i, j int) bool {
+		a, b := resp.Tokens[i], resp.Tokens[j]
+		if a.Pod != b.Pod {
+			return a.Pod < b.Pod
+		}
+		if a.Container != b.Container {
+			return a.Container < b.Container
+		}
+		return a.TokenPath < b.TokenPath
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// podTokenProjections returns the token projections mounted by each of a
+// pod's init and app containers.
+func podTokenProjections(pod corev1.Pod) []serviceAccountTokenProjection {
+	sources := make(map[string][]*corev1.ServiceAccountTokenProjection)
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Projected == nil {
+			continue
+		}
+		for _, source := range volume.Projected.Sources {
+			if source.ServiceAccountToken != nil {
+				sources[volume.Name] = append(sources[volume.Name], source.ServiceAccountToken)
+			}
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil
+	}
+
+	serviceAccount := pod.Spec.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+
+	var projections []serviceAccountTokenProjection
+
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, container := range containers {
+		for _, mount := range container.VolumeMounts {
+			for _, token := range sources[mount.Name] {
+				expiration := int64(defaultTokenExpirationSeconds)
+				if token.ExpirationSeconds != nil {
+					expiration = *token.ExpirationSeconds
+				}
+
+				projections = append(projections, serviceAccountTokenProjection{
+					Pod:               pod.Name,
+					Container:         container.Name,
+					ServiceAccount:    serviceAccount,
+					Audience:          token.Audience,
+					ExpirationSeconds: expiration,
+					MountPath:         mount.MountPath,
+					TokenPath:         path.Join(mount.MountPath, token.Path),
+					LongLived:         expiration > longLivedTokenSeconds,
+				})
+			}
+		}
+	}
+
+	return projections
+}
@@ -0,0 +1,114 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_satokensHandler(t *testing.T) {
+	seconds := func(n int64) *int64 { return &n }
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				ServiceAccountName: "web",
+				Volumes: []corev1.Volume{
+					{
+						Name: "vault-token",
+						VolumeSource: corev1.VolumeSource{
+							Projected: &corev1.ProjectedVolumeSource{
+								Sources: []corev1.VolumeProjection{
+									{ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Audience: "vault", ExpirationSeconds: seconds(7 * 24 * 60 * 60), Path: "token"}},
+								},
+							},
+						},
+					},
+					{
+						Name: "kube-api-access",
+						VolumeSource: corev1.VolumeSource{
+							Projected: &corev1.ProjectedVolumeSource{
+								Sources: []corev1.VolumeProjection{
+									{ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Path: "token"}},
+									{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "kube-root-ca.crt"}}},
+								},
+							},
+						},
+					},
+					{
+						Name:         "config",
+						VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "web"}}},
+					},
+				},
+				InitContainers: []corev1.Container{
+					{Name: "init", VolumeMounts: []corev1.VolumeMount{{Name: "vault-token", MountPath: "/var/run/secrets/vault"}}},
+				},
+				Containers: []corev1.Container{
+					{
+						Name: "app",
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: "kube-api-access", MountPath: "/var/run/secrets/kubernetes.io/serviceaccount"},
+							{Name: "config", MountPath: "/etc/web"},
+						},
+					},
+				},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app"}},
+			},
+		},
+	)
+
+	handler := newSATokensHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/serviceaccounttokens", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got serviceAccountTokensResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := []serviceAccountTokenProjection{
+		{
+			Pod:               "web",
+			Container:         "app",
+			ServiceAccount:    "web",
+			ExpirationSeconds: 3600,
+			MountPath:         "/var/run/secrets/kubernetes.io/serviceaccount",
+			TokenPath:         "/var/run/secrets/kubernetes.io/serviceaccount/token",
+		},
+		{
+			Pod:               "web",
+			Container:         "init",
+			ServiceAccount:    "web",
+			Audience:          "vault",
+			ExpirationSeconds: 604800,
+			MountPath:         "/var/run/secrets/vault",
+			TokenPath:         "/var/run/secrets/vault/token",
+			LongLived:         true,
+		},
+	}
+	assert.Equal(t, expected, got.Tokens)
+}
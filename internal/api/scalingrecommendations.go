@@ -0,0 +1,271 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type hpaScalingRecommendation struct {
+	Name                   string `json:"name"`
+	MinReplicas            int32  `json:"minReplicas"`
+	MaxReplicas            int32  `json:"maxReplicas"`
+	CurrentReplicas        int32  `json:"currentReplicas"`
+	DesiredReplicas        int32  `json:"desiredReplicas"`
+	CPUTargetUtilization   *int32 `json:"cpuTargetUtilization,omitempty"`
+	CPUCurrentUtilization  *int32 `json:"cpuCurrentUtilization,omitempty"`
+	ScalingOutForCPUTarget bool   `json:"scalingOutForCPUTarget"`
+}
+
+type vpaContainerScalingRecommendation struct {
+	Container      string              `json:"container"`
+	CurrentRequest corev1.ResourceList `json:"currentRequest"`
+	Target         corev1.ResourceList `json:"target"`
+}
+
+type vpaScalingRecommendation struct {
+	Containers []vpaContainerScalingRecommendation `json:"containers"`
+	ReducesCPU bool                                `json:"reducesCPU"`
+}
+
+type scalingRecommendation struct {
+	Workload          workloadRef               `json:"workload"`
+	HPARecommendation *hpaScalingRecommendation `json:"hpaRecommendation,omitempty"`
+	VPARecommendation *vpaScalingRecommendation `json:"vpaRecommendation,omitempty"`
+	Conflict          bool                      `json:"conflict"`
+	ConflictReason    string                    `json:"conflictReason,omitempty"`
+}
+
+type scalingRecommendationsResponse struct {
+	Recommendations []scalingRecommendation `json:"recommendations"`
+	VPAInstalled    bool                    `json:"vpaInstalled"`
+}
+
+type scalingRecommendationsHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*scalingRecommendationsHandler)(nil)
+
+func newScalingRecommendationsHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *scalingRecommendationsHandler {
+	return &scalingRecommendationsHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the HorizontalPodAutoscaler
+// and VerticalPodAutoscaler recommendations for each workload in a namespace,
+// matched by target reference. A workload is in conflict when its HPA is
+// scaling out because CPU utilization is over target while its VPA recommends
+// lowering a container's CPU request.
+func (h *scalingRecommendationsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	hpas, err := h.kubeClient.AutoscalingV2beta2().HorizontalPodAutoscalers(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	vpas, installed, err := listOptionalResource(h.dynamicClient, vpaGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	byWorkload := make(map[workloadRef]*scalingRecommendation)
+	recommendationFor := func(ref workloadRef) *scalingRecommendation {
+		if _, ok := byWorkload[ref]; !ok {
+			byWorkload[ref] = &scalingRecommendation{Workload: ref}
+		}
+		return byWorkload[ref]
+	}
+
+	for _, hpa := range hpas.Items {
+		ref := workloadRef{Kind: hpa.Spec.ScaleTargetRef.Kind, Name: hpa.Spec.ScaleTargetRef.Name}
+		recommendationFor(ref).HPARecommendation = hpaRecommendation(hpa)
+	}
+
+	for ref, targets := range vpaRecommendationsByWorkload(vpas.Items) {
+		requests, err := h.containerRequests(namespace, ref)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		recommendationFor(ref).VPARecommendation = vpaRecommendation(targets, requests)
+	}
+
+	resp := scalingRecommendationsResponse{
+		Recommendations: []scalingRecommendation{},
+		VPAInstalled:    installed,
+	}
+
+	for _, recommendation := range byWorkload {
+		hpa, vpa := recommendation.HPARecommendation, recommendation.VPARecommendation
+		if hpa != nil && vpa != nil && hpa.ScalingOutForCPUTarget && vpa.ReducesCPU {
+			recommendation.Conflict = true
+			recommendation.ConflictReason = fmt.Sprintf("HPA %s is scaling out on CPU while VPA recommends lower CPU requests", hpa.Name)
+		}
+
+		resp.Recommendations = append(resp.Recommendations, *recommendation)
+	}
+
+	sort.Slice(resp.Recommendations, func(i, j int) bool {
+		a, b := resp.Recommendations[i].Workload, resp.Recommendations[j].Workload
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// containerRequests returns the container resource requests from a
+// workload's pod template. Workloads which no longer exist, or are not a
+// known kind, have no requests.
+func (h *scalingRecommendationsHandler) containerRequests(namespace string, ref workloadRef) (map[string]corev1.ResourceList, error) {
+	requests := make(map[string]corev1.ResourceList)
+
+	template, err := h.podTemplate(namespace, ref)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return requests, nil
+		}
+		return nil, errors.Wrapf(err, "get %s %s", ref.Kind, ref.Name)
+	}
+
+	if template == nil {
+		return requests, nil
+	}
+
+	for _, container := range template.Spec.Containers {
+		requests[container.Name] = container.Resources.Requests
+	}
+
+	return requests, nil
+}
+
+func (h *scalingRecommendationsHandler) podTemplate(namespace string, ref workloadRef) (*corev1.PodTemplateSpec, error) {
+	apps := h.kubeClient.AppsV1()
+
+	switch ref.Kind {
+	case "Deployment":
+		deployment, err := apps.Deployments(namespace).Get(ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &deployment.Spec.Template, nil
+	case "StatefulSet":
+		statefulSet, err := apps.StatefulSets(namespace).Get(ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &statefulSet.Spec.Template, nil
+	case "DaemonSet":
+		daemonSet, err := apps.DaemonSets(namespace).Get(ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &daemonSet.Spec.Template, nil
+	case "ReplicaSet":
+		replicaSet, err := apps.ReplicaSets(namespace).Get(ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &replicaSet.Spec.Template, nil
+	default:
+		return nil, nil
+	}
+}
+
+// hpaRecommendation summarizes an HPA's replica counts and CPU utilization
+// metric. The HPA is scaling out for CPU when it wants more replicas than it
+// has and CPU utilization is over target.
+func hpaRecommendation(hpa autoscalingv2beta2.HorizontalPodAutoscaler) *hpaScalingRecommendation {
+	recommendation := &hpaScalingRecommendation{
+		Name:            hpa.Name,
+		MinReplicas:     1,
+		MaxReplicas:     hpa.Spec.MaxReplicas,
+		CurrentReplicas: hpa.Status.CurrentReplicas,
+		DesiredReplicas: hpa.Status.DesiredReplicas,
+	}
+	if hpa.Spec.MinReplicas != nil {
+		recommendation.MinReplicas = *hpa.Spec.MinReplicas
+	}
+
+	for _, metric := range hpa.Spec.Metrics {
+		if metric.Type == autoscalingv2beta2.ResourceMetricSourceType && metric.Resource != nil &&
+			metric.Resource.Name == corev1.ResourceCPU {
+			recommendation.CPUTargetUtilization = metric.Resource.Target.AverageUtilization
+		}
+	}
+
+	for _, metric := range hpa.Status.CurrentMetrics {
+		if metric.Type == autoscalingv2beta2.ResourceMetricSourceType && metric.Resource != nil &&
+			metric.Resource.Name == corev1.ResourceCPU {
+			recommendation.CPUCurrentUtilization = metric.Resource.Current.AverageUtilization
+		}
+	}
+
+	target, current := recommendation.CPUTargetUtilization, recommendation.CPUCurrentUtilization
+	recommendation.ScalingOutForCPUTarget = recommendation.DesiredReplicas > recommendation.CurrentReplicas &&
+		target != nil && current != nil && *current > *target
+
+	return recommendation
+}
+
+// vpaRecommendation pairs a VPA's container targets with the containers'
+// current requests. It reduces CPU when any target is below the container's
+// CPU request.
+func vpaRecommendation(targets, requests map[string]corev1.ResourceList) *vpaScalingRecommendation {
+	recommendation := &vpaScalingRecommendation{
+		Containers: []vpaContainerScalingRecommendation{},
+	}
+
+	for container, target := range targets {
+		current := requests[container]
+		if current == nil {
+			current = corev1.ResourceList{}
+		}
+
+		recommendation.Containers = append(recommendation.Containers, vpaContainerScalingRecommendation{
+			Container:      container,
+			CurrentRequest: current,
+			Target:         target,
+		})
+
+		request, hasRequest := current[corev1.ResourceCPU]
+		cpu, hasTarget := target[corev1.ResourceCPU]
+		if hasRequest && hasTarget && cpu.Cmp(request) < 0 {
+			recommendation.ReducesCPU = true
+		}
+	}
+
+	sort.Slice(recommendation.Containers, func(i, j int) bool {
+		return recommendation.Containers[i].Container < recommendation.Containers[j].Container
+	})
+
+	return recommendation
+}
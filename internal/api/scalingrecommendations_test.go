@@ -0,0 +1,168 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_scalingRecommendationsHandler(t *testing.T) {
+	int32Ptr := func(n int32) *int32 { return &n }
+
+	template := func(cpu string) corev1.PodTemplateSpec {
+		return corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name: "app",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	newHPA := func(name, target string, current, desired, currentCPU int32) *autoscalingv2beta2.HorizontalPodAutoscaler {
+		return &autoscalingv2beta2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: autoscalingv2beta2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2beta2.CrossVersionObjectReference{Kind: "Deployment", Name: target},
+				MinReplicas:    int32Ptr(2),
+				MaxReplicas:    10,
+				Metrics: []autoscalingv2beta2.MetricSpec{
+					{
+						Type: autoscalingv2beta2.ResourceMetricSourceType,
+						Resource: &autoscalingv2beta2.ResourceMetricSource{
+							Name:   corev1.ResourceCPU,
+							Target: autoscalingv2beta2.MetricTarget{Type: autoscalingv2beta2.UtilizationMetricType, AverageUtilization: int32Ptr(70)},
+						},
+					},
+				},
+			},
+			Status: autoscalingv2beta2.HorizontalPodAutoscalerStatus{
+				CurrentReplicas: current,
+				DesiredReplicas: desired,
+				CurrentMetrics: []autoscalingv2beta2.MetricStatus{
+					{
+						Type: autoscalingv2beta2.ResourceMetricSourceType,
+						Resource: &autoscalingv2beta2.ResourceMetricStatus{
+							Name:    corev1.ResourceCPU,
+							Current: autoscalingv2beta2.MetricValueStatus{AverageUtilization: int32Ptr(currentCPU)},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	newVPA := func(name, kind, target, cpu string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "autoscaling.k8s.io/v1",
+			"kind":       "VerticalPodAutoscaler",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"spec": map[string]interface{}{
+				"targetRef": map[string]interface{}{"kind": kind, "name": target},
+			},
+			"status": map[string]interface{}{
+				"recommendation": map[string]interface{}{
+					"containerRecommendations": []interface{}{
+						map[string]interface{}{
+							"containerName": "app",
+							"target":        map[string]interface{}{"cpu": cpu},
+						},
+					},
+				},
+			},
+		}}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Template: template("500m")},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+			Spec:       appsv1.StatefulSetSpec{Template: template("250m")},
+		},
+		newHPA("web", "web", 2, 4, 90),
+		newHPA("api", "api", 3, 3, 40),
+	)
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newVPA("web", "Deployment", "web", "200m"),
+		newVPA("db", "StatefulSet", "db", "1"),
+	)
+
+	handler := newScalingRecommendationsHandler(kubeClient, dynamicClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/autoscaling/recommendations/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got scalingRecommendationsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	assert.True(t, got.VPAInstalled)
+	require.Len(t, got.Recommendations, 3)
+
+	api, web, db := got.Recommendations[0], got.Recommendations[1], got.Recommendations[2]
+
+	assert.Equal(t, workloadRef{Kind: "Deployment", Name: "api"}, api.Workload)
+	require.NotNil(t, api.HPARecommendation)
+	assert.False(t, api.HPARecommendation.ScalingOutForCPUTarget)
+	assert.Nil(t, api.VPARecommendation)
+	assert.False(t, api.Conflict)
+
+	assert.Equal(t, workloadRef{Kind: "Deployment", Name: "web"}, web.Workload)
+	require.NotNil(t, web.HPARecommendation)
+	assert.Equal(t, &hpaScalingRecommendation{
+		Name:                   "web",
+		MinReplicas:            2,
+		MaxReplicas:            10,
+		CurrentReplicas:        2,
+		DesiredReplicas:        4,
+		CPUTargetUtilization:   int32Ptr(70),
+		CPUCurrentUtilization:  int32Ptr(90),
+		ScalingOutForCPUTarget: true,
+	}, web.HPARecommendation)
+	require.NotNil(t, web.VPARecommendation)
+	require.Len(t, web.VPARecommendation.Containers, 1)
+	webCPU := web.VPARecommendation.Containers[0].CurrentRequest[corev1.ResourceCPU]
+	assert.Equal(t, "500m", webCPU.String())
+	assert.True(t, web.VPARecommendation.ReducesCPU)
+	assert.True(t, web.Conflict)
+	assert.Equal(t, "HPA web is scaling out on CPU while VPA recommends lower CPU requests", web.ConflictReason)
+
+	assert.Equal(t, workloadRef{Kind: "StatefulSet", Name: "db"}, db.Workload)
+	assert.Nil(t, db.HPARecommendation)
+	require.NotNil(t, db.VPARecommendation)
+	assert.False(t, db.VPARecommendation.ReducesCPU)
+	assert.False(t, db.Conflict)
+}
@@ -0,0 +1,188 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// podSecurityLabelPrefix prefixes the namespace labels used by Pod
+	// Security Admission.
+	podSecurityLabelPrefix = "pod-security.kubernetes.io/"
+)
+
+type podSecurityAdmission struct {
+	Labeled bool              `json:"labeled"`
+	Labels  map[string]string `json:"labels"`
+}
+
+type securityPoliciesResponse struct {
+	Namespace                string               `json:"namespace"`
+	TotalPods                int                  `json:"totalPods"`
+	RunAsRoot                int                  `json:"runAsRoot"`
+	RunAsNonRootNotEnforced  int                  `json:"runAsNonRootNotEnforced"`
+	Privileged               int                  `json:"privileged"`
+	AllowPrivilegeEscalation int                  `json:"allowPrivilegeEscalation"`
+	DroppedAllCapabilities   int                  `json:"droppedAllCapabilities"`
+	PodSecurityAdmission     podSecurityAdmission `json:"podSecurityAdmission"`
+}
+
+type securityPoliciesHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*securityPoliciesHandler)(nil)
+
+func newSecurityPoliciesHandler(kubeClient kubernetes.Interface, logger log.Logger) *securityPoliciesHandler {
+	return &securityPoliciesHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and summarizes the security contexts of
+// the pods in the namespace given by the `namespace` query parameter. A pod is
+// counted when any of its containers matches. Privilege escalation is counted
+// when it is allowed explicitly or by default.
+func (h *securityPoliciesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		RespondWithError(w, http.StatusBadRequest, "namespace is required", h.logger)
+		return
+	}
+
+	ns, err := h.kubeClient.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := securityPoliciesResponse{
+		Namespace: namespace,
+		TotalPods: len(pods.Items),
+		PodSecurityAdmission: podSecurityAdmission{
+			Labels: map[string]string{},
+		},
+	}
+
+	for key, value := range ns.Labels {
+		if strings.HasPrefix(key, podSecurityLabelPrefix) {
+			resp.PodSecurityAdmission.Labels[strings.TrimPrefix(key, podSecurityLabelPrefix)] = value
+			resp.PodSecurityAdmission.Labeled = true
+		}
+	}
+
+	for i := range pods.Items {
+		summary := podSecuritySummary(&pods.Items[i])
+
+		if summary.runAsRoot {
+			resp.RunAsRoot++
+		}
+		if summary.runAsNonRootNotEnforced {
+			resp.RunAsNonRootNotEnforced++
+		}
+		if summary.privileged {
+			resp.Privileged++
+		}
+		if summary.allowPrivilegeEscalation {
+			resp.AllowPrivilegeEscalation++
+		}
+		if summary.droppedAllCapabilities {
+			resp.DroppedAllCapabilities++
+		}
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+type podSecurity struct {
+	runAsRoot                bool
+	runAsNonRootNotEnforced  bool
+	privileged               bool
+	allowPrivilegeEscalation bool
+	droppedAllCapabilities   bool
+}
+
+// podSecuritySummary combines the pod and container security contexts of a
+// pod. Container settings override pod settings.
+func podSecuritySummary(pod *corev1.Pod) podSecurity {
+	var runAsUser *int64
+	var runAsNonRoot *bool
+	if psc := pod.Spec.SecurityContext; psc != nil {
+		runAsUser = psc.RunAsUser
+		runAsNonRoot = psc.RunAsNonRoot
+	}
+
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+
+	summary := podSecurity{
+		droppedAllCapabilities: len(containers) > 0,
+	}
+
+	for _, container := range containers {
+		user, nonRoot := runAsUser, runAsNonRoot
+		privileged := false
+		escalation := true
+		droppedAll := false
+
+		if sc := container.SecurityContext; sc != nil {
+			if sc.RunAsUser != nil {
+				user = sc.RunAsUser
+			}
+			if sc.RunAsNonRoot != nil {
+				nonRoot = sc.RunAsNonRoot
+			}
+			if sc.Privileged != nil {
+				privileged = *sc.Privileged
+			}
+			if sc.AllowPrivilegeEscalation != nil {
+				escalation = *sc.AllowPrivilegeEscalation
+			}
+			if sc.Capabilities != nil {
+				for _, capability := range sc.Capabilities.Drop {
+					if strings.EqualFold(string(capability), "ALL") {
+						droppedAll = true
+					}
+				}
+			}
+		}
+
+		// Privileged containers can always escalate privileges.
+		escalation = escalation || privileged
+
+		if user != nil && *user == 0 {
+			summary.runAsRoot = true
+		}
+		if user == nil && (nonRoot == nil || !*nonRoot) {
+			summary.runAsNonRootNotEnforced = true
+		}
+		summary.privileged = summary.privileged || privileged
+		summary.allowPrivilegeEscalation = summary.allowPrivilegeEscalation || escalation
+		summary.droppedAllCapabilities = summary.droppedAllCapabilities && droppedAll
+	}
+
+	return summary
+}
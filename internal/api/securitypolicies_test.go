@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_securityPoliciesHandler(t *testing.T) {
+	root, nonRootUser := int64(0), int64(1000)
+	yes, no := true, false
+
+	newPod := func(name string, podContext *corev1.PodSecurityContext, contexts ...*corev1.SecurityContext) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       corev1.PodSpec{SecurityContext: podContext},
+		}
+		for _, sc := range contexts {
+			pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: "app", SecurityContext: sc})
+		}
+		return pod
+	}
+
+	restricted := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &no,
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "default",
+				Labels: map[string]string{
+					"pod-security.kubernetes.io/enforce": "baseline",
+					"pod-security.kubernetes.io/warn":    "restricted",
+					"team":                               "web",
+				},
+			},
+		},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "unlabeled"}},
+		newPod("restricted", &corev1.PodSecurityContext{RunAsUser: &nonRootUser}, restricted),
+		newPod("root", &corev1.PodSecurityContext{RunAsNonRoot: &yes}, &corev1.SecurityContext{RunAsUser: &root}),
+		newPod("privileged", nil, restricted, &corev1.SecurityContext{Privileged: &yes}),
+		newPod("defaults", nil, nil),
+	)
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expected     securityPoliciesResponse
+	}{
+		{
+			name:         "namespace",
+			query:        "?namespace=default",
+			expectedCode: http.StatusOK,
+			expected: securityPoliciesResponse{
+				Namespace:                "default",
+				TotalPods:                4,
+				RunAsRoot:                1,
+				RunAsNonRootNotEnforced:  2,
+				Privileged:               1,
+				AllowPrivilegeEscalation: 3,
+				DroppedAllCapabilities:   1,
+				PodSecurityAdmission: podSecurityAdmission{
+					Labeled: true,
+					Labels:  map[string]string{"enforce": "baseline", "warn": "restricted"},
+				},
+			},
+		},
+		{
+			name:         "unlabeled namespace",
+			query:        "?namespace=unlabeled",
+			expectedCode: http.StatusOK,
+			expected: securityPoliciesResponse{
+				Namespace:            "unlabeled",
+				PodSecurityAdmission: podSecurityAdmission{Labels: map[string]string{}},
+			},
+		},
+		{
+			name:         "missing namespace",
+			query:        "?namespace=missing",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "namespace required",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newSecurityPoliciesHandler(kubeClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/security/policies"+tc.query, nil)
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var got securityPoliciesResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
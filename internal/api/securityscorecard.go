@@ -0,0 +1,362 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	scorecardCheckPodSecurity        = "podSecurity"
+	scorecardCheckRBAC               = "rbac"
+	scorecardCheckNetworkPolicy      = "networkPolicy"
+	scorecardCheckSecretAge          = "secretAge"
+	scorecardCheckContainerPrivilege = "containerPrivilege"
+)
+
+// scorecardWeights are the points each finding of a severity takes off the
+// score.
+var scorecardWeights = map[findingSeverity]int{
+	severityHigh:   10,
+	severityMedium: 4,
+	severityLow:    1,
+}
+
+// scorecardSeverities is the order findings are returned in.
+var scorecardSeverities = []findingSeverity{severityHigh, severityMedium, severityLow}
+
+type securityScorecardFinding struct {
+	Check    string          `json:"check"`
+	Severity findingSeverity `json:"severity"`
+	Count    int             `json:"count"`
+	Details  []string        `json:"details"`
+}
+
+type securityScorecardResponse struct {
+	Score    int                        `json:"score"`
+	Grade    string                     `json:"grade"`
+	Findings []securityScorecardFinding `json:"findings"`
+}
+
+// scorecardDetails collects the details of a check's findings by severity.
+type scorecardDetails map[findingSeverity][]string
+
+func (d scorecardDetails) add(severity findingSeverity, format string, args ...interface{}) {
+	d[severity] = append(d[severity], fmt.Sprintf(format, args...))
+}
+
+// scorecardCheck finds the problems of one kind in a namespace.
+type scorecardCheck struct {
+	name string
+	run  func(namespace string) (scorecardDetails, error)
+}
+
+type securityScorecardHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	nowFn         func() time.Time
+	logger        log.Logger
+}
+
+var _ http.Handler = (*securityScorecardHandler)(nil)
+
+func newSecurityScorecardHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *securityScorecardHandler {
+	return &securityScorecardHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		nowFn:         time.Now,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and scores a namespace's security posture.
+// The Pod Security Standards, RBAC, network policy coverage, secret age and
+// container privilege checks run in parallel and their findings are counted
+// by severity. Each finding takes its severity's weight off a score of 100.
+func (h *securityScorecardHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	checks := []scorecardCheck{
+		{name: scorecardCheckPodSecurity, run: h.podSecurity},
+		{name: scorecardCheckRBAC, run: h.rbac},
+		{name: scorecardCheckNetworkPolicy, run: h.networkPolicy},
+		{name: scorecardCheckSecretAge, run: h.secretAge},
+		{name: scorecardCheckContainerPrivilege, run: h.containerPrivilege},
+	}
+
+	results := make([]scorecardDetails, len(checks))
+	errs := make([]error, len(checks))
+
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = checks[i].run(namespace)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, errors.Wrapf(err, "%s check", checks[i].name).Error(), h.logger)
+			return
+		}
+	}
+
+	resp := securityScorecardResponse{
+		Findings: []securityScorecardFinding{},
+	}
+
+	for _, severity := range scorecardSeverities {
+		for i, check := range checks {
+			details := results[i][severity]
+			if len(details) == 0 {
+				continue
+			}
+
+			sort.Strings(details)
+			resp.Findings = append(resp.Findings, securityScorecardFinding{
+				Check:    check.name,
+				Severity: severity,
+				Count:    len(details),
+				Details:  details,
+			})
+		}
+	}
+
+	resp.Score = securityScore(resp.Findings)
+	resp.Grade = securityGrade(resp.Score)
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// podSecurity finds pods which fail the baseline Pod Security Standard, or
+// pass it and fail the restricted standard.
+func (h *securityScorecardHandler) podSecurity(namespace string) (scorecardDetails, error) {
+	pods, err := h.dynamicClient.Resource(podGVR).Namespace(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list pods")
+	}
+
+	details := scorecardDetails{}
+	for _, object := range pods.Items {
+		var pod corev1.Pod
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &pod); err != nil {
+			return nil, errors.Wrapf(err, "convert pod %s", object.GetName())
+		}
+
+		if failures := psaBaselineFailures(&pod, object); len(failures) > 0 {
+			details.add(severityHigh, "pod %s fails %s: %s", pod.Name, psaLevelBaseline, psaFailureChecks(failures))
+			continue
+		}
+
+		if failures := psaRestrictedFailures(&pod, object); len(failures) > 0 {
+			details.add(severityLow, "pod %s fails %s: %s", pod.Name, psaLevelRestricted, psaFailureChecks(failures))
+		}
+	}
+
+	return details, nil
+}
+
+// rbac finds role bindings which grant cluster-admin, or every verb on every
+// resource.
+func (h *securityScorecardHandler) rbac(namespace string) (scorecardDetails, error) {
+	roleBindings, err := h.kubeClient.RbacV1().RoleBindings(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list role bindings")
+	}
+
+	rules := make(map[rbacv1.RoleRef][]rbacv1.PolicyRule)
+
+	details := scorecardDetails{}
+	for _, roleBinding := range roleBindings.Items {
+		roleRef := roleBinding.RoleRef
+
+		if roleRef.Kind == "ClusterRole" && roleRef.Name == clusterAdminRole {
+			details.add(severityHigh, "role binding %s grants %s", roleBinding.Name, clusterAdminRole)
+			continue
+		}
+
+		roleRules, ok := rules[roleRef]
+		if !ok {
+			roleRules, err = h.roleRules(namespace, roleRef)
+			if err != nil {
+				return nil, err
+			}
+			rules[roleRef] = roleRules
+		}
+
+		switch {
+		case grantsClusterAdmin(roleRules):
+			details.add(severityHigh, "role binding %s grants %s %s, which allows everything", roleBinding.Name, roleRef.Kind, roleRef.Name)
+		case grantsWildcardAccess(roleRules):
+			details.add(severityMedium, "role binding %s grants %s %s, which allows every verb on every resource", roleBinding.Name, roleRef.Kind, roleRef.Name)
+		}
+	}
+
+	return details, nil
+}
+
+// roleRules returns the rules of the role or cluster role a binding refers
+// to. Missing roles have no rules.
+func (h *securityScorecardHandler) roleRules(namespace string, roleRef rbacv1.RoleRef) ([]rbacv1.PolicyRule, error) {
+	switch roleRef.Kind {
+	case "ClusterRole":
+		clusterRole, err := h.kubeClient.RbacV1().ClusterRoles().Get(roleRef.Name, metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "get cluster role %s", roleRef.Name)
+		}
+		return clusterRole.Rules, nil
+	case "Role":
+		role, err := h.kubeClient.RbacV1().Roles(namespace).Get(roleRef.Name, metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "get role %s", roleRef.Name)
+		}
+		return role.Rules, nil
+	default:
+		return nil, nil
+	}
+}
+
+// networkPolicy finds running pods whose ingress or egress no network policy
+// restricts.
+func (h *securityScorecardHandler) networkPolicy(namespace string) (scorecardDetails, error) {
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list pods")
+	}
+
+	policies, err := h.kubeClient.NetworkingV1().NetworkPolicies(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list network policies")
+	}
+
+	details := scorecardDetails{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		coverage := podNetworkPolicyCoverage(pod, policies.Items, h.logger)
+		if finding, ok := networkPolicyCoverageFinding(coverage); ok {
+			details.add(finding.Severity, "pod %s: %s", finding.Pod, finding.Message)
+		}
+	}
+
+	return details, nil
+}
+
+// secretAge finds secrets older than a year which no service account uses.
+func (h *securityScorecardHandler) secretAge(namespace string) (scorecardDetails, error) {
+	secrets, err := h.kubeClient.CoreV1().Secrets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list secrets")
+	}
+
+	serviceAccounts, err := h.kubeClient.CoreV1().ServiceAccounts(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list service accounts")
+	}
+
+	details := scorecardDetails{}
+	for _, finding := range staleSecretAnomalies(secrets.Items, serviceAccounts.Items, h.nowFn()) {
+		details.add(finding.Severity, "secret %s: %s", finding.Resource.Name, finding.Message)
+	}
+
+	return details, nil
+}
+
+// containerPrivilege finds running pods with privileged containers or
+// containers which run as root.
+func (h *securityScorecardHandler) containerPrivilege(namespace string) (scorecardDetails, error) {
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list pods")
+	}
+
+	details := scorecardDetails{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		summary := podSecuritySummary(pod)
+		if summary.privileged {
+			details.add(severityHigh, "pod %s runs a privileged container", pod.Name)
+		}
+		if summary.runAsRoot {
+			details.add(severityMedium, "pod %s runs a container as root", pod.Name)
+		}
+	}
+
+	return details, nil
+}
+
+// psaFailureChecks lists the distinct checks which failed.
+func psaFailureChecks(failures []psaFailure) string {
+	var checks []string
+	for _, failure := range failures {
+		if !containsString(checks, failure.Check) {
+			checks = append(checks, failure.Check)
+		}
+	}
+
+	return strings.Join(checks, ", ")
+}
+
+// securityScore takes the weight of each finding off 100. Scores don't go
+// below zero.
+func securityScore(findings []securityScorecardFinding) int {
+	score := 100
+	for _, finding := range findings {
+		score -= scorecardWeights[finding.Severity] * finding.Count
+	}
+
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
+func securityGrade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
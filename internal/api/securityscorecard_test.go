@@ -0,0 +1,179 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_securityScorecardHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	yes := true
+
+	agent := &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default", Labels: map[string]string{"app": "agent"}},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "agent", SecurityContext: &corev1.SecurityContext{Privileged: &yes}},
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	web := &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{"app": "web"}},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "web"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	toUnstructured := func(pod *corev1.Pod) *unstructured.Unstructured {
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+		require.NoError(t, err)
+		return &unstructured.Unstructured{Object: object}
+	}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), toUnstructured(agent), toUnstructured(web))
+
+	kubeClient := kubefake.NewSimpleClientset(
+		agent,
+		web,
+		&networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "admins", Namespace: "default"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: clusterAdminRole},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "editors", Namespace: "default"},
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "everything"},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "missing", Namespace: "default"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "missing"},
+		},
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "everything", Namespace: "default"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "old",
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(now.AddDate(-2, 0, 0)),
+			},
+		},
+	)
+
+	type finding struct {
+		check    string
+		severity findingSeverity
+		count    int
+	}
+
+	tests := []struct {
+		name          string
+		namespace     string
+		expectedScore int
+		expectedGrade string
+		expected      []finding
+	}{
+		{
+			name:          "findings",
+			namespace:     "default",
+			expectedScore: 54,
+			expectedGrade: "F",
+			expected: []finding{
+				{check: scorecardCheckPodSecurity, severity: severityHigh, count: 1},
+				{check: scorecardCheckRBAC, severity: severityHigh, count: 1},
+				{check: scorecardCheckNetworkPolicy, severity: severityHigh, count: 1},
+				{check: scorecardCheckContainerPrivilege, severity: severityHigh, count: 1},
+				{check: scorecardCheckRBAC, severity: severityMedium, count: 1},
+				{check: scorecardCheckPodSecurity, severity: severityLow, count: 1},
+				{check: scorecardCheckSecretAge, severity: severityLow, count: 1},
+			},
+		},
+		{
+			name:          "no findings",
+			namespace:     "empty",
+			expectedScore: 100,
+			expectedGrade: "A",
+			expected:      []finding{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newSecurityScorecardHandler(kubeClient, dynamicClient, log.NopLogger())
+			handler.nowFn = func() time.Time { return now }
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/"+tc.namespace+"/security-scorecard", nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": tc.namespace})
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var resp securityScorecardResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+			got := []finding{}
+			for _, f := range resp.Findings {
+				got = append(got, finding{check: f.Check, severity: f.Severity, count: f.Count})
+				assert.Len(t, f.Details, f.Count)
+			}
+
+			assert.Equal(t, tc.expected, got)
+			assert.Equal(t, tc.expectedScore, resp.Score)
+			assert.Equal(t, tc.expectedGrade, resp.Grade)
+		})
+	}
+}
+
+func Test_securityGrade(t *testing.T) {
+	tests := []struct {
+		score    int
+		expected string
+	}{
+		{score: 100, expected: "A"},
+		{score: 90, expected: "A"},
+		{score: 85, expected: "B"},
+		{score: 70, expected: "C"},
+		{score: 60, expected: "D"},
+		{score: 0, expected: "F"},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, securityGrade(tc.score), "score %d", tc.score)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+type serviceAccountSecret struct {
+	Name              string            `json:"name"`
+	Type              corev1.SecretType `json:"type,omitempty"`
+	CreationTimestamp *metav1.Time      `json:"creationTimestamp,omitempty"`
+	Missing           bool              `json:"missing"`
+}
+
+type serviceAccountStatus struct {
+	Name                    string                 `json:"name"`
+	ImagePullSecrets        []serviceAccountSecret `json:"imagePullSecrets"`
+	Secrets                 []serviceAccountSecret `json:"secrets"`
+	AutomountedToken        bool                   `json:"automountedToken"`
+	MissingImagePullSecrets bool                   `json:"missingImagePullSecrets"`
+}
+
+type serviceAccountsResponse struct {
+	RequireImagePullSecrets bool                   `json:"requireImagePullSecrets"`
+	ServiceAccounts         []serviceAccountStatus `json:"serviceAccounts"`
+}
+
+type serviceAccountsHandler struct {
+	kubeClient              kubernetes.Interface
+	requireImagePullSecrets bool
+	logger                  log.Logger
+}
+
+var _ http.Handler = (*serviceAccountsHandler)(nil)
+
+func newServiceAccountsHandler(kubeClient kubernetes.Interface, requireImagePullSecrets bool, logger log.Logger) *serviceAccountsHandler {
+	return &serviceAccountsHandler{
+		kubeClient:              kubeClient,
+		requireImagePullSecrets: requireImagePullSecrets,
+		logger:                  logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the service accounts in a
+// namespace with the secrets they reference. When image pull secrets are
+// required, service accounts without any are flagged.
+func (h *serviceAccountsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	serviceAccounts, err := h.kubeClient.CoreV1().ServiceAccounts(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	secrets, err := h.kubeClient.CoreV1().Secrets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	secretsByName := make(map[string]*corev1.Secret)
+	for i := range secrets.Items {
+		secretsByName[secrets.Items[i].Name] = &secrets.Items[i]
+	}
+
+	resp := serviceAccountsResponse{
+		RequireImagePullSecrets: h.requireImagePullSecrets,
+		ServiceAccounts:         []serviceAccountStatus{},
+	}
+
+	for _, serviceAccount := range serviceAccounts.Items {
+		status := serviceAccountStatus{
+			Name:             serviceAccount.Name,
+			ImagePullSecrets: []serviceAccountSecret{},
+			Secrets:          []serviceAccountSecret{},
+		}
+
+		for _, ref := range serviceAccount.ImagePullSecrets {
+			status.ImagePullSecrets = append(status.ImagePullSecrets, linkedSecret(ref.Name, secretsByName))
+		}
+
+		hasToken := false
+		for _, ref := range serviceAccount.Secrets {
+			secret := linkedSecret(ref.Name, secretsByName)
+			if secret.Type == corev1.SecretTypeServiceAccountToken {
+				hasToken = true
+			}
+			status.Secrets = append(status.Secrets, secret)
+		}
+
+		// Tokens are mounted unless the service account opts out.
+		automount := serviceAccount.AutomountServiceAccountToken == nil || *serviceAccount.AutomountServiceAccountToken
+		status.AutomountedToken = automount && hasToken
+
+		status.MissingImagePullSecrets = h.requireImagePullSecrets && len(serviceAccount.ImagePullSecrets) == 0
+
+		resp.ServiceAccounts = append(resp.ServiceAccounts, status)
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func linkedSecret(name string, secrets map[string]*corev1.Secret) serviceAccountSecret {
+	secret, ok := secrets[name]
+	if !ok {
+		return serviceAccountSecret{Name: name, Missing: true}
+	}
+
+	return serviceAccountSecret{
+		Name:              name,
+		Type:              secret.Type,
+		CreationTimestamp: &secret.CreationTimestamp,
+	}
+}
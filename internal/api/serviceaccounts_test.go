@@ -0,0 +1,94 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_serviceAccountsHandler(t *testing.T) {
+	noAutomount := false
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.ServiceAccount{
+			ObjectMeta:       metav1.ObjectMeta{Name: "default", Namespace: "default"},
+			Secrets:          []corev1.ObjectReference{{Name: "default-token"}},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+		},
+		&corev1.ServiceAccount{
+			ObjectMeta:                   metav1.ObjectMeta{Name: "builder", Namespace: "default"},
+			Secrets:                      []corev1.ObjectReference{{Name: "builder-token"}, {Name: "deleted"}},
+			AutomountServiceAccountToken: &noAutomount,
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "default-token", Namespace: "default"},
+			Type:       corev1.SecretTypeServiceAccountToken,
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "builder-token", Namespace: "default"},
+			Type:       corev1.SecretTypeServiceAccountToken,
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "default"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+		},
+	)
+
+	tests := []struct {
+		name    string
+		require bool
+	}{
+		{name: "image pull secrets optional"},
+		{name: "image pull secrets required", require: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newServiceAccountsHandler(kubeClient, tc.require, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/serviceaccounts/default", nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var got serviceAccountsResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tc.require, got.RequireImagePullSecrets)
+
+			byName := make(map[string]serviceAccountStatus)
+			for _, serviceAccount := range got.ServiceAccounts {
+				byName[serviceAccount.Name] = serviceAccount
+			}
+
+			defaultAccount := byName["default"]
+			assert.True(t, defaultAccount.AutomountedToken)
+			assert.False(t, defaultAccount.MissingImagePullSecrets)
+			require.Len(t, defaultAccount.ImagePullSecrets, 1)
+			assert.Equal(t, corev1.SecretTypeDockerConfigJson, defaultAccount.ImagePullSecrets[0].Type)
+
+			builder := byName["builder"]
+			assert.False(t, builder.AutomountedToken)
+			assert.Equal(t, tc.require, builder.MissingImagePullSecrets)
+			require.Len(t, builder.Secrets, 2)
+			assert.False(t, builder.Secrets[0].Missing)
+			assert.True(t, builder.Secrets[1].Missing)
+		})
+	}
+}
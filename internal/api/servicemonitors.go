@@ -0,0 +1,268 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+var serviceMonitorGVR = schema.GroupVersionResource{
+	Group:    "monitoring.coreos.com",
+	Version:  "v1",
+	Resource: "servicemonitors",
+}
+
+// serviceMonitorEndpoint is a port of the selected services which a
+// ServiceMonitor scrapes.
+type serviceMonitorEndpoint struct {
+	Port       string              `json:"port"`
+	TargetPort *intstr.IntOrString `json:"targetPort"`
+	Interval   string              `json:"interval"`
+}
+
+// serviceMonitorSpec is the subset of a ServiceMonitor's spec used to find
+// its targets.
+type serviceMonitorSpec struct {
+	Selector          metav1.LabelSelector `json:"selector"`
+	NamespaceSelector struct {
+		Any        bool     `json:"any"`
+		MatchNames []string `json:"matchNames"`
+	} `json:"namespaceSelector"`
+	Endpoints []serviceMonitorEndpoint `json:"endpoints"`
+}
+
+type serviceMonitorStatus struct {
+	ServiceMonitor  string `json:"serviceMonitor"`
+	MatchedServices int    `json:"matchedServices"`
+	// ReadyEndpoints is the number of targets Prometheus scrapes: the ready
+	// addresses on each of the ServiceMonitor's ports.
+	ReadyEndpoints int `json:"readyEndpoints"`
+	// ScrapeInterval is the interval of the first endpoint. It is empty when
+	// Prometheus' global interval is used.
+	ScrapeInterval   string `json:"scrapeInterval,omitempty"`
+	MissingEndpoints bool   `json:"missingEndpoints"`
+	// ServicesWithoutEndpoints are the matched services with no ready
+	// address on one of the ServiceMonitor's ports.
+	ServicesWithoutEndpoints []string `json:"servicesWithoutEndpoints"`
+	Error                    string   `json:"error,omitempty"`
+}
+
+type serviceMonitorsResponse struct {
+	PrometheusOperatorNotInstalled bool                   `json:"prometheusOperatorNotInstalled"`
+	ServiceMonitors                []serviceMonitorStatus `json:"serviceMonitors"`
+}
+
+// monitoredNamespace is the services and endpoints in a namespace a
+// ServiceMonitor selects from.
+type monitoredNamespace struct {
+	services  []corev1.Service
+	endpoints map[string]*corev1.Endpoints
+}
+
+type serviceMonitorHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*serviceMonitorHandler)(nil)
+
+func newServiceMonitorHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *serviceMonitorHandler {
+	return &serviceMonitorHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and checks the scrape targets of the
+// ServiceMonitors in a namespace. Each ServiceMonitor's selector is resolved
+// to services in its own namespace, the namespaces it names, or every
+// namespace when it selects any. A ServiceMonitor is missing endpoints when
+// it matches no services or when a matched service has no ready address on
+// one of its ports, since Prometheus drops those targets without an error.
+func (h *serviceMonitorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	serviceMonitors, installed, err := listOptionalResource(h.dynamicClient, serviceMonitorGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := serviceMonitorsResponse{
+		PrometheusOperatorNotInstalled: !installed,
+		ServiceMonitors:                []serviceMonitorStatus{},
+	}
+
+	namespaces := make(map[string]*monitoredNamespace)
+
+	for _, serviceMonitor := range serviceMonitors.Items {
+		status, err := h.serviceMonitorStatus(serviceMonitor, namespaces)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+		resp.ServiceMonitors = append(resp.ServiceMonitors, status)
+	}
+
+	sort.Slice(resp.ServiceMonitors, func(i, j int) bool {
+		return resp.ServiceMonitors[i].ServiceMonitor < resp.ServiceMonitors[j].ServiceMonitor
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// serviceMonitorStatus resolves a ServiceMonitor's targets. Namespaces are
+// listed once and kept in namespaces for the other ServiceMonitors.
+func (h *serviceMonitorHandler) serviceMonitorStatus(serviceMonitor unstructured.Unstructured, namespaces map[string]*monitoredNamespace) (serviceMonitorStatus, error) {
+	status := serviceMonitorStatus{
+		ServiceMonitor:           serviceMonitor.GetName(),
+		ServicesWithoutEndpoints: []string{},
+	}
+
+	object, _, _ := unstructured.NestedMap(serviceMonitor.Object, "spec")
+
+	var spec serviceMonitorSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object, &spec); err != nil {
+		status.Error = err.Error()
+		return status, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&spec.Selector)
+	if err != nil {
+		status.Error = err.Error()
+		return status, nil
+	}
+
+	if len(spec.Endpoints) > 0 {
+		status.ScrapeInterval = spec.Endpoints[0].Interval
+	}
+
+	selected := []string{serviceMonitor.GetNamespace()}
+	switch {
+	case spec.NamespaceSelector.Any:
+		selected = []string{metav1.NamespaceAll}
+	case len(spec.NamespaceSelector.MatchNames) > 0:
+		selected = spec.NamespaceSelector.MatchNames
+	}
+
+	for _, name := range selected {
+		monitored, err := h.monitoredNamespace(name, namespaces)
+		if err != nil {
+			return serviceMonitorStatus{}, err
+		}
+
+		for i := range monitored.services {
+			service := &monitored.services[i]
+			if !selector.Matches(labels.Set(service.Labels)) {
+				continue
+			}
+
+			status.MatchedServices++
+
+			missing := len(spec.Endpoints) == 0
+			for _, endpoint := range spec.Endpoints {
+				ready := readyEndpointAddresses(service, monitored.endpoints[service.Namespace+"/"+service.Name], endpoint)
+				if ready == 0 {
+					missing = true
+				}
+				status.ReadyEndpoints += ready
+			}
+
+			if missing {
+				status.ServicesWithoutEndpoints = append(status.ServicesWithoutEndpoints, service.Name)
+			}
+		}
+	}
+
+	sort.Strings(status.ServicesWithoutEndpoints)
+	status.MissingEndpoints = status.MatchedServices == 0 || len(status.ServicesWithoutEndpoints) > 0
+
+	return status, nil
+}
+
+func (h *serviceMonitorHandler) monitoredNamespace(namespace string, namespaces map[string]*monitoredNamespace) (*monitoredNamespace, error) {
+	if monitored, ok := namespaces[namespace]; ok {
+		return monitored, nil
+	}
+
+	services, err := h.kubeClient.CoreV1().Services(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list services")
+	}
+
+	endpoints, err := h.kubeClient.CoreV1().Endpoints(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list endpoints")
+	}
+
+	monitored := &monitoredNamespace{
+		services:  services.Items,
+		endpoints: make(map[string]*corev1.Endpoints),
+	}
+	for i := range endpoints.Items {
+		e := &endpoints.Items[i]
+		monitored.endpoints[e.Namespace+"/"+e.Name] = e
+	}
+
+	namespaces[namespace] = monitored
+	return monitored, nil
+}
+
+// readyEndpointAddresses counts a service's ready addresses on a
+// ServiceMonitor endpoint's port. The port is the name of a service port, or
+// else a target port given by number or by the name the service targets.
+func readyEndpointAddresses(service *corev1.Service, endpoints *corev1.Endpoints, endpoint serviceMonitorEndpoint) int {
+	if endpoints == nil {
+		return 0
+	}
+
+	matches := func(port corev1.EndpointPort) bool {
+		switch {
+		case endpoint.Port != "":
+			return port.Name == endpoint.Port
+		case endpoint.TargetPort == nil:
+			return false
+		case endpoint.TargetPort.Type == intstr.Int:
+			return port.Port == endpoint.TargetPort.IntVal
+		default:
+			for _, servicePort := range service.Spec.Ports {
+				if servicePort.TargetPort.Type == intstr.String && servicePort.TargetPort.StrVal == endpoint.TargetPort.StrVal {
+					return port.Name == servicePort.Name
+				}
+			}
+			return false
+		}
+	}
+
+	count := 0
+	for _, subset := range endpoints.Subsets {
+		for _, port := range subset.Ports {
+			if matches(port) {
+				count += len(subset.Addresses)
+				break
+			}
+		}
+	}
+
+	return count
+}
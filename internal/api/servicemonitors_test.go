@@ -0,0 +1,172 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_serviceMonitorHandler(t *testing.T) {
+	serviceMonitor := func(name string, spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "monitoring.coreos.com/v1",
+			"kind":       "ServiceMonitor",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"spec":       spec,
+		}}
+	}
+
+	service := func(namespace, name string, labels map[string]string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{
+					{Name: "web", Port: 80, TargetPort: intstr.FromString("http")},
+					{Name: "metrics", Port: 9090, TargetPort: intstr.FromInt(9090)},
+				},
+			},
+		}
+	}
+
+	endpoints := func(namespace, name string, ready, notReady int, ports ...corev1.EndpointPort) *corev1.Endpoints {
+		subset := corev1.EndpointSubset{Ports: ports}
+		for i := 0; i < ready; i++ {
+			subset.Addresses = append(subset.Addresses, corev1.EndpointAddress{IP: "10.0.0.1"})
+		}
+		for i := 0; i < notReady; i++ {
+			subset.NotReadyAddresses = append(subset.NotReadyAddresses, corev1.EndpointAddress{IP: "10.0.1.1"})
+		}
+		return &corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Subsets:    []corev1.EndpointSubset{subset},
+		}
+	}
+
+	webPort := corev1.EndpointPort{Name: "web", Port: 8080}
+	metricsPort := corev1.EndpointPort{Name: "metrics", Port: 9090}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		service("default", "api", map[string]string{"app": "api"}),
+		endpoints("default", "api", 2, 1, webPort, metricsPort),
+		service("default", "api-canary", map[string]string{"app": "api"}),
+		endpoints("default", "api-canary", 0, 1, webPort, metricsPort),
+		service("default", "frontend", map[string]string{"app": "frontend"}),
+		endpoints("default", "frontend", 3, 0, webPort),
+		service("monitoring", "exporter", map[string]string{"app": "exporter"}),
+		endpoints("monitoring", "exporter", 1, 0, metricsPort),
+	)
+
+	t.Run("service monitors", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+			serviceMonitor("api", map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "api"}},
+				"endpoints": []interface{}{
+					map[string]interface{}{"port": "metrics", "interval": "15s"},
+				},
+			}),
+			serviceMonitor("frontend", map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "frontend"}},
+				"endpoints": []interface{}{
+					map[string]interface{}{"targetPort": "http"},
+					map[string]interface{}{"targetPort": int64(9090)},
+				},
+			}),
+			serviceMonitor("exporter", map[string]interface{}{
+				"selector":          map[string]interface{}{"matchLabels": map[string]interface{}{"app": "exporter"}},
+				"namespaceSelector": map[string]interface{}{"matchNames": []interface{}{"monitoring"}},
+				"endpoints": []interface{}{
+					map[string]interface{}{"port": "metrics", "interval": "1m"},
+				},
+			}),
+			serviceMonitor("typo", map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "apy"}},
+				"endpoints": []interface{}{
+					map[string]interface{}{"port": "metrics"},
+				},
+			}),
+		)
+
+		handler := newServiceMonitorHandler(kubeClient, dynamicClient, log.NopLogger())
+		got := serveServiceMonitors(t, handler)
+
+		expected := serviceMonitorsResponse{
+			ServiceMonitors: []serviceMonitorStatus{
+				{
+					ServiceMonitor:           "api",
+					MatchedServices:          2,
+					ReadyEndpoints:           2,
+					ScrapeInterval:           "15s",
+					MissingEndpoints:         true,
+					ServicesWithoutEndpoints: []string{"api-canary"},
+				},
+				{
+					ServiceMonitor:           "exporter",
+					MatchedServices:          1,
+					ReadyEndpoints:           1,
+					ScrapeInterval:           "1m",
+					ServicesWithoutEndpoints: []string{},
+				},
+				{
+					ServiceMonitor:           "frontend",
+					MatchedServices:          1,
+					ReadyEndpoints:           3,
+					MissingEndpoints:         true,
+					ServicesWithoutEndpoints: []string{"frontend"},
+				},
+				{
+					ServiceMonitor:           "typo",
+					MissingEndpoints:         true,
+					ServicesWithoutEndpoints: []string{},
+				},
+			},
+		}
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("prometheus operator not installed", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		dynamicClient.PrependReactor("list", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+		})
+
+		got := serveServiceMonitors(t, newServiceMonitorHandler(kubeClient, dynamicClient, log.NopLogger()))
+
+		assert.True(t, got.PrometheusOperatorNotInstalled)
+		assert.Empty(t, got.ServiceMonitors)
+	})
+}
+
+func serveServiceMonitors(t *testing.T, handler *serviceMonitorHandler) serviceMonitorsResponse {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/servicemonitoring", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var got serviceMonitorsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+
+	return got
+}
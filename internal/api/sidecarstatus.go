@@ -0,0 +1,265 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+	"github.com/vmware/octant/internal/octant"
+)
+
+const (
+	// defaultContainerAnnotation names a pod's primary container.
+	defaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+	sidecarContainerTypeInit    = "init"
+	sidecarContainerTypeSidecar = "sidecar"
+
+	crashLoopBackOffReason = "CrashLoopBackOff"
+
+	sidecarStatusEventType octant.EventType = "sidecarStatus"
+)
+
+// knownSidecarContainers are container names injected by meshes and agents.
+// They are never chosen as a pod's primary container.
+var knownSidecarContainers = []string{
+	"istio-proxy",
+	"linkerd-proxy",
+	"envoy",
+	"vault-agent",
+	"cloud-sql-proxy",
+	"cloudsql-proxy",
+	"fluent-bit",
+	"fluentd",
+}
+
+type sidecarContainerStatus struct {
+	Name                   string `json:"name"`
+	Type                   string `json:"type"`
+	Ready                  bool   `json:"ready"`
+	RestartCount           int32  `json:"restartCount"`
+	State                  string `json:"state"`
+	Reason                 string `json:"reason,omitempty"`
+	LastTerminatedReason   string `json:"lastTerminatedReason,omitempty"`
+	LastTerminatedExitCode *int32 `json:"lastTerminatedExitCode,omitempty"`
+	CrashLoopBackOff       bool   `json:"crashLoopBackOff"`
+}
+
+type sidecarStatusResponse struct {
+	Pod          string                   `json:"pod"`
+	Primary      string                   `json:"primary"`
+	Containers   []sidecarContainerStatus `json:"containers"`
+	CrashLooping []string                 `json:"crashLooping"`
+}
+
+type sidecarStatusHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*sidecarStatusHandler)(nil)
+
+func newSidecarStatusHandler(kubeClient kubernetes.Interface, logger log.Logger) *sidecarStatusHandler {
+	return &sidecarStatusHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the status of a pod's init
+// containers and sidecars. The primary container is the one named by the
+// `kubectl.kubernetes.io/default-container` annotation, or else the first
+// container which is not a well known sidecar; every other container is a
+// sidecar. With `watch=true` the status is streamed as server sent events
+// each time the pod changes, until the pod is deleted.
+func (h *sidecarStatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace, name := vars["namespace"], vars["pod"]
+
+	pod, err := h.kubeClient.CoreV1().Pods(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		RespondWithError(w, statusForKubeError(err), err.Error(), h.logger)
+		return
+	}
+
+	if r.URL.Query().Get("watch") != "true" {
+		resp := sidecarStatus(pod)
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	watcher, err := h.kubeClient.CoreV1().Pods(namespace).Watch(metav1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", name).String(),
+		ResourceVersion: pod.ResourceVersion,
+	})
+	if err != nil {
+		RespondWithError(w, statusForKubeError(err), err.Error(), h.logger)
+		return
+	}
+	defer watcher.Stop()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	ch := make(chan octant.Event)
+	go h.watchSidecarStatus(ctx, cancel, pod, watcher, ch)
+
+	streamer := &eventSourceStreamer{w: w}
+	streamer.Stream(ctx, ch)
+}
+
+// watchSidecarStatus sends the pod's status, then its status after each
+// change. It cancels the stream once the pod is deleted or the watch ends.
+func (h *sidecarStatusHandler) watchSidecarStatus(ctx context.Context, cancel context.CancelFunc, pod *corev1.Pod, watcher watch.Interface, ch chan<- octant.Event) {
+	defer cancel()
+
+	send := func(pod *corev1.Pod) bool {
+		data, err := json.Marshal(sidecarStatus(pod))
+		if err != nil {
+			h.logger.WithErr(err).Errorf("marshal sidecar status")
+			return false
+		}
+
+		select {
+		case ch <- octant.Event{Type: sidecarStatusEventType, Data: data}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if !send(pod) {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+
+			updated, isPod := e.Object.(*corev1.Pod)
+			if !isPod || updated.Name != pod.Name {
+				continue
+			}
+
+			if !send(updated) || e.Type == watch.Deleted {
+				return
+			}
+		}
+	}
+}
+
+// sidecarStatus returns the status of a pod's containers other than its
+// primary container.
+func sidecarStatus(pod *corev1.Pod) sidecarStatusResponse {
+	primary := primaryContainer(pod)
+
+	resp := sidecarStatusResponse{
+		Pod:          pod.Name,
+		Primary:      primary,
+		Containers:   []sidecarContainerStatus{},
+		CrashLooping: []string{},
+	}
+
+	add := func(containerType string, containers []corev1.Container, statuses []corev1.ContainerStatus) {
+		byName := make(map[string]corev1.ContainerStatus)
+		for _, status := range statuses {
+			byName[status.Name] = status
+		}
+
+		for _, container := range containers {
+			if containerType == sidecarContainerTypeSidecar && container.Name == primary {
+				continue
+			}
+
+			status := sidecarContainerState(container.Name, containerType, byName[container.Name])
+			if status.CrashLoopBackOff {
+				resp.CrashLooping = append(resp.CrashLooping, container.Name)
+			}
+			resp.Containers = append(resp.Containers, status)
+		}
+	}
+
+	add(sidecarContainerTypeInit, pod.Spec.InitContainers, pod.Status.InitContainerStatuses)
+	add(sidecarContainerTypeSidecar, pod.Spec.Containers, pod.Status.ContainerStatuses)
+
+	return resp
+}
+
+func primaryContainer(pod *corev1.Pod) string {
+	if name := pod.Annotations[defaultContainerAnnotation]; name != "" {
+		for _, container := range pod.Spec.Containers {
+			if container.Name == name {
+				return name
+			}
+		}
+	}
+
+	for _, container := range pod.Spec.Containers {
+		if !isKnownSidecar(container.Name) {
+			return container.Name
+		}
+	}
+
+	if len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Name
+	}
+
+	return ""
+}
+
+func isKnownSidecar(name string) bool {
+	if strings.HasSuffix(name, "-sidecar") {
+		return true
+	}
+	return containsString(knownSidecarContainers, name)
+}
+
+func sidecarContainerState(name, containerType string, status corev1.ContainerStatus) sidecarContainerStatus {
+	s := sidecarContainerStatus{
+		Name:         name,
+		Type:         containerType,
+		Ready:        status.Ready,
+		RestartCount: status.RestartCount,
+		State:        "unknown",
+	}
+
+	switch {
+	case status.State.Running != nil:
+		s.State = "running"
+	case status.State.Waiting != nil:
+		s.State = "waiting"
+		s.Reason = status.State.Waiting.Reason
+	case status.State.Terminated != nil:
+		s.State = "terminated"
+		s.Reason = status.State.Terminated.Reason
+	}
+
+	if terminated := status.LastTerminationState.Terminated; terminated != nil {
+		s.LastTerminatedReason = terminated.Reason
+		exitCode := terminated.ExitCode
+		s.LastTerminatedExitCode = &exitCode
+	}
+
+	s.CrashLoopBackOff = s.Reason == crashLoopBackOffReason
+
+	return s
+}
@@ -0,0 +1,159 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_sidecarStatusHandler(t *testing.T) {
+	exitCode := int32(1)
+
+	newPod := func() *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "migrate"}},
+				Containers:     []corev1.Container{{Name: "istio-proxy"}, {Name: "app"}, {Name: "log-sidecar"}},
+			},
+			Status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{
+					{Name: "migrate", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}}},
+				},
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "istio-proxy", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+					{Name: "app", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+					{
+						Name:                 "log-sidecar",
+						RestartCount:         7,
+						State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: crashLoopBackOffReason}},
+						LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: exitCode}},
+					},
+				},
+			},
+		}
+	}
+
+	expected := sidecarStatusResponse{
+		Pod:     "web",
+		Primary: "app",
+		Containers: []sidecarContainerStatus{
+			{Name: "migrate", Type: sidecarContainerTypeInit, State: "terminated", Reason: "Completed"},
+			{Name: "istio-proxy", Type: sidecarContainerTypeSidecar, Ready: true, State: "running"},
+			{
+				Name: "log-sidecar", Type: sidecarContainerTypeSidecar, RestartCount: 7, State: "waiting",
+				Reason: crashLoopBackOffReason, LastTerminatedReason: "Error", LastTerminatedExitCode: &exitCode,
+				CrashLoopBackOff: true,
+			},
+		},
+		CrashLooping: []string{"log-sidecar"},
+	}
+
+	t.Run("status", func(t *testing.T) {
+		handler := newSidecarStatusHandler(kubefake.NewSimpleClientset(newPod()), log.NopLogger())
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/pods/default/web/sidecarstatus", nil)
+		req = mux.SetURLVars(req, map[string]string{"namespace": "default", "pod": "web"})
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var got sidecarStatusResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("annotated primary container", func(t *testing.T) {
+		pod := newPod()
+		pod.Annotations = map[string]string{defaultContainerAnnotation: "log-sidecar"}
+
+		got := sidecarStatus(pod)
+		assert.Equal(t, "log-sidecar", got.Primary)
+		assert.Empty(t, got.CrashLooping)
+	})
+
+	t.Run("missing pod", func(t *testing.T) {
+		handler := newSidecarStatusHandler(kubefake.NewSimpleClientset(), log.NopLogger())
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/pods/default/web/sidecarstatus", nil)
+		req = mux.SetURLVars(req, map[string]string{"namespace": "default", "pod": "web"})
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		kubeClient := kubefake.NewSimpleClientset(newPod())
+
+		router := mux.NewRouter()
+		router.Handle("/pods/{namespace}/{pod}/sidecarstatus", newSidecarStatusHandler(kubeClient, log.NopLogger()))
+
+		server := httptest.NewServer(router)
+		defer server.Close()
+
+		res, err := http.Get(server.URL + "/pods/default/web/sidecarstatus?watch=true")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+		reader := bufio.NewReader(res.Body)
+		next := func() sidecarStatusResponse {
+			var data string
+			for {
+				line, err := reader.ReadString('\n')
+				require.NoError(t, err)
+				line = strings.TrimSpace(line)
+				if line == "" && data != "" {
+					break
+				}
+				if strings.HasPrefix(line, "event: ") {
+					assert.Equal(t, string(sidecarStatusEventType), strings.TrimPrefix(line, "event: "))
+				}
+				if strings.HasPrefix(line, "data: ") {
+					data = strings.TrimPrefix(line, "data: ")
+				}
+			}
+
+			var got sidecarStatusResponse
+			require.NoError(t, json.Unmarshal([]byte(data), &got))
+			return got
+		}
+
+		assert.Equal(t, expected, next())
+
+		recovered := newPod()
+		recovered.Status.ContainerStatuses[2].State = corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+		_, err = kubeClient.CoreV1().Pods("default").Update(recovered)
+		require.NoError(t, err)
+
+		got := next()
+		assert.Equal(t, "running", got.Containers[2].State)
+		assert.Empty(t, got.CrashLooping)
+
+		require.NoError(t, kubeClient.CoreV1().Pods("default").Delete("web", &metav1.DeleteOptions{}))
+		next()
+
+		_, err = reader.ReadString('\n')
+		assert.Error(t, err, "stream ends once the pod is deleted")
+	})
+}
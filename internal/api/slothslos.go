@@ -0,0 +1,313 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// slothIDLabel is the label Sloth puts on its recording rules and alerts
+	// to identify an SLO.
+	slothIDLabel = "sloth_id"
+
+	slothErrorRatio1hMetric         = "slo:sli_error:ratio_rate1h"
+	slothErrorRatio6hMetric         = "slo:sli_error:ratio_rate6h"
+	slothErrorBudgetRemainingMetric = "slo:period_error_budget_remaining:ratio"
+)
+
+var prometheusServiceLevelGVR = schema.GroupVersionResource{
+	Group:    "sloth.slok.dev",
+	Version:  "v1",
+	Resource: "prometheusservicelevels",
+}
+
+// prometheusSample is an instant vector sample from the Prometheus query API.
+// The value is a pair of a timestamp and a number formatted as a string.
+type prometheusSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  [2]interface{}    `json:"value"`
+}
+
+// prometheusQueryResponse is the subset of a Prometheus query API response
+// used by octant. The result's format depends on its type.
+type prometheusQueryResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// samples returns the samples of a vector result.
+func (r prometheusQueryResponse) samples() ([]prometheusSample, error) {
+	if r.Data.ResultType != "vector" {
+		return nil, errors.Errorf("prometheus returned a %s, not a vector", r.Data.ResultType)
+	}
+
+	var samples []prometheusSample
+	if err := json.Unmarshal(r.Data.Result, &samples); err != nil {
+		return nil, errors.Wrap(err, "decode prometheus vector")
+	}
+
+	return samples, nil
+}
+
+// queryPrometheus runs an instant query with the Prometheus query API.
+// Queries Prometheus rejects are returned with an error status rather than
+// an error.
+func queryPrometheus(httpClient *http.Client, prometheusURL, promQL string) (prometheusQueryResponse, error) {
+	query := url.Values{}
+	query.Set("query", promQL)
+
+	res, err := httpClient.Get(fmt.Sprintf("%s/api/v1/query?%s", prometheusURL, query.Encode()))
+	if err != nil {
+		return prometheusQueryResponse{}, errors.Wrap(err, "query prometheus")
+	}
+	defer res.Body.Close()
+
+	var found prometheusQueryResponse
+	if err := json.NewDecoder(res.Body).Decode(&found); err != nil {
+		return prometheusQueryResponse{}, errors.Wrapf(err, "decode prometheus response (%s)", res.Status)
+	}
+
+	return found, nil
+}
+
+type slothSLOStatus struct {
+	// Name is the PrometheusServiceLevel the SLO is defined in.
+	Name    string `json:"name"`
+	SLOName string `json:"sloName"`
+	// Objective is the target percentage of good events.
+	Objective  float64  `json:"objective"`
+	BurnRate1h *float64 `json:"burnRate1h"`
+	BurnRate6h *float64 `json:"burnRate6h"`
+	// ErrorBudgetRemaining is the ratio of the SLO period's error budget
+	// left.
+	ErrorBudgetRemaining *float64 `json:"errorBudgetRemaining"`
+	AlertFiring          bool     `json:"alertFiring"`
+
+	id string
+}
+
+type slothSLOResponse struct {
+	SlothNotInstalled       bool             `json:"slothNotInstalled"`
+	PrometheusNotConfigured bool             `json:"prometheusNotConfigured"`
+	SLOs                    []slothSLOStatus `json:"slos"`
+}
+
+type slothSLOHandler struct {
+	dynamicClient dynamic.Interface
+	prometheusURL string
+	httpClient    *http.Client
+	logger        log.Logger
+}
+
+var _ http.Handler = (*slothSLOHandler)(nil)
+
+func newSlothSLOHandler(dynamicClient dynamic.Interface, prometheusURL string, logger log.Logger) *slothSLOHandler {
+	return &slothSLOHandler{
+		dynamicClient: dynamicClient,
+		prometheusURL: strings.TrimSuffix(prometheusURL, "/"),
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the status of the SLOs
+// defined by Sloth PrometheusServiceLevels in a namespace. Burn rates and the
+// remaining error budget are read from the recording rules Sloth generates,
+// using the Prometheus or Thanos query API. A burn rate is the error ratio
+// over the window divided by the SLO's error budget, so a rate of 1 spends
+// the budget exactly over the SLO period. An SLO's alert is firing when
+// Prometheus has a firing alert with its `sloth_id`. Metrics without data
+// are null.
+func (h *slothSLOHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	serviceLevels, installed, err := listOptionalResource(h.dynamicClient, prometheusServiceLevelGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := slothSLOResponse{
+		SlothNotInstalled:       !installed,
+		PrometheusNotConfigured: h.prometheusURL == "",
+		SLOs:                    []slothSLOStatus{},
+	}
+
+	for _, serviceLevel := range serviceLevels.Items {
+		resp.SLOs = append(resp.SLOs, slothSLOs(serviceLevel)...)
+	}
+
+	sort.Slice(resp.SLOs, func(i, j int) bool {
+		if resp.SLOs[i].Name != resp.SLOs[j].Name {
+			return resp.SLOs[i].Name < resp.SLOs[j].Name
+		}
+		return resp.SLOs[i].SLOName < resp.SLOs[j].SLOName
+	})
+
+	if len(resp.SLOs) == 0 || h.prometheusURL == "" {
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	if err := h.addSLOMetrics(resp.SLOs); err != nil {
+		RespondWithError(w, http.StatusBadGateway, err.Error(), h.logger)
+		return
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// slothIDSelector returns a label matcher selecting the series of the SLO
+// ids. PromQL strings treat backslashes as escapes, so the backslashes
+// regexp.QuoteMeta adds are doubled to reach the regular expression.
+func slothIDSelector(ids []string) string {
+	var patterns []string
+	for _, id := range ids {
+		patterns = append(patterns, strings.ReplaceAll(regexp.QuoteMeta(id), `\`, `\\`))
+	}
+
+	return fmt.Sprintf(`%s=~"%s"`, slothIDLabel, strings.Join(patterns, "|"))
+}
+
+// addSLOMetrics queries Prometheus for the SLOs' burn rates, remaining error
+// budgets and firing alerts. Each metric is fetched for every SLO in one
+// query.
+func (h *slothSLOHandler) addSLOMetrics(slos []slothSLOStatus) error {
+	var ids []string
+	for _, slo := range slos {
+		ids = append(ids, slo.id)
+	}
+	selector := slothIDSelector(ids)
+
+	errorRatio1h, err := h.query(fmt.Sprintf("%s{%s}", slothErrorRatio1hMetric, selector))
+	if err != nil {
+		return err
+	}
+
+	errorRatio6h, err := h.query(fmt.Sprintf("%s{%s}", slothErrorRatio6hMetric, selector))
+	if err != nil {
+		return err
+	}
+
+	budgetRemaining, err := h.query(fmt.Sprintf("%s{%s}", slothErrorBudgetRemainingMetric, selector))
+	if err != nil {
+		return err
+	}
+
+	alerts, err := h.query(fmt.Sprintf(`ALERTS{alertstate="firing",%s}`, selector))
+	if err != nil {
+		return err
+	}
+
+	for i := range slos {
+		slo := &slos[i]
+		errorBudget := 1 - slo.Objective/100
+
+		if ratio, ok := errorRatio1h[slo.id]; ok && errorBudget > 0 {
+			burnRate := ratio / errorBudget
+			slo.BurnRate1h = &burnRate
+		}
+		if ratio, ok := errorRatio6h[slo.id]; ok && errorBudget > 0 {
+			burnRate := ratio / errorBudget
+			slo.BurnRate6h = &burnRate
+		}
+		if remaining, ok := budgetRemaining[slo.id]; ok {
+			slo.ErrorBudgetRemaining = &remaining
+		}
+		_, slo.AlertFiring = alerts[slo.id]
+	}
+
+	return nil
+}
+
+// query runs an instant query and returns the value of each sample keyed by
+// its `sloth_id`. Samples which are not a number are left out.
+func (h *slothSLOHandler) query(promQL string) (map[string]float64, error) {
+	found, err := queryPrometheus(h.httpClient, h.prometheusURL, promQL)
+	if err != nil {
+		return nil, err
+	}
+
+	if found.Status != "success" {
+		return nil, errors.Errorf("prometheus returned an error: %s", found.Error)
+	}
+
+	samples, err := found.samples()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]float64)
+	for _, sample := range samples {
+		s, _ := sample.Value[1].(string)
+		value, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+
+		values[sample.Metric[slothIDLabel]] = value
+	}
+
+	return values, nil
+}
+
+// slothSLOs returns the SLOs a PrometheusServiceLevel defines. Sloth
+// identifies each SLO by its service and name.
+func slothSLOs(serviceLevel unstructured.Unstructured) []slothSLOStatus {
+	service, _, _ := unstructured.NestedString(serviceLevel.Object, "spec", "service")
+	items, _, _ := unstructured.NestedSlice(serviceLevel.Object, "spec", "slos")
+
+	var slos []slothSLOStatus
+	for _, item := range items {
+		spec, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		name, _, _ := unstructured.NestedString(spec, "name")
+
+		var objective float64
+		switch value := spec["objective"].(type) {
+		case float64:
+			objective = value
+		case int64:
+			objective = float64(value)
+		}
+
+		slos = append(slos, slothSLOStatus{
+			Name:      serviceLevel.GetName(),
+			SLOName:   name,
+			Objective: objective,
+			id:        service + "-" + name,
+		})
+	}
+
+	return slos
+}
@@ -0,0 +1,161 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_slothSLOHandler(t *testing.T) {
+	serviceLevel := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "sloth.slok.dev/v1",
+		"kind":       "PrometheusServiceLevel",
+		"metadata":   map[string]interface{}{"name": "checkout-slos", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"service": "checkout",
+			"slos": []interface{}{
+				map[string]interface{}{"name": "requests-availability", "objective": 99.9},
+				map[string]interface{}{"name": "requests-latency", "objective": int64(99)},
+			},
+		},
+	}}
+
+	var queries []string
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+
+		query := r.URL.Query().Get("query")
+		queries = append(queries, query)
+
+		sample := func(id, value string) string {
+			return fmt.Sprintf(`{"metric":{"sloth_id":%q},"value":[1569931200,%q]}`, id, value)
+		}
+
+		var samples []string
+		switch {
+		case strings.Contains(query, "broken"):
+			fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"parse error"}`)
+			return
+		case strings.HasPrefix(query, slothErrorRatio1hMetric):
+			samples = append(samples, sample("checkout-requests-availability", "0.002"), sample("checkout-requests-latency", "0.001"))
+		case strings.HasPrefix(query, slothErrorRatio6hMetric):
+			samples = append(samples, sample("checkout-requests-availability", "NaN"), sample("checkout-requests-latency", "0.005"))
+		case strings.HasPrefix(query, slothErrorBudgetRemainingMetric):
+			samples = append(samples, sample("checkout-requests-availability", "0.4"))
+		case strings.HasPrefix(query, "ALERTS"):
+			samples = append(samples, sample("checkout-requests-latency", "1"))
+		}
+
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[%s]}}`, strings.Join(samples, ","))
+	}))
+	defer prometheus.Close()
+
+	t.Run("slo status", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), serviceLevel)
+		handler := newSlothSLOHandler(dynamicClient, prometheus.URL+"/", log.NopLogger())
+
+		got := serveSlothSLOs(t, handler, http.StatusOK)
+
+		assert.False(t, got.SlothNotInstalled)
+		assert.False(t, got.PrometheusNotConfigured)
+		require.Len(t, got.SLOs, 2)
+
+		availability := got.SLOs[0]
+		assert.Equal(t, "checkout-slos", availability.Name)
+		assert.Equal(t, "requests-availability", availability.SLOName)
+		assert.Equal(t, 99.9, availability.Objective)
+		require.NotNil(t, availability.BurnRate1h)
+		assert.InDelta(t, 2, *availability.BurnRate1h, 0.0001)
+		assert.Nil(t, availability.BurnRate6h)
+		require.NotNil(t, availability.ErrorBudgetRemaining)
+		assert.Equal(t, 0.4, *availability.ErrorBudgetRemaining)
+		assert.False(t, availability.AlertFiring)
+
+		latency := got.SLOs[1]
+		assert.Equal(t, "requests-latency", latency.SLOName)
+		assert.Equal(t, float64(99), latency.Objective)
+		require.NotNil(t, latency.BurnRate1h)
+		assert.InDelta(t, 0.1, *latency.BurnRate1h, 0.0001)
+		require.NotNil(t, latency.BurnRate6h)
+		assert.InDelta(t, 0.5, *latency.BurnRate6h, 0.0001)
+		assert.Nil(t, latency.ErrorBudgetRemaining)
+		assert.True(t, latency.AlertFiring)
+
+		require.Len(t, queries, 4)
+		assert.Equal(t, `ALERTS{alertstate="firing",sloth_id=~"checkout-requests-availability|checkout-requests-latency"}`, queries[3])
+	})
+
+	t.Run("prometheus error", func(t *testing.T) {
+		broken := serviceLevel.DeepCopy()
+		broken.Object["spec"].(map[string]interface{})["service"] = "broken"
+
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), broken)
+		handler := newSlothSLOHandler(dynamicClient, prometheus.URL, log.NopLogger())
+
+		serveSlothSLOs(t, handler, http.StatusBadGateway)
+	})
+
+	t.Run("prometheus not configured", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), serviceLevel)
+		handler := newSlothSLOHandler(dynamicClient, "", log.NopLogger())
+
+		got := serveSlothSLOs(t, handler, http.StatusOK)
+
+		assert.True(t, got.PrometheusNotConfigured)
+		require.Len(t, got.SLOs, 2)
+		assert.Nil(t, got.SLOs[0].BurnRate1h)
+		assert.Nil(t, got.SLOs[0].ErrorBudgetRemaining)
+	})
+
+	t.Run("sloth not installed", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		dynamicClient.PrependReactor("list", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+		})
+
+		got := serveSlothSLOs(t, newSlothSLOHandler(dynamicClient, prometheus.URL, log.NopLogger()), http.StatusOK)
+
+		assert.True(t, got.SlothNotInstalled)
+		assert.Empty(t, got.SLOs)
+	})
+}
+
+func Test_slothIDSelector(t *testing.T) {
+	got := slothIDSelector([]string{"checkout-requests-availability", "api.v1-latency"})
+	assert.Equal(t, `sloth_id=~"checkout-requests-availability|api\\.v1-latency"`, got)
+}
+
+func serveSlothSLOs(t *testing.T, handler *slothSLOHandler, expectedCode int) slothSLOResponse {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/slothslos", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, expectedCode, w.Code)
+
+	var got slothSLOResponse
+	if expectedCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	}
+
+	return got
+}
@@ -0,0 +1,69 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/vmware/octant/internal/log"
+	"github.com/vmware/octant/internal/telemetry"
+)
+
+type telemetryStatusResponse struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path,omitempty"`
+}
+
+type telemetryStatusHandler struct {
+	telemetry telemetry.Telemetry
+	logger    log.Logger
+}
+
+var _ http.Handler = (*telemetryStatusHandler)(nil)
+
+func newTelemetryStatusHandler(t telemetry.Telemetry, logger log.Logger) *telemetryStatusHandler {
+	return &telemetryStatusHandler{
+		telemetry: t,
+		logger:    logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns whether telemetry has been opted into.
+func (h *telemetryStatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp := telemetryStatusResponse{
+		Enabled: h.telemetry.Enabled(),
+	}
+
+	if lft, ok := h.telemetry.(*telemetry.LocalFileTelemetry); ok {
+		resp.Path = lft.Path()
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// trackRequests is a middleware which records the route template and method
+// for each API request. Route templates are used instead of request paths so
+// namespace and object names are never recorded.
+func trackRequests(t telemetry.Telemetry) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if t.Enabled() {
+				if route := mux.CurrentRoute(r); route != nil {
+					if template, err := route.GetPathTemplate(); err == nil {
+						t.Track("api.request", map[string]interface{}{
+							"route":  template,
+							"method": r.Method,
+						})
+					}
+				}
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/octant/internal/log"
+	"github.com/vmware/octant/internal/telemetry"
+)
+
+type recordingTelemetry struct {
+	events []map[string]interface{}
+}
+
+func (t *recordingTelemetry) Track(event string, properties map[string]interface{}) {
+	t.events = append(t.events, properties)
+}
+
+func (t *recordingTelemetry) Enabled() bool { return true }
+
+func Test_telemetryStatusHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		telemetry telemetry.Telemetry
+		expected  telemetryStatusResponse
+	}{
+		{
+			name:      "disabled",
+			telemetry: telemetry.NopTelemetry{},
+			expected:  telemetryStatusResponse{Enabled: false},
+		},
+		{
+			name:      "enabled",
+			telemetry: &recordingTelemetry{},
+			expected:  telemetryStatusResponse{Enabled: true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newTelemetryStatusHandler(tc.telemetry, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/telemetry/status", nil)
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			var got telemetryStatusResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func Test_trackRequests(t *testing.T) {
+	rt := &recordingTelemetry{}
+
+	router := mux.NewRouter()
+	router.Use(trackRequests(rt))
+	router.HandleFunc("/pods/{namespace}/{pod}/describe", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/pods/default/secret-name/describe", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	expected := []map[string]interface{}{
+		{"route": "/pods/{namespace}/{pod}/describe", "method": http.MethodGet},
+	}
+	assert.Equal(t, expected, rt.events)
+}
@@ -0,0 +1,147 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubernetes/staging/src/k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+// stuckTerminatingAge is how long a pod terminates before it is flagged.
+const stuckTerminatingAge = 5 * time.Minute
+
+type terminatingPod struct {
+	Pod              string          `json:"pod"`
+	TerminatingSince time.Time       `json:"terminatingSince"`
+	TerminatingFor   string          `json:"terminatingFor"`
+	Finalizers       []string        `json:"finalizers"`
+	Node             string          `json:"node,omitempty"`
+	Reason           string          `json:"reason"`
+	WarningLevel     findingSeverity `json:"warningLevel"`
+}
+
+type terminatingPodsResponse struct {
+	Pods []terminatingPod `json:"pods"`
+}
+
+type terminatingPodsHandler struct {
+	kubeClient kubernetes.Interface
+	nowFn      func() time.Time
+	logger     log.Logger
+}
+
+var _ http.Handler = (*terminatingPodsHandler)(nil)
+
+func newTerminatingPodsHandler(kubeClient kubernetes.Interface, logger log.Logger) *terminatingPodsHandler {
+	return &terminatingPodsHandler{
+		kubeClient: kubeClient,
+		nowFn:      time.Now,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the pods in a namespace which
+// are being deleted but haven't finished, longest terminating first. A pod
+// started terminating when it was deleted, which is its deletion timestamp
+// less its grace period. The reason is a guess at what the pod is waiting on:
+// its finalizers, its node, or its containers stopping. Pods terminating for
+// more than five minutes are flagged high.
+func (h *terminatingPodsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	now := h.nowFn()
+	nodes := make(map[string]*corev1.Node)
+
+	resp := terminatingPodsResponse{
+		Pods: []terminatingPod{},
+	}
+
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		since := pod.DeletionTimestamp.Time
+		if pod.DeletionGracePeriodSeconds != nil {
+			since = since.Add(-time.Duration(*pod.DeletionGracePeriodSeconds) * time.Second)
+		}
+		since = since.UTC()
+
+		item := terminatingPod{
+			Pod:              pod.Name,
+			TerminatingSince: since,
+			TerminatingFor:   duration.ShortHumanDuration(now.Sub(since)),
+			Finalizers:       append([]string{}, pod.Finalizers...),
+			Node:             pod.Spec.NodeName,
+			WarningLevel:     severityLow,
+		}
+		if now.Sub(since) > stuckTerminatingAge {
+			item.WarningLevel = severityHigh
+		}
+
+		node, ok := nodes[pod.Spec.NodeName]
+		if !ok && pod.Spec.NodeName != "" {
+			node, err = h.kubeClient.CoreV1().Nodes().Get(pod.Spec.NodeName, metav1.GetOptions{})
+			switch {
+			case kerrors.IsNotFound(err):
+				node = nil
+			case err != nil:
+				RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+				return
+			}
+			nodes[pod.Spec.NodeName] = node
+		}
+
+		item.Reason = terminatingReason(pod, node, now)
+
+		resp.Pods = append(resp.Pods, item)
+	}
+
+	sort.Slice(resp.Pods, func(i, j int) bool {
+		a, b := resp.Pods[i], resp.Pods[j]
+		if !a.TerminatingSince.Equal(b.TerminatingSince) {
+			return a.TerminatingSince.Before(b.TerminatingSince)
+		}
+		return a.Pod < b.Pod
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// terminatingReason explains what a terminating pod is waiting on. The node
+// is nil if the pod was never scheduled or its node no longer exists.
+func terminatingReason(pod corev1.Pod, node *corev1.Node, now time.Time) string {
+	switch {
+	case pod.Spec.NodeName != "" && node == nil:
+		return fmt.Sprintf("node %s no longer exists", pod.Spec.NodeName)
+	case node != nil && !isNodeReady(*node):
+		return fmt.Sprintf("node %s is not ready", node.Name)
+	case len(pod.Finalizers) > 0 && now.After(pod.DeletionTimestamp.Time):
+		return fmt.Sprintf("waiting on finalizers %s", strings.Join(pod.Finalizers, ", "))
+	case now.After(pod.DeletionTimestamp.Time):
+		return "containers did not stop within the grace period"
+	default:
+		return "containers are stopping"
+	}
+}
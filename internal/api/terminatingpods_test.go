@@ -0,0 +1,135 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_terminatingPodsHandler(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	grace := int64(30)
+
+	terminating := func(name, node string, deletedAgo time.Duration, finalizers ...string) *corev1.Pod {
+		deletion := metav1.NewTime(now.Add(-deletedAgo).Add(time.Duration(grace) * time.Second))
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:                       name,
+				Namespace:                  "default",
+				DeletionTimestamp:          &deletion,
+				DeletionGracePeriodSeconds: &grace,
+				Finalizers:                 finalizers,
+			},
+			Spec:   corev1.PodSpec{NodeName: node},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	finished := terminating("finished", "node-1", time.Hour)
+	finished.Status.Phase = corev1.PodSucceeded
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			}},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionUnknown},
+			}},
+		},
+		terminating("stopping", "node-1", 10*time.Second),
+		terminating("slow", "node-1", 2*time.Minute),
+		terminating("finalized", "node-1", time.Hour, "example.com/cleanup"),
+		terminating("unreachable", "node-2", 10*time.Minute),
+		terminating("orphaned", "node-3", 20*time.Minute),
+		finished,
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	)
+
+	handler := newTerminatingPodsHandler(kubeClient, log.NopLogger())
+	handler.nowFn = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/terminatingpods", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp terminatingPodsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+	expected := []terminatingPod{
+		{
+			Pod:              "finalized",
+			TerminatingSince: now.Add(-time.Hour),
+			TerminatingFor:   "1h",
+			Finalizers:       []string{"example.com/cleanup"},
+			Node:             "node-1",
+			Reason:           "waiting on finalizers example.com/cleanup",
+			WarningLevel:     severityHigh,
+		},
+		{
+			Pod:              "orphaned",
+			TerminatingSince: now.Add(-20 * time.Minute),
+			TerminatingFor:   "20m",
+			Finalizers:       []string{},
+			Node:             "node-3",
+			Reason:           "node node-3 no longer exists",
+			WarningLevel:     severityHigh,
+		},
+		{
+			Pod:              "unreachable",
+			TerminatingSince: now.Add(-10 * time.Minute),
+			TerminatingFor:   "10m",
+			Finalizers:       []string{},
+			Node:             "node-2",
+			Reason:           "node node-2 is not ready",
+			WarningLevel:     severityHigh,
+		},
+		{
+			Pod:              "slow",
+			TerminatingSince: now.Add(-2 * time.Minute),
+			TerminatingFor:   "2m",
+			Finalizers:       []string{},
+			Node:             "node-1",
+			Reason:           "containers did not stop within the grace period",
+			WarningLevel:     severityLow,
+		},
+		{
+			Pod:              "stopping",
+			TerminatingSince: now.Add(-10 * time.Second),
+			TerminatingFor:   "10s",
+			Finalizers:       []string{},
+			Node:             "node-1",
+			Reason:           "containers are stopping",
+			WarningLevel:     severityLow,
+		},
+	}
+
+	assert.Equal(t, expected, resp.Pods)
+}
@@ -0,0 +1,317 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	topologyNodeService  = "service"
+	topologyNodeSubset   = "subset"
+	topologyNodePod      = "pod"
+	topologyNodeExternal = "external"
+)
+
+var (
+	virtualServiceGVR = schema.GroupVersionResource{
+		Group:    "networking.istio.io",
+		Version:  "v1alpha3",
+		Resource: "virtualservices",
+	}
+
+	destinationRuleGVR = schema.GroupVersionResource{
+		Group:    "networking.istio.io",
+		Version:  "v1alpha3",
+		Resource: "destinationrules",
+	}
+)
+
+// topologyNode is a node in a service graph. Names are only unique per type,
+// so nodes are identified, and edges refer to them, by type and name.
+type topologyNode struct {
+	ID     string            `json:"id"`
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels"`
+}
+
+type topologyEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Weight int64  `json:"weight"`
+}
+
+type topologyResponse struct {
+	Nodes []topologyNode `json:"nodes"`
+	Edges []topologyEdge `json:"edges"`
+}
+
+type topologyHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*topologyHandler)(nil)
+
+func newTopologyHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *topologyHandler {
+	return &topologyHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns a service graph for a namespace.
+// Services and their endpoints are always included. Istio VirtualService and
+// DestinationRule objects add routing edges when the CRDs are installed.
+func (h *topologyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	services, err := h.kubeClient.CoreV1().Services(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	endpoints, err := h.kubeClient.CoreV1().Endpoints(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	virtualServices, _, err := listOptionalResource(h.dynamicClient, virtualServiceGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	destinationRules, _, err := listOptionalResource(h.dynamicClient, destinationRuleGVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	graph := newTopologyGraph(namespace)
+	graph.addServices(services.Items)
+	graph.addEndpoints(endpoints.Items)
+	graph.addDestinationRules(destinationRules.Items)
+	graph.addVirtualServices(virtualServices.Items)
+
+	resp := graph.response()
+	serveAsJSON(w, &resp, h.logger)
+}
+
+type topologyEdgeKey struct {
+	from string
+	to   string
+}
+
+type topologyGraph struct {
+	namespace string
+	// nodes are keyed by ID.
+	nodes map[string]topologyNode
+	edges map[topologyEdgeKey]int64
+}
+
+func newTopologyGraph(namespace string) *topologyGraph {
+	return &topologyGraph{
+		namespace: namespace,
+		nodes:     make(map[string]topologyNode),
+		edges:     make(map[topologyEdgeKey]int64),
+	}
+}
+
+// addNode adds a node unless it exists and returns its ID.
+func (g *topologyGraph) addNode(name, nodeType string, labels map[string]string) string {
+	id := topologyNodeID(nodeType, name)
+	if _, ok := g.nodes[id]; ok {
+		return id
+	}
+
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	g.nodes[id] = topologyNode{ID: id, Name: name, Type: nodeType, Labels: labels}
+
+	return id
+}
+
+// addHost adds a node for a mesh host and returns its ID. Hosts which do not
+// match a service in the namespace are treated as external.
+func (g *topologyGraph) addHost(host string) string {
+	name := shortHostName(host, g.namespace)
+	if id := topologyNodeID(topologyNodeService, name); g.hasNode(id) {
+		return id
+	}
+
+	return g.addNode(name, topologyNodeExternal, nil)
+}
+
+func (g *topologyGraph) hasNode(id string) bool {
+	_, ok := g.nodes[id]
+	return ok
+}
+
+func (g *topologyGraph) addEdge(from, to string, weight int64) {
+	g.edges[topologyEdgeKey{from: from, to: to}] += weight
+}
+
+func (g *topologyGraph) addServices(services []corev1.Service) {
+	for _, service := range services {
+		g.addNode(service.Name, topologyNodeService, service.Labels)
+	}
+}
+
+func (g *topologyGraph) addEndpoints(endpoints []corev1.Endpoints) {
+	for _, e := range endpoints {
+		service := topologyNodeID(topologyNodeService, e.Name)
+		if !g.hasNode(service) {
+			continue
+		}
+
+		for _, subset := range e.Subsets {
+			for _, address := range subset.Addresses {
+				if address.TargetRef == nil || address.TargetRef.Kind != "Pod" {
+					continue
+				}
+
+				pod := g.addNode(address.TargetRef.Name, topologyNodePod, nil)
+				g.addEdge(service, pod, 1)
+			}
+		}
+	}
+}
+
+// addDestinationRules adds a node for each named subset of a host.
+func (g *topologyGraph) addDestinationRules(destinationRules []unstructured.Unstructured) {
+	for _, destinationRule := range destinationRules {
+		host, _, _ := unstructured.NestedString(destinationRule.Object, "spec", "host")
+		if host == "" {
+			continue
+		}
+		hostName := g.nodes[g.addHost(host)].Name
+
+		subsets, _, _ := unstructured.NestedSlice(destinationRule.Object, "spec", "subsets")
+		for _, item := range subsets {
+			subset, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			name, _, _ := unstructured.NestedString(subset, "name")
+			labels, _, _ := unstructured.NestedStringMap(subset, "labels")
+			g.addNode(subsetNodeName(hostName, name), topologyNodeSubset, labels)
+		}
+	}
+}
+
+// addVirtualServices adds an edge from each virtual service host to the
+// destinations of its HTTP routes.
+func (g *topologyGraph) addVirtualServices(virtualServices []unstructured.Unstructured) {
+	for _, virtualService := range virtualServices {
+		hosts, _, _ := unstructured.NestedStringSlice(virtualService.Object, "spec", "hosts")
+		routes, _, _ := unstructured.NestedSlice(virtualService.Object, "spec", "http")
+
+		for _, item := range routes {
+			route, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			destinations, _, _ := unstructured.NestedSlice(route, "route")
+			for _, item := range destinations {
+				destination, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				host, _, _ := unstructured.NestedString(destination, "destination", "host")
+				if host == "" {
+					continue
+				}
+				to := g.addHost(host)
+
+				if subset, _, _ := unstructured.NestedString(destination, "destination", "subset"); subset != "" {
+					to = g.addNode(subsetNodeName(g.nodes[to].Name, subset), topologyNodeSubset, nil)
+				}
+
+				// Istio sends all traffic to a destination without a weight
+				// when it is the only destination.
+				weight, ok, _ := unstructured.NestedInt64(destination, "weight")
+				if !ok && len(destinations) == 1 {
+					weight = 100
+				}
+
+				for _, from := range hosts {
+					g.addEdge(g.addHost(from), to, weight)
+				}
+			}
+		}
+	}
+}
+
+func (g *topologyGraph) response() topologyResponse {
+	resp := topologyResponse{
+		Nodes: []topologyNode{},
+		Edges: []topologyEdge{},
+	}
+
+	for _, node := range g.nodes {
+		resp.Nodes = append(resp.Nodes, node)
+	}
+	sort.Slice(resp.Nodes, func(i, j int) bool {
+		return resp.Nodes[i].ID < resp.Nodes[j].ID
+	})
+
+	for key, weight := range g.edges {
+		resp.Edges = append(resp.Edges, topologyEdge{From: key.from, To: key.to, Weight: weight})
+	}
+	sort.Slice(resp.Edges, func(i, j int) bool {
+		if resp.Edges[i].From != resp.Edges[j].From {
+			return resp.Edges[i].From < resp.Edges[j].From
+		}
+		return resp.Edges[i].To < resp.Edges[j].To
+	})
+
+	return resp
+}
+
+// shortHostName converts a mesh host in the given namespace, such as
+// `reviews.default.svc.cluster.local`, to its service name.
+func shortHostName(host, namespace string) string {
+	for _, suffix := range []string{".svc.cluster.local", ".svc", ""} {
+		if name := strings.TrimSuffix(host, "."+namespace+suffix); name != host {
+			return name
+		}
+	}
+
+	return host
+}
+
+// topologyNodeID identifies a node by its type and name, e.g.
+// `service/reviews`.
+func topologyNodeID(nodeType, name string) string {
+	return nodeType + "/" + name
+}
+
+func subsetNodeName(host, subset string) string {
+	return host + "/" + subset
+}
@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_topologyHandler(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "reviews", Namespace: "default", Labels: map[string]string{"app": "reviews"}},
+		},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "reviews", Namespace: "default"},
+			Subsets: []corev1.EndpointSubset{
+				{
+					Addresses: []corev1.EndpointAddress{
+						{IP: "10.0.0.1", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "reviews-v1-abcde"}},
+						{IP: "10.0.0.2", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "reviews"}},
+					},
+				},
+			},
+		},
+	)
+
+	virtualService := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1alpha3",
+		"kind":       "VirtualService",
+		"metadata":   map[string]interface{}{"name": "reviews", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"hosts": []interface{}{"reviews.default.svc.cluster.local"},
+			"http": []interface{}{
+				map[string]interface{}{
+					"route": []interface{}{
+						map[string]interface{}{
+							"destination": map[string]interface{}{"host": "reviews", "subset": "v1"},
+							"weight":      int64(75),
+						},
+						map[string]interface{}{
+							"destination": map[string]interface{}{"host": "reviews", "subset": "v2"},
+							"weight":      int64(25),
+						},
+					},
+				},
+			},
+		},
+	}}
+
+	destinationRule := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1alpha3",
+		"kind":       "DestinationRule",
+		"metadata":   map[string]interface{}{"name": "reviews", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"host": "reviews",
+			"subsets": []interface{}{
+				map[string]interface{}{"name": "v1", "labels": map[string]interface{}{"version": "v1"}},
+				map[string]interface{}{"name": "v2", "labels": map[string]interface{}{"version": "v2"}},
+			},
+		},
+	}}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), virtualService, destinationRule)
+
+	handler := newTopologyHandler(kubeClient, dynamicClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/topology/default", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got topologyResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := topologyResponse{
+		Nodes: []topologyNode{
+			{ID: "pod/reviews", Name: "reviews", Type: topologyNodePod, Labels: map[string]string{}},
+			{ID: "pod/reviews-v1-abcde", Name: "reviews-v1-abcde", Type: topologyNodePod, Labels: map[string]string{}},
+			{ID: "service/reviews", Name: "reviews", Type: topologyNodeService, Labels: map[string]string{"app": "reviews"}},
+			{ID: "subset/reviews/v1", Name: "reviews/v1", Type: topologyNodeSubset, Labels: map[string]string{"version": "v1"}},
+			{ID: "subset/reviews/v2", Name: "reviews/v2", Type: topologyNodeSubset, Labels: map[string]string{"version": "v2"}},
+		},
+		Edges: []topologyEdge{
+			{From: "service/reviews", To: "pod/reviews", Weight: 1},
+			{From: "service/reviews", To: "pod/reviews-v1-abcde", Weight: 1},
+			{From: "service/reviews", To: "subset/reviews/v1", Weight: 75},
+			{From: "service/reviews", To: "subset/reviews/v2", Weight: 25},
+		},
+	}
+	assert.Equal(t, expected, got)
+}
+
+func Test_shortHostName(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{host: "reviews", expected: "reviews"},
+		{host: "reviews.default", expected: "reviews"},
+		{host: "reviews.default.svc", expected: "reviews"},
+		{host: "reviews.default.svc.cluster.local", expected: "reviews"},
+		{host: "reviews.other.svc.cluster.local", expected: "reviews.other.svc.cluster.local"},
+		{host: "example.com", expected: "example.com"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.host, func(t *testing.T) {
+			assert.Equal(t, tc.expected, shortHostName(tc.host, "default"))
+		})
+	}
+}
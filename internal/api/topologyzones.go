@@ -0,0 +1,228 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+var (
+	deploymentGVR  = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	statefulSetGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}
+)
+
+// topologySpreadConstraint mirrors core/v1 TopologySpreadConstraint, which
+// is newer than the vendored API types.
+type topologySpreadConstraint struct {
+	MaxSkew           int32                 `json:"maxSkew"`
+	TopologyKey       string                `json:"topologyKey"`
+	WhenUnsatisfiable string                `json:"whenUnsatisfiable"`
+	LabelSelector     *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+type constraintSpread struct {
+	TopologyKey       string         `json:"topologyKey"`
+	MaxSkew           int32          `json:"maxSkew"`
+	WhenUnsatisfiable string         `json:"whenUnsatisfiable"`
+	Domains           map[string]int `json:"domains"`
+	Skew              int            `json:"skew"`
+	Exceeded          bool           `json:"exceeded"`
+}
+
+type workloadSpread struct {
+	Workload    workloadRef        `json:"workload"`
+	Constraints []constraintSpread `json:"constraints"`
+	Exceeded    bool               `json:"exceeded"`
+}
+
+type topologyZonesResponse struct {
+	Workloads []workloadSpread `json:"workloads"`
+}
+
+type topologyZonesHandler struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+var _ http.Handler = (*topologyZonesHandler)(nil)
+
+func newTopologyZonesHandler(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, logger log.Logger) *topologyZonesHandler {
+	return &topologyZonesHandler{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the actual skew of each
+// topology spread constraint on the deployments and stateful sets in the
+// namespace given by the `namespace` query parameter. Skew is computed the way
+// the scheduler does: matching pods are counted per topology domain across all
+// nodes with the topology key, including domains without any pods.
+func (h *topologyZonesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		RespondWithError(w, http.StatusBadRequest, "namespace is required", h.logger)
+		return
+	}
+
+	// Workloads are read with the dynamic client because the vendored API
+	// types predate topology spread constraints.
+	var workloads []unstructured.Unstructured
+	for _, gvr := range []schema.GroupVersionResource{deploymentGVR, statefulSetGVR} {
+		list, err := h.dynamicClient.Resource(gvr).Namespace(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+		workloads = append(workloads, list.Items...)
+	}
+
+	pods, err := h.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	nodes, err := h.kubeClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := topologyZonesResponse{
+		Workloads: []workloadSpread{},
+	}
+
+	for _, workload := range workloads {
+		constraints, err := workloadSpreadConstraints(workload)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+		if len(constraints) == 0 {
+			continue
+		}
+
+		spread := workloadSpread{
+			Workload:    workloadRef{Kind: workload.GetKind(), Name: workload.GetName()},
+			Constraints: []constraintSpread{},
+		}
+
+		for _, constraint := range constraints {
+			cs, err := computeConstraintSpread(constraint, pods.Items, nodes.Items)
+			if err != nil {
+				RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+				return
+			}
+
+			spread.Exceeded = spread.Exceeded || cs.Exceeded
+			spread.Constraints = append(spread.Constraints, cs)
+		}
+
+		resp.Workloads = append(resp.Workloads, spread)
+	}
+
+	sort.Slice(resp.Workloads, func(i, j int) bool {
+		a, b := resp.Workloads[i].Workload, resp.Workloads[j].Workload
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func workloadSpreadConstraints(workload unstructured.Unstructured) ([]topologySpreadConstraint, error) {
+	items, _, _ := unstructured.NestedSlice(workload.Object, "spec", "template", "spec", "topologySpreadConstraints")
+
+	var constraints []topologySpreadConstraint
+	for _, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		var constraint topologySpreadConstraint
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object, &constraint); err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, constraint)
+	}
+
+	return constraints, nil
+}
+
+func computeConstraintSpread(constraint topologySpreadConstraint, pods []corev1.Pod, nodes []corev1.Node) (constraintSpread, error) {
+	cs := constraintSpread{
+		TopologyKey:       constraint.TopologyKey,
+		MaxSkew:           constraint.MaxSkew,
+		WhenUnsatisfiable: constraint.WhenUnsatisfiable,
+		Domains:           make(map[string]int),
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(constraint.LabelSelector)
+	if err != nil {
+		return constraintSpread{}, err
+	}
+
+	nodeDomains := make(map[string]string)
+	for _, node := range nodes {
+		domain, ok := node.Labels[constraint.TopologyKey]
+		if !ok {
+			continue
+		}
+		nodeDomains[node.Name] = domain
+
+		// Domains without matching pods count towards the skew.
+		if _, ok := cs.Domains[domain]; !ok {
+			cs.Domains[domain] = 0
+		}
+	}
+
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.DeletionTimestamp != nil || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+
+		if domain, ok := nodeDomains[pod.Spec.NodeName]; ok {
+			cs.Domains[domain]++
+		}
+	}
+
+	if len(cs.Domains) == 0 {
+		return cs, nil
+	}
+
+	min, max := -1, 0
+	for _, count := range cs.Domains {
+		if min == -1 || count < min {
+			min = count
+		}
+		if count > max {
+			max = count
+		}
+	}
+
+	cs.Skew = max - min
+	cs.Exceeded = cs.Skew > int(constraint.MaxSkew)
+
+	return cs, nil
+}
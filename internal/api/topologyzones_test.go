@@ -0,0 +1,163 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_topologyZonesHandler(t *testing.T) {
+	newWorkload := func(kind, name string, constraints ...interface{}) *unstructured.Unstructured {
+		object := map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		}
+		if len(constraints) > 0 {
+			object["spec"] = map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{"topologySpreadConstraints": constraints},
+				},
+			}
+		}
+		return &unstructured.Unstructured{Object: object}
+	}
+
+	constraint := func(topologyKey string, maxSkew int64, app string) map[string]interface{} {
+		return map[string]interface{}{
+			"maxSkew":           maxSkew,
+			"topologyKey":       topologyKey,
+			"whenUnsatisfiable": "DoNotSchedule",
+			"labelSelector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": app},
+			},
+		}
+	}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newWorkload("Deployment", "web", constraint(zoneLabel, 1, "web"), constraint(hostnameLabel, 2, "web")),
+		newWorkload("Deployment", "plain"),
+		newWorkload("StatefulSet", "db", constraint(zoneLabel, 1, "db")),
+	)
+
+	newNode := func(name, zone string) *corev1.Node {
+		nodeLabels := map[string]string{hostnameLabel: name}
+		if zone != "" {
+			nodeLabels[zoneLabel] = zone
+		}
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nodeLabels}}
+	}
+
+	newPod := func(name, app, node string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": app}},
+			Spec:       corev1.PodSpec{NodeName: node},
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newNode("node-a", "zone-a"),
+		newNode("node-b", "zone-b"),
+		newNode("node-c", "zone-c"),
+		newNode("edge", ""),
+		newPod("web-1", "web", "node-a"),
+		newPod("web-2", "web", "node-a"),
+		newPod("web-3", "web", "node-a"),
+		newPod("web-4", "web", "node-b"),
+		newPod("web-5", "web", "edge"),
+		newPod("web-pending", "web", ""),
+		newPod("db-0", "db", "node-a"),
+		newPod("db-1", "db", "node-b"),
+	)
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expected     topologyZonesResponse
+	}{
+		{
+			name:         "spread",
+			query:        "?namespace=default",
+			expectedCode: http.StatusOK,
+			expected: topologyZonesResponse{
+				Workloads: []workloadSpread{
+					{
+						Workload: workloadRef{Kind: "Deployment", Name: "web"},
+						Constraints: []constraintSpread{
+							{
+								TopologyKey:       zoneLabel,
+								MaxSkew:           1,
+								WhenUnsatisfiable: "DoNotSchedule",
+								Domains:           map[string]int{"zone-a": 3, "zone-b": 1, "zone-c": 0},
+								Skew:              3,
+								Exceeded:          true,
+							},
+							{
+								TopologyKey:       hostnameLabel,
+								MaxSkew:           2,
+								WhenUnsatisfiable: "DoNotSchedule",
+								Domains:           map[string]int{"node-a": 3, "node-b": 1, "node-c": 0, "edge": 1},
+								Skew:              3,
+								Exceeded:          true,
+							},
+						},
+						Exceeded: true,
+					},
+					{
+						Workload: workloadRef{Kind: "StatefulSet", Name: "db"},
+						Constraints: []constraintSpread{
+							{
+								TopologyKey:       zoneLabel,
+								MaxSkew:           1,
+								WhenUnsatisfiable: "DoNotSchedule",
+								Domains:           map[string]int{"zone-a": 1, "zone-b": 1, "zone-c": 0},
+								Skew:              1,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:         "namespace required",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newTopologyZonesHandler(kubeClient, dynamicClient, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/topology/zones"+tc.query, nil)
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, tc.expectedCode, resp.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var got topologyZonesResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -0,0 +1,272 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	tracingCacheTTL = 30 * time.Second
+
+	// maxTraces is how many of a service's most recent traces are returned.
+	maxTraces = 20
+
+	// tracingLookback is how far back Jaeger is searched for traces.
+	tracingLookback = "1h"
+
+	jaegerRefChildOf = "CHILD_OF"
+)
+
+// jaegerReference links a Jaeger span to its parent or to spans it follows.
+type jaegerReference struct {
+	RefType string `json:"refType"`
+}
+
+type jaegerTag struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// jaegerSpan is a span in a Jaeger trace. Times are in microseconds.
+type jaegerSpan struct {
+	SpanID        string            `json:"spanID"`
+	OperationName string            `json:"operationName"`
+	References    []jaegerReference `json:"references"`
+	StartTime     int64             `json:"startTime"`
+	Duration      int64             `json:"duration"`
+	Tags          []jaegerTag       `json:"tags"`
+	ProcessID     string            `json:"processID"`
+}
+
+type jaegerProcess struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type jaegerTrace struct {
+	TraceID   string                   `json:"traceID"`
+	Spans     []jaegerSpan             `json:"spans"`
+	Processes map[string]jaegerProcess `json:"processes"`
+}
+
+type jaegerError struct {
+	Msg string `json:"msg"`
+}
+
+// jaegerTracesResponse is the subset of a Jaeger query API trace search
+// response used for trace summaries.
+type jaegerTracesResponse struct {
+	Data   []jaegerTrace `json:"data"`
+	Errors []jaegerError `json:"errors"`
+}
+
+type traceSummary struct {
+	TraceID              string    `json:"traceId"`
+	Duration             string    `json:"duration"`
+	DurationMicroseconds int64     `json:"durationMicroseconds"`
+	Spans                int       `json:"spans"`
+	RootSpan             string    `json:"rootSpan"`
+	ErrorCount           int       `json:"errorCount"`
+	Timestamp            time.Time `json:"timestamp"`
+}
+
+type tracingResponse struct {
+	JaegerNotConfigured bool           `json:"jaegerNotConfigured"`
+	Service             string         `json:"service,omitempty"`
+	Traces              []traceSummary `json:"traces"`
+}
+
+type tracingHandler struct {
+	kubeClient kubernetes.Interface
+	jaegerURL  string
+	httpClient *http.Client
+	cache      *ttlCache
+	logger     log.Logger
+}
+
+var _ http.Handler = (*tracingHandler)(nil)
+
+func newTracingHandler(kubeClient kubernetes.Interface, jaegerURL string, logger log.Logger) *tracingHandler {
+	return &tracingHandler{
+		kubeClient: kubeClient,
+		jaegerURL:  strings.TrimSuffix(jaegerURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		cache:      newTTLCache(tracingCacheTTL),
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the 20 most recent traces
+// from the last hour for a service, most recent first, from the Jaeger query
+// API. The service is the `service` query parameter, or the `app` label of
+// the pod given by `pod`. Traces are cached for 30 seconds.
+func (h *tracingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp := tracingResponse{
+		Traces: []traceSummary{},
+	}
+
+	if h.jaegerURL == "" {
+		resp.JaegerNotConfigured = true
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	namespace := mux.Vars(r)["namespace"]
+	query := r.URL.Query()
+
+	service := query.Get("service")
+	if podName := query.Get("pod"); service == "" && podName != "" {
+		pod, err := h.kubeClient.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+				return
+			}
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+
+		service = pod.Labels["app"]
+		if service == "" {
+			RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("pod %q has no app label", podName), h.logger)
+			return
+		}
+	}
+
+	if service == "" {
+		RespondWithError(w, http.StatusBadRequest, "service or pod is required", h.logger)
+		return
+	}
+
+	resp.Service = service
+
+	if cached, ok := h.cache.get(service); ok {
+		resp.Traces = cached.([]traceSummary)
+		serveAsJSON(w, &resp, h.logger)
+		return
+	}
+
+	traces, err := h.traces(service)
+	if err != nil {
+		RespondWithError(w, http.StatusBadGateway, err.Error(), h.logger)
+		return
+	}
+
+	h.cache.set(service, traces)
+	resp.Traces = traces
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// traces searches Jaeger for a service's recent traces and summarizes them.
+func (h *tracingHandler) traces(service string) ([]traceSummary, error) {
+	query := url.Values{}
+	query.Set("service", service)
+	query.Set("limit", strconv.Itoa(maxTraces))
+	query.Set("lookback", tracingLookback)
+
+	res, err := h.httpClient.Get(fmt.Sprintf("%s/api/traces?%s", h.jaegerURL, query.Encode()))
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch traces from jaeger")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("jaeger returned %s", res.Status)
+	}
+
+	var found jaegerTracesResponse
+	if err := json.NewDecoder(res.Body).Decode(&found); err != nil {
+		return nil, errors.Wrap(err, "decode jaeger traces")
+	}
+
+	if len(found.Errors) > 0 {
+		return nil, errors.Errorf("jaeger returned an error: %s", found.Errors[0].Msg)
+	}
+
+	traces := []traceSummary{}
+	for _, trace := range found.Data {
+		if len(trace.Spans) == 0 {
+			continue
+		}
+
+		traces = append(traces, summarizeJaegerTrace(trace))
+	}
+
+	sort.Slice(traces, func(i, j int) bool {
+		return traces[i].Timestamp.After(traces[j].Timestamp)
+	})
+	if len(traces) > maxTraces {
+		traces = traces[:maxTraces]
+	}
+
+	return traces, nil
+}
+
+// summarizeJaegerTrace summarizes a trace. A trace lasts from the start of its
+// first span to the end of its last, and a span is an error when it has a true
+// `error` tag.
+func summarizeJaegerTrace(trace jaegerTrace) traceSummary {
+	summary := traceSummary{
+		TraceID: trace.TraceID,
+		Spans:   len(trace.Spans),
+	}
+
+	var start, end int64
+	for i, span := range trace.Spans {
+		if i == 0 || span.StartTime < start {
+			start = span.StartTime
+		}
+		if finish := span.StartTime + span.Duration; finish > end {
+			end = finish
+		}
+
+		if summary.RootSpan == "" && !spanHasParent(span) {
+			summary.RootSpan = trace.Processes[span.ProcessID].ServiceName + ": " + span.OperationName
+		}
+
+		for _, tag := range span.Tags {
+			if tag.Key == "error" && (tag.Value == true || tag.Value == "true") {
+				summary.ErrorCount++
+				break
+			}
+		}
+	}
+
+	elapsed := time.Duration(end-start) * time.Microsecond
+	summary.Duration = elapsed.String()
+	summary.DurationMicroseconds = end - start
+	summary.Timestamp = time.Unix(0, start*int64(time.Microsecond)).UTC()
+
+	return summary
+}
+
+// spanHasParent reports whether a span is the child of another span. Root
+// spans have no parent.
+func spanHasParent(span jaegerSpan) bool {
+	for _, reference := range span.References {
+		if reference.RefType == jaegerRefChildOf {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,142 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_tracingHandler(t *testing.T) {
+	requests := 0
+	jaeger := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/api/traces", r.URL.Path)
+		assert.Equal(t, "20", r.URL.Query().Get("limit"))
+
+		switch r.URL.Query().Get("service") {
+		case "failing":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "checkout":
+			fmt.Fprint(w, `{"data":[
+				{"traceID":"older","processes":{"p1":{"serviceName":"checkout"}},"spans":[
+					{"spanID":"a","operationName":"GET /cart","processID":"p1","startTime":1569931200000000,"duration":1500}
+				]},
+				{"traceID":"newer","processes":{"p1":{"serviceName":"checkout"},"p2":{"serviceName":"payments"}},"spans":[
+					{"spanID":"b","operationName":"charge","processID":"p2","startTime":1569931260001000,"duration":3000,
+					 "references":[{"refType":"CHILD_OF","spanID":"c"}],"tags":[{"key":"error","type":"bool","value":true}]},
+					{"spanID":"c","operationName":"POST /checkout","processID":"p1","startTime":1569931260000000,"duration":5000}
+				]}
+			]}`)
+		default:
+			fmt.Fprint(w, `{"data":[]}`)
+		}
+	}))
+	defer jaeger.Close()
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "checkout-1", Namespace: "default", Labels: map[string]string{"app": "checkout"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "unlabelled", Namespace: "default"}},
+	)
+
+	handler := newTracingHandler(kubeClient, jaeger.URL+"/", log.NopLogger())
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expected     tracingResponse
+	}{
+		{
+			name:         "traces for pod",
+			query:        "?pod=checkout-1",
+			expectedCode: http.StatusOK,
+			expected: tracingResponse{
+				Service: "checkout",
+				Traces: []traceSummary{
+					{
+						TraceID:              "newer",
+						Duration:             "5ms",
+						DurationMicroseconds: 5000,
+						Spans:                2,
+						RootSpan:             "checkout: POST /checkout",
+						ErrorCount:           1,
+						Timestamp:            time.Date(2019, 10, 1, 12, 1, 0, 0, time.UTC),
+					},
+					{
+						TraceID:              "older",
+						Duration:             "1.5ms",
+						DurationMicroseconds: 1500,
+						Spans:                1,
+						RootSpan:             "checkout: GET /cart",
+						Timestamp:            time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+		},
+		{
+			name:         "cached traces for service",
+			query:        "?service=checkout",
+			expectedCode: http.StatusOK,
+		},
+		{name: "no traces", query: "?service=cart", expectedCode: http.StatusOK, expected: tracingResponse{Service: "cart", Traces: []traceSummary{}}},
+		{name: "jaeger error", query: "?service=failing", expectedCode: http.StatusBadGateway},
+		{name: "pod without app label", query: "?pod=unlabelled", expectedCode: http.StatusBadRequest},
+		{name: "missing pod", query: "?pod=gone", expectedCode: http.StatusNotFound},
+		{name: "no service", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/tracing"+tc.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode != http.StatusOK || tc.expected.Service == "" {
+				return
+			}
+
+			var resp tracingResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Equal(t, tc.expected, resp)
+		})
+	}
+
+	// The pod and service lookups of checkout share a cache entry.
+	assert.Equal(t, 3, requests)
+
+	t.Run("jaeger not configured", func(t *testing.T) {
+		handler := newTracingHandler(kubeClient, "", log.NopLogger())
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/tracing?service=checkout", nil)
+		req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp tracingResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.True(t, resp.JaegerNotConfigured)
+		assert.Empty(t, resp.Traces)
+	})
+}
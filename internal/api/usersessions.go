@@ -0,0 +1,435 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	// defaultUserSessionTTL is how long a reviewed token is trusted before it
+	// is reviewed again.
+	defaultUserSessionTTL = 15 * time.Minute
+
+	// userSessionSweepInterval is how often expired sessions and revocations
+	// are removed.
+	userSessionSweepInterval = time.Minute
+
+	// failedTokenReviewTTL is how long a token which failed review is
+	// rejected before it is reviewed again.
+	failedTokenReviewTTL = time.Minute
+
+	// maxFailedTokenReviews caps the number of failed reviews remembered.
+	maxFailedTokenReviews = 1000
+
+	bearerPrefix = "Bearer "
+)
+
+// userSession is a user authenticated by a bearer token.
+type userSession struct {
+	User         string    `json:"user"`
+	Groups       []string  `json:"groups"`
+	Expiry       time.Time `json:"expiry"`
+	LastActivity time.Time `json:"lastActivity"`
+}
+
+// userSessionStore tracks the users authenticated by bearer tokens, keyed by
+// a hash of the token. Tokens whose sessions are invalidated are rejected
+// until their sessions would have expired. Tokens which fail review are
+// rejected for a while without being reviewed again.
+type userSessionStore struct {
+	ttl   time.Duration
+	nowFn func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]*userSession
+	revoked  map[string]time.Time
+	failed   *ttlCache
+}
+
+func newUserSessionStore(ttl time.Duration) *userSessionStore {
+	s := &userSessionStore{
+		ttl:      ttl,
+		nowFn:    time.Now,
+		sessions: make(map[string]*userSession),
+		revoked:  make(map[string]time.Time),
+		failed:   newTTLCache(failedTokenReviewTTL),
+	}
+	s.failed.maxEntries = maxFailedTokenReviews
+	s.failed.nowFn = func() time.Time { return s.nowFn() }
+
+	return s
+}
+
+// tokenKey returns the key a token's session is stored under, so tokens
+// aren't kept in memory.
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// authenticate returns the session for a token, creating it with a
+// TokenReview if the token has no session or its session has expired. It
+// returns nil if the token is revoked or not authenticated.
+func (s *userSessionStore) authenticate(kubeClient kubernetes.Interface, token string) (*userSession, error) {
+	now := s.nowFn()
+	key := tokenKey(token)
+
+	if _, ok := s.failed.get(key); ok {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	if expiry, ok := s.revoked[key]; ok && now.Before(expiry) {
+		s.mu.Unlock()
+		return nil, nil
+	}
+	if session, ok := s.sessions[key]; ok && now.Before(session.Expiry) {
+		session.LastActivity = now
+		copied := *session
+		s.mu.Unlock()
+		return &copied, nil
+	}
+	s.mu.Unlock()
+
+	review, err := kubeClient.AuthenticationV1().TokenReviews().Create(&authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "review token")
+	}
+
+	if !review.Status.Authenticated {
+		s.failed.set(key, true)
+		return nil, nil
+	}
+
+	session := &userSession{
+		User:         review.Status.User.Username,
+		Groups:       review.Status.User.Groups,
+		Expiry:       now.Add(s.ttl),
+		LastActivity: now,
+	}
+	if session.Groups == nil {
+		session.Groups = []string{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The token may have been revoked while it was being reviewed.
+	if expiry, ok := s.revoked[key]; ok && now.Before(expiry) {
+		return nil, nil
+	}
+	s.sessions[key] = session
+
+	copied := *session
+	return &copied, nil
+}
+
+// list returns the sessions which have not expired.
+func (s *userSessionStore) list() []userSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.nowFn()
+
+	var sessions []userSession
+	for _, session := range s.sessions {
+		if now.Before(session.Expiry) {
+			sessions = append(sessions, *session)
+		}
+	}
+
+	return sessions
+}
+
+// invalidate revokes the tokens of every session for a user and returns how
+// many sessions were removed.
+func (s *userSessionStore) invalidate(user string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.nowFn()
+
+	count := 0
+	for key, session := range s.sessions {
+		if session.User != user || !now.Before(session.Expiry) {
+			continue
+		}
+		delete(s.sessions, key)
+		s.revoked[key] = session.Expiry
+		count++
+	}
+
+	return count
+}
+
+// sweep removes expired sessions, revocations and failed reviews.
+func (s *userSessionStore) sweep() {
+	s.mu.Lock()
+	now := s.nowFn()
+	for key, session := range s.sessions {
+		if !now.Before(session.Expiry) {
+			delete(s.sessions, key)
+		}
+	}
+	for key, expiry := range s.revoked {
+		if !now.Before(expiry) {
+			delete(s.revoked, key)
+		}
+	}
+	s.mu.Unlock()
+
+	s.failed.sweep()
+}
+
+// sweepEvery sweeps the store each interval until ctx is done.
+func (s *userSessionStore) sweepEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
+
+type userSessionContextKey struct{}
+
+// userSessionFrom returns the session which authenticated a request, or nil
+// if the request carried no token.
+func userSessionFrom(ctx context.Context) *userSession {
+	session, _ := ctx.Value(userSessionContextKey{}).(*userSession)
+	return session
+}
+
+// userSessionAuthentication is a middleware which authenticates requests
+// carrying a bearer token with a TokenReview and records the session.
+// Requests with an invalid or revoked token are rejected. When requireToken
+// is set, requests without a token are rejected too; otherwise they are
+// served unchanged, as when octant runs locally.
+func userSessionAuthentication(sessions *userSessionStore, requireToken bool, kubeClient kubernetes.Interface, logger log.Logger) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization := r.Header.Get("Authorization")
+			if !strings.HasPrefix(authorization, bearerPrefix) {
+				if requireToken {
+					RespondWithError(w, http.StatusUnauthorized, "a bearer token is required", logger)
+					return
+				}
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			token := strings.TrimPrefix(authorization, bearerPrefix)
+
+			session, err := sessions.authenticate(kubeClient, token)
+			if err != nil {
+				RespondWithError(w, http.StatusInternalServerError, err.Error(), logger)
+				return
+			}
+
+			if session == nil {
+				RespondWithError(w, http.StatusUnauthorized, "token is invalid or has been revoked", logger)
+				return
+			}
+
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userSessionContextKey{}, session)))
+		})
+	}
+}
+
+type userSessionsResponse struct {
+	Sessions []userSession `json:"sessions"`
+}
+
+type invalidateUserSessionsResponse struct {
+	User        string `json:"user"`
+	Invalidated int    `json:"invalidated"`
+}
+
+type userSessionsHandler struct {
+	kubeClient kubernetes.Interface
+	sessions   *userSessionStore
+	logger     log.Logger
+}
+
+func newUserSessionsHandler(kubeClient kubernetes.Interface, sessions *userSessionStore, logger log.Logger) *userSessionsHandler {
+	return &userSessionsHandler{
+		kubeClient: kubeClient,
+		sessions:   sessions,
+		logger:     logger,
+	}
+}
+
+// list returns the active sessions of users who are bound to a role in the
+// namespace, either directly, through a group, or as a service account.
+// Sessions are ordered by most recent activity.
+func (h *userSessionsHandler) list(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	roleBindings, err := h.kubeClient.RbacV1().RoleBindings(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := userSessionsResponse{
+		Sessions: []userSession{},
+	}
+
+	for _, session := range h.sessions.list() {
+		if sessionBound(session, namespace, roleBindings.Items) {
+			resp.Sessions = append(resp.Sessions, session)
+		}
+	}
+
+	sort.Slice(resp.Sessions, func(i, j int) bool {
+		if !resp.Sessions[i].LastActivity.Equal(resp.Sessions[j].LastActivity) {
+			return resp.Sessions[i].LastActivity.After(resp.Sessions[j].LastActivity)
+		}
+		return resp.Sessions[i].User < resp.Sessions[j].User
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// invalidate revokes every session token for a user. The requester must be
+// allowed to impersonate the user.
+func (h *userSessionsHandler) invalidate(w http.ResponseWriter, r *http.Request) {
+	user := mux.Vars(r)["user"]
+
+	allowed, err := canInvalidateUserSessions(h.kubeClient, userSessionFrom(r.Context()), user)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+	if !allowed {
+		RespondWithError(w, http.StatusForbidden, fmt.Sprintf("not allowed to invalidate the sessions of user %q", user), h.logger)
+		return
+	}
+
+	count := h.sessions.invalidate(user)
+	if count == 0 {
+		RespondWithError(w, http.StatusNotFound, fmt.Sprintf("user %q has no active sessions", user), h.logger)
+		return
+	}
+
+	h.logger.With("user", user, "sessions", count).Infof("invalidated user sessions")
+
+	resp := invalidateUserSessionsResponse{
+		User:        user,
+		Invalidated: count,
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// canInvalidateUserSessions reports whether the requester may end a user's
+// sessions, which requires permission to impersonate the user.
+func canInvalidateUserSessions(kubeClient kubernetes.Interface, requester *userSession, user string) (bool, error) {
+	allowed, err := reviewAccess(kubeClient, requester, &authorizationv1.ResourceAttributes{
+		Verb:     "impersonate",
+		Resource: "users",
+		Name:     user,
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "review access to invalidate user sessions")
+	}
+
+	return allowed, nil
+}
+
+// requesterName returns the user a request is made by: the session token's
+// user, or the client's own identity when the request has no session.
+func requesterName(r *http.Request, clientUser string) string {
+	if session := userSessionFrom(r.Context()); session != nil {
+		return session.User
+	}
+
+	return clientUser
+}
+
+// reviewAccess asks the cluster whether the requester may act on a resource.
+// A requester authenticated by a session token is reviewed as the token's
+// user with a SubjectAccessReview. Otherwise the client's own identity is
+// reviewed with a SelfSubjectAccessReview.
+func reviewAccess(kubeClient kubernetes.Interface, requester *userSession, attributes *authorizationv1.ResourceAttributes) (bool, error) {
+	if requester == nil {
+		review, err := kubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(&authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: attributes},
+		})
+		if err != nil {
+			return false, err
+		}
+		return review.Status.Allowed, nil
+	}
+
+	review, err := kubeClient.AuthorizationV1().SubjectAccessReviews().Create(&authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: attributes,
+			User:               requester.User,
+			Groups:             requester.Groups,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return review.Status.Allowed, nil
+}
+
+// sessionBound reports whether a session's user is a subject of one of the
+// role bindings.
+func sessionBound(session userSession, namespace string, roleBindings []rbacv1.RoleBinding) bool {
+	for _, roleBinding := range roleBindings {
+		for _, subject := range roleBinding.Subjects {
+			switch subject.Kind {
+			case rbacv1.UserKind:
+				if subject.Name == session.User {
+					return true
+				}
+			case rbacv1.GroupKind:
+				if containsString(session.Groups, subject.Name) {
+					return true
+				}
+			case rbacv1.ServiceAccountKind:
+				subjectNamespace := subject.Namespace
+				if subjectNamespace == "" {
+					subjectNamespace = namespace
+				}
+				if session.User == serviceAccountUserPrefix+subjectNamespace+":"+subject.Name {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
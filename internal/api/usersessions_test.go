@@ -0,0 +1,209 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_userSessions(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	users := map[string]authenticationv1.UserInfo{
+		"alice-token": {Username: "alice"},
+		"bob-token":   {Username: "bob", Groups: []string{"developers"}},
+		"ci-token":    {Username: "system:serviceaccount:default:ci"},
+		"eve-token":   {Username: "eve"},
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(&rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "edit", Namespace: "default"},
+		Subjects: []rbacv1.Subject{
+			{Kind: rbacv1.UserKind, Name: "alice"},
+			{Kind: rbacv1.GroupKind, Name: "developers"},
+			{Kind: rbacv1.ServiceAccountKind, Name: "ci"},
+		},
+	})
+
+	reviews := 0
+	kubeClient.PrependReactor("create", "tokenreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		reviews++
+		review := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if user, ok := users[review.Spec.Token]; ok {
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: user}
+		}
+		return true, review, nil
+	})
+	kubeClient.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = review.Spec.User == "bob" && attributes.Verb == "impersonate" && attributes.Resource == "users"
+		return true, review, nil
+	})
+
+	sessions := newUserSessionStore(time.Hour)
+	sessions.nowFn = func() time.Time { return now }
+
+	router := mux.NewRouter()
+	router.Use(userSessionAuthentication(sessions, false, kubeClient, log.NopLogger()))
+
+	handler := newUserSessionsHandler(kubeClient, sessions, log.NopLogger())
+	router.HandleFunc("/namespaces/{namespace}/usersessions", handler.list).Methods(http.MethodGet)
+	router.HandleFunc("/namespaces/{namespace}/usersessions/{user}", handler.invalidate).Methods(http.MethodDelete)
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/namespaces/default/usersessions", "unknown-token").Code)
+
+	// Failed reviews are remembered for a while.
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/namespaces/default/usersessions", "unknown-token").Code)
+	assert.Equal(t, 1, reviews)
+
+	for _, token := range []string{"alice-token", "eve-token", "ci-token"} {
+		require.Equal(t, http.StatusOK, do(http.MethodGet, "/namespaces/default/usersessions", token).Code)
+	}
+
+	now = now.Add(time.Minute)
+	resp := do(http.MethodGet, "/namespaces/default/usersessions", "bob-token")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got userSessionsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	start := now.Add(-time.Minute)
+	expected := []userSession{
+		{User: "bob", Groups: []string{"developers"}, Expiry: now.Add(time.Hour), LastActivity: now},
+		{User: "alice", Groups: []string{}, Expiry: start.Add(time.Hour), LastActivity: start},
+		{User: "system:serviceaccount:default:ci", Groups: []string{}, Expiry: start.Add(time.Hour), LastActivity: start},
+	}
+	require.Len(t, got.Sessions, len(expected))
+	for i := range expected {
+		assert.Equal(t, expected[i].User, got.Sessions[i].User)
+		assert.Equal(t, expected[i].Groups, got.Sessions[i].Groups)
+		assert.True(t, expected[i].Expiry.Equal(got.Sessions[i].Expiry))
+		assert.True(t, expected[i].LastActivity.Equal(got.Sessions[i].LastActivity))
+	}
+
+	// Cached sessions are not reviewed again.
+	assert.Equal(t, 5, reviews)
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/namespaces/default/usersessions", "alice-token").Code)
+	assert.Equal(t, 5, reviews)
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodDelete, "/namespaces/default/usersessions/alice", "eve-token").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodDelete, "/namespaces/default/usersessions/alice", "bob-token").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/namespaces/default/usersessions/alice", "bob-token").Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/namespaces/default/usersessions", "alice-token").Code)
+
+	// Expired sessions and failed reviews are reviewed again.
+	now = now.Add(2 * time.Hour)
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/namespaces/default/usersessions", "bob-token").Code)
+	assert.Equal(t, 6, reviews)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/namespaces/default/usersessions", "unknown-token").Code)
+	assert.Equal(t, 7, reviews)
+}
+
+func Test_userSessionStore_sweep(t *testing.T) {
+	now := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	kubeClient := kubefake.NewSimpleClientset()
+	kubeClient.PrependReactor("create", "tokenreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token != "unknown-token" {
+			review.Status = authenticationv1.TokenReviewStatus{
+				Authenticated: true,
+				User:          authenticationv1.UserInfo{Username: strings.TrimSuffix(review.Spec.Token, "-token")},
+			}
+		}
+		return true, review, nil
+	})
+
+	sessions := newUserSessionStore(time.Hour)
+	sessions.nowFn = func() time.Time { return now }
+
+	for _, token := range []string{"alice-token", "bob-token", "unknown-token"} {
+		_, err := sessions.authenticate(kubeClient, token)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, sessions.invalidate("alice"))
+
+	// Tokens are stored by hash.
+	assert.Contains(t, sessions.sessions, tokenKey("bob-token"))
+	assert.NotContains(t, sessions.sessions, "bob-token")
+	assert.Contains(t, sessions.revoked, tokenKey("alice-token"))
+
+	sessions.sweep()
+	assert.Len(t, sessions.sessions, 1)
+	assert.Len(t, sessions.revoked, 1)
+	assert.Len(t, sessions.failed.entries, 1)
+
+	now = now.Add(2 * time.Hour)
+	sessions.sweep()
+	assert.Empty(t, sessions.sessions)
+	assert.Empty(t, sessions.revoked)
+	assert.Empty(t, sessions.failed.entries)
+}
+
+func Test_userSessionAuthentication_requireToken(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+
+	tests := []struct {
+		name         string
+		requireToken bool
+		expectedCode int
+	}{
+		{name: "token optional", expectedCode: http.StatusOK},
+		{name: "token required", requireToken: true, expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.Use(userSessionAuthentication(newUserSessionStore(time.Hour), test.requireToken, kubeClient, log.NopLogger()))
+			router.HandleFunc("/namespaces", func(w http.ResponseWriter, r *http.Request) {
+				assert.Nil(t, userSessionFrom(r.Context()))
+			})
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/namespaces", nil))
+			assert.Equal(t, test.expectedCode, resp.Code)
+		})
+	}
+}
+
+func Test_requesterName(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/rollback", nil)
+	assert.Equal(t, "octant", requesterName(req, "octant"))
+
+	req = req.WithContext(context.WithValue(req.Context(), userSessionContextKey{}, &userSession{User: "alice"}))
+	assert.Equal(t, "alice", requesterName(req, "octant"))
+}
@@ -0,0 +1,386 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	defaultValidatingHistoryLimit = 100
+	maxValidatingHistoryLimit     = 1000
+
+	// failedOpenAnnotationPrefix prefixes the audit annotations the API
+	// server adds when a validating webhook call fails and its failure policy
+	// ignores the error. The value is the webhook's name.
+	failedOpenAnnotationPrefix = "failed-open.validating.webhook.admission.k8s.io/"
+
+	validatingHistorySetupGuide = "validating webhook history is read from the Kubernetes audit log. " +
+		"Start the API server with --audit-log-path and an audit policy recording requests at the Metadata level or above, " +
+		"then set OCTANT_AUDIT_LOG_PATH to the audit log's path."
+)
+
+var (
+	webhookDeniedPattern = regexp.MustCompile(`admission webhook "([^"]+)" denied the request(?:: (.*))?`)
+	webhookFailedPattern = regexp.MustCompile(`failed calling webhook "([^"]+)": (.*)`)
+)
+
+// auditVerbOperations maps audit verbs to the admission operations webhooks
+// register for.
+var auditVerbOperations = map[string]admissionregistrationv1beta1.OperationType{
+	"create": admissionregistrationv1beta1.Create,
+	"update": admissionregistrationv1beta1.Update,
+	"patch":  admissionregistrationv1beta1.Update,
+	"delete": admissionregistrationv1beta1.Delete,
+}
+
+type validatingWebhookDecision struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Verb        string    `json:"verb"`
+	Resource    string    `json:"resource"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Name        string    `json:"name"`
+	User        string    `json:"user"`
+	WebhookName string    `json:"webhookName"`
+	Allowed     bool      `json:"allowed"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+type validatingHistoryResponse struct {
+	Decisions []validatingWebhookDecision `json:"decisions"`
+}
+
+type validatingHistoryHandler struct {
+	kubeClient   kubernetes.Interface
+	auditLogPath string
+	logger       log.Logger
+}
+
+var _ http.Handler = (*validatingHistoryHandler)(nil)
+
+func newValidatingHistoryHandler(kubeClient kubernetes.Interface, auditLogPath string, logger log.Logger) *validatingHistoryHandler {
+	return &validatingHistoryHandler{
+		kubeClient:   kubeClient,
+		auditLogPath: auditLogPath,
+		logger:       logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and reconstructs validating webhook
+// decisions from completed create, update, patch and delete requests in the
+// audit log, most recent first. A request is attributed to the cluster's
+// current validating webhooks whose rules and namespace selector match it;
+// object selectors can't be evaluated from the audit log. Webhooks are
+// allowed unless the API server reports that they denied the request or
+// their call failed, and failed calls which were ignored are noted.
+// Requests rejected before validation are skipped. `webhook` and
+// `namespace` filter the decisions and `limit` caps how many are returned.
+func (h *validatingHistoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.auditLogPath == "" {
+		RespondWithError(w, http.StatusNotImplemented, validatingHistorySetupGuide, h.logger)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit := defaultValidatingHistoryLimit
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			RespondWithError(w, http.StatusBadRequest, "limit must be a positive integer", h.logger)
+			return
+		}
+		if n > maxValidatingHistoryLimit {
+			n = maxValidatingHistoryLimit
+		}
+		limit = n
+	}
+
+	configurations, err := h.kubeClient.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	namespaces, err := h.kubeClient.CoreV1().Namespaces().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	namespaceLabels := make(map[string]labels.Set)
+	for _, namespace := range namespaces.Items {
+		namespaceLabels[namespace.Name] = namespace.Labels
+	}
+
+	var webhooks []admissionregistrationv1beta1.ValidatingWebhook
+	for _, configuration := range configurations.Items {
+		webhooks = append(webhooks, configuration.Webhooks...)
+	}
+
+	decisions, err := auditLogWebhookDecisions(h.auditLogPath, webhooks, namespaceLabels)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	resp := validatingHistoryResponse{
+		Decisions: []validatingWebhookDecision{},
+	}
+
+	webhookName := query.Get("webhook")
+	namespace := query.Get("namespace")
+	for _, decision := range decisions {
+		if webhookName != "" && decision.WebhookName != webhookName {
+			continue
+		}
+		if namespace != "" && decision.Namespace != namespace {
+			continue
+		}
+		resp.Decisions = append(resp.Decisions, decision)
+	}
+
+	sort.SliceStable(resp.Decisions, func(i, j int) bool {
+		return resp.Decisions[i].Timestamp.After(resp.Decisions[j].Timestamp)
+	})
+
+	if len(resp.Decisions) > limit {
+		resp.Decisions = resp.Decisions[:limit]
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// auditLogWebhookDecisions reads the validating webhook decisions for each
+// completed request in a JSON lines audit log.
+func auditLogWebhookDecisions(path string, webhooks []admissionregistrationv1beta1.ValidatingWebhook, namespaceLabels map[string]labels.Set) ([]validatingWebhookDecision, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open audit log")
+	}
+	defer f.Close()
+
+	var decisions []validatingWebhookDecision
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxAuditLogLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var event auditEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			continue
+		}
+
+		if event.Stage != "ResponseComplete" || event.ObjectRef == nil {
+			continue
+		}
+
+		operation, ok := auditVerbOperations[event.Verb]
+		if !ok {
+			continue
+		}
+
+		decisions = append(decisions, eventWebhookDecisions(event, operation, webhooks, namespaceLabels)...)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read audit log")
+	}
+
+	return decisions, nil
+}
+
+// eventWebhookDecisions returns the decision of each webhook matching an
+// audited request.
+func eventWebhookDecisions(event auditEvent, operation admissionregistrationv1beta1.OperationType,
+	webhooks []admissionregistrationv1beta1.ValidatingWebhook, namespaceLabels map[string]labels.Set) []validatingWebhookDecision {
+	ref := event.ObjectRef
+
+	resource := ref.Resource
+	if ref.Subresource != "" {
+		resource += "/" + ref.Subresource
+	}
+
+	var message string
+	var code int32
+	if event.ResponseStatus != nil {
+		message = event.ResponseStatus.Message
+		code = event.ResponseStatus.Code
+	}
+
+	// rejectedBy is the webhook which rejected the request, if any.
+	var rejectedBy, rejectedReason string
+	if match := webhookDeniedPattern.FindStringSubmatch(message); match != nil {
+		rejectedBy, rejectedReason = match[1], match[2]
+		if rejectedReason == "" {
+			rejectedReason = "denied the request"
+		}
+	} else if match := webhookFailedPattern.FindStringSubmatch(message); match != nil {
+		rejectedBy, rejectedReason = match[1], "webhook call failed: "+match[2]
+	} else if code >= http.StatusBadRequest {
+		// The request failed for another reason, so validating webhooks may
+		// not have been called.
+		return nil
+	}
+
+	failedOpen := make(map[string]bool)
+	webhookReasons := make(map[string][]string)
+	keys := make([]string, 0, len(event.Annotations))
+	for key := range event.Annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := event.Annotations[key]
+		if strings.HasPrefix(key, failedOpenAnnotationPrefix) {
+			failedOpen[strings.Trim(value, `"`)] = true
+			continue
+		}
+		// Webhooks add audit annotations prefixed with their name.
+		if i := strings.LastIndex(key, "/"); i > 0 {
+			webhookReasons[key[:i]] = append(webhookReasons[key[:i]], key[i+1:]+"="+value)
+		}
+	}
+
+	var decisions []validatingWebhookDecision
+	for _, webhook := range webhooks {
+		if !webhookMatches(webhook, operation, event, namespaceLabels) {
+			continue
+		}
+
+		decision := validatingWebhookDecision{
+			Timestamp:   event.StageTimestamp.Time.UTC(),
+			Verb:        event.Verb,
+			Resource:    resource,
+			Namespace:   ref.Namespace,
+			Name:        ref.Name,
+			User:        event.User.Username,
+			WebhookName: webhook.Name,
+			Allowed:     true,
+		}
+
+		switch {
+		case webhook.Name == rejectedBy:
+			decision.Allowed = false
+			decision.Reason = rejectedReason
+		case failedOpen[webhook.Name]:
+			decision.Reason = "webhook call failed and its failure policy ignores errors"
+		default:
+			decision.Reason = strings.Join(webhookReasons[webhook.Name], ", ")
+		}
+
+		decisions = append(decisions, decision)
+	}
+
+	return decisions
+}
+
+// webhookMatches returns true if one of a webhook's rules and its namespace
+// selector match an audited request.
+func webhookMatches(webhook admissionregistrationv1beta1.ValidatingWebhook, operation admissionregistrationv1beta1.OperationType,
+	event auditEvent, namespaceLabels map[string]labels.Set) bool {
+	ref := event.ObjectRef
+
+	if ref.Namespace != "" && webhook.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(webhook.NamespaceSelector)
+		if err != nil || !selector.Matches(namespaceLabels[ref.Namespace]) {
+			return false
+		}
+	}
+
+	for _, rule := range webhook.Rules {
+		if !matchesOperation(rule.Operations, operation) {
+			continue
+		}
+		if !matchesValue(rule.APIGroups, ref.APIGroup) || !matchesValue(rule.APIVersions, ref.APIVersion) {
+			continue
+		}
+		if !matchesResource(rule.Resources, ref.Resource, ref.Subresource) {
+			continue
+		}
+		if rule.Scope != nil {
+			switch *rule.Scope {
+			case admissionregistrationv1beta1.ClusterScope:
+				if ref.Namespace != "" {
+					continue
+				}
+			case admissionregistrationv1beta1.NamespacedScope:
+				if ref.Namespace == "" {
+					continue
+				}
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func matchesOperation(operations []admissionregistrationv1beta1.OperationType, operation admissionregistrationv1beta1.OperationType) bool {
+	for _, o := range operations {
+		if o == admissionregistrationv1beta1.OperationAll || o == operation {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesResource matches a resource and subresource against webhook rule
+// resources. `pods` matches only the resource, `pods/*` only its
+// subresources, `*` every resource and `*/*` everything.
+func matchesResource(resources []string, resource, subresource string) bool {
+	for _, r := range resources {
+		if r == "*/*" {
+			return true
+		}
+
+		parts := strings.SplitN(r, "/", 2)
+		if parts[0] != "*" && parts[0] != resource {
+			continue
+		}
+
+		if len(parts) == 1 && subresource == "" {
+			return true
+		}
+		if len(parts) == 2 && subresource != "" && (parts[1] == "*" || parts[1] == subresource) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,150 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_validatingHistoryHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validating-history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	auditLog := filepath.Join(dir, "audit.log")
+	lines := []string{
+		`{"stage":"ResponseComplete","verb":"create","user":{"username":"alice"},"objectRef":{"resource":"deployments","namespace":"default","name":"web","apiGroup":"apps","apiVersion":"v1"},"responseStatus":{"code":201},"annotations":{"policy.example.com/check":"passed"},"stageTimestamp":"2019-10-01T11:00:00.000000Z"}`,
+		`{"stage":"ResponseComplete","verb":"patch","user":{"username":"bob"},"objectRef":{"resource":"deployments","namespace":"default","name":"web","apiGroup":"apps","apiVersion":"v1"},"responseStatus":{"code":400,"message":"admission webhook \"policy.example.com\" denied the request: image tag latest is not allowed"},"stageTimestamp":"2019-10-01T11:10:00.000000Z"}`,
+		`{"stage":"ResponseComplete","verb":"delete","user":{"username":"alice"},"objectRef":{"resource":"deployments","namespace":"default","name":"web","apiGroup":"apps","apiVersion":"v1"},"responseStatus":{"code":200},"annotations":{"failed-open.validating.webhook.admission.k8s.io/round_0_index_1":"audit.example.com"},"stageTimestamp":"2019-10-01T11:20:00.000000Z"}`,
+		`{"stage":"ResponseComplete","verb":"create","user":{"username":"alice"},"objectRef":{"resource":"deployments","namespace":"default","name":"api","apiGroup":"apps","apiVersion":"v1"},"responseStatus":{"code":403,"message":"deployments.apps is forbidden"},"stageTimestamp":"2019-10-01T11:30:00.000000Z"}`,
+		`{"stage":"ResponseComplete","verb":"create","user":{"username":"alice"},"objectRef":{"resource":"deployments","namespace":"kube-system","name":"dns","apiGroup":"apps","apiVersion":"v1"},"responseStatus":{"code":201},"stageTimestamp":"2019-10-01T11:40:00.000000Z"}`,
+		`{"stage":"ResponseComplete","verb":"update","user":{"username":"kubelet"},"objectRef":{"resource":"deployments","namespace":"default","name":"web","apiGroup":"apps","apiVersion":"v1","subresource":"status"},"responseStatus":{"code":200},"stageTimestamp":"2019-10-01T11:50:00.000000Z"}`,
+		`{"stage":"RequestReceived","verb":"create","user":{"username":"alice"},"objectRef":{"resource":"deployments","namespace":"default","name":"web","apiGroup":"apps","apiVersion":"v1"},"stageTimestamp":"2019-10-01T11:00:00.000000Z"}`,
+		`{"stage":"ResponseComplete","verb":"get","user":{"username":"alice"},"objectRef":{"resource":"deployments","namespace":"default","name":"web","apiGroup":"apps","apiVersion":"v1"},"stageTimestamp":"2019-10-01T11:00:00.000000Z"}`,
+		`not json`,
+	}
+	require.NoError(t, ioutil.WriteFile(auditLog, []byte(strings.Join(lines, "\n")), 0600))
+
+	deployments := admissionregistrationv1beta1.Rule{
+		APIGroups:   []string{"apps"},
+		APIVersions: []string{"*"},
+		Resources:   []string{"deployments"},
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"policy": "enforced"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		&admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+			Webhooks: []admissionregistrationv1beta1.ValidatingWebhook{
+				{
+					Name: "policy.example.com",
+					Rules: []admissionregistrationv1beta1.RuleWithOperations{
+						{Operations: []admissionregistrationv1beta1.OperationType{admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update}, Rule: deployments},
+					},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"policy": "enforced"}},
+				},
+				{
+					Name: "audit.example.com",
+					Rules: []admissionregistrationv1beta1.RuleWithOperations{
+						{Operations: []admissionregistrationv1beta1.OperationType{admissionregistrationv1beta1.OperationAll}, Rule: deployments},
+					},
+				},
+			},
+		},
+	)
+
+	decision := func(minute int, verb, namespace, name, user, webhook string, allowed bool, reason string) validatingWebhookDecision {
+		return validatingWebhookDecision{
+			Timestamp:   time.Date(2019, 10, 1, 11, minute, 0, 0, time.UTC),
+			Verb:        verb,
+			Resource:    "deployments",
+			Namespace:   namespace,
+			Name:        name,
+			User:        user,
+			WebhookName: webhook,
+			Allowed:     allowed,
+			Reason:      reason,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		auditLogPath string
+		query        string
+		expectCode   int
+		expected     []validatingWebhookDecision
+	}{
+		{
+			name:         "decisions",
+			auditLogPath: auditLog,
+			expectCode:   http.StatusOK,
+			expected: []validatingWebhookDecision{
+				decision(40, "create", "kube-system", "dns", "alice", "audit.example.com", true, ""),
+				decision(20, "delete", "default", "web", "alice", "audit.example.com", true, "webhook call failed and its failure policy ignores errors"),
+				decision(10, "patch", "default", "web", "bob", "policy.example.com", false, "image tag latest is not allowed"),
+				decision(10, "patch", "default", "web", "bob", "audit.example.com", true, ""),
+				decision(0, "create", "default", "web", "alice", "policy.example.com", true, "check=passed"),
+				decision(0, "create", "default", "web", "alice", "audit.example.com", true, ""),
+			},
+		},
+		{
+			name:         "filtered",
+			auditLogPath: auditLog,
+			query:        "?webhook=policy.example.com&limit=1",
+			expectCode:   http.StatusOK,
+			expected: []validatingWebhookDecision{
+				decision(10, "patch", "default", "web", "bob", "policy.example.com", false, "image tag latest is not allowed"),
+			},
+		},
+		{
+			name:         "invalid limit",
+			auditLogPath: auditLog,
+			query:        "?limit=none",
+			expectCode:   http.StatusBadRequest,
+		},
+		{
+			name:       "no audit log",
+			expectCode: http.StatusNotImplemented,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := newValidatingHistoryHandler(kubeClient, test.auditLogPath, log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/clusteradmission/validating-history"+test.query, nil)
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, test.expectCode, resp.Code)
+
+			if test.expectCode != http.StatusOK {
+				return
+			}
+
+			var got validatingHistoryResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, test.expected, got.Decisions)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+var (
+	veleroBackupGVR = schema.GroupVersionResource{
+		Group:    "velero.io",
+		Version:  "v1",
+		Resource: "backups",
+	}
+
+	veleroRestoreGVR = schema.GroupVersionResource{
+		Group:    "velero.io",
+		Version:  "v1",
+		Resource: "restores",
+	}
+)
+
+// veleroStatus is the status Velero reports for backups and restores.
+// Warnings and errors are counts; their details are in the operation's logs.
+type veleroStatus struct {
+	Name                string   `json:"name"`
+	Namespace           string   `json:"namespace"`
+	Phase               string   `json:"phase"`
+	StartTimestamp      string   `json:"startTimestamp,omitempty"`
+	CompletionTimestamp string   `json:"completionTimestamp,omitempty"`
+	IncludedNamespaces  []string `json:"includedNamespaces"`
+	Warnings            int64    `json:"warnings"`
+	Errors              int64    `json:"errors"`
+	ValidationErrors    []string `json:"validationErrors"`
+}
+
+type veleroBackup struct {
+	veleroStatus
+	StorageLocation string `json:"storageLocation"`
+}
+
+type veleroRestore struct {
+	veleroStatus
+	BackupName string `json:"backupName"`
+}
+
+type veleroBackupsResponse struct {
+	VeleroNotInstalled bool           `json:"veleroNotInstalled"`
+	Backups            []veleroBackup `json:"backups"`
+}
+
+type veleroRestoresResponse struct {
+	VeleroNotInstalled bool            `json:"veleroNotInstalled"`
+	Restores           []veleroRestore `json:"restores"`
+}
+
+type veleroHandler struct {
+	dynamicClient dynamic.Interface
+	logger        log.Logger
+}
+
+func newVeleroHandler(dynamicClient dynamic.Interface, logger log.Logger) *veleroHandler {
+	return &veleroHandler{
+		dynamicClient: dynamicClient,
+		logger:        logger,
+	}
+}
+
+// backups returns Velero backups in all namespaces, most recently started
+// first.
+func (h *veleroHandler) backups(w http.ResponseWriter, r *http.Request) {
+	resp := veleroBackupsResponse{
+		Backups: []veleroBackup{},
+	}
+
+	backups, installed, err := listOptionalResource(h.dynamicClient, veleroBackupGVR, "", metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+	resp.VeleroNotInstalled = !installed
+
+	for _, backup := range backups.Items {
+		storageLocation, _, _ := unstructured.NestedString(backup.Object, "spec", "storageLocation")
+
+		resp.Backups = append(resp.Backups, veleroBackup{
+			veleroStatus:    newVeleroStatus(backup),
+			StorageLocation: storageLocation,
+		})
+	}
+
+	sort.SliceStable(resp.Backups, func(i, j int) bool {
+		return resp.Backups[i].StartTimestamp > resp.Backups[j].StartTimestamp
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// restores returns Velero restores in all namespaces, most recently started
+// first.
+func (h *veleroHandler) restores(w http.ResponseWriter, r *http.Request) {
+	resp := veleroRestoresResponse{
+		Restores: []veleroRestore{},
+	}
+
+	restores, installed, err := listOptionalResource(h.dynamicClient, veleroRestoreGVR, "", metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+	resp.VeleroNotInstalled = !installed
+
+	for _, restore := range restores.Items {
+		backupName, _, _ := unstructured.NestedString(restore.Object, "spec", "backupName")
+
+		resp.Restores = append(resp.Restores, veleroRestore{
+			veleroStatus: newVeleroStatus(restore),
+			BackupName:   backupName,
+		})
+	}
+
+	sort.SliceStable(resp.Restores, func(i, j int) bool {
+		return resp.Restores[i].StartTimestamp > resp.Restores[j].StartTimestamp
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// newVeleroStatus reads the fields backups and restores share. Timestamps
+// are RFC 3339 strings, so they sort lexically.
+func newVeleroStatus(object unstructured.Unstructured) veleroStatus {
+	phase, _, _ := unstructured.NestedString(object.Object, "status", "phase")
+	start, _, _ := unstructured.NestedString(object.Object, "status", "startTimestamp")
+	completion, _, _ := unstructured.NestedString(object.Object, "status", "completionTimestamp")
+	warnings, _, _ := unstructured.NestedInt64(object.Object, "status", "warnings")
+	errs, _, _ := unstructured.NestedInt64(object.Object, "status", "errors")
+
+	includedNamespaces, _, _ := unstructured.NestedStringSlice(object.Object, "spec", "includedNamespaces")
+	if includedNamespaces == nil {
+		includedNamespaces = []string{}
+	}
+
+	validationErrors, _, _ := unstructured.NestedStringSlice(object.Object, "status", "validationErrors")
+	if validationErrors == nil {
+		validationErrors = []string{}
+	}
+
+	return veleroStatus{
+		Name:                object.GetName(),
+		Namespace:           object.GetNamespace(),
+		Phase:               phase,
+		StartTimestamp:      start,
+		CompletionTimestamp: completion,
+		IncludedNamespaces:  includedNamespaces,
+		Warnings:            warnings,
+		Errors:              errs,
+		ValidationErrors:    validationErrors,
+	}
+}
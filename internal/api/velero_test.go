@@ -0,0 +1,153 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_veleroHandler(t *testing.T) {
+	newObject := func(kind, name string, spec, status map[string]interface{}) *unstructured.Unstructured {
+		object := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec, "status": status}}
+		object.SetAPIVersion("velero.io/v1")
+		object.SetKind(kind)
+		object.SetNamespace("velero")
+		object.SetName(name)
+		return object
+	}
+
+	objects := []runtime.Object{
+		newObject("Backup", "nightly-1", map[string]interface{}{
+			"storageLocation":    "default",
+			"includedNamespaces": []interface{}{"web"},
+		}, map[string]interface{}{
+			"phase":               "Completed",
+			"startTimestamp":      "2019-10-01T01:00:00Z",
+			"completionTimestamp": "2019-10-01T01:05:00Z",
+		}),
+		newObject("Backup", "nightly-2", map[string]interface{}{
+			"storageLocation": "default",
+		}, map[string]interface{}{
+			"phase":          "PartiallyFailed",
+			"startTimestamp": "2019-10-02T01:00:00Z",
+			"warnings":       int64(2),
+			"errors":         int64(1),
+		}),
+		newObject("Backup", "invalid", map[string]interface{}{
+			"storageLocation": "missing",
+		}, map[string]interface{}{
+			"phase":            "FailedValidation",
+			"validationErrors": []interface{}{"backup storage location 'missing' not found"},
+		}),
+		newObject("Restore", "restore-web", map[string]interface{}{
+			"backupName":         "nightly-1",
+			"includedNamespaces": []interface{}{"web"},
+		}, map[string]interface{}{
+			"phase":               "Completed",
+			"startTimestamp":      "2019-10-03T09:00:00Z",
+			"completionTimestamp": "2019-10-03T09:01:00Z",
+			"warnings":            int64(3),
+		}),
+	}
+
+	t.Run("backups", func(t *testing.T) {
+		handler := newVeleroHandler(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...), log.NopLogger())
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/velero/backups", nil)
+		resp := httptest.NewRecorder()
+		handler.backups(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var got veleroBackupsResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+		expected := veleroBackupsResponse{
+			Backups: []veleroBackup{
+				{
+					veleroStatus: veleroStatus{
+						Name: "nightly-2", Namespace: "velero", Phase: "PartiallyFailed",
+						StartTimestamp:     "2019-10-02T01:00:00Z",
+						IncludedNamespaces: []string{}, Warnings: 2, Errors: 1, ValidationErrors: []string{},
+					},
+					StorageLocation: "default",
+				},
+				{
+					veleroStatus: veleroStatus{
+						Name: "nightly-1", Namespace: "velero", Phase: "Completed",
+						StartTimestamp: "2019-10-01T01:00:00Z", CompletionTimestamp: "2019-10-01T01:05:00Z",
+						IncludedNamespaces: []string{"web"}, ValidationErrors: []string{},
+					},
+					StorageLocation: "default",
+				},
+				{
+					veleroStatus: veleroStatus{
+						Name: "invalid", Namespace: "velero", Phase: "FailedValidation",
+						IncludedNamespaces: []string{},
+						ValidationErrors:   []string{"backup storage location 'missing' not found"},
+					},
+					StorageLocation: "missing",
+				},
+			},
+		}
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("restores", func(t *testing.T) {
+		handler := newVeleroHandler(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...), log.NopLogger())
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/velero/restores", nil)
+		resp := httptest.NewRecorder()
+		handler.restores(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var got veleroRestoresResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+		expected := veleroRestoresResponse{
+			Restores: []veleroRestore{
+				{
+					veleroStatus: veleroStatus{
+						Name: "restore-web", Namespace: "velero", Phase: "Completed",
+						StartTimestamp: "2019-10-03T09:00:00Z", CompletionTimestamp: "2019-10-03T09:01:00Z",
+						IncludedNamespaces: []string{"web"}, Warnings: 3, ValidationErrors: []string{},
+					},
+					BackupName: "nightly-1",
+				},
+			},
+		}
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("velero not installed", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		dynamicClient.PrependReactor("list", "backups", func(action clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+		})
+		handler := newVeleroHandler(dynamicClient, log.NopLogger())
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/velero/backups", nil)
+		resp := httptest.NewRecorder()
+		handler.backups(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var got veleroBackupsResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		assert.Equal(t, veleroBackupsResponse{VeleroNotInstalled: true, Backups: []veleroBackup{}}, got)
+	})
+}
@@ -0,0 +1,394 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+// webhookCallTimeout is how long a webhook call may take when the webhook
+// doesn't set timeoutSeconds, matching the API server's default.
+const webhookCallTimeout = 10 * time.Second
+
+// webhookCaller sends an AdmissionReview to a webhook and returns the
+// webhook's AdmissionReview response. Calls give up after timeout.
+type webhookCaller func(clientConfig admissionregistrationv1beta1.WebhookClientConfig, timeout time.Duration, review []byte) ([]byte, error)
+
+type webhookDryRunStep struct {
+	Webhook   string          `json:"webhook"`
+	Skipped   string          `json:"skipped,omitempty"`
+	Allowed   bool            `json:"allowed"`
+	Message   string          `json:"message,omitempty"`
+	PatchType string          `json:"patchType,omitempty"`
+	Patch     json.RawMessage `json:"patch,omitempty"`
+}
+
+type webhookDryRunResponse struct {
+	Steps  []webhookDryRunStep    `json:"steps"`
+	Object map[string]interface{} `json:"object"`
+}
+
+type webhookDryRunHandler struct {
+	kubeClient kubernetes.Interface
+	call       webhookCaller
+	logger     log.Logger
+}
+
+var _ http.Handler = (*webhookDryRunHandler)(nil)
+
+func newWebhookDryRunHandler(kubeClient kubernetes.Interface, logger log.Logger) *webhookDryRunHandler {
+	return &webhookDryRunHandler{
+		kubeClient: kubeClient,
+		call:       newServiceProxyWebhookCaller(kubeClient),
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and sends the object in the request body
+// through the matching webhooks of the MutatingWebhookConfiguration named by
+// the `webhookConfig` query parameter, as if the object was being created.
+// Patches are applied in order and each step is returned with the final object.
+//
+// Requests are sent with dryRun set, and webhooks which declare side effects
+// on dry run are skipped.
+func (h *webhookDryRunHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	configName := r.URL.Query().Get("webhookConfig")
+	if configName == "" {
+		RespondWithError(w, http.StatusBadRequest, "webhookConfig is required", h.logger)
+		return
+	}
+
+	defer func() {
+		if cErr := r.Body.Close(); cErr != nil {
+			h.logger.WithErr(cErr).Errorf("unable to close dry run request body")
+		}
+	}()
+
+	var object unstructured.Unstructured
+	if err := json.NewDecoder(r.Body).Decode(&object.Object); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
+	gvk := object.GroupVersionKind()
+	if gvk.Kind == "" || gvk.Version == "" {
+		RespondWithError(w, http.StatusBadRequest, "object must have apiVersion and kind", h.logger)
+		return
+	}
+
+	config, err := h.kubeClient.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Get(configName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			RespondWithError(w, http.StatusNotFound, err.Error(), h.logger)
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	// Objects without a namespace are treated as cluster scoped.
+	var namespaceLabels labels.Set
+	if namespace := object.GetNamespace(); namespace != "" {
+		ns, err := h.kubeClient.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+		namespaceLabels = ns.Labels
+	}
+
+	// The resource is guessed from the kind since a REST mapper isn't available here.
+	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+
+	resp := webhookDryRunResponse{
+		Steps: []webhookDryRunStep{},
+	}
+
+	current, err := json.Marshal(object.Object)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	for _, webhook := range config.Webhooks {
+		matches, err := mutatingWebhookMatches(webhook, gvr, object.GetLabels(), namespaceLabels, object.GetNamespace() != "")
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+			return
+		}
+		if !matches {
+			continue
+		}
+
+		step := webhookDryRunStep{Webhook: webhook.Name}
+
+		if !webhookSafeForDryRun(webhook.SideEffects) {
+			step.Skipped = "webhook has side effects on dry run"
+			resp.Steps = append(resp.Steps, step)
+			continue
+		}
+
+		response, err := h.review(webhook, gvk, gvr, &object, current)
+		if err != nil {
+			RespondWithError(w, http.StatusBadGateway, errors.Wrapf(err, "call webhook %s", webhook.Name).Error(), h.logger)
+			return
+		}
+
+		step.Allowed = response.Allowed
+		if response.Result != nil {
+			step.Message = response.Result.Message
+		}
+
+		if len(response.Patch) > 0 {
+			step.Patch = response.Patch
+			if response.PatchType != nil {
+				step.PatchType = string(*response.PatchType)
+			}
+
+			patch, err := jsonpatch.DecodePatch(response.Patch)
+			if err != nil {
+				RespondWithError(w, http.StatusBadGateway, errors.Wrapf(err, "decode patch from webhook %s", webhook.Name).Error(), h.logger)
+				return
+			}
+
+			current, err = patch.Apply(current)
+			if err != nil {
+				RespondWithError(w, http.StatusBadGateway, errors.Wrapf(err, "apply patch from webhook %s", webhook.Name).Error(), h.logger)
+				return
+			}
+		}
+
+		resp.Steps = append(resp.Steps, step)
+
+		if !response.Allowed {
+			break
+		}
+	}
+
+	if err := json.Unmarshal(current, &resp.Object); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func (h *webhookDryRunHandler) review(webhook admissionregistrationv1beta1.MutatingWebhook, gvk schema.GroupVersionKind,
+	gvr schema.GroupVersionResource, object *unstructured.Unstructured, current []byte) (*admissionv1beta1.AdmissionResponse, error) {
+	dryRun := true
+
+	review := admissionv1beta1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: admissionv1beta1.SchemeGroupVersion.String(),
+			Kind:       "AdmissionReview",
+		},
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:       types.UID(uuid.New().String()),
+			Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+			Resource:  metav1.GroupVersionResource{Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource},
+			Name:      object.GetName(),
+			Namespace: object.GetNamespace(),
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: current},
+			DryRun:    &dryRun,
+		},
+	}
+
+	data, err := json.Marshal(review)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := h.call(webhook.ClientConfig, webhookTimeout(webhook), data)
+	if err != nil {
+		return nil, err
+	}
+
+	var result admissionv1beta1.AdmissionReview
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, errors.Wrap(err, "decode admission review")
+	}
+
+	if result.Response == nil {
+		return nil, errors.New("admission review has no response")
+	}
+
+	return result.Response, nil
+}
+
+// mutatingWebhookMatches reports whether a webhook would be called when
+// creating an object.
+func mutatingWebhookMatches(webhook admissionregistrationv1beta1.MutatingWebhook, gvr schema.GroupVersionResource,
+	objectLabels, namespaceLabels labels.Set, namespaced bool) (bool, error) {
+	matched := false
+	for _, rule := range webhook.Rules {
+		if admissionRuleMatches(rule, gvr, namespaced) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false, nil
+	}
+
+	if namespaced && webhook.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(webhook.NamespaceSelector)
+		if err != nil {
+			return false, err
+		}
+		if !selector.Matches(namespaceLabels) {
+			return false, nil
+		}
+	}
+
+	if webhook.ObjectSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(webhook.ObjectSelector)
+		if err != nil {
+			return false, err
+		}
+		if !selector.Matches(objectLabels) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func admissionRuleMatches(rule admissionregistrationv1beta1.RuleWithOperations, gvr schema.GroupVersionResource, namespaced bool) bool {
+	operations := make([]string, 0, len(rule.Operations))
+	for _, operation := range rule.Operations {
+		operations = append(operations, string(operation))
+	}
+
+	if !matchesAny(operations, string(admissionregistrationv1beta1.Create)) ||
+		!matchesAny(rule.APIGroups, gvr.Group) ||
+		!matchesAny(rule.APIVersions, gvr.Version) ||
+		!(matchesAny(rule.Resources, gvr.Resource) || matchesAny(rule.Resources, "*/*")) {
+		return false
+	}
+
+	if rule.Scope != nil {
+		switch *rule.Scope {
+		case admissionregistrationv1beta1.ClusterScope:
+			return !namespaced
+		case admissionregistrationv1beta1.NamespacedScope:
+			return namespaced
+		}
+	}
+
+	return true
+}
+
+func matchesAny(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func webhookSafeForDryRun(sideEffects *admissionregistrationv1beta1.SideEffectClass) bool {
+	if sideEffects == nil {
+		return false
+	}
+
+	return *sideEffects == admissionregistrationv1beta1.SideEffectClassNone ||
+		*sideEffects == admissionregistrationv1beta1.SideEffectClassNoneOnDryRun
+}
+
+// webhookTimeout returns the webhook's timeoutSeconds, or
+// webhookCallTimeout when it isn't set.
+func webhookTimeout(webhook admissionregistrationv1beta1.MutatingWebhook) time.Duration {
+	if webhook.TimeoutSeconds == nil || *webhook.TimeoutSeconds <= 0 {
+		return webhookCallTimeout
+	}
+
+	return time.Duration(*webhook.TimeoutSeconds) * time.Second
+}
+
+// newServiceProxyWebhookCaller creates a webhookCaller which reaches
+// service backed webhooks through the API server's service proxy, since
+// cluster DNS is usually not resolvable from where octant runs.
+func newServiceProxyWebhookCaller(kubeClient kubernetes.Interface) webhookCaller {
+	return func(clientConfig admissionregistrationv1beta1.WebhookClientConfig, timeout time.Duration, review []byte) ([]byte, error) {
+		if service := clientConfig.Service; service != nil {
+			port := int32(443)
+			if service.Port != nil {
+				port = *service.Port
+			}
+
+			path := ""
+			if service.Path != nil {
+				path = *service.Path
+			}
+
+			return kubeClient.CoreV1().RESTClient().Post().
+				Namespace(service.Namespace).
+				Resource("services").
+				Name(fmt.Sprintf("https:%s:%d", service.Name, port)).
+				SubResource("proxy").
+				Suffix(path).
+				SetHeader("Content-Type", "application/json").
+				Body(review).
+				Timeout(timeout).
+				DoRaw()
+		}
+
+		if clientConfig.URL == nil {
+			return nil, errors.New("webhook has no service or url")
+		}
+
+		tlsConfig := &tls.Config{}
+		if len(clientConfig.CABundle) > 0 {
+			pool := x509.NewCertPool()
+			pool.AppendCertsFromPEM(clientConfig.CABundle)
+			tlsConfig.RootCAs = pool
+		}
+
+		client := &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		}
+
+		res, err := client.Post(*clientConfig.URL, "application/json", bytes.NewReader(review))
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			return nil, errors.Errorf("webhook returned %s", res.Status)
+		}
+
+		return ioutil.ReadAll(res.Body)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_webhookDryRunHandler(t *testing.T) {
+	none := admissionregistrationv1beta1.SideEffectClassNone
+	injectTimeout := int32(3)
+	injectURL := "https://inject.example.com"
+	otherURL := "https://other.example.com"
+
+	podRule := admissionregistrationv1beta1.RuleWithOperations{
+		Operations: []admissionregistrationv1beta1.OperationType{admissionregistrationv1beta1.Create},
+		Rule: admissionregistrationv1beta1.Rule{
+			APIGroups:   []string{""},
+			APIVersions: []string{"v1"},
+			Resources:   []string{"pods"},
+		},
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"injection": "enabled"}}},
+		&admissionregistrationv1beta1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "injector"},
+			Webhooks: []admissionregistrationv1beta1.MutatingWebhook{
+				{
+					Name:              "inject.example.com",
+					ClientConfig:      admissionregistrationv1beta1.WebhookClientConfig{URL: &injectURL},
+					Rules:             []admissionregistrationv1beta1.RuleWithOperations{podRule},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"injection": "enabled"}},
+					SideEffects:       &none,
+					TimeoutSeconds:    &injectTimeout,
+				},
+				{
+					Name:         "unsafe.example.com",
+					ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{URL: &otherURL},
+					Rules:        []admissionregistrationv1beta1.RuleWithOperations{podRule},
+				},
+				{
+					Name:           "selected.example.com",
+					ClientConfig:   admissionregistrationv1beta1.WebhookClientConfig{URL: &otherURL},
+					Rules:          []admissionregistrationv1beta1.RuleWithOperations{podRule},
+					ObjectSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "other"}},
+					SideEffects:    &none,
+				},
+			},
+		},
+	)
+
+	handler := newWebhookDryRunHandler(kubeClient, log.NopLogger())
+
+	var calls []string
+	handler.call = func(clientConfig admissionregistrationv1beta1.WebhookClientConfig, timeout time.Duration, data []byte) ([]byte, error) {
+		calls = append(calls, *clientConfig.URL)
+		assert.Equal(t, 3*time.Second, timeout)
+
+		var review admissionv1beta1.AdmissionReview
+		require.NoError(t, json.Unmarshal(data, &review))
+		require.NotNil(t, review.Request.DryRun)
+		assert.True(t, *review.Request.DryRun)
+		assert.Equal(t, "pods", review.Request.Resource.Resource)
+
+		patchType := admissionv1beta1.PatchTypeJSONPatch
+		review.Response = &admissionv1beta1.AdmissionResponse{
+			UID:       review.Request.UID,
+			Allowed:   true,
+			Patch:     []byte(`[{"op":"add","path":"/metadata/labels/injected","value":"true"}]`),
+			PatchType: &patchType,
+		}
+		return json.Marshal(review)
+	}
+
+	body := `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"web","namespace":"default","labels":{"app":"web"}}}`
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/mutatingwebhook/dry-run?webhookConfig=injector", strings.NewReader(body))
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var got webhookDryRunResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	assert.Equal(t, []string{injectURL}, calls)
+	require.Len(t, got.Steps, 2)
+	assert.Equal(t, "inject.example.com", got.Steps[0].Webhook)
+	assert.True(t, got.Steps[0].Allowed)
+	assert.Equal(t, "JSONPatch", got.Steps[0].PatchType)
+	assert.Equal(t, "unsafe.example.com", got.Steps[1].Webhook)
+	assert.NotEmpty(t, got.Steps[1].Skipped)
+
+	metadata := got.Object["metadata"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"app": "web", "injected": "true"}, metadata["labels"])
+}
+
+func Test_webhookDryRunHandler_errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		body         string
+		expectedCode int
+	}{
+		{
+			name:         "missing webhook config",
+			body:         `{"apiVersion":"v1","kind":"Pod"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid object",
+			query:        "?webhookConfig=injector",
+			body:         `{"metadata":{}}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown webhook config",
+			query:        "?webhookConfig=missing",
+			body:         `{"apiVersion":"v1","kind":"Pod"}`,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newWebhookDryRunHandler(kubefake.NewSimpleClientset(), log.NopLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/mutatingwebhook/dry-run"+tc.query, strings.NewReader(tc.body))
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			assert.Equal(t, tc.expectedCode, resp.Code)
+		})
+	}
+}
+
+func Test_newServiceProxyWebhookCaller(t *testing.T) {
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1/namespaces/system/services/https:injector:8443/proxy/mutate", r.URL.Path)
+		assert.Equal(t, "3s", r.URL.Query().Get("timeout"))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer apiServer.Close()
+
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: apiServer.URL})
+	require.NoError(t, err)
+
+	port := int32(8443)
+	path := "/mutate"
+	clientConfig := admissionregistrationv1beta1.WebhookClientConfig{
+		Service: &admissionregistrationv1beta1.ServiceReference{
+			Namespace: "system", Name: "injector", Port: &port, Path: &path,
+		},
+	}
+
+	body, err := newServiceProxyWebhookCaller(kubeClient)(clientConfig, 3*time.Second, []byte(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(body))
+}
+
+func Test_webhookTimeout(t *testing.T) {
+	seconds := int32(30)
+	assert.Equal(t, webhookCallTimeout, webhookTimeout(admissionregistrationv1beta1.MutatingWebhook{}))
+	assert.Equal(t, 30*time.Second, webhookTimeout(admissionregistrationv1beta1.MutatingWebhook{TimeoutSeconds: &seconds}))
+}
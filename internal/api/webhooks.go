@@ -0,0 +1,292 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+const (
+	webhookTypeMutating   = "mutating"
+	webhookTypeValidating = "validating"
+
+	webhookProbeTimeout = 2 * time.Second
+)
+
+// webhookProber reports whether a webhook's server responds.
+type webhookProber func(clientConfig admissionregistrationv1beta1.WebhookClientConfig) bool
+
+type webhookStatus struct {
+	Configuration     string                `json:"configuration"`
+	Name              string                `json:"name"`
+	Type              string                `json:"type"`
+	URL               string                `json:"url"`
+	FailurePolicy     string                `json:"failurePolicy"`
+	SideEffects       string                `json:"sideEffects,omitempty"`
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	Reachable         bool                  `json:"reachable"`
+	// Blocking is true when the server is unreachable and the failure policy
+	// rejects requests, so matching API requests will fail.
+	Blocking bool `json:"blocking"`
+}
+
+type webhooksResponse struct {
+	Webhooks []webhookStatus `json:"webhooks"`
+}
+
+type webhooksHandler struct {
+	kubeClient kubernetes.Interface
+	probe      webhookProber
+	logger     log.Logger
+}
+
+var _ http.Handler = (*webhooksHandler)(nil)
+
+func newWebhooksHandler(kubeClient kubernetes.Interface, logger log.Logger) *webhooksHandler {
+	return &webhooksHandler{
+		kubeClient: kubeClient,
+		probe:      newServiceProxyWebhookProber(kubeClient),
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the cluster's mutating and
+// validating admission webhooks and whether their servers are reachable.
+func (h *webhooksHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	client := h.kubeClient.AdmissionregistrationV1beta1()
+
+	mutating, err := client.MutatingWebhookConfigurations().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	validating, err := client.ValidatingWebhookConfigurations().List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	var clientConfigs []admissionregistrationv1beta1.WebhookClientConfig
+
+	resp := webhooksResponse{
+		Webhooks: []webhookStatus{},
+	}
+
+	for _, configuration := range mutating.Items {
+		for _, webhook := range configuration.Webhooks {
+			resp.Webhooks = append(resp.Webhooks, newWebhookStatus(configuration.Name, webhookTypeMutating,
+				webhook.Name, webhook.ClientConfig, webhook.FailurePolicy, webhook.SideEffects, webhook.NamespaceSelector))
+			clientConfigs = append(clientConfigs, webhook.ClientConfig)
+		}
+	}
+
+	for _, configuration := range validating.Items {
+		for _, webhook := range configuration.Webhooks {
+			resp.Webhooks = append(resp.Webhooks, newWebhookStatus(configuration.Name, webhookTypeValidating,
+				webhook.Name, webhook.ClientConfig, webhook.FailurePolicy, webhook.SideEffects, webhook.NamespaceSelector))
+			clientConfigs = append(clientConfigs, webhook.ClientConfig)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range resp.Webhooks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			webhook := &resp.Webhooks[i]
+			webhook.Reachable = h.probe(clientConfigs[i])
+			webhook.Blocking = !webhook.Reachable &&
+				webhook.FailurePolicy == string(admissionregistrationv1beta1.Fail)
+		}(i)
+	}
+	wg.Wait()
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+func newWebhookStatus(configuration, webhookType, name string,
+	clientConfig admissionregistrationv1beta1.WebhookClientConfig,
+	failurePolicy *admissionregistrationv1beta1.FailurePolicyType,
+	sideEffects *admissionregistrationv1beta1.SideEffectClass,
+	namespaceSelector *metav1.LabelSelector) webhookStatus {
+	// v1beta1 defaults the failure policy to Ignore.
+	status := webhookStatus{
+		Configuration:     configuration,
+		Name:              name,
+		Type:              webhookType,
+		URL:               webhookURL(clientConfig),
+		FailurePolicy:     string(admissionregistrationv1beta1.Ignore),
+		NamespaceSelector: namespaceSelector,
+	}
+
+	if failurePolicy != nil {
+		status.FailurePolicy = string(*failurePolicy)
+	}
+	if sideEffects != nil {
+		status.SideEffects = string(*sideEffects)
+	}
+
+	return status
+}
+
+// webhookURL returns the URL the API server calls for a webhook.
+func webhookURL(clientConfig admissionregistrationv1beta1.WebhookClientConfig) string {
+	if clientConfig.URL != nil {
+		return *clientConfig.URL
+	}
+
+	service := clientConfig.Service
+	if service == nil {
+		return ""
+	}
+
+	port := int32(443)
+	if service.Port != nil {
+		port = *service.Port
+	}
+
+	path := ""
+	if service.Path != nil {
+		path = *service.Path
+	}
+
+	return fmt.Sprintf("https://%s.%s.svc:%d%s", service.Name, service.Namespace, port, path)
+}
+
+// newServiceProxyWebhookProber creates a webhookProber which sends a HEAD
+// request to a webhook server. Like newServiceProxyWebhookCaller, service
+// backed webhooks are reached through the API server's service proxy. They
+// are unreachable when the service is missing or has no ready endpoints, or
+// when the proxy rejects the request or can't reach the service. URL webhooks
+// are probed directly, and any HTTP response means the server is reachable.
+func newServiceProxyWebhookProber(kubeClient kubernetes.Interface) webhookProber {
+	transports := &webhookTransports{}
+
+	return func(clientConfig admissionregistrationv1beta1.WebhookClientConfig) bool {
+		if service := clientConfig.Service; service != nil {
+			if !serviceReady(kubeClient, service.Namespace, service.Name) {
+				return false
+			}
+
+			port := int32(443)
+			if service.Port != nil {
+				port = *service.Port
+			}
+
+			path := ""
+			if service.Path != nil {
+				path = *service.Path
+			}
+
+			statusCode := 0
+			kubeClient.CoreV1().RESTClient().Verb(http.MethodHead).
+				Namespace(service.Namespace).
+				Resource("services").
+				Name(fmt.Sprintf("https:%s:%d", service.Name, port)).
+				SubResource("proxy").
+				Suffix(path).
+				Timeout(webhookProbeTimeout).
+				Do().
+				StatusCode(&statusCode)
+
+			return statusCode != 0 && !isProxyErrorStatus(statusCode)
+		}
+
+		if clientConfig.URL == nil {
+			return false
+		}
+
+		client := &http.Client{
+			Timeout:   webhookProbeTimeout,
+			Transport: transports.get(clientConfig.CABundle),
+		}
+
+		res, err := client.Head(*clientConfig.URL)
+		if err != nil {
+			return false
+		}
+		_ = res.Body.Close()
+
+		return true
+	}
+}
+
+// serviceReady reports whether a service exists and its endpoints have a ready
+// address.
+func serviceReady(kubeClient kubernetes.Interface, namespace, name string) bool {
+	if _, err := kubeClient.CoreV1().Services(namespace).Get(name, metav1.GetOptions{}); err != nil {
+		return false
+	}
+
+	endpoints, err := kubeClient.CoreV1().Endpoints(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return false
+	}
+
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isProxyErrorStatus reports whether a service proxy status code comes from
+// the proxy rather than the service: the proxy refused the request, or
+// couldn't reach the service.
+func isProxyErrorStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusForbidden, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// webhookTransports shares one transport between the probes of webhooks
+// trusting the same CA bundle.
+type webhookTransports struct {
+	mu         sync.Mutex
+	transports map[string]*http.Transport
+}
+
+func (t *webhookTransports) get(caBundle []byte) *http.Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if transport, ok := t.transports[string(caBundle)]; ok {
+		return transport
+	}
+
+	tlsConfig := &tls.Config{}
+	if len(caBundle) > 0 {
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(caBundle)
+		tlsConfig.RootCAs = pool
+	}
+
+	if t.transports == nil {
+		t.transports = make(map[string]*http.Transport)
+	}
+	transport := &http.Transport{TLSClientConfig: tlsConfig}
+	t.transports[string(caBundle)] = transport
+
+	return transport
+}
@@ -0,0 +1,169 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_webhooksHandler(t *testing.T) {
+	fail := admissionregistrationv1beta1.Fail
+	none := admissionregistrationv1beta1.SideEffectClassNone
+	port := int32(8443)
+	path := "/mutate"
+	url := "https://hooks.example.com/validate"
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&admissionregistrationv1beta1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "injector"},
+			Webhooks: []admissionregistrationv1beta1.MutatingWebhook{
+				{
+					Name: "inject.example.com",
+					ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{
+						Service: &admissionregistrationv1beta1.ServiceReference{
+							Namespace: "system", Name: "injector", Port: &port, Path: &path,
+						},
+					},
+					FailurePolicy: &fail,
+					SideEffects:   &none,
+				},
+			},
+		},
+		&admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+			Webhooks: []admissionregistrationv1beta1.ValidatingWebhook{
+				{
+					Name:         "validate.example.com",
+					ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{URL: &url},
+				},
+			},
+		},
+	)
+
+	handler := newWebhooksHandler(kubeClient, log.NopLogger())
+	handler.probe = func(admissionregistrationv1beta1.WebhookClientConfig) bool { return false }
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admission/webhooks", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got webhooksResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := []webhookStatus{
+		{
+			Configuration: "injector",
+			Name:          "inject.example.com",
+			Type:          webhookTypeMutating,
+			URL:           "https://injector.system.svc:8443/mutate",
+			FailurePolicy: "Fail",
+			SideEffects:   "None",
+			Blocking:      true,
+		},
+		{
+			Configuration: "policy",
+			Name:          "validate.example.com",
+			Type:          webhookTypeValidating,
+			URL:           url,
+			FailurePolicy: "Ignore",
+		},
+	}
+	assert.Equal(t, expected, got.Webhooks)
+}
+
+func Test_newServiceProxyWebhookProber(t *testing.T) {
+	ready := []string{"injector", "forbidden", "unavailable"}
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/namespaces/system/"), "/")
+		if len(parts) < 2 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		resource, name := parts[0], parts[1]
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodGet && resource == "services":
+			if name == "deleted" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			assert.NoError(t, json.NewEncoder(w).Encode(&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "system", Name: name}}))
+		case r.Method == http.MethodGet && resource == "endpoints":
+			subset := corev1.EndpointSubset{NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}
+			if containsString(ready, name) {
+				subset = corev1.EndpointSubset{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}
+			}
+			assert.NoError(t, json.NewEncoder(w).Encode(&corev1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "system", Name: name},
+				Subsets:    []corev1.EndpointSubset{subset},
+			}))
+		case r.Method == http.MethodHead && resource == "services":
+			switch name {
+			case "https:injector:8443":
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			case "https:forbidden:8443":
+				w.WriteHeader(http.StatusForbidden)
+			default:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer apiServer.Close()
+
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: apiServer.URL})
+	require.NoError(t, err)
+
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	url := webhookServer.URL
+
+	probe := newServiceProxyWebhookProber(kubeClient)
+
+	port := int32(8443)
+	path := "/mutate"
+	service := func(name string) admissionregistrationv1beta1.WebhookClientConfig {
+		return admissionregistrationv1beta1.WebhookClientConfig{
+			Service: &admissionregistrationv1beta1.ServiceReference{
+				Namespace: "system", Name: name, Port: &port, Path: &path,
+			},
+		}
+	}
+
+	assert.True(t, probe(service("injector")))
+	assert.False(t, probe(service("deleted")), "the service does not exist")
+	assert.False(t, probe(service("unready")), "the service has no ready endpoints")
+	assert.False(t, probe(service("forbidden")), "the service proxy refused the request")
+	assert.False(t, probe(service("unavailable")), "the service proxy could not reach the service")
+
+	assert.True(t, probe(admissionregistrationv1beta1.WebhookClientConfig{URL: &url}))
+
+	webhookServer.Close()
+	assert.False(t, probe(admissionregistrationv1beta1.WebhookClientConfig{URL: &url}))
+	assert.False(t, probe(admissionregistrationv1beta1.WebhookClientConfig{}))
+}
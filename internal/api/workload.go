@@ -0,0 +1,43 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// workloadRef identifies the top level controller of a pod.
+type workloadRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// podWorkload returns the top level controller of a pod. Pods owned by a
+// replica set are attributed to the replica set's deployment if it has one.
+// Pods without a controller are their own workload.
+func podWorkload(pod *corev1.Pod, replicaSets []appsv1.ReplicaSet) workloadRef {
+	controller := metav1.GetControllerOf(pod)
+	if controller == nil {
+		return workloadRef{Kind: "Pod", Name: pod.Name}
+	}
+
+	if controller.Kind == "ReplicaSet" {
+		for i := range replicaSets {
+			replicaSet := &replicaSets[i]
+			if replicaSet.UID != controller.UID {
+				continue
+			}
+
+			if owner := metav1.GetControllerOf(replicaSet); owner != nil {
+				return workloadRef{Kind: owner.Kind, Name: owner.Name}
+			}
+		}
+	}
+
+	return workloadRef{Kind: controller.Kind, Name: controller.Name}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+// cloudIdentityAnnotations are the service account annotations which bind a
+// service account to a cloud IAM identity, by provider.
+var cloudIdentityAnnotations = []struct {
+	provider   string
+	annotation string
+}{
+	{provider: "gke", annotation: "iam.gke.io/gcp-service-account"},
+	{provider: "eks", annotation: "eks.amazonaws.com/role-arn"},
+	{provider: "azure", annotation: "azure.workload.identity/client-id"},
+}
+
+type cloudIdentity struct {
+	Provider string `json:"provider"`
+	Role     string `json:"role"`
+}
+
+type workloadIdentity struct {
+	Kind                 string         `json:"kind"`
+	Workload             string         `json:"workload"`
+	ServiceAccount       string         `json:"serviceAccount"`
+	ServiceAccountExists bool           `json:"serviceAccountExists"`
+	Secrets              []string       `json:"secrets"`
+	ImagePullSecrets     []string       `json:"imagePullSecrets"`
+	CloudIdentity        *cloudIdentity `json:"cloudIdentity"`
+}
+
+type workloadIdentityResponse struct {
+	Workloads []workloadIdentity `json:"workloads"`
+}
+
+type workloadIdentityHandler struct {
+	kubeClient kubernetes.Interface
+	logger     log.Logger
+}
+
+var _ http.Handler = (*workloadIdentityHandler)(nil)
+
+func newWorkloadIdentityHandler(kubeClient kubernetes.Interface, logger log.Logger) *workloadIdentityHandler {
+	return &workloadIdentityHandler{
+		kubeClient: kubeClient,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implements http.Handler and returns the service account each
+// deployment, stateful set, daemon set and job in a namespace runs as. The
+// service account's secrets are listed, as are the image pull secrets of both
+// the service account and the pod template. Service accounts annotated for
+// GKE Workload Identity, EKS IAM roles for service accounts or Azure
+// Workload Identity include the cloud identity they assume.
+func (h *workloadIdentityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+
+	deployments, err := h.kubeClient.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	statefulSets, err := h.kubeClient.AppsV1().StatefulSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	daemonSets, err := h.kubeClient.AppsV1().DaemonSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	jobs, err := h.kubeClient.BatchV1().Jobs(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	serviceAccounts, err := h.kubeClient.CoreV1().ServiceAccounts(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+
+	byName := make(map[string]*corev1.ServiceAccount)
+	for i := range serviceAccounts.Items {
+		byName[serviceAccounts.Items[i].Name] = &serviceAccounts.Items[i]
+	}
+
+	resp := workloadIdentityResponse{
+		Workloads: []workloadIdentity{},
+	}
+
+	add := func(kind, name string, spec corev1.PodSpec) {
+		resp.Workloads = append(resp.Workloads, podSpecIdentity(kind, name, spec, byName))
+	}
+
+	for _, deployment := range deployments.Items {
+		add("Deployment", deployment.Name, deployment.Spec.Template.Spec)
+	}
+	for _, statefulSet := range statefulSets.Items {
+		add("StatefulSet", statefulSet.Name, statefulSet.Spec.Template.Spec)
+	}
+	for _, daemonSet := range daemonSets.Items {
+		add("DaemonSet", daemonSet.Name, daemonSet.Spec.Template.Spec)
+	}
+	for _, job := range jobs.Items {
+		add("Job", job.Name, job.Spec.Template.Spec)
+	}
+
+	sort.Slice(resp.Workloads, func(i, j int) bool {
+		if resp.Workloads[i].Kind != resp.Workloads[j].Kind {
+			return resp.Workloads[i].Kind < resp.Workloads[j].Kind
+		}
+		return resp.Workloads[i].Workload < resp.Workloads[j].Workload
+	})
+
+	serveAsJSON(w, &resp, h.logger)
+}
+
+// podSpecIdentity returns the identity of a workload with a pod template.
+func podSpecIdentity(kind, name string, spec corev1.PodSpec, serviceAccounts map[string]*corev1.ServiceAccount) workloadIdentity {
+	serviceAccountName := spec.ServiceAccountName
+	if serviceAccountName == "" {
+		serviceAccountName = "default"
+	}
+
+	identity := workloadIdentity{
+		Kind:           kind,
+		Workload:       name,
+		ServiceAccount: serviceAccountName,
+		Secrets:        []string{},
+	}
+
+	imagePullSecrets := sets.NewString()
+	for _, ref := range spec.ImagePullSecrets {
+		imagePullSecrets.Insert(ref.Name)
+	}
+
+	if serviceAccount, ok := serviceAccounts[serviceAccountName]; ok {
+		identity.ServiceAccountExists = true
+
+		for _, ref := range serviceAccount.Secrets {
+			identity.Secrets = append(identity.Secrets, ref.Name)
+		}
+		for _, ref := range serviceAccount.ImagePullSecrets {
+			imagePullSecrets.Insert(ref.Name)
+		}
+
+		for _, candidate := range cloudIdentityAnnotations {
+			if role := serviceAccount.Annotations[candidate.annotation]; role != "" {
+				identity.CloudIdentity = &cloudIdentity{Provider: candidate.provider, Role: role}
+				break
+			}
+		}
+	}
+
+	identity.ImagePullSecrets = imagePullSecrets.List()
+
+	return identity
+}
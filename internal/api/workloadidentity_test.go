@@ -0,0 +1,98 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func Test_workloadIdentityHandler(t *testing.T) {
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "default"}
+	}
+
+	template := func(serviceAccount string, pullSecrets ...string) corev1.PodTemplateSpec {
+		spec := corev1.PodSpec{ServiceAccountName: serviceAccount}
+		for _, name := range pullSecrets {
+			spec.ImagePullSecrets = append(spec.ImagePullSecrets, corev1.LocalObjectReference{Name: name})
+		}
+		return corev1.PodTemplateSpec{Spec: spec}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: meta("web"), Spec: appsv1.DeploymentSpec{Template: template("web", "registry")}},
+		&appsv1.StatefulSet{ObjectMeta: meta("db"), Spec: appsv1.StatefulSetSpec{Template: template("db")}},
+		&appsv1.DaemonSet{ObjectMeta: meta("agent"), Spec: appsv1.DaemonSetSpec{Template: template("")}},
+		&batchv1.Job{ObjectMeta: meta("migrate"), Spec: batchv1.JobSpec{Template: template("missing")}},
+		&corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "web",
+				Namespace:   "default",
+				Annotations: map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::123456789012:role/web"},
+			},
+			Secrets:          []corev1.ObjectReference{{Name: "web-token-abc"}},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "mirror"}, {Name: "registry"}},
+		},
+		&corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "db",
+				Namespace:   "default",
+				Annotations: map[string]string{"iam.gke.io/gcp-service-account": "db@project.iam.gserviceaccount.com"},
+			},
+		},
+		&corev1.ServiceAccount{ObjectMeta: meta("default")},
+	)
+
+	handler := newWorkloadIdentityHandler(kubeClient, log.NopLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/workloadidentity", nil)
+	req = mux.SetURLVars(req, map[string]string{"namespace": "default"})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var got workloadIdentityResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	expected := []workloadIdentity{
+		{Kind: "DaemonSet", Workload: "agent", ServiceAccount: "default", ServiceAccountExists: true, Secrets: []string{}, ImagePullSecrets: []string{}},
+		{
+			Kind:                 "Deployment",
+			Workload:             "web",
+			ServiceAccount:       "web",
+			ServiceAccountExists: true,
+			Secrets:              []string{"web-token-abc"},
+			ImagePullSecrets:     []string{"mirror", "registry"},
+			CloudIdentity:        &cloudIdentity{Provider: "eks", Role: "arn:aws:iam::123456789012:role/web"},
+		},
+		{Kind: "Job", Workload: "migrate", ServiceAccount: "missing", Secrets: []string{}, ImagePullSecrets: []string{}},
+		{
+			Kind:                 "StatefulSet",
+			Workload:             "db",
+			ServiceAccount:       "db",
+			ServiceAccountExists: true,
+			Secrets:              []string{},
+			ImagePullSecrets:     []string{},
+			CloudIdentity:        &cloudIdentity{Provider: "gke", Role: "db@project.iam.gserviceaccount.com"},
+		},
+	}
+	assert.Equal(t, expected, got.Workloads)
+}
@@ -33,6 +33,7 @@ func newOctantCmd() *cobra.Command {
 	var klogVerbosity int
 	var clientQPS float32
 	var clientBurst int
+	var maxCopySize int64
 
 	octantCmd := &cobra.Command{
 		Use:   "octant",
@@ -73,6 +74,7 @@ func newOctantCmd() *cobra.Command {
 					Context:          initialContext,
 					ClientQPS:        clientQPS,
 					ClientBurst:      clientBurst,
+					MaxCopySize:      maxCopySize,
 				}
 
 				if klogVerbosity > 0 {
@@ -113,6 +115,7 @@ func newOctantCmd() *cobra.Command {
 	octantCmd.Flags().IntVarP(&klogVerbosity, "klog-verbosity", "", 0, "initial context")
 	octantCmd.Flags().Float32VarP(&clientQPS, "client-qps", "", 200, "maximum QPS for client")
 	octantCmd.Flags().IntVarP(&clientBurst, "client-burst", "", 400, "maximum burst for client throttle")
+	octantCmd.Flags().Int64VarP(&maxCopySize, "max-copy-size", "", 0, "maximum size in bytes of files copied from pods (default 100MiB)")
 
 	kubeConfig = clientcmd.NewDefaultClientConfigLoadingRules().GetDefaultFilename()
 
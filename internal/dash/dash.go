@@ -13,6 +13,8 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -35,6 +37,7 @@ import (
 	"github.com/vmware/octant/internal/modules/overview"
 	"github.com/vmware/octant/internal/objectstore"
 	"github.com/vmware/octant/internal/portforward"
+	"github.com/vmware/octant/internal/telemetry"
 	"github.com/vmware/octant/pkg/action"
 	"github.com/vmware/octant/pkg/plugin"
 	pluginAPI "github.com/vmware/octant/pkg/plugin/api"
@@ -55,6 +58,7 @@ type Options struct {
 	Context          string
 	ClientQPS        float32
 	ClientBurst      int
+	MaxCopySize      int64
 }
 
 // Run runs the dashboard.
@@ -168,7 +172,95 @@ func Run(ctx context.Context, logger log.Logger, shutdownCh chan bool, options O
 	}
 
 	// Initialize the API
-	apiService := api.New(ctx, apiPathPrefix, clusterClient, moduleManager, actionManger, logger)
+	var apiOptions []api.Option
+	if options.MaxCopySize > 0 {
+		apiOptions = append(apiOptions, api.WithMaxCopySize(options.MaxCopySize))
+	}
+
+	if alertmanagerURL := os.Getenv("OCTANT_ALERTMANAGER_URL"); alertmanagerURL != "" {
+		apiOptions = append(apiOptions, api.WithAlertmanagerURL(alertmanagerURL))
+	}
+
+	if trivyURL := os.Getenv("OCTANT_TRIVY_URL"); trivyURL != "" {
+		apiOptions = append(apiOptions, api.WithTrivyURL(trivyURL))
+	}
+
+	if kubecostURL := os.Getenv("OCTANT_KUBECOST_URL"); kubecostURL != "" {
+		apiOptions = append(apiOptions, api.WithKubecostURL(kubecostURL))
+	}
+
+	if jaegerURL := os.Getenv("OCTANT_JAEGER_URL"); jaegerURL != "" {
+		apiOptions = append(apiOptions, api.WithJaegerURL(jaegerURL))
+	}
+
+	if lokiURL := os.Getenv("OCTANT_LOKI_URL"); lokiURL != "" {
+		apiOptions = append(apiOptions, api.WithLokiURL(lokiURL))
+	}
+
+	if prometheusURL := os.Getenv("OCTANT_PROMETHEUS_URL"); prometheusURL != "" {
+		apiOptions = append(apiOptions, api.WithPrometheusURL(prometheusURL))
+	}
+
+	if dependencyLabel := os.Getenv("OCTANT_DEPENDENCY_LABEL"); dependencyLabel != "" {
+		apiOptions = append(apiOptions, api.WithDependencyLabel(dependencyLabel))
+	}
+
+	if quotaSnapshotPath := os.Getenv("OCTANT_QUOTA_SNAPSHOT_PATH"); quotaSnapshotPath != "" {
+		apiOptions = append(apiOptions, api.WithQuotaSnapshotPath(quotaSnapshotPath))
+	}
+
+	if costConfigPath := os.Getenv("OCTANT_COST_CONFIG_PATH"); costConfigPath != "" {
+		apiOptions = append(apiOptions, api.WithCostConfigPath(costConfigPath))
+	}
+
+	if costHistoryPath := os.Getenv("OCTANT_COST_HISTORY_PATH"); costHistoryPath != "" {
+		apiOptions = append(apiOptions, api.WithCostHistoryPath(costHistoryPath))
+	}
+
+	if os.Getenv("OCTANT_REQUIRE_IMAGE_PULL_SECRETS") != "" {
+		apiOptions = append(apiOptions, api.WithRequireImagePullSecrets(true))
+	}
+
+	if concurrency := os.Getenv("OCTANT_RESOURCE_LIST_CONCURRENCY"); concurrency != "" {
+		n, err := strconv.Atoi(concurrency)
+		if err != nil {
+			return errors.Wrap(err, "parse OCTANT_RESOURCE_LIST_CONCURRENCY")
+		}
+		apiOptions = append(apiOptions, api.WithResourceListConcurrency(n))
+	}
+
+	if impersonationTTL := os.Getenv("OCTANT_IMPERSONATION_TTL"); impersonationTTL != "" {
+		ttl, err := time.ParseDuration(impersonationTTL)
+		if err != nil {
+			return errors.Wrap(err, "parse OCTANT_IMPERSONATION_TTL")
+		}
+		apiOptions = append(apiOptions, api.WithImpersonationTTL(ttl))
+	}
+
+	if os.Getenv("OCTANT_REQUIRE_USER_TOKENS") != "" {
+		apiOptions = append(apiOptions, api.WithRequireUserTokens(true))
+	}
+
+	if auditLogPath := os.Getenv("OCTANT_AUDIT_LOG_PATH"); auditLogPath != "" {
+		apiOptions = append(apiOptions, api.WithAuditLogPath(auditLogPath))
+	}
+
+	if os.Getenv("OCTANT_ENABLE_TELEMETRY") != "" {
+		localTelemetry, err := initTelemetry(logger)
+		if err != nil {
+			return errors.Wrap(err, "initializing telemetry")
+		}
+		defer func() {
+			if cErr := localTelemetry.Close(); cErr != nil {
+				logger.WithErr(cErr).Errorf("closing telemetry file")
+			}
+		}()
+
+		logger.With("path", localTelemetry.Path()).Infof("Telemetry is enabled")
+		apiOptions = append(apiOptions, api.WithTelemetry(localTelemetry))
+	}
+
+	apiService := api.New(ctx, apiPathPrefix, clusterClient, moduleManager, actionManger, logger, apiOptions...)
 	for _, m := range moduleManager.Modules() {
 		if err := apiService.RegisterModule(m); err != nil {
 			return errors.Wrapf(err, "registering module: %v", m.Name())
@@ -219,6 +311,21 @@ func initObjectStore(ctx context.Context, client cluster.ClientInterface) (store
 	return appObjectStore, nil
 }
 
+// initTelemetry initializes telemetry written to OCTANT_TELEMETRY_FILE, or
+// to $HOME/.config/octant/telemetry.log if it is not set.
+func initTelemetry(logger log.Logger) (*telemetry.LocalFileTelemetry, error) {
+	path := os.Getenv("OCTANT_TELEMETRY_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errors.Wrap(err, "find home directory")
+		}
+		path = filepath.Join(home, ".config", "octant", "telemetry.log")
+	}
+
+	return telemetry.NewLocalFileTelemetry(path, logger)
+}
+
 func initPortForwarder(ctx context.Context, client cluster.ClientInterface, appObjectStore store.Store) (portforward.PortForwarder, error) {
 	return portforward.Default(ctx, client, appObjectStore)
 }
@@ -63,3 +63,21 @@ type Module interface {
 	// RemoveCRD removes a CRD this module was responsible for.
 	RemoveCRD(ctx context.Context, crd *unstructured.Unstructured) error
 }
+
+// Capabilities describes the features a module offers to the frontend.
+type Capabilities interface {
+	// SupportsSearch returns true if the module's content can be searched.
+	SupportsSearch() bool
+	// SupportsExport returns true if the module's content can be exported.
+	SupportsExport() bool
+	// SupportsEdit returns true if the module's objects can be edited.
+	SupportsEdit() bool
+	// SupportedActions returns the names of actions the module handles.
+	SupportedActions() []string
+}
+
+// CapabilitiesProvider is an optional interface for modules which declare
+// their capabilities.
+type CapabilitiesProvider interface {
+	Capabilities() Capabilities
+}
@@ -0,0 +1,117 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package telemetry
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+// Telemetry records anonymous usage events. Telemetry is opt-in; callers
+// should use NopTelemetry unless the user has enabled it.
+type Telemetry interface {
+	// Track records an event with optional properties.
+	Track(event string, properties map[string]interface{})
+	// Enabled returns true if events are being recorded.
+	Enabled() bool
+}
+
+// NopTelemetry discards all events.
+type NopTelemetry struct{}
+
+var _ Telemetry = (*NopTelemetry)(nil)
+
+// Track does nothing.
+func (NopTelemetry) Track(string, map[string]interface{}) {}
+
+// Enabled returns false.
+func (NopTelemetry) Enabled() bool { return false }
+
+// Event is a recorded telemetry event.
+type Event struct {
+	Timestamp  time.Time              `json:"timestamp"`
+	Event      string                 `json:"event"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// LocalFileTelemetry writes events to a local file as JSON, one event per line.
+// Nothing is sent over the network; the file is intended for maintainers to
+// review when users choose to share it.
+type LocalFileTelemetry struct {
+	path   string
+	logger log.Logger
+	nowFn  func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+var _ Telemetry = (*LocalFileTelemetry)(nil)
+
+// NewLocalFileTelemetry creates an instance of LocalFileTelemetry which appends
+// events to the file at path.
+func NewLocalFileTelemetry(path string, logger log.Logger) (*LocalFileTelemetry, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, errors.Wrap(err, "create telemetry directory")
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "open telemetry file")
+	}
+
+	return &LocalFileTelemetry{
+		path:   path,
+		logger: logger,
+		nowFn:  time.Now,
+		file:   file,
+	}, nil
+}
+
+// Path returns the path of the telemetry file.
+func (t *LocalFileTelemetry) Path() string {
+	return t.path
+}
+
+// Track appends an event to the telemetry file.
+func (t *LocalFileTelemetry) Track(event string, properties map[string]interface{}) {
+	data, err := json.Marshal(&Event{
+		Timestamp:  t.nowFn().UTC(),
+		Event:      event,
+		Properties: properties,
+	})
+	if err != nil {
+		t.logger.WithErr(err).Errorf("encoding telemetry event")
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, err := t.file.Write(append(data, '\n')); err != nil {
+		t.logger.WithErr(err).Errorf("writing telemetry event")
+	}
+}
+
+// Enabled returns true.
+func (t *LocalFileTelemetry) Enabled() bool {
+	return true
+}
+
+// Close closes the telemetry file.
+func (t *LocalFileTelemetry) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.file.Close()
+}
@@ -0,0 +1,64 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package telemetry
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/octant/internal/log"
+)
+
+func TestLocalFileTelemetry_Track(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telemetry")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nested", "telemetry.log")
+
+	lft, err := NewLocalFileTelemetry(path, log.NopLogger())
+	require.NoError(t, err)
+
+	now := time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC)
+	lft.nowFn = func() time.Time { return now }
+
+	lft.Track("api.request", map[string]interface{}{"route": "/namespaces"})
+	lft.Track("api.request", nil)
+	require.NoError(t, lft.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var got []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		got = append(got, e)
+	}
+
+	expected := []Event{
+		{Timestamp: now, Event: "api.request", Properties: map[string]interface{}{"route": "/namespaces"}},
+		{Timestamp: now, Event: "api.request"},
+	}
+	assert.Equal(t, expected, got)
+	assert.True(t, lft.Enabled())
+}
+
+func TestNopTelemetry(t *testing.T) {
+	var nt NopTelemetry
+	nt.Track("event", nil)
+	assert.False(t, nt.Enabled())
+}